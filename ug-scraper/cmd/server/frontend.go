@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+)
+
+// defaultFrontendDir holds a customized or newer SPA build that is served
+// instead of the embedded one
+const defaultFrontendDir = "/data/frontend"
+
+// overlayFS serves files from upper, falling back to lower for the files
+// upper doesn't have
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+// Open implements fs.FS
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if o.upper != nil {
+		f, err := o.upper.Open(name)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return f, err
+		}
+	}
+	if o.lower == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return o.lower.Open(name)
+}
+
+// frontendFiles returns the SPA build to serve: the files in FRONTEND_DIR
+// (default /data/frontend) over the embedded frontend/dist, so a new build
+// can be dropped in without recompiling. Files are read on every request,
+// so replacing them takes effect right away. Returns nil when neither has
+// an index.html.
+func frontendFiles() fs.FS {
+	var files overlayFS
+	if dist, err := fs.Sub(embedFrontend, "frontend/dist"); err == nil {
+		if _, err := fs.Stat(dist, "index.html"); err == nil {
+			files.lower = dist
+		}
+	}
+
+	dir := os.Getenv("FRONTEND_DIR")
+	if dir == "" {
+		dir = defaultFrontendDir
+	}
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		files.upper = os.DirFS(dir)
+		log.Printf("🎨 Serving frontend files from %s ahead of the built-in frontend\n", dir)
+	}
+
+	if _, err := fs.Stat(files, "index.html"); err != nil {
+		return nil
+	}
+	return files
+}
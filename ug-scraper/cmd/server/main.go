@@ -6,11 +6,13 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
-	"os"
+	"path"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/api"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/middleware"
 )
 
@@ -18,9 +20,20 @@ import (
 var embedFrontend embed.FS
 
 func main() {
+	// Settings from the add-on options, the settings file and the
+	// environment, checked before anything starts
+	cfg, err := config.LoadApp()
+	if err != nil {
+		log.Fatalf("❌ Invalid configuration:\n%v", err)
+	}
+	cfg.Export()
+	log.Printf("⚙️  Configuration: %s\n", cfg)
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName: "Ultimate Guitar Scraper v1.0.0",
+		// Archive imports upload whole zips of saved pages
+		BodyLimit: 32 * 1024 * 1024,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -33,49 +46,41 @@ func main() {
 	})
 
 	// Middleware
-	app.Use(middleware.Logger())
+	app.Use(middleware.Logger(cfg.Logging.Level))
 	app.Use(middleware.CORS())
+	app.Use(middleware.SecurityHeaders(cfg.Server.FrameAncestors))
+	app.Use(middleware.RequestContext())
 
-	// Serve embedded frontend first (before API routes so /assets works)
-	if _, err := fs.Stat(embedFrontend, "frontend/dist/index.html"); err == nil {
-		// Frontend is embedded, serve it
-		frontendFS, err := fs.Sub(embedFrontend, "frontend/dist")
-		if err != nil {
-			log.Fatal(err)
-		}
-
+	// Serve the frontend first (before API routes so /assets works)
+	frontend := frontendFiles()
+	if frontend != nil {
 		// Serve static assets (must be before SPA fallback)
 		app.Use("/assets", filesystem.New(filesystem.Config{
-			Root:       http.FS(frontendFS),
+			Root:       http.FS(frontend),
 			PathPrefix: "assets",
 			Browse:     false,
 		}))
-
-		// Serve vite.svg and other root assets
-		app.Get("/vite.svg", func(c *fiber.Ctx) error {
-			data, err := fs.ReadFile(frontendFS, "vite.svg")
-			if err != nil {
-				return c.SendStatus(404)
-			}
-			c.Type("svg")
-			return c.Send(data)
-		})
 	} else {
 		// Frontend not embedded (development mode)
 		log.Println("Frontend not embedded - serve separately with npm run dev")
 	}
 
 	// Setup API routes
-	api.SetupRoutes(app)
+	api.SetupRoutes(app, cfg)
 
 	// SPA fallback - must be LAST (after API and assets)
-	if _, err := fs.Stat(embedFrontend, "frontend/dist/index.html"); err == nil {
-		frontendFS, _ := fs.Sub(embedFrontend, "frontend/dist")
-
-		// Serve index.html for all other routes (SPA fallback)
+	if frontend != nil {
 		app.Use("*", func(c *fiber.Ctx) error {
+			// Files at the root of the build, such as vite.svg or a favicon
+			if name := strings.TrimPrefix(c.Path(), "/"); name != "" && !strings.Contains(name, "/") && fs.ValidPath(name) {
+				if data, err := fs.ReadFile(frontend, name); err == nil {
+					c.Type(path.Ext(name))
+					return c.Send(data)
+				}
+			}
+
 			// Serve index.html for all remaining routes
-			indexHTML, err := fs.ReadFile(frontendFS, "index.html")
+			indexHTML, err := fs.ReadFile(frontend, "index.html")
 			if err != nil {
 				return err
 			}
@@ -84,15 +89,9 @@ func main() {
 		})
 	}
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
 	// Start server
-	log.Printf("🚀 Server starting on port %s\n", port)
-	if err := app.Listen(fmt.Sprintf(":%s", port)); err != nil {
+	log.Printf("🚀 Server starting on port %d\n", cfg.Server.Port)
+	if err := app.Listen(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil {
 		log.Fatal(err)
 	}
 }
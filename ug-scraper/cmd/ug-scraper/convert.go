@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+// newConvertCmd creates the convert command
+func newConvertCmd() *cobra.Command {
+	var (
+		title        string
+		artist       string
+		output       string
+		spelling     string
+		chordStyle   string
+		autoSections bool
+		normalize    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "convert [file]",
+		Short: "Convert a local chord sheet to OnSong format (reads stdin when no file or \"-\" is given)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "-"
+			if len(args) == 1 {
+				path = args[0]
+			}
+
+			content, err := readInput(cmd, path)
+			if err != nil {
+				return err
+			}
+
+			if title == "" {
+				return fmt.Errorf("--title is required")
+			}
+			if spelling == "" {
+				spelling = converter.SpellingFromEnv()
+			}
+			if !converter.ValidSpelling(spelling) {
+				return fmt.Errorf("--spelling must be %s, %s or %s", converter.SpellingAuto, converter.SpellingSharps, converter.SpellingFlats)
+			}
+			style, err := parseChordStyle(chordStyle)
+			if err != nil {
+				return err
+			}
+
+			formatted := converter.NewOnSongConverter().
+				WithSpelling(spelling).
+				WithChordStyle(style).
+				WithAutoSections(autoSections).
+				WithNormalizeSections(normalize).
+				WithKeyStrategy(converter.KeyStrategyFromEnv()).
+				FormatManualContent(title, artist, content)
+			printWarnings(converter.ValidateChords(content))
+			return writeOutput(cmd, output, formatted)
+		},
+	}
+
+	cmd.Flags().StringVar(&title, "title", "", "song title (required)")
+	cmd.Flags().StringVar(&artist, "artist", "Unknown Artist", "song artist")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to a file instead of stdout")
+	cmd.Flags().StringVar(&spelling, "spelling", "", "chord spelling: auto, sharps or flats (default $CHORD_SPELLING or auto)")
+	cmd.Flags().StringVar(&chordStyle, "chord-style", "", "chord qualities: keep, standard, compact, jazz or forms such as M7,min,sus4 (default $CHORD_STYLE or keep)")
+	cmd.Flags().BoolVar(&autoSections, "auto-sections", converter.AutoSectionsFromEnv(), "label the sections of charts without [Verse]/[Chorus] markers (default $AUTO_SECTIONS or true)")
+	cmd.Flags().BoolVar(&normalize, "normalize-sections", converter.NormalizeSectionsFromEnv(), "map localized section headers such as [Estribillo] to English (default $NORMALIZE_SECTIONS or true)")
+
+	return cmd
+}
+
+// printWarnings lists chord problems on stderr, keeping stdout for the chart
+func printWarnings(warnings []converter.ChordWarning) {
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠️  line %d: %s\n", w.Line, w.Message)
+	}
+}
+
+// readInput reads a file, or stdin when path is "-"
+func readInput(cmd *cobra.Command, path string) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if path == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return string(data), nil
+}
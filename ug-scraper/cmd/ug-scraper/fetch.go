@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// newFetchCmd creates the fetch command
+func newFetchCmd() *cobra.Command {
+	var (
+		raw        bool
+		monospace  bool
+		asJSON     bool
+		asPDF      bool
+		output     string
+		conversion conversionFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fetch <tab-id|url>",
+		Short: "Fetch a tab and print it in OnSong format (Guitar Pro tabs are saved as files)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tab, result, err := fetchAndConvert(cmd.Context(), args[0], conversion)
+			if err != nil {
+				return err
+			}
+
+			// Guitar Pro tabs are binary; save the file itself
+			if result.Format == converter.FormatBinary {
+				return downloadTabFile(cmd, fmt.Sprintf("%d", tab.TabID), output)
+			}
+
+			if asPDF {
+				return writeTabPDF(tab, result, output)
+			}
+
+			if asJSON {
+				out := map[string]interface{}{
+					"id":             tab.TabID,
+					"title":          tab.SongName,
+					"artist":         tab.ArtistName,
+					"key":            result.DetectedKey,
+					"shape_key":      result.ShapeKey,
+					"sounding_key":   result.SoundingKey,
+					"key_confidence": result.KeyConfidence,
+					"profile":        result.Profile,
+					"capo":           tab.Capo,
+					"tuning":         tab.Tuning,
+					"type":           tab.Type,
+					"format":         result.Format,
+					"url":            tab.URLWeb,
+					"chords":         result.Chords,
+					"content":        tab.Content,
+					"onsong_format":  result.OnSongFormat,
+					"monospace":      result.Monospace,
+					"diagrams":       result.Diagrams,
+					"applicature":    tab.Applicature,
+					"warnings":       result.Warnings,
+					"strumming":      result.Strumming,
+					"notes":          result.Notes,
+					"bass_lines":     result.BassLines,
+				}
+				// Piano charts leave out the guitar voicings
+				if result.Profile == converter.ProfilePiano {
+					delete(out, "applicature")
+				}
+				return writeJSON(cmd, out)
+			}
+
+			printWarnings(result.Warnings)
+
+			text := result.OnSongFormat
+			if raw {
+				text = tab.Content
+			} else if monospace && result.Monospace != "" {
+				text = result.Monospace
+			}
+
+			return writeOutput(cmd, output, text)
+		},
+	}
+
+	cmd.Flags().BoolVar(&raw, "raw", false, "print the raw Ultimate Guitar content instead of OnSong")
+	cmd.Flags().BoolVar(&monospace, "monospace", false, "print tablature as plain monospace text instead of OnSong")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the tab and conversion as JSON")
+	cmd.Flags().BoolVar(&asPDF, "pdf", false, "save tablature as a PDF with tab blocks on a monospace grid")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to a file instead of stdout")
+	conversion.register(cmd)
+
+	return cmd
+}
+
+// conversionFlags are the conversion options of the fetch and send commands
+type conversionFlags struct {
+	key          string
+	keyHeader    string
+	spelling     string
+	chordStyle   string
+	autoSections bool
+	normalize    bool
+	profile      string
+	bassHints    bool
+	bassLines    bool
+	removeCapo   bool
+}
+
+// register adds the conversion flags to cmd
+func (f *conversionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.key, "key", "", "key of the chords as written, overriding Ultimate Guitar's or the detected key, e.g. G or F#m")
+	cmd.Flags().StringVar(&f.keyHeader, "key-header", "", "key written into the Key: header with a capo: shape or sounding (default $KEY_HEADER or shape)")
+	cmd.Flags().StringVar(&f.spelling, "spelling", "", "chord spelling: auto, sharps or flats (default $CHORD_SPELLING or auto)")
+	cmd.Flags().StringVar(&f.chordStyle, "chord-style", "", "chord qualities: keep, standard, compact, jazz or forms such as M7,min,sus4 (default $CHORD_STYLE or keep)")
+	cmd.Flags().BoolVar(&f.autoSections, "auto-sections", converter.AutoSectionsFromEnv(), "label the sections of charts without [Verse]/[Chorus] markers (default $AUTO_SECTIONS or true)")
+	cmd.Flags().BoolVar(&f.normalize, "normalize-sections", converter.NormalizeSectionsFromEnv(), "map localized section headers such as [Estribillo] to English (default $NORMALIZE_SECTIONS or true)")
+	cmd.Flags().StringVar(&f.profile, "profile", "", "conversion profile: guitar or piano (default $CONVERSION_PROFILE or guitar)")
+	cmd.Flags().BoolVar(&f.bassHints, "bass-hints", converter.BassHintsFromEnv(), "add left-hand bass notes above the chord lines of piano charts (default $PIANO_BASS_HINTS or false)")
+	cmd.Flags().BoolVar(&f.bassLines, "bass-lines", false, "suggest a bass line for each section of chord charts")
+	cmd.Flags().BoolVar(&f.removeCapo, "remove-capo", converter.RemoveCapoFromEnv(), "move capo chord charts up to sounding pitch and drop the Capo: line (default $REMOVE_CAPO or false)")
+}
+
+// parseTabID accepts a numeric tab ID or any UG tab URL or slug; shortened
+// links and slugs without an ID are resolved online
+func parseTabID(ctx context.Context, arg string) (string, error) {
+	ref, err := scraper.ParseTabRef(arg)
+	if err != nil {
+		return "", fmt.Errorf("%q: %w", arg, err)
+	}
+	if !ref.NeedsLookup() {
+		return ref.TabID, nil
+	}
+
+	resolution, err := scraper.NewResolver(scraper.NewUGClient(), scraper.NewSearchScraper()).Resolve(ctx, arg, false)
+	if err != nil {
+		return "", fmt.Errorf("resolving %q: %w", arg, err)
+	}
+	return resolution.TabID, nil
+}
+
+// parseChordStyle reads a --chord-style flag, falling back to $CHORD_STYLE
+func parseChordStyle(flag string) (converter.ChordStyle, error) {
+	if flag == "" {
+		flag = os.Getenv("CHORD_STYLE")
+	}
+	if flag == "" {
+		return converter.ChordStyle{}, nil
+	}
+	return converter.ParseChordStyle(flag)
+}
+
+// fetchAndConvert fetches a tab by ID or URL and converts it to OnSong format.
+// Unset key header, spelling, chord style and profile flags fall back to
+// $KEY_HEADER, $CHORD_SPELLING, $CHORD_STYLE and $CONVERSION_PROFILE.
+func fetchAndConvert(ctx context.Context, arg string, flags conversionFlags) (*scraper.TabResult, *converter.ConversionResult, error) {
+	tabID, err := parseTabID(ctx, arg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyHeader, spelling, profile := flags.keyHeader, flags.spelling, flags.profile
+	if keyHeader == "" {
+		keyHeader = os.Getenv("KEY_HEADER")
+	}
+	if keyHeader != "" && !converter.ValidKeyHeader(keyHeader) {
+		return nil, nil, fmt.Errorf("key header must be %s or %s", converter.KeyHeaderShape, converter.KeyHeaderSounding)
+	}
+	if spelling == "" {
+		spelling = converter.SpellingFromEnv()
+	}
+	if !converter.ValidSpelling(spelling) {
+		return nil, nil, fmt.Errorf("spelling must be %s, %s or %s", converter.SpellingAuto, converter.SpellingSharps, converter.SpellingFlats)
+	}
+	if _, ok := converter.ParseKey(flags.key); flags.key != "" && !ok {
+		return nil, nil, fmt.Errorf("key %q is not a key such as G, F#m or Bb", flags.key)
+	}
+	style, err := parseChordStyle(flags.chordStyle)
+	if err != nil {
+		return nil, nil, err
+	}
+	if profile == "" {
+		profile = converter.ProfileFromEnv()
+	}
+	if !converter.ValidProfile(profile) {
+		return nil, nil, fmt.Errorf("profile must be %s or %s", converter.ProfileGuitar, converter.ProfilePiano)
+	}
+
+	tab, err := scraper.NewUGClient().GetTabByID(ctx, tabID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch tab: %w", err)
+	}
+
+	conv := converter.NewOnSongConverter().
+		WithKey(flags.key).
+		WithKeyHeader(keyHeader).
+		WithSpelling(spelling).
+		WithChordStyle(style).
+		WithAutoSections(flags.autoSections).
+		WithNormalizeSections(flags.normalize).
+		WithProfile(profile).
+		WithBassHints(flags.bassHints).
+		WithBassLines(flags.bassLines).
+		WithRemoveCapo(flags.removeCapo).
+		WithKeyStrategy(converter.KeyStrategyFromEnv())
+	if err := conv.ValidateTab(tab); err != nil {
+		return nil, nil, fmt.Errorf("invalid tab data: %w", err)
+	}
+
+	result, err := conv.ConvertByType(tab)
+	if err != nil {
+		return nil, nil, fmt.Errorf("conversion failed: %w", err)
+	}
+
+	return tab, result, nil
+}
+
+// writeOutput writes text to path, or to the command output when path is empty
+func writeOutput(cmd *cobra.Command, path, text string) error {
+	if path == "" {
+		_, err := fmt.Fprint(cmd.OutOrStdout(), text)
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Saved to %s\n", path)
+	return nil
+}
+
+// writeTabPDF saves a tablature tab as a PDF to path, or to "Artist - Song.pdf"
+// when path is empty
+func writeTabPDF(tab *scraper.TabResult, result *converter.ConversionResult, path string) error {
+	if result.Format != converter.FormatTab {
+		return fmt.Errorf("PDF export is only available for tablature, not %s tabs", tab.Type)
+	}
+
+	if path == "" {
+		path = export.SanitizeFilename(fmt.Sprintf("%s - %s.pdf", tab.ArtistName, tab.SongName))
+	}
+
+	pdf := export.TabPDF(export.TabSheet{
+		Title:  tab.SongName,
+		Artist: tab.ArtistName,
+		Tuning: tab.Tuning,
+		Capo:   tab.Capo,
+		Key:    result.DetectedKey,
+		Blocks: result.Blocks,
+	}, 0)
+	if err := os.WriteFile(path, pdf, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Saved to %s\n", path)
+	return nil
+}
+
+// downloadTabFile saves a Guitar Pro tab's file to path, or under the name UG
+// gives it when path is empty
+func downloadTabFile(cmd *cobra.Command, tabID, path string) error {
+	file, err := scraper.NewUGClient().DownloadTabFile(cmd.Context(), tabID)
+	if err != nil {
+		return fmt.Errorf("failed to download tab file: %w", err)
+	}
+
+	if path == "" {
+		path = export.SanitizeFilename(file.Filename)
+	}
+
+	if err := os.WriteFile(path, file.Data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), path)
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/files"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/fsck"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// newFsckCmd creates the fsck command
+func newFsckCmd() *cobra.Command {
+	var (
+		file    string
+		exports string
+		repair  bool
+		asJSON  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check the library and saved exports for damage, e.g. after an unclean shutdown",
+		Long: "Check the library file, setlist references, revisions and the export\n" +
+			"directory. With --repair the repairable issues are fixed. Stop the add-on\n" +
+			"first, or use POST /api/admin/fsck while it runs. Exits non-zero when\n" +
+			"issues are left.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store := library.NewStore(file)
+			exportFiles := files.NewStore(files.Config{Dir: exports})
+
+			report := fsck.Run(repair,
+				fsck.Check{Name: fsck.AreaLibrary, Run: store.Check},
+				fsck.Check{Name: fsck.AreaFiles, Run: exportFiles.Check},
+			)
+
+			out := cmd.OutOrStdout()
+			if asJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				for _, issue := range report.Issues {
+					status := "found"
+					switch {
+					case issue.Repaired:
+						status = "repaired"
+					case !issue.Repairable:
+						status = "manual"
+					}
+					fmt.Fprintf(out, "%-8s %s/%s %s: %s\n", status, issue.Area, issue.Kind, issue.ID, issue.Message)
+				}
+				for _, msg := range report.Errors {
+					fmt.Fprintf(out, "error    %s\n", msg)
+				}
+				fmt.Fprintf(out, "%d issues found, %d repaired\n", report.Found, report.Repaired)
+			}
+
+			if report.Clean() {
+				return nil
+			}
+			for _, issue := range report.Issues {
+				if issue.Repairable && !issue.Repaired {
+					return fmt.Errorf("integrity check found issues; run with --repair to fix the repairable ones")
+				}
+			}
+			return fmt.Errorf("integrity check left issues to fix by hand")
+		},
+	}
+
+	defaultFile := os.Getenv("LIBRARY_FILE")
+	if defaultFile == "" {
+		defaultFile = "/data/library.json"
+	}
+	defaultExports := os.Getenv("EXPORTS_DIR")
+	if defaultExports == "" {
+		defaultExports = "/data/exports"
+	}
+	cmd.Flags().StringVar(&file, "file", defaultFile, "library file (default $LIBRARY_FILE or /data/library.json)")
+	cmd.Flags().StringVar(&exports, "exports", defaultExports, "export directory (default $EXPORTS_DIR or /data/exports)")
+	cmd.Flags().BoolVar(&repair, "repair", false, "fix the repairable issues")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the report as JSON")
+
+	return cmd
+}
@@ -0,0 +1,53 @@
+// Command ug-scraper is a standalone CLI for searching, fetching, converting
+// and sending Ultimate Guitar tabs without running the web server.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	// The scraper and converter packages log progress with fmt.Printf. Route
+	// that to stderr so stdout carries only command output and stays pipeable.
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+
+	root := newRootCmd()
+	root.SetOut(stdout)
+
+	// Ctrl-C cancels the UG, FlareSolverr and webhook requests in flight
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := root.ExecuteContext(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newRootCmd builds the command tree
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "ug-scraper",
+		Short:         "Search Ultimate Guitar and convert tabs to OnSong format",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	root.AddCommand(
+		newSearchCmd(),
+		newFetchCmd(),
+		newConvertCmd(),
+		newSendCmd(),
+		newMigrateCmd(),
+		newFsckCmd(),
+	)
+
+	return root
+}
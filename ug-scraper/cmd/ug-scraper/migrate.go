@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/migrate"
+)
+
+// newMigrateCmd creates the migrate command
+func newMigrateCmd() *cobra.Command {
+	var (
+		file string
+		to   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade or roll back the schema of a library file (the server upgrades it by itself at startup)",
+		Long: "Upgrade or roll back the schema of a library file. Roll back before\n" +
+			"installing an older add-on version: --to takes the schema version that\n" +
+			"version expects. The file is backed up next to itself before it changes.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := migrate.File(file, library.Migrations(), to)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if len(result.Applied) == 0 {
+				fmt.Fprintf(out, "%s is at schema v%d, nothing to do\n", file, result.From)
+				return nil
+			}
+			for _, name := range result.Applied {
+				fmt.Fprintf(out, "applied %s\n", name)
+			}
+			fmt.Fprintf(out, "%s migrated from schema v%d to v%d (backup: %s)\n", file, result.From, result.To, result.Backup)
+			return nil
+		},
+	}
+
+	defaultFile := os.Getenv("LIBRARY_FILE")
+	if defaultFile == "" {
+		defaultFile = "/data/library.json"
+	}
+	cmd.Flags().StringVar(&file, "file", defaultFile, "library file (default $LIBRARY_FILE or /data/library.json)")
+	cmd.Flags().IntVar(&to, "to", -1, fmt.Sprintf("schema version to migrate to (default latest, v%d)", library.SchemaVersion))
+
+	return cmd
+}
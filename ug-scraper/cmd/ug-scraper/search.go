@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// newSearchCmd creates the search command
+func newSearchCmd() *cobra.Command {
+	var (
+		tabType        string
+		difficulty     string
+		minRating      float64
+		minVotes       int
+		standardTuning bool
+		part           string
+		filter         string
+		sortOrder      string
+		asJSON         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search Ultimate Guitar for tabs",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			types, bad, ok := scraper.ParseTabTypes(tabType)
+			if !ok {
+				return fmt.Errorf("unknown tab type %q (accepted: %s)", bad, scraper.TabTypeList())
+			}
+
+			var level string
+			if difficulty != "" {
+				var ok bool
+				if level, ok = scraper.ParseDifficulty(difficulty); !ok {
+					return fmt.Errorf("unknown difficulty %q (accepted: %s)", difficulty, scraper.DifficultyList())
+				}
+			}
+
+			pick, ok := scraper.ParseResultFilter(filter)
+			if !ok {
+				return fmt.Errorf("unknown filter %q (accepted: %s)", filter, scraper.ResultFilterList())
+			}
+
+			order, ok := scraper.ParseSort(sortOrder)
+			if !ok {
+				return fmt.Errorf("unknown sort %q (accepted: %s)", sortOrder, scraper.SortList())
+			}
+
+			results, err := scraper.NewSearchScraper().SearchTabs(cmd.Context(), scraper.SearchOptions{
+				Query:          strings.Join(args, " "),
+				Types:          types,
+				Difficulty:     level,
+				MinRating:      minRating,
+				MinVotes:       minVotes,
+				StandardTuning: standardTuning,
+				Part:           part,
+				Filter:         pick,
+				Sort:           order,
+			})
+			if err != nil {
+				return fmt.Errorf("search failed: %w", err)
+			}
+
+			if asJSON {
+				return writeJSON(cmd, results)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tARTIST\tTITLE\tTYPE\tRATING\tVOTES")
+			for _, r := range results {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.1f\t%d\n", r.ID, r.Artist, r.Title, r.Type, r.Rating, r.Votes)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&tabType, "type", "t", "", "tab type filter, comma-separated for several: "+scraper.TabTypeList())
+	cmd.Flags().StringVarP(&difficulty, "difficulty", "d", "", "difficulty filter: "+scraper.DifficultyList())
+	cmd.Flags().Float64Var(&minRating, "min-rating", 0, "only tabs rated at least this (0-5)")
+	cmd.Flags().IntVar(&minVotes, "min-votes", 0, "only tabs with at least this many votes")
+	cmd.Flags().BoolVar(&standardTuning, "standard-tuning", false, "only tabs in standard tuning")
+	cmd.Flags().StringVar(&part, "part", "", "only tabs of this part (intro, solo, ...; "+scraper.PartWhole+" for the whole song)")
+	cmd.Flags().StringVar(&filter, "filter", scraper.FilterTop, "results to keep: "+scraper.ResultFilterList()+" (top is one per artist, none is every result)")
+	cmd.Flags().StringVar(&sortOrder, "sort", scraper.SortRelevance, "result order: "+scraper.SortList())
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print results as JSON")
+
+	return cmd
+}
+
+// writeJSON prints v as indented JSON to the command output
+func writeJSON(cmd *cobra.Command, v interface{}) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
+)
+
+// newSendCmd creates the send command
+func newSendCmd() *cobra.Command {
+	var (
+		webhookURL string
+		configFile string
+		headers    []string
+		schema     int
+		bundle     bool
+		conversion conversionFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send <tab-id|url>",
+		Short: "Fetch a tab, convert it and deliver it to a webhook",
+		Long: "Fetch a tab, convert it and deliver it to a webhook.\n\n" +
+			"The webhook URL and headers default to the web server's saved configuration " +
+			"(CONFIG_FILE, /data/webhook-config.json) unless --webhook is given.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := resolveTarget(webhookURL, configFile, headers)
+			if err != nil {
+				return err
+			}
+			if !webhook.ValidSchema(schema) {
+				return fmt.Errorf("--schema must be between 1 and %d", webhook.LatestSchema)
+			}
+			if schema > 0 {
+				target.SchemaVersion = schema
+			}
+			if bundle {
+				target.Bundle = true
+			}
+
+			tab, result, err := fetchAndConvert(cmd.Context(), args[0], conversion)
+			if err != nil {
+				return err
+			}
+			if result.Format == converter.FormatBinary {
+				return fmt.Errorf("Guitar Pro tabs are binary files; use fetch to download them")
+			}
+
+			// Piano charts are at sounding pitch, with no capo
+			capo := tab.Capo
+			if result.Profile == converter.ProfilePiano {
+				capo = 0
+			}
+
+			payload := &webhook.WebhookPayload{
+				Title:        tab.SongName,
+				Artist:       tab.ArtistName,
+				Key:          result.DetectedKey,
+				Capo:         capo,
+				OnSongFormat: result.OnSongFormat,
+				Timestamp:    time.Now(),
+				Source:       "Ultimate Guitar Scraper",
+			}
+
+			delivery, err := webhook.NewClient().SendWithRetry(cmd.Context(), target, payload)
+			if err != nil {
+				return fmt.Errorf("webhook delivery failed: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ Sent %s - %s (delivery %s, %d attempts)\n",
+				tab.ArtistName, tab.SongName, delivery.DeliveryID, delivery.Attempts)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&webhookURL, "webhook", "w", "", "webhook URL (overrides the saved configuration)")
+	cmd.Flags().StringVar(&configFile, "config", "", "webhook config file (default $CONFIG_FILE or /data/webhook-config.json)")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "extra header as \"Name: value\" (repeatable)")
+	cmd.Flags().BoolVar(&bundle, "bundle", false, "include the chart AST and the OnSong, ChordPro and plain text renderings")
+	cmd.Flags().IntVar(&schema, "schema", 0, fmt.Sprintf("payload schema version, 1-%d (default: the saved configuration's, else 1)", webhook.LatestSchema))
+	conversion.register(cmd)
+
+	return cmd
+}
+
+// resolveTarget builds the webhook target from flags, falling back to the saved config
+func resolveTarget(webhookURL, configFile string, headers []string) (webhook.Target, error) {
+	target := webhook.Target{URL: webhookURL, Headers: map[string]string{}}
+
+	if target.URL == "" {
+		if configFile == "" {
+			configFile = os.Getenv("CONFIG_FILE")
+		}
+		if configFile == "" {
+			configFile = "/data/webhook-config.json"
+		}
+
+		store := config.NewConfigStore(configFile)
+		target.URL = store.GetURL()
+		target.SchemaVersion = store.GetSchemaVersion()
+		target.Bundle = store.IncludesBundle()
+		for name, value := range store.GetHeaders() {
+			target.Headers[name] = value
+		}
+	}
+
+	if target.URL == "" {
+		return target, fmt.Errorf("no webhook configured: pass --webhook or save one in %s", configFile)
+	}
+
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return target, fmt.Errorf("invalid header %q, expected \"Name: value\"", h)
+		}
+		target.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return target, nil
+}
@@ -8,7 +8,8 @@ import SearchResults from './components/SearchResults';
 import PreviewPane from './components/PreviewPane';
 import ManualEntry from './components/ManualEntry';
 import WebhookConfig from './components/WebhookConfig';
-import { searchTabs, fetchTab, getWebhookConfig, getOnSongCloudConfig } from './services/api';
+import LoginDialog from './components/LoginDialog';
+import { searchTabs, fetchTab, getWebhookConfig, getOnSongCloudConfig, getDropboxConfig, getSession, getCapabilities } from './services/api';
 import type { SearchResult, Tab } from './services/api';
 
 function App() {
@@ -21,13 +22,50 @@ function App() {
   const [settingsOpen, setSettingsOpen] = useState(false);
   const [webhookConfigured, setWebhookConfigured] = useState(false);
   const [onsongCloudConfigured, setOnsongCloudConfigured] = useState(false);
+  const [dropboxConfigured, setDropboxConfigured] = useState(false);
+  const [webhooksEnabled, setWebhooksEnabled] = useState(true);
+  const [loginOpen, setLoginOpen] = useState(false);
   const isMobile = useMediaQuery(theme.breakpoints.down('md'));
 
   useEffect(() => {
-    checkWebhookConfig();
-    checkOnSongCloudConfig();
+    checkSession();
   }, []);
 
+  const checkSession = async () => {
+    try {
+      const status = await getSession();
+      if (!status.authenticated && status.login_required) {
+        setLoginOpen(true);
+        return;
+      }
+    } catch (error) {
+      console.error('Failed to check login session:', error);
+    }
+    loadConfigs();
+  };
+
+  const loadConfigs = async () => {
+    // Subsystems switched off in the add-on options answer 501; skip them
+    let webhooks = true;
+    try {
+      const capabilities = await getCapabilities();
+      webhooks = capabilities.webhooks?.enabled ?? true;
+    } catch (error) {
+      console.error('Failed to load capabilities:', error);
+    }
+    setWebhooksEnabled(webhooks);
+    if (webhooks) {
+      checkWebhookConfig();
+    }
+    checkOnSongCloudConfig();
+    checkDropboxConfig();
+  };
+
+  const handleLoggedIn = () => {
+    setLoginOpen(false);
+    loadConfigs();
+  };
+
   const checkWebhookConfig = async () => {
     try {
       const config = await getWebhookConfig();
@@ -46,6 +84,15 @@ function App() {
     }
   };
 
+  const checkDropboxConfig = async () => {
+    try {
+      const config = await getDropboxConfig();
+      setDropboxConfigured(config.configured);
+    } catch (error) {
+      console.error('Failed to check Dropbox config:', error);
+    }
+  };
+
   const handleSearch = useCallback(async (query: string) => {
     if (!query.trim()) {
       setResults([]);
@@ -93,7 +140,7 @@ function App() {
     <ThemeProvider theme={theme}>
       <CssBaseline />
       <Box sx={{ display: 'flex', flexDirection: 'column', height: '100vh', bgcolor: 'background.default' }}>
-        <Header onSettingsClick={() => setSettingsOpen(true)} />
+        <Header onSettingsClick={webhooksEnabled ? () => setSettingsOpen(true) : undefined} />
 
         {/* Tab navigation */}
         <Tabs
@@ -120,6 +167,7 @@ function App() {
                   loading={loadingTab}
                   webhookConfigured={webhookConfigured}
                   onsongCloudConfigured={onsongCloudConfigured}
+                  dropboxConfigured={dropboxConfigured}
                   onBack={handleBack}
                   showBackButton
                 />
@@ -155,6 +203,7 @@ function App() {
                     loading={loadingTab}
                     webhookConfigured={webhookConfigured}
                     onsongCloudConfigured={onsongCloudConfigured}
+                    dropboxConfigured={dropboxConfigured}
                   />
                 </Box>
               </Box>
@@ -174,6 +223,8 @@ function App() {
           onClose={() => setSettingsOpen(false)}
           onSaved={handleSettingsSaved}
         />
+
+        <LoginDialog open={loginOpen} onLoggedIn={handleLoggedIn} />
       </Box>
     </ThemeProvider>
   );
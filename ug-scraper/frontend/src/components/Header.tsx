@@ -2,7 +2,7 @@ import { AppBar, Toolbar, Typography, IconButton } from '@mui/material';
 import { Settings as SettingsIcon, MusicNote as MusicNoteIcon } from '@mui/icons-material';
 
 interface HeaderProps {
-  onSettingsClick: () => void;
+  onSettingsClick?: () => void; // No settings button when webhooks are switched off
 }
 
 export default function Header({ onSettingsClick }: HeaderProps) {
@@ -13,9 +13,11 @@ export default function Header({ onSettingsClick }: HeaderProps) {
         <Typography variant="h6" component="div" sx={{ flexGrow: 1 }}>
           Ultimate Guitar Scraper
         </Typography>
-        <IconButton color="inherit" onClick={onSettingsClick} aria-label="settings">
-          <SettingsIcon />
-        </IconButton>
+        {onSettingsClick && (
+          <IconButton color="inherit" onClick={onSettingsClick} aria-label="settings">
+            <SettingsIcon />
+          </IconButton>
+        )}
       </Toolbar>
     </AppBar>
   );
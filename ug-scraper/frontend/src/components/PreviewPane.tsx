@@ -17,8 +17,11 @@ import {
   Close as CloseIcon,
   ArrowBack as ArrowBackIcon,
   CloudUpload as CloudUploadIcon,
+  Download as DownloadIcon,
+  CloudSync as CloudSyncIcon,
+  PictureAsPdf as PdfIcon,
 } from '@mui/icons-material';
-import { sendToWebhook, sendToOnSongCloud } from '../services/api';
+import { sendToWebhook, sendToOnSongCloud, sendToDropbox } from '../services/api';
 import type { Tab } from '../services/api';
 
 interface PreviewPaneProps {
@@ -26,13 +29,15 @@ interface PreviewPaneProps {
   loading?: boolean;
   webhookConfigured: boolean;
   onsongCloudConfigured: boolean;
+  dropboxConfigured: boolean;
   onBack?: () => void;
   showBackButton?: boolean;
 }
 
-export default function PreviewPane({ tab, loading, webhookConfigured, onsongCloudConfigured, onBack, showBackButton }: PreviewPaneProps) {
+export default function PreviewPane({ tab, loading, webhookConfigured, onsongCloudConfigured, dropboxConfigured, onBack, showBackButton }: PreviewPaneProps) {
   const [sending, setSending] = useState(false);
   const [sendingCloud, setSendingCloud] = useState(false);
+  const [sendingDropbox, setSendingDropbox] = useState(false);
   const [snackbar, setSnackbar] = useState<{ open: boolean; message: string; severity: 'success' | 'error' }>({
     open: false,
     message: '',
@@ -92,6 +97,28 @@ export default function PreviewPane({ tab, loading, webhookConfigured, onsongClo
     }
   };
 
+  const handleSendDropbox = async () => {
+    if (!tab) return;
+
+    setSendingDropbox(true);
+    try {
+      const result = await sendToDropbox({
+        title: tab.title,
+        artist: tab.artist,
+        content: tab.onsong_format,
+      });
+      setSnackbar({ open: true, message: `Uploaded to Dropbox: ${result.path}`, severity: 'success' });
+    } catch (error: any) {
+      setSnackbar({
+        open: true,
+        message: error.response?.data?.error || 'Failed to upload to Dropbox',
+        severity: 'error',
+      });
+    } finally {
+      setSendingDropbox(false);
+    }
+  };
+
   if (loading) {
     return (
       <Box sx={{ display: 'flex', justifyContent: 'center', alignItems: 'center', flexGrow: 1, p: 4 }}>
@@ -135,10 +162,49 @@ export default function PreviewPane({ tab, loading, webhookConfigured, onsongClo
         <Stack direction="row" spacing={0.5} sx={{ mt: 1, flexWrap: 'wrap', gap: 0.5 }}>
           {tab.key && <Chip label={`Key: ${tab.key}`} size="small" color="primary" sx={{ height: 24, fontSize: '0.75rem' }} />}
           {tab.capo > 0 && <Chip label={`Capo: ${tab.capo}`} size="small" sx={{ height: 24, fontSize: '0.75rem' }} />}
+          {tab.capo > 0 && tab.shape_key && tab.sounding_key && tab.shape_key !== tab.sounding_key && (
+            <Chip
+              label={tab.key_header === 'sounding' ? `Shapes: ${tab.shape_key}` : `Sounds in ${tab.sounding_key}`}
+              size="small"
+              variant="outlined"
+              sx={{ height: 24, fontSize: '0.75rem' }}
+            />
+          )}
           {tab.difficulty && <Chip label={tab.difficulty} size="small" sx={{ height: 24, fontSize: '0.75rem' }} />}
           {tab.chord_count > 0 && <Chip label={`${tab.chord_count} chords`} size="small" variant="outlined" sx={{ height: 24, fontSize: '0.75rem' }} />}
+          {tab.strumming?.map((s, i) => (
+            <Chip
+              key={i}
+              label={`${s.part ? `${s.part}: ` : ''}${s.pattern}${s.bpm ? ` @ ${s.bpm}` : ''}`}
+              size="small"
+              variant="outlined"
+              sx={{ height: 24, fontSize: '0.75rem', fontFamily: 'monospace' }}
+            />
+          ))}
         </Stack>
 
+        {tab.warnings && tab.warnings.length > 0 && (
+          <Alert severity="warning" sx={{ mt: 1, py: 0, fontSize: '0.75rem' }}>
+            {tab.warnings.slice(0, 5).map((w, i) => (
+              <div key={i}>Line {w.line}: {w.message}</div>
+            ))}
+            {tab.warnings.length > 5 && <div>…and {tab.warnings.length - 5} more</div>}
+          </Alert>
+        )}
+
+        {tab.format === 'binary' ? (
+          <Stack direction="row" spacing={1} sx={{ mt: 1.5 }}>
+            <Button
+              variant="contained"
+              startIcon={<DownloadIcon />}
+              href={tab.file_url}
+              size="small"
+              sx={{ textTransform: 'none', flex: 1 }}
+            >
+              Download Guitar Pro file
+            </Button>
+          </Stack>
+        ) : (
         <Stack direction="row" spacing={1} sx={{ mt: 1.5 }}>
           <Button
             variant="outlined"
@@ -170,7 +236,32 @@ export default function PreviewPane({ tab, loading, webhookConfigured, onsongClo
           >
             {sendingCloud ? 'Uploading...' : 'OnSong Cloud'}
           </Button>
+          {dropboxConfigured && (
+            <Button
+              variant="contained"
+              color="secondary"
+              startIcon={<CloudSyncIcon />}
+              onClick={handleSendDropbox}
+              disabled={sendingDropbox}
+              size="small"
+              sx={{ textTransform: 'none', flex: 1 }}
+            >
+              {sendingDropbox ? 'Uploading...' : 'Dropbox'}
+            </Button>
+          )}
+          {tab.pdf_url && (
+            <Button
+              variant="outlined"
+              startIcon={<PdfIcon />}
+              href={tab.pdf_url}
+              size="small"
+              sx={{ textTransform: 'none', flex: 1 }}
+            >
+              PDF
+            </Button>
+          )}
         </Stack>
+        )}
       </Box>
 
       {/* OnSong content */}
@@ -199,7 +290,9 @@ export default function PreviewPane({ tab, loading, webhookConfigured, onsongClo
             color: 'rgba(255,255,255,0.9)',
           }}
         >
-          {tab.onsong_format}
+          {tab.format === 'binary'
+            ? 'Guitar Pro tabs are binary files and have no text preview. Download the file to open it in Guitar Pro or TuxGuitar.'
+            : tab.onsong_format}
         </pre>
       </Paper>
 
@@ -1,6 +1,7 @@
-import { useState } from 'react';
-import { TextField, InputAdornment, CircularProgress, Box, IconButton } from '@mui/material';
+import { useEffect, useState } from 'react';
+import { Autocomplete, TextField, InputAdornment, CircularProgress, Box, IconButton } from '@mui/material';
 import { Search as SearchIcon } from '@mui/icons-material';
+import { getSuggestions } from '../services/api';
 
 interface SearchBarProps {
   onSearch: (query: string) => void;
@@ -9,58 +10,95 @@ interface SearchBarProps {
 
 export default function SearchBar({ onSearch, loading }: SearchBarProps) {
   const [query, setQuery] = useState('');
+  const [suggestions, setSuggestions] = useState<string[]>([]);
 
-  const handleSearch = () => {
-    if (query.trim()) {
-      onSearch(query);
+  // Ask for suggestions once typing pauses; the server caches them too
+  useEffect(() => {
+    const prefix = query.trim();
+    if (prefix.length < 2) {
+      setSuggestions([]);
+      return;
     }
-  };
 
-  const handleKeyPress = (e: React.KeyboardEvent) => {
-    if (e.key === 'Enter') {
-      handleSearch();
+    let cancelled = false;
+    const timer = setTimeout(() => {
+      getSuggestions(prefix)
+        .then((items) => !cancelled && setSuggestions(items))
+        .catch(() => !cancelled && setSuggestions([]));
+    }, 250);
+
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [query]);
+
+  const handleSearch = (value: string = query) => {
+    if (value.trim()) {
+      onSearch(value);
     }
   };
 
   return (
     <Box sx={{ width: '100%', px: { xs: 2, sm: 3 }, py: { xs: 1.5, sm: 2 }, maxWidth: 700, mx: 'auto' }}>
-      <TextField
-        fullWidth
-        variant="outlined"
-        placeholder="Search songs or artists..."
-        value={query}
-        onChange={(e) => setQuery(e.target.value)}
-        onKeyPress={handleKeyPress}
-        size="medium"
-        InputProps={{
-          startAdornment: (
-            <InputAdornment position="start">
-              <SearchIcon sx={{ color: 'text.secondary' }} />
-            </InputAdornment>
-          ),
-          endAdornment: loading ? (
-            <InputAdornment position="end">
-              <CircularProgress size={20} />
-            </InputAdornment>
-          ) : (
-            <InputAdornment position="end">
-              <IconButton 
-                onClick={handleSearch} 
-                disabled={!query.trim() || loading}
-                edge="end"
-                sx={{ mr: -1 }}
-              >
-                <SearchIcon />
-              </IconButton>
-            </InputAdornment>
-          ),
+      <Autocomplete
+        freeSolo
+        options={suggestions}
+        filterOptions={(options) => options}
+        value={null}
+        inputValue={query}
+        onInputChange={(_, value, reason) => {
+          // Keep the text after a search instead of clearing it
+          if (reason !== 'reset') {
+            setQuery(value);
+          }
         }}
-        sx={{
-          '& .MuiOutlinedInput-root': {
-            borderRadius: 3,
-            bgcolor: 'background.paper',
-          },
+        onChange={(_, value) => {
+          // Enter or picking a suggestion
+          if (typeof value === 'string') {
+            setQuery(value);
+            handleSearch(value);
+          }
         }}
+        renderInput={(params) => (
+          <TextField
+            {...params}
+            fullWidth
+            variant="outlined"
+            placeholder="Search songs or artists..."
+            size="medium"
+            InputProps={{
+              ...params.InputProps,
+              startAdornment: (
+                <InputAdornment position="start">
+                  <SearchIcon sx={{ color: 'text.secondary' }} />
+                </InputAdornment>
+              ),
+              endAdornment: loading ? (
+                <InputAdornment position="end">
+                  <CircularProgress size={20} />
+                </InputAdornment>
+              ) : (
+                <InputAdornment position="end">
+                  <IconButton
+                    onClick={() => handleSearch()}
+                    disabled={!query.trim() || loading}
+                    edge="end"
+                    sx={{ mr: -1 }}
+                  >
+                    <SearchIcon />
+                  </IconButton>
+                </InputAdornment>
+              ),
+            }}
+            sx={{
+              '& .MuiOutlinedInput-root': {
+                borderRadius: 3,
+                bgcolor: 'background.paper',
+              },
+            }}
+          />
+        )}
       />
     </Box>
   );
@@ -8,7 +8,7 @@ import {
   Rating,
 } from '@mui/material';
 import { MusicNote as MusicNoteIcon } from '@mui/icons-material';
-import type { SearchResult } from '../services/api';
+import { TAB_TYPE_LABELS, type SearchResult } from '../services/api';
 
 interface SearchResultsProps {
   results: SearchResult[];
@@ -58,7 +58,7 @@ export default function SearchResults({ results, onSelect, selectedId }: SearchR
                 </Typography>
                 {result.type && (
                   <Chip
-                    label={result.type}
+                    label={TAB_TYPE_LABELS[result.type]}
                     size="small"
                     sx={{
                       height: 20,
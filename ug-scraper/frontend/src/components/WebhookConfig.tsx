@@ -11,9 +11,28 @@ import {
   Alert,
   Box,
   CircularProgress,
+  IconButton,
+  Typography,
+  MenuItem,
 } from '@mui/material';
+import { Delete as DeleteIcon } from '@mui/icons-material';
 import { getWebhookConfig, saveWebhookConfig, testWebhook } from '../services/api';
 
+interface HeaderRow {
+  name: string;
+  value: string;
+}
+
+const toHeaderMap = (rows: HeaderRow[]): Record<string, string> => {
+  const headers: Record<string, string> = {};
+  rows.forEach(({ name, value }) => {
+    if (name.trim()) {
+      headers[name.trim()] = value;
+    }
+  });
+  return headers;
+};
+
 interface WebhookConfigProps {
   open: boolean;
   onClose: () => void;
@@ -23,6 +42,9 @@ interface WebhookConfigProps {
 export default function WebhookConfig({ open, onClose, onSaved }: WebhookConfigProps) {
   const [url, setUrl] = useState('');
   const [enabled, setEnabled] = useState(true);
+  const [headers, setHeaders] = useState<HeaderRow[]>([]);
+  const [schemaVersion, setSchemaVersion] = useState(1);
+  const [includeBundle, setIncludeBundle] = useState(false);
   const [loading, setLoading] = useState(false);
   const [testing, setTesting] = useState(false);
   const [error, setError] = useState<string | null>(null);
@@ -42,6 +64,11 @@ export default function WebhookConfig({ open, onClose, onSaved }: WebhookConfigP
       if (config.configured) {
         setUrl(config.url || '');
         setEnabled(config.enabled || false);
+        setHeaders(
+          Object.entries(config.headers || {}).map(([name, value]) => ({ name, value })),
+        );
+        setSchemaVersion(config.schema_version || 1);
+        setIncludeBundle(config.include_bundle || false);
       }
     } catch (err: any) {
       setError('Failed to load configuration');
@@ -67,7 +94,7 @@ export default function WebhookConfig({ open, onClose, onSaved }: WebhookConfigP
     setLoading(true);
     try {
       // Auto-enable webhook when saving
-      await saveWebhookConfig(url, true);
+      await saveWebhookConfig(url, true, toHeaderMap(headers), schemaVersion, includeBundle);
       setEnabled(true);
       setSuccess('Webhook configuration saved successfully!');
       setTimeout(() => {
@@ -93,10 +120,15 @@ export default function WebhookConfig({ open, onClose, onSaved }: WebhookConfigP
 
     try {
       // First save the config
-      await saveWebhookConfig(url, true);
+      await saveWebhookConfig(url, true, toHeaderMap(headers), schemaVersion, includeBundle);
       // Then test it
-      await testWebhook();
-      setSuccess('Test webhook sent successfully! Check your endpoint.');
+      const result = await testWebhook();
+      const suggested = result.suggested_schema_version;
+      setSuccess(
+        suggested && suggested > schemaVersion
+          ? `Test webhook sent successfully! Your endpoint also accepts payload v${suggested}.`
+          : 'Test webhook sent successfully! Check your endpoint.',
+      );
     } catch (err: any) {
       setError(err.response?.data?.error || 'Test webhook failed');
     } finally {
@@ -104,6 +136,18 @@ export default function WebhookConfig({ open, onClose, onSaved }: WebhookConfigP
     }
   };
 
+  const updateHeader = (index: number, field: keyof HeaderRow, value: string) => {
+    setHeaders((rows) => rows.map((row, i) => (i === index ? { ...row, [field]: value } : row)));
+  };
+
+  const addHeader = () => {
+    setHeaders((rows) => [...rows, { name: '', value: '' }]);
+  };
+
+  const removeHeader = (index: number) => {
+    setHeaders((rows) => rows.filter((_, i) => i !== index));
+  };
+
   return (
     <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
       <DialogTitle>Webhook Configuration</DialogTitle>
@@ -139,6 +183,64 @@ export default function WebhookConfig({ open, onClose, onSaved }: WebhookConfigP
               sx={{ mt: 2 }}
             />
 
+            <TextField
+              select
+              margin="dense"
+              label="Payload schema"
+              fullWidth
+              value={schemaVersion}
+              onChange={(e) => setSchemaVersion(Number(e.target.value))}
+              helperText="Sent as schema_version and the X-Webhook-Schema header"
+              sx={{ mt: 2 }}
+            >
+              <MenuItem value={1}>v1 – title, artist, key and OnSong text</MenuItem>
+              <MenuItem value={2}>v2 – adds sections, lines and chord positions</MenuItem>
+            </TextField>
+
+            <FormControlLabel
+              control={
+                <Switch
+                  checked={includeBundle}
+                  onChange={(e) => setIncludeBundle(e.target.checked)}
+                />
+              }
+              label="Include chart structure and ChordPro / plain text versions"
+              sx={{ mt: 1 }}
+            />
+
+            <Box sx={{ mt: 2 }}>
+              <Typography variant="subtitle2">Custom headers</Typography>
+              <Typography variant="caption" color="text.secondary">
+                Sent with every delivery, e.g. Authorization: Bearer &lt;token&gt; or X-Api-Key
+              </Typography>
+              {headers.map((header, index) => (
+                <Box key={index} sx={{ display: 'flex', gap: 1, mt: 1, alignItems: 'center' }}>
+                  <TextField
+                    size="small"
+                    label="Header"
+                    value={header.name}
+                    onChange={(e) => updateHeader(index, 'name', e.target.value)}
+                    placeholder="Authorization"
+                    sx={{ flex: 1 }}
+                  />
+                  <TextField
+                    size="small"
+                    label="Value"
+                    value={header.value}
+                    onChange={(e) => updateHeader(index, 'value', e.target.value)}
+                    placeholder="Bearer ..."
+                    sx={{ flex: 2 }}
+                  />
+                  <IconButton aria-label="remove header" onClick={() => removeHeader(index)}>
+                    <DeleteIcon fontSize="small" />
+                  </IconButton>
+                </Box>
+              ))}
+              <Button size="small" onClick={addHeader} sx={{ mt: 1 }}>
+                Add header
+              </Button>
+            </Box>
+
             {error && (
               <Alert severity="error" sx={{ mt: 2 }}>
                 {error}
@@ -5,15 +5,93 @@ const api = axios.create({
   timeout: 30000,
 });
 
+// CSRF token of the login session, or of the browser while it has none; the
+// session and the browser's token live in HttpOnly cookies
+let csrfToken = '';
+
+api.interceptors.request.use((config) => {
+  const method = (config.method ?? 'get').toLowerCase();
+  if (csrfToken && method !== 'get' && method !== 'head') {
+    config.headers.set('X-CSRF-Token', csrfToken);
+  }
+  return config;
+});
+
+export type TabType =
+  | 'chords'
+  | 'tab'
+  | 'guitar_pro'
+  | 'bass'
+  | 'ukulele'
+  | 'drums'
+  | 'power'
+  | 'official'
+  | 'video';
+
+export const TAB_TYPE_LABELS: Record<TabType, string> = {
+  chords: 'Chords',
+  tab: 'Tab',
+  guitar_pro: 'Guitar Pro',
+  bass: 'Bass',
+  ukulele: 'Ukulele',
+  drums: 'Drums',
+  power: 'Power',
+  official: 'Official',
+  video: 'Video',
+};
+
 export interface SearchResult {
   id: string;
   title: string;
   artist: string;
-  type: string;
+  type: TabType | '';
   rating: number;
   votes: number;
   difficulty?: string;
   url: string;
+  locale?: string;
+}
+
+export interface ChordWarning {
+  line: number;
+  chord: string;
+  kind: 'invalid_chord' | 'missed_chord';
+  message: string;
+}
+
+export interface StrumPattern {
+  part?: string;
+  bpm?: number;
+  beat?: string;
+  triplet?: boolean;
+  strokes: string[];
+  pattern: string;
+}
+
+export interface PerformanceNote {
+  line: number;
+  kind: 'repeat' | 'technique';
+  text: string;
+}
+
+export interface ChordDiagram {
+  chord: string;
+  frets: number[];
+  fingers?: number[];
+  base_fret?: number;
+  source?: 'author' | 'built-in';
+}
+
+export interface ChordVoicing {
+  frets: number[];
+  fingers?: number[];
+  base_fret: number;
+  barres?: { fret: number; from_string: number; to_string: number; finger?: number }[];
+}
+
+export interface Applicature {
+  chord: string;
+  voicings: ChordVoicing[];
 }
 
 export interface Tab {
@@ -21,22 +99,40 @@ export interface Tab {
   title: string;
   artist: string;
   key: string;
+  shape_key?: string;
+  sounding_key?: string;
+  key_header?: 'shape' | 'sounding';
+  key_confidence?: number;
+  warnings?: ChordWarning[];
+  strumming?: StrumPattern[];
+  notes?: PerformanceNote[];
+  diagrams?: ChordDiagram[];
+  applicature?: Applicature[];
   capo: number;
   tuning: string;
   difficulty: string;
   rating: number;
   votes: number;
   content: string;
+  type?: TabType | '';
+  format?: 'onsong' | 'tab' | 'binary';
   onsong_format: string;
+  monospace?: string;
+  file_url?: string;
+  pdf_url?: string;
   chords: string[];
   chord_count: number;
   url: string;
+  locale?: string;
 }
 
 export interface WebhookConfig {
   configured: boolean;
   url?: string;
   enabled?: boolean;
+  headers?: Record<string, string>;
+  schema_version?: number;
+  include_bundle?: boolean;
   created_at?: string;
   updated_at?: string;
 }
@@ -50,7 +146,22 @@ export interface DeliveryResult {
   timestamp: string;
 }
 
-export const searchTabs = async (query: string, type?: string): Promise<SearchResult[]> => {
+export interface Capability {
+  name: string;
+  enabled: boolean;
+  configured: boolean;
+  available: boolean;
+  option?: string;
+  reason?: string;
+  endpoints?: string[];
+}
+
+export const getCapabilities = async (): Promise<Record<string, Capability>> => {
+  const response = await api.get('/capabilities');
+  return response.data.capabilities ?? {};
+};
+
+export const searchTabs = async (query: string, type?: TabType): Promise<SearchResult[]> => {
   const params: any = { q: query };
   if (type) params.type = type;
 
@@ -59,6 +170,11 @@ export const searchTabs = async (query: string, type?: string): Promise<SearchRe
   return Array.isArray(response.data) ? response.data : [];
 };
 
+export const getSuggestions = async (prefix: string): Promise<string[]> => {
+  const response = await api.get('/suggest', { params: { q: prefix } });
+  return response.data.suggestions || [];
+};
+
 export const fetchTab = async (id: string): Promise<Tab> => {
   const response = await api.get(`/tab/${id}`);
   return response.data;
@@ -69,12 +185,32 @@ export const getWebhookConfig = async (): Promise<WebhookConfig> => {
   return response.data;
 };
 
-export const saveWebhookConfig = async (url: string, enabled: boolean): Promise<void> => {
-  await api.post('/webhook/config', { url, enabled });
+export const saveWebhookConfig = async (
+  url: string,
+  enabled: boolean,
+  headers: Record<string, string> = {},
+  schemaVersion = 1,
+  includeBundle = false,
+): Promise<void> => {
+  await api.post('/webhook/config', {
+    url,
+    enabled,
+    headers,
+    schema_version: schemaVersion,
+    include_bundle: includeBundle,
+  });
 };
 
-export const testWebhook = async (): Promise<void> => {
-  await api.post('/webhook/test');
+export interface WebhookTestResult {
+  success: boolean;
+  schema_version: number;
+  accepted_schema_versions?: number[];
+  suggested_schema_version?: number;
+}
+
+export const testWebhook = async (): Promise<WebhookTestResult> => {
+  const response = await api.post('/webhook/test');
+  return response.data;
 };
 
 export interface WebhookSendPayload {
@@ -115,4 +251,56 @@ export const sendToOnSongCloud = async (payload: OnSongCloudSendPayload): Promis
   return response.data;
 };
 
+export interface DropboxConfig {
+  configured: boolean;
+  folder: string;
+}
+
+export const getDropboxConfig = async (): Promise<DropboxConfig> => {
+  const response = await api.get('/dropbox/config');
+  return response.data;
+};
+
+export const sendToDropbox = async (payload: OnSongCloudSendPayload): Promise<{ success: boolean; filename: string; path: string }> => {
+  const response = await api.post('/dropbox/send', payload);
+  return response.data;
+};
+
+export interface AuthSession {
+  username: string;
+  via: 'password' | 'ingress';
+  csrf_token: string;
+  created_at: string;
+  expires_at: string;
+}
+
+export interface AuthStatus {
+  authenticated: boolean;
+  session?: AuthSession;
+  login_required?: boolean;
+  password_login?: boolean;
+  ingress?: boolean;
+  csrf_token?: string;
+}
+
+const rememberSession = (status: AuthStatus): AuthStatus => {
+  csrfToken = status.session?.csrf_token ?? status.csrf_token ?? '';
+  return status;
+};
+
+export const getSession = async (): Promise<AuthStatus> => {
+  const response = await api.get('/auth/session');
+  return rememberSession(response.data);
+};
+
+export const login = async (username: string, password: string): Promise<AuthStatus> => {
+  const response = await api.post('/auth/login', { username, password });
+  return rememberSession(response.data);
+};
+
+export const logout = async (): Promise<void> => {
+  const response = await api.post('/auth/logout');
+  csrfToken = response.data.csrf_token ?? '';
+};
+
 export default api;
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"os"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/dropbox"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/features"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/homeassistant"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/mqtt"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/ocr"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/sharefolder"
+)
+
+// switchable are the capabilities a feature flag can turn off; their
+// endpoints answer 501 while they are off
+var switchable = []string{features.Library, features.Webhooks, features.MQTT}
+
+// registerCapabilities describes the add-on's optional subsystems: the ones
+// feature flags switch off and the integrations that only work once set up
+func registerCapabilities(registry *features.Registry, flags features.Flags, configStore *config.ConfigStore, mqttConfig mqtt.Config,
+	searchScraper *scraper.SearchScraper, ocrEngine ocr.Engine, dropboxClient *dropbox.Client, shareWriter *sharefolder.Writer, haNotifier *homeassistant.Notifier) {
+	registry.Add(features.Spec{
+		Name:    features.Library,
+		Enabled: flags.Library,
+		Option:  "feature_library",
+		Endpoints: []string{"/api/library", "/api/setlists", "/api/manifests", "/api/sync",
+			"/api/import", "/api/watchlist", "/api/feedback", "/api/admin/index", "/api/admin/reindex"},
+	})
+	registry.Add(features.Spec{
+		Name:       features.Webhooks,
+		Enabled:    flags.Webhooks,
+		Configured: configStore.IsConfigured,
+		Option:     "feature_webhooks",
+		Endpoints:  []string{"/api/webhook"},
+	})
+	registry.Add(features.Spec{
+		Name:       features.MQTT,
+		Enabled:    flags.MQTT,
+		Configured: func() bool { return mqttConfig.Broker != "" },
+		Option:     "feature_mqtt",
+		Endpoints:  []string{"/api/mqtt"},
+	})
+
+	registry.Add(features.Spec{
+		Name:       "flaresolverr",
+		Enabled:    true,
+		Configured: searchScraper.FlareSolverrConfigured,
+	})
+	registry.Add(features.Spec{
+		Name:       "ocr",
+		Enabled:    true,
+		Configured: func() bool { return ocrEngine != nil },
+		Endpoints:  []string{"/api/import/image"},
+	})
+	registry.Add(features.Spec{
+		Name:       "dropbox",
+		Enabled:    true,
+		Configured: dropboxClient.Enabled,
+		Endpoints:  []string{"/api/dropbox"},
+	})
+	registry.Add(features.Spec{
+		Name:       "onsong_cloud",
+		Enabled:    true,
+		Configured: func() bool { return os.Getenv("ONSONG_TOKEN") != "" },
+		Endpoints:  []string{"/api/onsong-cloud"},
+	})
+	registry.Add(features.Spec{
+		Name:       "share_folder",
+		Enabled:    true,
+		Configured: shareWriter.Enabled,
+	})
+	registry.Add(features.Spec{
+		Name:       "ha_notifications",
+		Enabled:    true,
+		Configured: haNotifier.Enabled,
+	})
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/health"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/mqtt"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
+)
+
+// startupGates are the dependencies STARTUP_GATES can require at startup
+func startupGates(searchScraper *scraper.SearchScraper, configStore *config.ConfigStore, webhookClient *webhook.Client, mqttClient *mqtt.Client) []health.Gate {
+	return []health.Gate{
+		{
+			// FlareSolverr must be reachable
+			Name:  "flaresolverr",
+			Check: searchScraper.PingFlareSolverr,
+		},
+		{
+			// The webhook target must accept the test payload
+			Name: "webhook",
+			Check: func(ctx context.Context) error {
+				webhookURL := configStore.GetURL()
+				if webhookURL == "" {
+					return fmt.Errorf("webhook is not configured")
+				}
+				_, err := webhookClient.TestWebhook(ctx, webhook.Target{
+					URL:           webhookURL,
+					Headers:       configStore.GetHeaders(),
+					SchemaVersion: configStore.GetSchemaVersion(),
+					Bundle:        configStore.IncludesBundle(),
+				})
+				return err
+			},
+		},
+		{
+			// The MQTT broker must be connected
+			Name: "mqtt",
+			Check: func(context.Context) error {
+				if !mqttClient.Enabled() {
+					return fmt.Errorf("MQTT is not configured")
+				}
+				if !mqttClient.Connected() {
+					return fmt.Errorf("not connected to %s", mqttClient.Broker())
+				}
+				return nil
+			},
+		},
+	}
+}
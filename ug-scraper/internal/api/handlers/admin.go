@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+)
+
+// AdminHandler handles API key management and the audit log
+type AdminHandler struct {
+	keys  *auth.KeyStore
+	audit *auth.AuditLog
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(keys *auth.KeyStore, audit *auth.AuditLog) *AdminHandler {
+	return &AdminHandler{
+		keys:  keys,
+		audit: audit,
+	}
+}
+
+// ListKeys returns every API key with its role, expiry and last use
+func (h *AdminHandler) ListKeys(c *fiber.Ctx) error {
+	keys := h.keys.List()
+
+	views := make([]fiber.Map, len(keys))
+	for i := range keys {
+		views[i] = keyView(&keys[i])
+	}
+
+	return c.JSON(views)
+}
+
+// CreateKey creates a named API key. Body: { "name", "role": "reader|editor|admin",
+// "expires_in_days": 90 } or "expires_at" (RFC 3339). The secret is only
+// returned in this response.
+func (h *AdminHandler) CreateKey(c *fiber.Ctx) error {
+	var req struct {
+		Name          string     `json:"name"`
+		Role          string     `json:"role"`
+		ExpiresInDays int        `json:"expires_in_days"`
+		ExpiresAt     *time.Time `json:"expires_at"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	expiresAt := req.ExpiresAt
+	if expiresAt == nil && req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	key, secret, err := h.keys.Create(req.Name, req.Role, expiresAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid API key",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("🔑 API key created: %s (%s)\n", key.Name, key.Role)
+	h.recordKeyEvent(c, auth.EventKeyCreated, key.ID, key.Name+" ("+key.Role+")")
+	view := keyView(key)
+	view["key"] = secret
+	return c.Status(fiber.StatusCreated).JSON(view)
+}
+
+// RotateKey replaces a key's secret; the old secret stops working immediately
+func (h *AdminHandler) RotateKey(c *fiber.Ctx) error {
+	key, secret, err := h.keys.Rotate(c.Params("id"))
+	if err != nil {
+		return keyError(c, err)
+	}
+
+	fmt.Printf("🔑 API key rotated: %s\n", key.Name)
+	h.recordKeyEvent(c, auth.EventKeyRotated, key.ID, key.Name)
+	view := keyView(key)
+	view["key"] = secret
+	return c.JSON(view)
+}
+
+// RevokeKey disables a key
+func (h *AdminHandler) RevokeKey(c *fiber.Ctx) error {
+	if err := h.keys.Revoke(c.Params("id")); err != nil {
+		return keyError(c, err)
+	}
+
+	fmt.Printf("🔑 API key revoked: %s\n", c.Params("id"))
+	h.recordKeyEvent(c, auth.EventKeyRevoked, c.Params("id"), "")
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// Audit lists audit events, newest first. Query: ?type=login_failed&limit=100
+func (h *AdminHandler) Audit(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "200"))
+	if err != nil || limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid limit",
+			"details": "limit must be a non-negative number",
+		})
+	}
+
+	return c.JSON(h.audit.List(c.Query("type"), limit))
+}
+
+// recordKeyEvent adds a key management action to the audit log
+func (h *AdminHandler) recordKeyEvent(c *fiber.Ctx, eventType, keyID, details string) {
+	h.audit.Record(auth.AuditEvent{
+		Type:    eventType,
+		IP:      c.IP(),
+		Actor:   requestActor(c),
+		KeyID:   keyID,
+		Details: details,
+	})
+}
+
+// recordAction adds a configuration change or deletion to the audit log
+func recordAction(audit *auth.AuditLog, c *fiber.Ctx, eventType, details string) {
+	audit.Record(auth.AuditEvent{
+		Type:    eventType,
+		IP:      c.IP(),
+		Actor:   requestActor(c),
+		Method:  c.Method(),
+		Path:    c.Path(),
+		Details: details,
+	})
+}
+
+// requestActor names who made an authenticated request, for the audit log
+func requestActor(c *fiber.Ctx) string {
+	if key, ok := c.Locals("api_key").(*auth.APIKey); ok {
+		return "key:" + key.Name
+	}
+	if session, ok := c.Locals("session").(*auth.Session); ok {
+		return session.Username
+	}
+	if auth.FromIngress(c.IP(), c.Get("X-Ingress-Path")) {
+		return utils.CopyString(c.Get("X-Remote-User-Name", "home-assistant"))
+	}
+	return ""
+}
+
+// keyView returns the public fields of a key, without its hash
+func keyView(key *auth.APIKey) fiber.Map {
+	return fiber.Map{
+		"id":           key.ID,
+		"name":         key.Name,
+		"role":         key.Role,
+		"prefix":       key.Prefix,
+		"created_at":   key.CreatedAt,
+		"expires_at":   key.ExpiresAt,
+		"last_used_at": key.LastUsedAt,
+		"revoked_at":   key.RevokedAt,
+		"known_ips":    key.KnownIPs,
+		"active":       key.Active(time.Now()),
+	}
+}
+
+// keyError translates key store errors to responses
+func keyError(c *fiber.Ctx, err error) error {
+	if err == auth.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "API key not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   "failed to update API key",
+		"details": err.Error(),
+	})
+}
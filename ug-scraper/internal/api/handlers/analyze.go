@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+// AnalyzeHandler reports what the converter's analyses make of a chart
+type AnalyzeHandler struct {
+	converter *converter.OnSongConverter
+}
+
+// NewAnalyzeHandler creates a new analyze handler
+func NewAnalyzeHandler(conv *converter.OnSongConverter) *AnalyzeHandler {
+	return &AnalyzeHandler{
+		converter: conv,
+	}
+}
+
+// Keys runs every key detection strategy on a chart, so they can be
+// compared before picking one with key_detection.
+// Body: { "content": "..." } or { "chords": ["C", "Am", "F", "G"] }
+func (h *AnalyzeHandler) Keys(c *fiber.Ctx) error {
+	var req struct {
+		Content string   `json:"content"`
+		Chords  []string `json:"chords"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	chords := req.Chords
+	if len(chords) == 0 {
+		chords = h.converter.ContentChords(req.Content)
+	}
+	if len(chords) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "content or chords is required",
+		})
+	}
+
+	estimates := converter.CompareKeyStrategies(chords)
+	agree := true
+	for _, estimate := range estimates[1:] {
+		if estimate.Key != estimates[0].Key {
+			agree = false
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"strategy": h.converter.KeyStrategy(),
+		"chords":   len(chords),
+		"results":  estimates,
+		"agree":    agree,
+	})
+}
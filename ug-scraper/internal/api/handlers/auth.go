@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+)
+
+// AuthHandler handles browser login sessions for the web UI
+type AuthHandler struct {
+	sessions *auth.SessionStore
+	keys     *auth.KeyStore
+	limiter  *auth.Limiter
+	audit    *auth.AuditLog
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(sessions *auth.SessionStore, keys *auth.KeyStore, limiter *auth.Limiter, audit *auth.AuditLog) *AuthHandler {
+	return &AuthHandler{
+		sessions: sessions,
+		keys:     keys,
+		limiter:  limiter,
+		audit:    audit,
+	}
+}
+
+// Session returns the current login session with its CSRF token, or what
+// login options exist and the CSRF token to send without a session when
+// there is none
+func (h *AuthHandler) Session(c *fiber.Ctx) error {
+	if session, ok := h.sessions.Get(c.Cookies(auth.SessionCookie)); ok {
+		return c.JSON(fiber.Map{
+			"authenticated": true,
+			"session":       session,
+		})
+	}
+
+	ingress := auth.FromIngress(c.IP(), c.Get("X-Ingress-Path"))
+	return c.JSON(fiber.Map{
+		"authenticated":  false,
+		"login_required": !ingress && (h.keys.Enabled() || h.sessions.PasswordLogin()),
+		"password_login": h.sessions.PasswordLogin(),
+		"ingress":        ingress,
+		"csrf_token":     c.Locals("csrf_token"),
+	})
+}
+
+// Login starts a session and sets the HttpOnly session cookie.
+// Body: { "username", "password" }. Requests through Home Assistant ingress
+// are trusted and need no password; the session takes the HA user's name.
+// Repeated failures lock the client address out for increasing periods.
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	if wait := h.limiter.Check(c.IP()); wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	username, via := req.Username, auth.LoginPassword
+	switch {
+	case auth.FromIngress(c.IP(), c.Get("X-Ingress-Path")):
+		via = auth.LoginIngress
+		username = utils.CopyString(c.Get("X-Remote-User-Name", "home-assistant"))
+	case !h.sessions.PasswordLogin():
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "password login is not configured",
+			"details": "set ui_username and ui_password in the add-on options",
+		})
+	case !h.sessions.CheckPassword(req.Username, req.Password):
+		fmt.Printf("⚠️  Failed login for %q from %s\n", req.Username, c.IP())
+		h.audit.Record(auth.AuditEvent{
+			Type:  auth.EventLoginFailed,
+			IP:    c.IP(),
+			Actor: req.Username,
+		})
+		if lockout := h.limiter.Fail(c.IP()); lockout > 0 {
+			h.recordLockout(c, lockout)
+			return tooManyAttempts(c, lockout)
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid username or password",
+		})
+	}
+	h.limiter.Succeed(c.IP())
+
+	session, err := h.sessions.Create(username, via)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to create session",
+			"details": err.Error(),
+		})
+	}
+
+	c.Cookie(sessionCookie(c, session.ID, session.ExpiresAt))
+	fmt.Printf("🔑 %s logged in (%s)\n", session.Username, session.Via)
+	h.audit.Record(auth.AuditEvent{
+		Type:    auth.EventLoginSucceeded,
+		IP:      c.IP(),
+		Actor:   session.Username,
+		Details: session.Via,
+	})
+
+	return c.JSON(fiber.Map{
+		"authenticated": true,
+		"session":       session,
+	})
+}
+
+// Logout ends the current session and clears the cookie. The response
+// carries the CSRF token to send without a session.
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	h.sessions.Delete(c.Cookies(auth.SessionCookie))
+	c.Cookie(sessionCookie(c, "", time.Unix(0, 0)))
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"csrf_token": c.Locals("csrf_token"),
+	})
+}
+
+// tooManyAttempts responds 429 with a Retry-After header for a locked out client
+func tooManyAttempts(c *fiber.Ctx, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":   "too many failed attempts",
+		"details": fmt.Sprintf("try again in %d seconds", seconds),
+	})
+}
+
+// recordLockout adds a lockout to the audit log
+func (h *AuthHandler) recordLockout(c *fiber.Ctx, lockout time.Duration) {
+	fmt.Printf("🔒 Locked out %s for %s after repeated failures\n", c.IP(), lockout)
+	h.audit.Record(auth.AuditEvent{
+		Type:    auth.EventLockedOut,
+		IP:      c.IP(),
+		Method:  c.Method(),
+		Path:    c.Path(),
+		Details: "locked for " + lockout.String(),
+	})
+}
+
+// sessionCookie builds the session cookie. It is HttpOnly so scripts cannot
+// read it and SameSite=Strict so other sites cannot send it; Secure is set
+// when the request arrived over HTTPS.
+func sessionCookie(c *fiber.Ctx, value string, expires time.Time) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteStrictMode,
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/backup"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/files"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/index"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// BackupHandler backs up and restores the add-on's data
+type BackupHandler struct {
+	manager *backup.Manager
+	store   *library.Store
+	index   *index.Manager
+	files   *files.Store
+	audit   *auth.AuditLog
+}
+
+// NewBackupHandler creates a new backup handler
+func NewBackupHandler(manager *backup.Manager, store *library.Store, indexManager *index.Manager, backupFiles *files.Store, audit *auth.AuditLog) *BackupHandler {
+	return &BackupHandler{
+		manager: manager,
+		store:   store,
+		index:   indexManager,
+		files:   backupFiles,
+		audit:   audit,
+	}
+}
+
+// Backup downloads a zip archive of the webhook, export settings, profiles
+// and the library with its setlists. ?save=true saves it under /data/backups
+// instead.
+func (h *BackupHandler) Backup(c *fiber.Ctx) error {
+	filename := fmt.Sprintf("ug-scraper-backup-%s.zip", time.Now().Format("2006-01-02"))
+
+	if c.QueryBool("save") {
+		file, err := h.files.WriteAsync(filename, func(w io.Writer) error {
+			_, err := h.manager.Write(w)
+			return err
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   "failed to save backup",
+				"details": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusAccepted).JSON(file)
+	}
+
+	fmt.Printf("\n💾 Backing up %d songs\n", h.store.Count())
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := h.manager.Write(w); err != nil {
+			fmt.Printf("❌ Backup failed: %v\n", err)
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// Restore replaces the data with a backup archive (raw body or multipart
+// "file"); ?sections=library,profiles restores only those. The current data
+// is saved under /data/backups first, so a restore can be undone.
+func (h *BackupHandler) Restore(c *fiber.Ctx) error {
+	body, err := readUpload(c, "file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid upload",
+			"details": err.Error(),
+		})
+	}
+
+	var sections []string
+	for _, name := range strings.Split(c.Query("sections"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sections = append(sections, name)
+		}
+	}
+
+	restored, err := h.manager.Read(body, sections)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid backup",
+			"details": err.Error(),
+		})
+	}
+
+	previous, err := h.files.Write(fmt.Sprintf("ug-scraper-before-restore-%s.zip", time.Now().Format("2006-01-02")), func(w io.Writer) error {
+		_, err := h.manager.Write(w)
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to save the current data before restoring",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("\n💾 Restoring backup: %d bytes (current data saved as %s)\n", len(body), previous.Name)
+	report, err := h.manager.Restore(restored)
+	if len(report.Restored) > 0 {
+		recordAction(h.audit, c, auth.EventBackupRestored, strings.Join(report.Restored, ", "))
+		h.index.Reindex("backup restored")
+	}
+	if err != nil {
+		fmt.Printf("❌ Restore failed: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":    "restore failed",
+			"details":  err.Error(),
+			"report":   report,
+			"previous": previous,
+		})
+	}
+
+	fmt.Printf("✅ Restored %s: %d songs, %d setlists\n", strings.Join(report.Restored, ", "), h.store.Count(), len(h.store.ListSetlists()))
+	return c.JSON(fiber.Map{
+		"report":   report,
+		"songs":    h.store.Count(),
+		"setlists": len(h.store.ListSetlists()),
+		"previous": previous,
+	})
+}
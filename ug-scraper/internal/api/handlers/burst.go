@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// BurstHandler handles time-boxed burst mode for large imports
+type BurstHandler struct {
+	budget *scraper.RateBudget
+	audit  *auth.AuditLog
+}
+
+// NewBurstHandler creates a new burst mode handler
+func NewBurstHandler(budget *scraper.RateBudget, audit *auth.AuditLog) *BurstHandler {
+	return &BurstHandler{
+		budget: budget,
+		audit:  audit,
+	}
+}
+
+// Status returns the running burst and any request awaiting confirmation
+func (h *BurstHandler) Status(c *fiber.Ctx) error {
+	return c.JSON(h.budget.Status())
+}
+
+// Request asks for a burst of { "minutes": 30 }. Nothing changes until the
+// returned confirm_token is sent to POST /api/admin/burst/confirm.
+func (h *BurstHandler) Request(c *fiber.Ctx) error {
+	var req struct {
+		Minutes int `json:"minutes"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	request, err := h.budget.Request(req.Minutes, requestActor(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid burst request",
+			"details": err.Error(),
+		})
+	}
+
+	h.record(c, auth.EventBurstRequested, fmt.Sprintf("%d minutes", request.Minutes))
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"confirm_token": request.Token,
+		"minutes":       request.Minutes,
+		"expires_at":    request.ExpiresAt,
+		"message": fmt.Sprintf("Confirm within 2 minutes to make UG requests %dx faster for %d minutes",
+			scraper.BurstFactor, request.Minutes),
+	})
+}
+
+// Confirm starts a requested burst. Body: { "token" }
+func (h *BurstHandler) Confirm(c *fiber.Ctx) error {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	burst, err := h.budget.Confirm(req.Token, requestActor(c))
+	if err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   "burst not started",
+			"details": err.Error(),
+		})
+	}
+
+	h.record(c, auth.EventBurstEnabled, fmt.Sprintf("until %s, pauses %dx shorter", burst.EndsAt.Format("15:04:05"), scraper.BurstFactor))
+	return c.JSON(h.budget.Status())
+}
+
+// End stops a running burst early
+func (h *BurstHandler) End(c *fiber.Ctx) error {
+	burst, err := h.budget.End()
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	details := "ended early"
+	if burst.EnabledBy != "" {
+		details += "; enabled by " + burst.EnabledBy
+	}
+	h.record(c, auth.EventBurstEnded, details)
+	return c.JSON(h.budget.Status())
+}
+
+// record adds a burst mode action to the audit log
+func (h *BurstHandler) record(c *fiber.Ctx, eventType, details string) {
+	h.audit.Record(auth.AuditEvent{
+		Type:    eventType,
+		IP:      c.IP(),
+		Actor:   requestActor(c),
+		Details: details,
+	})
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/features"
+)
+
+// CapabilitiesHandler describes which optional subsystems are available
+type CapabilitiesHandler struct {
+	registry *features.Registry
+}
+
+// NewCapabilitiesHandler creates a new capabilities handler
+func NewCapabilitiesHandler(registry *features.Registry) *CapabilitiesHandler {
+	return &CapabilitiesHandler{
+		registry: registry,
+	}
+}
+
+// List returns every capability keyed by name: whether it is switched on,
+// configured and available, why not, and the endpoints it serves
+func (h *CapabilitiesHandler) List(c *fiber.Ctx) error {
+	capabilities := make(map[string]features.Capability)
+	for _, capability := range h.registry.List() {
+		capabilities[capability.Name] = capability
+	}
+
+	return c.JSON(fiber.Map{
+		"capabilities": capabilities,
+	})
+}
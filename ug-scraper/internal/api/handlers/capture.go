@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/importer"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// CaptureHandler builds setlists from song lists dictated to a voice
+// assistant such as HA Assist
+type CaptureHandler struct {
+	pipeline *importer.Pipeline
+}
+
+// NewCaptureHandler creates a new quick capture handler
+func NewCaptureHandler(pipeline *importer.Pipeline) *CaptureHandler {
+	return &CaptureHandler{
+		pipeline: pipeline,
+	}
+}
+
+// captureRequest is the body of a quick capture
+type captureRequest struct {
+	Text      string `json:"text"`
+	SetlistID string `json:"setlist_id"`
+	DryRun    bool   `json:"dry_run"`
+}
+
+// Capture parses a dictated request like "add Wonderwall, Hallelujah, and
+// Wish You Were Here to Friday's set", resolves the songs and adds them to
+// the setlist. The response says what was understood and carries a "speech"
+// sentence for the assistant to read back; dry_run only shows the parse.
+func (h *CaptureHandler) Capture(c *fiber.Ctx) error {
+	var req captureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+	req.DryRun = req.DryRun || c.QueryBool("dry_run", false)
+	if strings.TrimSpace(req.Text) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "text is required",
+		})
+	}
+
+	fmt.Printf("\n🎙️  Setlist capture: %q (dry_run=%v)\n", req.Text, req.DryRun)
+	result, err := h.pipeline.CaptureSetlist(c.UserContext(), req.Text, req.SetlistID, req.DryRun)
+	if err != nil {
+		if err == library.ErrNotFound {
+			return setlistNotFound(c)
+		}
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "could not understand the request",
+			"details": err.Error(),
+			"speech":  "Sorry, " + err.Error() + ".",
+		})
+	}
+
+	fmt.Printf("✅ %s\n", result.Speech)
+	status := fiber.StatusOK
+	if result.Created && !result.DryRun {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(result)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/collab"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// CollabHandler handles collaborative chart editing sessions. Editors poll
+// the session, lock the section they are working on and save it on its own,
+// so two people can clean up the same chart without overwriting each other.
+type CollabHandler struct {
+	manager *collab.Manager
+}
+
+// NewCollabHandler creates a new collaboration handler
+func NewCollabHandler(manager *collab.Manager) *CollabHandler {
+	return &CollabHandler{
+		manager: manager,
+	}
+}
+
+// Session returns the chart split into sections with their locks and the
+// editors currently present. Query: editor=<name> marks the caller as present.
+func (h *CollabHandler) Session(c *fiber.Ctx) error {
+	state, err := h.manager.State(c.Params("id"), c.Query("editor"))
+	if err != nil {
+		return collabError(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, revisionTag(state.Revision))
+	return c.JSON(state)
+}
+
+// Lock claims a section for an editor, or renews their lock.
+// Body: { "editor": "Sam" }. Locks expire after two minutes without renewal.
+func (h *CollabHandler) Lock(c *fiber.Ctx) error {
+	index, err := c.ParamsInt("section")
+	if err != nil {
+		return collabError(c, collab.ErrNoSection)
+	}
+
+	var req struct {
+		Editor string `json:"editor"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Editor == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "editor is required",
+		})
+	}
+
+	lock, err := h.manager.Lock(c.Params("id"), index, req.Editor)
+	if err == collab.ErrLocked {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+			"lock":  lock,
+		})
+	}
+	if err != nil {
+		return collabError(c, err)
+	}
+
+	return c.JSON(lock)
+}
+
+// Unlock releases an editor's lock on a section. Query: editor=<name>.
+func (h *CollabHandler) Unlock(c *fiber.Ctx) error {
+	index, err := c.ParamsInt("section")
+	if err != nil {
+		return collabError(c, collab.ErrNoSection)
+	}
+
+	if err := h.manager.Unlock(c.Params("id"), index, c.Query("editor")); err != nil {
+		return collabError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// EditSection saves one locked section. Body: { "editor": "Sam", "text": "..." }.
+func (h *CollabHandler) EditSection(c *fiber.Ctx) error {
+	index, err := c.ParamsInt("section")
+	if err != nil {
+		return collabError(c, collab.ErrNoSection)
+	}
+
+	var req struct {
+		Editor string `json:"editor"`
+		Text   string `json:"text"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if req.Editor == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "editor is required",
+		})
+	}
+
+	song, err := h.manager.EditSection(c.Params("id"), index, req.Editor, req.Text)
+	if err != nil {
+		return collabError(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, revisionTag(song.Revision))
+	return c.JSON(song)
+}
+
+// collabError translates collaboration errors to responses
+func collabError(c *fiber.Ctx, err error) error {
+	switch err {
+	case library.ErrNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	case collab.ErrNoSection:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case collab.ErrLocked, collab.ErrNotLocked, library.ErrConflict:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid edit",
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/dropbox"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// DropboxHandler handles uploads to the Dropbox folder OnSong syncs from
+type DropboxHandler struct {
+	client  *dropbox.Client
+	library *library.Store
+}
+
+// NewDropboxHandler creates a new Dropbox handler
+func NewDropboxHandler(client *dropbox.Client, libraryStore *library.Store) *DropboxHandler {
+	return &DropboxHandler{
+		client:  client,
+		library: libraryStore,
+	}
+}
+
+// GetConfig returns whether Dropbox delivery is configured and its folder
+func (h *DropboxHandler) GetConfig(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"configured": h.client.Enabled(),
+		"folder":     h.client.Folder(),
+	})
+}
+
+// Send uploads a song to Dropbox as "Artist - Title.onsong".
+// Body: { "title", "artist", "content" } or { "song_id" } for a library song.
+func (h *DropboxHandler) Send(c *fiber.Ctx) error {
+	if !h.client.Enabled() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Dropbox token not configured",
+		})
+	}
+
+	var req struct {
+		SongID  string `json:"song_id"`
+		Title   string `json:"title"`
+		Artist  string `json:"artist"`
+		Content string `json:"content"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	var song *library.Song
+	if req.SongID != "" {
+		s, ok := h.library.Get(req.SongID)
+		if !ok {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "song not found",
+			})
+		}
+		song = s
+		req.Title, req.Artist, req.Content = s.Title, s.Artist, export.SongDocument(s)
+	}
+
+	if req.Title == "" || req.Content == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "title and content are required",
+		})
+	}
+
+	filename := export.SanitizeFilename(req.Title) + ".onsong"
+	if req.Artist != "" {
+		filename = export.SanitizeFilename(req.Artist+" - "+req.Title) + ".onsong"
+	}
+	fmt.Printf("\n📦 Uploading to Dropbox: %s/%s\n", h.client.Folder(), filename)
+
+	result, err := h.client.Upload(filename, req.Content)
+	if err != nil {
+		fmt.Printf("❌ Dropbox upload failed: %v\n\n", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   "Dropbox upload failed",
+			"details": err.Error(),
+		})
+	}
+
+	manifest := &library.Manifest{
+		Kind:         library.ManifestDropboxDelivery,
+		Reference:    result.Rev,
+		Name:         filename,
+		Format:       "onsong",
+		Destinations: []string{"dropbox:" + result.Path},
+		Files:        []library.ManifestFile{export.ManifestEntry(filename, song, req.Content)},
+	}
+	manifest.Files[0].Title = req.Title
+	manifest.Files[0].Artist = req.Artist
+	if err := h.library.AddManifest(manifest); err != nil {
+		fmt.Printf("⚠️  Failed to record delivery manifest: %v\n", err)
+	} else {
+		c.Set("X-Manifest-ID", manifest.ID)
+	}
+
+	fmt.Printf("✅ Dropbox upload successful: %s\n\n", result.Path)
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"filename": filename,
+		"path":     result.Path,
+		"rev":      result.Rev,
+	})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// contentTag returns an entity tag for a response body: the start of its
+// SHA-256 hash
+func contentTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:])[:32] + `"`
+}
+
+// notModified sets etag on the response and reports whether the request's
+// If-None-Match already names it, so a 304 can be sent instead. Browsers are
+// told to revalidate every time rather than guess how fresh the copy is.
+func notModified(c *fiber.Ctx, etag string) bool {
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+
+	// If-None-Match compares weakly: W/"1" matches "1"
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(c.Get(fiber.HeaderIfNoneMatch), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// sendTagged writes v as JSON tagged with its content hash, or a 304 when
+// the client already has it
+func sendTagged(c *fiber.Ctx, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if notModified(c, contentTag(body)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
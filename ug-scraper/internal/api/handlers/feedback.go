@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// FeedbackHandler records how accurate imported charts were. Ratings feed
+// into best-version selection: versions and contributors marked wrong are
+// down-ranked in later imports.
+type FeedbackHandler struct {
+	store *library.Store
+}
+
+// NewFeedbackHandler creates a new feedback handler
+func NewFeedbackHandler(store *library.Store) *FeedbackHandler {
+	return &FeedbackHandler{
+		store: store,
+	}
+}
+
+// feedbackRequest is the body of a chart rating
+type feedbackRequest struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+// Rate records a rating from 1 (wrong) to 5 (spot on) for a library song
+func (h *FeedbackHandler) Rate(c *fiber.Ctx) error {
+	var req feedbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	feedback := &library.Feedback{
+		SongID:  utils.CopyString(c.Params("id")),
+		Rating:  req.Rating,
+		Comment: req.Comment,
+	}
+	if err := h.store.AddFeedback(feedback); err != nil {
+		if err == library.ErrNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "song not found",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid feedback",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(feedback)
+}
+
+// List returns the ratings given to a library song, newest first
+func (h *FeedbackHandler) List(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, ok := h.store.Get(id); !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+
+	feedback := h.store.ListFeedback(id)
+	if feedback == nil {
+		feedback = []library.Feedback{}
+	}
+	return c.JSON(fiber.Map{
+		"song_id":  id,
+		"feedback": feedback,
+	})
+}
+
+// Summary returns the feedback aggregated per UG tab and contributor,
+// lowest rated first
+func (h *FeedbackHandler) Summary(c *fiber.Ctx) error {
+	return c.JSON(h.store.FeedbackSummary())
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/files"
+)
+
+// FilesHandler lists and serves the exports saved under /data/exports
+type FilesHandler struct {
+	files *files.Store
+}
+
+// NewFilesHandler creates a new exported files handler
+func NewFilesHandler(store *files.Store) *FilesHandler {
+	return &FilesHandler{
+		files: store,
+	}
+}
+
+// List returns every saved export, newest first, including the ones still
+// being written and the ones that failed
+func (h *FilesHandler) List(c *fiber.Ctx) error {
+	list, err := h.files.List()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to list exports",
+			"details": err.Error(),
+		})
+	}
+	return c.JSON(list)
+}
+
+// Download sends a saved export. An export still being written answers
+// 409 with its status.
+func (h *FilesHandler) Download(c *fiber.Ctx) error {
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return fileError(c, files.ErrInvalidName)
+	}
+
+	path, file, err := h.files.Open(name)
+	if errors.Is(err, files.ErrPending) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+			"file":  file,
+		})
+	}
+	if err != nil {
+		return fileError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, file.Name))
+	return c.SendFile(path)
+}
+
+// Delete removes a saved export, or forgets a failed one
+func (h *FilesHandler) Delete(c *fiber.Ctx) error {
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return fileError(c, files.ErrInvalidName)
+	}
+
+	if err := h.files.Remove(name); err != nil {
+		return fileError(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"message": "export deleted",
+	})
+}
+
+// fileError maps an export store error to a response
+func fileError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, files.ErrNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, files.ErrInvalidName):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, files.ErrPending):
+		status = fiber.StatusConflict
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// saveExport stores a finished export under name and answers 201 with it
+func saveExport(c *fiber.Ctx, store *files.Store, name string, data []byte) error {
+	file, err := store.Write(name, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to save export",
+			"details": err.Error(),
+		})
+	}
+	fmt.Printf("📂 Saved export %s (%d bytes)\n", file.Name, file.Size)
+	return c.Status(fiber.StatusCreated).JSON(file)
+}
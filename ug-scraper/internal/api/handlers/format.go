@@ -2,27 +2,45 @@ package handlers
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
 )
 
 // FormatHandler handles manual content formatting to OnSong format
 type FormatHandler struct {
 	converter *converter.OnSongConverter
+	output    chartOutput
 }
 
-// NewFormatHandler creates a new format handler
-func NewFormatHandler(conv *converter.OnSongConverter) *FormatHandler {
+// NewFormatHandler creates a new format handler. formats holds the ChordPro
+// settings of the chordpro output format.
+func NewFormatHandler(conv *converter.OnSongConverter, formats *config.FormatStore) *FormatHandler {
 	return &FormatHandler{
 		converter: conv,
+		output:    chartOutput{formats: formats},
 	}
 }
 
-// Handle processes format requests for manual content
+// Handle processes format requests for manual content. The optional
+// "spelling" field (auto, sharps or flats) overrides the chord spelling,
+// "chord_style" the chord qualities and "auto_sections" turns section
+// labelling of bare charts on or off. ?format= or the Accept header picks
+// onsong or chordpro text instead of the JSON response.
 func (h *FormatHandler) Handle(c *fiber.Ctx) error {
+	format, ok := outputFormat(c, outputJSON)
+	if !ok {
+		return nil
+	}
+
 	var req struct {
-		Title   string `json:"title"`
-		Artist  string `json:"artist"`
-		Content string `json:"content"`
+		Title    string `json:"title"`
+		Artist   string `json:"artist"`
+		Content  string `json:"content"`
+		Spelling string `json:"spelling"`
+		// Optional, overrides the chord_style option
+		ChordStyle string `json:"chord_style"`
+		// Optional, overrides the auto_sections option
+		AutoSections *bool `json:"auto_sections"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -42,9 +60,31 @@ func (h *FormatHandler) Handle(c *fiber.Ctx) error {
 		req.Artist = "Unknown Artist"
 	}
 
-	formatted := h.converter.FormatManualContent(req.Title, req.Artist, req.Content)
+	if err := checkConversionOptions("", req.Spelling); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+
+	conv, err := withChordStyle(h.converter.WithSpelling(req.Spelling), req.ChordStyle)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+	if req.AutoSections != nil {
+		conv = conv.WithAutoSections(*req.AutoSections)
+	}
+	formatted := conv.FormatManualContent(req.Title, req.Artist, req.Content)
+	if format != outputJSON {
+		return h.output.send(c, format, formatted, nil)
+	}
 
 	return c.JSON(fiber.Map{
 		"formatted": formatted,
+		"chart":     converter.ParseChart(formatted),
+		"warnings":  converter.ValidateChords(req.Content),
 	})
 }
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/fsck"
+)
+
+// FsckHandler runs the data integrity checks
+type FsckHandler struct {
+	checks []fsck.Check
+	audit  *auth.AuditLog
+}
+
+// NewFsckHandler creates a new integrity check handler
+func NewFsckHandler(audit *auth.AuditLog, checks ...fsck.Check) *FsckHandler {
+	return &FsckHandler{
+		checks: checks,
+		audit:  audit,
+	}
+}
+
+// Run checks the library, setlists, saved exports and search index and
+// returns what it found. Body or query: { "repair": true } also fixes the
+// repairable issues.
+func (h *FsckHandler) Run(c *fiber.Ctx) error {
+	var req struct {
+		Repair bool `json:"repair"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "invalid request body",
+				"details": err.Error(),
+			})
+		}
+	}
+	req.Repair = req.Repair || c.QueryBool("repair", false)
+
+	report := fsck.Run(req.Repair, h.checks...)
+	fmt.Printf("🔧 Integrity check: %d issues found, %d repaired\n", report.Found, report.Repaired)
+	if report.Repaired > 0 {
+		h.audit.Record(auth.AuditEvent{
+			Type:    auth.EventDataRepaired,
+			IP:      c.IP(),
+			Actor:   requestActor(c),
+			Details: fmt.Sprintf("%d of %d issues repaired", report.Repaired, report.Found),
+		})
+	}
+	return c.JSON(report)
+}
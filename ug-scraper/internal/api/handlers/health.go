@@ -5,23 +5,42 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/health"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
 )
 
 var startTime = time.Now()
 
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	configStore *config.ConfigStore
+	configStore   *config.ConfigStore
+	monitor       *health.Monitor
+	gates         *health.Gates
+	readiness     *health.Readiness
+	searchScraper *scraper.SearchScraper
+	probes        []health.Probe
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(configStore *config.ConfigStore) *HealthHandler {
+func NewHealthHandler(configStore *config.ConfigStore, monitor *health.Monitor, gates *health.Gates, readiness *health.Readiness, searchScraper *scraper.SearchScraper, probes []health.Probe) *HealthHandler {
 	return &HealthHandler{
-		configStore: configStore,
+		configStore:   configStore,
+		monitor:       monitor,
+		gates:         gates,
+		readiness:     readiness,
+		searchScraper: searchScraper,
+		probes:        probes,
 	}
 }
 
-// Handle processes health check requests
+// Handle processes health check requests, including the FlareSolverr status
+// from its background checks. With ?deep=true every subsystem
+// is checked on the spot (restarting degraded ones) and the per-subsystem
+// scores and recent recovery actions are included, as are the results of
+// the probes (UG API, FlareSolverr, config file, cache) with their latency;
+// a failed probe makes a healthy add-on degraded. This is the liveness
+// check: it answers 200 while the add-on runs, also during startup and while
+// a startup gate blocks readiness (see Ready).
 func (h *HealthHandler) Handle(c *fiber.Ctx) error {
 	uptime := time.Since(startTime)
 
@@ -33,5 +52,51 @@ func (h *HealthHandler) Handle(c *fiber.Ctx) error {
 		"timestamp":           time.Now(),
 	}
 
+	response["flaresolverr"] = h.searchScraper.FlareSolverrStatus()
+	response["solver_routing"] = h.searchScraper.Routing()
+	response["solvers"] = h.searchScraper.SolverStats()
+
+	if c.QueryBool("deep") {
+		report := h.monitor.CheckNow()
+		response["status"] = report.Status
+		response["health"] = report
+
+		checks := health.RunProbes(c.UserContext(), h.probes)
+		response["checks"] = checks
+		if health.ProbesFailed(checks) && report.Status == health.StatusHealthy {
+			response["status"] = health.StatusDegraded
+		}
+	}
+
+	if h.gates.Configured() {
+		response["startup_gates"] = h.gates.Report()
+	}
+
+	return c.JSON(response)
+}
+
+// Ready is the readiness check: 200 once config, storage and routes are set
+// up and no startup gate blocks, 503 with what is pending until then
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	report := h.readiness.Report()
+	response := fiber.Map{
+		"ready":    report.Ready,
+		"ready_at": report.ReadyAt,
+	}
+	pending := report.Pending
+
+	if h.gates.Configured() {
+		gates := h.gates.Report()
+		response["startup_gates"] = gates
+		if !gates.Ready {
+			pending = append(pending, "startup_gates")
+		}
+	}
+
+	if len(pending) > 0 {
+		response["ready"] = false
+		response["pending"] = pending
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
 	return c.JSON(response)
 }
@@ -0,0 +1,515 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/importer"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/ocr"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/tuning"
+)
+
+// ImportHandler handles bulk imports into the library
+type ImportHandler struct {
+	pipeline *importer.Pipeline
+	jobs     *importer.JobManager
+	ocr      ocr.Engine
+	renders  tuning.Limiter
+}
+
+// NewImportHandler creates a new import handler. ocrEngine may be nil when
+// OCR is off; renders bounds how many scans are rendered and read at once.
+func NewImportHandler(pipeline *importer.Pipeline, jobs *importer.JobManager, ocrEngine ocr.Engine, renders tuning.Limiter) *ImportHandler {
+	return &ImportHandler{
+		pipeline: pipeline,
+		jobs:     jobs,
+		ocr:      ocrEngine,
+		renders:  renders,
+	}
+}
+
+// ImportURLs starts a background import of a newline-separated list of UG
+// tab URLs or IDs, sent as the raw body or a multipart "file" field.
+// Responds 202 with the job; poll GET /api/import/:id for per-item status.
+func (h *ImportHandler) ImportURLs(c *fiber.Ctx) error {
+	body, err := readUpload(c, "file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid upload",
+			"details": err.Error(),
+		})
+	}
+
+	inputs, err := importer.ParseURLList(bytes.NewReader(body))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid URL list",
+			"details": err.Error(),
+		})
+	}
+
+	if len(inputs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "URL list contains no entries",
+		})
+	}
+
+	job, err := h.jobs.Submit(inputs)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   "import not queued",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("📥 Bulk import queued: %s (%d items)\n", job.ID, job.Total)
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// ListJobs returns all recent bulk import jobs
+func (h *ImportHandler) ListJobs(c *fiber.Ctx) error {
+	return c.JSON(h.jobs.List())
+}
+
+// GetJob returns a bulk import job with per-item status
+func (h *ImportHandler) GetJob(c *fiber.Ctx) error {
+	job, ok := h.jobs.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "import job not found",
+		})
+	}
+
+	return c.JSON(job)
+}
+
+// ImportCSV imports a repertoire CSV of artist,title[,preferred_key] rows.
+// The CSV may be sent as the raw request body or as a multipart "file" field.
+// Query: strict=true requires exact title/artist matches; misses are queued for review.
+func (h *ImportHandler) ImportCSV(c *fiber.Ctx) error {
+	body, err := readUpload(c, "file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid upload",
+			"details": err.Error(),
+		})
+	}
+
+	requests, err := importer.ParseRepertoireCSV(bytes.NewReader(body))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid CSV",
+			"details": err.Error(),
+		})
+	}
+
+	if len(requests) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "CSV contains no songs",
+		})
+	}
+
+	strict := c.QueryBool("strict", false)
+	fmt.Printf("\n📥 CSV import: %d songs (strict=%v)\n", len(requests), strict)
+
+	if wantsNDJSON(c) {
+		return streamNDJSON(c, func(ctx context.Context, emit func(any) bool) {
+			counts, done := h.importCSV(ctx, requests, strict, func(result importer.ItemResult) bool {
+				return emit(result)
+			})
+			if done {
+				emit(ndjsonSummary(len(requests), counts))
+			}
+		})
+	}
+
+	results := make([]importer.ItemResult, 0, len(requests))
+	counts, done := h.importCSV(c.UserContext(), requests, strict, func(result importer.ItemResult) bool {
+		results = append(results, result)
+		return true
+	})
+	if !done {
+		return c.UserContext().Err()
+	}
+
+	return c.JSON(fiber.Map{
+		"total":   len(requests),
+		"summary": counts,
+		"results": results,
+	})
+}
+
+// importCSV imports CSV rows one by one, handing each result to each.
+// Returns the count per status and whether every row was imported; a
+// cancelled context or each returning false stops the import.
+func (h *ImportHandler) importCSV(ctx context.Context, requests []importer.SongRequest, strict bool, each func(importer.ItemResult) bool) (map[string]int, bool) {
+	counts := make(map[string]int)
+	for i, req := range requests {
+		if ctx.Err() != nil {
+			fmt.Printf("⚠️  CSV import abandoned after %d of %d songs\n", i, len(requests))
+			return counts, false
+		}
+		fmt.Printf("   [%d/%d] %s - %s\n", i+1, len(requests), req.Artist, req.Title)
+		result := h.pipeline.Import(ctx, req, strict)
+		counts[result.Status]++
+		if !each(result) {
+			fmt.Printf("⚠️  CSV import abandoned after %d of %d songs\n", i+1, len(requests))
+			return counts, false
+		}
+	}
+
+	fmt.Printf("✅ CSV import complete: %v\n\n", counts)
+	return counts, true
+}
+
+// ImportArchive imports a zip of previously downloaded UG pages (.html),
+// OnSong/ChordPro charts and plain text tabs (.txt). Files are parsed offline; nothing is fetched from UG.
+// The zip may be sent as the raw request body or as a multipart "file" field.
+func (h *ImportHandler) ImportArchive(c *fiber.Ctx) error {
+	body, err := readUpload(c, "file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid upload",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("\n📦 Archive import: %d bytes\n", len(body))
+	stream := wantsNDJSON(c)
+	if stream {
+		// The import runs after the handler returns, when the request body
+		// may already be reused
+		body = bytes.Clone(body)
+	}
+	items, err := h.pipeline.ArchiveItems(body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid archive",
+			"details": err.Error(),
+		})
+	}
+
+	if stream {
+		return streamNDJSON(c, func(_ context.Context, emit func(any) bool) {
+			total := 0
+			counts := make(map[string]int)
+			for result := range items {
+				total++
+				counts[result.Status]++
+				if !emit(result) {
+					fmt.Printf("⚠️  Archive import abandoned after %d files\n", total)
+					return
+				}
+			}
+			fmt.Printf("✅ Archive import complete: %v\n\n", counts)
+			emit(ndjsonSummary(total, counts))
+		})
+	}
+
+	results := slices.Collect(items)
+	if len(results) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "archive contains no files",
+		})
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	fmt.Printf("✅ Archive import complete: %v\n\n", counts)
+	return c.JSON(fiber.Map{
+		"total":   len(results),
+		"summary": counts,
+		"results": results,
+	})
+}
+
+// ImportFiles imports existing OnSong (.onsong), ChordPro (.chordpro, .cho,
+// .crd, .pro) and text (.txt) charts into the library. Send one or more
+// multipart "files" fields, or a single file as the raw body with
+// ?filename=Song.onsong. Query: replace=true overwrites songs that are
+// already in the library with the same artist and title.
+func (h *ImportHandler) ImportFiles(c *fiber.Ctx) error {
+	uploads, err := readUploads(c, "files")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid upload",
+			"details": err.Error(),
+		})
+	}
+
+	replace := c.QueryBool("replace", false)
+	fmt.Printf("\n📥 Song file import: %d files (replace=%v)\n", len(uploads), replace)
+
+	if wantsNDJSON(c) {
+		// The import runs after the handler returns, when the request body
+		// may already be reused
+		for i := range uploads {
+			uploads[i].name = strings.Clone(uploads[i].name)
+			uploads[i].data = bytes.Clone(uploads[i].data)
+		}
+		return streamNDJSON(c, func(_ context.Context, emit func(any) bool) {
+			counts, done := h.importFiles(uploads, replace, func(result importer.ArchiveItem) bool {
+				return emit(result)
+			})
+			if done {
+				emit(ndjsonSummary(len(uploads), counts))
+			}
+		})
+	}
+
+	results := make([]importer.ArchiveItem, 0, len(uploads))
+	counts, _ := h.importFiles(uploads, replace, func(result importer.ArchiveItem) bool {
+		results = append(results, result)
+		return true
+	})
+
+	return c.JSON(fiber.Map{
+		"total":   len(results),
+		"summary": counts,
+		"results": results,
+	})
+}
+
+// importFiles imports uploaded charts one by one, handing each result to
+// each. Returns the count per status and whether every file was imported.
+func (h *ImportHandler) importFiles(uploads []uploadedFile, replace bool, each func(importer.ArchiveItem) bool) (map[string]int, bool) {
+	counts := make(map[string]int)
+	for i, upload := range uploads {
+		result := importer.ArchiveItem{File: upload.name, Status: importer.StatusSkipped, Error: "unsupported file type"}
+		if importer.IsSongFile(upload.name) {
+			result = h.pipeline.ImportSongFile(upload.name, string(upload.data), replace)
+		}
+		counts[result.Status]++
+		if !each(result) {
+			fmt.Printf("⚠️  Song file import abandoned after %d of %d files\n", i+1, len(uploads))
+			return counts, false
+		}
+	}
+
+	fmt.Printf("✅ Song file import complete: %v\n\n", counts)
+	return counts, true
+}
+
+// ImportLibrary imports an NDJSON library export (GET
+// /api/library/export.ndjson), one song per line, sent as the raw body or a
+// multipart "file" field. Query: replace=true overwrites songs that are
+// already in the library with the same ID, or artist and title.
+func (h *ImportHandler) ImportLibrary(c *fiber.Ctx) error {
+	body, err := readUpload(c, "file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid upload",
+			"details": err.Error(),
+		})
+	}
+
+	replace := c.QueryBool("replace", false)
+	fmt.Printf("\n📥 NDJSON library import: %d bytes (replace=%v)\n", len(body), replace)
+
+	if wantsNDJSON(c) {
+		// The import runs after the handler returns, when the request body
+		// may already be reused
+		lines := h.pipeline.LibraryLines(bytes.NewReader(bytes.Clone(body)), replace)
+		return streamNDJSON(c, func(_ context.Context, emit func(any) bool) {
+			total := 0
+			counts := make(map[string]int)
+			for result := range lines {
+				total++
+				counts[result.Status]++
+				if !emit(result) {
+					fmt.Printf("⚠️  NDJSON library import abandoned after %d songs\n", total)
+					return
+				}
+			}
+			fmt.Printf("✅ NDJSON library import complete: %v\n\n", counts)
+			emit(ndjsonSummary(total, counts))
+		})
+	}
+
+	results := slices.Collect(h.pipeline.LibraryLines(bytes.NewReader(body), replace))
+	if len(results) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "export contains no songs",
+		})
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	fmt.Printf("✅ NDJSON library import complete: %v\n\n", counts)
+	return c.JSON(fiber.Map{
+		"total":   len(results),
+		"summary": counts,
+		"results": results,
+	})
+}
+
+// ImportImage reads a photo or PDF scan of a paper chart with OCR, sent as
+// the raw body or a multipart "file" field, and queues the cleaned-up chart
+// for proofreading in the review queue. Query: title and artist override
+// the ones guessed from the chart's heading.
+func (h *ImportHandler) ImportImage(c *fiber.Ctx) error {
+	if h.ocr == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   "OCR is not configured",
+			"details": "set ocr_engine to tesseract or api",
+		})
+	}
+
+	body, err := readUpload(c, "file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid upload",
+			"details": err.Error(),
+		})
+	}
+
+	contentType := c.Get(fiber.HeaderContentType)
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		contentType = fileHeader.Header.Get(fiber.HeaderContentType)
+	}
+	isImage := strings.HasPrefix(contentType, "image/") || strings.HasPrefix(http.DetectContentType(body), "image/")
+	if !isImage && !ocr.IsPDF(body, contentType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "upload must be an image or a PDF",
+		})
+	}
+
+	fmt.Printf("\n📷 Scan import: %d bytes (%s, engine=%s)\n", len(body), contentType, h.ocr.Name())
+	if err := h.renders.Acquire(c.UserContext()); err != nil {
+		return err
+	}
+	text, err := h.ocr.Recognize(body, contentType)
+	h.renders.Release()
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   "OCR failed",
+			"details": err.Error(),
+		})
+	}
+
+	item, chart, err := h.pipeline.QueueScan(text, utils.CopyString(c.Query("title")), utils.CopyString(c.Query("artist")))
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "scan could not be imported",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("✅ Scan queued for review: %s - %s (%d chord lines, %d lyric lines)\n\n", item.Artist, item.Title, chart.ChordLines, chart.LyricLines)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"review":      item,
+		"engine":      h.ocr.Name(),
+		"chord_lines": chart.ChordLines,
+		"lyric_lines": chart.LyricLines,
+	})
+}
+
+// AcceptScan saves a proofread scan from the review queue to the library.
+// The optional body {"title","artist","content"} carries corrections.
+func (h *ImportHandler) AcceptScan(c *fiber.Ctx) error {
+	var edits importer.ScanEdits
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&edits); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "invalid request body",
+				"details": err.Error(),
+			})
+		}
+	}
+
+	song, err := h.pipeline.AcceptScan(c.Params("id"), edits)
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "review item not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "failed to accept scan",
+			"details": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(song)
+}
+
+// uploadedFile is a named file read from a request
+type uploadedFile struct {
+	name string
+	data []byte
+}
+
+// readUploads returns every file of a multipart field ("file" is accepted
+// too), or the raw request body named by the filename query parameter
+func readUploads(c *fiber.Ctx, field string) ([]uploadedFile, error) {
+	if form, err := c.MultipartForm(); err == nil {
+		var headers []*multipart.FileHeader
+		headers = append(headers, form.File[field]...)
+		headers = append(headers, form.File["file"]...)
+		if len(headers) == 0 {
+			return nil, fmt.Errorf("no files in multipart field %q", field)
+		}
+
+		uploads := make([]uploadedFile, 0, len(headers))
+		for _, header := range headers {
+			file, err := header.Open()
+			if err != nil {
+				return nil, fmt.Errorf("opening %s: %w", header.Filename, err)
+			}
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", header.Filename, err)
+			}
+			uploads = append(uploads, uploadedFile{name: header.Filename, data: data})
+		}
+		return uploads, nil
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return nil, fmt.Errorf("request body is empty")
+	}
+	name := utils.CopyString(c.Query("filename"))
+	if name == "" {
+		return nil, fmt.Errorf("filename query parameter is required for raw uploads")
+	}
+
+	return []uploadedFile{{name: name, data: body}}, nil
+}
+
+// readUpload returns the content of a multipart file field, or the raw
+// request body when the request is not multipart
+func readUpload(c *fiber.Ctx, field string) ([]byte, error) {
+	if fileHeader, err := c.FormFile(field); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening uploaded file: %w", err)
+		}
+		defer file.Close()
+
+		return io.ReadAll(file)
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return nil, fmt.Errorf("request body is empty")
+	}
+
+	return body, nil
+}
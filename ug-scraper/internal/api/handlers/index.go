@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/index"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// IndexHandler handles library search and the search index
+type IndexHandler struct {
+	manager *index.Manager
+	store   *library.Store
+}
+
+// NewIndexHandler creates a new search index handler
+func NewIndexHandler(manager *index.Manager, store *library.Store) *IndexHandler {
+	return &IndexHandler{
+		manager: manager,
+		store:   store,
+	}
+}
+
+// Search finds library songs by words of their title, artist or lyrics
+// (?q=) and by chords they use (?chords=G,C,D). With only=true a song may
+// use no other chords, to find what can be played with the chords one knows.
+// Requests with a profile only find songs in the profile's scope.
+func (h *IndexHandler) Search(c *fiber.Ctx) error {
+	query := index.Query{
+		Text:       c.Query("q"),
+		OnlyChords: c.QueryBool("only", false),
+		Limit:      c.QueryInt("limit", 50),
+	}
+	for _, chord := range strings.Split(c.Query("chords"), ",") {
+		if chord = strings.TrimSpace(chord); chord != "" {
+			query.Chords = append(query.Chords, chord)
+		}
+	}
+	if strings.TrimSpace(query.Text) == "" && len(query.Chords) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "q or chords is required",
+		})
+	}
+
+	hits, err := h.manager.Search(query)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   "search unavailable",
+			"details": err.Error(),
+		})
+	}
+
+	results := make([]fiber.Map, 0, len(hits))
+	for _, hit := range hits {
+		song, ok := h.store.Get(hit.SongID)
+		if !ok || !inProfileScope(c, song) {
+			continue
+		}
+		result := songSummary(*song)
+		result["score"] = hit.Score
+		result["chords"] = hit.Chords
+		results = append(results, result)
+	}
+
+	return c.JSON(results)
+}
+
+// Status reports the index version, coverage and any rebuild in progress
+func (h *IndexHandler) Status(c *fiber.Ctx) error {
+	return c.JSON(h.manager.Status())
+}
+
+// Reindex rebuilds the search index in the background; searches keep using
+// the current index until the new one is ready
+func (h *IndexHandler) Reindex(c *fiber.Ctx) error {
+	started := h.manager.Reindex("requested via API")
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"started": started,
+		"status":  h.manager.Status(),
+	})
+}
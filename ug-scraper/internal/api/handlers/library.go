@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/files"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// LibraryHandler handles access to stored songs and the review queue
+type LibraryHandler struct {
+	store    *library.Store
+	formats  *config.FormatStore
+	files    *files.Store
+	language string
+	audit    *auth.AuditLog
+}
+
+// NewLibraryHandler creates a new library handler. language is the default
+// language of section names in exported charts.
+func NewLibraryHandler(store *library.Store, formats *config.FormatStore, exports *files.Store, language string, audit *auth.AuditLog) *LibraryHandler {
+	return &LibraryHandler{
+		store:    store,
+		formats:  formats,
+		files:    exports,
+		language: language,
+		audit:    audit,
+	}
+}
+
+// List returns all songs in the library without their full content.
+// Query: tag=<tag>[,<tag>...] lists only the songs carrying every tag.
+// Requests with a profile list only the songs in the profile's scope.
+func (h *LibraryHandler) List(c *fiber.Ctx) error {
+	songs := h.store.ListTagged(library.ParseTags(c.Query("tag"))...)
+
+	summaries := make([]fiber.Map, 0, len(songs))
+	for i := range songs {
+		if inProfileScope(c, &songs[i]) {
+			summaries = append(summaries, songSummary(songs[i]))
+		}
+	}
+
+	return c.JSON(summaries)
+}
+
+// Get returns a single song including its content. The revision is the
+// ETag; If-None-Match with the current one returns 304.
+func (h *LibraryHandler) Get(c *fiber.Ctx) error {
+	song, ok := h.store.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+
+	if notModified(c, revisionTag(song.Revision)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.JSON(song)
+}
+
+// Update edits a stored song. Only the fields present in the body change.
+// The If-Match header must carry the revision the edit was based on; stale
+// writes get 409 with the current song so the client can reapply its edits.
+func (h *LibraryHandler) Update(c *fiber.Ctx) error {
+	song, ok := h.store.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+
+	revision, err := ifMatchRevision(c)
+	if err != nil {
+		return preconditionRequired(c, err)
+	}
+
+	var req struct {
+		Title        *string   `json:"title"`
+		Artist       *string   `json:"artist"`
+		Key          *string   `json:"key"`
+		PreferredKey *string   `json:"preferred_key"`
+		Capo         *int      `json:"capo"`
+		Tuning       *string   `json:"tuning"`
+		Content      *string   `json:"content"`
+		OnSongFormat *string   `json:"onsong_format"`
+		Tags         *[]string `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	setIf(&song.Title, req.Title)
+	setIf(&song.Artist, req.Artist)
+	setIf(&song.Key, req.Key)
+	setIf(&song.PreferredKey, req.PreferredKey)
+	setIf(&song.Capo, req.Capo)
+	setIf(&song.Tuning, req.Tuning)
+	setIf(&song.Content, req.Content)
+	setIf(&song.OnSongFormat, req.OnSongFormat)
+	setIf(&song.Tags, req.Tags)
+
+	if revision == anyRevision {
+		err = h.store.Save(song)
+	} else {
+		err = h.store.SaveIfMatch(song, revision)
+	}
+	if err == library.ErrConflict {
+		// The song may have been deleted since it was read
+		current, ok := h.store.Get(song.ID)
+		if !ok {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "song not found",
+			})
+		}
+		return revisionConflict(c, current.Revision, current)
+	}
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid song",
+			"details": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderETag, revisionTag(song.Revision))
+	return c.JSON(song)
+}
+
+// Tags lists every tag in use with the number of songs carrying it
+func (h *LibraryHandler) Tags(c *fiber.Ctx) error {
+	return c.JSON(h.store.Tags())
+}
+
+// setIf assigns *value to *field when the request provided it
+func setIf[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+// Export streams a zip of every song as an individual file for bulk import
+// into OnSong. Query: format=onsong (default) or chordpro, directives=long or
+// short and environments=true|false for {start_of_chorus}-style sections
+// (defaults from the ChordPro format settings), language=<code> for
+// section names (default from the add-on configuration), destination=<label>
+// naming the device or person the export is for. A delivery manifest is
+// recorded and its ID returned in the X-Manifest-ID header. With save=true
+// the zip is written to the exports directory in the background instead,
+// answering 202 with the file to fetch from /api/files once it is ready.
+func (h *LibraryHandler) Export(c *fiber.Ctx) error {
+	format := utils.CopyString(c.Query("format", export.ArchiveOnSong))
+	if !export.ValidArchiveFormat(format) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("format must be %q or %q", export.ArchiveOnSong, export.ArchiveChordPro),
+		})
+	}
+
+	chordPro := h.formats.Defaults().ChordPro.Options()
+	chordPro.Directives = utils.CopyString(c.Query("directives", chordPro.Directives))
+	if !export.ValidDirectiveStyle(chordPro.Directives) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("directives must be %q or %q", export.DirectivesLong, export.DirectivesShort),
+		})
+	}
+	chordPro.Environments = c.QueryBool("environments", chordPro.Environments)
+
+	language, err := sectionLanguage(c.Query("language"), h.language)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	songs := h.store.List()
+	files, err := export.LibraryArchiveFiles(songs, format, chordPro, language)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to export library",
+			"details": err.Error(),
+		})
+	}
+	fmt.Printf("\n📦 Exporting %d songs as %s archive\n", len(files), format)
+
+	filename := fmt.Sprintf("library-%s-%s.zip", format, time.Now().Format("2006-01-02"))
+
+	manifest := &library.Manifest{
+		Kind:         library.ManifestLibraryExport,
+		Name:         filename,
+		Format:       format,
+		Destinations: []string{utils.CopyString(c.Query("destination", "download"))},
+		Files:        make([]library.ManifestFile, len(files)),
+	}
+	for i, file := range files {
+		manifest.Files[i] = export.ManifestEntry(file.Name, file.Song, file.Content)
+	}
+	if err := h.store.AddManifest(manifest); err != nil {
+		fmt.Printf("⚠️  Failed to record export manifest: %v\n", err)
+	} else {
+		c.Set("X-Manifest-ID", manifest.ID)
+	}
+
+	if c.QueryBool("save") {
+		file, err := h.files.WriteAsync(filename, func(w io.Writer) error {
+			return export.WriteArchive(w, files)
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   "failed to save export",
+				"details": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusAccepted).JSON(file)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export.WriteArchive(w, files); err != nil {
+			fmt.Printf("❌ Library export failed: %v\n", err)
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// ExportNDJSON streams every song as newline-delimited JSON, one song per
+// line with its raw content, converted chart and metadata, for scripted
+// migrations. POST /api/library/import.ndjson reads it back.
+func (h *LibraryHandler) ExportNDJSON(c *fiber.Ctx) error {
+	songs := h.store.List()
+	fmt.Printf("\n📦 Exporting %d songs as NDJSON\n", len(songs))
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="library-%s.ndjson"`, time.Now().Format("2006-01-02")))
+	return streamNDJSON(c, func(_ context.Context, emit func(any) bool) {
+		for i := range songs {
+			if !emit(&songs[i]) {
+				return
+			}
+		}
+	})
+}
+
+// Drummer returns a song's drummer chart: sections with bar counts, tempo,
+// time signature and hits. Query: format=text (default) or json.
+func (h *LibraryHandler) Drummer(c *fiber.Ctx) error {
+	song, ok := h.store.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+
+	chart := export.BuildDrummerChart(song)
+	switch c.Query("format", "text") {
+	case "json":
+		return c.JSON(chart)
+	case "text":
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(chart.Text())
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": `format must be "text" or "json"`,
+		})
+	}
+}
+
+// Delete removes a song from the library
+func (h *LibraryHandler) Delete(c *fiber.Ctx) error {
+	deleted := c.Params("id")
+	if song, ok := h.store.Get(deleted); ok {
+		deleted = song.Artist + " - " + song.Title
+	}
+	if err := h.store.Delete(c.Params("id")); err != nil {
+		if err == library.ErrNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "song not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to delete song",
+			"details": err.Error(),
+		})
+	}
+
+	recordAction(h.audit, c, auth.EventSongDeleted, deleted)
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// ListReview returns song requests waiting for manual review
+func (h *LibraryHandler) ListReview(c *fiber.Ctx) error {
+	return c.JSON(h.store.ListReview())
+}
+
+// DismissReview removes an item from the review queue
+func (h *LibraryHandler) DismissReview(c *fiber.Ctx) error {
+	if err := h.store.RemoveReview(c.Params("id")); err != nil {
+		if err == library.ErrNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "review item not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to dismiss review item",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// songSummary returns the listing fields for a song
+func songSummary(song library.Song) fiber.Map {
+	return fiber.Map{
+		"id":            song.ID,
+		"title":         song.Title,
+		"artist":        song.Artist,
+		"key":           song.Key,
+		"preferred_key": song.PreferredKey,
+		"capo":          song.Capo,
+		"type":          song.Type,
+		"source":        song.Source,
+		"source_tab_id": song.SourceTabID,
+		"tags":          song.Tags,
+		"has_match":     song.SuggestedMatch != nil,
+		"updated_at":    song.UpdatedAt,
+	}
+}
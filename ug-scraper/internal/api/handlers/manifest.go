@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// ManifestHandler serves the delivery manifests recorded for exports and deliveries
+type ManifestHandler struct {
+	store *library.Store
+}
+
+// NewManifestHandler creates a new manifest handler
+func NewManifestHandler(store *library.Store) *ManifestHandler {
+	return &ManifestHandler{
+		store: store,
+	}
+}
+
+// List returns delivery manifests, newest first. Query: kind filters by
+// library_export, setlist_export, webhook_delivery or dropbox_delivery.
+func (h *ManifestHandler) List(c *fiber.Ctx) error {
+	return c.JSON(h.store.ListManifests(c.Query("kind")))
+}
+
+// Get returns a single manifest. Query: download=true serves it as a JSON
+// file attachment for archiving alongside the delivered charts.
+func (h *ManifestHandler) Get(c *fiber.Ctx) error {
+	manifest, ok := h.store.GetManifest(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "manifest not found",
+		})
+	}
+
+	if c.QueryBool("download", false) {
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.json"`, manifest.ID))
+	}
+
+	return c.JSON(manifest)
+}
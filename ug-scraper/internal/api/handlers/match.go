@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/importer"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// MatchHandler handles source matching for hand-entered library songs
+type MatchHandler struct {
+	matcher  *importer.Matcher
+	pipeline *importer.Pipeline
+	store    *library.Store
+}
+
+// NewMatchHandler creates a new match handler
+func NewMatchHandler(matcher *importer.Matcher, pipeline *importer.Pipeline, store *library.Store) *MatchHandler {
+	return &MatchHandler{
+		matcher:  matcher,
+		pipeline: pipeline,
+		store:    store,
+	}
+}
+
+// Status returns the matcher schedule and last run
+func (h *MatchHandler) Status(c *fiber.Ctx) error {
+	return c.JSON(h.matcher.Status())
+}
+
+// RunAll starts a matching pass over the whole library in the background
+func (h *MatchHandler) RunAll(c *fiber.Ctx) error {
+	go func() {
+		if _, err := h.matcher.Run(); err != nil {
+			fmt.Printf("⚠️  Source matcher: %v\n", err)
+		}
+	}()
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "source matching started",
+	})
+}
+
+// MatchSong searches for a UG version of a single song now
+func (h *MatchHandler) MatchSong(c *fiber.Ctx) error {
+	found, err := h.matcher.MatchSong(c.UserContext(), c.Params("id"))
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   "matching failed",
+			"details": err.Error(),
+		})
+	}
+
+	song, ok := h.store.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"found":           found,
+		"suggested_match": song.SuggestedMatch,
+	})
+}
+
+// Merge accepts the suggested match for a song.
+// Query: keep_content=true links the source without replacing the chart.
+func (h *MatchHandler) Merge(c *fiber.Ctx) error {
+	song, err := h.pipeline.MergeMatch(c.UserContext(), c.Params("id"), c.QueryBool("keep_content", false))
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	if err == library.ErrConflict {
+		// The song was edited meanwhile; the match stays suggested
+		if current, ok := h.store.Get(c.Params("id")); ok {
+			return revisionConflict(c, current.Revision, current)
+		}
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "merge failed",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(song)
+}
+
+// Dismiss rejects the suggested match so it isn't offered again
+func (h *MatchHandler) Dismiss(c *fiber.Ctx) error {
+	song, ok := h.store.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	if song.SuggestedMatch == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "song has no suggested match",
+		})
+	}
+
+	song.DismissedMatches = append(song.DismissedMatches, song.SuggestedMatch.TabID)
+	song.SuggestedMatch = nil
+
+	err := h.store.SaveIfMatch(song, song.Revision)
+	if err == library.ErrConflict {
+		current, ok := h.store.Get(song.ID)
+		if !ok {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "song not found",
+			})
+		}
+		return revisionConflict(c, current.Revision, current)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to save song",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
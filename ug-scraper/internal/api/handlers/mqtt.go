@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/mqtt"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
+)
+
+// MQTTHandler handles publishing songs to an MQTT broker
+type MQTTHandler struct {
+	client *mqtt.Client
+}
+
+// NewMQTTHandler creates a new MQTT handler
+func NewMQTTHandler(client *mqtt.Client) *MQTTHandler {
+	return &MQTTHandler{
+		client: client,
+	}
+}
+
+// GetStatus returns whether MQTT is configured and connected
+func (h *MQTTHandler) GetStatus(c *fiber.Ctx) error {
+	if !h.client.Enabled() {
+		return c.JSON(fiber.Map{
+			"configured": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"configured": true,
+		"connected":  h.client.Connected(),
+		"broker":     h.client.Broker(),
+		"topic":      h.client.Topic("songs"),
+	})
+}
+
+// SendTab publishes tab data to the songs topic
+func (h *MQTTHandler) SendTab(c *fiber.Ctx) error {
+	if !h.client.Enabled() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "MQTT not configured",
+		})
+	}
+
+	var req struct {
+		Title   string `json:"title"`
+		Artist  string `json:"artist"`
+		Content string `json:"content"`
+		Key     string `json:"key"`
+		Capo    int    `json:"capo"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if req.Title == "" || req.Content == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "title and content are required",
+		})
+	}
+
+	fmt.Printf("\n📡 Publishing to MQTT: %s - %s\n", req.Artist, req.Title)
+
+	payload := &webhook.WebhookPayload{
+		Title:        req.Title,
+		Artist:       req.Artist,
+		Key:          req.Key,
+		Capo:         req.Capo,
+		OnSongFormat: req.Content,
+		Timestamp:    time.Now(),
+		Source:       "Ultimate Guitar Scraper",
+	}
+
+	if err := h.client.PublishSong(payload); err != nil {
+		fmt.Printf("❌ MQTT publish failed: %v\n\n", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error":   "MQTT publish failed",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Print("✅ Published to MQTT\n\n")
+	return c.JSON(fiber.Map{
+		"success": true,
+		"topic":   h.client.Topic("songs"),
+	})
+}
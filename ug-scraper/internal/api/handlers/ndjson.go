@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mimeNDJSON is the content type of newline-delimited JSON responses
+const mimeNDJSON = "application/x-ndjson"
+
+// wantsNDJSON reports whether the client asked for a batch's results as
+// newline-delimited JSON, with Accept: application/x-ndjson or ?stream=ndjson
+func wantsNDJSON(c *fiber.Ctx) bool {
+	return c.Query("stream") == "ndjson" || strings.Contains(c.Get(fiber.HeaderAccept), mimeNDJSON)
+}
+
+// streamNDJSON responds with one JSON value per line, sent as soon as
+// produce emits it, so neither end holds the whole batch in memory. produce
+// runs after the handler has returned and must not touch c; emit returns
+// false once the client has gone, and ctx is cancelled then too.
+func streamNDJSON(c *fiber.Ctx, produce func(ctx context.Context, emit func(v any) bool)) error {
+	c.Set(fiber.HeaderContentType, mimeNDJSON)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		enc := json.NewEncoder(w)
+		produce(ctx, func(v any) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			if err := enc.Encode(v); err != nil {
+				fmt.Printf("⚠️  NDJSON stream stopped: %v\n", err)
+				cancel()
+				return false
+			}
+			if err := w.Flush(); err != nil {
+				fmt.Printf("⚠️  NDJSON stream stopped, client gone: %v\n", err)
+				cancel()
+				return false
+			}
+			return true
+		})
+	})
+
+	return nil
+}
+
+// ndjsonSummary is the last line of a streamed batch
+func ndjsonSummary(total int, counts map[string]int) fiber.Map {
+	return fiber.Map{
+		"done":    true,
+		"total":   total,
+		"summary": counts,
+	}
+}
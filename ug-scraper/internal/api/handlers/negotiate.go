@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/tuning"
+)
+
+// Output formats of the conversion endpoints
+const (
+	outputJSON     = "json"     // The endpoint's JSON response
+	outputOnSong   = "onsong"   // The OnSong chart as plain text
+	outputChordPro = "chordpro" // The chart as ChordPro, with the stored ChordPro settings
+	outputPDF      = "pdf"      // Tablature laid out as a PDF
+)
+
+// outputTypes are the content types of the output formats
+var outputTypes = []struct {
+	format      string
+	contentType string
+}{
+	{outputJSON, fiber.MIMEApplicationJSON},
+	{outputOnSong, fiber.MIMETextPlain},
+	{outputChordPro, "text/x-chordpro"},
+	{outputPDF, "application/pdf"},
+}
+
+// outputFormat picks the output format of a conversion endpoint: the format
+// query parameter, otherwise the Accept header, otherwise fallback, which
+// also wins when the client accepts anything. It answers the 400 or 406
+// itself and returns false when no format fits.
+func outputFormat(c *fiber.Ctx, fallback string) (string, bool) {
+	c.Vary(fiber.HeaderAccept)
+
+	if format := c.Query("format"); format != "" {
+		for _, t := range outputTypes {
+			if t.format == format {
+				return format, true
+			}
+		}
+		_ = c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid format",
+			"details": fmt.Sprintf("format must be %s, %s, %s or %s", outputJSON, outputOnSong, outputChordPro, outputPDF),
+		})
+		return "", false
+	}
+
+	if c.Get(fiber.HeaderAccept) == "" {
+		return fallback, true
+	}
+
+	// The fallback is offered first, so */* keeps the endpoint's default
+	offers := []string{}
+	for _, t := range outputTypes {
+		if t.format == fallback {
+			offers = append([]string{t.contentType}, offers...)
+		} else {
+			offers = append(offers, t.contentType)
+		}
+	}
+	accepted := c.Accepts(offers...)
+	for _, t := range outputTypes {
+		if t.contentType == accepted {
+			return t.format, true
+		}
+	}
+
+	_ = c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{
+		"error":   "no acceptable format",
+		"details": fmt.Sprintf("conversions are available as %s, %s, text/x-chordpro or application/pdf; or pick one with ?format=", fiber.MIMEApplicationJSON, fiber.MIMETextPlain),
+	})
+	return "", false
+}
+
+// chartOutput writes converted charts in the text and PDF output formats
+type chartOutput struct {
+	formats *config.FormatStore
+	renders tuning.Limiter
+}
+
+// send writes an OnSong chart as format, which must not be JSON. Only
+// tablature, which sheet holds, can be sent as a PDF; sheet is nil for
+// chord charts.
+func (o chartOutput) send(c *fiber.Ctx, format, onsong string, sheet *export.TabSheet) error {
+	var body []byte
+	contentType := fiber.MIMETextPlainCharsetUTF8
+	switch format {
+	case outputOnSong:
+		body = []byte(onsong)
+	case outputChordPro:
+		body = []byte(export.OnSongToChordPro(onsong, o.formats.Defaults().ChordPro.Options()))
+		contentType = "text/x-chordpro; charset=utf-8"
+	case outputPDF:
+		if sheet == nil {
+			return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{
+				"error":   "PDF output is only available for tablature",
+				"details": "chord charts are available as json, onsong or chordpro",
+			})
+		}
+		if err := o.renders.Acquire(c.UserContext()); err != nil {
+			return err
+		}
+		body = export.TabPDF(*sheet, o.formats.Defaults().PDF.FontSize)
+		o.renders.Release()
+		contentType = "application/pdf"
+	}
+
+	if notModified(c, contentTag(body)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(body)
+}
+
+// tabSheet returns the PDF sheet of a converted tab, or nil when the tab is
+// not tablature
+func tabSheet(tab *scraper.TabResult, result *converter.ConversionResult) *export.TabSheet {
+	if result.Format != converter.FormatTab {
+		return nil
+	}
+	return &export.TabSheet{
+		Title:  tab.SongName,
+		Artist: tab.ArtistName,
+		Tuning: tab.Tuning,
+		Capo:   tab.Capo,
+		Key:    result.DetectedKey,
+		Blocks: result.Blocks,
+	}
+}
@@ -4,29 +4,46 @@ import (
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/tuning"
 )
 
 // OnSongHandler handles OnSong format conversion
 type OnSongHandler struct {
 	ugClient  *scraper.UGClient
 	converter *converter.OnSongConverter
+	output    chartOutput
 }
 
-// NewOnSongHandler creates a new OnSong handler
-func NewOnSongHandler(ugClient *scraper.UGClient, conv *converter.OnSongConverter) *OnSongHandler {
+// NewOnSongHandler creates a new OnSong handler. formats holds the ChordPro
+// and PDF settings of other output formats; renders bounds PDF rendering.
+func NewOnSongHandler(ugClient *scraper.UGClient, conv *converter.OnSongConverter, formats *config.FormatStore, renders tuning.Limiter) *OnSongHandler {
 	return &OnSongHandler{
 		ugClient:  ugClient,
 		converter: conv,
+		output:    chartOutput{formats: formats, renders: renders},
 	}
 }
 
 // Handle processes OnSong format requests
-// Expects POST body: { "id": "tab_id" }
+// Expects POST body: { "id": "tab_id", "key": "G", "key_header": "shape|sounding",
+// "spelling": "auto|sharps|flats", "chord_style": "keep|standard|compact|jazz",
+// "auto_sections": true|false, "profile": "guitar|piano",
+// "bass_hints": true|false, "remove_capo": true|false, "bass_lines": true }
+// Answers with the OnSong chart as plain text, or in the format picked with
+// ?format= or the Accept header: json for the GET /api/tab/:id response,
+// chordpro, or pdf for tablature.
 func (h *OnSongHandler) Handle(c *fiber.Ctx) error {
+	format, ok := outputFormat(c, outputOnSong)
+	if !ok {
+		return nil
+	}
+
 	var req struct {
 		ID interface{} `json:"id"` // Can be string or number
+		conversionBody
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -57,8 +74,16 @@ func (h *OnSongHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
+	conv, err := req.apply(h.converter)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+
 	// Fetch tab from Ultimate Guitar
-	tab, err := h.ugClient.GetTabByID(tabID)
+	tab, err := h.ugClient.GetTabByID(c.UserContext(), tabID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to fetch tab",
@@ -75,7 +100,13 @@ func (h *OnSongHandler) Handle(c *fiber.Ctx) error {
 	}
 
 	// Convert to OnSong format
-	result, err := h.converter.Convert(tab)
+	result, err := conv.ConvertByType(tab)
+	if err == converter.ErrNoTextContent {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "tab cannot be converted",
+			"details": err.Error(),
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "conversion failed",
@@ -83,6 +114,15 @@ func (h *OnSongHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
-	// Return just the OnSong formatted string (as your frontend expects)
-	return c.SendString(result.OnSongFormat)
+	if result.Format == converter.FormatBinary {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":    "Guitar Pro tabs have no text content",
+			"file_url": fmt.Sprintf("/api/tab/%s/file", tabID),
+		})
+	}
+
+	if format == outputJSON {
+		return sendTagged(c, tabResponse(tab, result))
+	}
+	return h.output.send(c, format, result.OnSongFormat, tabSheet(tab, result))
 }
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+// checkConversionOptions validates the optional per-request key_header and
+// spelling choices; empty values fall back to the add-on configuration
+func checkConversionOptions(keyHeader, spelling string) error {
+	if keyHeader != "" && !converter.ValidKeyHeader(keyHeader) {
+		return fmt.Errorf("key_header must be %s or %s", converter.KeyHeaderShape, converter.KeyHeaderSounding)
+	}
+	if spelling != "" && !converter.ValidSpelling(spelling) {
+		return fmt.Errorf("spelling must be %s, %s or %s", converter.SpellingAuto, converter.SpellingSharps, converter.SpellingFlats)
+	}
+	return nil
+}
+
+// withAutoSections applies an optional per-request auto_sections switch
+// ("true" or "false"); an empty value keeps the add-on configuration
+func withAutoSections(conv *converter.OnSongConverter, value string) (*converter.OnSongConverter, error) {
+	if value == "" {
+		return conv, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("auto_sections must be true or false")
+	}
+	return conv.WithAutoSections(enabled), nil
+}
+
+// withChordStyle applies an optional per-request chord_style (a preset or
+// forms such as "M7,min,sus4"); an empty value keeps the add-on configuration
+func withChordStyle(conv *converter.OnSongConverter, value string) (*converter.OnSongConverter, error) {
+	if value == "" {
+		return conv, nil
+	}
+	style, err := converter.ParseChordStyle(value)
+	if err != nil {
+		return nil, fmt.Errorf("chord_style: %w", err)
+	}
+	return conv.WithChordStyle(style), nil
+}
+
+// withKey applies an optional per-request key override, the key of the
+// chords as written; an empty value keeps Ultimate Guitar's or the detected key
+func withKey(conv *converter.OnSongConverter, value string) (*converter.OnSongConverter, error) {
+	if value == "" {
+		return conv, nil
+	}
+	if _, ok := converter.ParseKey(value); !ok {
+		return nil, fmt.Errorf("key %q is not a key such as G, F#m or Bb", value)
+	}
+	return conv.WithKey(value), nil
+}
+
+// conversionBody holds the optional conversion overrides of a JSON request
+// body; unset fields keep the add-on configuration
+type conversionBody struct {
+	Key          string `json:"key"`
+	KeyHeader    string `json:"key_header"`
+	Spelling     string `json:"spelling"`
+	ChordStyle   string `json:"chord_style"`
+	AutoSections *bool  `json:"auto_sections"`
+	Profile      string `json:"profile"`
+	BassHints    *bool  `json:"bass_hints"`
+	BassLines    bool   `json:"bass_lines"`
+	RemoveCapo   *bool  `json:"remove_capo"`
+}
+
+// apply validates the overrides and returns conv with them applied
+func (b conversionBody) apply(conv *converter.OnSongConverter) (*converter.OnSongConverter, error) {
+	if err := checkConversionOptions(b.KeyHeader, b.Spelling); err != nil {
+		return nil, err
+	}
+	if b.Profile != "" && !converter.ValidProfile(b.Profile) {
+		return nil, fmt.Errorf("profile must be %s or %s", converter.ProfileGuitar, converter.ProfilePiano)
+	}
+
+	conv, err := withChordStyle(conv.WithKeyHeader(b.KeyHeader).WithSpelling(b.Spelling).WithProfile(b.Profile), b.ChordStyle)
+	if err == nil {
+		conv, err = withKey(conv, b.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.AutoSections != nil {
+		conv = conv.WithAutoSections(*b.AutoSections)
+	}
+	if b.BassHints != nil {
+		conv = conv.WithBassHints(*b.BassHints)
+	}
+	if b.RemoveCapo != nil {
+		conv = conv.WithRemoveCapo(*b.RemoveCapo)
+	}
+	return conv.WithBassLines(b.BassLines), nil
+}
+
+// withProfile applies an optional per-request conversion profile and
+// bass_hints switch; empty values keep the add-on configuration
+func withProfile(conv *converter.OnSongConverter, profile, bassHints string) (*converter.OnSongConverter, error) {
+	if profile != "" && !converter.ValidProfile(profile) {
+		return nil, fmt.Errorf("profile must be %s or %s", converter.ProfileGuitar, converter.ProfilePiano)
+	}
+	conv = conv.WithProfile(profile)
+
+	if bassHints == "" {
+		return conv, nil
+	}
+	enabled, err := strconv.ParseBool(bassHints)
+	if err != nil {
+		return nil, fmt.Errorf("bass_hints must be true or false")
+	}
+	return conv.WithBassHints(enabled), nil
+}
+
+// withRemoveCapo applies an optional per-request remove_capo switch ("true"
+// or "false"); an empty value keeps the add-on configuration
+func withRemoveCapo(conv *converter.OnSongConverter, value string) (*converter.OnSongConverter, error) {
+	if value == "" {
+		return conv, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("remove_capo must be true or false")
+	}
+	return conv.WithRemoveCapo(enabled), nil
+}
+
+// sectionLanguage validates an optional per-request language for exported
+// section names; an empty value keeps the add-on configuration
+func sectionLanguage(value, fallback string) (string, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	lang, ok := converter.ParseSectionLanguage(value)
+	if !ok {
+		return "", fmt.Errorf("language must be one of %s", strings.Join(converter.SectionLanguages(), ", "))
+	}
+	return lang, nil
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/profiles"
+)
+
+// ProfileHandler manages user profiles and the selected profile's
+// favorites and history
+type ProfileHandler struct {
+	profiles *profiles.Store
+	library  *library.Store
+	audit    *auth.AuditLog
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(store *profiles.Store, libraryStore *library.Store, audit *auth.AuditLog) *ProfileHandler {
+	return &ProfileHandler{
+		profiles: store,
+		library:  libraryStore,
+		audit:    audit,
+	}
+}
+
+// List returns every profile without its history
+func (h *ProfileHandler) List(c *fiber.Ctx) error {
+	list := h.profiles.List()
+
+	views := make([]fiber.Map, len(list))
+	for i := range list {
+		views[i] = profileView(&list[i])
+	}
+	return c.JSON(views)
+}
+
+// Create adds a profile. Body: { "name", "library_tags": ["anna"] }. Name
+// it like a login or Home Assistant user to select it when they sign in.
+func (h *ProfileHandler) Create(c *fiber.Ctx) error {
+	var req struct {
+		Name        string   `json:"name"`
+		LibraryTags []string `json:"library_tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	profile, err := h.profiles.Create(req.Name, req.LibraryTags)
+	if err != nil {
+		return profileError(c, err)
+	}
+
+	fmt.Printf("👤 Profile created: %s\n", profile.Name)
+	recordAction(h.audit, c, auth.EventProfileCreated, profile.Name)
+	return c.Status(fiber.StatusCreated).JSON(profileView(profile))
+}
+
+// Delete removes a profile with its webhook, favorites and history
+func (h *ProfileHandler) Delete(c *fiber.Ctx) error {
+	if err := h.profiles.Delete(c.Params("name")); err != nil {
+		return profileError(c, err)
+	}
+
+	fmt.Printf("👤 Profile deleted: %s\n", c.Params("name"))
+	recordAction(h.audit, c, auth.EventProfileDeleted, c.Params("name"))
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// Current returns the profile the request acts for
+func (h *ProfileHandler) Current(c *fiber.Ctx) error {
+	profile := requestProfile(c)
+	if profile == nil {
+		return noProfile(c)
+	}
+	return c.JSON(profileView(profile))
+}
+
+// Update changes the selected profile's library scope.
+// Body: { "library_tags": ["anna", "duets"] }, empty for the whole library.
+func (h *ProfileHandler) Update(c *fiber.Ctx) error {
+	current := requestProfile(c)
+	if current == nil {
+		return noProfile(c)
+	}
+
+	var req struct {
+		LibraryTags []string `json:"library_tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	profile, err := h.profiles.SetLibraryTags(current.Name, req.LibraryTags)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(profileView(profile))
+}
+
+// Favorites lists the selected profile's favorite songs, most recently
+// added first
+func (h *ProfileHandler) Favorites(c *fiber.Ctx) error {
+	profile := requestProfile(c)
+	if profile == nil {
+		return noProfile(c)
+	}
+
+	songs := make([]fiber.Map, 0, len(profile.Favorites))
+	for _, id := range profile.Favorites {
+		if song, ok := h.library.Get(id); ok {
+			songs = append(songs, songSummary(*song))
+		}
+	}
+	return c.JSON(songs)
+}
+
+// AddFavorite marks a library song as a favorite of the selected profile
+func (h *ProfileHandler) AddFavorite(c *fiber.Ctx) error {
+	profile := requestProfile(c)
+	if profile == nil {
+		return noProfile(c)
+	}
+
+	song, ok := h.library.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+
+	if err := h.profiles.AddFavorite(profile.Name, song.ID); err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// RemoveFavorite unmarks a favorite of the selected profile
+func (h *ProfileHandler) RemoveFavorite(c *fiber.Ctx) error {
+	profile := requestProfile(c)
+	if profile == nil {
+		return noProfile(c)
+	}
+
+	if err := h.profiles.RemoveFavorite(profile.Name, c.Params("id")); err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// History lists the tabs the selected profile viewed and sent, newest
+// first. Query: ?limit=50
+func (h *ProfileHandler) History(c *fiber.Ctx) error {
+	profile := requestProfile(c)
+	if profile == nil {
+		return noProfile(c)
+	}
+
+	limit := c.QueryInt("limit", 50)
+	if limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid limit",
+			"details": "limit must be a non-negative number",
+		})
+	}
+	history := profile.History
+	if limit > 0 && len(history) > limit {
+		history = history[:limit]
+	}
+	return c.JSON(history)
+}
+
+// ClearHistory empties the selected profile's history
+func (h *ProfileHandler) ClearHistory(c *fiber.Ctx) error {
+	profile := requestProfile(c)
+	if profile == nil {
+		return noProfile(c)
+	}
+
+	if err := h.profiles.ClearHistory(profile.Name); err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// requestProfile returns the profile selected for the request, or nil
+func requestProfile(c *fiber.Ctx) *profiles.Profile {
+	profile, _ := c.Locals("profile").(*profiles.Profile)
+	return profile
+}
+
+// requestProfileName names the profile selected for the request, or ""
+func requestProfileName(c *fiber.Ctx) string {
+	if profile := requestProfile(c); profile != nil {
+		return profile.Name
+	}
+	return ""
+}
+
+// inProfileScope reports whether a song is in the selected profile's part
+// of the library; without a profile every song is
+func inProfileScope(c *fiber.Ctx, song *library.Song) bool {
+	profile := requestProfile(c)
+	return profile == nil || profile.InScope(song.Tags)
+}
+
+// profileView returns a profile without its history and webhook secrets
+func profileView(profile *profiles.Profile) fiber.Map {
+	return fiber.Map{
+		"name":               profile.Name,
+		"library_tags":       profile.LibraryTags,
+		"favorites":          len(profile.Favorites),
+		"history":            len(profile.History),
+		"webhook_configured": profile.Webhook != nil && profile.Webhook.URL != "",
+		"created_at":         profile.CreatedAt,
+		"updated_at":         profile.UpdatedAt,
+	}
+}
+
+// noProfile answers requests that need a profile but have none
+func noProfile(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":   "no profile selected",
+		"details": "send the X-Profile header or log in as a user with a profile",
+	})
+}
+
+// profileError maps a profile store error to a response
+func profileError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, profiles.ErrNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, profiles.ErrExists):
+		status = fiber.StatusConflict
+	case errors.Is(err, profiles.ErrInvalidName), errors.Is(err, profiles.ErrInvalidTags):
+		status = fiber.StatusBadRequest
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
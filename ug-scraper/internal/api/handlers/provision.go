@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/provision"
+)
+
+// ProvisionHandler applies declarative configuration documents
+type ProvisionHandler struct {
+	reconciler *provision.Reconciler
+	audit      *auth.AuditLog
+}
+
+// NewProvisionHandler creates a new provisioning handler
+func NewProvisionHandler(reconciler *provision.Reconciler, audit *auth.AuditLog) *ProvisionHandler {
+	return &ProvisionHandler{
+		reconciler: reconciler,
+		audit:      audit,
+	}
+}
+
+// Apply reconciles the configuration with the document in the body (JSON,
+// or YAML with a yaml Content-Type) and returns the change report; applying
+// the same document again changes nothing. ?dry_run=true only reports what
+// would change. Invalid documents answer 422 without changing anything.
+func (h *ProvisionHandler) Apply(c *fiber.Ctx) error {
+	yamlBody := strings.Contains(c.Get(fiber.HeaderContentType), "yaml")
+	doc, err := provision.Parse(c.Body(), yamlBody)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := doc.Validate(); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "invalid provisioning document",
+			"details": strings.Split(err.Error(), "\n"),
+		})
+	}
+
+	report, err := h.reconciler.Reconcile(doc, c.QueryBool("dry_run", false))
+	if report.Changed && !report.DryRun {
+		h.record(c, report)
+	}
+	if err != nil {
+		fmt.Printf("❌ Provisioning failed: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "provisioning failed",
+			"details": err.Error(),
+			"report":  report,
+		})
+	}
+
+	if report.DryRun {
+		fmt.Printf("📋 Provisioning dry run: %d changes\n", len(report.Changes))
+	} else if report.Changed {
+		fmt.Printf("📋 Provisioned: %d changes\n", len(report.Changes))
+	}
+	return c.JSON(report)
+}
+
+// record adds an applied provisioning run to the audit log
+func (h *ProvisionHandler) record(c *fiber.Ctx, report *provision.Report) {
+	changes := make([]string, len(report.Changes))
+	for i, change := range report.Changes {
+		changes[i] = change.Section + " " + change.Action + " " + change.Name
+	}
+	h.audit.Record(auth.AuditEvent{
+		Type:    auth.EventProvisioned,
+		IP:      c.IP(),
+		Actor:   requestActor(c),
+		Details: strings.Join(changes, "; "),
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// ResolveHandler resolves UG URLs and slugs to tabs
+type ResolveHandler struct {
+	resolver *scraper.Resolver
+}
+
+// NewResolveHandler creates a new resolve handler
+func NewResolveHandler(resolver *scraper.Resolver) *ResolveHandler {
+	return &ResolveHandler{
+		resolver: resolver,
+	}
+}
+
+// Handle resolves ?url= (a tab URL, slug, shortened link or tab ID) to its
+// canonical tab. The tab is fetched to confirm artist, title and type unless
+// ?verify=false.
+func (h *ResolveHandler) Handle(c *fiber.Ctx) error {
+	input := strings.TrimSpace(c.Query("url"))
+	if input == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "url parameter is required",
+		})
+	}
+
+	resolution, err := h.resolver.Resolve(c.UserContext(), input, c.QueryBool("verify", true))
+	if errors.Is(err, scraper.ErrNotUGReference) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "not a tab reference",
+			"details": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   "could not resolve tab",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(resolution)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// anyRevision is returned by ifMatchRevision for "If-Match: *"
+const anyRevision = -1
+
+// revisionTag formats a revision as an entity tag
+func revisionTag(revision int) string {
+	return fmt.Sprintf(`"%d"`, revision)
+}
+
+// ifMatchRevision reads the revision from the If-Match header. Updates must
+// send the revision they were based on; "*" explicitly overwrites whatever
+// is stored.
+func ifMatchRevision(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return 0, fmt.Errorf("If-Match header with the current revision is required")
+	}
+	if header == "*" {
+		return anyRevision, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	revision, err := strconv.Atoi(tag)
+	if err != nil || revision < 0 {
+		return 0, fmt.Errorf("If-Match must be a revision such as %s", revisionTag(3))
+	}
+
+	return revision, nil
+}
+
+// preconditionRequired writes a 428 response for a missing or malformed If-Match
+func preconditionRequired(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{
+		"error":   "revision required",
+		"details": err.Error(),
+	})
+}
+
+// revisionConflict writes a 409 response carrying the current revision and entry
+func revisionConflict(c *fiber.Ctx, revision int, current interface{}) error {
+	c.Set(fiber.HeaderETag, revisionTag(revision))
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":            "revision conflict",
+		"details":          "the entry was changed since it was loaded; reapply your edits to the current revision",
+		"current_revision": revision,
+		"current":          current,
+	})
+}
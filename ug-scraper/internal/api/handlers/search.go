@@ -2,20 +2,27 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
 )
 
 // SearchHandler handles tab search requests
 type SearchHandler struct {
 	searchScraper *scraper.SearchScraper
+	events        *events.Dispatcher
 }
 
 // NewSearchHandler creates a new search handler
-func NewSearchHandler(searchScraper *scraper.SearchScraper) *SearchHandler {
+func NewSearchHandler(searchScraper *scraper.SearchScraper, dispatcher *events.Dispatcher) *SearchHandler {
 	return &SearchHandler{
 		searchScraper: searchScraper,
+		events:        dispatcher,
 	}
 }
 
@@ -32,20 +39,99 @@ func (h *SearchHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
-	tabType := c.Query("type", "")
-	difficulty := c.Query("difficulty", "")
+	// type accepts any spelling of chords|tab|guitar_pro|bass|ukulele|drums|power|official|video,
+	// or several separated by commas
+	types, bad, ok := scraper.ParseTabTypes(c.Query("type", ""))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fmt.Sprintf("unknown tab type %q", bad),
+			"details": "accepted values: " + scraper.TabTypeList(),
+		})
+	}
+	var difficulty string
+	if raw := c.Query("difficulty", ""); raw != "" {
+		var ok bool
+		if difficulty, ok = scraper.ParseDifficulty(raw); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fmt.Sprintf("unknown difficulty %q", raw),
+				"details": "accepted values: " + scraper.DifficultyList(),
+			})
+		}
+	}
+
+	minRating, err := strconv.ParseFloat(c.Query("min_rating", "0"), 64)
+	if err != nil || minRating < 0 || minRating > 5 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "min_rating must be a number from 0 to 5",
+		})
+	}
+	minVotes, err := strconv.Atoi(c.Query("min_votes", "0"))
+	if err != nil || minVotes < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "min_votes must be a non-negative integer",
+		})
+	}
+	tuning := strings.ToLower(c.Query("tuning", ""))
+	if tuning != "" && tuning != "standard" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "tuning only accepts \"standard\"",
+		})
+	}
 
-	fmt.Printf("\n🎸 Search Request: q=%q type=%s difficulty=%s\n", query, tabType, difficulty)
+	// filter picks top (one result per artist), chords or none (every result)
+	filter, ok := scraper.ParseResultFilter(c.Query("filter", ""))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fmt.Sprintf("unknown filter %q", c.Query("filter")),
+			"details": "accepted values: " + scraper.ResultFilterList(),
+		})
+	}
+
+	// sort orders the results by relevance (UG's order), rating, votes or newest
+	sort, ok := scraper.ParseSort(c.Query("sort", ""))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fmt.Sprintf("unknown sort %q", c.Query("sort")),
+			"details": "accepted values: " + scraper.SortList(),
+		})
+	}
 
 	opts := scraper.SearchOptions{
-		Query:      query,
-		Type:       tabType,
-		Difficulty: difficulty,
+		Query:          query,
+		Types:          types,
+		Difficulty:     difficulty,
+		MinRating:      minRating,
+		MinVotes:       minVotes,
+		StandardTuning: tuning == "standard",
+		Part:           c.Query("part", ""),
+		Filter:         filter,
+		Sort:           sort,
 	}
 
-	results, err := h.searchScraper.SearchTabs(opts)
+	fmt.Printf("\n🎸 Search Request: q=%q type=%v difficulty=%s\n", query, types, difficulty)
+
+	// debug=true wraps the results with the solver routing of every page fetch
+	ctx := c.UserContext()
+	var trace *scraper.Trace
+	if c.QueryBool("debug") {
+		ctx, trace = scraper.WithTrace(ctx)
+	}
+
+	results, err := h.searchScraper.SearchTabs(ctx, opts)
 	if err != nil {
 		fmt.Printf("❌ Search failed: %v\n", err)
+		h.events.PublishEvent(events.ScrapeFailed, fiber.Map{
+			"operation": "search",
+			"query":     utils.CopyString(query),
+			"error":     err.Error(),
+		})
+		if trace != nil {
+			return c.JSON(fiber.Map{
+				"results": []fiber.Map{},
+				"error":   err.Error(),
+				"trace":   trace.Fetches(),
+			})
+		}
 		// Return empty array instead of error (UG blocks automated search)
 		// Frontend can handle empty results gracefully
 		return c.JSON([]fiber.Map{})
@@ -63,10 +149,64 @@ func (h *SearchHandler) Handle(c *fiber.Ctx) error {
 			"rating":     r.Rating,
 			"votes":      r.Votes,
 			"difficulty": r.Difficulty,
+			"part":       r.Part,
+			"tuning":     r.Tuning,
+			"date":       r.Date,
 			"url":        r.URL,
+			"locale":     r.Locale,
 		}
 	}
 
 	fmt.Printf("✅ Returning %d results\n\n", len(formattedResults))
+	if trace != nil {
+		return c.JSON(fiber.Map{
+			"results": formattedResults,
+			"trace":   trace.Fetches(),
+		})
+	}
 	return c.JSON(formattedResults)
 }
+
+// ArtistTabs returns every tab on an artist's UG page, walking all of its
+// pages. :name is an artist name or artist page URL; ?type= filters by type.
+func (h *SearchHandler) ArtistTabs(c *fiber.Ctx) error {
+	// PathUnescape hands back the request's own memory when nothing is
+	// escaped, and the name outlives the request in failure events
+	name, err := url.PathUnescape(c.Params("name"))
+	name = utils.CopyString(name)
+	if err != nil || strings.TrimSpace(name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "artist name is required",
+		})
+	}
+
+	var tabType scraper.TabType
+	if raw := c.Query("type", ""); raw != "" {
+		var ok bool
+		if tabType, ok = scraper.ParseTabType(raw); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fmt.Sprintf("unknown tab type %q", raw),
+				"details": "accepted values: " + scraper.TabTypeList(),
+			})
+		}
+	}
+
+	fmt.Printf("\n🎸 Artist Request: %q type=%s\n", name, tabType)
+
+	disco, err := h.searchScraper.ArtistTabs(c.UserContext(), name, tabType)
+	if err != nil {
+		fmt.Printf("❌ Artist lookup failed: %v\n", err)
+		h.events.PublishEvent(events.ScrapeFailed, fiber.Map{
+			"operation": "artist",
+			"artist":    name,
+			"error":     err.Error(),
+		})
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   "failed to fetch artist tabs",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("✅ Returning %d tabs by %s from %d pages\n\n", disco.Total, disco.Artist, disco.Pages)
+	return c.JSON(disco)
+}
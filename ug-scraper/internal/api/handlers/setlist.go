@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/files"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// SetlistHandler handles setlist management and export
+type SetlistHandler struct {
+	store    *library.Store
+	files    *files.Store
+	spelling string
+	language string
+	audit    *auth.AuditLog
+}
+
+// NewSetlistHandler creates a new setlist handler. spelling is the default
+// chord spelling for transposed charts (auto, sharps or flats), language the
+// default language of section names.
+func NewSetlistHandler(store *library.Store, exports *files.Store, spelling, language string, audit *auth.AuditLog) *SetlistHandler {
+	return &SetlistHandler{
+		store:    store,
+		files:    exports,
+		spelling: spelling,
+		language: language,
+		audit:    audit,
+	}
+}
+
+// List returns all setlists
+func (h *SetlistHandler) List(c *fiber.Ctx) error {
+	return c.JSON(h.store.ListSetlists())
+}
+
+// Get returns a single setlist
+func (h *SetlistHandler) Get(c *fiber.Ctx) error {
+	setlist, ok := h.store.GetSetlist(c.Params("id"))
+	if !ok {
+		return setlistNotFound(c)
+	}
+
+	c.Set(fiber.HeaderETag, revisionTag(setlist.Revision))
+	return c.JSON(setlist)
+}
+
+// Create adds a new setlist
+func (h *SetlistHandler) Create(c *fiber.Ctx) error {
+	var setlist library.Setlist
+	if err := c.BodyParser(&setlist); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	setlist.ID = ""
+	return h.save(c, &setlist, fiber.StatusCreated)
+}
+
+// Update replaces an existing setlist. The If-Match header must carry the
+// revision the edit was based on; stale writes get 409 with the current setlist.
+func (h *SetlistHandler) Update(c *fiber.Ctx) error {
+	if _, ok := h.store.GetSetlist(c.Params("id")); !ok {
+		return setlistNotFound(c)
+	}
+
+	revision, err := ifMatchRevision(c)
+	if err != nil {
+		return preconditionRequired(c, err)
+	}
+
+	var setlist library.Setlist
+	if err := c.BodyParser(&setlist); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	setlist.ID = utils.CopyString(c.Params("id"))
+	if revision == anyRevision {
+		return h.save(c, &setlist, fiber.StatusOK)
+	}
+
+	if err := h.store.SaveSetlistIfMatch(&setlist, revision); err != nil {
+		if err == library.ErrConflict {
+			// The setlist may have been deleted since it was read
+			current, ok := h.store.GetSetlist(setlist.ID)
+			if !ok {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "setlist not found",
+				})
+			}
+			return revisionConflict(c, current.Revision, current)
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid setlist",
+			"details": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderETag, revisionTag(setlist.Revision))
+	return c.JSON(setlist)
+}
+
+// Delete removes a setlist
+func (h *SetlistHandler) Delete(c *fiber.Ctx) error {
+	deleted := c.Params("id")
+	if setlist, ok := h.store.GetSetlist(deleted); ok {
+		deleted = setlist.Name
+	}
+	if err := h.store.DeleteSetlist(c.Params("id")); err != nil {
+		if err == library.ErrNotFound {
+			return setlistNotFound(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to delete setlist",
+			"details": err.Error(),
+		})
+	}
+
+	recordAction(h.audit, c, auth.EventSetlistDeleted, deleted)
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// AddSongs appends songs to a setlist: the ones listed and every library song
+// carrying all of the given tags, in library order. Songs already in the
+// setlist are skipped; added songs equivalent to one already in it (the
+// same song under another spelling) are listed in X-Duplicate-Songs.
+// Body: { "song_ids": ["..."], "tags": ["christmas"], "key": "G" }
+func (h *SetlistHandler) AddSongs(c *fiber.Ctx) error {
+	setlist, ok := h.store.GetSetlist(c.Params("id"))
+	if !ok {
+		return setlistNotFound(c)
+	}
+
+	var req struct {
+		SongIDs []string `json:"song_ids"`
+		Tags    []string `json:"tags"`
+		Key     string   `json:"key"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	tags, err := library.NormalizeTags(req.Tags)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid tags",
+			"details": err.Error(),
+		})
+	}
+	if len(req.SongIDs) == 0 && len(tags) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "song_ids or tags is required",
+		})
+	}
+
+	ids := make([]string, 0, len(req.SongIDs))
+	for _, id := range req.SongIDs {
+		if _, ok := h.store.Get(id); !ok {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("song %q not found", id),
+			})
+		}
+		ids = append(ids, id)
+	}
+	if len(tags) > 0 {
+		for _, song := range h.store.ListTagged(tags...) {
+			ids = append(ids, song.ID)
+		}
+	}
+
+	present := make(map[string]bool, len(setlist.Items))
+	var songs []*library.Song
+	for _, item := range setlist.Items {
+		present[item.SongID] = true
+		if song, ok := h.store.Get(item.SongID); ok {
+			songs = append(songs, song)
+		}
+	}
+	added := 0
+	var duplicates []string
+	for _, id := range ids {
+		if present[id] {
+			continue
+		}
+		present[id] = true
+		setlist.Items = append(setlist.Items, library.SetlistItem{SongID: id, Key: req.Key})
+		added++
+
+		song, ok := h.store.Get(id)
+		if !ok {
+			continue
+		}
+		for _, other := range songs {
+			if library.Equivalent(song, other) {
+				duplicates = append(duplicates, id)
+				break
+			}
+		}
+		songs = append(songs, song)
+	}
+	if added == 0 {
+		c.Set(fiber.HeaderETag, revisionTag(setlist.Revision))
+		return c.JSON(setlist)
+	}
+
+	fmt.Printf("🎶 Added %d songs to setlist %q\n", added, setlist.Name)
+	if len(duplicates) > 0 {
+		fmt.Printf("⚠️  Setlist %q now holds look-alikes of songs already in it: %s\n", setlist.Name, strings.Join(duplicates, ", "))
+		c.Set("X-Duplicate-Songs", strings.Join(duplicates, ", "))
+	}
+	return h.save(c, setlist, fiber.StatusOK)
+}
+
+// CreateMedley groups a run of consecutive setlist items into a medley.
+// Body: { "name", "target_key", "notes", "start": 1, "end": 3, "segues": ["..."] }
+// where start/end are 1-based item positions and segues[i] is the transition
+// out of the i-th song of the medley.
+func (h *SetlistHandler) CreateMedley(c *fiber.Ctx) error {
+	setlist, ok := h.store.GetSetlist(c.Params("id"))
+	if !ok {
+		return setlistNotFound(c)
+	}
+
+	var req struct {
+		Name      string   `json:"name"`
+		TargetKey string   `json:"target_key"`
+		Notes     string   `json:"notes"`
+		Start     int      `json:"start"`
+		End       int      `json:"end"`
+		Segues    []string `json:"segues"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if req.Start < 1 || req.End <= req.Start || req.End > len(setlist.Items) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("start and end must select at least two of the %d items", len(setlist.Items)),
+		})
+	}
+
+	for i := req.Start - 1; i < req.End; i++ {
+		if setlist.Items[i].MedleyID != "" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": fmt.Sprintf("item %d is already part of a medley", i+1),
+			})
+		}
+	}
+
+	medley := library.Medley{
+		ID:        h.store.NewMedleyID(),
+		Name:      req.Name,
+		TargetKey: req.TargetKey,
+		Notes:     req.Notes,
+	}
+	setlist.Medleys = append(setlist.Medleys, medley)
+
+	for i := req.Start - 1; i < req.End; i++ {
+		setlist.Items[i].MedleyID = medley.ID
+		if n := i - (req.Start - 1); n < len(req.Segues) {
+			setlist.Items[i].SegueNote = req.Segues[n]
+		}
+	}
+
+	return h.save(c, setlist, fiber.StatusCreated)
+}
+
+// DeleteMedley ungroups a medley, keeping its songs in the setlist
+func (h *SetlistHandler) DeleteMedley(c *fiber.Ctx) error {
+	setlist, ok := h.store.GetSetlist(c.Params("id"))
+	if !ok {
+		return setlistNotFound(c)
+	}
+
+	medleyID := c.Params("medleyId")
+	medleys := setlist.Medleys[:0]
+	found := false
+	for _, m := range setlist.Medleys {
+		if m.ID == medleyID {
+			found = true
+			continue
+		}
+		medleys = append(medleys, m)
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "medley not found",
+		})
+	}
+	setlist.Medleys = medleys
+
+	for i := range setlist.Items {
+		if setlist.Items[i].MedleyID == medleyID {
+			setlist.Items[i].MedleyID = ""
+			setlist.Items[i].SegueNote = ""
+		}
+	}
+
+	return h.save(c, setlist, fiber.StatusOK)
+}
+
+// Pages returns the setlist split into pages for paged display; songs in a
+// medley share a page. Query: spelling=auto|sharps|flats, language=<code>
+// for section names (default from the add-on configuration)
+func (h *SetlistHandler) Pages(c *fiber.Ctx) error {
+	setlist, ok := h.store.GetSetlist(c.Params("id"))
+	if !ok {
+		return setlistNotFound(c)
+	}
+
+	spelling := c.Query("spelling", h.spelling)
+	if err := checkConversionOptions("", spelling); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+	language, err := sectionLanguage(c.Query("language"), h.language)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+
+	pages, missing := export.PaginateSetlist(setlist, h.store, spelling, language)
+	return c.JSON(fiber.Map{
+		"id":            setlist.ID,
+		"name":          setlist.Name,
+		"pages":         pages,
+		"missing_songs": missing,
+	})
+}
+
+// Export returns the whole setlist as a single ChordPro/OnSong text document.
+// Query: destination=<label> naming the device or person the export is for,
+// spelling=auto|sharps|flats, language=<code> for section names, save=true to
+// keep the document in the exports directory (answering 201 with the file)
+// instead of downloading it. A delivery manifest is recorded and its ID
+// returned in the X-Manifest-ID header.
+func (h *SetlistHandler) Export(c *fiber.Ctx) error {
+	setlist, ok := h.store.GetSetlist(c.Params("id"))
+	if !ok {
+		return setlistNotFound(c)
+	}
+
+	spelling := c.Query("spelling", h.spelling)
+	if err := checkConversionOptions("", spelling); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+	language, err := sectionLanguage(c.Query("language"), h.language)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+
+	pages, _ := export.PaginateSetlist(setlist, h.store, spelling, language)
+	filename := export.SanitizeFilename(setlist.Name) + ".txt"
+	document := export.RenderSetlistText(setlist, pages)
+
+	// The manifest lists the document itself plus each chart as rendered into it
+	manifest := &library.Manifest{
+		Kind:         library.ManifestSetlistExport,
+		Reference:    setlist.ID,
+		Name:         filename,
+		Format:       "onsong",
+		Destinations: []string{utils.CopyString(c.Query("destination", "download"))},
+		Files:        []library.ManifestFile{export.ManifestEntry(filename, nil, document)},
+	}
+	for _, page := range pages {
+		for _, entry := range page.Songs {
+			song, _ := h.store.Get(entry.SongID)
+			name := fmt.Sprintf("%s#%d", filename, entry.Position)
+			manifest.Files = append(manifest.Files, export.ManifestEntry(name, song, entry.Content))
+		}
+	}
+	if err := h.store.AddManifest(manifest); err != nil {
+		fmt.Printf("⚠️  Failed to record setlist manifest: %v\n", err)
+	} else {
+		c.Set("X-Manifest-ID", manifest.ID)
+	}
+
+	if c.QueryBool("save") {
+		return saveExport(c, h.files, filename, []byte(document))
+	}
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Type("txt", "utf-8")
+	return c.SendString(document)
+}
+
+// save validates and stores a setlist, translating store errors to responses
+func (h *SetlistHandler) save(c *fiber.Ctx, setlist *library.Setlist, status int) error {
+	if err := h.store.SaveSetlist(setlist); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid setlist",
+			"details": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderETag, revisionTag(setlist.Revision))
+	return c.Status(status).JSON(setlist)
+}
+
+// setlistNotFound writes a 404 response for a missing setlist
+func setlistNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": "setlist not found",
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+)
+
+// SettingsHandler manages the stored per-format export defaults
+type SettingsHandler struct {
+	formats *config.FormatStore
+	audit   *auth.AuditLog
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(formats *config.FormatStore, audit *auth.AuditLog) *SettingsHandler {
+	return &SettingsHandler{
+		formats: formats,
+		audit:   audit,
+	}
+}
+
+// ListFormats returns the defaults of every export format
+func (h *SettingsHandler) ListFormats(c *fiber.Ctx) error {
+	return c.JSON(h.formats.Defaults())
+}
+
+// GetFormat returns the defaults of one export format
+func (h *SettingsHandler) GetFormat(c *fiber.Ctx) error {
+	format := c.Params("format")
+
+	options, err := h.formats.Get(format)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   "unknown format",
+			"details": err.Error(),
+			"formats": config.Formats(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"format":  format,
+		"options": options,
+	})
+}
+
+// UpdateFormat changes the defaults of one export format. The body is a
+// JSON object of the options to change; the others keep their value.
+func (h *SettingsHandler) UpdateFormat(c *fiber.Ctx) error {
+	format := c.Params("format")
+
+	options, err := h.formats.Update(format, c.Body())
+	if errors.Is(err, config.ErrUnknownFormat) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   "unknown format",
+			"details": err.Error(),
+			"formats": config.Formats(),
+		})
+	}
+	if errors.Is(err, config.ErrInvalidOptions) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid format settings",
+			"details": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to save format settings",
+			"details": err.Error(),
+		})
+	}
+
+	var changed bytes.Buffer
+	json.Compact(&changed, c.Body())
+	recordAction(h.audit, c, auth.EventSettingsChanged, format+": "+changed.String())
+
+	return c.JSON(fiber.Map{
+		"format":  format,
+		"options": options,
+	})
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"bytes"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/bandwidth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// StatsHandler handles usage statistics
+type StatsHandler struct {
+	meter         *bandwidth.Meter
+	searchScraper *scraper.SearchScraper
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(meter *bandwidth.Meter, searchScraper *scraper.SearchScraper) *StatsHandler {
+	return &StatsHandler{
+		meter:         meter,
+		searchScraper: searchScraper,
+	}
+}
+
+// Stats returns the bytes downloaded from each upstream today, on earlier
+// days and during recent import jobs, with the daily cap, and the
+// FlareSolverr status
+func (h *StatsHandler) Stats(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"bandwidth":    h.meter.Stats(),
+		"flaresolverr": h.searchScraper.FlareSolverrStatus(),
+	})
+}
+
+// Metrics returns the same counters for Prometheus to scrape
+func (h *StatsHandler) Metrics(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	h.meter.WritePrometheus(&buf)
+	h.searchScraper.WriteFlareSolverrPrometheus(&buf)
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// minSuggestPrefix is the shortest prefix sent upstream
+const minSuggestPrefix = 2
+
+// SuggestHandler handles search autocomplete requests
+type SuggestHandler struct {
+	cache *scraper.SuggestCache
+}
+
+// NewSuggestHandler creates a new suggest handler
+func NewSuggestHandler(cache *scraper.SuggestCache) *SuggestHandler {
+	return &SuggestHandler{
+		cache: cache,
+	}
+}
+
+// Handle returns autocomplete suggestions for ?q=<prefix>. Answers come from
+// an in-memory cache; prefixes shorter than two characters get no suggestions.
+func (h *SuggestHandler) Handle(c *fiber.Ctx) error {
+	// The prefix outlives the request as a cache key and in the refresh
+	// goroutine, so it must not share the request buffer
+	prefix := utils.CopyString(scraper.NormalizeSuggestPrefix(c.Query("q")))
+	if len([]rune(prefix)) < minSuggestPrefix {
+		return c.JSON(scraper.SuggestResult{Suggestions: []string{}})
+	}
+
+	result, err := h.cache.Get(c.UserContext(), prefix)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to fetch suggestions",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
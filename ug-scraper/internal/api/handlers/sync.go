@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+var sha256HexRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// SyncHandler serves the content-hash sync protocol used by companion apps
+// to mirror the library
+type SyncHandler struct {
+	store    *library.Store
+	formats  *config.FormatStore
+	language string
+}
+
+// NewSyncHandler creates a new sync handler. Synced charts name their
+// sections in language.
+func NewSyncHandler(store *library.Store, formats *config.FormatStore, language string) *SyncHandler {
+	return &SyncHandler{
+		store:    store,
+		formats:  formats,
+		language: language,
+	}
+}
+
+// Manifest lists every chart with its content hash. Clients compare hashes
+// with their local copies and fetch only changed charts from /sync/blob.
+// The revision doubles as an ETag, so polling with If-None-Match returns
+// 304 when nothing changed. Query: format=onsong (default) or chordpro,
+// ChordPro charts using the stored directive style and environments.
+func (h *SyncHandler) Manifest(c *fiber.Ctx) error {
+	format := c.Query("format", export.ArchiveOnSong)
+	manifest, _, err := h.build(format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid format",
+			"details": err.Error(),
+		})
+	}
+
+	if notModified(c, fmt.Sprintf(`"%s"`, manifest.Revision)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.JSON(manifest)
+}
+
+// Blob returns the chart content with the given SHA-256 hash.
+// Query: format must match the format the manifest was requested in.
+func (h *SyncHandler) Blob(c *fiber.Ctx) error {
+	hash := c.Params("hash")
+	if !sha256HexRegex.MatchString(hash) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "hash must be a lowercase hex SHA-256",
+		})
+	}
+
+	_, blobs, err := h.build(c.Query("format", export.ArchiveOnSong))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid format",
+			"details": err.Error(),
+		})
+	}
+
+	content, ok := blobs[hash]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "no chart with this hash; refresh the sync manifest",
+		})
+	}
+
+	// Content is addressed by its hash, so it never changes
+	c.Set(fiber.HeaderETag, fmt.Sprintf(`"%s"`, hash))
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	c.Type("txt", "utf-8")
+	return c.SendString(content)
+}
+
+// build renders the library in format and hashes every chart. Changing the
+// stored ChordPro settings changes the hashes, so devices resync.
+func (h *SyncHandler) build(format string) (*export.SyncManifest, map[string]string, error) {
+	return export.BuildSyncManifest(h.store.List(), format, h.formats.Defaults().ChordPro.Options(), h.language)
+}
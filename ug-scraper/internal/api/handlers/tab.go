@@ -1,24 +1,52 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/files"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/profiles"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/sharefolder"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/tuning"
 )
 
 // TabHandler handles tab fetch requests
 type TabHandler struct {
 	ugClient  *scraper.UGClient
 	converter *converter.OnSongConverter
+	events    *events.Dispatcher
+	share     *sharefolder.Writer
+	formats   *config.FormatStore
+	resolver  *scraper.Resolver
+	files     *files.Store
+	profiles  *profiles.Store
+	renders   tuning.Limiter
+	output    chartOutput
 }
 
-// NewTabHandler creates a new tab handler
-func NewTabHandler(ugClient *scraper.UGClient, conv *converter.OnSongConverter) *TabHandler {
+// NewTabHandler creates a new tab handler. renders bounds how many PDFs are
+// rendered at once.
+func NewTabHandler(ugClient *scraper.UGClient, conv *converter.OnSongConverter, dispatcher *events.Dispatcher, share *sharefolder.Writer, formats *config.FormatStore, resolver *scraper.Resolver, exports *files.Store, profileStore *profiles.Store, renders tuning.Limiter) *TabHandler {
 	return &TabHandler{
 		ugClient:  ugClient,
 		converter: conv,
+		events:    dispatcher,
+		share:     share,
+		formats:   formats,
+		resolver:  resolver,
+		files:     exports,
+		profiles:  profileStore,
+		renders:   renders,
+		output:    chartOutput{formats: formats, renders: renders},
 	}
 }
 
@@ -31,12 +59,129 @@ func (h *TabHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
+	conv, err := h.queryConverter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+
+	return h.respond(c, tabID, conv, nil)
+}
+
+// queryConverter applies the conversion options of the query string:
+// ?key=<key> overrides the key of the chords as written,
+// ?key_header=shape|sounding picks the key for the Key: header,
+// ?spelling=auto|sharps|flats the chord accidentals,
+// ?chord_style=keep|standard|compact|jazz the chord qualities and
+// ?auto_sections=true|false whether bare charts get section labels,
+// ?profile=guitar|piano the instrument, ?bass_hints=true|false
+// whether piano charts show left-hand bass notes, ?remove_capo=true|false
+// whether capo charts are moved to sounding pitch and ?bass_lines=true
+// adds suggested bass lines
+func (h *TabHandler) queryConverter(c *fiber.Ctx) (*converter.OnSongConverter, error) {
+	keyHeader, spelling := c.Query("key_header"), c.Query("spelling")
+	if err := checkConversionOptions(keyHeader, spelling); err != nil {
+		return nil, err
+	}
+	conv, err := withAutoSections(h.converter.WithKeyHeader(keyHeader).WithSpelling(spelling), c.Query("auto_sections"))
+	if err == nil {
+		conv, err = withChordStyle(conv, c.Query("chord_style"))
+	}
+	if err == nil {
+		conv, err = withKey(conv, c.Query("key"))
+	}
+	if err == nil {
+		conv, err = withProfile(conv, c.Query("profile"), c.Query("bass_hints"))
+	}
+	if err == nil {
+		conv, err = withRemoveCapo(conv, c.Query("remove_capo"))
+	}
+	if err == nil && c.QueryBool("bass_lines") {
+		conv = conv.WithBassLines(true)
+	}
+	return conv, err
+}
+
+// FetchURL fetches and converts the tab behind any UG link, as copied from
+// the app or browser: tab pages, mobile and localized URLs, share and
+// shortened links. Expects POST body: { "url": "https://...", "key",
+// "key_header", "spelling", "chord_style", "auto_sections", "profile",
+// "bass_hints", "remove_capo", "bass_lines" } with the same options as
+// POST /api/onsong.
+// The response is that of GET /api/tab/:id plus how the link was resolved.
+func (h *TabHandler) FetchURL(c *fiber.Ctx) error {
+	var req struct {
+		URL string `json:"url"`
+		conversionBody
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	input := strings.TrimSpace(req.URL)
+	if input == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "url is required",
+		})
+	}
+
+	conv, err := req.apply(h.converter)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("\n🔗 Fetching tab by URL: %s\n", input)
+
+	// The tab is fetched below, so the resolver doesn't need to verify it
+	resolution, err := h.resolver.Resolve(c.UserContext(), input, false)
+	if errors.Is(err, scraper.ErrNotUGReference) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "not an Ultimate Guitar tab URL",
+			"details": err.Error(),
+		})
+	}
+	if err != nil {
+		fmt.Printf("❌ Could not resolve URL: %v\n\n", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   "could not resolve tab",
+			"details": err.Error(),
+		})
+	}
+
+	return h.respond(c, resolution.TabID, conv, resolution)
+}
+
+// respond fetches a tab, converts it with conv and writes the tab response,
+// or the chart alone when the client asks for another output format.
+// resolution, when set, is included to show how a URL led to the tab.
+func (h *TabHandler) respond(c *fiber.Ctx, tabID string, conv *converter.OnSongConverter, resolution *scraper.Resolution) error {
+	format, ok := outputFormat(c, outputJSON)
+	if !ok {
+		return nil
+	}
+
 	fmt.Printf("\n🎼 Fetching tab: ID=%s\n", tabID)
 
 	// Fetch tab from Ultimate Guitar
-	tab, err := h.ugClient.GetTabByID(tabID)
+	tab, err := h.ugClient.GetTabByID(c.UserContext(), tabID)
 	if err != nil {
 		fmt.Printf("❌ Failed to fetch tab: %v\n\n", err)
+		// Events are published after the handler returns, when the request
+		// memory behind tabID may already be reused
+		h.events.PublishEvent(events.ScrapeFailed, fiber.Map{
+			"operation": "tab",
+			"id":        utils.CopyString(tabID),
+			"error":     err.Error(),
+		})
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to fetch tab",
 			"details": err.Error(),
@@ -44,6 +189,12 @@ func (h *TabHandler) Handle(c *fiber.Ctx) error {
 	}
 
 	fmt.Printf("✅ Tab fetched: %s - %s\n", tab.ArtistName, tab.SongName)
+	h.profiles.Record(requestProfileName(c), profiles.HistoryEntry{
+		Action: profiles.ActionViewed,
+		TabID:  tab.TabID,
+		Title:  tab.SongName,
+		Artist: tab.ArtistName,
+	})
 
 	// Validate tab
 	if err := h.converter.ValidateTab(tab); err != nil {
@@ -54,9 +205,15 @@ func (h *TabHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
-	fmt.Println("🔄 Converting to OnSong format...")
-	// Convert to OnSong format
-	result, err := h.converter.Convert(tab)
+	fmt.Printf("🔄 Converting (%s)...\n", tab.Type)
+	// Route to the conversion that suits the tab type
+	result, err := conv.ConvertByType(tab)
+	if err == converter.ErrNoTextContent {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "tab cannot be converted",
+			"details": err.Error(),
+		})
+	}
 	if err != nil {
 		fmt.Printf("❌ Conversion failed: %v\n\n", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -65,23 +222,375 @@ func (h *TabHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
+	// Guitar Pro tabs are binary; point the client at the file download
+	if result.Format == converter.FormatBinary {
+		fmt.Printf("📦 Guitar Pro tab, serving file route\n\n")
+		response := fiber.Map{
+			"id":       tab.TabID,
+			"title":    tab.SongName,
+			"artist":   tab.ArtistName,
+			"type":     tab.Type,
+			"format":   result.Format,
+			"file_url": fmt.Sprintf("/api/tab/%d/file", tab.TabID),
+			"url":      tab.URLWeb,
+			"locale":   tab.Locale,
+		}
+		if resolution != nil {
+			response["resolved"] = resolution
+		}
+		if format != outputJSON {
+			return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{
+				"error":    "Guitar Pro tabs have no text content",
+				"file_url": response["file_url"],
+			})
+		}
+		return sendTagged(c, response)
+	}
+
 	fmt.Printf("✅ Conversion complete: key=%s, capo=%d, %d chords\n\n", result.DetectedKey, tab.Capo, result.ChordCount)
 
-	// Return both raw and formatted content
-	return c.JSON(fiber.Map{
-		"id":            tab.TabID,
-		"title":         tab.SongName,
-		"artist":        tab.ArtistName,
-		"key":           result.DetectedKey,
-		"capo":          tab.Capo,
-		"tuning":        tab.Tuning,
-		"difficulty":    tab.Difficulty,
-		"rating":        tab.Rating,
-		"votes":         tab.Votes,
-		"content":       tab.Content,
-		"onsong_format": result.OnSongFormat,
-		"chords":        result.Chords,
-		"chord_count":   result.ChordCount,
-		"url":           tab.URLWeb,
+	h.share.Save(sharefolder.Entry{
+		TabID:   tab.TabID,
+		Title:   tab.SongName,
+		Artist:  tab.ArtistName,
+		Key:     result.DetectedKey,
+		Type:    string(tab.Type),
+		Content: result.OnSongFormat,
+	})
+
+	h.events.PublishEvent(events.TabConverted, fiber.Map{
+		"id":     tab.TabID,
+		"title":  tab.SongName,
+		"artist": tab.ArtistName,
+		"key":    result.DetectedKey,
 	})
+
+	if format != outputJSON {
+		return h.output.send(c, format, result.OnSongFormat, tabSheet(tab, result))
+	}
+
+	response := tabResponse(tab, result)
+	if resolution != nil {
+		response["resolved"] = resolution
+	}
+
+	// Tagged by content, so clients holding the same conversion get a 304
+	return sendTagged(c, response)
+}
+
+// tabResponse is the JSON response of a converted tab: the raw and the
+// formatted content with the tab's metadata, and the chart as structured data
+func tabResponse(tab *scraper.TabResult, result *converter.ConversionResult) fiber.Map {
+	response := fiber.Map{
+		"id":             tab.TabID,
+		"title":          tab.SongName,
+		"artist":         tab.ArtistName,
+		"key":            result.DetectedKey,
+		"shape_key":      result.ShapeKey,
+		"sounding_key":   result.SoundingKey,
+		"key_header":     result.KeyHeader,
+		"key_confidence": result.KeyConfidence,
+		"profile":        result.Profile,
+		"warnings":       result.Warnings,
+		"strumming":      result.Strumming,
+		"notes":          result.Notes,
+		"type":           tab.Type,
+		"format":         result.Format,
+		"capo":           tab.Capo,
+		"tuning":         tab.Tuning,
+		"difficulty":     tab.Difficulty,
+		"rating":         tab.Rating,
+		"votes":          tab.Votes,
+		"content":        tab.Content,
+		"onsong_format":  result.OnSongFormat,
+		"chart":          converter.ParseChart(result.OnSongFormat),
+		"monospace":      result.Monospace,
+		"diagrams":       result.Diagrams,
+		"applicature":    tab.Applicature,
+		"chords":         result.Chords,
+		"chord_count":    result.ChordCount,
+		"url":            tab.URLWeb,
+		"locale":         tab.Locale,
+	}
+	// Piano charts leave out the guitar voicings
+	if result.Profile == converter.ProfilePiano {
+		delete(response, "applicature")
+	}
+	if result.BassLines != nil {
+		response["bass_lines"] = result.BassLines
+	}
+	if result.Format == converter.FormatTab {
+		response["pdf_url"] = fmt.Sprintf("/api/tab/%d/pdf", tab.TabID)
+	}
+	return response
+}
+
+// File downloads the binary file behind a Guitar Pro tab
+func (h *TabHandler) File(c *fiber.Ctx) error {
+	tabID := c.Params("id")
+
+	fmt.Printf("\n📦 Downloading tab file: ID=%s\n", tabID)
+
+	file, err := h.ugClient.DownloadTabFile(c.UserContext(), tabID)
+	if err != nil {
+		fmt.Printf("❌ Download failed: %v\n\n", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   "failed to download tab file",
+			"details": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, export.SanitizeFilename(file.Filename)))
+	return c.Send(file.Data)
+}
+
+// PDF renders a tablature tab as a PDF with its tab blocks on a monospace
+// grid. ?font_size sets the tab font size in points, overriding the stored
+// PDF default; ?save=true keeps the PDF in the exports directory, answering
+// 201 with the file, instead of downloading it. Chord charts have no tab
+// blocks and are refused; they export through OnSong and ChordPro.
+func (h *TabHandler) PDF(c *fiber.Ctx) error {
+	tabID := c.Params("id")
+
+	fontSize := h.formats.Defaults().PDF.FontSize
+	if v := c.Query("font_size"); v != "" {
+		size, err := strconv.ParseFloat(v, 64)
+		if err != nil || size < export.MinTabFontSize || size > export.MaxTabFontSize {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "invalid font_size",
+				"details": fmt.Sprintf("font_size must be between %g and %g", export.MinTabFontSize, export.MaxTabFontSize),
+			})
+		}
+		fontSize = size
+	}
+
+	fmt.Printf("\n📄 Rendering tab PDF: ID=%s\n", tabID)
+
+	tab, err := h.ugClient.GetTabByID(c.UserContext(), tabID)
+	if err != nil {
+		fmt.Printf("❌ Failed to fetch tab: %v\n\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to fetch tab",
+			"details": err.Error(),
+		})
+	}
+
+	result, err := h.converter.ConvertByType(tab)
+	if err == converter.ErrNoTextContent {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "tab cannot be converted",
+			"details": err.Error(),
+		})
+	}
+	if err != nil {
+		fmt.Printf("❌ Conversion failed: %v\n\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "conversion failed",
+			"details": err.Error(),
+		})
+	}
+	if result.Format != converter.FormatTab {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "PDF export is only available for tablature",
+			"details": fmt.Sprintf("%s tabs have no tab blocks", tab.Type),
+		})
+	}
+
+	if err := h.renders.Acquire(c.UserContext()); err != nil {
+		return err
+	}
+	defer h.renders.Release()
+
+	pdf := export.TabPDF(*tabSheet(tab, result), fontSize)
+
+	fmt.Printf("✅ PDF rendered: %d bytes\n\n", len(pdf))
+
+	filename := export.SanitizeFilename(fmt.Sprintf("%s - %s.pdf", tab.ArtistName, tab.SongName))
+	if c.QueryBool("save") {
+		return saveExport(c, h.files, filename, pdf)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(pdf)
+}
+
+// HTML renders a tab as a standalone chord sheet page, with chords above the
+// lyrics, for a wall tablet or printing from a browser. It takes the
+// conversion options of GET /api/tab/:id; ?theme=light|dark fixes the colour
+// scheme, which otherwise follows the device.
+func (h *TabHandler) HTML(c *fiber.Ctx) error {
+	theme := c.Query("theme", export.ThemeAuto)
+	if !export.ValidTheme(theme) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid theme",
+			"details": fmt.Sprintf("theme must be %s, %s or %s", export.ThemeAuto, export.ThemeLight, export.ThemeDark),
+		})
+	}
+
+	_, chart, ok := h.textChart(c)
+	if !ok {
+		return nil
+	}
+
+	page, err := export.ChartHTML(chart, theme)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to render chart",
+			"details": err.Error(),
+		})
+	}
+
+	if notModified(c, contentTag(page)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(page)
+}
+
+// MusicXML downloads a tab as a MusicXML lead sheet with its chord symbols
+// and lyrics, to open in MuseScore and notate further. It takes the
+// conversion options of GET /api/tab/:id; ?save=true keeps the file in the
+// exports directory, answering 201 with the file, instead of downloading it.
+func (h *TabHandler) MusicXML(c *fiber.Ctx) error {
+	tab, chart, ok := h.textChart(c)
+	if !ok {
+		return nil
+	}
+
+	score, err := export.ChartMusicXML(chart)
+	if err != nil {
+		fmt.Printf("❌ MusicXML export failed: %v\n\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to write MusicXML",
+			"details": err.Error(),
+		})
+	}
+
+	fmt.Printf("🎼 MusicXML written: ID=%d, %d bytes\n", tab.TabID, len(score))
+
+	filename := export.SanitizeFilename(fmt.Sprintf("%s - %s.musicxml", tab.ArtistName, tab.SongName))
+	if c.QueryBool("save") {
+		return saveExport(c, h.files, filename, score)
+	}
+
+	c.Set(fiber.HeaderContentType, export.MusicXMLContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(score)
+}
+
+// MIDI downloads the chord progression of a tab as a MIDI backing track with
+// piano chords and a bass line, at sounding pitch. ?tempo sets the beats per
+// minute, defaulting to the tab's strumming tempo; ?beats how many beats
+// every chord is held (default 4). It takes the conversion options of
+// GET /api/tab/:id; ?save=true keeps the file in the exports directory,
+// answering 201 with the file, instead of downloading it.
+func (h *TabHandler) MIDI(c *fiber.Ctx) error {
+	opts := export.MIDIOptions{Beats: export.DefaultMIDIBeats}
+	if v := c.Query("tempo"); v != "" {
+		tempo, err := strconv.Atoi(v)
+		if err != nil || tempo < export.MinMIDITempo || tempo > export.MaxMIDITempo {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "invalid tempo",
+				"details": fmt.Sprintf("tempo must be between %d and %d", export.MinMIDITempo, export.MaxMIDITempo),
+			})
+		}
+		opts.Tempo = tempo
+	}
+	if v := c.Query("beats"); v != "" {
+		beats, err := strconv.Atoi(v)
+		if err != nil || beats < export.MinMIDIBeats || beats > export.MaxMIDIBeats {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "invalid beats",
+				"details": fmt.Sprintf("beats must be between %d and %d", export.MinMIDIBeats, export.MaxMIDIBeats),
+			})
+		}
+		opts.Beats = beats
+	}
+
+	tab, chart, ok := h.textChart(c)
+	if !ok {
+		return nil
+	}
+
+	// Without a tempo the first of the tab's strumming patterns that has one
+	// sets it
+	for _, s := range tab.Strummings {
+		if opts.Tempo == 0 && s.BPM >= export.MinMIDITempo && s.BPM <= export.MaxMIDITempo {
+			opts.Tempo = s.BPM
+		}
+	}
+
+	midi := export.ChartMIDI(chart, opts)
+
+	fmt.Printf("🎹 MIDI written: ID=%d, %d bytes\n", tab.TabID, len(midi))
+
+	filename := export.SanitizeFilename(fmt.Sprintf("%s - %s.mid", tab.ArtistName, tab.SongName))
+	if c.QueryBool("save") {
+		return saveExport(c, h.files, filename, midi)
+	}
+
+	c.Set(fiber.HeaderContentType, export.MIDIContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(midi)
+}
+
+// textChart fetches and converts the tab of the request with the conversion
+// options of its query string and parses the converted chart. It answers
+// the error itself and returns false when the tab has no text chart.
+func (h *TabHandler) textChart(c *fiber.Ctx) (*scraper.TabResult, *converter.Chart, bool) {
+	tabID := c.Params("id")
+
+	conv, err := h.queryConverter(c)
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid conversion options",
+			"details": err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	tab, err := h.ugClient.GetTabByID(c.UserContext(), tabID)
+	if err != nil {
+		fmt.Printf("❌ Failed to fetch tab: %v\n\n", err)
+		_ = c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to fetch tab",
+			"details": err.Error(),
+		})
+		return nil, nil, false
+	}
+	if err := h.converter.ValidateTab(tab); err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid tab data",
+			"details": err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	result, err := conv.ConvertByType(tab)
+	if err == converter.ErrNoTextContent {
+		_ = c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "tab cannot be converted",
+			"details": err.Error(),
+		})
+		return nil, nil, false
+	}
+	if err != nil {
+		fmt.Printf("❌ Conversion failed: %v\n\n", err)
+		_ = c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "conversion failed",
+			"details": err.Error(),
+		})
+		return nil, nil, false
+	}
+	if result.Format == converter.FormatBinary {
+		_ = c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":    "Guitar Pro tabs have no text content",
+			"file_url": fmt.Sprintf("/api/tab/%s/file", tabID),
+		})
+		return nil, nil, false
+	}
+
+	return tab, converter.ParseChart(result.OnSongFormat), true
 }
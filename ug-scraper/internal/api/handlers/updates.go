@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/importer"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// UpdateHandler handles update checks of library songs against their UG tabs
+type UpdateHandler struct {
+	checker *importer.UpdateChecker
+	store   *library.Store
+}
+
+// NewUpdateHandler creates a new update handler
+func NewUpdateHandler(checker *importer.UpdateChecker, store *library.Store) *UpdateHandler {
+	return &UpdateHandler{
+		checker: checker,
+		store:   store,
+	}
+}
+
+// List returns the checker status and the songs with an update waiting
+func (h *UpdateHandler) List(c *fiber.Ctx) error {
+	songs := h.store.ListPendingUpdates()
+	updates := make([]fiber.Map, len(songs))
+	for i, song := range songs {
+		updates[i] = fiber.Map{
+			"id":      song.ID,
+			"title":   song.Title,
+			"artist":  song.Artist,
+			"update":  song.PendingUpdate,
+			"checked": song.LastCheckedAt,
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  h.checker.Status(),
+		"updates": updates,
+	})
+}
+
+// RunAll starts an update check over the whole library in the background
+func (h *UpdateHandler) RunAll(c *fiber.Ctx) error {
+	go func() {
+		if _, err := h.checker.Run(); err != nil {
+			fmt.Printf("⚠️  Update checker: %v\n", err)
+		}
+	}()
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "update check started",
+	})
+}
+
+// CheckSong checks a single song for an update now
+func (h *UpdateHandler) CheckSong(c *fiber.Ctx) error {
+	update, err := h.checker.CheckSong(c.UserContext(), c.Params("id"))
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   "update check failed",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"updated": update != nil,
+		"update":  update,
+	})
+}
+
+// Accept replaces a song's chart with the updated UG version
+func (h *UpdateHandler) Accept(c *fiber.Ctx) error {
+	song, err := h.checker.Accept(c.UserContext(), c.Params("id"))
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	if err == library.ErrConflict {
+		// The song was edited meanwhile; the update stays pending
+		if current, ok := h.store.Get(c.Params("id")); ok {
+			return revisionConflict(c, current.Revision, current)
+		}
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "accepting update failed",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(song)
+}
+
+// Ignore keeps a song's chart and dismisses its pending update
+func (h *UpdateHandler) Ignore(c *fiber.Ctx) error {
+	song, err := h.checker.Ignore(c.Params("id"))
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "song not found",
+		})
+	}
+	if err == library.ErrConflict {
+		// The song was edited meanwhile; the update stays pending
+		if current, ok := h.store.Get(c.Params("id")); ok {
+			return revisionConflict(c, current.Revision, current)
+		}
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "ignoring update failed",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(song)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/importer"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// WatchlistHandler handles the watchlist of artist and song searches that
+// are re-run on a schedule
+type WatchlistHandler struct {
+	watcher *importer.Watcher
+	store   *library.Store
+	audit   *auth.AuditLog
+}
+
+// NewWatchlistHandler creates a new watchlist handler
+func NewWatchlistHandler(watcher *importer.Watcher, store *library.Store, audit *auth.AuditLog) *WatchlistHandler {
+	return &WatchlistHandler{
+		watcher: watcher,
+		store:   store,
+		audit:   audit,
+	}
+}
+
+// watchRequest is the body of a new watch
+type watchRequest struct {
+	Artist     string `json:"artist"`
+	Song       string `json:"song"`
+	Type       string `json:"type"`
+	WebhookURL string `json:"webhook_url"`
+}
+
+// List returns the watcher status and every watch with its recent hits
+func (h *WatchlistHandler) List(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":  h.watcher.Status(),
+		"watches": h.store.ListWatches(),
+	})
+}
+
+// Create adds a watch and runs its first search in the background, which
+// records the tabs that already exist
+func (h *WatchlistHandler) Create(c *fiber.Ctx) error {
+	var req watchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	watch := &library.Watch{Artist: req.Artist, Song: req.Song, WebhookURL: req.WebhookURL}
+	if req.Type != "" {
+		tabType, ok := scraper.ParseTabType(req.Type)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fmt.Sprintf("unknown tab type %q", req.Type),
+				"details": "accepted values: " + scraper.TabTypeList(),
+			})
+		}
+		watch.Type = tabType
+	}
+
+	if err := h.store.SaveWatch(watch); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid watch",
+			"details": err.Error(),
+		})
+	}
+
+	go func() {
+		if _, err := h.watcher.CheckWatch(context.Background(), watch.ID); err != nil {
+			fmt.Printf("⚠️  Watchlist: %v\n", err)
+		}
+	}()
+
+	return c.Status(fiber.StatusCreated).JSON(watch)
+}
+
+// Delete removes a watch
+func (h *WatchlistHandler) Delete(c *fiber.Ctx) error {
+	deleted := c.Params("id")
+	if watch, ok := h.store.GetWatch(deleted); ok {
+		deleted = watch.Query()
+	}
+	if err := h.store.DeleteWatch(c.Params("id")); err != nil {
+		if err == library.ErrNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "watch not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "failed to delete watch",
+			"details": err.Error(),
+		})
+	}
+
+	recordAction(h.audit, c, auth.EventWatchDeleted, deleted)
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// RunAll re-runs every watch in the background
+func (h *WatchlistHandler) RunAll(c *fiber.Ctx) error {
+	go func() {
+		if _, err := h.watcher.Run(); err != nil {
+			fmt.Printf("⚠️  Watchlist: %v\n", err)
+		}
+	}()
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "watchlist run started",
+	})
+}
+
+// Check re-runs a single watch now and returns its new tabs
+func (h *WatchlistHandler) Check(c *fiber.Ctx) error {
+	hits, err := h.watcher.CheckWatch(c.UserContext(), c.Params("id"))
+	if err == library.ErrNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "watch not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   "watch search failed",
+			"details": err.Error(),
+		})
+	}
+	if hits == nil {
+		hits = []library.WatchHit{}
+	}
+
+	return c.JSON(fiber.Map{
+		"new_tabs": hits,
+	})
+}
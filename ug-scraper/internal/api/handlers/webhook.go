@@ -2,53 +2,90 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/profiles"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
 )
 
-// WebhookHandler handles webhook configuration and delivery
+// WebhookHandler handles webhook configuration and delivery. Requests with
+// a profile use and change the profile's own webhook; profiles without one
+// send to the shared webhook.
 type WebhookHandler struct {
 	configStore   *config.ConfigStore
+	profiles      *profiles.Store
 	webhookClient *webhook.Client
+	events        *events.Dispatcher
+	library       *library.Store
+	audit         *auth.AuditLog
 }
 
 // NewWebhookHandler creates a new webhook handler
 func NewWebhookHandler(
 	configStore *config.ConfigStore,
+	profileStore *profiles.Store,
 	webhookClient *webhook.Client,
+	dispatcher *events.Dispatcher,
+	libraryStore *library.Store,
+	audit *auth.AuditLog,
 ) *WebhookHandler {
 	return &WebhookHandler{
 		configStore:   configStore,
+		profiles:      profileStore,
 		webhookClient: webhookClient,
+		events:        dispatcher,
+		library:       libraryStore,
+		audit:         audit,
 	}
 }
 
-// GetConfig returns the current webhook configuration
+// GetConfig returns the webhook configuration the request sends to. With a
+// profile, "shared" tells whether it is the shared webhook because the
+// profile has none of its own.
 func (h *WebhookHandler) GetConfig(c *fiber.Ctx) error {
-	config := h.configStore.Get()
+	config, shared := h.config(c)
+	response := fiber.Map{
+		"configured": false,
+	}
+	if profile := requestProfile(c); profile != nil {
+		response["profile"] = profile.Name
+		response["shared"] = shared
+	}
 	if config == nil || config.URL == "" {
-		return c.JSON(fiber.Map{
-			"configured": false,
-		})
+		return c.JSON(response)
 	}
 
-	return c.JSON(fiber.Map{
-		"configured": true,
-		"url":        config.URL,
-		"enabled":    config.Enabled,
-		"created_at": config.CreatedAt,
-		"updated_at": config.UpdatedAt,
-	})
+	response["configured"] = true
+	response["url"] = config.URL
+	response["enabled"] = config.Enabled
+	response["headers"] = maskHeaders(config.Headers)
+	response["schema_version"] = schemaVersion(config.SchemaVersion)
+	response["include_bundle"] = config.IncludeBundle
+	response["redact_fields"] = config.RedactFields
+	response["max_lines"] = config.MaxLines
+	response["created_at"] = config.CreatedAt
+	response["updated_at"] = config.UpdatedAt
+	return c.JSON(response)
 }
 
 // SaveConfig updates the webhook configuration
 func (h *WebhookHandler) SaveConfig(c *fiber.Ctx) error {
 	var req struct {
-		URL     string `json:"url"`
-		Enabled bool   `json:"enabled"`
+		URL           string            `json:"url"`
+		Enabled       bool              `json:"enabled"`
+		Headers       map[string]string `json:"headers"`
+		SchemaVersion int               `json:"schema_version"`
+		IncludeBundle bool              `json:"include_bundle"`
+		RedactFields  []string          `json:"redact_fields"`
+		MaxLines      int               `json:"max_lines"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -58,12 +95,37 @@ func (h *WebhookHandler) SaveConfig(c *fiber.Ctx) error {
 		})
 	}
 
-	fmt.Printf("\n🔗 Webhook Config: url=%s enabled=%v\n", req.URL, req.Enabled)
+	fmt.Printf("\n🔗 Webhook Config: url=%s enabled=%v headers=%d schema=v%d\n", req.URL, req.Enabled, len(req.Headers), schemaVersion(req.SchemaVersion))
+
+	if !webhook.ValidSchema(req.SchemaVersion) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid webhook configuration",
+			"details": fmt.Sprintf("schema_version must be between 1 and %d", webhook.LatestSchema),
+		})
+	}
+	if err := webhook.ValidateRedaction(req.RedactFields, req.MaxLines); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid webhook configuration",
+			"details": err.Error(),
+		})
+	}
+
+	// Headers sent back masked keep the value saved before
+	current, _ := h.config(c)
+	var stored map[string]string
+	if current != nil {
+		stored = current.Headers
+	}
 
 	// Create config
 	webhookConfig := &config.WebhookConfig{
-		URL:     req.URL,
-		Enabled: req.Enabled,
+		URL:           req.URL,
+		Enabled:       req.Enabled,
+		Headers:       unmaskHeaders(req.Headers, stored),
+		SchemaVersion: req.SchemaVersion,
+		IncludeBundle: req.IncludeBundle,
+		RedactFields:  req.RedactFields,
+		MaxLines:      req.MaxLines,
 	}
 
 	// Validate config
@@ -74,8 +136,14 @@ func (h *WebhookHandler) SaveConfig(c *fiber.Ctx) error {
 		})
 	}
 
-	// Save config
-	if err := h.configStore.Save(webhookConfig); err != nil {
+	// Save config, to the profile's own webhook when there is a profile
+	save := h.configStore.Save
+	if name := requestProfileName(c); name != "" {
+		save = func(config *config.WebhookConfig) error {
+			return h.profiles.SaveWebhook(name, config)
+		}
+	}
+	if err := save(webhookConfig); err != nil {
 		fmt.Printf("❌ Failed to save webhook config: %v\n\n", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to save configuration",
@@ -83,7 +151,8 @@ func (h *WebhookHandler) SaveConfig(c *fiber.Ctx) error {
 		})
 	}
 
-	fmt.Println("✅ Webhook configuration saved\n")
+	fmt.Print("✅ Webhook configuration saved\n\n")
+	recordAction(h.audit, c, auth.EventWebhookChanged, webhookAuditDetails(c, webhookConfig))
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "webhook configuration saved",
@@ -92,15 +161,17 @@ func (h *WebhookHandler) SaveConfig(c *fiber.Ctx) error {
 
 // TestWebhook sends a test payload to the configured webhook
 func (h *WebhookHandler) TestWebhook(c *fiber.Ctx) error {
-	webhookURL := h.configStore.GetURL()
-	if webhookURL == "" {
+	config, _ := h.config(c)
+	if enabledURL(config) == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "webhook not configured",
 		})
 	}
 
 	// Send test webhook
-	if err := h.webhookClient.TestWebhook(webhookURL); err != nil {
+	target := deliveryTarget(config)
+	accepted, err := h.webhookClient.TestWebhook(c.UserContext(), target)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "test webhook failed",
@@ -108,10 +179,17 @@ func (h *WebhookHandler) TestWebhook(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "test webhook sent successfully",
-	})
+	response := fiber.Map{
+		"success":        true,
+		"message":        "test webhook sent successfully",
+		"schema_version": schemaVersion(target.SchemaVersion),
+	}
+	// Receivers that list the schemas they accept get the newest suggested
+	if len(accepted) > 0 {
+		response["accepted_schema_versions"] = accepted
+		response["suggested_schema_version"] = slices.Max(accepted)
+	}
+	return c.JSON(response)
 }
 
 // SendTab sends tab data to the webhook
@@ -140,7 +218,8 @@ func (h *WebhookHandler) SendTab(c *fiber.Ctx) error {
 	fmt.Printf("\n📤 Sending to webhook: %s - %s\n", req.Artist, req.Title)
 
 	// Check if webhook is configured
-	webhookURL := h.configStore.GetURL()
+	config, _ := h.config(c)
+	webhookURL := enabledURL(config)
 	if webhookURL == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "webhook not configured or not enabled",
@@ -159,9 +238,14 @@ func (h *WebhookHandler) SendTab(c *fiber.Ctx) error {
 	}
 
 	// Send with retry
-	deliveryResult, err := h.webhookClient.SendWithRetry(webhookURL, payload)
+	deliveryResult, err := h.webhookClient.SendWithRetry(c.UserContext(), deliveryTarget(config), payload)
 	if err != nil {
 		fmt.Printf("❌ Webhook delivery failed: %v\n\n", err)
+		h.events.PublishEvent(events.WebhookFailed, fiber.Map{
+			"title":  req.Title,
+			"artist": req.Artist,
+			"error":  err.Error(),
+		})
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "webhook delivery failed",
@@ -171,17 +255,153 @@ func (h *WebhookHandler) SendTab(c *fiber.Ctx) error {
 	}
 
 	fmt.Printf("✅ Webhook delivered successfully (attempts=%d)\n\n", deliveryResult.Attempts)
+	h.profiles.Record(requestProfileName(c), profiles.HistoryEntry{
+		Action: profiles.ActionSent,
+		Title:  req.Title,
+		Artist: req.Artist,
+	})
+	h.events.PublishEvent(events.WebhookSent, fiber.Map{
+		"title":       req.Title,
+		"artist":      req.Artist,
+		"delivery_id": deliveryResult.DeliveryID,
+		"attempts":    deliveryResult.Attempts,
+	})
+
+	name := export.SanitizeFilename(req.Artist+" - "+req.Title) + ".onsong"
+	if req.Artist == "" {
+		name = export.SanitizeFilename(req.Title) + ".onsong"
+	}
+	manifest := &library.Manifest{
+		Kind:         library.ManifestWebhookDelivery,
+		Reference:    deliveryResult.DeliveryID,
+		Name:         name,
+		Format:       "onsong",
+		Destinations: []string{manifestDestination(webhookURL)},
+		Files:        []library.ManifestFile{export.ManifestEntry(name, nil, req.Content)},
+	}
+	manifest.Files[0].Title = req.Title
+	manifest.Files[0].Artist = req.Artist
+	if err := h.library.AddManifest(manifest); err != nil {
+		fmt.Printf("⚠️  Failed to record delivery manifest: %v\n", err)
+	} else {
+		c.Set("X-Manifest-ID", manifest.ID)
+	}
+
 	return c.JSON(deliveryResult)
 }
 
-// ClearConfig removes the webhook configuration
+// manifestDestination describes a webhook URL for a manifest without its
+// query string or credentials, which often carry secrets
+func manifestDestination(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "webhook"
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// config returns the webhook configuration for the request: the
+// profile's own, else the shared one (shared is then true)
+func (h *WebhookHandler) config(c *fiber.Ctx) (config *config.WebhookConfig, shared bool) {
+	if profile := requestProfile(c); profile != nil && profile.Webhook != nil {
+		return profile.Webhook, false
+	}
+	return h.configStore.Get(), true
+}
+
+// maskedHeader replaces custom header values in responses. They often carry
+// credentials, such as an Authorization token, and any key may read them.
+const maskedHeader = "***"
+
+// maskHeaders returns the names of custom headers with their values masked
+func maskHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	masked := make(map[string]string, len(headers))
+	for name := range headers {
+		masked[name] = maskedHeader
+	}
+	return masked
+}
+
+// unmaskHeaders puts the stored value back for every header sent with the
+// mask unchanged
+func unmaskHeaders(headers, stored map[string]string) map[string]string {
+	for name, value := range headers {
+		if value != maskedHeader {
+			continue
+		}
+		if old, ok := stored[name]; ok {
+			headers[name] = old
+		}
+	}
+	return headers
+}
+
+// enabledURL returns the webhook URL if configured and enabled
+func enabledURL(config *config.WebhookConfig) string {
+	if config == nil || !config.Enabled {
+		return ""
+	}
+	return config.URL
+}
+
+// webhookAuditDetails describes a webhook change for the audit log. Only the
+// host is named: URLs of services like Discord carry their token.
+func webhookAuditDetails(c *fiber.Ctx, config *config.WebhookConfig) string {
+	details := "shared webhook"
+	if name := requestProfileName(c); name != "" {
+		details = "webhook of profile " + name
+	}
+	if config != nil {
+		host := "invalid URL"
+		if u, err := url.Parse(config.URL); err == nil {
+			host = u.Host
+		}
+		details += fmt.Sprintf(": %s enabled=%v", host, config.Enabled)
+	}
+	return details
+}
+
+// deliveryTarget builds the delivery target for a webhook configuration
+func deliveryTarget(config *config.WebhookConfig) webhook.Target {
+	return webhook.Target{
+		URL:           config.URL,
+		Headers:       config.Headers,
+		SchemaVersion: config.SchemaVersion,
+		Bundle:        config.IncludeBundle,
+		Redaction: webhook.Redaction{
+			Fields:   config.RedactFields,
+			MaxLines: config.MaxLines,
+		},
+	}
+}
+
+// schemaVersion reports a configured payload schema, 0 being the default
+func schemaVersion(version int) int {
+	if version == 0 {
+		return webhook.DefaultSchema
+	}
+	return version
+}
+
+// ClearConfig removes the webhook configuration; a profile's own webhook is
+// removed so the profile sends to the shared one again
 func (h *WebhookHandler) ClearConfig(c *fiber.Ctx) error {
-	if err := h.configStore.Clear(); err != nil {
+	remove := h.configStore.Clear
+	if name := requestProfileName(c); name != "" {
+		remove = func() error {
+			return h.profiles.ClearWebhook(name)
+		}
+	}
+	if err := remove(); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "failed to clear configuration",
 			"details": err.Error(),
 		})
 	}
+	recordAction(h.audit, c, auth.EventWebhookCleared, webhookAuditDetails(c, nil))
 
 	return c.JSON(fiber.Map{
 		"success": true,
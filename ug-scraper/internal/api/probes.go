@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/health"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// healthProbes are the dependencies a deep health check tries out on the spot
+func healthProbes(ugClient *scraper.UGClient, searchScraper *scraper.SearchScraper, configStore *config.ConfigStore, suggestCache *scraper.SuggestCache) []health.Probe {
+	return []health.Probe{
+		{
+			// The UG app API answers
+			Name:  "ug_api",
+			Check: ugClient.Ping,
+		},
+		{
+			// FlareSolverr answers its health endpoint
+			Name: "flaresolverr",
+			Check: func(ctx context.Context) (string, error) {
+				if !searchScraper.FlareSolverrConfigured() {
+					return "", fmt.Errorf("FlareSolverr is %w", health.ErrNotConfigured)
+				}
+				if err := searchScraper.PingFlareSolverr(ctx); err != nil {
+					return "", err
+				}
+				return "reachable", nil
+			},
+		},
+		{
+			// Webhook settings can be saved
+			Name: "config_writable",
+			Check: func(context.Context) (string, error) {
+				if !configStore.Persistent() {
+					return "", fmt.Errorf("config file %w, settings are kept in memory", health.ErrNotConfigured)
+				}
+				if err := configStore.CheckWritable(); err != nil {
+					return "", err
+				}
+				return "writable", nil
+			},
+		},
+		{
+			// The suggest cache isn't stuck
+			Name:  "cache",
+			Check: suggestCache.Check,
+		},
+	}
+}
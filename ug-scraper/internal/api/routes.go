@@ -1,53 +1,322 @@
 package api
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/api/handlers"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/backup"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/bandwidth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/collab"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/dropbox"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/features"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/files"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/fsck"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/health"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/homeassistant"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/importer"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/index"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/localtime"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/middleware"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/mqtt"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/ocr"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/profiles"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/provision"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/sharefolder"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/tuning"
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
 )
 
-// SetupRoutes configures all API routes
-func SetupRoutes(app *fiber.App) {
-	// Initialize components - use CONFIG_FILE env var or default to /data/webhook-config.json
-	configFile := "/data/webhook-config.json"
-	if cf := os.Getenv("CONFIG_FILE"); cf != "" {
-		configFile = cf
-	}
-	configStore := config.NewConfigStore(configFile)
-	ugClient := scraper.NewUGClient()
-	searchScraper := scraper.NewSearchScraper()
-	onSongConverter := converter.NewOnSongConverter()
+// SetupRoutes configures all API routes. cfg is the validated startup
+// configuration; settings it doesn't cover are read from the environment.
+func SetupRoutes(app *fiber.App, cfg *config.App) {
+	// Startup steps /api/ready waits for; the last one is done once the
+	// server listens
+	readiness := health.NewReadiness(health.StepConfig, health.StepStorage, health.StepRoutes)
+	app.Hooks().OnListen(func(fiber.ListenData) error {
+		readiness.Done(health.StepRoutes)
+		return nil
+	})
+
+	// Time zone and date locale from TIMEZONE and DATE_LOCALE, before
+	// anything records a timestamp
+	localtime.Apply(localtime.ConfigFromEnv())
+
+	// Webhook target saved from the UI, replaced by the configured one if any
+	configStore := config.NewConfigStore(cfg.Webhook.File)
+	if target := cfg.WebhookConfig(); target != nil {
+		// Redaction is set from the UI or API; keep it for the same URL
+		if saved := configStore.Get(); saved != nil && saved.URL == target.URL {
+			target.RedactFields, target.MaxLines = saved.RedactFields, saved.MaxLines
+		}
+		if err := configStore.Save(target); err != nil {
+			fmt.Printf("⚠️  Failed to save the configured webhook: %v\n", err)
+		} else {
+			fmt.Printf("🔗 Webhook configured: %s (enabled=%t)\n", target.URL, target.Enabled)
+		}
+	}
+	readiness.Done(health.StepConfig)
+
+	// Optional subsystems switched off by FEATURE_LIBRARY, FEATURE_WEBHOOKS
+	// and FEATURE_MQTT
+	flags := features.FlagsFromEnv()
+	capabilities := features.NewRegistry()
+
+	// Library - use LIBRARY_FILE env var or default to /data/library.json
+	libraryFile := "/data/library.json"
+	if lf := os.Getenv("LIBRARY_FILE"); lf != "" {
+		libraryFile = lf
+	}
+	libraryStore := library.NewStore(libraryFile)
+
+	// Search index - use INDEX_FILE env var or default to /data/library-index.json
+	indexFile := "/data/library-index.json"
+	if xf := os.Getenv("INDEX_FILE"); xf != "" {
+		indexFile = xf
+	}
+	indexManager := index.NewManager(libraryStore, indexFile)
+	if flags.Library {
+		indexManager.Start()
+	}
+
+	// User profiles - use PROFILES_FILE env var or default to /data/profiles.json
+	profilesFile := "/data/profiles.json"
+	if pf := os.Getenv("PROFILES_FILE"); pf != "" {
+		profilesFile = pf
+	}
+	profileStore := profiles.NewStore(profilesFile)
+	libraryStore.OnSongChange(profileStore.SongDeleted)
+
+	// API keys - use API_KEYS_FILE env var or default to /data/api-keys.json
+	keysFile := "/data/api-keys.json"
+	if kf := os.Getenv("API_KEYS_FILE"); kf != "" {
+		keysFile = kf
+	}
+	keyStore := auth.NewKeyStore(keysFile)
+	sessionStore := auth.NewSessionStore(os.Getenv("UI_USERNAME"), os.Getenv("UI_PASSWORD"))
+	authLimiter := auth.NewLimiter()
+
+	// Auth audit log - use AUDIT_LOG_FILE env var or default to /data/audit-log.json
+	auditFile := "/data/audit-log.json"
+	if af := os.Getenv("AUDIT_LOG_FILE"); af != "" {
+		auditFile = af
+	}
+	auditLog := auth.NewAuditLog(auditFile)
+
+	// Export format defaults - use FORMAT_SETTINGS_FILE env var or default to /data/format-settings.json
+	formatsFile := "/data/format-settings.json"
+	if ff := os.Getenv("FORMAT_SETTINGS_FILE"); ff != "" {
+		formatsFile = ff
+	}
+	formatStore := config.NewFormatStore(formatsFile)
+
+	// Bandwidth totals - use BANDWIDTH_FILE env var or default to /data/bandwidth.json
+	bandwidthFile := "/data/bandwidth.json"
+	if bf := os.Getenv("BANDWIDTH_FILE"); bf != "" {
+		bandwidthFile = bf
+	}
+	bandwidthMeter := bandwidth.NewMeter(bandwidth.ConfigFromEnv(), bandwidthFile)
+
+	// Saved exports - EXPORTS_DIR (default /data/exports), expired after
+	// EXPORTS_RETENTION_DAYS
+	exportFiles := files.NewStore(files.ConfigFromEnv())
+	exportFiles.Start()
+
+	// Saved backups - BACKUPS_DIR (default /data/backups), apart from the
+	// exports readers can download
+	backupFiles := files.NewStore(files.BackupConfigFromEnv())
+	backupFiles.Start()
+	readiness.Done(health.StepStorage)
+
+	// Worker pools, caches and rendering sized for the hardware
+	perf := tuning.FromEnv()
+	fmt.Printf("⚙️  Performance settings: %s\n", perf)
+	renderSlots := tuning.NewLimiter(perf.PDFConcurrency)
+
+	ugClient := scraper.NewUGClient().WithMeter(bandwidthMeter)
+	searchScraper := scraper.NewSearchScraper().WithMeter(bandwidthMeter)
+	fmt.Printf("🧩 Fetching UG pages via: %s\n", strings.Join(searchScraper.Solvers(), ", "))
+	searchScraper.MonitorFlareSolverr()
+	suggestCache := scraper.NewSuggestCache(ugClient, perf.SuggestCacheSize)
+	chordSpelling := converter.SpellingFromEnv()
+	sectionLanguage := converter.SectionLanguageFromEnv()
+	onSongConverter := converter.NewOnSongConverter().
+		WithKeyHeader(os.Getenv("KEY_HEADER")).
+		WithSpelling(chordSpelling).
+		WithChordStyle(converter.ChordStyleFromEnv()).
+		WithAutoSections(converter.AutoSectionsFromEnv()).
+		WithNormalizeSections(converter.NormalizeSectionsFromEnv()).
+		WithProfile(converter.ProfileFromEnv()).
+		WithBassHints(converter.BassHintsFromEnv()).
+		WithRemoveCapo(converter.RemoveCapoFromEnv()).
+		WithKeyStrategy(converter.KeyStrategyFromEnv())
 	webhookClient := webhook.NewClient()
+	mqttConfig := mqtt.ConfigFromEnv()
+	mqttClient := mqtt.NewClient(mqtt.Config{})
+	if flags.MQTT {
+		mqttClient = mqtt.NewClient(mqttConfig)
+	}
+	dropboxClient := dropbox.NewClient(dropbox.ConfigFromEnv())
+	shareWriter := sharefolder.NewWriter(sharefolder.ConfigFromEnv())
+	haNotifier := homeassistant.NewNotifier(homeassistant.ConfigFromEnv())
+	eventDispatcher := events.NewDispatcher(mqttClient, haNotifier)
+	versionScores := importer.NewFeedbackScoreModel(importer.DefaultScoreModel{}, libraryStore)
+	importPipeline := importer.NewPipeline(searchScraper, ugClient, onSongConverter, libraryStore, shareWriter).
+		WithScoreModel(versionScores).
+		WithDuplicates(importer.DuplicatesFromEnv())
+	tabResolver := scraper.NewResolver(ugClient, searchScraper)
+	rateBudget := scraper.NewRateBudget()
+	rateBudget.OnExpire(func(burst scraper.Burst) {
+		auditLog.Record(auth.AuditEvent{
+			Type:    auth.EventBurstEnded,
+			Actor:   burst.EnabledBy,
+			Details: "expired",
+		})
+	})
+	ocrEngine := ocr.New(ocr.ConfigFromEnv())
+	importJobs := importer.NewJobManager(importPipeline, tabResolver, rateBudget, bandwidthMeter, eventDispatcher, perf.ImportWorkers)
+	sourceMatcher := importer.NewMatcher(searchScraper, libraryStore, rateBudget).WithScoreModel(versionScores)
+	updateChecker := importer.NewUpdateChecker(ugClient, importPipeline, libraryStore, rateBudget, eventDispatcher)
+	watcher := importer.NewWatcher(searchScraper, libraryStore, rateBudget, eventDispatcher, webhookClient)
+	if flags.Library {
+		sourceMatcher.Start()
+		updateChecker.Start()
+		watcher.Start()
+	}
+	registerCapabilities(capabilities, flags, configStore, mqttConfig, searchScraper, ocrEngine, dropboxClient, shareWriter, haNotifier)
+	collabManager := collab.NewManager(libraryStore)
+	healthMonitor := health.NewMonitor()
+	registerSubsystems(healthMonitor, capabilities, ugClient, searchScraper, importJobs, mqttClient, shareWriter, indexManager)
+	healthMonitor.Start()
+	gates := health.GatesFromEnv(startupGates(searchScraper, configStore, webhookClient, mqttClient))
+	gates.Start()
 
 	// Create handlers
-	healthHandler := handlers.NewHealthHandler(configStore)
-	searchHandler := handlers.NewSearchHandler(searchScraper)
-	tabHandler := handlers.NewTabHandler(ugClient, onSongConverter)
-	onSongHandler := handlers.NewOnSongHandler(ugClient, onSongConverter)
-	webhookHandler := handlers.NewWebhookHandler(configStore, webhookClient)
-	formatHandler := handlers.NewFormatHandler(onSongConverter)
+	healthHandler := handlers.NewHealthHandler(configStore, healthMonitor, gates, readiness, searchScraper,
+		healthProbes(ugClient, searchScraper, configStore, suggestCache))
+	searchHandler := handlers.NewSearchHandler(searchScraper, eventDispatcher)
+	suggestHandler := handlers.NewSuggestHandler(suggestCache)
+	resolveHandler := handlers.NewResolveHandler(tabResolver)
+	tabHandler := handlers.NewTabHandler(ugClient, onSongConverter, eventDispatcher, shareWriter, formatStore, tabResolver, exportFiles, profileStore, renderSlots)
+	onSongHandler := handlers.NewOnSongHandler(ugClient, onSongConverter, formatStore, renderSlots)
+	webhookHandler := handlers.NewWebhookHandler(configStore, profileStore, webhookClient, eventDispatcher, libraryStore, auditLog)
+	formatHandler := handlers.NewFormatHandler(onSongConverter, formatStore)
+	analyzeHandler := handlers.NewAnalyzeHandler(onSongConverter)
 	onsongCloudHandler := handlers.NewOnSongCloudHandler()
+	dropboxHandler := handlers.NewDropboxHandler(dropboxClient, libraryStore)
+	libraryHandler := handlers.NewLibraryHandler(libraryStore, formatStore, exportFiles, sectionLanguage, auditLog)
+	importHandler := handlers.NewImportHandler(importPipeline, importJobs, ocrEngine, renderSlots)
+	mqttHandler := handlers.NewMQTTHandler(mqttClient)
+	manifestHandler := handlers.NewManifestHandler(libraryStore)
+	syncHandler := handlers.NewSyncHandler(libraryStore, formatStore, sectionLanguage)
+	collabHandler := handlers.NewCollabHandler(collabManager)
+	adminHandler := handlers.NewAdminHandler(keyStore, auditLog)
+	burstHandler := handlers.NewBurstHandler(rateBudget, auditLog)
+	authHandler := handlers.NewAuthHandler(sessionStore, keyStore, authLimiter, auditLog)
+	matchHandler := handlers.NewMatchHandler(sourceMatcher, importPipeline, libraryStore)
+	updateHandler := handlers.NewUpdateHandler(updateChecker, libraryStore)
+	watchlistHandler := handlers.NewWatchlistHandler(watcher, libraryStore, auditLog)
+	feedbackHandler := handlers.NewFeedbackHandler(libraryStore)
+	setlistHandler := handlers.NewSetlistHandler(libraryStore, exportFiles, chordSpelling, sectionLanguage, auditLog)
+	captureHandler := handlers.NewCaptureHandler(importPipeline)
+	indexHandler := handlers.NewIndexHandler(indexManager, libraryStore)
+	settingsHandler := handlers.NewSettingsHandler(formatStore, auditLog)
+	statsHandler := handlers.NewStatsHandler(bandwidthMeter, searchScraper)
+	capabilitiesHandler := handlers.NewCapabilitiesHandler(capabilities)
+	filesHandler := handlers.NewFilesHandler(exportFiles)
+	profileHandler := handlers.NewProfileHandler(profileStore, libraryStore, auditLog)
+	fsckHandler := handlers.NewFsckHandler(auditLog,
+		fsck.Check{Name: fsck.AreaLibrary, Run: libraryStore.Check},
+		fsck.Check{Name: fsck.AreaFiles, Run: exportFiles.Check},
+		fsck.Check{Name: fsck.AreaIndex, Run: indexManager.Check},
+	)
+	provisionHandler := handlers.NewProvisionHandler(
+		provision.NewReconciler(configStore, keyStore, profileStore, formatStore, libraryStore),
+		auditLog,
+	)
+	backupHandler := handlers.NewBackupHandler(
+		backup.NewManager(formatStore, configStore, profileStore, libraryStore),
+		libraryStore, indexManager, backupFiles, auditLog,
+	)
+	backupFilesHandler := handlers.NewFilesHandler(backupFiles)
 
 	// API routes group
-	api := app.Group("/api")
+	api := app.Group("/api", middleware.APIKey(keyStore, sessionStore, authLimiter, auditLog))
+
+	// Browsers without a session or key echo the CSRF cookie's token
+	api.Use(middleware.CSRF(sessionStore, auditLog))
+
+	// Switched-off subsystems answer 501 with their capability descriptor
+	for _, name := range switchable {
+		api.Use(middleware.RequireFeature(capabilities, name))
+	}
+
+	// Requests act for the profile named in X-Profile or the logged-in user's
+	api.Use(middleware.Profile(profileStore))
 
 	// Health check
 	api.Get("/health", healthHandler.Handle)
+	api.Get("/ready", healthHandler.Ready)
+	api.Get("/capabilities", capabilitiesHandler.List)
+
+	// Usage statistics
+	api.Get("/stats", statsHandler.Stats)
+	api.Get("/metrics", statsHandler.Metrics)
+
+	// Login session endpoints
+	api.Get("/auth/session", authHandler.Session)
+	api.Post("/auth/login", authHandler.Login)
+	api.Post("/auth/logout", authHandler.Logout)
+
+	// Admin endpoints
+	admin := api.Group("/admin", middleware.RequireAdmin(auditLog))
+	admin.Get("/keys", adminHandler.ListKeys)
+	admin.Post("/keys", adminHandler.CreateKey)
+	admin.Post("/keys/:id/rotate", adminHandler.RotateKey)
+	admin.Delete("/keys/:id", adminHandler.RevokeKey)
+	admin.Get("/audit", adminHandler.Audit)
+	admin.Get("/index", indexHandler.Status)
+	admin.Post("/reindex", indexHandler.Reindex)
+	admin.Post("/fsck", fsckHandler.Run)
+	api.Put("/provision", middleware.RequireAdmin(auditLog), provisionHandler.Apply)
+	api.Get("/backup", middleware.RequireAdmin(auditLog), backupHandler.Backup)
+	api.Post("/restore", middleware.RequireAdmin(auditLog), backupHandler.Restore)
+	backups := api.Group("/backups", middleware.RequireAdmin(auditLog))
+	backups.Get("/", backupFilesHandler.List)
+	backups.Get("/:name", backupFilesHandler.Download)
+	backups.Delete("/:name", backupFilesHandler.Delete)
+	admin.Get("/burst", burstHandler.Status)
+	admin.Post("/burst", burstHandler.Request)
+	admin.Post("/burst/confirm", burstHandler.Confirm)
+	admin.Delete("/burst", burstHandler.End)
 
 	// Search endpoints
 	api.Get("/search", searchHandler.Handle)
+	api.Get("/suggest", suggestHandler.Handle)
+	api.Get("/resolve", resolveHandler.Handle)
+	api.Get("/artist/:name/tabs", searchHandler.ArtistTabs)
 
 	// Tab endpoints
 	api.Get("/tab/:id", tabHandler.Handle)
+	api.Get("/tab/:id/file", tabHandler.File)
+	api.Get("/tab/:id/pdf", tabHandler.PDF)
+	api.Get("/tab/:id/html", tabHandler.HTML)
+	api.Get("/tab/:id/musicxml", tabHandler.MusicXML)
+	api.Get("/tab/:id/midi", tabHandler.MIDI)
+	api.Post("/fetch-url", tabHandler.FetchURL)
 	api.Post("/onsong", onSongHandler.Handle)
 
 	// Format endpoint (manual content)
 	api.Post("/format", formatHandler.Handle)
+	api.Post("/analyze/keys", analyzeHandler.Keys)
 
 	// Webhook endpoints
 	api.Get("/webhook/config", webhookHandler.GetConfig)
@@ -56,7 +325,108 @@ func SetupRoutes(app *fiber.App) {
 	api.Post("/webhook/test", webhookHandler.TestWebhook)
 	api.Post("/webhook/send", webhookHandler.SendTab)
 
+	// User profile endpoints
+	api.Get("/profiles", profileHandler.List)
+	api.Post("/profiles", profileHandler.Create)
+	api.Delete("/profiles/:name", profileHandler.Delete)
+	api.Get("/profile", profileHandler.Current)
+	api.Put("/profile", profileHandler.Update)
+	api.Get("/profile/favorites", profileHandler.Favorites)
+	api.Put("/profile/favorites/:id", profileHandler.AddFavorite)
+	api.Delete("/profile/favorites/:id", profileHandler.RemoveFavorite)
+	api.Get("/profile/history", profileHandler.History)
+	api.Delete("/profile/history", profileHandler.ClearHistory)
+
 	// OnSong Cloud endpoints
 	api.Get("/onsong-cloud/config", onsongCloudHandler.GetConfig)
 	api.Post("/onsong-cloud/send", onsongCloudHandler.Send)
+
+	// Dropbox endpoints
+	api.Get("/dropbox/config", dropboxHandler.GetConfig)
+	api.Post("/dropbox/send", dropboxHandler.Send)
+
+	// MQTT endpoints
+	api.Get("/mqtt/status", mqttHandler.GetStatus)
+	api.Post("/mqtt/send", mqttHandler.SendTab)
+
+	// Library endpoints
+	api.Get("/library", libraryHandler.List)
+	api.Get("/library/export", libraryHandler.Export)
+	api.Get("/library/export.ndjson", libraryHandler.ExportNDJSON)
+	api.Post("/library/import.ndjson", importHandler.ImportLibrary)
+	api.Get("/library/search", indexHandler.Search)
+	api.Get("/library/tags", libraryHandler.Tags)
+	api.Get("/library/review", libraryHandler.ListReview)
+	api.Delete("/library/review/:id", libraryHandler.DismissReview)
+	api.Post("/library/review/:id/accept", importHandler.AcceptScan)
+	api.Get("/library/match", matchHandler.Status)
+	api.Post("/library/match", matchHandler.RunAll)
+	api.Get("/library/updates", updateHandler.List)
+	api.Post("/library/updates", updateHandler.RunAll)
+	api.Get("/library/:id", libraryHandler.Get)
+	api.Put("/library/:id", libraryHandler.Update)
+	api.Delete("/library/:id", libraryHandler.Delete)
+	api.Get("/library/:id/drummer", libraryHandler.Drummer)
+	api.Post("/library/:id/match", matchHandler.MatchSong)
+	api.Delete("/library/:id/match", matchHandler.Dismiss)
+	api.Post("/library/:id/merge", matchHandler.Merge)
+	api.Post("/library/:id/check", updateHandler.CheckSong)
+	api.Post("/library/:id/update", updateHandler.Accept)
+	api.Delete("/library/:id/update", updateHandler.Ignore)
+	api.Get("/library/:id/feedback", feedbackHandler.List)
+	api.Post("/library/:id/feedback", feedbackHandler.Rate)
+	api.Get("/library/:id/session", collabHandler.Session)
+	api.Post("/library/:id/sections/:section/lock", collabHandler.Lock)
+	api.Delete("/library/:id/sections/:section/lock", collabHandler.Unlock)
+	api.Put("/library/:id/sections/:section", collabHandler.EditSection)
+
+	// Chart accuracy feedback
+	api.Get("/feedback", feedbackHandler.Summary)
+
+	// Watchlist endpoints
+	api.Get("/watchlist", watchlistHandler.List)
+	api.Post("/watchlist", watchlistHandler.Create)
+	api.Post("/watchlist/run", watchlistHandler.RunAll)
+	api.Delete("/watchlist/:id", watchlistHandler.Delete)
+	api.Post("/watchlist/:id/check", watchlistHandler.Check)
+
+	// Setlist endpoints
+	api.Get("/setlists", setlistHandler.List)
+	api.Post("/setlists", setlistHandler.Create)
+	api.Post("/setlists/capture", captureHandler.Capture)
+	api.Get("/setlists/:id", setlistHandler.Get)
+	api.Put("/setlists/:id", setlistHandler.Update)
+	api.Delete("/setlists/:id", setlistHandler.Delete)
+	api.Post("/setlists/:id/songs", setlistHandler.AddSongs)
+	api.Post("/setlists/:id/medleys", setlistHandler.CreateMedley)
+	api.Delete("/setlists/:id/medleys/:medleyId", setlistHandler.DeleteMedley)
+	api.Get("/setlists/:id/pages", setlistHandler.Pages)
+	api.Get("/setlists/:id/export", setlistHandler.Export)
+
+	// Delivery manifest endpoints
+	api.Get("/manifests", manifestHandler.List)
+	api.Get("/manifests/:id", manifestHandler.Get)
+
+	// Device sync endpoints
+	api.Get("/sync/manifest", syncHandler.Manifest)
+	api.Get("/sync/blob/:hash", syncHandler.Blob)
+
+	// Saved export endpoints
+	api.Get("/files", filesHandler.List)
+	api.Get("/files/:name", filesHandler.Download)
+	api.Delete("/files/:name", filesHandler.Delete)
+
+	// Export format settings endpoints
+	api.Get("/settings/formats", settingsHandler.ListFormats)
+	api.Get("/settings/formats/:format", settingsHandler.GetFormat)
+	api.Put("/settings/formats/:format", settingsHandler.UpdateFormat)
+
+	// Import endpoints
+	api.Get("/import", importHandler.ListJobs)
+	api.Post("/import", importHandler.ImportURLs)
+	api.Post("/import/csv", importHandler.ImportCSV)
+	api.Post("/import/archive", importHandler.ImportArchive)
+	api.Post("/import/files", importHandler.ImportFiles)
+	api.Post("/import/image", importHandler.ImportImage)
+	api.Get("/import/:id", importHandler.GetJob)
 }
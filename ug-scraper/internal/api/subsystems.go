@@ -0,0 +1,96 @@
+package api
+
+import (
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/features"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/health"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/importer"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/index"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/mqtt"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/sharefolder"
+)
+
+// registerSubsystems puts the add-on's subsystems under the health monitor.
+// Weights reflect how much each matters to the add-on's main job of
+// fetching and converting tabs.
+func registerSubsystems(monitor *health.Monitor, capabilities *features.Registry, ugClient *scraper.UGClient, searchScraper *scraper.SearchScraper,
+	jobs *importer.JobManager, mqttClient *mqtt.Client, shareWriter *sharefolder.Writer, indexManager *index.Manager) {
+	monitor.Register(health.Subsystem{
+		Name:   "scraper",
+		Weight: 3,
+		Check: func() health.Check {
+			tabScore, tabDetail := ugClient.Health()
+			searchScore, searchDetail := searchScraper.Health()
+			return health.Check{
+				Score:  min(tabScore, searchScore),
+				Detail: "tabs: " + tabDetail + "; search: " + searchDetail,
+			}
+		},
+		Recover: func() (string, error) {
+			ugClient.ResetConnections()
+			searchScraper.ResetConnections()
+			return "reset the upstream connections", nil
+		},
+	})
+
+	monitor.Register(health.Subsystem{
+		Name:   "flaresolverr",
+		Weight: 1,
+		Check: func() health.Check {
+			if !searchScraper.FlareSolverrConfigured() {
+				return health.Check{Score: 1, Detail: "not configured", Disabled: true}
+			}
+			score, detail := searchScraper.FlareSolverrHealth()
+			return health.Check{Score: score, Detail: detail}
+		},
+	})
+
+	monitor.Register(health.Subsystem{
+		Name:   "jobs",
+		Weight: 2,
+		Check: func() health.Check {
+			score, detail := jobs.Health()
+			return health.Check{Score: score, Detail: detail}
+		},
+		Recover: jobs.Restart,
+	})
+
+	monitor.Register(health.Subsystem{
+		Name:   "mqtt",
+		Weight: 1,
+		Check: func() health.Check {
+			if !mqttClient.Enabled() {
+				return health.Check{Score: 1, Detail: "not configured", Disabled: true}
+			}
+			score, detail := mqttClient.Health()
+			return health.Check{Score: score, Detail: detail}
+		},
+		Recover: mqttClient.Reconnect,
+	})
+
+	monitor.Register(health.Subsystem{
+		Name:   "sync",
+		Weight: 1,
+		Check: func() health.Check {
+			if !shareWriter.Enabled() {
+				return health.Check{Score: 1, Detail: "share folder export not configured", Disabled: true}
+			}
+			score, detail := shareWriter.Health()
+			return health.Check{Score: score, Detail: detail}
+		},
+		Recover: shareWriter.Recreate,
+	})
+
+	monitor.Register(health.Subsystem{
+		Name:   "index",
+		Weight: 1,
+		Check: func() health.Check {
+			if !capabilities.Enabled(features.Library) {
+				return health.Check{Score: 1, Detail: "library switched off", Disabled: true}
+			}
+			score, detail := indexManager.Health()
+			return health.Check{Score: score, Detail: detail}
+		},
+		Recover: indexManager.Recover,
+	})
+}
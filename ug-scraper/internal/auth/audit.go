@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit event types
+const (
+	EventLoginSucceeded   = "login_succeeded"
+	EventLoginFailed      = "login_failed"
+	EventLockedOut        = "locked_out"
+	EventKeyInvalid       = "key_invalid"
+	EventKeyNewIP         = "key_new_ip"
+	EventPermissionDenied = "permission_denied"
+	EventKeyCreated       = "key_created"
+	EventKeyRotated       = "key_rotated"
+	EventKeyRevoked       = "key_revoked"
+	EventBurstRequested   = "burst_requested"
+	EventBurstEnabled     = "burst_enabled"
+	EventBurstEnded       = "burst_ended"
+	EventDataRepaired     = "data_repaired"
+	EventProvisioned      = "provisioned"
+	EventWebhookChanged   = "webhook_changed"
+	EventWebhookCleared   = "webhook_cleared"
+	EventSettingsChanged  = "settings_changed"
+	EventProfileCreated   = "profile_created"
+	EventProfileDeleted   = "profile_deleted"
+	EventSongDeleted      = "song_deleted"
+	EventSetlistDeleted   = "setlist_deleted"
+	EventWatchDeleted     = "watch_deleted"
+	EventBackupRestored   = "backup_restored"
+)
+
+// maxAuditEvents is how many events the log keeps
+const maxAuditEvents = 1000
+
+// AuditEvent records a security-relevant action, configuration change or
+// deletion
+type AuditEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	IP      string    `json:"ip,omitempty"`
+	Actor   string    `json:"actor,omitempty"` // Username or "key:<name>"
+	KeyID   string    `json:"key_id,omitempty"`
+	Method  string    `json:"method,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Details string    `json:"details,omitempty"`
+}
+
+// AuditLog keeps the most recent audit events, persisted to a JSON file
+type AuditLog struct {
+	mu         sync.RWMutex
+	events     []AuditEvent
+	filePath   string
+	persistent bool
+}
+
+// NewAuditLog creates a new audit log, loading existing events from filePath
+func NewAuditLog(filePath string) *AuditLog {
+	log := &AuditLog{
+		filePath:   filePath,
+		persistent: filePath != "",
+	}
+
+	if log.persistent {
+		if err := log.loadFromFile(); err != nil {
+			fmt.Printf("⚠️  Failed to load audit log: %v\n", err)
+		}
+	}
+
+	return log
+}
+
+// Record appends an event, stamping its time. Failures to save are logged
+// and never block the request being audited.
+func (l *AuditLog) Record(event AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	// Request values may point into buffers the web server reuses
+	event.IP = strings.Clone(event.IP)
+	event.Actor = strings.Clone(event.Actor)
+	event.KeyID = strings.Clone(event.KeyID)
+	event.Method = strings.Clone(event.Method)
+	event.Path = strings.Clone(event.Path)
+	event.Details = strings.Clone(event.Details)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, event)
+	if len(l.events) > maxAuditEvents {
+		l.events = l.events[len(l.events)-maxAuditEvents:]
+	}
+
+	if err := l.persist(); err != nil {
+		fmt.Printf("⚠️  Failed to save audit log: %v\n", err)
+	}
+}
+
+// List returns events newest first, optionally filtered by type and capped
+// at limit (0 for all)
+func (l *AuditLog) List(eventType string, limit int) []AuditEvent {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	events := []AuditEvent{}
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if eventType != "" && l.events[i].Type != eventType {
+			continue
+		}
+		events = append(events, l.events[i])
+		if limit > 0 && len(events) == limit {
+			break
+		}
+	}
+
+	return events
+}
+
+// persist saves the events to their JSON file (caller holds the lock)
+func (l *AuditLog) persist() error {
+	if !l.persistent {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.filePath), 0755); err != nil {
+		return fmt.Errorf("creating audit log directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(l.events, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling audit log: %w", err)
+	}
+
+	tmpPath := l.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	if err := os.Rename(tmpPath, l.filePath); err != nil {
+		return fmt.Errorf("replacing audit log: %w", err)
+	}
+
+	return nil
+}
+
+// loadFromFile loads the events from their JSON file
+func (l *AuditLog) loadFromFile() error {
+	raw, err := os.ReadFile(l.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, &l.events); err != nil {
+		return fmt.Errorf("unmarshaling audit log: %w", err)
+	}
+
+	return nil
+}
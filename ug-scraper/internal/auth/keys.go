@@ -0,0 +1,403 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles an API key can have, from least to most privileged
+const (
+	RoleReader = "reader" // GET requests outside /api/admin
+	RoleEditor = "editor" // Everything except /api/admin
+	RoleAdmin  = "admin"  // Everything, including key management
+)
+
+const (
+	keyPrefix = "ugs_"
+	// lastUsedPersistInterval limits how often last-used timestamps hit the disk
+	lastUsedPersistInterval = time.Minute
+	// maxKnownIPs is how many client addresses are remembered per key
+	maxKnownIPs = 20
+)
+
+// ErrNotFound is returned when an API key does not exist
+var ErrNotFound = fmt.Errorf("api key not found")
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	return role == RoleReader || role == RoleEditor || role == RoleAdmin
+}
+
+// APIKey is a named credential for an automation or client. Only a hash of
+// the secret is stored; the secret itself is shown once when created.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Prefix     string     `json:"prefix"` // First characters of the secret, to tell keys apart
+	Hash       string     `json:"hash"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	KnownIPs   []string   `json:"known_ips,omitempty"` // Addresses the key was used from, oldest first
+}
+
+// Active reports whether the key can still be used
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// RoutePath returns a request path the way the router matches it: routes
+// are matched case-insensitively and a trailing slash is ignored, so
+// "/API/Admin/keys/" reaches "/api/admin/keys"
+func RoutePath(p string) string {
+	return path.Clean("/" + strings.ToLower(p))
+}
+
+// Allows reports whether the key's role permits a request. Admin endpoints,
+// provisioning and backups are reserved for admin keys whatever the method.
+func (k *APIKey) Allows(method, requestPath string) bool {
+	if k.Role == RoleAdmin {
+		return true
+	}
+	p := RoutePath(requestPath)
+	if p == "/api/admin" || strings.HasPrefix(p, "/api/admin/") || p == "/api/provision" || p == "/api/backup" || p == "/api/restore" ||
+		p == "/api/backups" || strings.HasPrefix(p, "/api/backups/") {
+		return false
+	}
+
+	switch k.Role {
+	case RoleEditor:
+		return true
+	case RoleReader:
+		return method == "GET" || method == "HEAD"
+	default:
+		return false
+	}
+}
+
+// KeyStore manages API keys with thread-safe operations
+type KeyStore struct {
+	mu            sync.RWMutex
+	keys          map[string]*APIKey
+	filePath      string
+	persistent    bool
+	lastPersisted time.Time
+}
+
+// NewKeyStore creates a new key store, loading existing keys from filePath
+func NewKeyStore(filePath string) *KeyStore {
+	store := &KeyStore{
+		keys:       make(map[string]*APIKey),
+		filePath:   filePath,
+		persistent: filePath != "",
+	}
+
+	if store.persistent {
+		if err := store.loadFromFile(); err != nil {
+			fmt.Printf("⚠️  Failed to load API keys: %v\n", err)
+		}
+	}
+
+	return store
+}
+
+// Enabled reports whether any usable key exists. Without keys the API stays
+// open, as it was before keys were introduced.
+func (s *KeyStore) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.Active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create adds a key and returns it with its secret, which is not stored
+func (s *KeyStore) Create(name, role string, expiresAt *time.Time) (*APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", fmt.Errorf("name is required")
+	}
+	if !ValidRole(role) {
+		return nil, "", fmt.Errorf("role must be %s, %s or %s", RoleReader, RoleEditor, RoleAdmin)
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("expiry must be in the future")
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &APIKey{
+		ID:        "key_" + id,
+		Name:      strings.TrimSpace(name),
+		Role:      role,
+		Prefix:    secret[:len(keyPrefix)+6],
+		Hash:      hashSecret(secret),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = key
+	if err := s.persist(); err != nil {
+		delete(s.keys, key.ID)
+		return nil, "", err
+	}
+
+	keyCopy := *key
+	return &keyCopy, secret, nil
+}
+
+// Rotate replaces a key's secret, keeping its name, role and expiry
+func (s *KeyStore) Rotate(id string) (*APIKey, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return nil, "", ErrNotFound
+	}
+
+	key.Prefix = secret[:len(keyPrefix)+6]
+	key.Hash = hashSecret(secret)
+	key.LastUsedAt = nil
+	if err := s.persist(); err != nil {
+		return nil, "", err
+	}
+
+	keyCopy := *key
+	return &keyCopy, secret, nil
+}
+
+// Update changes a key's role and expiry, keeping its secret
+func (s *KeyStore) Update(id, role string, expiresAt *time.Time) (*APIKey, error) {
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("role must be %s, %s or %s", RoleReader, RoleEditor, RoleAdmin)
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	previous := *key
+	key.Role = role
+	key.ExpiresAt = expiresAt
+	if err := s.persist(); err != nil {
+		*key = previous
+		return nil, err
+	}
+
+	keyCopy := *key
+	return &keyCopy, nil
+}
+
+// Revoke disables a key; it stays listed so its history remains visible
+func (s *KeyStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+	}
+
+	return s.persist()
+}
+
+// List returns every key, newest first
+func (s *KeyStore) List() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys
+}
+
+// Authenticate returns the active key matching secret and records its use
+func (s *KeyStore) Authenticate(secret string) (*APIKey, bool) {
+	if !strings.HasPrefix(secret, keyPrefix) {
+		return nil, false
+	}
+	hash := hashSecret(secret)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) != 1 || !key.Active(now) {
+			continue
+		}
+
+		key.LastUsedAt = &now
+		if now.Sub(s.lastPersisted) > lastUsedPersistInterval {
+			if err := s.persist(); err != nil {
+				fmt.Printf("⚠️  Failed to save API key usage: %v\n", err)
+			}
+		}
+
+		keyCopy := *key
+		return &keyCopy, true
+	}
+
+	return nil, false
+}
+
+// NoteIP records that a key was used from ip and reports whether the address
+// is new for that key
+func (s *KeyStore) NoteIP(id, ip string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return false
+	}
+	for _, known := range key.KnownIPs {
+		if known == ip {
+			return false
+		}
+	}
+
+	key.KnownIPs = append(key.KnownIPs, ip)
+	if len(key.KnownIPs) > maxKnownIPs {
+		key.KnownIPs = key.KnownIPs[len(key.KnownIPs)-maxKnownIPs:]
+	}
+	if err := s.persist(); err != nil {
+		fmt.Printf("⚠️  Failed to save API key usage: %v\n", err)
+	}
+
+	return true
+}
+
+// persist saves the keys to their JSON file (caller holds the lock)
+func (s *KeyStore) persist() error {
+	if !s.persistent {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("creating key directory: %w", err)
+	}
+
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling api keys: %w", err)
+	}
+
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("writing api key file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.filePath); err != nil {
+		return fmt.Errorf("replacing api key file: %w", err)
+	}
+
+	s.lastPersisted = time.Now()
+	return nil
+}
+
+// loadFromFile loads the keys from their JSON file
+func (s *KeyStore) loadFromFile() error {
+	raw, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading api key file: %w", err)
+	}
+
+	var keys []*APIKey
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return fmt.Errorf("unmarshaling api keys: %w", err)
+	}
+
+	for _, key := range keys {
+		s.keys[key.ID] = key
+	}
+
+	return nil
+}
+
+// newSecret returns a new random API key secret
+func newSecret() (string, error) {
+	random, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	return keyPrefix + random, nil
+}
+
+// randomHex returns n random bytes hex encoded
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating random bytes: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashSecret returns the stored form of a secret. Secrets are long random
+// strings, so a plain SHA-256 is enough; no password hashing is needed.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxFailures is how many failed attempts an address gets before a lockout
+	maxFailures = 5
+	// failureWindow is how long failed attempts are remembered
+	failureWindow = 15 * time.Minute
+	// baseLockout is the first lockout; each further lockout doubles it
+	baseLockout = 30 * time.Second
+	// maxLockout caps the lockout duration
+	maxLockout = time.Hour
+	// lockoutMemory is how long an address must stay quiet to start over
+	lockoutMemory = 24 * time.Hour
+)
+
+// attempts tracks failed authentication from one address
+type attempts struct {
+	failures    int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Limiter slows down password and API key guessing: after maxFailures
+// failed attempts an address is locked out, for twice as long each time.
+type Limiter struct {
+	mu      sync.Mutex
+	clients map[string]*attempts
+}
+
+// NewLimiter creates a new failed-attempt limiter
+func NewLimiter() *Limiter {
+	return &Limiter{
+		clients: make(map[string]*attempts),
+	}
+}
+
+// Check returns how long ip must wait before trying again, or 0
+func (l *Limiter) Check(ip string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, ok := l.clients[ip]
+	if !ok {
+		return 0
+	}
+	if wait := time.Until(client.lockedUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail records a failed attempt and returns the lockout it triggered, or 0
+func (l *Limiter) Fail(ip string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	client, ok := l.clients[ip]
+	if !ok {
+		client = &attempts{}
+		l.clients[ip] = client
+	}
+	if now.Sub(client.lastFailure) > failureWindow {
+		client.failures = 0
+	}
+
+	client.failures++
+	client.lastFailure = now
+	if client.failures < maxFailures {
+		return 0
+	}
+
+	lockout := baseLockout << client.lockouts
+	if lockout > maxLockout || lockout <= 0 {
+		lockout = maxLockout
+	}
+	client.lockouts++
+	client.failures = 0
+	client.lockedUntil = now.Add(lockout)
+
+	return lockout
+}
+
+// Succeed clears the failed attempts of ip
+func (l *Limiter) Succeed(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.clients, ip)
+}
+
+// prune forgets addresses that have been quiet long enough (caller holds the lock)
+func (l *Limiter) prune(now time.Time) {
+	for ip, client := range l.clients {
+		if now.Sub(client.lastFailure) > lockoutMemory {
+			delete(l.clients, ip)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+	"time"
+)
+
+const (
+	// SessionCookie is the name of the HttpOnly login session cookie
+	SessionCookie = "ugs_session"
+	// CSRFCookie holds the CSRF token of browsers without a session
+	CSRFCookie = "ugs_csrf"
+	// SessionTTL is how long a session lasts without being used
+	SessionTTL = 24 * time.Hour
+	// sessionMaxAge caps a session's lifetime however often it is used
+	sessionMaxAge = 7 * 24 * time.Hour
+)
+
+// How a session was established
+const (
+	LoginPassword = "password"
+	LoginIngress  = "ingress"
+)
+
+// ingressProxyIP is the address Home Assistant's ingress proxy connects from
+const ingressProxyIP = "172.30.32.2"
+
+// FromIngress reports whether a request came through Home Assistant ingress,
+// which only lets users HA has already authenticated through
+func FromIngress(remoteIP, ingressPath string) bool {
+	return remoteIP == ingressProxyIP && ingressPath != ""
+}
+
+// Session is a logged-in browser. The ID travels in the session cookie; the
+// CSRF token must be echoed in the X-CSRF-Token header on unsafe requests.
+type Session struct {
+	ID        string    `json:"-"`
+	Username  string    `json:"username"`
+	Via       string    `json:"via"`
+	CSRFToken string    `json:"csrf_token"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ValidCSRF reports whether token matches the session's CSRF token
+func (s *Session) ValidCSRF(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.CSRFToken)) == 1
+}
+
+// NewCSRFToken returns a random token for the CSRF cookie
+func NewCSRFToken() (string, error) {
+	return randomHex(32)
+}
+
+// SessionStore keeps login sessions for the web UI in memory; a restart logs
+// everyone out
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	username string
+	password [32]byte
+}
+
+// NewSessionStore creates a session store. Password login is available when
+// both username and password are set.
+func NewSessionStore(username, password string) *SessionStore {
+	store := &SessionStore{
+		sessions: make(map[string]*Session),
+		username: username,
+	}
+	if username != "" && password != "" {
+		store.password = sha256.Sum256([]byte(password))
+	}
+	return store
+}
+
+// PasswordLogin reports whether a UI username and password are configured
+func (s *SessionStore) PasswordLogin() bool {
+	return s.password != [32]byte{}
+}
+
+// CheckPassword reports whether the credentials match the configured ones
+func (s *SessionStore) CheckPassword(username, password string) bool {
+	if !s.PasswordLogin() {
+		return false
+	}
+
+	// Compare hashes so the comparison takes the same time whatever the length
+	wantUser := sha256.Sum256([]byte(s.username))
+	gotUser := sha256.Sum256([]byte(username))
+	gotPassword := sha256.Sum256([]byte(password))
+
+	userOK := subtle.ConstantTimeCompare(wantUser[:], gotUser[:])
+	passwordOK := subtle.ConstantTimeCompare(s.password[:], gotPassword[:])
+	return userOK&passwordOK == 1
+}
+
+// Create starts a session for username
+func (s *SessionStore) Create(username, via string) (*Session, error) {
+	id, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	csrf, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:        id,
+		Username:  username,
+		Via:       via,
+		CSRFToken: csrf,
+		CreatedAt: now,
+		ExpiresAt: now.Add(SessionTTL),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+	s.sessions[id] = session
+
+	sessionCopy := *session
+	return &sessionCopy, nil
+}
+
+// Get returns the live session with the given ID and extends it
+func (s *SessionStore) Get(id string) (*Session, bool) {
+	if id == "" {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return nil, false
+	}
+
+	session.ExpiresAt = now.Add(SessionTTL)
+	if limit := session.CreatedAt.Add(sessionMaxAge); session.ExpiresAt.After(limit) {
+		session.ExpiresAt = limit
+	}
+
+	sessionCopy := *session
+	return &sessionCopy, true
+}
+
+// Delete ends a session
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+}
+
+// prune drops expired sessions (caller holds the lock)
+func (s *SessionStore) prune(now time.Time) {
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
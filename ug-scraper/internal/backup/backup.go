@@ -0,0 +1,244 @@
+// Package backup packs the add-on's data into a single zip archive and
+// restores it, so moving to a new Home Assistant box keeps the webhook,
+// export settings, library, setlists and profile history. API keys and the
+// audit log stay with the box they belong to.
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// FormatVersion is the archive layout written by this version
+const FormatVersion = 1
+
+// manifestFile describes the archive; the sections are stored next to it
+const manifestFile = "backup.json"
+
+// maxSectionSize bounds one unpacked section
+const maxSectionSize = 256 << 20
+
+// Sections of a backup, in the order they are restored
+const (
+	SectionSettings = "settings" // Export format defaults
+	SectionWebhook  = "webhook"  // Shared webhook target
+	SectionProfiles = "profiles" // Profiles with their webhooks, favorites and history
+	SectionLibrary  = "library"  // Songs, setlists, watches, review queue and manifests
+)
+
+// Source is a store that can be backed up: Snapshot returns its data and
+// Restore replaces everything with such data
+type Source interface {
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
+// Manifest describes a backup
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Sections      []string  `json:"sections"`
+}
+
+// Backup is a backup archive read and checked, ready to restore
+type Backup struct {
+	Manifest
+	Missing   []string // Sections asked for but not in the archive
+	snapshots map[string][]byte
+}
+
+// Report is what a restore did
+type Report struct {
+	CreatedAt time.Time `json:"created_at"` // When the backup was taken
+	Restored  []string  `json:"restored"`
+	Missing   []string  `json:"missing,omitempty"` // Sections asked for but not in the backup
+}
+
+// section is one store and its file in the archive
+type section struct {
+	name   string
+	source Source
+}
+
+// Manager backs up and restores the stores
+type Manager struct {
+	sections []section
+}
+
+// NewManager creates a backup manager for the given stores
+func NewManager(settings, webhook, profiles, library Source) *Manager {
+	return &Manager{
+		sections: []section{
+			{SectionSettings, settings},
+			{SectionWebhook, webhook},
+			{SectionProfiles, profiles},
+			{SectionLibrary, library},
+		},
+	}
+}
+
+// Sections lists the backup sections in restore order
+func (m *Manager) Sections() []string {
+	names := make([]string, len(m.sections))
+	for i, s := range m.sections {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Write snapshots every store and writes them as a zip archive. The
+// snapshots are all taken before anything is written, so a slow client
+// doesn't hold up the stores.
+func (m *Manager) Write(w io.Writer) (*Manifest, error) {
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now(),
+	}
+	snapshots := make([][]byte, len(m.sections))
+	for i, s := range m.sections {
+		data, err := s.source.Snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		snapshots[i] = data
+		manifest.Sections = append(manifest.Sections, s.name)
+	}
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling manifest: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeEntry(zw, manifestFile, encoded, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	for i, s := range m.sections {
+		if err := writeEntry(zw, s.name+".json", snapshots[i], manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("finishing archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// writeEntry adds one file to the archive
+func writeEntry(zw *zip.Writer, name string, data []byte, modified time.Time) error {
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("adding %s: %w", name, err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// Read unpacks and checks a backup archive, keeping only the named sections
+// when any are given
+func (m *Manager) Read(archive []byte, only []string) (*Backup, error) {
+	for _, name := range only {
+		if !slices.Contains(m.Sections(), name) {
+			return nil, fmt.Errorf("unknown section %q", name)
+		}
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("reading archive: %w", err)
+	}
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	manifestEntry, ok := entries[manifestFile]
+	if !ok {
+		return nil, fmt.Errorf("not a backup: %s is missing", manifestFile)
+	}
+	raw, err := readEntry(manifestEntry)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", manifestFile, err)
+	}
+	if manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("backup format v%d is newer than this version supports (v%d)", manifest.FormatVersion, FormatVersion)
+	}
+
+	b := &Backup{Manifest: manifest, snapshots: make(map[string][]byte)}
+	for _, s := range m.sections {
+		if len(only) > 0 && !slices.Contains(only, s.name) {
+			continue
+		}
+		entry, ok := entries[s.name+".json"]
+		if !ok {
+			b.Missing = append(b.Missing, s.name)
+			continue
+		}
+		data, err := readEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("%s: not valid JSON", entry.Name)
+		}
+		b.snapshots[s.name] = data
+	}
+
+	return b, nil
+}
+
+// Restore replaces the stores with the sections of a backup. Should a store
+// fail to restore, the report lists the sections restored before it.
+func (m *Manager) Restore(b *Backup) (*Report, error) {
+	report := &Report{CreatedAt: b.CreatedAt, Restored: []string{}, Missing: b.Missing}
+	for _, s := range m.sections {
+		data, ok := b.snapshots[s.name]
+		if !ok {
+			continue
+		}
+		if err := s.source.Restore(data); err != nil {
+			return report, fmt.Errorf("restoring %s: %w", s.name, err)
+		}
+		report.Restored = append(report.Restored, s.name)
+	}
+
+	return report, nil
+}
+
+// readEntry unpacks one file of the archive
+func readEntry(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxSectionSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", f.Name, maxSectionSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxSectionSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+	}
+	if len(data) > maxSectionSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", f.Name, maxSectionSize)
+	}
+	return data, nil
+}
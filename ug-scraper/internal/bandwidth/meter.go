@@ -0,0 +1,403 @@
+package bandwidth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Upstreams whose downloads are counted
+const (
+	UpstreamUGAPI        = "ug_api"       // UG app API: tabs and files
+	UpstreamUGWeb        = "ug_web"       // UG website: search and artist pages
+	UpstreamFlareSolverr = "flaresolverr" // Pages fetched through FlareSolverr
+)
+
+const (
+	// keepDays is how many days of totals are kept
+	keepDays = 31
+	// maxJobs is how many import jobs keep their totals
+	maxJobs = 50
+	// saveEvery limits how often totals are written to disk
+	saveEvery = 30 * time.Second
+	dayFormat = "2006-01-02"
+)
+
+// ErrCapReached is returned for requests made after the daily cap is used up
+var ErrCapReached = errors.New("daily bandwidth cap reached")
+
+// Config holds the daily download cap
+type Config struct {
+	DailyCap int64 // Bytes per day; 0 for no cap
+}
+
+// ConfigFromEnv reads BANDWIDTH_DAILY_CAP_MB
+func ConfigFromEnv() Config {
+	var cfg Config
+	if v := os.Getenv("BANDWIDTH_DAILY_CAP_MB"); v != "" {
+		if mb, err := strconv.ParseFloat(v, 64); err == nil && mb > 0 {
+			cfg.DailyCap = int64(mb * 1024 * 1024)
+		} else if v != "0" {
+			fmt.Printf("⚠️  Ignoring BANDWIDTH_DAILY_CAP_MB=%q\n", v)
+		}
+	}
+	return cfg
+}
+
+// Day is the bytes downloaded from each upstream on one day
+type Day struct {
+	Date      string           `json:"date"`
+	Upstreams map[string]int64 `json:"upstreams"`
+	Total     int64            `json:"total"`
+}
+
+// Job is the bytes downloaded while an import job ran
+type Job struct {
+	ID        string           `json:"id"`
+	Upstreams map[string]int64 `json:"upstreams"`
+	Total     int64            `json:"total"`
+	Running   bool             `json:"running"`
+}
+
+// Stats is a snapshot of the meter
+type Stats struct {
+	Today      Day              `json:"today"`
+	DailyCap   int64            `json:"daily_cap,omitempty"`
+	Remaining  *int64           `json:"remaining,omitempty"` // Left under the cap today
+	SinceStart map[string]int64 `json:"since_start"`
+	Days       []Day            `json:"days"` // Newest first
+	Jobs       []Job            `json:"jobs"`
+}
+
+// Meter counts bytes downloaded from each upstream per day and per import
+// job, and refuses requests once the daily cap is used up. Daily totals are
+// saved so a restart doesn't reset the cap.
+type Meter struct {
+	cfg Config
+
+	mu         sync.Mutex
+	days       map[string]map[string]int64 // date -> upstream -> bytes
+	sinceStart map[string]int64
+	jobs       map[string]map[string]int64
+	jobOrder   []string
+	running    map[string]bool
+	filePath   string
+	lastSave   time.Time
+	dirty      bool
+}
+
+// NewMeter creates a meter, loading earlier daily totals from filePath
+func NewMeter(cfg Config, filePath string) *Meter {
+	m := &Meter{
+		cfg:        cfg,
+		days:       make(map[string]map[string]int64),
+		sinceStart: make(map[string]int64),
+		jobs:       make(map[string]map[string]int64),
+		running:    make(map[string]bool),
+		filePath:   filePath,
+	}
+
+	if filePath != "" {
+		if err := m.loadFromFile(); err != nil {
+			fmt.Printf("⚠️  Failed to load bandwidth totals: %v\n", err)
+		}
+	}
+
+	return m
+}
+
+// Transport wraps base so the responses it receives count towards upstream
+func (m *Meter) Transport(upstream string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &meteredTransport{meter: m, upstream: upstream, base: base}
+}
+
+// Client returns a copy of client that counts its downloads towards upstream
+func (m *Meter) Client(upstream string, client *http.Client) *http.Client {
+	metered := *client
+	metered.Transport = m.Transport(upstream, client.Transport)
+	return &metered
+}
+
+// Allow returns ErrCapReached when today's downloads have used up the cap
+func (m *Meter) Allow() error {
+	if m.cfg.DailyCap <= 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if total(m.days[today()]) >= m.cfg.DailyCap {
+		return ErrCapReached
+	}
+	return nil
+}
+
+// Add counts n bytes downloaded from upstream
+func (m *Meter) Add(upstream string, n int64) {
+	m.add(upstream, "", n)
+}
+
+// add counts n bytes downloaded from upstream, also towards a running job
+func (m *Meter) add(upstream, jobID string, n int64) {
+	if n <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	date := today()
+	if m.days[date] == nil {
+		m.days[date] = make(map[string]int64)
+		m.prune()
+	}
+	m.days[date][upstream] += n
+	m.sinceStart[upstream] += n
+	if job := m.jobs[jobID]; job != nil && m.running[jobID] {
+		job[upstream] += n
+	}
+
+	m.dirty = true
+	if time.Since(m.lastSave) >= saveEvery {
+		m.save()
+	}
+}
+
+// jobKey carries the import job a request is made for
+type jobKey struct{}
+
+// WithJob marks requests made with the returned context as part of an
+// import job, so several jobs running at once each get their own totals
+func WithJob(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, jobKey{}, id)
+}
+
+// StartJob attributes downloads of requests made with WithJob(ctx, id) to
+// an import job until EndJob
+func (m *Meter) StartJob(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running[id] = true
+	if m.jobs[id] == nil {
+		m.jobs[id] = make(map[string]int64)
+		m.jobOrder = append(m.jobOrder, id)
+	}
+	for len(m.jobOrder) > maxJobs {
+		delete(m.jobs, m.jobOrder[0])
+		m.jobOrder = m.jobOrder[1:]
+	}
+}
+
+// EndJob stops attributing downloads to a job and returns its totals
+func (m *Meter) EndJob(id string) Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.running, id)
+	if m.dirty {
+		m.save()
+	}
+	return m.job(id)
+}
+
+// Job returns the totals of an import job
+func (m *Meter) Job(id string) Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.job(id)
+}
+
+// Stats returns today's totals, the cap, earlier days and recent jobs
+func (m *Meter) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := Stats{
+		Today:      day(today(), m.days[today()]),
+		DailyCap:   m.cfg.DailyCap,
+		SinceStart: copyCounts(m.sinceStart),
+		Days:       []Day{},
+		Jobs:       []Job{},
+	}
+	if m.cfg.DailyCap > 0 {
+		remaining := max(m.cfg.DailyCap-stats.Today.Total, 0)
+		stats.Remaining = &remaining
+	}
+
+	dates := make([]string, 0, len(m.days))
+	for date := range m.days {
+		dates = append(dates, date)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	for _, date := range dates {
+		stats.Days = append(stats.Days, day(date, m.days[date]))
+	}
+
+	for i := len(m.jobOrder) - 1; i >= 0; i-- {
+		stats.Jobs = append(stats.Jobs, m.job(m.jobOrder[i]))
+	}
+
+	return stats
+}
+
+// job returns a job's totals (caller holds the lock)
+func (m *Meter) job(id string) Job {
+	counts := m.jobs[id]
+	return Job{
+		ID:        id,
+		Upstreams: copyCounts(counts),
+		Total:     total(counts),
+		Running:   m.running[id],
+	}
+}
+
+// prune drops days older than keepDays (caller holds the lock)
+func (m *Meter) prune() {
+	oldest := time.Now().AddDate(0, 0, -keepDays).Format(dayFormat)
+	for date := range m.days {
+		if date < oldest {
+			delete(m.days, date)
+		}
+	}
+}
+
+// save writes the daily totals to disk (caller holds the lock). Failures
+// are logged; counting goes on regardless.
+func (m *Meter) save() {
+	m.lastSave = time.Now()
+	if m.filePath == "" {
+		m.dirty = false
+		return
+	}
+
+	if err := m.persist(); err != nil {
+		fmt.Printf("⚠️  Failed to save bandwidth totals: %v\n", err)
+		return
+	}
+	m.dirty = false
+}
+
+// persist writes the daily totals to their JSON file
+func (m *Meter) persist() error {
+	if err := os.MkdirAll(filepath.Dir(m.filePath), 0755); err != nil {
+		return fmt.Errorf("creating bandwidth directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(m.days, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling bandwidth totals: %w", err)
+	}
+
+	tmpPath := m.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("writing bandwidth totals: %w", err)
+	}
+	if err := os.Rename(tmpPath, m.filePath); err != nil {
+		return fmt.Errorf("replacing bandwidth totals: %w", err)
+	}
+
+	return nil
+}
+
+// loadFromFile loads daily totals saved by an earlier run
+func (m *Meter) loadFromFile() error {
+	data, err := os.ReadFile(m.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading bandwidth totals: %w", err)
+	}
+
+	var days map[string]map[string]int64
+	if err := json.Unmarshal(data, &days); err != nil {
+		return fmt.Errorf("unmarshaling bandwidth totals: %w", err)
+	}
+	for date, counts := range days {
+		if counts != nil {
+			m.days[date] = counts
+		}
+	}
+	m.prune()
+
+	return nil
+}
+
+// meteredTransport counts response bodies as they are read
+type meteredTransport struct {
+	meter    *Meter
+	upstream string
+	base     http.RoundTripper
+}
+
+// RoundTrip refuses requests over the cap and counts the response
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.meter.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	jobID, _ := req.Context().Value(jobKey{}).(string)
+	resp.Body = &countingBody{ReadCloser: resp.Body, meter: t.meter, upstream: t.upstream, jobID: jobID}
+	return resp, nil
+}
+
+// countingBody adds every byte read to the meter
+type countingBody struct {
+	io.ReadCloser
+	meter    *Meter
+	upstream string
+	jobID    string
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.meter.add(b.upstream, b.jobID, int64(n))
+	return n, err
+}
+
+// day builds the totals of one day
+func day(date string, counts map[string]int64) Day {
+	return Day{Date: date, Upstreams: copyCounts(counts), Total: total(counts)}
+}
+
+// today is the current local date
+func today() string {
+	return time.Now().Format(dayFormat)
+}
+
+// total sums the counts of all upstreams
+func total(counts map[string]int64) int64 {
+	var sum int64
+	for _, n := range counts {
+		sum += n
+	}
+	return sum
+}
+
+// copyCounts returns a copy of counts that is never nil
+func copyCounts(counts map[string]int64) map[string]int64 {
+	copied := make(map[string]int64, len(counts))
+	for upstream, n := range counts {
+		copied[upstream] = n
+	}
+	return copied
+}
@@ -0,0 +1,43 @@
+package bandwidth
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// WritePrometheus writes the meter in the Prometheus text exposition format
+func (m *Meter) WritePrometheus(w io.Writer) {
+	stats := m.Stats()
+
+	fmt.Fprintln(w, "# HELP ug_scraper_downloaded_bytes_total Bytes downloaded from each upstream since the add-on started.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_downloaded_bytes_total counter")
+	writeUpstreams(w, "ug_scraper_downloaded_bytes_total", "", stats.SinceStart)
+
+	fmt.Fprintln(w, "# HELP ug_scraper_downloaded_bytes_today Bytes downloaded from each upstream today.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_downloaded_bytes_today gauge")
+	writeUpstreams(w, "ug_scraper_downloaded_bytes_today", "", stats.Today.Upstreams)
+
+	fmt.Fprintln(w, "# HELP ug_scraper_bandwidth_daily_cap_bytes Daily download cap, 0 when there is none.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_bandwidth_daily_cap_bytes gauge")
+	fmt.Fprintf(w, "ug_scraper_bandwidth_daily_cap_bytes %d\n", stats.DailyCap)
+
+	fmt.Fprintln(w, "# HELP ug_scraper_import_job_downloaded_bytes Bytes downloaded from each upstream during recent import jobs.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_import_job_downloaded_bytes gauge")
+	for _, job := range stats.Jobs {
+		writeUpstreams(w, "ug_scraper_import_job_downloaded_bytes", fmt.Sprintf("job=%q,", job.ID), job.Upstreams)
+	}
+}
+
+// writeUpstreams writes one sample per upstream, in a stable order
+func writeUpstreams(w io.Writer, name, labels string, counts map[string]int64) {
+	upstreams := make([]string, 0, len(counts))
+	for upstream := range counts {
+		upstreams = append(upstreams, upstream)
+	}
+	sort.Strings(upstreams)
+
+	for _, upstream := range upstreams {
+		fmt.Fprintf(w, "%s{%supstream=%q} %d\n", name, labels, upstream, counts[upstream])
+	}
+}
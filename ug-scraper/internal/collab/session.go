@@ -0,0 +1,253 @@
+package collab
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+const (
+	// LockTTL is how long a section lock lasts without being renewed
+	LockTTL = 2 * time.Minute
+	// presenceTTL is how long an editor is listed after their last request
+	presenceTTL = 5 * time.Minute
+)
+
+var (
+	// ErrLocked is returned when another editor holds the section lock
+	ErrLocked = fmt.Errorf("section is locked by another editor")
+	// ErrNotLocked is returned when editing a section without holding its lock
+	ErrNotLocked = fmt.Errorf("lock the section before editing it")
+	// ErrNoSection is returned for a section index outside the chart
+	ErrNoSection = fmt.Errorf("section does not exist")
+)
+
+// Lock is an editor's claim on one section of a chart
+type Lock struct {
+	Editor    string    `json:"editor"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Section is a blank-line separated block of a chart. Section 0 is the
+// header with title, artist and metadata.
+type Section struct {
+	Index int    `json:"index"`
+	Label string `json:"label,omitempty"`
+	Text  string `json:"text"`
+	Lock  *Lock  `json:"lock,omitempty"`
+}
+
+// State is the collaboration view of a chart that editors poll
+type State struct {
+	SongID   string    `json:"song_id"`
+	Revision int       `json:"revision"`
+	Editors  []string  `json:"editors"`
+	Sections []Section `json:"sections"`
+}
+
+// session tracks locks and presence for one song
+type session struct {
+	locks   map[int]Lock
+	editors map[string]time.Time
+}
+
+// Manager coordinates section-level locking so several people can clean up
+// the same chart at once: each editor locks the section they work on, and
+// saving a section merges it into the latest chart without touching the rest.
+type Manager struct {
+	mu       sync.Mutex
+	store    *library.Store
+	sessions map[string]*session
+}
+
+// NewManager creates a new collaboration manager
+func NewManager(store *library.Store) *Manager {
+	return &Manager{
+		store:    store,
+		sessions: make(map[string]*session),
+	}
+}
+
+// State returns the chart split into sections with their current locks.
+// editor, when set, is recorded as present in the session.
+func (m *Manager) State(songID, editor string) (*State, error) {
+	song, ok := m.store.Get(songID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess := m.session(songID)
+	if editor != "" {
+		sess.editors[strings.Clone(editor)] = time.Now()
+	}
+
+	return m.state(song, sess), nil
+}
+
+// Lock claims a section for editor, or renews the editor's existing lock
+func (m *Manager) Lock(songID string, index int, editor string) (*Lock, error) {
+	song, ok := m.store.Get(songID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+	if index < 0 || index >= len(SplitSections(song.OnSongFormat)) {
+		return nil, ErrNoSection
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess := m.session(songID)
+	if lock, ok := sess.locks[index]; ok && lock.Editor != editor {
+		return &lock, ErrLocked
+	}
+
+	editor = strings.Clone(editor)
+	lock := Lock{Editor: editor, ExpiresAt: time.Now().Add(LockTTL)}
+	sess.locks[index] = lock
+	sess.editors[editor] = time.Now()
+
+	return &lock, nil
+}
+
+// Unlock releases editor's lock on a section
+func (m *Manager) Unlock(songID string, index int, editor string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess := m.session(songID)
+	lock, ok := sess.locks[index]
+	if !ok {
+		return nil
+	}
+	if lock.Editor != editor {
+		return ErrLocked
+	}
+
+	delete(sess.locks, index)
+	return nil
+}
+
+// EditSection replaces one section of the chart. The editor must hold the
+// section lock; the change is applied to the latest revision so concurrent
+// edits to other sections are kept. Sections cannot contain blank lines,
+// since that would renumber every section after them.
+func (m *Manager) EditSection(songID string, index int, editor, text string) (*library.Song, error) {
+	text = strings.Trim(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if strings.Contains(text, "\n\n") {
+		return nil, fmt.Errorf("section text cannot contain blank lines")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("section text cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess := m.session(songID)
+	if lock, ok := sess.locks[index]; !ok || lock.Editor != editor {
+		return nil, ErrNotLocked
+	}
+
+	song, ok := m.store.Get(songID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+
+	sections := SplitSections(song.OnSongFormat)
+	if index < 0 || index >= len(sections) {
+		return nil, ErrNoSection
+	}
+	sections[index] = text
+	song.OnSongFormat = strings.Join(sections, "\n\n") + "\n"
+
+	// Renew the lock: saving counts as activity
+	editor = strings.Clone(editor)
+	sess.locks[index] = Lock{Editor: editor, ExpiresAt: time.Now().Add(LockTTL)}
+	sess.editors[editor] = time.Now()
+
+	if err := m.store.SaveIfMatch(song, song.Revision); err != nil {
+		return nil, err
+	}
+
+	return song, nil
+}
+
+// session returns the session for a song, dropping expired locks and idle
+// editors (caller holds the lock). IDs and names are cloned before being kept,
+// since request values may point into buffers the web server reuses.
+func (m *Manager) session(songID string) *session {
+	sess, ok := m.sessions[songID]
+	if !ok {
+		sess = &session{
+			locks:   make(map[int]Lock),
+			editors: make(map[string]time.Time),
+		}
+		m.sessions[strings.Clone(songID)] = sess
+	}
+
+	now := time.Now()
+	for index, lock := range sess.locks {
+		if now.After(lock.ExpiresAt) {
+			delete(sess.locks, index)
+		}
+	}
+	for editor, seen := range sess.editors {
+		if now.Sub(seen) > presenceTTL {
+			delete(sess.editors, editor)
+		}
+	}
+
+	return sess
+}
+
+// state builds the polled view of a session (caller holds the lock)
+func (m *Manager) state(song *library.Song, sess *session) *State {
+	state := &State{
+		SongID:   song.ID,
+		Revision: song.Revision,
+		Editors:  make([]string, 0, len(sess.editors)),
+	}
+	for editor := range sess.editors {
+		state.Editors = append(state.Editors, editor)
+	}
+
+	for i, text := range SplitSections(song.OnSongFormat) {
+		section := Section{Index: i, Label: sectionLabel(text), Text: text}
+		if lock, ok := sess.locks[i]; ok {
+			section.Lock = &lock
+		}
+		state.Sections = append(state.Sections, section)
+	}
+
+	return state
+}
+
+// SplitSections splits a chart into blank-line separated blocks
+func SplitSections(chart string) []string {
+	chart = strings.Trim(strings.ReplaceAll(chart, "\r\n", "\n"), "\n")
+
+	var sections []string
+	for _, block := range strings.Split(chart, "\n\n") {
+		if block = strings.Trim(block, "\n"); block != "" {
+			sections = append(sections, block)
+		}
+	}
+
+	return sections
+}
+
+// sectionLabel returns the "Verse 1:" style label of a section, if any
+func sectionLabel(text string) string {
+	first, _, _ := strings.Cut(text, "\n")
+	if label, ok := strings.CutSuffix(strings.TrimSpace(first), ":"); ok && len(label) <= 30 {
+		return label
+	}
+	return ""
+}
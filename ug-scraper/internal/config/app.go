@@ -0,0 +1,497 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultAppFile is the optional settings file read at startup
+	DefaultAppFile = "/data/ug-scraper.yaml"
+	// DefaultOptionsFile is where the Supervisor writes the add-on options
+	DefaultOptionsFile = "/data/options.json"
+	// DefaultWebhookFile holds the webhook target saved from the UI
+	DefaultWebhookFile = "/data/webhook-config.json"
+)
+
+// Log levels
+const (
+	LogDebug = "debug" // Request log with client address and errors
+	LogInfo  = "info"  // Request log
+	LogWarn  = "warn"  // No request log
+	LogError = "error" // No request log
+)
+
+// knownSolvers are the names SOLVERS accepts
+var knownSolvers = map[string]bool{"direct": true, "flaresolverr": true, "byparr": true, "chrome": true}
+
+// App is the server configuration. It is read once at startup from, in
+// increasing priority: built-in defaults, the add-on options, the settings
+// file and environment variables.
+type App struct {
+	Server     ServerConfig    `json:"server" yaml:"server"`
+	Logging    LoggingConfig   `json:"logging" yaml:"logging"`
+	Solvers    SolverConfig    `json:"solvers" yaml:"solvers"`
+	Cache      CacheConfig     `json:"cache" yaml:"cache"`
+	RateLimits RateLimitConfig `json:"rate_limits" yaml:"rate_limits"`
+	Webhook    WebhookTarget   `json:"webhook" yaml:"webhook"`
+	Exports    ExportConfig    `json:"exports" yaml:"exports"`
+
+	// Sources lists where the settings came from, for the startup log
+	Sources []string `json:"-" yaml:"-"`
+
+	// passthrough holds the other add-on options, by environment variable
+	passthrough map[string]string
+}
+
+// ServerConfig is the HTTP listener
+type ServerConfig struct {
+	Port int `json:"port" yaml:"port"`
+	// FrameAncestors are origins besides the add-on's own (which covers
+	// Home Assistant ingress) allowed to show the web UI in a frame; "*"
+	// allows any
+	FrameAncestors []string `json:"frame_ancestors" yaml:"frame_ancestors"`
+}
+
+// LoggingConfig controls how chatty the server is
+type LoggingConfig struct {
+	Level string `json:"level" yaml:"level"`
+}
+
+// SolverConfig is the chain of Cloudflare challenge solvers
+type SolverConfig struct {
+	Order           []string `json:"order" yaml:"order"`
+	Routing         string   `json:"routing" yaml:"routing"`
+	FlareSolverrURL string   `json:"flaresolverr_url" yaml:"flaresolverr_url"`
+	ByparrURL       string   `json:"byparr_url" yaml:"byparr_url"`
+	ChromeURL       string   `json:"chrome_url" yaml:"chrome_url"`
+	CheckInterval   string   `json:"check_interval" yaml:"check_interval"` // Go duration or seconds, "0" disables
+}
+
+// CacheConfig sizes the in-memory caches; 0 sizes them for the hardware
+type CacheConfig struct {
+	SuggestSize int `json:"suggest_size" yaml:"suggest_size"`
+}
+
+// RateLimitConfig bounds the load put on Ultimate Guitar and the host;
+// 0 means no cap, or a value sized for the hardware
+type RateLimitConfig struct {
+	BandwidthDailyCapMB float64 `json:"bandwidth_daily_cap_mb" yaml:"bandwidth_daily_cap_mb"`
+	UGRetries           int     `json:"ug_retries" yaml:"ug_retries"`
+	ImportWorkers       int     `json:"import_workers" yaml:"import_workers"`
+	PDFConcurrency      int     `json:"pdf_concurrency" yaml:"pdf_concurrency"`
+}
+
+// WebhookTarget is the webhook set up at startup. A URL here replaces the
+// one saved from the UI on every start; leave it empty to manage the
+// webhook from the UI only.
+type WebhookTarget struct {
+	URL           string            `json:"url" yaml:"url"`
+	Enabled       bool              `json:"enabled" yaml:"enabled"`
+	Headers       map[string]string `json:"headers,omitempty" yaml:"headers"`
+	SchemaVersion int               `json:"schema_version" yaml:"schema_version"`
+	IncludeBundle bool              `json:"include_bundle" yaml:"include_bundle"`
+	File          string            `json:"file" yaml:"file"` // Where the UI saves the webhook
+}
+
+// ExportConfig is where converted songs are written outside the library
+type ExportConfig struct {
+	ShareDir         string `json:"share_dir" yaml:"share_dir"` // Empty disables the share folder export
+	FilenameTemplate string `json:"filename_template" yaml:"filename_template"`
+	ExistingFiles    string `json:"existing_files" yaml:"existing_files"` // overwrite or skip
+	DropboxFolder    string `json:"dropbox_folder" yaml:"dropbox_folder"`
+}
+
+// Defaults returns the configuration used when nothing is set
+func Defaults() *App {
+	return &App{
+		Server:     ServerConfig{Port: 8080},
+		Logging:    LoggingConfig{Level: LogInfo},
+		Solvers:    SolverConfig{Order: []string{"flaresolverr", "direct"}, Routing: "adaptive", CheckInterval: "1m"},
+		RateLimits: RateLimitConfig{UGRetries: 3},
+		Webhook:    WebhookTarget{File: DefaultWebhookFile},
+		Exports:    ExportConfig{FilenameTemplate: "{artist} - {title}", ExistingFiles: "overwrite", DropboxFolder: "/Apps/OnSong"},
+	}
+}
+
+// binding ties a setting to its environment variable and add-on option
+type binding struct {
+	env    string
+	option string // Empty when the add-on has no such option
+	set    func(string) error
+	get    func() string
+}
+
+// bindings lists every setting that can come from the environment or the
+// add-on options. get returns the value in the form the environment
+// variable expects.
+func (a *App) bindings() []binding {
+	return []binding{
+		{"PORT", "", intSetter(&a.Server.Port), intGetter(&a.Server.Port)},
+		{"FRAME_ANCESTORS", "frame_ancestors", listSetter(&a.Server.FrameAncestors), listGetter(&a.Server.FrameAncestors)},
+		{"LOG_LEVEL", "log_level", stringSetter(&a.Logging.Level), stringGetter(&a.Logging.Level)},
+		{"SOLVERS", "solvers", listSetter(&a.Solvers.Order), listGetter(&a.Solvers.Order)},
+		{"SOLVER_ROUTING", "solver_routing", stringSetter(&a.Solvers.Routing), stringGetter(&a.Solvers.Routing)},
+		{"FLARESOLVERR_URL", "flaresolverr_url", stringSetter(&a.Solvers.FlareSolverrURL), stringGetter(&a.Solvers.FlareSolverrURL)},
+		{"BYPARR_URL", "byparr_url", stringSetter(&a.Solvers.ByparrURL), stringGetter(&a.Solvers.ByparrURL)},
+		{"CHROME_URL", "chrome_url", stringSetter(&a.Solvers.ChromeURL), stringGetter(&a.Solvers.ChromeURL)},
+		{"FLARESOLVERR_CHECK_INTERVAL", "flaresolverr_check_interval", stringSetter(&a.Solvers.CheckInterval), durationGetter(&a.Solvers.CheckInterval)},
+		{"SUGGEST_CACHE_SIZE", "suggest_cache_size", intSetter(&a.Cache.SuggestSize), intGetter(&a.Cache.SuggestSize)},
+		{"BANDWIDTH_DAILY_CAP_MB", "bandwidth_daily_cap_mb", floatSetter(&a.RateLimits.BandwidthDailyCapMB), floatGetter(&a.RateLimits.BandwidthDailyCapMB)},
+		{"UG_RETRY_MAX", "ug_retry_max", intSetter(&a.RateLimits.UGRetries), intGetter(&a.RateLimits.UGRetries)},
+		{"IMPORT_WORKERS", "import_workers", intSetter(&a.RateLimits.ImportWorkers), intGetter(&a.RateLimits.ImportWorkers)},
+		{"PDF_CONCURRENCY", "pdf_concurrency", intSetter(&a.RateLimits.PDFConcurrency), intGetter(&a.RateLimits.PDFConcurrency)},
+		{"WEBHOOK_URL", "webhook_url", stringSetter(&a.Webhook.URL), stringGetter(&a.Webhook.URL)},
+		{"WEBHOOK_ENABLED", "webhook_enabled", boolSetter(&a.Webhook.Enabled), boolGetter(&a.Webhook.Enabled)},
+		{"CONFIG_FILE", "", stringSetter(&a.Webhook.File), stringGetter(&a.Webhook.File)},
+		{"SHARE_EXPORT_DIR", "share_export_dir", stringSetter(&a.Exports.ShareDir), stringGetter(&a.Exports.ShareDir)},
+		{"SHARE_FILENAME_TEMPLATE", "share_filename_template", stringSetter(&a.Exports.FilenameTemplate), stringGetter(&a.Exports.FilenameTemplate)},
+		{"SHARE_EXISTING_FILES", "share_existing_files", stringSetter(&a.Exports.ExistingFiles), stringGetter(&a.Exports.ExistingFiles)},
+		{"DROPBOX_FOLDER", "dropbox_folder", stringSetter(&a.Exports.DropboxFolder), stringGetter(&a.Exports.DropboxFolder)},
+	}
+}
+
+// LoadApp reads the configuration: the defaults, then the add-on options
+// in HA_OPTIONS_FILE (default /data/options.json), then the settings file
+// in APP_CONFIG_FILE (default /data/ug-scraper.yaml, YAML or JSON), then
+// the environment variables. Missing default files are skipped. The result
+// is validated.
+func LoadApp() (*App, error) {
+	app := Defaults()
+
+	optionsFile := os.Getenv("HA_OPTIONS_FILE")
+	if optionsFile == "" {
+		optionsFile = DefaultOptionsFile
+	}
+	if err := app.loadOptions(optionsFile); err != nil {
+		return nil, err
+	}
+
+	appFile, explicit := os.Getenv("APP_CONFIG_FILE"), true
+	if appFile == "" {
+		appFile, explicit = DefaultAppFile, false
+	}
+	if err := app.loadFile(appFile, explicit); err != nil {
+		return nil, err
+	}
+
+	if err := app.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := app.Validate(); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// loadFile applies the settings file at path, parsed as JSON for a .json
+// file and as YAML otherwise. A missing file is an error only when it was
+// asked for explicitly.
+func (a *App) loadFile(path string, explicit bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading settings file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(a)
+	} else {
+		decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(a); errors.Is(err, io.EOF) {
+			err = nil // Empty file
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("parsing settings file %s: %w", path, err)
+	}
+
+	a.Sources = append(a.Sources, path)
+	return nil
+}
+
+// loadEnv applies the environment variables that are set
+func (a *App) loadEnv() error {
+	var applied bool
+	for _, b := range a.bindings() {
+		value, ok := os.LookupEnv(b.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := b.set(value); err != nil {
+			return fmt.Errorf("%s: %w", b.env, err)
+		}
+		applied = true
+	}
+
+	if applied {
+		a.Sources = append(a.Sources, "environment")
+	}
+	return nil
+}
+
+// Validate checks the configuration, reporting every problem at once
+func (a *App) Validate() error {
+	var problems []error
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if a.Server.Port < 1 || a.Server.Port > 65535 {
+		add("server.port: %d is not a valid port", a.Server.Port)
+	}
+	for _, origin := range a.Server.FrameAncestors {
+		if err := validateOrigin(origin); err != nil {
+			add("server.frame_ancestors: %v", err)
+		}
+	}
+
+	switch a.Logging.Level {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		add("logging.level: %q is not one of debug, info, warn or error", a.Logging.Level)
+	}
+
+	for _, name := range a.Solvers.Order {
+		if !knownSolvers[strings.ToLower(strings.TrimSpace(name))] {
+			add("solvers.order: unknown solver %q", name)
+		}
+	}
+	if routing := strings.ToLower(a.Solvers.Routing); routing != "adaptive" && routing != "fixed" {
+		add("solvers.routing: %q is not adaptive or fixed", a.Solvers.Routing)
+	}
+	for _, service := range []struct{ name, url string }{
+		{"solvers.flaresolverr_url", a.Solvers.FlareSolverrURL},
+		{"solvers.byparr_url", a.Solvers.ByparrURL},
+		{"solvers.chrome_url", a.Solvers.ChromeURL},
+	} {
+		if err := validateServiceURL(service.url); err != nil {
+			add("%s: %v", service.name, err)
+		}
+	}
+	if _, err := parseInterval(a.Solvers.CheckInterval); err != nil {
+		add("solvers.check_interval: %v", err)
+	}
+
+	if a.Cache.SuggestSize < 0 {
+		add("cache.suggest_size: must not be negative")
+	}
+	if a.RateLimits.BandwidthDailyCapMB < 0 {
+		add("rate_limits.bandwidth_daily_cap_mb: must not be negative")
+	}
+	if a.RateLimits.UGRetries < 0 || a.RateLimits.UGRetries > 10 {
+		add("rate_limits.ug_retries: %d is not between 0 and 10", a.RateLimits.UGRetries)
+	}
+	if a.RateLimits.ImportWorkers < 0 || a.RateLimits.ImportWorkers > 16 {
+		add("rate_limits.import_workers: %d is not between 0 and 16", a.RateLimits.ImportWorkers)
+	}
+	if a.RateLimits.PDFConcurrency < 0 || a.RateLimits.PDFConcurrency > 16 {
+		add("rate_limits.pdf_concurrency: %d is not between 0 and 16", a.RateLimits.PDFConcurrency)
+	}
+
+	if a.Webhook.URL != "" {
+		target := WebhookConfig{URL: a.Webhook.URL, Headers: a.Webhook.Headers}
+		if err := target.Validate(); err != nil {
+			add("webhook: %v", err)
+		}
+	}
+	if a.Webhook.SchemaVersion < 0 {
+		add("webhook.schema_version: must not be negative")
+	}
+	if a.Webhook.File == "" {
+		add("webhook.file: is required")
+	}
+
+	if a.Exports.ShareDir != "" && !filepath.IsAbs(a.Exports.ShareDir) {
+		add("exports.share_dir: %q is not an absolute path", a.Exports.ShareDir)
+	}
+	if a.Exports.FilenameTemplate == "" {
+		add("exports.filename_template: is required")
+	}
+	if policy := strings.ToLower(a.Exports.ExistingFiles); policy != "overwrite" && policy != "skip" {
+		add("exports.existing_files: %q is not overwrite or skip", a.Exports.ExistingFiles)
+	}
+	if strings.Trim(a.Exports.DropboxFolder, "/") == "" {
+		add("exports.dropbox_folder: is required")
+	}
+
+	return errors.Join(problems...)
+}
+
+// Export sets the environment variables the rest of the server reads its
+// settings from to the loaded values. The other add-on options are passed
+// on as well, unless their variable is already set.
+func (a *App) Export() {
+	for _, b := range a.bindings() {
+		_ = os.Setenv(b.env, b.get())
+	}
+	for env, value := range a.passthrough {
+		if os.Getenv(env) == "" {
+			_ = os.Setenv(env, value)
+		}
+	}
+}
+
+// WebhookConfig returns the webhook to set up at startup, or nil when the
+// webhook is managed from the UI only
+func (a *App) WebhookConfig() *WebhookConfig {
+	if a.Webhook.URL == "" {
+		return nil
+	}
+	return &WebhookConfig{
+		URL:           a.Webhook.URL,
+		Enabled:       a.Webhook.Enabled,
+		Headers:       copyHeaders(a.Webhook.Headers),
+		SchemaVersion: a.Webhook.SchemaVersion,
+		IncludeBundle: a.Webhook.IncludeBundle,
+	}
+}
+
+// String describes the configuration for the startup log, without secrets
+func (a *App) String() string {
+	sources := "defaults"
+	if len(a.Sources) > 0 {
+		sources += ", " + strings.Join(a.Sources, ", ")
+	}
+	return fmt.Sprintf("port %d, log level %s, solvers %s (%s), from %s",
+		a.Server.Port, a.Logging.Level, strings.Join(a.Solvers.Order, ","), a.Solvers.Routing, sources)
+}
+
+// validateServiceURL checks an optional http(s) service address
+func validateServiceURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", value)
+	}
+	return nil
+}
+
+// validateOrigin checks a frame ancestor: "*" or an http(s) origin without
+// a path
+func validateOrigin(value string) error {
+	if value == "*" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q is not * or an http(s) origin such as https://ha.example.com:8123", value)
+	}
+	return nil
+}
+
+// parseInterval reads a Go duration, or a plain number of seconds as the
+// add-on options give it
+func parseInterval(value string) (time.Duration, error) {
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%q is not a duration", value)
+	}
+	return d, nil
+}
+
+func stringSetter(p *string) func(string) error {
+	return func(v string) error {
+		*p = strings.TrimSpace(v)
+		return nil
+	}
+}
+
+func stringGetter(p *string) func() string {
+	return func() string { return *p }
+}
+
+func intSetter(p *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", v)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func intGetter(p *int) func() string {
+	return func() string { return strconv.Itoa(*p) }
+}
+
+func floatSetter(p *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		*p = f
+		return nil
+	}
+}
+
+func floatGetter(p *float64) func() string {
+	return func() string { return strconv.FormatFloat(*p, 'f', -1, 64) }
+}
+
+func boolSetter(p *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", v)
+		}
+		*p = b
+		return nil
+	}
+}
+
+func boolGetter(p *bool) func() string {
+	return func() string { return strconv.FormatBool(*p) }
+}
+
+func listSetter(p *[]string) func(string) error {
+	return func(v string) error {
+		var list []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+				list = append(list, item)
+			}
+		}
+		*p = list
+		return nil
+	}
+}
+
+func listGetter(p *[]string) func() string {
+	return func() string { return strings.Join(*p, ",") }
+}
+
+// durationGetter exports an interval as a Go duration
+func durationGetter(p *string) func() string {
+	return func() string {
+		d, err := parseInterval(*p)
+		if err != nil {
+			return *p
+		}
+		return d.String()
+	}
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+)
+
+// Export formats with stored defaults
+const (
+	FormatPDF      = "pdf"
+	FormatChordPro = "chordpro"
+)
+
+var (
+	// ErrUnknownFormat is returned for formats without stored defaults
+	ErrUnknownFormat = errors.New("unknown export format")
+	// ErrInvalidOptions is returned for format options that can't be stored
+	ErrInvalidOptions = errors.New("invalid options")
+)
+
+// PDFDefaults are the defaults for tab PDF exports
+type PDFDefaults struct {
+	FontSize float64 `json:"font_size"` // Tab font size in points
+}
+
+// Validate checks the PDF defaults
+func (d *PDFDefaults) Validate() error {
+	if d.FontSize < export.MinTabFontSize || d.FontSize > export.MaxTabFontSize {
+		return fmt.Errorf("font_size must be between %g and %g", export.MinTabFontSize, export.MaxTabFontSize)
+	}
+	return nil
+}
+
+// ChordProDefaults are the defaults for ChordPro exports
+type ChordProDefaults struct {
+	DirectiveStyle string `json:"directive_style"` // export.DirectivesLong or export.DirectivesShort
+	Environments   bool   `json:"environments"`    // Sections as {start_of_chorus}-style blocks
+}
+
+// Options returns the ChordPro export options of the defaults
+func (d ChordProDefaults) Options() export.ChordProOptions {
+	return export.ChordProOptions{Directives: d.DirectiveStyle, Environments: d.Environments}
+}
+
+// Validate checks the ChordPro defaults
+func (d *ChordProDefaults) Validate() error {
+	if !export.ValidDirectiveStyle(d.DirectiveStyle) {
+		return fmt.Errorf("directive_style must be %s or %s", export.DirectivesLong, export.DirectivesShort)
+	}
+	return nil
+}
+
+// FormatDefaults holds the stored defaults of every export format
+type FormatDefaults struct {
+	PDF       PDFDefaults      `json:"pdf"`
+	ChordPro  ChordProDefaults `json:"chordpro"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// defaultFormatDefaults is what a fresh install starts with
+func defaultFormatDefaults() FormatDefaults {
+	return FormatDefaults{
+		PDF:      PDFDefaults{FontSize: export.DefaultTabFontSize},
+		ChordPro: ChordProDefaults{DirectiveStyle: export.DirectivesLong},
+	}
+}
+
+// formatOptions is the part of the defaults belonging to one format
+type formatOptions interface {
+	Validate() error
+}
+
+// section returns the options of a format inside d
+func (d *FormatDefaults) section(format string) (formatOptions, error) {
+	switch format {
+	case FormatPDF:
+		return &d.PDF, nil
+	case FormatChordPro:
+		return &d.ChordPro, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+// Formats lists the formats with stored defaults
+func Formats() []string {
+	return []string{FormatChordPro, FormatPDF}
+}
+
+// FormatStore persists per-format export defaults, applied whenever a
+// request doesn't choose an option itself
+type FormatStore struct {
+	mu         sync.RWMutex
+	defaults   FormatDefaults
+	filePath   string
+	persistent bool
+}
+
+// NewFormatStore creates a format defaults store backed by filePath
+func NewFormatStore(filePath string) *FormatStore {
+	store := &FormatStore{
+		defaults:   defaultFormatDefaults(),
+		filePath:   filePath,
+		persistent: filePath != "",
+	}
+
+	if store.persistent {
+		if err := store.loadFromFile(); err != nil {
+			fmt.Printf("⚠️  Failed to load format settings: %v\n", err)
+		}
+	}
+
+	return store
+}
+
+// Defaults returns a copy of every format's defaults
+func (s *FormatStore) Defaults() FormatDefaults {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.defaults
+}
+
+// Get returns the defaults of one format
+func (s *FormatStore) Get(format string) (interface{}, error) {
+	defaults := s.Defaults()
+	return defaults.section(format)
+}
+
+// Update merges a JSON object of options into a format's defaults; options
+// left out keep their value. The result is validated before it is stored.
+func (s *FormatStore) Update(format string, data []byte) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := s.defaults
+	options, err := updated.section(format)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, options); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+
+	updated.UpdatedAt = time.Now()
+	previous := s.defaults
+	s.defaults = updated
+	if err := s.persist(); err != nil {
+		s.defaults = previous
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// Snapshot returns every format's defaults in their on-disk form, for
+// backups
+func (s *FormatStore) Snapshot() ([]byte, error) {
+	defaults := s.Defaults()
+	data, err := json.MarshalIndent(defaults, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling format settings: %w", err)
+	}
+	return data, nil
+}
+
+// Restore replaces the defaults with a snapshot's. Formats it doesn't know
+// keep their built-in defaults; nothing changes when an option is invalid.
+func (s *FormatStore) Restore(data []byte) error {
+	restored := defaultFormatDefaults()
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+	for _, format := range Formats() {
+		options, _ := restored.section(format)
+		if err := options.Validate(); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidOptions, format, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.defaults
+	s.defaults = restored
+	if err := s.persist(); err != nil {
+		s.defaults = previous
+		return err
+	}
+	return nil
+}
+
+// persist writes the defaults to disk
+func (s *FormatStore) persist() error {
+	if !s.persistent {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("creating settings directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.defaults, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling format settings: %w", err)
+	}
+
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("writing format settings: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.filePath); err != nil {
+		return fmt.Errorf("replacing format settings: %w", err)
+	}
+
+	return nil
+}
+
+// loadFromFile loads stored defaults over the built-in ones, so formats
+// added later keep their built-in defaults
+func (s *FormatStore) loadFromFile() error {
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading format settings: %w", err)
+	}
+
+	loaded := defaultFormatDefaults()
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("unmarshaling format settings: %w", err)
+	}
+
+	for _, format := range Formats() {
+		options, _ := loaded.section(format)
+		if err := options.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", format, err)
+		}
+	}
+
+	s.defaults = loaded
+	return nil
+}
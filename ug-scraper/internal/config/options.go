@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// optionEnv maps the add-on options the App doesn't model to the
+// environment variables their subsystems read
+var optionEnv = map[string]string{
+	"ug_web_url":                 "UG_WEB_BASE_URL",
+	"ug_api_url":                 "UG_API_BASE_URL",
+	"ug_timeout":                 "UG_HTTP_TIMEOUT",
+	"flaresolverr_timeout":       "FLARESOLVERR_HTTP_TIMEOUT",
+	"webhook_timeout":            "WEBHOOK_HTTP_TIMEOUT",
+	"http_keep_alive":            "HTTP_KEEP_ALIVE",
+	"http_max_idle_conns":        "HTTP_MAX_IDLE_CONNS",
+	"onsong_token":               "ONSONG_TOKEN",
+	"mqtt_broker":                "MQTT_BROKER",
+	"mqtt_username":              "MQTT_USERNAME",
+	"mqtt_password":              "MQTT_PASSWORD",
+	"mqtt_topic_prefix":          "MQTT_TOPIC_PREFIX",
+	"mqtt_discovery":             "MQTT_DISCOVERY",
+	"ha_notify_mode":             "HA_NOTIFY_MODE",
+	"ha_notify_conversions":      "HA_NOTIFY_CONVERSIONS",
+	"ha_notify_webhook_failures": "HA_NOTIFY_WEBHOOK_FAILURES",
+	"ha_notify_gig_changes":      "HA_NOTIFY_GIG_CHANGES",
+	"gig_alert_days":             "GIG_ALERT_DAYS",
+	"dropbox_token":              "DROPBOX_TOKEN",
+	"key_header":                 "KEY_HEADER",
+	"chord_spelling":             "CHORD_SPELLING",
+	"chord_style":                "CHORD_STYLE",
+	"key_detection":              "KEY_DETECTION",
+	"auto_sections":              "AUTO_SECTIONS",
+	"section_language":           "SECTION_LANGUAGE",
+	"normalize_sections":         "NORMALIZE_SECTIONS",
+	"conversion_profile":         "CONVERSION_PROFILE",
+	"piano_bass_hints":           "PIANO_BASS_HINTS",
+	"remove_capo":                "REMOVE_CAPO",
+	"ocr_engine":                 "OCR_ENGINE",
+	"ocr_language":               "OCR_LANGUAGE",
+	"ocr_api_url":                "OCR_API_URL",
+	"ocr_api_key":                "OCR_API_KEY",
+	"ui_username":                "UI_USERNAME",
+	"ui_password":                "UI_PASSWORD",
+	"import_retries":             "IMPORT_RETRY_MAX",
+	"duplicate_songs":            "DUPLICATE_SONGS",
+	"exports_retention_days":     "EXPORTS_RETENTION_DAYS",
+	"feature_library":            "FEATURE_LIBRARY",
+	"feature_webhooks":           "FEATURE_WEBHOOKS",
+	"feature_mqtt":               "FEATURE_MQTT",
+	"timezone":                   "TIMEZONE",
+	"date_locale":                "DATE_LOCALE",
+	"startup_gates":              "STARTUP_GATES",
+	"startup_gate_policy":        "STARTUP_GATE_POLICY",
+	"startup_gate_timeout":       "STARTUP_GATE_TIMEOUT",
+}
+
+// loadOptions applies the add-on options the Supervisor writes to path:
+// the ones the App models are validated with it, the others are handed to
+// their subsystems by Export. Options the server doesn't know are logged
+// and ignored.
+func (a *App) loadOptions(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading add-on options: %w", err)
+	}
+
+	var options map[string]any
+	if err := json.Unmarshal(data, &options); err != nil {
+		return fmt.Errorf("parsing add-on options %s: %w", path, err)
+	}
+
+	bound := make(map[string]bool)
+	for _, b := range a.bindings() {
+		if b.option == "" {
+			continue
+		}
+		bound[b.option] = true
+		value, ok := options[b.option]
+		if !ok || value == nil {
+			continue
+		}
+		if err := b.set(optionString(value)); err != nil {
+			return fmt.Errorf("add-on option %s: %w", b.option, err)
+		}
+	}
+
+	a.passthrough = make(map[string]string)
+	for name, value := range options {
+		if bound[name] || value == nil {
+			continue
+		}
+		env, ok := optionEnv[name]
+		if !ok {
+			fmt.Printf("⚠️  Unknown add-on option %q, ignoring it\n", name)
+			continue
+		}
+		a.passthrough[env] = optionString(value)
+	}
+
+	a.Sources = append(a.Sources, "add-on options")
+	return nil
+}
+
+// optionString turns a JSON option value into the text form an
+// environment variable would carry
+func optionString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
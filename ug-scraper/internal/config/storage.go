@@ -3,17 +3,35 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/textproto"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
 
 // WebhookConfig holds webhook configuration
 type WebhookConfig struct {
-	URL       string    `json:"url"`
-	Enabled   bool      `json:"enabled"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	URL           string            `json:"url"`
+	Enabled       bool              `json:"enabled"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	SchemaVersion int               `json:"schema_version,omitempty"` // Payload schema the receiver expects, 0 for the default
+	IncludeBundle bool              `json:"include_bundle,omitempty"` // Add the chart AST and every format to deliveries
+	RedactFields  []string          `json:"redact_fields,omitempty"`  // Payload fields left out of deliveries
+	MaxLines      int               `json:"max_lines,omitempty"`      // Chart lines delivered after the header, 0 for all
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// reservedHeaders are set by the webhook client and cannot be overridden
+var reservedHeaders = map[string]bool{
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Host":           true,
+	"X-Delivery-Id":  true,
+	"X-Attempt":      true,
 }
 
 // ConfigStore manages webhook configuration with thread-safe operations
@@ -51,6 +69,8 @@ func (s *ConfigStore) Get() *WebhookConfig {
 	}
 
 	configCopy := *s.config
+	configCopy.Headers = copyHeaders(s.config.Headers)
+	configCopy.RedactFields = slices.Clone(s.config.RedactFields)
 	return &configCopy
 }
 
@@ -101,6 +121,40 @@ func (s *ConfigStore) GetURL() string {
 	return ""
 }
 
+// GetHeaders returns a copy of the custom headers sent with each delivery
+func (s *ConfigStore) GetHeaders() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil {
+		return nil
+	}
+
+	return copyHeaders(s.config.Headers)
+}
+
+// GetSchemaVersion returns the payload schema the webhook expects, 0 for
+// the default
+func (s *ConfigStore) GetSchemaVersion() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil {
+		return 0
+	}
+
+	return s.config.SchemaVersion
+}
+
+// IncludesBundle reports whether deliveries carry the chart AST and every
+// export format
+func (s *ConfigStore) IncludesBundle() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.config != nil && s.config.IncludeBundle
+}
+
 // Clear removes the webhook configuration
 func (s *ConfigStore) Clear() error {
 	s.mu.Lock()
@@ -125,7 +179,7 @@ func (s *ConfigStore) persistToFile() error {
 	}
 
 	// Create directory if it doesn't exist
-	dir := s.filePath[:len(s.filePath)-len("/webhook-config.json")]
+	dir := filepath.Dir(s.filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil && !os.IsExist(err) {
 		return fmt.Errorf("creating config directory: %w", err)
 	}
@@ -144,6 +198,72 @@ func (s *ConfigStore) persistToFile() error {
 	return nil
 }
 
+// Snapshot returns the webhook configuration in its on-disk form, for
+// backups
+func (s *ConfigStore) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := json.MarshalIndent(s.config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling config: %w", err)
+	}
+	return data, nil
+}
+
+// Restore replaces the webhook configuration with a snapshot, keeping its
+// timestamps. A snapshot without a URL clears it.
+func (s *ConfigStore) Restore(data []byte) error {
+	var config WebhookConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("unmarshaling config: %w", err)
+	}
+	if config.URL == "" {
+		return s.Clear()
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.config
+	s.config = &config
+	if s.persistent {
+		if err := s.persistToFile(); err != nil {
+			s.config = previous
+			return err
+		}
+	}
+	return nil
+}
+
+// Persistent reports whether the configuration is saved to a file
+func (s *ConfigStore) Persistent() bool {
+	return s.persistent
+}
+
+// CheckWritable verifies the configuration could be saved right now: the
+// file can be opened for writing or, before the first save, created. Nothing
+// is changed.
+func (s *ConfigStore) CheckWritable() error {
+	f, err := os.OpenFile(s.filePath, os.O_WRONLY, 0)
+	if err == nil {
+		return f.Close()
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("config file not writable: %w", err)
+	}
+
+	probe, err := os.CreateTemp(filepath.Dir(s.filePath), ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("config directory not writable: %w", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
 // loadFromFile loads configuration from JSON file
 func (s *ConfigStore) loadFromFile() error {
 	if s.filePath == "" {
@@ -183,9 +303,50 @@ func (c *WebhookConfig) Validate() error {
 		return fmt.Errorf("invalid webhook URL format")
 	}
 
+	for name, value := range c.Headers {
+		if err := validateHeader(name, value); err != nil {
+			return err
+		}
+	}
+
+	if c.MaxLines < 0 {
+		return fmt.Errorf("max_lines must not be negative")
+	}
+
 	return nil
 }
 
+// validateHeader checks that a custom header name and value are safe to send
+func validateHeader(name, value string) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+
+	if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+		return fmt.Errorf("header %q is set automatically and cannot be overridden", name)
+	}
+
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("header %q value must not contain line breaks", name)
+	}
+
+	return nil
+}
+
+// copyHeaders returns a shallow copy of a header map
+func copyHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	copied := make(map[string]string, len(headers))
+	for name, value := range headers {
+		copied[name] = value
+	}
+
+	return copied
+}
+
 // startsWithHTTP checks if string starts with http://
 func startsWithHTTP(s string) bool {
 	return len(s) >= 7 && s[:7] == "http://"
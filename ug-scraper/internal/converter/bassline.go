@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"strings"
+)
+
+// BassStep is what the bass plays under one chord
+type BassStep struct {
+	Chord    string   `json:"chord"`
+	Notes    []string `json:"notes"`              // Root and fifth, or root and an approach note
+	Approach bool     `json:"approach,omitempty"` // The last note walks into the next chord
+}
+
+// SectionBassLine is a suggested bass line for one section of a chart
+type SectionBassLine struct {
+	Section string     `json:"section"`
+	Steps   []BassStep `json:"steps"`
+	Line    string     `json:"line"` // Steps for display, e.g. "C G | A E | G B"
+}
+
+// BassLines suggests a simple bass line for every section of a formatted
+// chart: root and fifth under each chord, and a half-step walk into the next
+// chord at cadences, where the bass falls a fifth (G to C) or a section
+// hands over to the next. Slash chords keep their written bass. Notes the
+// chart doesn't name are spelled with flats when flats is set.
+func BassLines(chart string, flats bool) []SectionBassLine {
+	type section struct {
+		label  string
+		chords []string
+	}
+
+	var sections []section
+	current := section{}
+	for _, line := range strings.Split(chart, "\n") {
+		if label := onSongSectionLabel(line); label != "" {
+			if len(current.chords) > 0 {
+				sections = append(sections, current)
+			}
+			current = section{label: label}
+			continue
+		}
+		for _, m := range inlineChordRegex.FindAllStringSubmatch(line, -1) {
+			chord := m[1]
+			if bassNote(chord) == "" {
+				continue
+			}
+			// A chord repeated across lines is held, not replayed
+			if n := len(current.chords); n > 0 && current.chords[n-1] == chord {
+				continue
+			}
+			current.chords = append(current.chords, chord)
+		}
+	}
+	if len(current.chords) > 0 {
+		sections = append(sections, current)
+	}
+
+	lines := make([]SectionBassLine, 0, len(sections))
+	for i, s := range sections {
+		label := s.label
+		if label == "" {
+			label = "Chart"
+		}
+
+		bass := SectionBassLine{Section: label, Steps: make([]BassStep, 0, len(s.chords))}
+		for j, chord := range s.chords {
+			next, handover := "", false
+			switch {
+			case j+1 < len(s.chords):
+				next = s.chords[j+1]
+			case i+1 < len(sections):
+				next, handover = sections[i+1].chords[0], true
+			}
+			bass.Steps = append(bass.Steps, bassStep(chord, next, handover, flats))
+		}
+
+		bars := make([]string, len(bass.Steps))
+		for j, step := range bass.Steps {
+			bars[j] = strings.Join(step.Notes, " ")
+		}
+		bass.Line = strings.Join(bars, " | ")
+		lines = append(lines, bass)
+	}
+
+	return lines
+}
+
+// bassStep picks the notes under chord, walking into next at a cadence
+func bassStep(chord, next string, handover, flats bool) BassStep {
+	step := BassStep{Chord: chord, Notes: []string{bassNote(chord)}}
+	from := NoteIndex(bassNote(chord))
+
+	if to := NoteIndex(bassNote(next)); to >= 0 && to != from {
+		fallsFifth := (to-from+12)%12 == 5
+		if fallsFifth || handover {
+			// Lead in from a half step below, or above when that is where
+			// the bass already is
+			approach := (to + 11) % 12
+			if approach == from {
+				approach = (to + 1) % 12
+			}
+			step.Notes = append(step.Notes, noteName(approach, flats))
+			step.Approach = true
+			return step
+		}
+	}
+
+	if parsed, ok := parseChordTones(chord); ok {
+		for _, tone := range parsed.tones {
+			if interval := (tone - parsed.root + 12) % 12; interval >= 6 && interval <= 8 {
+				step.Notes = append(step.Notes, noteName(tone, flats))
+				break
+			}
+		}
+	}
+	return step
+}
+
+// noteName spells a pitch class with sharps or flats
+func noteName(pitch int, flats bool) string {
+	if flats {
+		return flatNotes[pitch]
+	}
+	return sharpNotes[pitch]
+}
+
+// addBassLineBlock writes the suggested bass lines as {comment} lines after
+// the chart, above the source footer
+func addBassLineBlock(result *ConversionResult) {
+	if len(result.BassLines) == 0 {
+		return
+	}
+
+	block := []string{commentLine("Bass lines")}
+	for _, line := range result.BassLines {
+		block = append(block, commentLine(line.Section+": "+line.Line))
+	}
+
+	chart, footer, found := strings.Cut(result.OnSongFormat, "\n# Source:")
+	chart = strings.TrimRight(chart, "\n") + "\n\n" + strings.Join(block, "\n") + "\n"
+	if found {
+		chart += "\n# Source:" + footer
+	}
+	result.OnSongFormat = chart
+}
@@ -0,0 +1,292 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of chart lines
+const (
+	LineLyrics    = "lyrics"    // Lyrics, possibly with chords above them
+	LineChords    = "chords"    // Chords only
+	LineComment   = "comment"   // A "(...)" or {comment:} note for the performer
+	LineTab       = "tab"       // A line of tablature, kept as written
+	LineDirective = "directive" // Another ChordPro directive, such as {define:}
+)
+
+// Chart is the structure of an OnSong or ChordPro chart: its header and its
+// sections of lines, with every chord placed in the lyrics
+type Chart struct {
+	Title    string         `json:"title"`
+	Artist   string         `json:"artist,omitempty"`
+	Key      string         `json:"key,omitempty"`
+	Capo     int            `json:"capo,omitempty"`
+	Tuning   string         `json:"tuning,omitempty"`
+	Sections []ChartSection `json:"sections"`
+	Notes    []string       `json:"notes,omitempty"` // "#" comments such as the source
+}
+
+// ChartSection is a labelled part of a chart, or an unlabelled block
+type ChartSection struct {
+	Label string      `json:"label,omitempty"`
+	Lines []ChartLine `json:"lines"`
+}
+
+// ChartLine is one line of a chart. Lyrics and chord lines are also split
+// into fragments at their chords.
+type ChartLine struct {
+	Kind      string          `json:"kind"`
+	Text      string          `json:"text"` // The line without its chords
+	Chords    []ChordPosition `json:"chords,omitempty"`
+	Fragments []ChartFragment `json:"fragments,omitempty"`
+}
+
+// ChartFragment is a chord and the lyrics sung from it up to the next
+// chord. Lyrics before the first chord make a fragment without one.
+type ChartFragment struct {
+	Chord    string `json:"chord,omitempty"`
+	Lyric    string `json:"lyric"`
+	Position int    `json:"position"` // Where the lyric starts in the line text
+}
+
+// ChordPosition is a chord played at a character offset into the line text
+type ChordPosition struct {
+	Chord    string `json:"chord"`
+	Position int    `json:"position"`
+}
+
+// ParseChart reads the structure of an OnSong or ChordPro chart. Sections
+// start at OnSong labels ("Chorus:"); blank lines separate unlabelled
+// blocks. Chords come from [C] markers or from lines of chords only; staff
+// lines and {start_of_tab} blocks are tablature.
+func ParseChart(content string) *Chart {
+	song := ParseSongFile(content)
+	chart := &Chart{
+		Title:    song.Title,
+		Artist:   song.Artist,
+		Key:      song.Key,
+		Capo:     song.Capo,
+		Tuning:   song.Tuning,
+		Sections: []ChartSection{},
+	}
+
+	var current *ChartSection
+	flush := func() {
+		if current != nil && (current.Label != "" || len(current.Lines) > 0) {
+			chart.Sections = append(chart.Sections, *current)
+		}
+		current = nil
+	}
+
+	add := func(line ChartLine) {
+		if current == nil {
+			current = &ChartSection{}
+		}
+		current.Lines = append(current.Lines, line)
+	}
+
+	inTab := false
+	for _, raw := range strings.Split(song.Body, "\n") {
+		line := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimSpace(line)
+		directive := directiveRegex.FindStringSubmatch(trimmed)
+
+		switch {
+		case directive != nil && !inlineChordRegex.MatchString(trimmed):
+			switch strings.ToLower(directive[1]) {
+			case "start_of_tab", "sot":
+				inTab = true
+			case "end_of_tab", "eot":
+				inTab = false
+			case "comment", "c", "comment_italic", "ci", "comment_box", "cb", "highlight":
+				add(ChartLine{Kind: LineComment, Text: directive[2]})
+			default:
+				add(ChartLine{Kind: LineDirective, Text: trimmed})
+			}
+		case inTab && trimmed != "", IsStaffLine(line):
+			add(ChartLine{Kind: LineTab, Text: line})
+		case strings.HasPrefix(trimmed, "#"):
+			chart.Notes = append(chart.Notes, strings.TrimSpace(strings.TrimPrefix(trimmed, "#")))
+		case trimmed == "":
+			// A blank line ends a block, unless it directly follows a label
+			if current != nil && len(current.Lines) > 0 {
+				flush()
+			}
+		case onSongSectionLabel(trimmed) != "":
+			flush()
+			current = &ChartSection{Label: onSongSectionLabel(trimmed)}
+		default:
+			add(parseChartLine(line))
+		}
+	}
+	flush()
+
+	return chart
+}
+
+// parseChartLine splits a line into its text and the chords placed in it
+func parseChartLine(line string) ChartLine {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "(") && strings.HasSuffix(trimmed, ")") {
+		return ChartLine{Kind: LineComment, Text: strings.TrimSpace(trimmed[1 : len(trimmed)-1])}
+	}
+
+	if inlineChordRegex.MatchString(line) {
+		var text strings.Builder
+		var chords []ChordPosition
+		last := 0
+		for _, m := range inlineChordRegex.FindAllStringSubmatchIndex(line, -1) {
+			text.WriteString(line[last:m[0]])
+			chords = append(chords, ChordPosition{
+				Chord:    line[m[2]:m[3]],
+				Position: utf8.RuneCountInString(text.String()),
+			})
+			last = m[1]
+		}
+		text.WriteString(line[last:])
+
+		kind := LineLyrics
+		if strings.TrimSpace(text.String()) == "" {
+			kind = LineChords
+		}
+		return withFragments(ChartLine{Kind: kind, Text: strings.TrimRight(text.String(), " "), Chords: chords})
+	}
+
+	// A plain line of chord names, such as a leftover chord line
+	if chords := plainChordPositions(line); chords != nil {
+		return withFragments(ChartLine{Kind: LineChords, Chords: chords})
+	}
+	return withFragments(ChartLine{Kind: LineLyrics, Text: line})
+}
+
+// withFragments splits the text of a line at its chords
+func withFragments(line ChartLine) ChartLine {
+	text := []rune(line.Text)
+	if len(line.Chords) == 0 {
+		line.Fragments = []ChartFragment{{Lyric: line.Text}}
+		return line
+	}
+
+	if first := min(line.Chords[0].Position, len(text)); first > 0 {
+		line.Fragments = append(line.Fragments, ChartFragment{Lyric: string(text[:first])})
+	}
+	for i, chord := range line.Chords {
+		start, end := min(chord.Position, len(text)), len(text)
+		if i+1 < len(line.Chords) {
+			end = max(start, min(line.Chords[i+1].Position, len(text)))
+		}
+		line.Fragments = append(line.Fragments, ChartFragment{
+			Chord:    chord.Chord,
+			Lyric:    string(text[start:end]),
+			Position: chord.Position,
+		})
+	}
+	return line
+}
+
+// plainChordPositions returns the chords of a line made only of chord names
+// at their columns, or nil when the line has anything else on it
+func plainChordPositions(line string) []ChordPosition {
+	var chords []ChordPosition
+	column := 0
+	for _, field := range strings.SplitAfter(line, " ") {
+		name := strings.TrimSpace(field)
+		if name != "" {
+			if !ValidChord(name) && name != "|" {
+				return nil
+			}
+			if name != "|" {
+				chords = append(chords, ChordPosition{Chord: name, Position: column})
+			}
+		}
+		column += utf8.RuneCountInString(field)
+	}
+	return chords
+}
+
+// Chords returns the distinct chords of the chart in the order they first
+// appear
+func (c *Chart) Chords() []string {
+	seen := make(map[string]bool)
+	var chords []string
+	for _, section := range c.Sections {
+		for _, line := range section.Lines {
+			for _, chord := range line.Chords {
+				if !seen[chord.Chord] {
+					seen[chord.Chord] = true
+					chords = append(chords, chord.Chord)
+				}
+			}
+		}
+	}
+	return chords
+}
+
+// Text renders the chart as plain text with chords above the lyrics, for
+// receivers that show it as is
+func (c *Chart) Text() string {
+	var out strings.Builder
+
+	out.WriteString(c.Title)
+	if c.Artist != "" {
+		out.WriteString(" - " + c.Artist)
+	}
+	out.WriteString("\n")
+	if c.Key != "" {
+		out.WriteString("Key: " + c.Key)
+		if c.Capo > 0 {
+			out.WriteString(fmt.Sprintf(" (Capo: %d)", c.Capo))
+		}
+		out.WriteString("\n")
+	}
+
+	for _, section := range c.Sections {
+		out.WriteString("\n")
+		if section.Label != "" {
+			out.WriteString("[" + section.Label + "]\n")
+		}
+		for _, line := range section.Lines {
+			switch line.Kind {
+			case LineComment:
+				out.WriteString("(" + line.Text + ")\n")
+			case LineTab:
+				out.WriteString(line.Text + "\n")
+			case LineDirective:
+			case LineChords:
+				out.WriteString(chordRow(line.Chords) + "\n")
+			default:
+				if len(line.Chords) > 0 {
+					out.WriteString(chordRow(line.Chords) + "\n")
+				}
+				out.WriteString(line.Text + "\n")
+			}
+		}
+	}
+
+	if len(c.Notes) > 0 {
+		out.WriteString("\n")
+		for _, note := range c.Notes {
+			out.WriteString("# " + note + "\n")
+		}
+	}
+
+	return out.String()
+}
+
+// chordRow places chords at their positions, keeping at least one space
+// between neighbours
+func chordRow(chords []ChordPosition) string {
+	var row []rune
+	for _, chord := range chords {
+		pad := chord.Position - len(row)
+		if len(row) > 0 {
+			pad = max(pad, 1)
+		}
+		for ; pad > 0; pad-- {
+			row = append(row, ' ')
+		}
+		row = append(row, []rune(chord.Chord)...)
+	}
+	return string(row)
+}
@@ -9,13 +9,15 @@ import (
 // ChordParser handles chord extraction and analysis
 type ChordParser struct {
 	chordRegex *regexp.Regexp
+	strategy   string // Key detection strategy
 }
 
 // NewChordParser creates a new chord parser
 func NewChordParser() *ChordParser {
 	// Regex to match chords in [Ch] format
 	return &ChordParser{
-		chordRegex: regexp.MustCompile(`\[ch\]([A-G][#b]?(?:maj|min|m|sus|aug|dim|add|[0-9])*)\[/ch\]`),
+		chordRegex: regexp.MustCompile(`\[ch\]([A-G]` + accidental + `?(?:maj|min|m|sus|aug|dim|add|[0-9])*)\[/ch\]`),
+		strategy:   KeyStrategyFrequency,
 	}
 }
 
@@ -26,50 +28,22 @@ func (p *ChordParser) ExtractChords(content string) []string {
 	chords := make([]string, 0, len(matches))
 	for _, match := range matches {
 		if len(match) > 1 {
-			chords = append(chords, match[1])
+			chords = append(chords, NormalizeAccidentals(match[1]))
 		}
 	}
 
 	return chords
 }
 
-// DetectKey analyzes chord occurrences to detect the likely key
+// DetectKey returns the most likely key of a chord sequence, or "" when
+// none of the chords can be read
 func (p *ChordParser) DetectKey(chords []string) string {
-	if len(chords) == 0 {
-		return ""
-	}
-
-	// Count root note occurrences
-	rootCounts := make(map[string]int)
-	for _, chord := range chords {
-		root := extractRootNote(chord)
-		if root != "" {
-			rootCounts[root]++
-		}
-	}
-
-	// Find the most common root note
-	var mostCommon string
-	maxCount := 0
-	for root, count := range rootCounts {
-		if count > maxCount {
-			maxCount = count
-			mostCommon = root
-		}
-	}
-
-	// Analyze chord quality to determine major/minor
-	quality := analyzeChordQuality(chords, mostCommon)
-
-	if quality == "minor" && mostCommon != "" {
-		return mostCommon + "m"
-	}
-
-	return mostCommon
+	return p.EstimateKey(chords).Key
 }
 
 // extractRootNote gets the root note from a chord (e.g., "Am7" -> "A")
 func extractRootNote(chord string) string {
+	chord = NormalizeAccidentals(chord)
 	if len(chord) == 0 {
 		return ""
 	}
@@ -99,45 +73,14 @@ func extractRootNote(chord string) string {
 	return ""
 }
 
-// analyzeChordQuality determines if the key is likely major or minor
-func analyzeChordQuality(chords []string, rootNote string) string {
-	if rootNote == "" {
-		return "major"
-	}
-
-	majorCount := 0
-	minorCount := 0
-
-	for _, chord := range chords {
-		root := extractRootNote(chord)
-		if root != rootNote {
-			continue
-		}
-
-		chordLower := strings.ToLower(chord)
-
-		// Check if chord is minor
-		if strings.Contains(chordLower, "m") && !strings.Contains(chordLower, "maj") {
-			minorCount++
-		} else {
-			majorCount++
-		}
-	}
-
-	if minorCount > majorCount {
-		return "minor"
-	}
-
-	return "major"
-}
-
-// NormalizeChordName converts chord names to a standard format
-func NormalizeChordName(chord string) string {
+// NormalizeChordName converts chord names to a standard format, with ♯ and
+// ♭ spelled # and b and the qualities written in the given style
+func NormalizeChordName(chord string, style ChordStyle) string {
 	// Remove [ch] tags if present
 	chord = strings.ReplaceAll(chord, "[ch]", "")
 	chord = strings.ReplaceAll(chord, "[/ch]", "")
 
-	return strings.TrimSpace(chord)
+	return StyleChord(NormalizeAccidentals(strings.TrimSpace(chord)), style)
 }
 
 // ChordStats holds statistics about chords in a tab
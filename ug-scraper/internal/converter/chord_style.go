@@ -0,0 +1,179 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// How a chord style writes each quality; "" keeps what the chart uses
+const (
+	Major7Maj7  = "maj7" // Cmaj7, Cmaj9
+	Major7M7    = "M7"   // CM7, CM9
+	Major7Delta = "Δ7"   // CΔ7, CΔ9
+
+	MinorM    = "m"   // Am7
+	MinorMin  = "min" // Amin7
+	MinorDash = "-"   // A-7
+
+	SusSus4 = "sus4" // Dsus4, D7sus4
+	SusSus  = "sus"  // Dsus, D7sus
+)
+
+// Chord style presets
+const (
+	ChordStyleKeep     = "keep"     // Chords as the chart writes them
+	ChordStyleStandard = "standard" // maj7, m, sus4
+	ChordStyleCompact  = "compact"  // M7, m, sus
+	ChordStyleJazz     = "jazz"     // Δ7, -, sus
+)
+
+// ChordStyle is how chord qualities are written: the major seventh, minor
+// and suspended fourth. Empty fields keep what the chart uses.
+type ChordStyle struct {
+	Major7 string `json:"major7,omitempty"`
+	Minor  string `json:"minor,omitempty"`
+	Sus    string `json:"sus,omitempty"`
+}
+
+// chordStylePresets are the named chord styles
+var chordStylePresets = map[string]ChordStyle{
+	ChordStyleKeep:     {},
+	ChordStyleStandard: {Major7: Major7Maj7, Minor: MinorM, Sus: SusSus4},
+	ChordStyleCompact:  {Major7: Major7M7, Minor: MinorM, Sus: SusSus},
+	ChordStyleJazz:     {Major7: Major7Delta, Minor: MinorDash, Sus: SusSus},
+}
+
+var (
+	// major7Regex matches a major seventh, or a major ninth, eleventh or
+	// thirteenth, at the start of a chord suffix
+	major7Regex = regexp.MustCompile(`^(?:maj|Maj|MAJ|ma|M|Δ|∆)(7|9|11|13)?`)
+	// susRegex matches a suspended chord's sus, sus2 or sus4
+	susRegex = regexp.MustCompile(`sus([24]?)`)
+)
+
+// ParseChordStyle reads a chord style: a preset (keep, standard, compact or
+// jazz) or the forms to use, separated by commas, such as "M7,min,sus4".
+// Qualities left out of the list keep what the chart uses.
+func ParseChordStyle(raw string) (ChordStyle, error) {
+	raw = strings.TrimSpace(raw)
+	if style, ok := chordStylePresets[strings.ToLower(raw)]; ok {
+		return style, nil
+	}
+
+	var style ChordStyle
+	for _, form := range strings.Split(raw, ",") {
+		form = strings.TrimSpace(form)
+		var field *string
+		switch form {
+		case Major7Maj7, Major7M7, Major7Delta:
+			field = &style.Major7
+		case MinorM, MinorMin, MinorDash:
+			field = &style.Minor
+		case SusSus4, SusSus:
+			field = &style.Sus
+		default:
+			return ChordStyle{}, fmt.Errorf("unknown chord style %q: use keep, standard, compact, jazz or forms such as \"maj7,m,sus4\"", form)
+		}
+		if *field != "" {
+			return ChordStyle{}, fmt.Errorf("chord style %q sets %s and %s", raw, *field, form)
+		}
+		*field = form
+	}
+	return style, nil
+}
+
+// ChordStyleFromEnv reads the global chord style from CHORD_STYLE, keeping
+// chords as written when it is unset or invalid
+func ChordStyleFromEnv() ChordStyle {
+	raw := os.Getenv("CHORD_STYLE")
+	if raw == "" {
+		return ChordStyle{}
+	}
+	style, err := ParseChordStyle(raw)
+	if err != nil {
+		fmt.Printf("⚠️  %v; keeping chords as written\n", err)
+	}
+	return style
+}
+
+// String names the style: its preset, or its forms separated by commas
+func (s ChordStyle) String() string {
+	for name, preset := range chordStylePresets {
+		if s == preset {
+			return name
+		}
+	}
+	var forms []string
+	for _, form := range []string{s.Major7, s.Minor, s.Sus} {
+		if form != "" {
+			forms = append(forms, form)
+		}
+	}
+	return strings.Join(forms, ",")
+}
+
+// StyleChord writes a chord's qualities in the given style, leaving its
+// root, bass note and other extensions alone: Cmaj7 becomes CΔ7 and Am7
+// A-7 in the jazz style. Chords it can't read are returned unchanged.
+func StyleChord(chord string, style ChordStyle) string {
+	if style == (ChordStyle{}) {
+		return chord
+	}
+	parts := chordPartsRegex.FindStringSubmatch(chord)
+	if parts == nil {
+		return chord
+	}
+
+	suffix := parts[2]
+	minor := ""
+	switch {
+	case strings.HasPrefix(suffix, "min"):
+		minor, suffix = "min", suffix[3:]
+	case strings.HasPrefix(suffix, "m") && !strings.HasPrefix(suffix, "maj"):
+		minor, suffix = "m", suffix[1:]
+	case strings.HasPrefix(suffix, "-"):
+		minor, suffix = "-", suffix[1:]
+	}
+	if minor != "" && style.Minor != "" {
+		minor = style.Minor
+	}
+
+	// A major seventh needs its number, except Δ alone; M alone is a plain
+	// major chord
+	if m := major7Regex.FindStringSubmatch(suffix); m != nil && style.Major7 != "" &&
+		(m[1] != "" || strings.HasPrefix(m[0], "Δ") || strings.HasPrefix(m[0], "∆")) {
+		number := m[1]
+		if number == "" {
+			number = "7"
+		}
+		suffix = strings.TrimSuffix(style.Major7, "7") + number + suffix[len(m[0]):]
+	}
+
+	if style.Sus != "" {
+		suffix = susRegex.ReplaceAllStringFunc(suffix, func(sus string) string {
+			if sus == "sus2" {
+				return sus
+			}
+			return style.Sus
+		})
+	}
+
+	result := parts[1] + minor + suffix
+	if parts[3] != "" {
+		result += "/" + parts[3]
+	}
+	return result
+}
+
+// StyleOnSong writes every inline [chord] of an OnSong chart in the given
+// style
+func StyleOnSong(content string, style ChordStyle) string {
+	if style == (ChordStyle{}) {
+		return content
+	}
+	return inlineChord.ReplaceAllStringFunc(content, func(match string) string {
+		return "[" + StyleChord(match[1:len(match)-1], style) + "]"
+	})
+}
@@ -0,0 +1,252 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// directiveRegex matches a ChordPro directive such as "{title: Song}" or "{soc}"
+	directiveRegex = regexp.MustCompile(`^\{\s*([A-Za-z_]+)\s*(?::\s*(.*?))?\s*\}$`)
+	// chordProMarkerRegex detects ChordPro metadata directives anywhere in a file
+	chordProMarkerRegex = regexp.MustCompile(`(?mi)^\s*\{\s*(title|t|subtitle|st|artist|start_of_chorus|soc)\s*[:}]`)
+	// onSongMetaRegex matches OnSong header metadata such as "Key: G"
+	onSongMetaRegex = regexp.MustCompile(`^(Key|Capo|Tuning|Tempo|Time|Album|Year|Copyright|CCLI|Book|Flow):\s*(.*)$`)
+	// onSongLabelRegex matches a line usable as an OnSong section label
+	onSongLabelRegex = regexp.MustCompile(`^\p{L}[\p{L}\- ]{0,24}\d*$`)
+	// inlineChordRegex matches [Chord] markers in OnSong and ChordPro charts
+	inlineChordRegex = regexp.MustCompile(`\[([A-G]` + accidental + `?[^\]\s]*)\]`)
+	blankRunRegex    = regexp.MustCompile(`\n{3,}`)
+)
+
+// SongFile is a chart read from an existing OnSong or ChordPro file
+type SongFile struct {
+	Title  string
+	Artist string
+	Key    string
+	Capo   int
+	Tuning string
+	// Meta holds other header fields (Tempo, Time, Album, ...) in file order
+	Meta [][2]string
+	// Body is the chart in OnSong form, without the header block
+	Body string
+}
+
+// IsChordPro reports whether content uses ChordPro metadata directives
+func IsChordPro(content string) bool {
+	return chordProMarkerRegex.MatchString(content)
+}
+
+// ParseSongFile reads an OnSong or ChordPro chart. ChordPro is detected from
+// its directives, so OnSong files that use ChordPro syntax are handled too.
+func ParseSongFile(content string) *SongFile {
+	content = strings.ReplaceAll(strings.TrimPrefix(content, "\ufeff"), "\r\n", "\n")
+	content = NormalizeTypography(content)
+	if IsChordPro(content) {
+		return ParseChordPro(content)
+	}
+	return ParseOnSong(content)
+}
+
+// ParseOnSong reads an OnSong chart: title and artist on the first two lines,
+// then metadata lines, then the chart after the first blank line
+func ParseOnSong(content string) *SongFile {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	song := &SongFile{}
+
+	// Skip leading blank lines
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+
+	for n := 0; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i, n = i+1, n+1 {
+		line := strings.TrimSpace(lines[i])
+		if m := onSongMetaRegex.FindStringSubmatch(line); m != nil {
+			song.setMeta(m[1], m[2])
+			continue
+		}
+		// A section label or chord line means the file has no header block
+		if onSongSectionLabel(line) != "" || inlineChordRegex.MatchString(line) {
+			break
+		}
+		switch n {
+		case 0:
+			song.Title = line
+		case 1:
+			song.Artist = line
+		default:
+			song.Meta = append(song.Meta, [2]string{"", line})
+		}
+	}
+
+	song.Body = strings.Trim(strings.Join(lines[i:], "\n"), "\n")
+	return song
+}
+
+// ParseChordPro reads a ChordPro chart, turning metadata directives into the
+// header and section directives into OnSong section labels
+func ParseChordPro(content string) *SongFile {
+	song := &SongFile{}
+	var body []string
+
+	for _, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := directiveRegex.FindStringSubmatch(line)
+		if m == nil {
+			body = append(body, strings.TrimRight(raw, " \t"))
+			continue
+		}
+
+		name, value := strings.ToLower(m[1]), m[2]
+		switch name {
+		case "title", "t":
+			song.Title = value
+		case "artist":
+			song.Artist = value
+		case "subtitle", "st":
+			if song.Artist == "" {
+				song.Artist = value
+			}
+		case "key", "capo", "tuning", "tempo", "time", "album", "year", "copyright", "ccli":
+			song.setMeta(name, value)
+		case "comment", "c", "comment_italic", "ci", "comment_box", "cb", "highlight":
+			if label := onSongSectionLabel(value + ":"); label != "" {
+				body = append(body, label+":")
+			} else {
+				body = append(body, "("+value+")")
+			}
+		case "start_of_chorus", "soc":
+			body = append(body, sectionStart(value, "Chorus"))
+		case "start_of_verse", "sov":
+			body = append(body, sectionStart(value, "Verse"))
+		case "start_of_bridge", "sob":
+			body = append(body, sectionStart(value, "Bridge"))
+		case "end_of_chorus", "eoc", "end_of_verse", "eov", "end_of_bridge", "eob":
+			body = append(body, "")
+		default:
+			// Tab blocks, chord definitions and layout directives are understood by OnSong
+			body = append(body, line)
+		}
+	}
+
+	text := strings.Join(body, "\n")
+	text = blankRunRegex.ReplaceAllString(text, "\n\n")
+	song.Body = strings.Trim(text, "\n")
+	return song
+}
+
+// OnSong renders the chart as an OnSong document
+func (s *SongFile) OnSong() string {
+	var out strings.Builder
+
+	out.WriteString(s.Title + "\n")
+	if s.Artist != "" {
+		out.WriteString(s.Artist + "\n")
+	}
+	if s.Key != "" {
+		out.WriteString(fmt.Sprintf("Key: %s\n", s.Key))
+	}
+	if s.Capo > 0 {
+		out.WriteString(fmt.Sprintf("Capo: %d\n", s.Capo))
+	}
+	if s.Tuning != "" {
+		out.WriteString(fmt.Sprintf("Tuning: %s\n", s.Tuning))
+	}
+	for _, meta := range s.Meta {
+		if meta[0] == "" {
+			out.WriteString(meta[1] + "\n")
+		} else {
+			out.WriteString(fmt.Sprintf("%s: %s\n", meta[0], meta[1]))
+		}
+	}
+	out.WriteString("\n")
+	out.WriteString(s.Body)
+	out.WriteString("\n")
+
+	return out.String()
+}
+
+// InlineChords returns the [Chord] markers of the chart in order
+func (s *SongFile) InlineChords() []string {
+	var chords []string
+	for _, m := range inlineChordRegex.FindAllStringSubmatch(s.Body, -1) {
+		chords = append(chords, m[1])
+	}
+	return chords
+}
+
+// setMeta stores a header field, keeping the well-known ones in their own fields
+func (s *SongFile) setMeta(name, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	switch strings.ToLower(name) {
+	case "key":
+		s.Key = value
+	case "capo":
+		if capo, err := strconv.Atoi(value); err == nil {
+			s.Capo = capo
+		}
+	case "tuning":
+		s.Tuning = value
+	default:
+		label := strings.ToUpper(name[:1]) + strings.ToLower(name[1:])
+		if strings.EqualFold(name, "ccli") {
+			label = "CCLI"
+		}
+		s.Meta = append(s.Meta, [2]string{label, value})
+	}
+}
+
+// onSongSectionLabel returns the label of an OnSong section line such as
+// "Verse 1:", or "" when the line is not one
+func onSongSectionLabel(line string) string {
+	label, ok := strings.CutSuffix(strings.TrimSpace(line), ":")
+	if !ok || !onSongLabelRegex.MatchString(label) || len(strings.Fields(label)) > 3 {
+		return ""
+	}
+	return label
+}
+
+// SectionEnvironment returns the ChordPro environment of an OnSong section
+// label, English or localized: "chorus", "verse" or "bridge", or "" for
+// sections ChordPro has no environment for, such as an intro
+func SectionEnvironment(label string) string {
+	m := sectionLabelParts.FindStringSubmatch(strings.TrimSuffix(strings.TrimSpace(label), ":"))
+	name := strings.ToLower(m[1])
+	if english, ok := localSectionNames[name]; ok {
+		name = strings.ToLower(english)
+	}
+
+	switch name {
+	case "chorus", "refrain":
+		return "chorus"
+	case "verse":
+		return "verse"
+	case "bridge":
+		return "bridge"
+	}
+	return ""
+}
+
+// sectionStart renders a ChordPro start_of_* directive as an OnSong label
+func sectionStart(label, fallback string) string {
+	if strings.TrimSpace(label) == "" {
+		label = fallback
+	}
+	return strings.TrimSpace(label) + ":"
+}
+
+// DetectKey guesses the key of a chart from its chord names
+func (c *OnSongConverter) DetectKey(chords []string) string {
+	return c.parser.DetectKey(chords)
+}
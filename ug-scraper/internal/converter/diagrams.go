@@ -0,0 +1,50 @@
+package converter
+
+import (
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// Diagram sources
+const (
+	DiagramAuthor  = "author"   // The voicing the tab author drew on Ultimate Guitar
+	DiagramBuiltIn = "built-in" // A common open shape from this add-on
+)
+
+// AuthorDiagrams returns the author's preferred voicing of every chord the
+// tab has applicature for, in the author's order
+func AuthorDiagrams(applicature []scraper.Applicature) []ChordDiagram {
+	diagrams := make([]ChordDiagram, 0, len(applicature))
+	for _, a := range applicature {
+		if a.Chord == "" || len(a.Voicings) == 0 {
+			continue
+		}
+		v := a.Voicings[0]
+		diagrams = append(diagrams, ChordDiagram{
+			Chord:    a.Chord,
+			Frets:    v.Frets,
+			Fingers:  v.Fingers,
+			BaseFret: v.BaseFret,
+			Source:   DiagramAuthor,
+		})
+	}
+	return diagrams
+}
+
+// addDefines sets a result's diagrams and writes them as {define} lines
+// right after the header block
+func addDefines(result *ConversionResult, diagrams []ChordDiagram) {
+	result.Diagrams = diagrams
+	if len(diagrams) == 0 {
+		return
+	}
+
+	defines := make([]string, len(diagrams))
+	for i, d := range diagrams {
+		defines[i] = d.Define()
+	}
+
+	header, body, _ := strings.Cut(result.OnSongFormat, "\n\n")
+	result.OnSongFormat = header + "\n" + strings.Join(defines, "\n") + "\n\n" + body
+}
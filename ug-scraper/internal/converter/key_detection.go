@@ -0,0 +1,296 @@
+package converter
+
+import (
+	"math"
+	"strings"
+)
+
+// Krumhansl-Kessler key profiles: how strongly each scale degree (0 = tonic)
+// establishes a major or minor key
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// Chord qualities as far as key detection cares
+const (
+	qualityMajor = iota
+	qualityMinor
+	qualityDiminished
+	qualityOther // sus, aug, power chords: no third to judge by
+)
+
+// Diatonic triads by scale degree (semitones above the tonic). Minor keys
+// also accept the major V and diminished vii of harmonic minor.
+var (
+	majorDiatonic = map[int][]int{
+		0: {qualityMajor}, 2: {qualityMinor}, 4: {qualityMinor}, 5: {qualityMajor},
+		7: {qualityMajor}, 9: {qualityMinor}, 11: {qualityDiminished},
+	}
+	minorDiatonic = map[int][]int{
+		0: {qualityMinor}, 2: {qualityDiminished}, 3: {qualityMajor}, 5: {qualityMinor},
+		7: {qualityMinor, qualityMajor}, 8: {qualityMajor}, 10: {qualityMajor}, 11: {qualityDiminished},
+	}
+)
+
+// Weights of the scoring components
+const (
+	diatonicWeight = 1.0  // Share of chords that belong to the key
+	cadenceWeight  = 0.5  // Share of changes that resolve V-I or IV-I
+	tonicEndWeight = 0.25 // Song starts and ends on the tonic chord
+	// softmaxTemperature turns score gaps into a confidence; lower is sharper
+	softmaxTemperature = 0.15
+	// minDistinctRoots is how many different chord roots full confidence needs
+	minDistinctRoots = 3
+)
+
+// KeyEstimate is a detected key with how sure the detection is
+type KeyEstimate struct {
+	Key        string  `json:"key"`
+	Confidence float64 `json:"confidence"` // 0-1, the best key's share against all 24 candidates
+}
+
+// parsedChord is a chord reduced to what key detection needs
+type parsedChord struct {
+	root    int
+	quality int
+	tones   []int // Pitch classes of the chord tones
+}
+
+// EstimateKey detects the key of a chord sequence with the parser's
+// strategy (see KeyStrategyFromEnv)
+func (p *ChordParser) EstimateKey(chords []string) KeyEstimate {
+	return EstimateKeyWith(p.strategy, chords)
+}
+
+// estimateFrequency scores all 24 major and minor keys against a chord
+// sequence. Each key's score combines the correlation of the chords' pitch
+// classes with its Krumhansl profile, the share of chords diatonic to it,
+// how many chord changes resolve onto its tonic (V-I, IV-I) and whether the
+// song starts or ends on its tonic chord.
+func estimateFrequency(parsed []parsedChord) KeyEstimate {
+	var histogram [12]float64
+	for _, chord := range parsed {
+		for i, tone := range chord.tones {
+			// The root matters most, then the third and fifth
+			weight := 1.0
+			if i == 0 {
+				weight = 1.5
+			}
+			histogram[tone] += weight
+		}
+	}
+
+	return pickKey(parsed, func(tonic int, minor bool) float64 {
+		return scoreKey(parsed, histogram, tonic, minor)
+	})
+}
+
+// pickKey scores all 24 major and minor keys and returns the best one. Its
+// confidence is its softmax share against the others, lowered for charts
+// with too few distinct roots to tell keys apart.
+func pickKey(parsed []parsedChord, score func(tonic int, minor bool) float64) KeyEstimate {
+	type candidate struct {
+		tonic int
+		minor bool
+		score float64
+	}
+	candidates := make([]candidate, 0, 24)
+	for tonic := 0; tonic < 12; tonic++ {
+		for _, minor := range []bool{false, true} {
+			candidates = append(candidates, candidate{
+				tonic: tonic,
+				minor: minor,
+				score: score(tonic, minor),
+			})
+		}
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.score > best.score {
+			best = c
+		}
+	}
+
+	var total float64
+	for _, c := range candidates {
+		total += math.Exp((c.score - best.score) / softmaxTemperature)
+	}
+
+	// A chart with one or two distinct roots fits many keys equally well
+	roots := make(map[int]bool)
+	for _, chord := range parsed {
+		roots[chord.root] = true
+	}
+	evidence := math.Min(1, float64(len(roots))/minDistinctRoots)
+
+	key := sharpNotes[best.tonic]
+	if best.minor {
+		key += "m"
+	}
+	if UsesFlats(key) {
+		key = flatNotes[best.tonic]
+		if best.minor {
+			key += "m"
+		}
+	}
+
+	return KeyEstimate{
+		Key:        key,
+		Confidence: math.Round(100*evidence/total) / 100,
+	}
+}
+
+// scoreKey rates how well a chord sequence fits one key
+func scoreKey(chords []parsedChord, histogram [12]float64, tonic int, minor bool) float64 {
+	profile, diatonic := majorProfile, majorDiatonic
+	tonicQuality := qualityMajor
+	if minor {
+		profile, diatonic = minorProfile, minorDiatonic
+		tonicQuality = qualityMinor
+	}
+
+	var rotated [12]float64
+	for pc := 0; pc < 12; pc++ {
+		rotated[pc] = profile[(pc-tonic+12)%12]
+	}
+	score := correlation(histogram, rotated)
+	score += diatonicWeight * diatonicShare(chords, diatonic, tonic)
+
+	isTonic := func(c parsedChord) bool {
+		return isTonicChord(c, tonic, tonicQuality)
+	}
+
+	if len(chords) > 1 {
+		cadences := 0
+		for i := 1; i < len(chords); i++ {
+			from := (chords[i-1].root - tonic + 12) % 12
+			if isTonic(chords[i]) && (from == 7 || from == 5) && chords[i-1].root != chords[i].root {
+				cadences++
+			}
+		}
+		score += cadenceWeight * float64(cadences) / float64(len(chords)-1)
+	}
+
+	ends := 0.0
+	if isTonic(chords[0]) {
+		ends += 0.5
+	}
+	if isTonic(chords[len(chords)-1]) {
+		ends += 0.5
+	}
+	score += tonicEndWeight * ends
+
+	return score
+}
+
+// diatonicShare returns the share of chords diatonic to a key
+func diatonicShare(chords []parsedChord, diatonic map[int][]int, tonic int) float64 {
+	inKey := 0
+	for _, chord := range chords {
+		if fitsDegree(diatonic, (chord.root-tonic+12)%12, chord.quality) {
+			inKey++
+		}
+	}
+	return float64(inKey) / float64(len(chords))
+}
+
+// isTonicChord reports whether a chord is the tonic chord of a key; chords
+// without a third count for major and minor alike
+func isTonicChord(c parsedChord, tonic, tonicQuality int) bool {
+	return c.root == tonic && (c.quality == tonicQuality || c.quality == qualityOther)
+}
+
+// fitsDegree reports whether a chord quality is diatonic on a scale degree
+func fitsDegree(diatonic map[int][]int, degree, quality int) bool {
+	qualities, ok := diatonic[degree]
+	if !ok {
+		return false
+	}
+	if quality == qualityOther {
+		return true
+	}
+	for _, q := range qualities {
+		if q == quality {
+			return true
+		}
+	}
+	return false
+}
+
+// ChordTones returns the pitch classes (0-11) of a chord's root, third,
+// fifth and seventh, root first. Extensions and slash bass notes are left
+// out. Compact and jazz qualities (CM7, A-) are read as their standard names.
+func ChordTones(chord string) ([]int, bool) {
+	parsed, ok := parseChordTones(StyleChord(chord, chordStylePresets[ChordStyleStandard]))
+	return parsed.tones, ok
+}
+
+// parseChordTones reads a chord name's root, quality and chord tones.
+// Slash bass notes are ignored; they rarely change the key.
+func parseChordTones(chord string) (parsedChord, bool) {
+	parts := chordPartsRegex.FindStringSubmatch(NormalizeChordName(chord, ChordStyle{}))
+	if parts == nil {
+		return parsedChord{}, false
+	}
+	root := NoteIndex(parts[1])
+	if root < 0 {
+		return parsedChord{}, false
+	}
+
+	suffix := parts[2]
+	quality, third, fifth := qualityMajor, 4, 7
+	switch {
+	case strings.HasPrefix(suffix, "dim"), strings.Contains(suffix, "m7b5"):
+		quality, third, fifth = qualityDiminished, 3, 6
+	case strings.HasPrefix(suffix, "min"), strings.HasPrefix(suffix, "m") && !strings.HasPrefix(suffix, "maj"):
+		quality, third = qualityMinor, 3
+	case strings.HasPrefix(suffix, "aug"), strings.HasPrefix(suffix, "+"):
+		quality, fifth = qualityOther, 8
+	case strings.Contains(suffix, "sus2"):
+		quality, third = qualityOther, 2
+	case strings.Contains(suffix, "sus"):
+		quality, third = qualityOther, 5
+	case suffix == "5":
+		quality, third = qualityOther, -1
+	}
+
+	tones := []int{root}
+	if third >= 0 {
+		tones = append(tones, (root+third)%12)
+	}
+	tones = append(tones, (root+fifth)%12)
+
+	switch {
+	case strings.Contains(suffix, "maj7"):
+		tones = append(tones, (root+11)%12)
+	case strings.Contains(suffix, "7"):
+		tones = append(tones, (root+10)%12)
+	}
+
+	return parsedChord{root: root, quality: quality, tones: tones}, true
+}
+
+// correlation returns the Pearson correlation of two pitch-class vectors
+func correlation(a, b [12]float64) float64 {
+	var meanA, meanB float64
+	for i := 0; i < 12; i++ {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= 12
+	meanB /= 12
+
+	var cov, varA, varB float64
+	for i := 0; i < 12; i++ {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
@@ -0,0 +1,160 @@
+package converter
+
+import (
+	"os"
+	"strings"
+)
+
+// Key detection strategies, used where a chart doesn't state its key
+const (
+	KeyStrategyFrequency = "frequency" // Pitch-class profile, diatonic chords, cadences and tonic start/end
+	KeyStrategyDiatonic  = "diatonic"  // Share of diatonic chords, then of tonic chords
+	KeyStrategyEndpoints = "endpoints" // The first and last chord, as most songs start and end at home
+)
+
+// Weights of the simpler strategies
+const (
+	tonicShareWeight  = 0.25 // diatonic: tells relative major and minor apart
+	lastChordWeight   = 0.3  // endpoints: songs end on the tonic more often than they start on it
+	firstChordWeight  = 0.2
+	endpointsDiatonic = 0.1 // endpoints: breaks ties, e.g. for sus or power chords
+)
+
+// KeyStrategies lists the key detection strategies, the default first
+func KeyStrategies() []string {
+	return []string{KeyStrategyFrequency, KeyStrategyDiatonic, KeyStrategyEndpoints}
+}
+
+// ValidKeyStrategy reports whether strategy is a known key detection strategy
+func ValidKeyStrategy(strategy string) bool {
+	return strategy == KeyStrategyFrequency || strategy == KeyStrategyDiatonic || strategy == KeyStrategyEndpoints
+}
+
+// KeyStrategyFromEnv reads KEY_DETECTION, defaulting to frequency
+func KeyStrategyFromEnv() string {
+	if strategy := strings.ToLower(os.Getenv("KEY_DETECTION")); ValidKeyStrategy(strategy) {
+		return strategy
+	}
+	return KeyStrategyFrequency
+}
+
+// WithKeyStrategy returns a converter that detects keys with the given
+// strategy. Unknown strategies keep the current choice.
+func (c *OnSongConverter) WithKeyStrategy(strategy string) *OnSongConverter {
+	if !ValidKeyStrategy(strategy) {
+		return c
+	}
+
+	parser := *c.parser
+	parser.strategy = strategy
+	conv := *c
+	conv.parser = &parser
+	return &conv
+}
+
+// KeyStrategy returns the converter's key detection strategy
+func (c *OnSongConverter) KeyStrategy() string {
+	return c.parser.strategy
+}
+
+// EstimateKeyWith detects the key of a chord sequence with the given
+// strategy; unknown strategies use frequency. The key is "" when none of the
+// chords can be read.
+func EstimateKeyWith(strategy string, chords []string) KeyEstimate {
+	var parsed []parsedChord
+	for _, chord := range chords {
+		if pc, ok := parseChordTones(chord); ok {
+			parsed = append(parsed, pc)
+		}
+	}
+	if len(parsed) == 0 {
+		return KeyEstimate{}
+	}
+
+	switch strategy {
+	case KeyStrategyDiatonic:
+		return estimateDiatonic(parsed)
+	case KeyStrategyEndpoints:
+		return estimateEndpoints(parsed)
+	default:
+		return estimateFrequency(parsed)
+	}
+}
+
+// estimateDiatonic picks the key most of the chords belong to. Relative
+// keys share their chords, so the share of tonic chords decides between them.
+func estimateDiatonic(parsed []parsedChord) KeyEstimate {
+	return pickKey(parsed, func(tonic int, minor bool) float64 {
+		diatonic, tonicQuality := majorDiatonic, qualityMajor
+		if minor {
+			diatonic, tonicQuality = minorDiatonic, qualityMinor
+		}
+
+		tonics := 0
+		for _, chord := range parsed {
+			if isTonicChord(chord, tonic, tonicQuality) {
+				tonics++
+			}
+		}
+		return diatonicShare(parsed, diatonic, tonic) + tonicShareWeight*float64(tonics)/float64(len(parsed))
+	})
+}
+
+// estimateEndpoints takes the key from the last chord, or the first one
+// when the song doesn't end at home
+func estimateEndpoints(parsed []parsedChord) KeyEstimate {
+	first, last := parsed[0], parsed[len(parsed)-1]
+	return pickKey(parsed, func(tonic int, minor bool) float64 {
+		diatonic, tonicQuality := majorDiatonic, qualityMajor
+		if minor {
+			diatonic, tonicQuality = minorDiatonic, qualityMinor
+		}
+
+		score := endpointsDiatonic * diatonicShare(parsed, diatonic, tonic)
+		if isTonicChord(last, tonic, tonicQuality) {
+			score += lastChordWeight
+		}
+		if isTonicChord(first, tonic, tonicQuality) {
+			score += firstChordWeight
+		}
+		return score
+	})
+}
+
+// StrategyEstimate is the key one strategy detected
+type StrategyEstimate struct {
+	Strategy string `json:"strategy"`
+	KeyEstimate
+}
+
+// CompareKeyStrategies runs every key detection strategy on a chord sequence
+func CompareKeyStrategies(chords []string) []StrategyEstimate {
+	var estimates []StrategyEstimate
+	for _, strategy := range KeyStrategies() {
+		estimates = append(estimates, StrategyEstimate{
+			Strategy:    strategy,
+			KeyEstimate: EstimateKeyWith(strategy, chords),
+		})
+	}
+	return estimates
+}
+
+// ContentChords returns the chords of a chart or tab in order, from its
+// [ch] tags, [C] markers or chord-only lines, whichever it uses
+func (c *OnSongConverter) ContentChords(content string) []string {
+	if chords := c.parser.ExtractChords(content); len(chords) > 0 {
+		return chords
+	}
+
+	var chords []string
+	for _, m := range inlineChordRegex.FindAllStringSubmatch(content, -1) {
+		// Section labels such as [Chorus] look like markers too
+		if ValidChord(m[1]) {
+			chords = append(chords, m[1])
+		}
+	}
+	if len(chords) > 0 {
+		return chords
+	}
+	return c.extractPlainChords(content)
+}
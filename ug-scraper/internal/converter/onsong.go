@@ -2,30 +2,184 @@ package converter
 
 import (
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 
 	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
 )
 
+// Which key goes into the Key: header of a chart played with a capo
+const (
+	KeyHeaderShape    = "shape"    // Key of the chord shapes as written
+	KeyHeaderSounding = "sounding" // Shape key raised by the capo, as heard
+)
+
+// ValidKeyHeader reports whether mode is a known key header choice
+func ValidKeyHeader(mode string) bool {
+	return mode == KeyHeaderShape || mode == KeyHeaderSounding
+}
+
 // OnSongConverter converts Ultimate Guitar tabs to OnSong/ChordPro format
 type OnSongConverter struct {
-	parser *ChordParser
+	parser       *ChordParser
+	keyHeader    string
+	key          string // Key of the chords as written, overriding detection
+	spelling     string
+	style        ChordStyle
+	autoSections bool
+	profile      string
+	bassHints    bool
+	bassLines    bool
+	removeCapo   bool
+
+	normalizeSections bool // Localized section headers become their English sections
 }
 
 // NewOnSongConverter creates a new OnSong converter
 func NewOnSongConverter() *OnSongConverter {
 	return &OnSongConverter{
-		parser: NewChordParser(),
+		parser:       NewChordParser(),
+		keyHeader:    KeyHeaderShape,
+		spelling:     SpellingAuto,
+		autoSections: true,
+		profile:      ProfileGuitar,
+
+		normalizeSections: true,
+	}
+}
+
+// WithKeyHeader returns a converter that writes the given key (shape or
+// sounding) into the Key: header. Unknown modes keep the current choice.
+func (c *OnSongConverter) WithKeyHeader(mode string) *OnSongConverter {
+	if !ValidKeyHeader(mode) {
+		return c
+	}
+
+	conv := *c
+	conv.keyHeader = mode
+	return &conv
+}
+
+// WithSpelling returns a converter that spells chords with the given
+// preference (auto, sharps or flats). Unknown values keep the current choice.
+func (c *OnSongConverter) WithSpelling(spelling string) *OnSongConverter {
+	if !ValidSpelling(spelling) {
+		return c
+	}
+
+	conv := *c
+	conv.spelling = spelling
+	return &conv
+}
+
+// WithChordStyle returns a converter that writes chord qualities in the
+// given style, such as CM7 or CΔ7 for Cmaj7
+func (c *OnSongConverter) WithChordStyle(style ChordStyle) *OnSongConverter {
+	conv := *c
+	conv.style = style
+	return &conv
+}
+
+// AutoSectionsFromEnv reads the AUTO_SECTIONS switch; labelling is on
+// unless it is "false"
+func AutoSectionsFromEnv() bool {
+	return os.Getenv("AUTO_SECTIONS") != "false"
+}
+
+// WithAutoSections returns a converter that does or does not label the
+// sections of charts that have no [Verse]/[Chorus] markers
+func (c *OnSongConverter) WithAutoSections(enabled bool) *OnSongConverter {
+	conv := *c
+	conv.autoSections = enabled
+	return &conv
+}
+
+// WithBassLines returns a converter that does or does not suggest a bass
+// line for each section of chord charts
+func (c *OnSongConverter) WithBassLines(enabled bool) *OnSongConverter {
+	conv := *c
+	conv.bassLines = enabled
+	return &conv
+}
+
+// respell applies the spelling preference and chord style to a conversion
+// result. Auto mode follows the detected shape key, since that is what the
+// chords are in; keys keep their plain m.
+func (c *OnSongConverter) respell(result *ConversionResult) {
+	key := result.ShapeKey
+	respellKey := func(k string) string {
+		if k == "" || k == "Unknown" {
+			return k
+		}
+		return RespellChord(k, c.spelling, key)
+	}
+
+	result.OnSongFormat = StyleOnSong(respellChart(result.OnSongFormat, c.spelling, key), c.style)
+	for i, chord := range result.Chords {
+		result.Chords[i] = StyleChord(RespellChord(chord, c.spelling, key), c.style)
+	}
+	result.DetectedKey = respellKey(result.DetectedKey)
+	result.SoundingKey = respellKey(result.SoundingKey)
+	result.ShapeKey = respellKey(result.ShapeKey)
+}
+
+// WithKey returns a converter that takes key as the key of the chords as
+// written, instead of Ultimate Guitar's tonality or the detected key. It is
+// written into the Key: header and chords are spelled against it. Keys
+// ParseKey can't read, including "", keep the current choice.
+func (c *OnSongConverter) WithKey(key string) *OnSongConverter {
+	parsed, ok := ParseKey(key)
+	if !ok {
+		return c
+	}
+
+	conv := *c
+	conv.key = parsed
+	return &conv
+}
+
+// shapeKey returns the key of a tab's chords as written and how sure it is:
+// the key asked for, Ultimate Guitar's tonality, or else the key estimated
+// from the chords
+func (c *OnSongConverter) shapeKey(tab *scraper.TabResult, chords []string) (string, float64) {
+	if c.key != "" {
+		return c.key, 1
+	}
+	if tab.TonalityName != "" && tab.TonalityName != "undefined" {
+		return tab.TonalityName, 1
 	}
+	estimate := c.parser.EstimateKey(chords)
+	return estimate.Key, estimate.Confidence
+}
+
+// headerKey returns the key to write into the Key: header
+func (c *OnSongConverter) headerKey(shapeKey, soundingKey string) string {
+	if c.keyHeader == KeyHeaderSounding {
+		return soundingKey
+	}
+	return shapeKey
 }
 
 // ConversionResult holds the converted tab and metadata
 type ConversionResult struct {
-	OnSongFormat string
-	DetectedKey  string
-	ChordCount   int
-	Chords       []string
+	Format        string // FormatOnSong, FormatTab or FormatBinary
+	OnSongFormat  string
+	Monospace     string     // Plain monospace text, set for FormatTab
+	Blocks        []TabBlock // Tab and text blocks of Monospace, set for FormatTab
+	DetectedKey   string     // The key written into the Key: header
+	ShapeKey      string     // Key of the chord shapes as written
+	SoundingKey   string     // Key heard with the capo applied; equals ShapeKey without a capo
+	KeyHeader     string     // KeyHeaderShape or KeyHeaderSounding
+	KeyConfidence float64    // 0-1; 1 when Ultimate Guitar supplied the key
+	ChordCount    int
+	Chords        []string
+	Diagrams      []ChordDiagram    // Chord fingerings: the author's voicings, or built-in ukulele shapes
+	Warnings      []ChordWarning    // Chord problems found in the source content
+	Strumming     []StrumPattern    // Strumming patterns from the tab metadata
+	Notes         []PerformanceNote // Performance notes moved onto {comment:} lines
+	Profile       string            // ProfileGuitar or ProfilePiano
+	BassLines     []SectionBassLine // Suggested bass lines, set when asked for
 }
 
 // Convert transforms a TabResult into OnSong/ChordPro format
@@ -37,17 +191,34 @@ func (c *OnSongConverter) Convert(tab *scraper.TabResult) (*ConversionResult, er
 	// Extract chords from content
 	chords := c.parser.ExtractChords(tab.Content)
 
-	// Detect key if not provided
-	detectedKey := tab.TonalityName
-	if detectedKey == "" || detectedKey == "undefined" {
-		detectedKey = c.parser.DetectKey(chords)
+	// Detect key if not provided. Chords (and UG's tonality) describe the
+	// shapes played, so with a capo the song sounds higher than this.
+	shapeKey, confidence := c.shapeKey(tab, chords)
+	soundingKey := SoundingKey(shapeKey, tab.Capo)
+
+	// Piano charts, and charts with the capo removed, are written at
+	// sounding pitch: there is no capo to play the shapes with, so the
+	// shapes become what is heard
+	capo := tab.Capo
+	if c.sounding() {
+		chords = soundingChords(chords, capo)
+		shapeKey = soundingKey
+		capo = 0
 	}
+
+	detectedKey := c.headerKey(shapeKey, soundingKey)
 	if detectedKey == "" {
 		detectedKey = "Unknown"
 	}
 
 	// Convert the content
 	formattedContent := c.formatContent(tab.Content)
+	if c.autoSections {
+		formattedContent = AutoSections(formattedContent)
+	}
+	if c.sounding() {
+		formattedContent = soundingChart(formattedContent, tab.Capo)
+	}
 
 	// Build OnSong format
 	output := strings.Builder{}
@@ -60,16 +231,21 @@ func (c *OnSongConverter) Convert(tab *scraper.TabResult) (*ConversionResult, er
 		output.WriteString(fmt.Sprintf("Key: %s\n", detectedKey))
 	}
 
-	if tab.Capo > 0 {
-		output.WriteString(fmt.Sprintf("Capo: %d\n", tab.Capo))
+	if capo > 0 {
+		output.WriteString(fmt.Sprintf("Capo: %d\n", capo))
 	}
 
-	if tab.Tuning != "" && tab.Tuning != "E A D G B E" {
+	if !c.piano() && tab.Tuning != "" && tab.Tuning != "E A D G B E" {
 		output.WriteString(fmt.Sprintf("Tuning: %s\n", tab.Tuning))
 	}
 
 	output.WriteString("\n")
 
+	strumming := StrumPatterns(tab.Strummings)
+	if len(strumming) > 0 {
+		output.WriteString(strumComments(strumming) + "\n")
+	}
+
 	// Add the formatted tab content
 	output.WriteString(formattedContent)
 
@@ -79,12 +255,46 @@ func (c *OnSongConverter) Convert(tab *scraper.TabResult) (*ConversionResult, er
 	output.WriteString(fmt.Sprintf("# Contributor: %s\n", tab.Contributor.Username))
 	output.WriteString(fmt.Sprintf("# Rating: %.1f/5.0 (%d votes)\n", tab.Rating, tab.Votes))
 
-	return &ConversionResult{
-		OnSongFormat: output.String(),
-		DetectedKey:  detectedKey,
-		ChordCount:   len(chords),
-		Chords:       c.getUniqueChords(chords),
-	}, nil
+	result := &ConversionResult{
+		Format:        FormatOnSong,
+		OnSongFormat:  output.String(),
+		DetectedKey:   detectedKey,
+		ShapeKey:      shapeKey,
+		SoundingKey:   soundingKey,
+		KeyHeader:     c.keyHeader,
+		KeyConfidence: confidence,
+		ChordCount:    len(chords),
+		Chords:        c.getUniqueChords(chords),
+		Warnings:      ValidateChords(tab.Content),
+		Strumming:     strumming,
+		Notes:         PerformanceNotes(tab.Content),
+		Profile:       c.profile,
+	}
+	c.respell(result)
+
+	if c.bassLines {
+		result.BassLines = BassLines(result.OnSongFormat, PreferFlats(c.spelling, result.ShapeKey))
+	}
+
+	if c.piano() {
+		// Piano charts have no guitar diagrams; slash chords are called out
+		// instead, with the left hand's bass notes if asked for
+		addSlashChords(result)
+		if c.bassHints {
+			addBassHints(result)
+		}
+	} else if !c.removeCapo || tab.Capo == 0 {
+		// The author's voicings, named to match the respelled chart. They
+		// are capo shapes, so a chart with the capo removed leaves them out.
+		diagrams := AuthorDiagrams(tab.Applicature)
+		for i := range diagrams {
+			diagrams[i].Chord = StyleChord(RespellChord(diagrams[i].Chord, c.spelling, result.ShapeKey), c.style)
+		}
+		addDefines(result, diagrams)
+	}
+	addBassLineBlock(result)
+
+	return result, nil
 }
 
 // formatContent converts Ultimate Guitar format to OnSong/ChordPro format
@@ -93,6 +303,12 @@ func (c *OnSongConverter) formatContent(content string) string {
 	content = strings.ReplaceAll(content, "[tab]", "")
 	content = strings.ReplaceAll(content, "[/tab]", "")
 
+	// Spell F♯ and B♭ as F# and Bb and straighten quotes and dashes
+	content = NormalizeTypography(content)
+
+	// Move "x2", "let ring" and similar notes onto {comment:} lines
+	content, _ = extractPerformanceNotes(content)
+
 	// Check if content has [ch] tags (UG format) or plain chords
 	hasChTags := strings.Contains(content, "[ch]")
 
@@ -102,10 +318,9 @@ func (c *OnSongConverter) formatContent(content string) string {
 		content = regexp.MustCompile(`\[/ch\]`).ReplaceAllString(content, "]")
 	}
 
-	// Convert section headers from [Section Name] to "Section Name:"
-	// Match common section names
-	sectionPattern := regexp.MustCompile(`(?mi)^\[(Intro|Verse\s*\d*|Chorus\s*\d*|Pre-Chorus|Bridge|Instrumental|Interlude|Turnaround|Outro|Tag|Ending|Solo|Break|Refrain|Coda|Hook|Vamp|Outro Chorus)\]\s*$`)
-	content = sectionPattern.ReplaceAllString(content, "$1:")
+	// Convert section headers from [Section Name] to "Section Name:",
+	// mapping localized names such as [Estribillo] to their English section
+	content = formatSectionHeaders(content, c.normalizeSections)
 
 	// If no [ch] tags were present, detect plain chord lines and wrap them
 	if !hasChTags {
@@ -136,8 +351,18 @@ func (c *OnSongConverter) formatContent(content string) string {
 	return content
 }
 
+// sectionNames are the section labels recognised in [Section Name] headers
+const sectionNames = `Intro|Verse\s*\d*|Chorus\s*\d*|Pre-Chorus|Bridge|Instrumental|Interlude|Turnaround|Outro|Tag|Ending|Solo|Break|Refrain|Coda|Hook|Vamp|Outro Chorus`
+
+// sectionHeaderRegex matches common [Section Name] header lines
+var sectionHeaderRegex = regexp.MustCompile(`(?mi)^\[(` + sectionNames + `)\]\s*$`)
+
+// sectionNoteRegex matches a section header, English or localized,
+// carrying a note, such as "[Chorus x2]", "[Chorus] (x2)" or "[Coro x2]"
+var sectionNoteRegex = regexp.MustCompile(`(?i)^\s*\[(` + sectionNames + `|` + localSectionPattern + `)(?:\s+|\]\s*)[(*]?([^()*\[\]]+?)[)*]?\]?\s*$`)
+
 // chordLineRegex matches a single chord token (e.g. G, Am, F#m7, Bb, Dsus4, C/G)
-var chordTokenRegex = regexp.MustCompile(`^[A-G][#b]?(?:maj|min|m|M|sus[24]?|aug|dim|add|no)?[0-9]*(?:/[A-G][#b]?)?$`)
+var chordTokenRegex = regexp.MustCompile(`^[A-G]` + accidental + `?(?:maj|min|m|M|sus[24]?|aug|dim|add|no)?[0-9]*(?:/[A-G]` + accidental + `?)?$`)
 
 // wrapPlainChordLines detects lines that consist only of chord names and
 // wraps each chord in [] brackets for OnSong format
@@ -182,7 +407,7 @@ func (c *OnSongConverter) getUniqueChords(chords []string) []string {
 	unique := []string{}
 
 	for _, chord := range chords {
-		normalized := NormalizeChordName(chord)
+		normalized := NormalizeChordName(chord, c.style)
 		if !seen[normalized] && normalized != "" {
 			seen[normalized] = true
 			unique = append(unique, normalized)
@@ -223,7 +448,8 @@ func (c *OnSongConverter) ValidateTab(tab *scraper.TabResult) error {
 		return fmt.Errorf("artist name is required")
 	}
 
-	if tab.Content == "" {
+	// Guitar Pro tabs are binary downloads and carry no text content
+	if tab.Content == "" && tab.Type != scraper.TypeGuitarPro {
 		return fmt.Errorf("tab content is empty")
 	}
 
@@ -256,10 +482,13 @@ func (c *OnSongConverter) FormatManualContent(title, artist, content string) str
 	// Format the content using the same logic as scraped tabs
 	if content != "" {
 		formatted := c.formatContent(content)
+		if c.autoSections {
+			formatted = AutoSections(formatted)
+		}
 		output.WriteString(formatted)
 	}
 
-	return output.String()
+	return StyleOnSong(RespellOnSong(output.String(), c.spelling), c.style)
 }
 
 // extractPlainChords scans plain text for chord-only lines and returns chord names
@@ -0,0 +1,263 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// Performance note kinds
+const (
+	NoteRepeat    = "repeat"    // "x2", "2x", "repeat 3 times"
+	NoteTechnique = "technique" // "let ring", "palm mute", "fade out"
+)
+
+var (
+	// repeatNoteRegex matches a repeat count such as "x2", "2x", "(x4)" or "repeat 2 times"
+	repeatNoteRegex = regexp.MustCompile(`(?i)^(?:[x×]\s?\d+|\d+\s?[x×]|repeat(?:\s+[x×]?\d+(?:\s*(?:[x×]|times))?)?)$`)
+	// techniqueNoteRegex matches playing instructions written into charts
+	techniqueNoteRegex = regexp.MustCompile(`(?i)^(?:let\s+ring|ring\s+out|palm\s+mut(?:e|ed|ing)|p\.?m\.?|muted?|fade\s+out|tacet|staccato|fingerpick(?:ed)?|fingerpicking|rit\.?|ritardando|a\s+tempo|slowly|single\s+strums?|hold)$`)
+	// trailingNoteRegex splits a line into its body and a final note, which
+	// may be wrapped in parentheses or asterisks
+	trailingNoteRegex = regexp.MustCompile(`^(.*?)\s*([(*]?)([^()*]+?)([)*]?)\s*$`)
+	// inlineChordOnlyRegex matches lines that hold nothing but [chords] and bar lines
+	inlineChordOnlyRegex = regexp.MustCompile(`^(?:\s*(?:\[[^\]]+\]|\|))+\s*$`)
+)
+
+// PerformanceNote is a playing instruction found in a chart, written into
+// the conversion as a {comment:} line instead of being left in the lyrics
+type PerformanceNote struct {
+	Line int    `json:"line"` // 1-based line in the source content
+	Kind string `json:"kind"` // NoteRepeat or NoteTechnique
+	Text string `json:"text"`
+}
+
+// performanceNote classifies text as a note, returning its kind or ""
+func performanceNote(text string) string {
+	text = strings.TrimSpace(text)
+	switch {
+	case repeatNoteRegex.MatchString(text):
+		return NoteRepeat
+	case techniqueNoteRegex.MatchString(text):
+		return NoteTechnique
+	}
+	return ""
+}
+
+// splitTrailingNote splits the note off the end of a line. Bare notes count
+// only when they make up the whole line or follow chords; on lyric lines
+// only bracketed repeats like "(x2)" do, so lyrics ending in "hold" stay put.
+func splitTrailingNote(line string) (body, note string) {
+	plain := chTagRegex.ReplaceAllString(line, "")
+	m := trailingNoteRegex.FindStringSubmatch(plain)
+	if m == nil {
+		return line, ""
+	}
+	open, text, close := m[2], strings.TrimSpace(m[3]), m[4]
+	if (open == "(") != (close == ")") || (open == "*") != (close == "*") {
+		return line, ""
+	}
+
+	// Unwrapped notes end the line; try its last three words, then fewer
+	kind := performanceNote(text)
+	if kind == "" && open == "" {
+		words := strings.Fields(text)
+		for n := min(3, len(words)-1); n >= 1 && kind == ""; n-- {
+			if k := performanceNote(strings.Join(words[len(words)-n:], " ")); k != "" {
+				kind = k
+				m[1] = strings.Join(words[:len(words)-n], " ")
+				text = strings.Join(words[len(words)-n:], " ")
+			}
+		}
+	}
+	if kind == "" {
+		return line, ""
+	}
+
+	rest := strings.TrimSpace(m[1])
+	wrapped := open != ""
+	if rest != "" && !isChordLine(rest) && !(wrapped && kind == NoteRepeat) {
+		return line, ""
+	}
+
+	// Cut the note from the original line so [ch] tags are kept
+	cut := strings.LastIndex(line, text)
+	if cut < 0 {
+		return line, ""
+	}
+	body = strings.TrimRight(line[:cut], " \t(*")
+	if strings.TrimSpace(chTagRegex.ReplaceAllString(body, "")) == "" {
+		body = ""
+	}
+	return body, text
+}
+
+// isChordLine reports whether a line holds only chords and bar lines
+func isChordLine(line string) bool {
+	if inlineChordOnlyRegex.MatchString(line) {
+		return true
+	}
+	tokens := strings.Fields(strings.ReplaceAll(line, "|", " "))
+	for _, t := range tokens {
+		if !ValidChord(t) {
+			return false
+		}
+	}
+	return len(tokens) > 0
+}
+
+// extractPerformanceNotes moves performance notes out of chart lines onto
+// their own {comment:} lines and returns the rewritten content and the notes.
+// A note on a chord line goes below it, unless lyrics follow the chords, in
+// which case it goes above so chords and lyrics stay together.
+func extractPerformanceNotes(content string) (string, []PerformanceNote) {
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	var notes []PerformanceNote
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "{") {
+			out = append(out, line)
+			continue
+		}
+
+		// "[Chorus x2]" and "[Chorus] x2" label the section and note the repeat
+		if m := sectionNoteRegex.FindStringSubmatch(line); m != nil && performanceNote(m[2]) != "" {
+			note := strings.TrimSpace(m[2])
+			notes = append(notes, PerformanceNote{Line: i + 1, Kind: performanceNote(note), Text: note})
+			out = append(out, "["+strings.TrimSpace(m[1])+"]", commentLine(note))
+			continue
+		}
+
+		body, note := splitTrailingNote(line)
+		if note == "" {
+			out = append(out, line)
+			continue
+		}
+		notes = append(notes, PerformanceNote{Line: i + 1, Kind: performanceNote(note), Text: note})
+
+		switch {
+		case body == "":
+			out = append(out, commentLine(note))
+		case isChordLine(chTagRegex.ReplaceAllString(body, "")) && i+1 < len(lines) && isLyricLine(lines[i+1]):
+			out = append(out, commentLine(note), body)
+		default:
+			out = append(out, body, commentLine(note))
+		}
+	}
+
+	return strings.Join(out, "\n"), notes
+}
+
+// PerformanceNotes lists the performance notes of a chart
+func PerformanceNotes(content string) []PerformanceNote {
+	_, notes := extractPerformanceNotes(content)
+	return notes
+}
+
+// isLyricLine reports whether a line is lyrics rather than chords, a label or blank
+func isLyricLine(line string) bool {
+	plain := strings.TrimSpace(chTagRegex.ReplaceAllString(line, ""))
+	return plain != "" && !strings.HasPrefix(plain, "[") && !strings.HasSuffix(plain, ":") && !isChordLine(plain)
+}
+
+// commentLine writes a note as a ChordPro comment, which OnSong shows too
+func commentLine(note string) string {
+	return "{comment: " + note + "}"
+}
+
+// Strum strokes
+const (
+	StrokeDown = "D"
+	StrokeUp   = "U"
+	StrokeRest = "-"
+)
+
+// StrumPattern is a strumming pattern from Ultimate Guitar's tab metadata
+type StrumPattern struct {
+	Part    string   `json:"part,omitempty"` // Section the pattern is for
+	BPM     int      `json:"bpm,omitempty"`
+	Beat    string   `json:"beat,omitempty"` // Note value of one stroke, e.g. "1/8"
+	Triplet bool     `json:"triplet,omitempty"`
+	Strokes []string `json:"strokes"`
+	Pattern string   `json:"pattern"` // Strokes joined for display, e.g. "D - D U - U D U"
+}
+
+// StrumPatterns converts UG's strumming metadata. Each measure code holds the
+// stroke in its last two digits (1 down, 2 up, anything else a rest) and
+// accents (1) or mutes (2) in the hundreds; accents are marked ">" and muted
+// strokes "x".
+func StrumPatterns(strummings []scraper.Strumming) []StrumPattern {
+	var patterns []StrumPattern
+	for _, s := range strummings {
+		if len(s.Measures) == 0 {
+			continue
+		}
+
+		strokes := make([]string, len(s.Measures))
+		for i, code := range s.Measures {
+			stroke := StrokeRest
+			switch code % 100 {
+			case 1:
+				stroke = StrokeDown
+			case 2:
+				stroke = StrokeUp
+			}
+			if stroke != StrokeRest {
+				switch code / 100 {
+				case 1:
+					stroke += ">"
+				case 2:
+					stroke += "x"
+				}
+			}
+			strokes[i] = stroke
+		}
+
+		pattern := StrumPattern{
+			Part:    strings.TrimSpace(s.Part),
+			BPM:     s.BPM,
+			Triplet: s.Triplet,
+			Strokes: strokes,
+			Pattern: strings.Join(strokes, " "),
+		}
+		if s.Denominator > 0 {
+			pattern.Beat = fmt.Sprintf("1/%d", s.Denominator)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// strumComments writes strumming patterns as {comment:} lines for the top
+// of a chart
+func strumComments(patterns []StrumPattern) string {
+	var b strings.Builder
+	for _, p := range patterns {
+		label := "Strumming"
+		if p.Part != "" {
+			label += " (" + p.Part + ")"
+		}
+
+		var details []string
+		if p.Beat != "" {
+			beat := p.Beat
+			if p.Triplet {
+				beat += " triplets"
+			}
+			details = append(details, beat)
+		}
+		if p.BPM > 0 {
+			details = append(details, fmt.Sprintf("%d bpm", p.BPM))
+		}
+
+		line := label + ": " + p.Pattern
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		b.WriteString(commentLine(line) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package converter
+
+import (
+	"os"
+	"strings"
+)
+
+// Conversion profiles: which instrument the chart is written for
+const (
+	ProfileGuitar = "guitar" // Capo, tuning and chord diagrams as on Ultimate Guitar
+	ProfilePiano  = "piano"  // Sounding chords only, slash chords called out
+)
+
+// ValidProfile reports whether profile is a known conversion profile
+func ValidProfile(profile string) bool {
+	return profile == ProfileGuitar || profile == ProfilePiano
+}
+
+// ProfileFromEnv reads CONVERSION_PROFILE, defaulting to guitar
+func ProfileFromEnv() string {
+	if profile := strings.ToLower(os.Getenv("CONVERSION_PROFILE")); ValidProfile(profile) {
+		return profile
+	}
+	return ProfileGuitar
+}
+
+// BassHintsFromEnv reads the PIANO_BASS_HINTS switch; hints are off unless
+// it is "true"
+func BassHintsFromEnv() bool {
+	return os.Getenv("PIANO_BASS_HINTS") == "true"
+}
+
+// WithProfile returns a converter that writes charts for the given profile.
+// Unknown profiles keep the current choice.
+func (c *OnSongConverter) WithProfile(profile string) *OnSongConverter {
+	if !ValidProfile(profile) {
+		return c
+	}
+
+	conv := *c
+	conv.profile = profile
+	return &conv
+}
+
+// WithBassHints returns a converter that does or does not add left-hand
+// bass notes above the chord lines of piano charts
+func (c *OnSongConverter) WithBassHints(enabled bool) *OnSongConverter {
+	conv := *c
+	conv.bassHints = enabled
+	return &conv
+}
+
+// RemoveCapoFromEnv reads the REMOVE_CAPO switch; capo charts keep their
+// capo unless it is "true"
+func RemoveCapoFromEnv() bool {
+	return os.Getenv("REMOVE_CAPO") == "true"
+}
+
+// WithRemoveCapo returns a converter that does or does not remove the capo
+// of guitar charts: the chords are moved up by the capo to the pitch they
+// sound at and the Capo: line is dropped, for bass players and others who
+// play without one. Piano charts are always written that way.
+func (c *OnSongConverter) WithRemoveCapo(enabled bool) *OnSongConverter {
+	conv := *c
+	conv.removeCapo = enabled
+	return &conv
+}
+
+// sounding reports whether capo charts are written at sounding pitch
+func (c *OnSongConverter) sounding() bool {
+	return c.piano() || c.removeCapo
+}
+
+// piano reports whether charts are written for piano
+func (c *OnSongConverter) piano() bool {
+	return c.profile == ProfilePiano
+}
+
+// soundingChart moves a capo chart's inline chords up to the pitch they
+// sound at, for instruments with no capo to make up the difference
+func soundingChart(content string, capo int) string {
+	if capo <= 0 {
+		return content
+	}
+
+	return inlineChord.ReplaceAllStringFunc(content, func(match string) string {
+		chord := match[1 : len(match)-1]
+		if !chordPartsRegex.MatchString(chord) {
+			return match
+		}
+		return "[" + TransposeChord(chord, capo, false) + "]"
+	})
+}
+
+// soundingChords moves chord names up by the capo
+func soundingChords(chords []string, capo int) []string {
+	if capo <= 0 {
+		return chords
+	}
+
+	moved := make([]string, len(chords))
+	for i, chord := range chords {
+		moved[i] = TransposeChord(chord, capo, false)
+	}
+	return moved
+}
+
+// slashChords returns the distinct chords of a chart that name a bass note
+// other than their root, in order of appearance
+func slashChords(content string) []string {
+	seen := make(map[string]bool)
+	var slash []string
+	for _, m := range inlineChord.FindAllStringSubmatch(content, -1) {
+		parts := chordPartsRegex.FindStringSubmatch(m[1])
+		if parts == nil || parts[3] == "" || NoteIndex(parts[3]) == NoteIndex(parts[1]) || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		slash = append(slash, m[1])
+	}
+	return slash
+}
+
+// bassNote returns the note a pianist's left hand plays under a chord: the
+// slash bass if there is one, otherwise the root
+func bassNote(chord string) string {
+	parts := chordPartsRegex.FindStringSubmatch(chord)
+	if parts == nil {
+		return ""
+	}
+	if parts[3] != "" {
+		return parts[3]
+	}
+	return parts[1]
+}
+
+// addSlashChords calls out a chart's slash chords: they join the chord list,
+// which otherwise leaves them out, and are listed on a {comment} line at the
+// top of the body so the bass line stands out
+func addSlashChords(result *ConversionResult) {
+	header, body, _ := strings.Cut(result.OnSongFormat, "\n\n")
+	slash := slashChords(body)
+	if len(slash) == 0 {
+		return
+	}
+
+	result.Chords = append(result.Chords, slash...)
+	result.OnSongFormat = header + "\n\n" + commentLine("Slash chords: "+strings.Join(slash, ", ")) + "\n" + body
+}
+
+// addBassHints writes the left-hand bass notes of every chord line in a
+// chart's body on a {comment: LH: ...} line right above it
+func addBassHints(result *ConversionResult) {
+	header, body, _ := strings.Cut(result.OnSongFormat, "\n\n")
+	lines := strings.Split(body, "\n")
+	out := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		var notes []string
+		for _, m := range inlineChord.FindAllStringSubmatch(line, -1) {
+			if note := bassNote(m[1]); note != "" {
+				notes = append(notes, note)
+			}
+		}
+		if len(notes) > 0 {
+			out = append(out, commentLine("LH: "+strings.Join(notes, " ")))
+		}
+		out = append(out, line)
+	}
+
+	result.OnSongFormat = header + "\n\n" + strings.Join(out, "\n")
+}
@@ -0,0 +1,200 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// Output formats chosen by ConvertByType
+const (
+	FormatOnSong = "onsong" // Chord chart with inline chords
+	FormatTab    = "tab"    // Tablature kept in monospace tab blocks
+	FormatBinary = "binary" // Guitar Pro file, served as a download
+)
+
+var (
+	// ErrNoTextContent is returned for tab types UG serves without text (video lessons)
+	ErrNoTextContent = errors.New("this tab type has no text content to convert")
+
+	tabBlockRegex = regexp.MustCompile(`(?s)\[tab\](.*?)\[/tab\]`)
+	chTagRegex    = regexp.MustCompile(`\[/?ch\]`)
+	// staffLineRegex matches a line of tablature such as "e|--3--|" or "HH|x-x-|"
+	staffLineRegex = regexp.MustCompile(`^\s*[A-Za-z]{0,2}` + accidental + `?\s*[|:].*-.*-.*-.*[|-]\s*$`)
+)
+
+// ConvertByType routes a tab to the conversion that suits its type: chord
+// charts go through the OnSong converter, ukulele charts additionally get
+// chord diagrams, tablature types keep their tab blocks, and Guitar Pro tabs
+// are reported as binary so callers can offer the file download instead.
+func (c *OnSongConverter) ConvertByType(tab *scraper.TabResult) (*ConversionResult, error) {
+	if tab == nil {
+		return nil, fmt.Errorf("tab cannot be nil")
+	}
+
+	switch tab.Type {
+	case scraper.TypeGuitarPro:
+		return &ConversionResult{Format: FormatBinary}, nil
+	case scraper.TypeVideo:
+		return nil, ErrNoTextContent
+	case scraper.TypeTab, scraper.TypeBass, scraper.TypeDrums, scraper.TypePower:
+		return c.ConvertTablature(tab)
+	case scraper.TypeUkulele:
+		return c.convertUkulele(tab)
+	default:
+		return c.Convert(tab)
+	}
+}
+
+// convertUkulele converts a ukulele chart and adds {define} diagrams for its
+// chords, built-in shapes standing in when the author drew no voicings.
+// Piano charts get no diagrams.
+func (c *OnSongConverter) convertUkulele(tab *scraper.TabResult) (*ConversionResult, error) {
+	result, err := c.Convert(tab)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Diagrams) == 0 && !c.piano() {
+		addDefines(result, UkuleleDiagrams(result.Chords))
+	}
+
+	return result, nil
+}
+
+// ConvertTablature converts tab, bass, drum and power tabs. Tablature is kept
+// character-for-character inside {start_of_tab}/{end_of_tab} blocks so it
+// stays aligned in a monospace font; Monospace holds a plain-text version.
+func (c *OnSongConverter) ConvertTablature(tab *scraper.TabResult) (*ConversionResult, error) {
+	if tab == nil {
+		return nil, fmt.Errorf("tab cannot be nil")
+	}
+
+	chords := c.parser.ExtractChords(tab.Content)
+	shapeKey, confidence := c.shapeKey(tab, chords)
+	soundingKey := SoundingKey(shapeKey, tab.Capo)
+	key := c.headerKey(shapeKey, soundingKey)
+
+	header := strings.Builder{}
+	header.WriteString(tab.SongName + "\n")
+	header.WriteString(tab.ArtistName + "\n")
+	if key != "" {
+		header.WriteString(fmt.Sprintf("Key: %s\n", key))
+	}
+	if tab.Capo > 0 {
+		header.WriteString(fmt.Sprintf("Capo: %d\n", tab.Capo))
+	}
+	if tab.Tuning != "" {
+		header.WriteString(fmt.Sprintf("Tuning: %s\n", tab.Tuning))
+	}
+	header.WriteString("\n")
+
+	blocks := splitTabBlocks(tab.Content)
+
+	onsong := strings.Builder{}
+	plain := strings.Builder{}
+	onsong.WriteString(header.String())
+	plain.WriteString(header.String())
+
+	var tabBlocks []TabBlock
+	for _, b := range blocks {
+		text := NormalizeTypography(strings.Trim(chTagRegex.ReplaceAllString(b.text, ""), "\n"))
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if b.isTab {
+			onsong.WriteString("{start_of_tab}\n" + text + "\n{end_of_tab}\n\n")
+		} else {
+			text = formatSectionHeaders(text, c.normalizeSections)
+			onsong.WriteString(text + "\n\n")
+		}
+		plain.WriteString(text + "\n\n")
+		tabBlocks = append(tabBlocks, TabBlock{Text: text, IsTab: b.isTab})
+	}
+
+	onsong.WriteString(fmt.Sprintf("# Source: Ultimate Guitar (Tab ID: %d)\n", tab.TabID))
+	onsong.WriteString(fmt.Sprintf("# Contributor: %s\n", tab.Contributor.Username))
+	onsong.WriteString(fmt.Sprintf("# Rating: %.1f/5.0 (%d votes)\n", tab.Rating, tab.Votes))
+
+	if key == "" {
+		key = "Unknown"
+	}
+
+	result := &ConversionResult{
+		Format:        FormatTab,
+		OnSongFormat:  onsong.String(),
+		Monospace:     strings.TrimRight(plain.String(), "\n") + "\n",
+		Blocks:        tabBlocks,
+		DetectedKey:   key,
+		ShapeKey:      shapeKey,
+		SoundingKey:   soundingKey,
+		KeyHeader:     c.keyHeader,
+		KeyConfidence: confidence,
+		ChordCount:    len(chords),
+		Chords:        c.getUniqueChords(chords),
+		Profile:       c.profile,
+	}
+	c.respell(result)
+
+	return result, nil
+}
+
+// TabBlock is a block of converted tablature content: a riff kept on a
+// monospace grid, or the text around it
+type TabBlock struct {
+	Text  string
+	IsTab bool
+}
+
+// IsStaffLine reports whether a chart line is a line of tablature
+func IsStaffLine(line string) bool {
+	return staffLineRegex.MatchString(chTagRegex.ReplaceAllString(line, ""))
+}
+
+// textBlock is a run of content that is either tablature or plain text
+type textBlock struct {
+	text  string
+	isTab bool
+}
+
+// splitTabBlocks splits content into tablature and text blocks, using UG's
+// [tab] markers when present and detecting staff lines otherwise
+func splitTabBlocks(content string) []textBlock {
+	if tabBlockRegex.MatchString(content) {
+		var blocks []textBlock
+		last := 0
+		for _, m := range tabBlockRegex.FindAllStringSubmatchIndex(content, -1) {
+			blocks = append(blocks, textBlock{text: content[last:m[0]]})
+			blocks = append(blocks, textBlock{text: content[m[2]:m[3]], isTab: true})
+			last = m[1]
+		}
+		return append(blocks, textBlock{text: content[last:]})
+	}
+
+	var blocks []textBlock
+	var current []string
+	inTab := false
+	flush := func() {
+		if len(current) > 0 {
+			blocks = append(blocks, textBlock{text: strings.Join(current, "\n"), isTab: inTab})
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		isStaff := IsStaffLine(line)
+		// Blank lines end a tab block; other lines join whichever block is open
+		if isStaff != inTab && (isStaff || strings.TrimSpace(line) == "") {
+			flush()
+			inTab = isStaff
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return blocks
+}
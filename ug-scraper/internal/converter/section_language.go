@@ -0,0 +1,267 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SectionLanguageEnglish is the language charts are stored in: localized
+// section headers are mapped to their English names while parsing
+const SectionLanguageEnglish = "en"
+
+// sectionVocabulary lists, per language, the names a section goes by. The
+// first name is the one exports are written with; the rest are only read.
+// Sections missing from a language keep their English name.
+var sectionVocabulary = map[string]map[string][]string{
+	"es": {
+		"Intro":        {"Intro", "Introducción", "Entrada"},
+		"Verse":        {"Estrofa", "Verso"},
+		"Pre-Chorus":   {"Pre-Coro", "Precoro", "Pre-Estribillo", "Preestribillo"},
+		"Chorus":       {"Coro", "Estribillo"},
+		"Bridge":       {"Puente"},
+		"Instrumental": {"Instrumental"},
+		"Interlude":    {"Interludio"},
+		"Solo":         {"Solo"},
+		"Outro":        {"Final", "Salida", "Cierre"},
+	},
+	"pt": {
+		"Intro":        {"Intro", "Introdução"},
+		"Verse":        {"Estrofe"},
+		"Pre-Chorus":   {"Pré-Refrão", "Pre-Refrão", "Pré-Refrao", "Pré-Coro"},
+		"Chorus":       {"Refrão", "Refrao"},
+		"Bridge":       {"Ponte"},
+		"Instrumental": {"Instrumental"},
+		"Interlude":    {"Interlúdio", "Interludio"},
+		"Solo":         {"Solo"},
+		"Outro":        {"Final", "Finalização"},
+	},
+	"de": {
+		"Intro":        {"Intro", "Vorspiel", "Einleitung"},
+		"Verse":        {"Strophe", "Vers"},
+		"Pre-Chorus":   {"Pre-Refrain", "Vorrefrain"},
+		"Chorus":       {"Refrain", "Kehrvers"},
+		"Bridge":       {"Bridge", "Überleitung"},
+		"Instrumental": {"Instrumental"},
+		"Interlude":    {"Zwischenspiel"},
+		"Solo":         {"Solo"},
+		"Outro":        {"Outro", "Nachspiel"},
+		"Ending":       {"Schluss"},
+	},
+	"fr": {
+		"Intro":        {"Intro", "Introduction"},
+		"Verse":        {"Couplet"},
+		"Pre-Chorus":   {"Pré-Refrain", "Pre-Refrain"},
+		"Chorus":       {"Refrain"},
+		"Bridge":       {"Pont"},
+		"Instrumental": {"Instrumental"},
+		"Interlude":    {"Interlude"},
+		"Solo":         {"Solo"},
+		"Outro":        {"Outro"},
+		"Ending":       {"Fin"},
+	},
+	"it": {
+		"Intro":        {"Intro", "Introduzione"},
+		"Verse":        {"Strofa"},
+		"Pre-Chorus":   {"Pre-Ritornello", "Pre-Inciso"},
+		"Chorus":       {"Ritornello", "Inciso"},
+		"Bridge":       {"Ponte"},
+		"Instrumental": {"Strumentale"},
+		"Interlude":    {"Interludio"},
+		"Solo":         {"Assolo"},
+		"Outro":        {"Finale"},
+	},
+	"nl": {
+		"Intro":        {"Intro"},
+		"Verse":        {"Couplet", "Vers"},
+		"Pre-Chorus":   {"Pre-Refrein"},
+		"Chorus":       {"Refrein"},
+		"Bridge":       {"Brug"},
+		"Instrumental": {"Instrumentaal"},
+		"Interlude":    {"Tussenspel"},
+		"Solo":         {"Solo"},
+		"Outro":        {"Outro", "Slot"},
+	},
+	"ru": {
+		"Intro":        {"Вступление", "Интро"},
+		"Verse":        {"Куплет"},
+		"Pre-Chorus":   {"Предприпев"},
+		"Chorus":       {"Припев"},
+		"Bridge":       {"Бридж", "Связка"},
+		"Instrumental": {"Проигрыш"},
+		"Solo":         {"Соло"},
+		"Outro":        {"Концовка", "Кода", "Аутро"},
+	},
+}
+
+// localSectionNames maps lower-cased localized section names to English.
+// Names that are English section names already, such as "Refrain", keep
+// their English meaning.
+var localSectionNames = func() map[string]string {
+	english := regexp.MustCompile(`(?i)^(` + sectionNames + `)$`)
+	names := make(map[string]string)
+	for _, sections := range sectionVocabulary {
+		for canonical, localized := range sections {
+			for _, name := range localized {
+				if !english.MatchString(name) {
+					names[strings.ToLower(name)] = canonical
+				}
+			}
+		}
+	}
+	return names
+}()
+
+// localSectionPattern matches any localized section name, longest first
+// so "Pre-Coro" isn't read as "Coro"
+var localSectionPattern = func() string {
+	names := make([]string, 0, len(localSectionNames))
+	for name := range localSectionNames {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	slices.SortFunc(names, func(a, b string) int { return len(b) - len(a) })
+	return strings.Join(names, "|")
+}()
+
+// localSectionRegex matches a bracketed header line that may be a localized
+// section name, numbered before or after and with an optional colon:
+// "[Estrofa 2]", "[2. Strophe]", "[Coro:]", "[Припев]"
+var localSectionRegex = regexp.MustCompile(`(?m)^\[\s*(?:(\d+)[.ºª]?\s*)?(\p{L}[\p{L}\- ]*?)\s*(\d*)\s*:?\s*\]\s*$`)
+
+// localLabelRegex matches a localized section name written as a label line
+// rather than a bracketed header: "Estribillo:", "2. Strophe:"
+var localLabelRegex = regexp.MustCompile(`(?mi)^[ \t]*(?:(\d+)[.ºª]?[ \t]*)?(` + localSectionPattern + `)[ \t]*(\d*)[ \t]*:[ \t]*$`)
+
+// NormalizeSectionsFromEnv reads the NORMALIZE_SECTIONS switch; localized
+// section headers are mapped to English unless it is "false"
+func NormalizeSectionsFromEnv() bool {
+	return os.Getenv("NORMALIZE_SECTIONS") != "false"
+}
+
+// WithNormalizeSections returns a converter that does or does not map
+// localized section headers such as [Estribillo] to their English section.
+// Either way they become OnSong labels; kept names are written as found.
+func (c *OnSongConverter) WithNormalizeSections(enabled bool) *OnSongConverter {
+	conv := *c
+	conv.normalizeSections = enabled
+	return &conv
+}
+
+// sectionLabelParts splits an OnSong section label into its name and number
+var sectionLabelParts = regexp.MustCompile(`^(.*?)\s*(\d*)$`)
+
+// formatSectionHeaders turns [Section Name] header lines, English or
+// localized, into OnSong "Section Name:" labels. With normalize, localized
+// names, also those already written as labels, are mapped to their English
+// section.
+func formatSectionHeaders(content string, normalize bool) string {
+	content = localSectionRegex.ReplaceAllStringFunc(content, func(line string) string {
+		m := localSectionRegex.FindStringSubmatch(line)
+		label, ok := localSectionLabel(m[1], m[2], m[3], normalize)
+		if !ok {
+			return line
+		}
+		return label + ":"
+	})
+	if normalize {
+		content = localLabelRegex.ReplaceAllStringFunc(content, func(line string) string {
+			m := localLabelRegex.FindStringSubmatch(line)
+			label, _ := localSectionLabel(m[1], m[2], m[3], true)
+			return label + ":"
+		})
+	}
+	return sectionHeaderRegex.ReplaceAllString(content, "$1:")
+}
+
+// localSectionLabel builds the label of a localized header from its name
+// and its number, written before or after it; ok is false when the name
+// isn't a known section
+func localSectionLabel(before, name, after string, normalize bool) (string, bool) {
+	canonical, ok := localSectionNames[strings.ToLower(name)]
+	if !ok {
+		return "", false
+	}
+	if !normalize {
+		canonical = name
+	}
+	if after == "" {
+		after = before
+	}
+	return joinSectionLabel(canonical, after), true
+}
+
+// joinSectionLabel appends a section number to a name when there is one
+func joinSectionLabel(name, number string) string {
+	if number == "" {
+		return name
+	}
+	return name + " " + number
+}
+
+// SectionLanguages lists the languages section names can be exported in
+func SectionLanguages() []string {
+	languages := []string{SectionLanguageEnglish}
+	for lang := range sectionVocabulary {
+		languages = append(languages, lang)
+	}
+	slices.Sort(languages)
+	return languages
+}
+
+// ParseSectionLanguage normalizes a language code such as "pt-BR" to a
+// supported section language; ok is false for languages without names
+func ParseSectionLanguage(raw string) (string, bool) {
+	lang := strings.ToLower(strings.TrimSpace(raw))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if lang == SectionLanguageEnglish {
+		return lang, true
+	}
+	_, ok := sectionVocabulary[lang]
+	return lang, ok
+}
+
+// SectionLanguageFromEnv reads the export language of section names from
+// SECTION_LANGUAGE, defaulting to English
+func SectionLanguageFromEnv() string {
+	raw := os.Getenv("SECTION_LANGUAGE")
+	if raw == "" {
+		return SectionLanguageEnglish
+	}
+	lang, ok := ParseSectionLanguage(raw)
+	if !ok {
+		fmt.Printf("⚠️  Unknown SECTION_LANGUAGE %q, using %s (available: %s)\n",
+			raw, SectionLanguageEnglish, strings.Join(SectionLanguages(), ", "))
+		return SectionLanguageEnglish
+	}
+	return lang
+}
+
+// LocalizeSections renames the English section labels of an OnSong chart
+// into lang, keeping their numbers: "Verse 2:" becomes "Estrofa 2:" in
+// Spanish. Labels without a translation and charts in English are unchanged.
+func LocalizeSections(chart, lang string) string {
+	sections := sectionVocabulary[lang]
+	if sections == nil {
+		return chart
+	}
+
+	lines := strings.Split(chart, "\n")
+	for i, line := range lines {
+		label := onSongSectionLabel(line)
+		if label == "" {
+			continue
+		}
+		m := sectionLabelParts.FindStringSubmatch(label)
+		for canonical, localized := range sections {
+			if strings.EqualFold(m[1], canonical) {
+				lines[i] = joinSectionLabel(localized[0], m[2]) + ":"
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
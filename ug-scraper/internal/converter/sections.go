@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Section labels inserted by AutoSections
+const (
+	sectionIntro        = "Intro"
+	sectionVerse        = "Verse"
+	sectionChorus       = "Chorus"
+	sectionBridge       = "Bridge"
+	sectionInstrumental = "Instrumental"
+	sectionOutro        = "Outro"
+)
+
+// chartBlock is a blank-line separated block of a formatted chart
+type chartBlock struct {
+	text      string
+	chords    string // Chord sequence, the block's harmonic fingerprint
+	lyrics    string // Normalised lyrics without chords
+	hasChords bool
+}
+
+// HasSections reports whether a formatted chart already has section labels,
+// either OnSong "Verse 1:" lines or leftover [Section] lines
+func HasSections(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if onSongSectionLabel(trimmed) != "" {
+			return true
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") &&
+			strings.Count(trimmed, "[") == 1 && !ValidChord(trimmed[1:len(trimmed)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoSections labels the blocks of a formatted chart that has no section
+// markers, so OnSong can navigate it. Lyrics that repeat are the chorus,
+// blocks without lyrics are the intro, outro or instrumentals, a one-off
+// chord pattern after the first chorus is the bridge and the remaining
+// lyric blocks are numbered verses. Charts with labels are left unchanged.
+func AutoSections(content string) string {
+	if HasSections(content) {
+		return content
+	}
+
+	var blocks []chartBlock
+	for _, text := range strings.Split(content, "\n\n") {
+		if strings.TrimSpace(text) != "" {
+			blocks = append(blocks, parseChartBlock(text))
+		}
+	}
+	if len(blocks) < 2 {
+		return content
+	}
+
+	lyricCounts := make(map[string]int)
+	patternCounts := make(map[string]int)
+	for _, b := range blocks {
+		if b.lyrics != "" {
+			lyricCounts[b.lyrics]++
+		}
+	}
+	for _, b := range blocks {
+		if b.lyrics != "" && lyricCounts[b.lyrics] == 1 {
+			patternCounts[b.chords]++
+		}
+	}
+
+	labels := make([]string, len(blocks))
+	seenChorus := false
+	for i, b := range blocks {
+		switch {
+		case b.lyrics == "" && !b.hasChords:
+			// Nothing to go on, e.g. a comment block
+		case b.lyrics == "" && i == 0:
+			labels[i] = sectionIntro
+		case b.lyrics == "" && i == len(blocks)-1:
+			labels[i] = sectionOutro
+		case b.lyrics == "":
+			labels[i] = sectionInstrumental
+		case lyricCounts[b.lyrics] > 1:
+			labels[i] = sectionChorus
+			seenChorus = true
+		case seenChorus && patternCounts[b.chords] == 1 && b.hasChords:
+			labels[i] = sectionBridge
+		default:
+			labels[i] = sectionVerse
+		}
+	}
+
+	verses := 0
+	for _, label := range labels {
+		if label == sectionVerse {
+			verses++
+		}
+	}
+
+	out := make([]string, len(blocks))
+	verse := 0
+	for i, b := range blocks {
+		label := labels[i]
+		// A chorus written as several stanzas gets one label
+		if label == "" || (i > 0 && label == labels[i-1] && label != sectionVerse) {
+			out[i] = b.text
+			continue
+		}
+		if label == sectionVerse && verses > 1 {
+			verse++
+			label = fmt.Sprintf("%s %d", sectionVerse, verse)
+		}
+		out[i] = label + ":\n" + b.text
+	}
+
+	return strings.Join(out, "\n\n")
+}
+
+// parseChartBlock splits a block into its chord sequence and lyrics
+func parseChartBlock(text string) chartBlock {
+	var chords []string
+	for _, m := range inlineChordRegex.FindAllStringSubmatch(text, -1) {
+		chords = append(chords, m[1])
+	}
+
+	// Directives such as {comment: x2} are neither chords nor lyrics
+	var plain []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			plain = append(plain, line)
+		}
+	}
+	lyrics := strings.Fields(strings.ToLower(inlineChordRegex.ReplaceAllString(strings.Join(plain, "\n"), "")))
+	hasLetters := strings.ContainsFunc(strings.Join(lyrics, ""), unicode.IsLetter)
+	block := chartBlock{
+		text:      text,
+		chords:    strings.Join(chords, " "),
+		hasChords: len(chords) > 0,
+	}
+	if hasLetters {
+		block.lyrics = strings.Join(lyrics, " ")
+	}
+	return block
+}
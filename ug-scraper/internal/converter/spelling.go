@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"os"
+	"strings"
+)
+
+// Enharmonic spelling preferences for chord names
+const (
+	SpellingAuto   = "auto"   // Follow the key: D# becomes Eb in a song in Eb
+	SpellingSharps = "sharps" // Always C#, D#, F#, G#, A#
+	SpellingFlats  = "flats"  // Always Db, Eb, Gb, Ab, Bb
+)
+
+var (
+	majorScale = []int{0, 2, 4, 5, 7, 9, 11}
+	minorScale = []int{0, 2, 3, 5, 7, 8, 10}
+)
+
+// ValidSpelling reports whether spelling is a known preference
+func ValidSpelling(spelling string) bool {
+	return spelling == SpellingAuto || spelling == SpellingSharps || spelling == SpellingFlats
+}
+
+// SpellingFromEnv reads the global preference from CHORD_SPELLING, defaulting
+// to auto
+func SpellingFromEnv() string {
+	if spelling := strings.ToLower(os.Getenv("CHORD_SPELLING")); ValidSpelling(spelling) {
+		return spelling
+	}
+	return SpellingAuto
+}
+
+// PreferFlats reports whether chords transposed into key should be spelled
+// with flats under the given preference
+func PreferFlats(spelling, key string) bool {
+	switch spelling {
+	case SpellingSharps:
+		return false
+	case SpellingFlats:
+		return true
+	default:
+		return UsesFlats(key)
+	}
+}
+
+// RespellChord rewrites a chord's root and bass note with the preferred
+// accidentals without changing its pitch. In auto mode a note is only
+// respelled when the other spelling belongs to the key's scale, so D# turns
+// into Eb in Eb major while a borrowed Bb in G major is left alone. ♯ and ♭
+// are spelled # and b.
+func RespellChord(chord, spelling, key string) string {
+	chord = NormalizeAccidentals(chord)
+	switch spelling {
+	case SpellingSharps:
+		return TransposeChord(chord, 0, false)
+	case SpellingFlats:
+		return TransposeChord(chord, 0, true)
+	}
+
+	names := scaleSpelling(key)
+	if names == nil {
+		return chord
+	}
+
+	parts := chordPartsRegex.FindStringSubmatch(chord)
+	if parts == nil {
+		return chord
+	}
+
+	result := respellNote(parts[1], names) + parts[2]
+	if parts[3] != "" {
+		result += "/" + respellNote(parts[3], names)
+	}
+	return result
+}
+
+// RespellOnSong respells every inline [chord] and the Key: header of an
+// OnSong chart. Auto mode follows the chart's Key: header and leaves charts
+// without one unchanged.
+func RespellOnSong(content, spelling string) string {
+	key := ""
+	if m := keyHeaderRegex.FindStringSubmatch(content); m != nil {
+		key = m[1]
+	}
+	return respellChart(content, spelling, key)
+}
+
+// respellChart respells a chart's chords against key, which may differ from
+// its Key: header when the header shows the sounding key of a capo chart
+func respellChart(content, spelling, key string) string {
+	if spelling == SpellingAuto && key == "" {
+		return content
+	}
+
+	content = inlineChord.ReplaceAllStringFunc(content, func(match string) string {
+		chord := match[1 : len(match)-1]
+		return "[" + RespellChord(chord, spelling, key) + "]"
+	})
+
+	content = keyHeaderRegex.ReplaceAllStringFunc(content, func(line string) string {
+		header := strings.TrimSpace(strings.TrimPrefix(line, "Key:"))
+		return "Key: " + RespellChord(header, spelling, header)
+	})
+
+	return content
+}
+
+// scaleSpelling maps the pitch classes of a key's scale to their names in
+// that key, or returns nil for an unknown key
+func scaleSpelling(key string) map[int]string {
+	root := NoteIndex(extractRootNote(key))
+	if root < 0 {
+		return nil
+	}
+
+	scale := majorScale
+	if IsMinorKey(key) {
+		scale = minorScale
+	}
+	notes := sharpNotes
+	if UsesFlats(key) {
+		notes = flatNotes
+	}
+
+	names := make(map[int]string, len(scale))
+	for _, step := range scale {
+		pc := (root + step) % 12
+		names[pc] = notes[pc]
+	}
+	return names
+}
+
+// respellNote returns the scale's name for note when it has one
+func respellNote(note string, names map[int]string) string {
+	if name, ok := names[NoteIndex(note)]; ok {
+		return name
+	}
+	return note
+}
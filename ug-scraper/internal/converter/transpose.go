@@ -0,0 +1,179 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	sharpNotes = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNotes  = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+	noteIndex = map[string]int{
+		"C": 0, "B#": 0,
+		"C#": 1, "Db": 1,
+		"D":  2,
+		"D#": 3, "Eb": 3,
+		"E": 4, "Fb": 4,
+		"F": 5, "E#": 5,
+		"F#": 6, "Gb": 6,
+		"G":  7,
+		"G#": 8, "Ab": 8,
+		"A":  9,
+		"A#": 10, "Bb": 10,
+		"B": 11, "Cb": 11,
+	}
+
+	// Pitch classes of keys conventionally written with flats (F, Bb, Eb, Ab, Db
+	// major and Dm, Gm, Cm, Fm, Bbm, Ebm minor)
+	majorFlatKeys = map[int]bool{5: true, 10: true, 3: true, 8: true, 1: true}
+	minorFlatKeys = map[int]bool{2: true, 7: true, 0: true, 5: true, 10: true, 3: true}
+
+	chordPartsRegex = regexp.MustCompile(`^([A-G]` + accidental + `?)([^/]*)(?:/([A-G]` + accidental + `?))?$`)
+	inlineChord     = regexp.MustCompile(`\[([^\]\s]+)\]`)
+	keyHeaderRegex  = regexp.MustCompile(`(?m)^Key: *(\S+) *$`)
+)
+
+// NoteIndex returns the pitch class (0-11) of a note name, or -1 if unknown
+func NoteIndex(note string) int {
+	if idx, ok := noteIndex[NormalizeAccidentals(note)]; ok {
+		return idx
+	}
+	return -1
+}
+
+// UsesFlats reports whether a key is conventionally spelled with flats
+func UsesFlats(key string) bool {
+	idx := NoteIndex(extractRootNote(key))
+	if idx < 0 {
+		return false
+	}
+	if IsMinorKey(key) {
+		return minorFlatKeys[idx]
+	}
+	return majorFlatKeys[idx]
+}
+
+// IsMinorKey reports whether a key or chord name is minor (e.g. "Am", "C#min")
+func IsMinorKey(key string) bool {
+	quality := strings.TrimPrefix(key, extractRootNote(key))
+	return strings.HasPrefix(quality, "m") && !strings.HasPrefix(quality, "maj")
+}
+
+// SemitonesBetween returns the upward distance in semitones from one key to
+// another (0-11), or 0 when either key cannot be parsed
+func SemitonesBetween(from, to string) int {
+	fromRoot := NoteIndex(extractRootNote(from))
+	toRoot := NoteIndex(extractRootNote(to))
+	if fromRoot < 0 || toRoot < 0 {
+		return 0
+	}
+	return ((toRoot-fromRoot)%12 + 12) % 12
+}
+
+// TransposeNote shifts a single note name by the given number of semitones
+func TransposeNote(note string, semitones int, preferFlats bool) string {
+	idx := NoteIndex(note)
+	if idx < 0 {
+		return note
+	}
+
+	idx = ((idx+semitones)%12 + 12) % 12
+	if preferFlats {
+		return flatNotes[idx]
+	}
+	return sharpNotes[idx]
+}
+
+// TransposeChord shifts a chord (including any slash bass note) by the given
+// number of semitones, spelling ♯ and ♭ as # and b. Unrecognized chords are
+// returned unchanged.
+func TransposeChord(chord string, semitones int, preferFlats bool) string {
+	parts := chordPartsRegex.FindStringSubmatch(NormalizeAccidentals(chord))
+	if parts == nil {
+		return chord
+	}
+
+	result := TransposeNote(parts[1], semitones, preferFlats) + parts[2]
+	if parts[3] != "" {
+		result += "/" + TransposeNote(parts[3], semitones, preferFlats)
+	}
+
+	return result
+}
+
+// SplitChord splits a chord name into its root, its quality (everything up
+// to a slash bass, such as "m7" or "sus4") and its slash bass, spelling ♯ and
+// ♭ as # and b
+func SplitChord(chord string) (root, quality, bass string, ok bool) {
+	parts := chordPartsRegex.FindStringSubmatch(NormalizeAccidentals(chord))
+	if parts == nil {
+		return "", "", "", false
+	}
+	return parts[1], parts[2], parts[3], true
+}
+
+// keyNameRegex matches a key name such as "G", "f#", "B♭m" or "A minor"
+var keyNameRegex = regexp.MustCompile(`^([A-Ga-g])(` + accidental + `?)\s*(m|min|minor|Min|Minor|-|M|maj|major|Maj|Major)?$`)
+
+// ParseKey reads a key name given by a user, such as "f#", "Bb", "B♭m" or
+// "A minor", and returns it as a chart writes it: "F#", "Bb", "Bbm", "Am"
+func ParseKey(raw string) (string, bool) {
+	m := keyNameRegex.FindStringSubmatch(strings.TrimSpace(raw))
+	if m == nil {
+		return "", false
+	}
+	key := strings.ToUpper(m[1]) + NormalizeAccidentals(m[2])
+	if NoteIndex(key) < 0 {
+		return "", false
+	}
+	switch m[3] {
+	case "m", "min", "minor", "Min", "Minor", "-":
+		key += "m"
+	}
+	return key, true
+}
+
+// TransposeKey shifts a key name such as "Am" or "Eb" by the given number of semitones
+func TransposeKey(key string, semitones int, preferFlats bool) string {
+	return TransposeChord(key, semitones, preferFlats)
+}
+
+// SoundingKey returns the key a chart sounds in when its shapes are played
+// with a capo on the given fret. Unknown keys and capo 0 are returned as is.
+func SoundingKey(shapeKey string, capo int) string {
+	if capo <= 0 || NoteIndex(extractRootNote(shapeKey)) < 0 {
+		return shapeKey
+	}
+	return TransposeKey(shapeKey, capo, UsesFlats(TransposeKey(shapeKey, capo, false)))
+}
+
+// TransposeOnSong shifts every inline [chord] and the Key: header of an
+// OnSong chart. Spelling follows the resulting key when preferFlats is nil.
+func TransposeOnSong(content string, semitones int, preferFlats *bool) string {
+	if semitones%12 == 0 {
+		return content
+	}
+
+	flats := false
+	if preferFlats != nil {
+		flats = *preferFlats
+	} else if m := keyHeaderRegex.FindStringSubmatch(content); m != nil {
+		flats = UsesFlats(TransposeKey(m[1], semitones, false))
+	}
+
+	content = inlineChord.ReplaceAllStringFunc(content, func(match string) string {
+		chord := match[1 : len(match)-1]
+		if !chordPartsRegex.MatchString(chord) {
+			return match
+		}
+		return "[" + TransposeChord(chord, semitones, flats) + "]"
+	})
+
+	content = keyHeaderRegex.ReplaceAllStringFunc(content, func(line string) string {
+		key := strings.TrimSpace(strings.TrimPrefix(line, "Key:"))
+		return "Key: " + TransposeKey(key, semitones, flats)
+	})
+
+	return content
+}
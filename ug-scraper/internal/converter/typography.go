@@ -0,0 +1,32 @@
+package converter
+
+import "strings"
+
+// accidental is the regex class of an accidental after a note name. ♯ and ♭
+// are read as # and b, as charts pasted from word processors and PDFs use
+// them.
+const accidental = `[#b♯♭]`
+
+// accidentalReplacer spells ♯ and ♭ in ASCII
+var accidentalReplacer = strings.NewReplacer("♯", "#", "♭", "b")
+
+// typographyReplacer maps the typographic characters charts are pasted with
+// to the ASCII OnSong and the chord grammar expect: ♯ and ♭ in chords,
+// curly quotes and long dashes in lyrics
+var typographyReplacer = strings.NewReplacer(
+	"♯", "#", "♭", "b",
+	"‘", "'", "’", "'", "‚", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "″", `"`,
+	"–", "-", "—", "-", "‒", "-", "―", "-", "−", "-",
+)
+
+// NormalizeAccidentals spells the ♯ and ♭ of a chord or note name as # and b
+func NormalizeAccidentals(chord string) string {
+	return accidentalReplacer.Replace(chord)
+}
+
+// NormalizeTypography rewrites chart content in ASCII: ♯ and ♭ become # and
+// b, curly quotes straight quotes and en, em and minus dashes hyphens
+func NormalizeTypography(content string) string {
+	return typographyReplacer.Replace(content)
+}
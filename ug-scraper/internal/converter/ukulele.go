@@ -0,0 +1,97 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChordDiagram is a fingering for one chord, frets listed from the lowest
+// string in the instrument's tuning (G C E A for ukulele). Frets are
+// absolute; -1 is a muted string.
+type ChordDiagram struct {
+	Chord    string `json:"chord"`
+	Frets    []int  `json:"frets"`
+	Fingers  []int  `json:"fingers,omitempty"`
+	BaseFret int    `json:"base_fret,omitempty"` // Fret the diagram starts at; 0 means 1
+	Source   string `json:"source,omitempty"`    // DiagramAuthor or DiagramBuiltIn
+}
+
+// Define renders the diagram as an OnSong/ChordPro {define} directive, whose
+// frets count from the base fret
+func (d ChordDiagram) Define() string {
+	base := max(d.BaseFret, 1)
+	frets := make([]string, len(d.Frets))
+	for i, f := range d.Frets {
+		switch {
+		case f < 0:
+			frets[i] = "x"
+		case f == 0:
+			frets[i] = "0"
+		default:
+			frets[i] = fmt.Sprintf("%d", f-base+1)
+		}
+	}
+
+	define := fmt.Sprintf("{define: %s base-fret %d frets %s", d.Chord, base, strings.Join(frets, " "))
+	if len(d.Fingers) == len(d.Frets) {
+		fingers := make([]string, len(d.Fingers))
+		for i, f := range d.Fingers {
+			fingers[i] = fmt.Sprintf("%d", f)
+		}
+		define += " fingers " + strings.Join(fingers, " ")
+	}
+	return define + "}"
+}
+
+// ukuleleShapes holds common open-position GCEA fingerings by chord quality
+// and root pitch class (0 = C)
+var ukuleleShapes = map[string][12]string{
+	"":     {"0003", "1114", "2220", "0331", "4442", "2010", "3121", "0232", "5343", "2100", "3211", "4322"},
+	"m":    {"0333", "1104", "2210", "3321", "0432", "1013", "2120", "0231", "4342", "2000", "3111", "4222"},
+	"7":    {"0001", "1112", "2223", "3334", "1202", "2313", "3424", "0212", "1323", "0100", "1211", "2322"},
+	"m7":   {"3333", "1102", "2213", "3324", "0202", "1313", "2424", "0211", "1322", "0000", "1111", "2222"},
+	"maj7": {"0002", "1113", "2224", "3335", "1302", "2413", "3524", "0222", "1333", "1100", "3210", "4321"},
+}
+
+// ukuleleQualityAliases maps chord suffixes to the qualities in ukuleleShapes
+var ukuleleQualityAliases = map[string]string{
+	"": "", "maj": "",
+	"m": "m", "min": "m", "-": "m",
+	"7":  "7",
+	"m7": "m7", "min7": "m7", "-7": "m7",
+	"maj7": "maj7", "M7": "maj7",
+}
+
+// UkuleleDiagram returns the ukulele fingering for a chord. Slash chords use
+// the shape of the upper chord; unsupported qualities return false.
+func UkuleleDiagram(chord string) (ChordDiagram, bool) {
+	parts := chordPartsRegex.FindStringSubmatch(chord)
+	if parts == nil {
+		return ChordDiagram{}, false
+	}
+
+	root := NoteIndex(parts[1])
+	quality, ok := ukuleleQualityAliases[parts[2]]
+	if root < 0 || !ok {
+		return ChordDiagram{}, false
+	}
+
+	shape := ukuleleShapes[quality][root]
+	frets := make([]int, len(shape))
+	for i, r := range shape {
+		frets[i] = int(r - '0')
+	}
+
+	return ChordDiagram{Chord: chord, Frets: frets, Source: DiagramBuiltIn}, true
+}
+
+// UkuleleDiagrams returns fingerings for every chord that has a known shape
+func UkuleleDiagrams(chords []string) []ChordDiagram {
+	diagrams := make([]ChordDiagram, 0, len(chords))
+	for _, chord := range chords {
+		if d, ok := UkuleleDiagram(chord); ok {
+			diagrams = append(diagrams, d)
+		}
+	}
+	return diagrams
+}
@@ -0,0 +1,143 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of chord warnings
+const (
+	WarningInvalidChord = "invalid_chord" // Marked as a chord but not a chord name
+	WarningMissedChord  = "missed_chord"  // Looks like a chord but was left as lyrics
+)
+
+// ChordWarning points at a chord problem in the source content, so it can be
+// fixed before the chart is sent to OnSong
+type ChordWarning struct {
+	Line    int    `json:"line"` // 1-based line in the source content
+	Chord   string `json:"chord"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+var (
+	// validChordRegex is the chord grammar: a root, any run of qualities,
+	// extensions and alterations (m7b5, sus4, add9, 6/9, (#11)...) and an
+	// optional slash bass. ♯ and ♭ count as # and b.
+	validChordRegex = regexp.MustCompile(`^[A-G]` + accidental + `?(?:maj|min|dim|aug|sus|add|no|omit|alt|m|M|6/9|[0-9]|[#b♯♭+°ø()−-])*(?:/[A-G]` + accidental + `?)?$`)
+
+	// chTagContentRegex matches a UG [ch]...[/ch] chord tag
+	chTagContentRegex = regexp.MustCompile(`\[ch\](.*?)\[/ch\]`)
+
+	// inlineBracketRegex matches an inline [chord] in OnSong content
+	inlineBracketRegex = regexp.MustCompile(`\[([^\]\n]*)\]`)
+
+	// notChordTags are bracketed UG markup that is not a chord
+	notChordTags = map[string]bool{"ch": true, "/ch": true, "tab": true, "/tab": true}
+)
+
+// ValidChord reports whether chord parses as a chord name
+func ValidChord(chord string) bool {
+	return validChordRegex.MatchString(chord)
+}
+
+// ValidateChords checks the chords of source content, either UG's [ch]
+// tagged format or plain/OnSong text. It flags marked chords that are not
+// chord names and chord-only lines the converter leaves unmarked.
+func ValidateChords(content string) []ChordWarning {
+	warnings := []ChordWarning{}
+	tagged := strings.Contains(content, "[ch]")
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+
+		if tagged {
+			for _, m := range chTagContentRegex.FindAllStringSubmatch(line, -1) {
+				if !ValidChord(m[1]) {
+					warnings = append(warnings, invalidChord(lineNo, m[1]))
+				}
+			}
+		} else {
+			for _, m := range inlineBracketRegex.FindAllStringSubmatch(line, -1) {
+				if notChordTags[m[1]] || strings.TrimSpace(line) == m[0] {
+					continue // Tags and whole-line section labels
+				}
+				if !ValidChord(m[1]) {
+					warnings = append(warnings, invalidChord(lineNo, m[1]))
+				}
+			}
+		}
+
+		warnings = append(warnings, missedChords(lineNo, line, tagged)...)
+	}
+
+	return warnings
+}
+
+// invalidChord builds a warning for a marked chord that does not parse
+func invalidChord(line int, chord string) ChordWarning {
+	return ChordWarning{
+		Line:    line,
+		Chord:   chord,
+		Kind:    WarningInvalidChord,
+		Message: fmt.Sprintf("%q is marked as a chord but is not a valid chord name", chord),
+	}
+}
+
+// missedChords finds chord names the converter will not mark on a line. In
+// tagged content that is a chord-only line without tags; in plain content it
+// is a mostly-chord line whose other tokens stop it being recognised.
+func missedChords(lineNo int, line string, tagged bool) []ChordWarning {
+	// A trailing "x2" or "let ring" is moved off the line during conversion
+	if body, note := splitTrailingNote(line); note != "" {
+		line = body
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.Contains(trimmed, "[") || strings.HasSuffix(trimmed, ":") {
+		return nil
+	}
+
+	tokens := strings.Fields(trimmed)
+	var chords, others []string
+	for _, t := range tokens {
+		if ValidChord(t) {
+			chords = append(chords, t)
+		} else {
+			others = append(others, t)
+		}
+	}
+
+	// A lone "A" or a lyric line with a stray capital is not a chord line
+	if len(chords) == 0 || len(chords) < 2*len(others) || (len(chords) == 1 && len(others) == 0 && len(chords[0]) == 1) {
+		return nil
+	}
+
+	reason := "will be left as lyrics: its line has no [ch] tags"
+	if !tagged {
+		// Plain lines are only wrapped when every token is a chord the
+		// converter recognises
+		var unrecognised []string
+		for _, t := range tokens {
+			if !chordTokenRegex.MatchString(t) {
+				unrecognised = append(unrecognised, t)
+			}
+		}
+		if len(unrecognised) == 0 {
+			return nil
+		}
+		reason = fmt.Sprintf("will be left as lyrics: its line contains %q, which the converter does not read as a chord", strings.Join(unrecognised, " "))
+	}
+
+	warnings := make([]ChordWarning, len(chords))
+	for i, chord := range chords {
+		warnings[i] = ChordWarning{
+			Line:    lineNo,
+			Chord:   chord,
+			Kind:    WarningMissedChord,
+			Message: fmt.Sprintf("%q %s", chord, reason),
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,149 @@
+package dropbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	uploadURL     = "https://content.dropboxapi.com/2/files/upload"
+	defaultFolder = "/Apps/OnSong"
+)
+
+// Config holds the Dropbox delivery target settings
+type Config struct {
+	Token  string // OAuth access token with files.content.write scope
+	Folder string // Folder OnSong syncs from, e.g. /Apps/OnSong
+}
+
+// ConfigFromEnv reads DROPBOX_TOKEN and DROPBOX_FOLDER. An empty Token means
+// Dropbox delivery is disabled.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Token:  os.Getenv("DROPBOX_TOKEN"),
+		Folder: os.Getenv("DROPBOX_FOLDER"),
+	}
+
+	if cfg.Folder == "" {
+		cfg.Folder = defaultFolder
+	}
+	cfg.Folder = "/" + strings.Trim(cfg.Folder, "/")
+
+	return cfg
+}
+
+// Client uploads converted songs to a Dropbox folder
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// UploadResult describes a file stored in Dropbox
+type UploadResult struct {
+	Path           string `json:"path"`
+	Size           int    `json:"size"`
+	Rev            string `json:"rev"`
+	ContentHash    string `json:"content_hash"`
+	ServerModified string `json:"server_modified"`
+}
+
+// NewClient creates a new Dropbox client
+func NewClient(cfg Config) *Client {
+	return &Client{
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Enabled reports whether a Dropbox token is configured
+func (c *Client) Enabled() bool {
+	return c.config.Token != ""
+}
+
+// Folder returns the Dropbox folder songs are uploaded to
+func (c *Client) Folder() string {
+	return c.config.Folder
+}
+
+// Upload stores content as filename in the configured folder, overwriting
+// any previous version so OnSong picks up the change on its next sync
+func (c *Client) Upload(filename, content string) (*UploadResult, error) {
+	if !c.Enabled() {
+		return nil, fmt.Errorf("dropbox token not configured")
+	}
+
+	arg, err := json.Marshal(map[string]interface{}{
+		"path":       path.Join(c.config.Folder, filename),
+		"mode":       "overwrite",
+		"autorename": false,
+		"mute":       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding upload arguments: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", uploadURL, strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Dropbox-API-Arg", asciiJSON(string(arg)))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("uploading to dropbox: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dropbox returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		PathDisplay    string `json:"path_display"`
+		Size           int    `json:"size"`
+		Rev            string `json:"rev"`
+		ContentHash    string `json:"content_hash"`
+		ServerModified string `json:"server_modified"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parsing dropbox response: %w", err)
+	}
+
+	return &UploadResult{
+		Path:           result.PathDisplay,
+		Size:           result.Size,
+		Rev:            result.Rev,
+		ContentHash:    result.ContentHash,
+		ServerModified: result.ServerModified,
+	}, nil
+}
+
+// asciiJSON escapes non-ASCII characters, which Dropbox rejects in the
+// Dropbox-API-Arg header, as \uXXXX sequences
+func asciiJSON(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case r > 0xFFFF:
+			// Characters outside the BMP are written as a UTF-16 surrogate pair
+			r -= 0x10000
+			fmt.Fprintf(&b, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
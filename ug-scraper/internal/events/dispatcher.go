@@ -0,0 +1,45 @@
+package events
+
+// Event names published by the add-on
+const (
+	TabConverted    = "tab_converted"
+	WebhookSent     = "webhook_delivered"
+	WebhookFailed   = "webhook_failed"
+	SongPublished   = "song_published"
+	ImportCompleted = "import_completed"
+	ScrapeFailed    = "scrape_failed"
+	TabUpdateFound  = "tab_update_found"
+	WatchNewTabs    = "watchlist_new_tabs"
+	// GigChartChanged is a tab update found for a song in an upcoming setlist
+	GigChartChanged = "gig_chart_changed"
+)
+
+// Publisher receives add-on events. Implementations must be safe for
+// concurrent use and should treat delivery as best-effort.
+type Publisher interface {
+	PublishEvent(name string, data interface{})
+}
+
+// Dispatcher fans events out to every registered publisher
+type Dispatcher struct {
+	publishers []Publisher
+}
+
+// NewDispatcher creates a dispatcher for the given publishers
+func NewDispatcher(publishers ...Publisher) *Dispatcher {
+	return &Dispatcher{
+		publishers: publishers,
+	}
+}
+
+// PublishEvent sends an event to every publisher in the background so slow
+// integrations never hold up an API response
+func (d *Dispatcher) PublishEvent(name string, data interface{}) {
+	if d == nil {
+		return
+	}
+
+	for _, p := range d.publishers {
+		go p.PublishEvent(name, data)
+	}
+}
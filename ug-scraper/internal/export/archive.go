@@ -0,0 +1,93 @@
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// Archive formats
+const (
+	ArchiveOnSong   = "onsong"
+	ArchiveChordPro = "chordpro"
+)
+
+// ValidArchiveFormat reports whether format is a supported archive format
+func ValidArchiveFormat(format string) bool {
+	return format == ArchiveOnSong || format == ArchiveChordPro
+}
+
+// ArchiveFile is one file of a library archive
+type ArchiveFile struct {
+	Name    string
+	Song    *library.Song
+	Content string
+}
+
+// LibraryArchiveFiles renders every song as its own archive file, ChordPro
+// files with the given options and section names in the given language. Files are named "Artist - Title.<format>"; clashing names get a
+// numeric suffix.
+func LibraryArchiveFiles(songs []library.Song, format string, chordPro ChordProOptions, language string) ([]ArchiveFile, error) {
+	if !ValidArchiveFormat(format) {
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+
+	files := make([]ArchiveFile, 0, len(songs))
+	used := make(map[string]int)
+
+	for i := range songs {
+		song := &songs[i]
+		name := SanitizeFilename(song.Title)
+		if song.Artist != "" {
+			name = SanitizeFilename(song.Artist + " - " + song.Title)
+		}
+
+		// Zip entry names are compared case-insensitively by most unpackers
+		key := strings.ToLower(name)
+		used[key]++
+		if n := used[key]; n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+
+		content := converter.LocalizeSections(SongDocument(song), language)
+		if format == ArchiveChordPro {
+			content = OnSongToChordPro(content, chordPro)
+		}
+
+		files = append(files, ArchiveFile{
+			Name:    name + "." + format,
+			Song:    song,
+			Content: content,
+		})
+	}
+
+	return files, nil
+}
+
+// WriteArchive writes prepared files into a zip archive
+func WriteArchive(w io.Writer, files []ArchiveFile) error {
+	zw := zip.NewWriter(w)
+
+	for _, file := range files {
+		header := &zip.FileHeader{
+			Name:   file.Name,
+			Method: zip.Deflate,
+		}
+		if file.Song != nil {
+			header.Modified = file.Song.UpdatedAt
+		}
+		f, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("adding %s: %w", header.Name, err)
+		}
+		if _, err := io.WriteString(f, file.Content); err != nil {
+			return fmt.Errorf("writing %s: %w", header.Name, err)
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,211 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+var (
+	// onSongHeaderRegex matches OnSong metadata lines such as "Key: G" or "Capo: 2"
+	onSongHeaderRegex = regexp.MustCompile(`^(Key|Capo|Tuning|Tempo|Time): *(.+)$`)
+	// onSongSectionRegex matches OnSong section labels such as "Verse 1:"
+	onSongSectionRegex = regexp.MustCompile(`^(\p{Lu}[\p{L}\- ]*\d*):$`)
+	// directiveRegex matches a ChordPro directive line such as "{title: Song}"
+	directiveRegex = regexp.MustCompile(`^\{(\w+)(:.*)?\}$`)
+)
+
+// ChordPro directive styles
+const (
+	DirectivesLong  = "long"  // {title: ...}, {comment: ...}
+	DirectivesShort = "short" // {t: ...}, {c: ...}
+)
+
+// ChordProOptions are how OnSong charts are written as ChordPro
+type ChordProOptions struct {
+	Directives string // DirectivesLong or DirectivesShort
+	// Environments wraps choruses, verses, bridges and tablature in
+	// {start_of_*}/{end_of_*} blocks instead of {comment:} labels
+	Environments bool
+}
+
+// shortDirectives are the abbreviations ChordPro defines for long directives
+var shortDirectives = map[string]string{
+	"title":           "t",
+	"subtitle":        "st",
+	"comment":         "c",
+	"comment_italic":  "ci",
+	"comment_box":     "cb",
+	"start_of_chorus": "soc",
+	"end_of_chorus":   "eoc",
+	"start_of_verse":  "sov",
+	"end_of_verse":    "eov",
+	"start_of_bridge": "sob",
+	"end_of_bridge":   "eob",
+	"start_of_tab":    "sot",
+	"end_of_tab":      "eot",
+}
+
+// ValidDirectiveStyle reports whether style is a known directive style
+func ValidDirectiveStyle(style string) bool {
+	return style == DirectivesLong || style == DirectivesShort
+}
+
+// SongDocument returns the OnSong text of a song, building a minimal header
+// for hand-entered songs that were stored without one
+func SongDocument(song *library.Song) string {
+	if song.OnSongFormat != "" {
+		return song.OnSongFormat
+	}
+
+	header := song.Title + "\n" + song.Artist + "\n"
+	if song.Key != "" {
+		header += "Key: " + song.Key + "\n"
+	}
+	return header + "\n" + song.Content
+}
+
+// OnSongToChordPro rewrites an OnSong document using ChordPro directives,
+// abbreviated where ChordPro allows it with DirectivesShort. Inline [chords]
+// are shared by both formats and pass through unchanged.
+func OnSongToChordPro(onsong string, opts ChordProOptions) string {
+	lines := strings.Split(strings.ReplaceAll(onsong, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines)+2)
+
+	// The header block runs until the first blank line: title, artist, then metadata
+	i := 0
+	for n := 0; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i, n = i+1, n+1 {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(line, "{"):
+			out = append(out, line)
+		case onSongHeaderRegex.MatchString(line):
+			m := onSongHeaderRegex.FindStringSubmatch(line)
+			out = append(out, fmt.Sprintf("{%s: %s}", strings.ToLower(m[1]), strings.TrimSpace(m[2])))
+		case n == 0:
+			out = append(out, "{title: "+line+"}")
+		case n == 1:
+			out = append(out, "{artist: "+line+"}")
+		default:
+			out = append(out, "{comment: "+line+"}")
+		}
+	}
+
+	if opts.Environments {
+		out = append(out, sectionEnvironments(lines[i:])...)
+	} else {
+		for _, line := range lines[i:] {
+			if m := onSongSectionRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				line = "{comment: " + m[1] + "}"
+			}
+			out = append(out, line)
+		}
+	}
+
+	if opts.Directives == DirectivesShort {
+		for i, line := range out {
+			if m := directiveRegex.FindStringSubmatch(line); m != nil && shortDirectives[m[1]] != "" {
+				out[i] = "{" + shortDirectives[m[1]] + m[2] + "}"
+			}
+		}
+	}
+
+	return strings.TrimRight(strings.Join(out, "\n"), "\n") + "\n"
+}
+
+// sectionEnvironments wraps the sections of a chart body in ChordPro
+// environments: a chorus, verse or bridge label opens its environment, which
+// runs until the next label or "#" comment. Runs of staff lines become tab
+// blocks; as environments can't nest, a tab block inside a section closes it
+// and the section is reopened after the tab. Sections without an environment
+// keep their {comment:} label.
+func sectionEnvironments(lines []string) []string {
+	out := make([]string, 0, len(lines)+8)
+	open, resume := "", ""
+	inTab, staves := false, false
+
+	// end closes an environment before the blank lines ending its section
+	end := func(name string) {
+		n := len(out)
+		for n > 0 && strings.TrimSpace(out[n-1]) == "" {
+			n--
+		}
+		out = slices.Insert(out, n, "{end_of_"+name+"}")
+	}
+	closeSection := func() {
+		if open != "" {
+			end(open)
+			open = ""
+		}
+	}
+	pauseSection := func() {
+		if open != "" {
+			resume = open
+			closeSection()
+		}
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// Tab blocks already in the chart pass through untouched
+		if inTab {
+			out = append(out, line)
+			if m := directiveRegex.FindStringSubmatch(trimmed); m != nil && (m[1] == "end_of_tab" || m[1] == "eot") {
+				inTab = false
+			}
+			continue
+		}
+
+		staff := converter.IsStaffLine(line)
+		if staves && !staff {
+			end("tab")
+			staves = false
+		}
+
+		m := directiveRegex.FindStringSubmatch(trimmed)
+		switch {
+		case onSongSectionRegex.MatchString(trimmed):
+			closeSection()
+			resume = ""
+			label := onSongSectionRegex.FindStringSubmatch(trimmed)[1]
+			if name := converter.SectionEnvironment(label); name != "" {
+				out = append(out, "{start_of_"+name+": "+label+"}")
+				open = name
+			} else {
+				out = append(out, "{comment: "+label+"}")
+			}
+		case m != nil && (m[1] == "start_of_tab" || m[1] == "sot"):
+			pauseSection()
+			inTab = true
+			out = append(out, line)
+		case strings.HasPrefix(trimmed, "#"):
+			closeSection()
+			resume = ""
+			out = append(out, line)
+		case staff:
+			if !staves {
+				pauseSection()
+				out = append(out, "{start_of_tab}")
+				staves = true
+			}
+			out = append(out, line)
+		default:
+			if resume != "" && trimmed != "" {
+				out = append(out, "{start_of_"+resume+"}")
+				open, resume = resume, ""
+			}
+			out = append(out, line)
+		}
+	}
+
+	if staves {
+		end("tab")
+	}
+	closeSection()
+	return out
+}
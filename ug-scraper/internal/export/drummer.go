@@ -0,0 +1,220 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// defaultTimeSignature is assumed when a chart doesn't state one
+const defaultTimeSignature = "4/4"
+
+var (
+	// drummerChordRegex matches an inline [chord]
+	drummerChordRegex = regexp.MustCompile(`\[([^\]]+)\]`)
+	// commentDirectiveRegex matches a {comment: ...} line in either spelling
+	commentDirectiveRegex = regexp.MustCompile(`^\{(?:comment|c|comment_italic|ci|comment_box|cb):\s*(.*?)\s*\}$`)
+	// repeatCountRegex matches a repeat note such as "x2", "2x" or "repeat 3 times"
+	repeatCountRegex = regexp.MustCompile(`(?i)^(?:[x×]\s?(\d+)|(\d+)\s?[x×]|repeat(?:\s+[x×]?(\d+)(?:\s*(?:[x×]|times))?)?)$`)
+	// hitNoteRegex matches notes a drummer plays to: stops, hits, breaks and
+	// tempo or dynamic changes
+	hitNoteRegex = regexp.MustCompile(`(?i)^(?:stops?|hits?|accents?|breaks?|n\.?c\.?|no\s+chords?|tacet|hold|fermata|let\s+ring|ring\s+out|fade\s+out|rit\.?|ritardando|a\s+tempo|build|half[\s-]time|double[\s-]time|all\s+in|drums?\s+in|drums?\s+out)\b`)
+	// bpmRegex finds a tempo written into a comment, e.g. "(1/8, 120 bpm)"
+	bpmRegex = regexp.MustCompile(`(?i)\b(\d{2,3})\s*bpm\b`)
+)
+
+// DrummerChart is the road map of a song for its drummer: no chords, just
+// the sections, how long they are and where the band stops or hits
+type DrummerChart struct {
+	Title         string           `json:"title"`
+	Artist        string           `json:"artist"`
+	Tempo         int              `json:"tempo,omitempty"` // Beats per minute, 0 when unknown
+	TimeSignature string           `json:"time_signature"`
+	TimeAssumed   bool             `json:"time_assumed,omitempty"` // The chart didn't state one
+	Sections      []DrummerSection `json:"sections"`
+	TotalBars     int              `json:"total_bars"`
+}
+
+// DrummerSection is one section of a drummer chart
+type DrummerSection struct {
+	Name   string   `json:"name"`
+	Bars   int      `json:"bars"`            // Bars in one pass
+	Repeat int      `json:"repeat"`          // Passes, 1 when not repeated
+	Total  int      `json:"total"`           // Bars over all passes
+	Notes  []string `json:"notes,omitempty"` // Hits and stops, e.g. "bar 4: stop"
+}
+
+// BuildDrummerChart derives a drummer chart from a song's OnSong document.
+// Every chord change counts as a bar. A repeat note right under a section
+// label repeats the whole section; under a chord line it repeats that line.
+// Tempo comes from the Tempo header or a "bpm" comment such as a strumming
+// pattern.
+func BuildDrummerChart(song *library.Song) *DrummerChart {
+	chart := &DrummerChart{Title: song.Title, Artist: song.Artist}
+
+	lines := strings.Split(strings.ReplaceAll(SongDocument(song), "\r\n", "\n"), "\n")
+	i := 0
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		m := onSongHeaderRegex.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if m == nil {
+			continue
+		}
+		switch m[1] {
+		case "Tempo":
+			chart.Tempo = leadingNumber(m[2])
+		case "Time":
+			chart.TimeSignature = strings.TrimSpace(m[2])
+		}
+	}
+
+	var current *DrummerSection
+	lastLineBars := 0
+	startSection := func(name string) {
+		chart.Sections = append(chart.Sections, DrummerSection{Name: name, Repeat: 1})
+		current = &chart.Sections[len(chart.Sections)-1]
+		lastLineBars = 0
+	}
+
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := onSongSectionRegex.FindStringSubmatch(line); m != nil {
+			startSection(m[1])
+			continue
+		}
+
+		if m := commentDirectiveRegex.FindStringSubmatch(line); m != nil {
+			note := m[1]
+			if chart.Tempo == 0 {
+				if bpm := bpmRegex.FindStringSubmatch(note); bpm != nil {
+					chart.Tempo, _ = strconv.Atoi(bpm[1])
+				}
+			}
+			if current == nil {
+				continue
+			}
+			switch {
+			case repeatCountRegex.MatchString(note):
+				n := repeatCount(note)
+				if current.Bars == 0 {
+					current.Repeat = n
+				} else if lastLineBars > 0 {
+					current.Bars += lastLineBars * (n - 1)
+					current.Notes = append(current.Notes, fmt.Sprintf("bar %d: last %d bars %s", current.Bars, lastLineBars, note))
+				}
+			case hitNoteRegex.MatchString(note):
+				current.Notes = append(current.Notes, fmt.Sprintf("bar %d: %s", max(current.Bars, 1), note))
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		chords := drummerChordRegex.FindAllStringSubmatch(line, -1)
+		if len(chords) == 0 {
+			continue
+		}
+		if current == nil {
+			startSection("Intro")
+		}
+
+		lastLineBars = 0
+		for _, c := range chords {
+			chord := strings.TrimSpace(c[1])
+			lastLineBars++
+			if hitNoteRegex.MatchString(chord) {
+				current.Notes = append(current.Notes, fmt.Sprintf("bar %d: stop (%s)", current.Bars+lastLineBars, chord))
+			}
+		}
+		current.Bars += lastLineBars
+	}
+
+	// Sections with only lyrics or labels carry no bars and are left out
+	sections := chart.Sections[:0]
+	for _, s := range chart.Sections {
+		if s.Bars == 0 {
+			continue
+		}
+		s.Total = s.Bars * s.Repeat
+		chart.TotalBars += s.Total
+		sections = append(sections, s)
+	}
+	chart.Sections = sections
+
+	if chart.TimeSignature == "" {
+		chart.TimeSignature = defaultTimeSignature
+		chart.TimeAssumed = true
+	}
+
+	return chart
+}
+
+// Text renders the chart as a plain-text road map
+func (d *DrummerChart) Text() string {
+	var b strings.Builder
+
+	b.WriteString(d.Title + "\n")
+	if d.Artist != "" {
+		b.WriteString(d.Artist + "\n")
+	}
+	b.WriteString("\n")
+
+	tempo := "? bpm"
+	if d.Tempo > 0 {
+		tempo = fmt.Sprintf("%d bpm", d.Tempo)
+	}
+	timeSig := d.TimeSignature
+	if d.TimeAssumed {
+		timeSig += " (assumed)"
+	}
+	fmt.Fprintf(&b, "Tempo: %s\nTime: %s\nBars: %d\n\n", tempo, timeSig, d.TotalBars)
+
+	width := 0
+	for _, s := range d.Sections {
+		width = max(width, len(s.Name))
+	}
+	for _, s := range d.Sections {
+		length := fmt.Sprintf("%d bars", s.Bars)
+		if s.Repeat > 1 {
+			length = fmt.Sprintf("%d bars x%d = %d", s.Bars, s.Repeat, s.Total)
+		}
+		fmt.Fprintf(&b, "%-*s  %s\n", width, s.Name, length)
+		for _, note := range s.Notes {
+			fmt.Fprintf(&b, "%-*s    %s\n", width, "", note)
+		}
+	}
+
+	return b.String()
+}
+
+// repeatCount reads the number of passes from a repeat note; a bare
+// "repeat" means twice
+func repeatCount(note string) int {
+	m := repeatCountRegex.FindStringSubmatch(strings.TrimSpace(note))
+	if m == nil {
+		return 1
+	}
+	for _, group := range m[1:] {
+		if n, err := strconv.Atoi(group); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 2
+}
+
+// leadingNumber parses the number a header value starts with, e.g. 120 from
+// "120 bpm"
+func leadingNumber(value string) int {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[0])
+	return n
+}
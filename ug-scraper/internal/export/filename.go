@@ -0,0 +1,31 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]+`)
+
+// SanitizeFilename makes a song or setlist name safe to use as a file name on
+// every platform OnSong syncs from
+func SanitizeFilename(name string) string {
+	name = unsafeFilenameChars.ReplaceAllString(name, "-")
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.Trim(name, " .-")
+
+	if len(name) > 120 {
+		// Cut on a character boundary so the name stays valid UTF-8
+		cut := 120
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = strings.TrimSpace(name[:cut])
+	}
+	if name == "" {
+		name = "untitled"
+	}
+
+	return name
+}
@@ -0,0 +1,184 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+	"strconv"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+// HTML colour schemes
+const (
+	ThemeAuto  = "auto"  // Follows the device's light or dark mode
+	ThemeLight = "light" // Always dark text on a light page
+	ThemeDark  = "dark"  // Always light text on a dark page
+)
+
+// ValidTheme reports whether theme is a known HTML colour scheme
+func ValidTheme(theme string) bool {
+	return theme == ThemeAuto || theme == ThemeLight || theme == ThemeDark
+}
+
+// htmlPage is a chart prepared for the HTML template
+type htmlPage struct {
+	Theme    string
+	Title    string
+	Artist   string
+	Meta     []string // "Key: G", "Capo: 2", ...
+	Sections []htmlSection
+	Notes    []string
+}
+
+type htmlSection struct {
+	Label string
+	Lines []htmlLine
+}
+
+// htmlLine is a line of lyrics or chords, a comment, or a block of
+// tablature lines
+type htmlLine struct {
+	Kind      string
+	Text      string
+	Chords    bool // Whether the fragments have a chord row
+	Fragments []converter.ChartFragment
+}
+
+// ChartHTML renders a chart as a standalone HTML page: chords sit above the
+// syllable they are played on and lines wrap with the screen width, while
+// tablature stays monospace. The page follows the device's colour scheme
+// with ThemeAuto and prints black on white.
+func ChartHTML(chart *converter.Chart, theme string) ([]byte, error) {
+	page := htmlPage{Theme: theme, Title: chart.Title, Artist: chart.Artist, Notes: chart.Notes}
+	if chart.Key != "" {
+		page.Meta = append(page.Meta, "Key: "+chart.Key)
+	}
+	if chart.Capo > 0 {
+		page.Meta = append(page.Meta, "Capo: "+strconv.Itoa(chart.Capo))
+	}
+	if chart.Tuning != "" {
+		page.Meta = append(page.Meta, "Tuning: "+chart.Tuning)
+	}
+
+	for _, section := range chart.Sections {
+		out := htmlSection{Label: section.Label}
+		for _, line := range section.Lines {
+			switch line.Kind {
+			case converter.LineDirective:
+				continue
+			case converter.LineTab:
+				// Consecutive tab lines share one monospace block
+				if n := len(out.Lines); n > 0 && out.Lines[n-1].Kind == converter.LineTab {
+					out.Lines[n-1].Text += "\n" + line.Text
+					continue
+				}
+				out.Lines = append(out.Lines, htmlLine{Kind: line.Kind, Text: line.Text})
+			case converter.LineComment:
+				out.Lines = append(out.Lines, htmlLine{Kind: line.Kind, Text: line.Text})
+			default:
+				out.Lines = append(out.Lines, htmlLine{
+					Kind:      line.Kind,
+					Chords:    len(line.Chords) > 0,
+					Fragments: line.Fragments,
+				})
+			}
+		}
+
+		if out.Label != "" || len(out.Lines) > 0 {
+			page.Sections = append(page.Sections, out)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var htmlTemplate = template.Must(template.New("chart").Parse(`<!DOCTYPE html>
+<html lang="en"{{if ne .Theme "auto"}} data-theme="{{.Theme}}"{{end}}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}{{if .Artist}} - {{.Artist}}{{end}}</title>
+<style>
+:root {
+  color-scheme: light dark;
+  --bg: #ffffff; --fg: #1c1c1e; --muted: #6e6e73; --chord: #b3261e; --label: #0b57d0; --rule: #dddddd;
+}
+@media (prefers-color-scheme: dark) {
+  :root:not([data-theme="light"]) {
+    --bg: #121212; --fg: #e8e8e8; --muted: #9a9a9f; --chord: #ffb4a9; --label: #a8c7fa; --rule: #333333;
+  }
+}
+:root[data-theme="dark"] {
+  color-scheme: dark;
+  --bg: #121212; --fg: #e8e8e8; --muted: #9a9a9f; --chord: #ffb4a9; --label: #a8c7fa; --rule: #333333;
+}
+:root[data-theme="light"] { color-scheme: light; }
+body {
+  margin: 0; background: var(--bg); color: var(--fg);
+  font: clamp(16px, 0.9vw + 12px, 28px)/1.35 system-ui, -apple-system, "Segoe UI", Roboto, sans-serif;
+}
+main { max-width: 60em; margin: 0 auto; padding: 1.2em 1em 3em; }
+header { border-bottom: 1px solid var(--rule); padding-bottom: 0.6em; margin-bottom: 1em; }
+h1 { font-size: 1.8em; margin: 0; line-height: 1.15; }
+.artist { font-size: 1.15em; color: var(--muted); margin: 0.15em 0 0; }
+.meta { margin: 0.4em 0 0; color: var(--muted); }
+.meta span + span::before { content: " · "; }
+section { margin: 0 0 1.2em; break-inside: avoid; }
+h2 { font-size: 0.85em; text-transform: uppercase; letter-spacing: 0.06em; color: var(--label); margin: 0 0 0.3em; }
+.line { display: flex; flex-wrap: wrap; align-items: flex-end; }
+.seg { display: inline-flex; flex-direction: column; white-space: pre; }
+.chord { color: var(--chord); font-weight: 700; min-height: 1.3em; padding-right: 0.35em; }
+.chords .seg { margin-right: 1em; }
+.comment { font-style: italic; color: var(--muted); margin: 0.2em 0; }
+pre.tab { font: 0.8em/1.25 ui-monospace, Menlo, Consolas, monospace; overflow-x: auto; margin: 0.4em 0; }
+footer { border-top: 1px solid var(--rule); margin-top: 2em; padding-top: 0.5em; font-size: 0.75em; color: var(--muted); }
+footer p { margin: 0.2em 0; }
+@media print {
+  :root, :root[data-theme] { --bg: #ffffff; --fg: #000000; --muted: #444444; --chord: #000000; --label: #000000; --rule: #999999; }
+  body { font-size: 11pt; }
+  main { max-width: none; padding: 0; }
+}
+</style>
+</head>
+<body>
+<main>
+<header>
+<h1>{{.Title}}</h1>
+{{- if .Artist}}
+<p class="artist">{{.Artist}}</p>
+{{- end}}
+{{- if .Meta}}
+<p class="meta">{{range .Meta}}<span>{{.}}</span>{{end}}</p>
+{{- end}}
+</header>
+{{- range .Sections}}
+<section>
+{{- if .Label}}
+<h2>{{.Label}}</h2>
+{{- end}}
+{{- range .Lines}}
+{{- if eq .Kind "tab"}}
+<pre class="tab">{{.Text}}</pre>
+{{- else if eq .Kind "comment"}}
+<p class="comment">{{.Text}}</p>
+{{- else}}
+<div class="line {{.Kind}}">{{$chords := .Chords}}{{range .Fragments}}<span class="seg">{{if $chords}}<span class="chord">{{.Chord}}</span>{{end}}<span class="lyric">{{.Lyric}}</span></span>{{end}}</div>
+{{- end}}
+{{- end}}
+</section>
+{{- end}}
+{{- if .Notes}}
+<footer>
+{{- range .Notes}}
+<p>{{.}}</p>
+{{- end}}
+</footer>
+{{- end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,23 @@
+package export
+
+import (
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// ManifestEntry describes a delivered file for a delivery manifest, hashing
+// the exact bytes that were sent. song may be nil for files not tied to a song.
+func ManifestEntry(name string, song *library.Song, content string) library.ManifestFile {
+	entry := library.ManifestFile{
+		Name:   name,
+		SHA256: contentHash(content),
+		Size:   len(content),
+	}
+	if song != nil {
+		entry.SongID = song.ID
+		entry.Title = song.Title
+		entry.Artist = song.Artist
+		updated := song.UpdatedAt
+		entry.SongUpdated = &updated
+	}
+	return entry
+}
@@ -0,0 +1,173 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+// MIDI backing track limits
+const (
+	DefaultMIDITempo = 100 // Beats per minute when neither request nor tab sets one
+	MinMIDITempo     = 40
+	MaxMIDITempo     = 240
+
+	DefaultMIDIBeats = 4 // Beats every chord is held, one bar of 4/4
+	MinMIDIBeats     = 1
+	MaxMIDIBeats     = 16
+)
+
+// MIDIContentType is the media type of Standard MIDI Files
+const MIDIContentType = "audio/midi"
+
+// midiTicks is the resolution of the file in ticks per quarter note
+const midiTicks = 480
+
+// MIDI channels and General MIDI programs of the backing track
+const (
+	chordChannel = 0
+	chordProgram = 0 // Acoustic grand piano
+	bassChannel  = 1
+	bassProgram  = 33 // Electric bass (finger)
+)
+
+// MIDIOptions are how a chart is played as a backing track
+type MIDIOptions struct {
+	Tempo int // Beats per minute
+	Beats int // Beats every chord is held
+}
+
+// ChartMIDI writes the chord progression of a chart as a Standard MIDI File:
+// a piano track holding each chord for opts.Beats beats over a bass track
+// playing its root, or its slash bass, on every beat. Chords are played at
+// sounding pitch, moved up by the capo, so the key can be checked by ear.
+// Section labels become markers and every chord is named in a text event;
+// chords that can't be read leave a rest.
+func ChartMIDI(chart *converter.Chart, opts MIDIOptions) []byte {
+	if opts.Tempo <= 0 {
+		opts.Tempo = DefaultMIDITempo
+	}
+	if opts.Beats <= 0 {
+		opts.Beats = DefaultMIDIBeats
+	}
+
+	var conductor, chords, bass midiTrack
+	perBeat := 60000000 / opts.Tempo // Microseconds per quarter note
+	conductor.meta(0, 0x03, []byte(chart.Title))
+	conductor.meta(0, 0x51, []byte{byte(perBeat >> 16), byte(perBeat >> 8), byte(perBeat)})
+	conductor.meta(0, 0x58, []byte{4, 2, 24, 8}) // 4/4
+	chords.meta(0, 0x03, []byte("Chords"))
+	chords.event(0, 0xC0|chordChannel, chordProgram)
+	bass.meta(0, 0x03, []byte("Bass"))
+	bass.event(0, 0xC0|bassChannel, bassProgram)
+
+	length := opts.Beats * midiTicks
+	tick := 0
+	for _, section := range chart.Sections {
+		if section.Label != "" {
+			conductor.meta(tick, 0x06, []byte(section.Label))
+		}
+		for _, line := range section.Lines {
+			if line.Kind != converter.LineLyrics && line.Kind != converter.LineChords {
+				continue
+			}
+			for _, chord := range line.Chords {
+				chords.meta(tick, 0x01, []byte(chord.Chord))
+				if notes, root, ok := chordVoicing(chord.Chord, chart.Capo); ok {
+					// Notes end a little early so repeated chords are struck again
+					for _, note := range notes {
+						chords.event(tick, 0x90|chordChannel, note, 80)
+					}
+					for _, note := range notes {
+						chords.event(tick+length-midiTicks/16, 0x80|chordChannel, note, 0)
+					}
+					for beat := 0; beat < opts.Beats; beat++ {
+						at := tick + beat*midiTicks
+						bass.event(at, 0x90|bassChannel, root, 96)
+						bass.event(at+midiTicks*7/8, 0x80|bassChannel, root, 0)
+					}
+				}
+				tick += length
+			}
+		}
+	}
+
+	// Format 1: the conductor track, then the chord and bass tracks
+	var out bytes.Buffer
+	out.WriteString("MThd")
+	_ = binary.Write(&out, binary.BigEndian, uint32(6))
+	_ = binary.Write(&out, binary.BigEndian, []uint16{1, 3, midiTicks})
+	for _, track := range []*midiTrack{&conductor, &chords, &bass} {
+		track.meta(tick, 0x2F, nil)
+		out.WriteString("MTrk")
+		_ = binary.Write(&out, binary.BigEndian, uint32(track.data.Len()))
+		out.Write(track.data.Bytes())
+	}
+	return out.Bytes()
+}
+
+// chordVoicing returns the MIDI notes of a chord in close position around
+// middle C, moved up by the capo, with the bass note an octave or two below
+func chordVoicing(chord string, capo int) (notes []byte, bass byte, ok bool) {
+	tones, ok := converter.ChordTones(chord)
+	if !ok {
+		return nil, 0, false
+	}
+
+	root := (tones[0] + capo) % 12
+	base := 60 + root // C4 to B4, folded below F#4
+	if base > 66 {
+		base -= 12
+	}
+	for _, tone := range tones {
+		notes = append(notes, byte(base+((tone+capo)%12-root+12)%12))
+	}
+
+	bassClass := root
+	if _, _, slash, _ := converter.SplitChord(chord); slash != "" {
+		if pc := converter.NoteIndex(slash); pc >= 0 {
+			bassClass = (pc + capo) % 12
+		}
+	}
+	// A1 to G#2, the range of a bass guitar's lower strings
+	return notes, byte(33 + (bassClass-9+12)%12), true
+}
+
+// midiTrack collects the events of a track chunk, added in time order
+type midiTrack struct {
+	data bytes.Buffer
+	last int // Tick of the last event
+}
+
+// event writes a channel event at an absolute tick
+func (t *midiTrack) event(tick int, data ...byte) {
+	t.delta(tick)
+	t.data.Write(data)
+}
+
+// meta writes a meta event at an absolute tick
+func (t *midiTrack) meta(tick int, kind byte, data []byte) {
+	t.delta(tick)
+	t.data.Write([]byte{0xFF, kind})
+	writeVarLen(&t.data, len(data))
+	t.data.Write(data)
+}
+
+// delta writes the time since the last event
+func (t *midiTrack) delta(tick int) {
+	writeVarLen(&t.data, max(0, tick-t.last))
+	t.last = max(tick, t.last)
+}
+
+// writeVarLen writes a MIDI variable-length quantity
+func writeVarLen(buf *bytes.Buffer, n int) {
+	var groups []byte
+	groups = append(groups, byte(n&0x7F))
+	for n >>= 7; n > 0; n >>= 7 {
+		groups = append(groups, byte(n&0x7F)|0x80)
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		buf.WriteByte(groups[i])
+	}
+}
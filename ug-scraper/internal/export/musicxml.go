@@ -0,0 +1,316 @@
+package export
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+// MusicXMLContentType is the media type of uncompressed MusicXML files
+const MusicXMLContentType = "application/vnd.recordare.musicxml+xml"
+
+// musicXMLDoctype is the document type of MusicXML 4.0 partwise scores
+const musicXMLDoctype = `<!DOCTYPE score-partwise PUBLIC "-//Recordare//DTD MusicXML 4.0 Partwise//EN" "http://www.musicxml.org/dtds/partwise.dtd">` + "\n"
+
+type mxScore struct {
+	XMLName        xml.Name         `xml:"score-partwise"`
+	Version        string           `xml:"version,attr"`
+	Title          string           `xml:"work>work-title"`
+	Identification mxIdentification `xml:"identification"`
+	PartList       mxPartList       `xml:"part-list"`
+	Part           mxPart           `xml:"part"`
+}
+
+type mxIdentification struct {
+	Creator  *mxCreator `xml:"creator,omitempty"`
+	Software string     `xml:"encoding>software"`
+}
+
+type mxCreator struct {
+	Type string `xml:"type,attr"`
+	Name string `xml:",chardata"`
+}
+
+type mxPartList struct {
+	ScorePart mxScorePart `xml:"score-part"`
+}
+
+type mxScorePart struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"part-name"`
+}
+
+type mxPart struct {
+	ID       string      `xml:"id,attr"`
+	Measures []mxMeasure `xml:"measure"`
+}
+
+type mxMeasure struct {
+	Number     int           `xml:"number,attr"`
+	Print      *mxPrint      `xml:"print,omitempty"`
+	Attributes *mxAttributes `xml:"attributes,omitempty"`
+	Directions []mxDirection `xml:"direction"`
+	Harmony    *mxHarmony    `xml:"harmony,omitempty"`
+	Note       mxNote        `xml:"note"`
+	Barline    *mxBarline    `xml:"barline,omitempty"`
+}
+
+type mxPrint struct {
+	NewSystem string `xml:"new-system,attr"`
+}
+
+type mxAttributes struct {
+	Divisions int    `xml:"divisions"`
+	Fifths    int    `xml:"key>fifths"`
+	Mode      string `xml:"key>mode"`
+	Beats     int    `xml:"time>beats"`
+	BeatType  int    `xml:"time>beat-type"`
+	ClefSign  string `xml:"clef>sign"`
+	ClefLine  int    `xml:"clef>line"`
+}
+
+type mxDirection struct {
+	Placement string `xml:"placement,attr"`
+	Rehearsal string `xml:"direction-type>rehearsal,omitempty"`
+	Words     string `xml:"direction-type>words,omitempty"`
+}
+
+type mxHarmony struct {
+	RootStep  string  `xml:"root>root-step"`
+	RootAlter int     `xml:"root>root-alter,omitempty"`
+	Kind      mxKind  `xml:"kind"`
+	Bass      *mxBass `xml:"bass,omitempty"`
+}
+
+type mxKind struct {
+	Text  string `xml:"text,attr"`
+	Value string `xml:",chardata"`
+}
+
+type mxBass struct {
+	Step  string `xml:"bass-step"`
+	Alter int    `xml:"bass-alter,omitempty"`
+}
+
+type mxNote struct {
+	Step     string   `xml:"pitch>step"`
+	Octave   int      `xml:"pitch>octave"`
+	Duration int      `xml:"duration"`
+	Type     string   `xml:"type"`
+	Notehead string   `xml:"notehead"`
+	Lyric    *mxLyric `xml:"lyric,omitempty"`
+}
+
+type mxLyric struct {
+	Number   string `xml:"number,attr"`
+	Syllabic string `xml:"syllabic"`
+	Text     string `xml:"text"`
+}
+
+type mxBarline struct {
+	Location string `xml:"location,attr"`
+	Style    string `xml:"bar-style"`
+}
+
+// harmonyKinds are the MusicXML chord kinds of common chord qualities.
+// Other qualities fall back to major or minor; the written quality is kept
+// as the kind's text either way, so notation programs show the chord as the
+// chart writes it.
+var harmonyKinds = map[string]string{
+	"":     "major",
+	"maj":  "major",
+	"M":    "major",
+	"m":    "minor",
+	"min":  "minor",
+	"-":    "minor",
+	"7":    "dominant",
+	"maj7": "major-seventh",
+	"M7":   "major-seventh",
+	"Δ7":   "major-seventh",
+	"Δ":    "major-seventh",
+	"m7":   "minor-seventh",
+	"min7": "minor-seventh",
+	"-7":   "minor-seventh",
+	"dim":  "diminished",
+	"°":    "diminished",
+	"dim7": "diminished-seventh",
+	"°7":   "diminished-seventh",
+	"m7b5": "half-diminished",
+	"ø":    "half-diminished",
+	"ø7":   "half-diminished",
+	"aug":  "augmented",
+	"+":    "augmented",
+	"sus":  "suspended-fourth",
+	"sus4": "suspended-fourth",
+	"sus2": "suspended-second",
+	"6":    "major-sixth",
+	"m6":   "minor-sixth",
+	"9":    "dominant-ninth",
+	"maj9": "major-ninth",
+	"m9":   "minor-ninth",
+	"5":    "power",
+}
+
+// ChartMusicXML writes a chart as a MusicXML 4.0 lead sheet that MuseScore
+// and other notation programs open for further notation. The chart has no
+// rhythm, so every chord gets a measure of its own: a chord symbol over a
+// slash note carrying the lyrics sung from that chord. Each chart line starts
+// a new system, section labels become rehearsal marks and comments become
+// directions; tablature is left out.
+func ChartMusicXML(chart *converter.Chart) ([]byte, error) {
+	score := mxScore{
+		Version:        "4.0",
+		Title:          chart.Title,
+		Identification: mxIdentification{Software: "ug-scraper"},
+		PartList:       mxPartList{ScorePart: mxScorePart{ID: "P1", Name: "Voice"}},
+		Part:           mxPart{ID: "P1"},
+	}
+	if chart.Artist != "" {
+		score.Identification.Creator = &mxCreator{Type: "composer", Name: chart.Artist}
+	}
+
+	var pending []mxDirection
+	if chart.Capo > 0 {
+		pending = append(pending, mxDirection{Placement: "above", Words: "Capo " + strconv.Itoa(chart.Capo)})
+	}
+
+	// measure adds a measure, starting a new system at the first of a line
+	measures := &score.Part.Measures
+	measure := func(chord, lyric, syllabic string, newLine bool) {
+		m := mxMeasure{
+			Number:     len(*measures) + 1,
+			Directions: pending,
+			Note:       mxNote{Step: "B", Octave: 4, Duration: 4, Type: "whole", Notehead: "slash"},
+		}
+		pending = nil
+		if newLine && len(*measures) > 0 {
+			m.Print = &mxPrint{NewSystem: "yes"}
+		}
+		if chord != "" {
+			m.Harmony = harmony(chord)
+		}
+		if lyric != "" {
+			m.Note.Lyric = &mxLyric{Number: "1", Syllabic: syllabic, Text: lyric}
+		}
+		*measures = append(*measures, m)
+	}
+
+	for _, section := range chart.Sections {
+		if section.Label != "" {
+			pending = append(pending, mxDirection{Placement: "above", Rehearsal: section.Label})
+		}
+		for _, line := range section.Lines {
+			switch line.Kind {
+			case converter.LineComment:
+				pending = append(pending, mxDirection{Placement: "above", Words: line.Text})
+			case converter.LineLyrics, converter.LineChords:
+				if len(line.Chords) == 0 && strings.TrimSpace(line.Text) == "" {
+					continue
+				}
+				for i, fragment := range line.Fragments {
+					measure(fragment.Chord, strings.TrimSpace(fragment.Lyric), syllabic(line.Fragments, i), i == 0)
+				}
+			}
+		}
+	}
+
+	if len(*measures) == 0 {
+		measure("", "", "", true)
+	}
+	fifths, mode := keySignature(chart.Key)
+	first, last := &(*measures)[0], &(*measures)[len(*measures)-1]
+	first.Attributes = &mxAttributes{
+		Divisions: 1,
+		Fifths:    fifths,
+		Mode:      mode,
+		Beats:     4,
+		BeatType:  4,
+		ClefSign:  "G",
+		ClefLine:  2,
+	}
+	last.Directions = append(last.Directions, pending...)
+	last.Barline = &mxBarline{Location: "right", Style: "light-heavy"}
+
+	body, err := xml.MarshalIndent(score, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []byte(xml.Header + musicXMLDoctype + string(body) + "\n"), nil
+}
+
+// harmony returns the MusicXML chord symbol of a chord name
+func harmony(chord string) *mxHarmony {
+	root, quality, bass, ok := converter.SplitChord(chord)
+	if !ok {
+		return nil
+	}
+
+	kind, known := harmonyKinds[quality]
+	if !known {
+		kind = "major"
+		if converter.IsMinorKey(chord) {
+			kind = "minor"
+		}
+	}
+
+	h := &mxHarmony{Kind: mxKind{Text: quality, Value: kind}}
+	h.RootStep, h.RootAlter = step(root)
+	if bass != "" {
+		b := &mxBass{}
+		b.Step, b.Alter = step(bass)
+		h.Bass = b
+	}
+	return h
+}
+
+// step splits a note name into its letter and its alteration in semitones
+func step(note string) (string, int) {
+	alter := strings.Count(note, "#") - strings.Count(note, "b")
+	return note[:1], alter
+}
+
+// syllabic tells whether the lyric of a fragment is a whole word or part of
+// one, going by the spaces at the fragment edges
+func syllabic(fragments []converter.ChartFragment, i int) string {
+	lyric := fragments[i].Lyric
+	startsMid := i > 0 && !strings.HasSuffix(fragments[i-1].Lyric, " ") &&
+		fragments[i-1].Lyric != "" && !strings.HasPrefix(lyric, " ")
+	endsMid := i+1 < len(fragments) && !strings.HasSuffix(lyric, " ") &&
+		fragments[i+1].Lyric != "" && !strings.HasPrefix(fragments[i+1].Lyric, " ")
+
+	switch {
+	case startsMid && endsMid:
+		return "middle"
+	case startsMid:
+		return "end"
+	case endsMid:
+		return "begin"
+	}
+	return "single"
+}
+
+// keySignature returns the MusicXML key signature of a key: its number of
+// sharps (positive) or flats (negative) and its mode. Keys that can't be
+// read get C major.
+func keySignature(key string) (int, string) {
+	root, _, _, ok := converter.SplitChord(key)
+	pc := converter.NoteIndex(root)
+	if !ok || pc < 0 {
+		return 0, "major"
+	}
+
+	mode := "major"
+	if converter.IsMinorKey(key) {
+		mode, pc = "minor", (pc+3)%12
+	}
+
+	// Each fifth up adds a sharp; six sharps are written as six flats from
+	// flat roots (Gb, Ebm)
+	fifths := pc * 7 % 12
+	if fifths > 6 || fifths == 6 && strings.HasSuffix(root, "b") {
+		fifths -= 12
+	}
+	return fifths, mode
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PDF fonts. Both are standard Type1 fonts every reader ships, so nothing
+// has to be embedded; Courier keeps every glyph 600/1000 em wide.
+const (
+	pdfFontRegular = "F1" // Courier
+	pdfFontBold    = "F2" // Courier-Bold
+
+	// courierAdvance is the width of a Courier glyph as a share of the font size
+	courierAdvance = 0.6
+)
+
+// A4 in points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+)
+
+// pdfText is a line of text placed on a page
+type pdfText struct {
+	font string
+	size float64
+	x, y float64 // Baseline start, from the bottom-left corner
+	text string
+}
+
+// pdfWriter collects pages of text and writes them as a minimal PDF 1.4
+// document
+type pdfWriter struct {
+	title string
+	pages [][]pdfText
+}
+
+// newPage starts a new page and makes it the one text is added to
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, nil)
+}
+
+// text adds a line to the current page
+func (w *pdfWriter) text(font string, size, x, y float64, text string) {
+	if len(w.pages) == 0 {
+		w.newPage()
+	}
+	last := len(w.pages) - 1
+	w.pages[last] = append(w.pages[last], pdfText{font: font, size: size, x: x, y: y, text: text})
+}
+
+// bytes renders the document
+func (w *pdfWriter) bytes() []byte {
+	if len(w.pages) == 0 {
+		w.newPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-5 are fixed; each page then takes a page and a content object
+	const firstPage = 6
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title %s /Producer (ug-scraper) >>", pdfString(w.title)))
+
+	for i, page := range w.pages {
+		var content bytes.Buffer
+		for _, t := range page {
+			fmt.Fprintf(&content, "BT /%s %s Tf %s %s Td %s Tj ET\n",
+				t.font, pdfNumber(t.size), pdfNumber(t.x), pdfNumber(t.y), pdfString(t.text))
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(pdfPageWidth), pdfNumber(pdfPageHeight), pdfFontRegular, pdfFontBold, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfNumber formats a coordinate without trailing zeros
+func pdfNumber(n float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", n), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// winAnsiExtras maps the characters WinAnsiEncoding places in 0x80-0x9F
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// pdfString encodes text as a WinAnsi literal string. Characters the
+// standard fonts can't show become "?" so every glyph keeps its column.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		var c byte
+		switch {
+		case r == '\t':
+			c = ' '
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			c = byte(r)
+		case winAnsiExtras[r] != 0:
+			c = winAnsiExtras[r]
+		default:
+			c = '?'
+		}
+
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// SetlistPage is one page of a rendered setlist. Songs inside a medley share
+// a single page so they flow without a page turn.
+type SetlistPage struct {
+	Number     int            `json:"number"`
+	MedleyID   string         `json:"medley_id,omitempty"`
+	MedleyName string         `json:"medley_name,omitempty"`
+	Songs      []SetlistEntry `json:"songs"`
+}
+
+// SetlistEntry is a song rendered in its performance key
+type SetlistEntry struct {
+	Position  int    `json:"position"`
+	SongID    string `json:"song_id"`
+	Title     string `json:"title"`
+	Artist    string `json:"artist"`
+	Key       string `json:"key,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+	SegueNote string `json:"segue_note,omitempty"`
+	Content   string `json:"content"`
+}
+
+// PaginateSetlist renders each setlist item in its performance key and
+// groups medley items onto shared pages. Chords are spelled according to
+// spelling (auto, sharps or flats) and section names written in language.
+// Items whose song no longer exists are skipped and their IDs returned.
+func PaginateSetlist(setlist *library.Setlist, store *library.Store, spelling, language string) ([]SetlistPage, []string) {
+	var pages []SetlistPage
+	var missing []string
+
+	for i, item := range setlist.Items {
+		song, ok := store.Get(item.SongID)
+		if !ok {
+			missing = append(missing, item.SongID)
+			continue
+		}
+
+		targetKey := item.Key
+		var medley *library.Medley
+		if item.MedleyID != "" {
+			if m, ok := setlist.Medley(item.MedleyID); ok {
+				medley = m
+				if m.TargetKey != "" {
+					targetKey = m.TargetKey
+				}
+			}
+		}
+
+		entry := renderEntry(song, targetKey, spelling)
+		entry.Content = converter.LocalizeSections(entry.Content, language)
+		entry.Position = i + 1
+		entry.Notes = item.Notes
+		entry.SegueNote = item.SegueNote
+
+		// Continue the current page when this item belongs to the same medley
+		if medley != nil && len(pages) > 0 && pages[len(pages)-1].MedleyID == medley.ID {
+			last := &pages[len(pages)-1]
+			last.Songs = append(last.Songs, entry)
+			continue
+		}
+
+		page := SetlistPage{
+			Number: len(pages) + 1,
+			Songs:  []SetlistEntry{entry},
+		}
+		if medley != nil {
+			page.MedleyID = medley.ID
+			page.MedleyName = medley.Name
+		}
+		pages = append(pages, page)
+	}
+
+	return pages, missing
+}
+
+// renderEntry returns the song chart transposed to targetKey when set
+func renderEntry(song *library.Song, targetKey, spelling string) SetlistEntry {
+	entry := SetlistEntry{
+		SongID:  song.ID,
+		Title:   song.Title,
+		Artist:  song.Artist,
+		Key:     song.Key,
+		Content: song.OnSongFormat,
+	}
+
+	if targetKey == "" || song.Key == "" || song.Key == "Unknown" {
+		// Stored charts are kept as written; forced spellings still apply
+		if spelling == converter.SpellingSharps || spelling == converter.SpellingFlats {
+			entry.Content = converter.RespellOnSong(entry.Content, spelling)
+			entry.Key = converter.RespellChord(entry.Key, spelling, entry.Key)
+		}
+		return entry
+	}
+
+	semitones := converter.SemitonesBetween(song.Key, targetKey)
+	flats := converter.PreferFlats(spelling, targetKey)
+	entry.Content = converter.TransposeOnSong(song.OnSongFormat, semitones, &flats)
+	entry.Key = converter.TransposeKey(song.Key, semitones, flats)
+
+	return entry
+}
+
+// RenderSetlistText renders paginated setlist pages as a single ChordPro/OnSong
+// document. Pages are separated by {new_page}; songs within a medley are
+// joined by their segue notes instead.
+func RenderSetlistText(setlist *library.Setlist, pages []SetlistPage) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("{title: %s}\n", setlist.Name))
+	if setlist.Date != "" {
+		out.WriteString(fmt.Sprintf("{subtitle: %s}\n", setlist.Date))
+	}
+
+	for _, page := range pages {
+		out.WriteString("\n{new_page}\n\n")
+
+		if page.MedleyID != "" {
+			name := page.MedleyName
+			if name == "" {
+				name = "Medley"
+			}
+			out.WriteString(fmt.Sprintf("{comment: %s}\n\n", name))
+		}
+
+		for i, entry := range page.Songs {
+			out.WriteString(strings.TrimSpace(entry.Content))
+			out.WriteString("\n")
+			if entry.Notes != "" {
+				out.WriteString(fmt.Sprintf("{comment: %s}\n", entry.Notes))
+			}
+
+			if i < len(page.Songs)-1 {
+				segue := entry.SegueNote
+				if segue == "" {
+					segue = "segue"
+				}
+				out.WriteString(fmt.Sprintf("\n{comment: → %s}\n\n", segue))
+			}
+		}
+	}
+
+	return out.String()
+}
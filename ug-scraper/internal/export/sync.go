@@ -0,0 +1,71 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// SyncManifest lists every chart of the library by content hash so companion
+// apps can mirror it and download only what changed
+type SyncManifest struct {
+	Format      string      `json:"format"`
+	Revision    string      `json:"revision"` // Hash over every entry; unchanged means nothing to sync
+	GeneratedAt time.Time   `json:"generated_at"`
+	Songs       []SyncEntry `json:"songs"`
+}
+
+// SyncEntry is one chart of the sync manifest
+type SyncEntry struct {
+	SongID    string    `json:"song_id"`
+	Title     string    `json:"title"`
+	Artist    string    `json:"artist"`
+	Name      string    `json:"name"` // Suggested file name on the device
+	Hash      string    `json:"hash"` // SHA-256 of the chart content
+	Size      int       `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BuildSyncManifest hashes every song rendered in format (ChordPro with the
+// given options, section names in the given language) and returns the
+// manifest together with the chart content keyed by hash
+func BuildSyncManifest(songs []library.Song, format string, chordPro ChordProOptions, language string) (*SyncManifest, map[string]string, error) {
+	files, err := LibraryArchiveFiles(songs, format, chordPro, language)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest := &SyncManifest{
+		Format:      format,
+		GeneratedAt: time.Now(),
+		Songs:       make([]SyncEntry, 0, len(files)),
+	}
+	blobs := make(map[string]string, len(files))
+	revision := sha256.New()
+
+	for _, file := range files {
+		hash := contentHash(file.Content)
+		blobs[hash] = file.Content
+		manifest.Songs = append(manifest.Songs, SyncEntry{
+			SongID:    file.Song.ID,
+			Title:     file.Song.Title,
+			Artist:    file.Song.Artist,
+			Name:      file.Name,
+			Hash:      hash,
+			Size:      len(file.Content),
+			UpdatedAt: file.Song.UpdatedAt,
+		})
+		revision.Write([]byte(file.Song.ID + ":" + file.Name + ":" + hash + "\n"))
+	}
+	manifest.Revision = hex.EncodeToString(revision.Sum(nil))
+
+	return manifest, blobs, nil
+}
+
+// contentHash returns the hex SHA-256 of content
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,314 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+// Tab PDF layout, in points
+const (
+	tabPDFMargin      = 40.0
+	tabPDFLineSpacing = 1.2 // Line height as a multiple of the font size
+	tabPDFTitleSize   = 16.0
+	tabPDFArtistSize  = 12.0
+	tabPDFMetaSize    = 10.0
+	tabPDFFooterSize  = 8.0
+
+	// DefaultTabFontSize is the size tab blocks are set in. Wider riffs shrink
+	// the whole sheet down to MinTabFontSize before lines are broken.
+	DefaultTabFontSize = 9.0
+	MinTabFontSize     = 6.0
+	MaxTabFontSize     = 14.0
+)
+
+// TabSheet is a tablature chart to lay out as a PDF
+type TabSheet struct {
+	Title  string
+	Artist string
+	Tuning string
+	Capo   int
+	Key    string
+	Blocks []converter.TabBlock
+}
+
+// TabPDF renders a tab sheet as an A4 PDF. Tab blocks are set in Courier on
+// a fixed grid so the strings stay aligned, and a riff is only broken across
+// pages when it is taller than a page. fontSize 0 uses DefaultTabFontSize.
+func TabPDF(sheet TabSheet, fontSize float64) []byte {
+	if fontSize <= 0 {
+		fontSize = DefaultTabFontSize
+	}
+	fontSize = max(MinTabFontSize, min(fontSize, MaxTabFontSize))
+
+	l := &tabLayout{pdf: &pdfWriter{title: sheet.Title}}
+
+	// Shrink the sheet until the widest riff fits, keeping one size throughout
+	widest := 0
+	for _, b := range sheet.Blocks {
+		if b.IsTab {
+			for _, line := range strings.Split(expandTabs(b.Text), "\n") {
+				widest = max(widest, len([]rune(line)))
+			}
+		}
+	}
+	if widest > 0 {
+		fits := (pdfPageWidth - 2*tabPDFMargin) / (float64(widest) * courierAdvance)
+		fontSize = max(MinTabFontSize, min(fontSize, float64(int(fits*4))/4))
+	}
+	l.size = fontSize
+	l.columns = int((pdfPageWidth - 2*tabPDFMargin) / (fontSize * courierAdvance))
+
+	l.page()
+	l.header(sheet)
+
+	for i, b := range sheet.Blocks {
+		lines := strings.Split(expandTabs(b.Text), "\n")
+		if b.IsTab {
+			for j, system := range splitSystem(lines, l.columns) {
+				if j > 0 {
+					l.y -= l.lineHeight()
+				}
+				l.keep(system)
+			}
+		} else {
+			lines = wrapLines(lines, l.columns)
+			// A short label such as "Riff 1:" stays on the page of the riff it names
+			if len(lines) <= 2 && i+1 < len(sheet.Blocks) && sheet.Blocks[i+1].IsTab {
+				l.reserve(len(lines) + 1 + min(blockHeight(sheet.Blocks[i+1]), l.linesPerPage()-len(lines)-1))
+			}
+			l.flow(lines)
+		}
+		l.y -= l.lineHeight()
+	}
+
+	l.footer(sheet)
+	return l.pdf.bytes()
+}
+
+// tabLayout places lines top to bottom, starting new pages as they fill
+type tabLayout struct {
+	pdf     *pdfWriter
+	size    float64
+	columns int
+	y       float64 // Baseline of the next line
+}
+
+// lineHeight is the distance between body baselines
+func (l *tabLayout) lineHeight() float64 {
+	return l.size * tabPDFLineSpacing
+}
+
+// linesPerPage is how many body lines fit on an empty page
+func (l *tabLayout) linesPerPage() int {
+	return l.fits(pdfPageHeight - tabPDFMargin - l.size)
+}
+
+// remaining is how many body lines still fit on the current page
+func (l *tabLayout) remaining() int {
+	return l.fits(l.y)
+}
+
+// fits is how many lines fit from baseline y down to the bottom margin
+func (l *tabLayout) fits(y float64) int {
+	if y < tabPDFMargin {
+		return 0
+	}
+	return int((y-tabPDFMargin)/l.lineHeight()) + 1
+}
+
+// page starts a new page with the next line at its top
+func (l *tabLayout) page() {
+	l.pdf.newPage()
+	l.y = pdfPageHeight - tabPDFMargin - l.size
+}
+
+// reserve starts a new page unless n more lines fit on this one
+func (l *tabLayout) reserve(n int) {
+	if n > l.remaining() && l.remaining() < l.linesPerPage() {
+		l.page()
+	}
+}
+
+// line writes one body line
+func (l *tabLayout) line(text string) {
+	if l.remaining() < 1 {
+		l.page()
+	}
+	l.pdf.text(pdfFontRegular, l.size, tabPDFMargin, l.y, text)
+	l.y -= l.lineHeight()
+}
+
+// flow writes lines, breaking pages wherever they fill
+func (l *tabLayout) flow(lines []string) {
+	for _, line := range lines {
+		l.line(line)
+	}
+}
+
+// keep writes lines on one page, moving them to the next when they don't
+// fit; only blocks taller than a page are broken
+func (l *tabLayout) keep(lines []string) {
+	l.reserve(len(lines))
+	l.flow(lines)
+}
+
+// header writes the title, artist and the tuning, capo and key line
+func (l *tabLayout) header(sheet TabSheet) {
+	y := pdfPageHeight - tabPDFMargin - tabPDFTitleSize
+	l.pdf.text(pdfFontBold, tabPDFTitleSize, tabPDFMargin, y, sheet.Title)
+	y -= tabPDFArtistSize * 1.5
+	if sheet.Artist != "" {
+		l.pdf.text(pdfFontRegular, tabPDFArtistSize, tabPDFMargin, y, sheet.Artist)
+		y -= tabPDFMetaSize * 1.5
+	}
+
+	var meta []string
+	tuning := sheet.Tuning
+	if tuning == "" {
+		tuning = "Standard"
+	}
+	meta = append(meta, "Tuning: "+tuning)
+	if sheet.Capo > 0 {
+		meta = append(meta, fmt.Sprintf("Capo: %d", sheet.Capo))
+	}
+	if sheet.Key != "" && sheet.Key != "Unknown" {
+		meta = append(meta, "Key: "+sheet.Key)
+	}
+	l.pdf.text(pdfFontBold, tabPDFMetaSize, tabPDFMargin, y, strings.Join(meta, "   "))
+
+	l.y = y - tabPDFMetaSize*1.5 - l.size
+}
+
+// footer numbers the pages once the page count is known
+func (l *tabLayout) footer(sheet TabSheet) {
+	pages := l.pdf.pages
+	for i := range pages {
+		text := fmt.Sprintf("%s - page %d of %d", sheet.Title, i+1, len(pages))
+		l.pdf.pages[i] = append(pages[i], pdfText{
+			font: pdfFontRegular,
+			size: tabPDFFooterSize,
+			x:    tabPDFMargin,
+			y:    tabPDFMargin / 2,
+			text: text,
+		})
+	}
+}
+
+// blockHeight is the number of lines a block takes
+func blockHeight(b converter.TabBlock) int {
+	return strings.Count(b.Text, "\n") + 1
+}
+
+// splitSystem cuts a tab block wider than columns into systems stacked one
+// under the other. Every string is cut at the same column, at a bar line
+// when one is close, so the grid stays aligned, and later systems repeat
+// the string names.
+func splitSystem(lines []string, columns int) [][]string {
+	runes := make([][]rune, len(lines))
+	labels := make([]string, len(lines))
+	width, labelWidth := 0, 0
+	for i, line := range lines {
+		runes[i] = []rune(line)
+		width = max(width, len(runes[i]))
+		// "e|" or "HH|" names the string or drum
+		if bar := strings.IndexRune(line, '|'); bar > 0 && bar <= 3 {
+			labels[i] = line[:bar+1]
+			labelWidth = max(labelWidth, len([]rune(labels[i])))
+		}
+	}
+	if width <= columns {
+		return [][]string{lines}
+	}
+
+	var systems [][]string
+	for start := 0; start < width; {
+		room := columns
+		if start > 0 {
+			room -= labelWidth
+		}
+		end := min(start+room, width)
+		if end < width {
+			end = barCut(runes, start, end)
+		}
+
+		system := make([]string, len(runes))
+		for i, r := range runes {
+			if start > 0 && labelWidth > 0 {
+				system[i] = fmt.Sprintf("%*s", labelWidth, labels[i])
+			}
+			if start < len(r) {
+				system[i] += string(r[start:min(end, len(r))])
+			}
+		}
+		systems = append(systems, system)
+		start = end
+	}
+	return systems
+}
+
+// barCut moves a cut back to just after a bar line shared by every staff
+// line, when one is in the second half of the system
+func barCut(lines [][]rune, start, end int) int {
+	for cut := end; cut > start+(end-start)/2; cut-- {
+		shared, staves := true, 0
+		for _, r := range lines {
+			if len(r) < cut {
+				continue
+			}
+			staves++
+			if r[cut-1] != '|' {
+				shared = false
+				break
+			}
+		}
+		if shared && staves > 1 {
+			return cut
+		}
+	}
+	return end
+}
+
+// wrapLines word-wraps text lines to the page width
+func wrapLines(lines []string, columns int) []string {
+	var out []string
+	for _, line := range lines {
+		for len([]rune(line)) > columns {
+			r := []rune(line)
+			cut := strings.LastIndex(string(r[:columns]), " ")
+			if cut <= 0 {
+				cut = len(string(r[:columns]))
+			}
+			out = append(out, line[:cut])
+			line = strings.TrimLeft(line[cut:], " ")
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// expandTabs replaces tab characters with spaces up to the next 8-column stop
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		switch r {
+		case '\t':
+			n := 8 - col%8
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n':
+			b.WriteRune(r)
+			col = 0
+		default:
+			b.WriteRune(r)
+			col++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+// Package features switches optional subsystems off and describes what the
+// running add-on can do, so the web UI and integrations can adapt instead
+// of running into errors.
+package features
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Subsystems that can be switched off
+const (
+	Library  = "library"
+	Webhooks = "webhooks"
+	MQTT     = "mqtt"
+)
+
+// Flags tells which optional subsystems are switched on
+type Flags struct {
+	Library  bool
+	Webhooks bool
+	MQTT     bool
+}
+
+// FlagsFromEnv reads FEATURE_LIBRARY, FEATURE_WEBHOOKS and FEATURE_MQTT;
+// every subsystem is on unless its variable is false
+func FlagsFromEnv() Flags {
+	return Flags{
+		Library:  envBool("FEATURE_LIBRARY"),
+		Webhooks: envBool("FEATURE_WEBHOOKS"),
+		MQTT:     envBool("FEATURE_MQTT"),
+	}
+}
+
+// envBool reads a flag that defaults to on
+func envBool(name string) bool {
+	on, err := strconv.ParseBool(os.Getenv(name))
+	return err != nil || on
+}
+
+// Capability describes one optional part of the add-on
+type Capability struct {
+	Name       string   `json:"name"`
+	Enabled    bool     `json:"enabled"`    // Not switched off by a feature flag
+	Configured bool     `json:"configured"` // Has the settings it needs
+	Available  bool     `json:"available"`  // Enabled and configured
+	Option     string   `json:"option,omitempty"`
+	Reason     string   `json:"reason,omitempty"` // Why it is unavailable
+	Endpoints  []string `json:"endpoints,omitempty"`
+}
+
+// Spec registers a capability
+type Spec struct {
+	Name       string
+	Enabled    bool
+	Configured func() bool // nil means nothing needs configuring
+	Option     string      // Add-on option that switches it off
+	Endpoints  []string    // API path prefixes that answer 501 while it is off
+}
+
+// Registry holds the add-on's capabilities
+type Registry struct {
+	mu    sync.RWMutex
+	specs map[string]Spec
+}
+
+// NewRegistry creates an empty capability registry
+func NewRegistry() *Registry {
+	return &Registry{specs: make(map[string]Spec)}
+}
+
+// Add registers a capability
+func (r *Registry) Add(spec Spec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.specs[spec.Name] = spec
+}
+
+// Enabled reports whether a capability is switched on; unknown names count
+// as on
+func (r *Registry) Enabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	spec, ok := r.specs[name]
+	return !ok || spec.Enabled
+}
+
+// Get describes one capability
+func (r *Registry) Get(name string) (Capability, bool) {
+	r.mu.RLock()
+	spec, ok := r.specs[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return Capability{}, false
+	}
+	return describe(spec), true
+}
+
+// List describes every capability, sorted by name
+func (r *Registry) List() []Capability {
+	r.mu.RLock()
+	specs := make([]Spec, 0, len(r.specs))
+	for _, spec := range r.specs {
+		specs = append(specs, spec)
+	}
+	r.mu.RUnlock()
+
+	caps := make([]Capability, 0, len(specs))
+	for _, spec := range specs {
+		caps = append(caps, describe(spec))
+	}
+	sort.Slice(caps, func(i, j int) bool { return caps[i].Name < caps[j].Name })
+	return caps
+}
+
+// describe evaluates a capability's current state
+func describe(spec Spec) Capability {
+	c := Capability{
+		Name:       spec.Name,
+		Enabled:    spec.Enabled,
+		Configured: spec.Configured == nil || spec.Configured(),
+		Option:     spec.Option,
+		Endpoints:  spec.Endpoints,
+	}
+	c.Available = c.Enabled && c.Configured
+
+	switch {
+	case !c.Enabled:
+		c.Reason = "switched off"
+		if spec.Option != "" {
+			c.Reason += " with the " + spec.Option + " option"
+		}
+	case !c.Configured:
+		c.Reason = "not configured"
+	}
+	return c
+}
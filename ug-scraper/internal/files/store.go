@@ -0,0 +1,451 @@
+// Package files keeps exported charts under /data/exports, so other devices
+// can download them after the export that wrote them has finished.
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/fsck"
+)
+
+const (
+	defaultDir       = "/data/exports"
+	defaultBackupDir = "/data/backups"
+	defaultRoute     = "/api/files"
+	defaultRetention = 7 * 24 * time.Hour
+	// pruneInterval is how often expired files are looked for
+	pruneInterval = time.Hour
+	// partialSuffix marks a file still being written
+	partialSuffix = ".partial"
+)
+
+// File statuses
+const (
+	StatusReady   = "ready"
+	StatusPending = "pending" // Still being written
+	StatusFailed  = "failed"
+)
+
+var (
+	// ErrNotFound is returned for a file that doesn't exist
+	ErrNotFound = errors.New("file not found")
+	// ErrInvalidName is returned for names that would leave the directory
+	ErrInvalidName = errors.New("invalid file name")
+	// ErrPending is returned when a file is still being written
+	ErrPending = errors.New("file is still being written")
+)
+
+// Config holds the export directory settings
+type Config struct {
+	Dir       string
+	Retention time.Duration // How long files are kept; 0 keeps them until deleted
+	Route     string        // API path the files are downloaded under, /api/files by default
+}
+
+// ConfigFromEnv reads EXPORTS_DIR (default /data/exports) and
+// EXPORTS_RETENTION_DAYS (default 7, 0 keeps files until deleted)
+func ConfigFromEnv() Config {
+	cfg := Config{Dir: os.Getenv("EXPORTS_DIR"), Retention: defaultRetention}
+	if cfg.Dir == "" {
+		cfg.Dir = defaultDir
+	}
+
+	if v := os.Getenv("EXPORTS_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.ParseFloat(v, 64); err == nil && days >= 0 {
+			cfg.Retention = time.Duration(days * float64(24*time.Hour))
+		} else {
+			fmt.Printf("⚠️  Ignoring EXPORTS_RETENTION_DAYS=%q\n", v)
+		}
+	}
+	return cfg
+}
+
+// BackupConfigFromEnv reads BACKUPS_DIR (default /data/backups) for saved
+// backups, which hold admin-only data and so are kept apart from the exports
+// that readers can download. They expire like exports.
+func BackupConfigFromEnv() Config {
+	cfg := ConfigFromEnv()
+	cfg.Dir = os.Getenv("BACKUPS_DIR")
+	if cfg.Dir == "" {
+		cfg.Dir = defaultBackupDir
+	}
+	cfg.Route = "/api/backups"
+	return cfg
+}
+
+// File describes an exported file
+type File struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size"`
+	ContentType string     `json:"content_type"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	URL         string     `json:"url"` // Download path
+}
+
+// Store writes exports into the directory and lists, serves and expires
+// them. Writes in progress and failed ones are tracked in memory.
+type Store struct {
+	config Config
+	mu     sync.Mutex
+	jobs   map[string]*File // Pending and failed writes by name
+}
+
+// NewStore creates a store for the configured directory
+func NewStore(config Config) *Store {
+	return &Store{
+		config: config,
+		jobs:   make(map[string]*File),
+	}
+}
+
+// Dir returns the export directory
+func (s *Store) Dir() string {
+	return s.config.Dir
+}
+
+// Start removes expired files now and then every hour in the background
+func (s *Store) Start() {
+	if s.config.Retention <= 0 {
+		fmt.Printf("📂 Files kept in %s until deleted\n", s.config.Dir)
+		return
+	}
+
+	fmt.Printf("📂 Files kept in %s for %s\n", s.config.Dir, s.config.Retention)
+	go func() {
+		for {
+			if removed := s.Prune(); removed > 0 {
+				fmt.Printf("🧹 Removed %d expired exports\n", removed)
+			}
+			time.Sleep(pruneInterval)
+		}
+	}()
+}
+
+// Write stores a file under name, or a free variant of it such as
+// "setlist (2).txt", and returns it once write has finished
+func (s *Store) Write(name string, write func(io.Writer) error) (File, error) {
+	file, err := s.reserve(name)
+	if err != nil {
+		return File{}, err
+	}
+	return s.finish(file, s.writeFile(file.Name, write))
+}
+
+// WriteAsync is Write in the background: the file is listed as pending
+// until write finishes, then as ready or failed
+func (s *Store) WriteAsync(name string, write func(io.Writer) error) (File, error) {
+	file, err := s.reserve(name)
+	if err != nil {
+		return File{}, err
+	}
+
+	go func() {
+		if _, err := s.finish(file, s.writeFile(file.Name, write)); err != nil {
+			fmt.Printf("❌ Export %s failed: %v\n", file.Name, err)
+		} else {
+			fmt.Printf("✅ Export %s ready\n", file.Name)
+		}
+	}()
+	return file, nil
+}
+
+// reserve picks a free name and lists it as pending
+func (s *Store) reserve(name string) (File, error) {
+	if !validName(name) {
+		return File{}, ErrInvalidName
+	}
+	if err := os.MkdirAll(s.config.Dir, 0755); err != nil {
+		return File{}, fmt.Errorf("creating export directory: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for n := 2; s.taken(name); n++ {
+		name = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+
+	file := &File{
+		Name:        name,
+		Status:      StatusPending,
+		ContentType: contentType(name),
+		CreatedAt:   time.Now(),
+		URL:         s.downloadURL(name),
+	}
+	s.jobs[name] = file
+	return *file, nil
+}
+
+// taken reports whether name is in use; callers must hold the lock
+func (s *Store) taken(name string) bool {
+	if _, ok := s.jobs[name]; ok {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(s.config.Dir, name))
+	return err == nil
+}
+
+// writeFile writes a file through a partial file, so it is only listed as
+// ready once complete
+func (s *Store) writeFile(name string, write func(io.Writer) error) error {
+	path := filepath.Join(s.config.Dir, name)
+	partial := path + partialSuffix
+
+	f, err := os.Create(partial)
+	if err != nil {
+		return fmt.Errorf("creating export file: %w", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(partial)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("writing export file: %w", err)
+	}
+	return os.Rename(partial, path)
+}
+
+// finish records the outcome of a write
+func (s *Store) finish(file File, err error) (File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		failed := s.jobs[file.Name]
+		failed.Status = StatusFailed
+		failed.Error = err.Error()
+		return *failed, err
+	}
+
+	delete(s.jobs, file.Name)
+	return s.stat(file.Name)
+}
+
+// List returns every export, newest first
+func (s *Store) List() ([]File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading export directory: %w", err)
+	}
+
+	files := make([]File, 0, len(entries)+len(s.jobs))
+	for _, job := range s.jobs {
+		files = append(files, *job)
+	}
+	for _, entry := range entries {
+		if !validName(entry.Name()) || strings.HasSuffix(entry.Name(), partialSuffix) || !entry.Type().IsRegular() {
+			continue
+		}
+		if file, err := s.stat(entry.Name()); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].CreatedAt.Equal(files[j].CreatedAt) {
+			return files[i].CreatedAt.After(files[j].CreatedAt)
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// Open returns the path of a ready file to serve, with its description
+func (s *Store) Open(name string) (string, File, error) {
+	if !validName(name) || strings.HasSuffix(name, partialSuffix) {
+		return "", File{}, ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[name]; ok {
+		if job.Status == StatusPending {
+			return "", *job, ErrPending
+		}
+		return "", *job, ErrNotFound
+	}
+	file, err := s.stat(name)
+	if err != nil {
+		return "", File{}, err
+	}
+	return filepath.Join(s.config.Dir, name), file, nil
+}
+
+// Remove deletes a file, or forgets a failed write
+func (s *Store) Remove(name string) error {
+	if !validName(name) {
+		return ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[name]; ok {
+		if job.Status == StatusPending {
+			return ErrPending
+		}
+		delete(s.jobs, name)
+		return nil
+	}
+
+	err := os.Remove(filepath.Join(s.config.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Prune removes the files and failed writes older than the retention and
+// returns how many it removed
+func (s *Store) Prune() int {
+	if s.config.Retention <= 0 {
+		return 0
+	}
+	cutoff := time.Now().Add(-s.config.Retention)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for name, job := range s.jobs {
+		if job.Status == StatusFailed && job.CreatedAt.Before(cutoff) {
+			delete(s.jobs, name)
+			removed++
+		}
+	}
+
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return removed
+	}
+	for _, entry := range entries {
+		if _, pending := s.jobs[strings.TrimSuffix(entry.Name(), partialSuffix)]; pending || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.Dir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// stat describes a file on disk; callers must hold the lock
+func (s *Store) stat(name string) (File, error) {
+	info, err := os.Stat(filepath.Join(s.config.Dir, name))
+	if errors.Is(err, os.ErrNotExist) || (err == nil && !info.Mode().IsRegular()) {
+		return File{}, ErrNotFound
+	}
+	if err != nil {
+		return File{}, err
+	}
+
+	file := File{
+		Name:        name,
+		Status:      StatusReady,
+		Size:        info.Size(),
+		ContentType: contentType(name),
+		CreatedAt:   info.ModTime(),
+		URL:         s.downloadURL(name),
+	}
+	if s.config.Retention > 0 {
+		expires := info.ModTime().Add(s.config.Retention)
+		file.ExpiresAt = &expires
+	}
+	return file, nil
+}
+
+// validName reports whether name is a plain file name inside the directory
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && filepath.Base(name) == name &&
+		!strings.ContainsAny(name, `/\`) && len(name) <= 255
+}
+
+// contentType guesses a file's media type from its extension
+func contentType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".onsong", ".chordpro", ".cho":
+		return "text/plain; charset=utf-8"
+	case ".musicxml":
+		return "application/vnd.recordare.musicxml+xml"
+	case ".mid":
+		return "audio/midi"
+	}
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// downloadURL is the API path a file is downloaded from
+func (s *Store) downloadURL(name string) string {
+	route := s.config.Route
+	if route == "" {
+		route = defaultRoute
+	}
+	return route + "/" + url.PathEscape(name)
+}
+
+// Check looks for partial files that no write is still working on, which
+// an export interrupted by a restart leaves behind. With repair they are
+// removed.
+func (s *Store) Check(repair bool) ([]fsck.Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.config.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading export directory: %w", err)
+	}
+
+	var issues []fsck.Issue
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), partialSuffix)
+		if name == entry.Name() || !entry.Type().IsRegular() {
+			continue
+		}
+		if job, ok := s.jobs[name]; ok && job.Status == StatusPending {
+			continue
+		}
+		issue := fsck.Issue{
+			Area:       fsck.AreaFiles,
+			Kind:       "orphaned_partial_file",
+			ID:         entry.Name(),
+			Message:    "an export was interrupted before it finished writing",
+			Repairable: true,
+		}
+		if repair && os.Remove(filepath.Join(s.config.Dir, entry.Name())) == nil {
+			issue.Repaired = true
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
@@ -0,0 +1,80 @@
+// Package fsck runs the data integrity checks of the stores that keep state
+// on disk and collects what they found, e.g. after an unclean shutdown left
+// half-written files on an SD card.
+package fsck
+
+import (
+	"time"
+)
+
+// Issue areas
+const (
+	AreaLibrary   = "library"
+	AreaSetlists  = "setlists"
+	AreaRevisions = "revisions"
+	AreaFiles     = "files"
+	AreaIndex     = "index"
+)
+
+// Issue is one problem a check found
+type Issue struct {
+	Area     string `json:"area"`
+	Kind     string `json:"kind"`
+	ID       string `json:"id,omitempty"` // Song, setlist or file concerned
+	Message  string `json:"message"`
+	Repaired bool   `json:"repaired"`
+	// Repairable is false for problems that need a person, such as a
+	// library file that can't be read and has no intact copy
+	Repairable bool `json:"repairable"`
+}
+
+// Check looks for problems in one store, fixing the repairable ones when
+// repair is set. It returns an error only when it couldn't check at all.
+type Check struct {
+	Name string
+	Run  func(repair bool) ([]Issue, error)
+}
+
+// Report is the result of a run
+type Report struct {
+	Repair     bool      `json:"repair"`
+	Checks     []string  `json:"checks"`
+	Issues     []Issue   `json:"issues"`
+	Found      int       `json:"found"`
+	Repaired   int       `json:"repaired"`
+	Errors     []string  `json:"errors,omitempty"` // Checks that couldn't run
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// Clean reports whether nothing is left to fix
+func (r *Report) Clean() bool {
+	return len(r.Errors) == 0 && r.Found == r.Repaired
+}
+
+// Run runs every check in order
+func Run(repair bool, checks ...Check) Report {
+	report := Report{
+		Repair:    repair,
+		Issues:    []Issue{},
+		StartedAt: time.Now(),
+	}
+
+	for _, check := range checks {
+		report.Checks = append(report.Checks, check.Name)
+		issues, err := check.Run(repair)
+		if err != nil {
+			report.Errors = append(report.Errors, check.Name+": "+err.Error())
+		}
+		for _, issue := range issues {
+			report.Found++
+			if issue.Repaired {
+				report.Repaired++
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	report.DurationMS = time.Since(report.StartedAt).Milliseconds()
+	return report
+}
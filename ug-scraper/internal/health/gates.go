@@ -0,0 +1,215 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Startup gate policies
+const (
+	GatePolicyWarn  = "warn"  // Log failing gates loudly and carry on
+	GatePolicyBlock = "block" // Not ready until every gate has passed
+)
+
+const (
+	defaultGateTimeout = 2 * time.Minute
+	// gateAttemptTimeout bounds one check of a gate
+	gateAttemptTimeout = 30 * time.Second
+	// Failing gates are retried from minGateRetry, doubling up to maxGateRetry
+	minGateRetry = 5 * time.Second
+	maxGateRetry = time.Minute
+)
+
+// Gate is a dependency the add-on is useless without, such as FlareSolverr
+// or the webhook target, checked when it starts. Check returns nil once the
+// dependency works.
+type Gate struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// GateReport is the state of a startup gate
+type GateReport struct {
+	Name     string     `json:"name"`
+	Passed   bool       `json:"passed"`
+	Attempts int        `json:"attempts"`
+	Error    string     `json:"error,omitempty"` // Last failure, until it passes
+	PassedAt *time.Time `json:"passed_at,omitempty"`
+}
+
+// GatesReport is the state of every startup gate
+type GatesReport struct {
+	Policy string       `json:"policy"`
+	Ready  bool         `json:"ready"`
+	Gates  []GateReport `json:"gates"`
+}
+
+// Gates checks the startup gates in the background, retrying each until it
+// passes. Gates still failing after the timeout are reported loudly; under
+// the block policy the add-on is not ready until they pass.
+type Gates struct {
+	policy  string
+	timeout time.Duration
+
+	mu    sync.Mutex
+	gates []Gate
+	state map[string]*GateReport
+}
+
+// GatesFromEnv picks the gates named in STARTUP_GATES (comma-separated) from
+// the available ones. STARTUP_GATE_POLICY is warn (default) or block;
+// STARTUP_GATE_TIMEOUT is how many seconds a gate may fail before it is
+// reported (default 120).
+func GatesFromEnv(available []Gate) *Gates {
+	g := &Gates{
+		policy:  GatePolicyWarn,
+		timeout: defaultGateTimeout,
+		state:   make(map[string]*GateReport),
+	}
+
+	switch policy := strings.ToLower(os.Getenv("STARTUP_GATE_POLICY")); policy {
+	case "", GatePolicyWarn:
+	case GatePolicyBlock:
+		g.policy = GatePolicyBlock
+	default:
+		fmt.Printf("⚠️  Unknown STARTUP_GATE_POLICY %q, using %s\n", policy, GatePolicyWarn)
+	}
+	if v := os.Getenv("STARTUP_GATE_TIMEOUT"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			g.timeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	for _, name := range strings.Split(os.Getenv("STARTUP_GATES"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || g.state[name] != nil {
+			continue
+		}
+		found := false
+		for _, gate := range available {
+			if gate.Name == name {
+				g.gates = append(g.gates, gate)
+				g.state[name] = &GateReport{Name: name}
+				found = true
+				break
+			}
+		}
+		if !found {
+			fmt.Printf("⚠️  Unknown startup gate %q in STARTUP_GATES, ignoring it\n", name)
+		}
+	}
+
+	return g
+}
+
+// Start checks every gate in the background
+func (g *Gates) Start() {
+	if len(g.gates) == 0 {
+		return
+	}
+
+	names := make([]string, len(g.gates))
+	for i, gate := range g.gates {
+		names[i] = gate.Name
+	}
+	fmt.Printf("🚦 Startup gates: %s (policy: %s)\n", strings.Join(names, ", "), g.policy)
+
+	for _, gate := range g.gates {
+		go g.run(gate)
+	}
+}
+
+// run retries a gate until it passes
+func (g *Gates) run(gate Gate) {
+	started := time.Now()
+	retry := minGateRetry
+	reported := false
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), gateAttemptTimeout)
+		err := gate.Check(ctx)
+		cancel()
+
+		g.mu.Lock()
+		state := g.state[gate.Name]
+		state.Attempts++
+		if err == nil {
+			now := time.Now()
+			state.Passed, state.PassedAt, state.Error = true, &now, ""
+		} else {
+			state.Error = err.Error()
+		}
+		g.mu.Unlock()
+
+		if err == nil {
+			if reported {
+				fmt.Printf("✅ Startup gate %q passed after %s\n", gate.Name, time.Since(started).Round(time.Second))
+			} else {
+				fmt.Printf("🚦 Startup gate %q passed\n", gate.Name)
+			}
+			return
+		}
+
+		if !reported && time.Since(started) >= g.timeout {
+			reported = true
+			g.reportFailure(gate.Name, err)
+		}
+		time.Sleep(retry)
+		retry = min(retry*2, maxGateRetry)
+	}
+}
+
+// reportFailure logs a gate that is still failing after the timeout
+func (g *Gates) reportFailure(name string, err error) {
+	fmt.Println("🚨 ============================================================")
+	fmt.Printf("🚨 Startup gate %q still failing after %s: %v\n", name, g.timeout, err)
+	if g.policy == GatePolicyBlock {
+		fmt.Println("🚨 The add-on reports itself NOT READY until it passes")
+	} else {
+		fmt.Println("🚨 Carrying on anyway; features depending on it will fail")
+	}
+	fmt.Println("🚨 ============================================================")
+}
+
+// Configured reports whether any gate was declared
+func (g *Gates) Configured() bool {
+	return len(g.gates) > 0
+}
+
+// Ready reports whether the gates let the add-on serve: always under the
+// warn policy, once every gate has passed under the block policy
+func (g *Gates) Ready() bool {
+	if g.policy != GatePolicyBlock {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, state := range g.state {
+		if !state.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Report returns the state of every gate
+func (g *Gates) Report() GatesReport {
+	report := GatesReport{
+		Policy: g.policy,
+		Ready:  g.Ready(),
+		Gates:  make([]GateReport, 0, len(g.gates)),
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, gate := range g.gates {
+		report.Gates = append(report.Gates, *g.state[gate.Name])
+	}
+	return report
+}
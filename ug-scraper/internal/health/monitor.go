@@ -0,0 +1,283 @@
+package health
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCheckInterval = 30 * time.Second
+	// recoverBelow is the score under which a subsystem is restarted
+	recoverBelow = 0.5
+	// Failed recoveries back off from minBackoff, doubling up to maxBackoff
+	minBackoff = 30 * time.Second
+	maxBackoff = 10 * time.Minute
+	// maxActions is how many recovery actions are kept for reporting
+	maxActions = 50
+)
+
+// Subsystem statuses
+const (
+	StatusHealthy  = "healthy"  // Score of 0.8 or more
+	StatusDegraded = "degraded" // Working, but with failures
+	StatusDown     = "down"     // Score of 0
+	StatusDisabled = "disabled" // Not configured; left out of the overall score
+)
+
+// Check is the outcome of one subsystem check
+type Check struct {
+	Score    float64 // 0 (down) to 1 (fully healthy)
+	Detail   string
+	Disabled bool // Not configured
+}
+
+// Subsystem is a part of the add-on the monitor watches. Recover restarts
+// just that part and describes what it did; it may be nil when nothing can
+// be done from inside the add-on.
+type Subsystem struct {
+	Name    string
+	Weight  float64 // Share of the overall score
+	Check   func() Check
+	Recover func() (string, error)
+}
+
+// SubsystemReport is the last known state of a subsystem
+type SubsystemReport struct {
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	Score        float64    `json:"score"`
+	Weight       float64    `json:"weight"`
+	Detail       string     `json:"detail,omitempty"`
+	CheckedAt    time.Time  `json:"checked_at"`
+	Recoveries   int        `json:"recoveries"`
+	LastRecovery *time.Time `json:"last_recovery,omitempty"`
+}
+
+// RecoveryAction records an automatic restart attempt
+type RecoveryAction struct {
+	Time      time.Time `json:"time"`
+	Subsystem string    `json:"subsystem"`
+	Reason    string    `json:"reason"`
+	Action    string    `json:"action"`
+	Succeeded bool      `json:"succeeded"` // The restart itself worked
+	Recovered bool      `json:"recovered"` // The subsystem was healthy again right after
+	Error     string    `json:"error,omitempty"`
+}
+
+// Report is the weighted health of every subsystem
+type Report struct {
+	Status     string            `json:"status"`
+	Score      float64           `json:"score"`
+	Subsystems []SubsystemReport `json:"subsystems"`
+	Recoveries []RecoveryAction  `json:"recoveries"`
+}
+
+// watched is a registered subsystem and its monitoring state
+type watched struct {
+	Subsystem
+	report      SubsystemReport
+	nextAttempt time.Time
+	backoff     time.Duration
+}
+
+// Monitor checks subsystems on a schedule and restarts the ones that
+// degrade, so a dropped MQTT connection or a dead worker heals itself
+// without restarting the add-on
+type Monitor struct {
+	interval time.Duration
+
+	mu         sync.Mutex
+	subsystems []*watched
+	actions    []RecoveryAction
+	checking   sync.Mutex // Serialises check passes
+	stop       chan struct{}
+}
+
+// NewMonitor creates a monitor configured from HEALTH_CHECK_INTERVAL (a Go
+// duration, "0" disables the schedule; checks still run on deep health
+// requests)
+func NewMonitor() *Monitor {
+	interval := defaultCheckInterval
+	if v := os.Getenv("HEALTH_CHECK_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			interval = d
+		} else if v == "0" {
+			interval = 0
+		}
+	}
+
+	return &Monitor{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register adds a subsystem to watch
+func (m *Monitor) Register(s Subsystem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subsystems = append(m.subsystems, &watched{
+		Subsystem: s,
+		report:    SubsystemReport{Name: s.Name, Status: StatusHealthy, Score: 1, Weight: s.Weight},
+		backoff:   minBackoff,
+	})
+}
+
+// Start runs checks on the schedule in the background
+func (m *Monitor) Start() {
+	if m.interval <= 0 {
+		fmt.Println("🩺 Health monitor schedule disabled")
+		return
+	}
+
+	fmt.Printf("🩺 Health monitor checking every %s\n", m.interval)
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.CheckNow()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background schedule
+func (m *Monitor) Stop() {
+	close(m.stop)
+}
+
+// CheckNow checks every subsystem, restarts degraded ones whose backoff has
+// passed and returns the resulting report
+func (m *Monitor) CheckNow() Report {
+	m.checking.Lock()
+	defer m.checking.Unlock()
+
+	m.mu.Lock()
+	subsystems := append([]*watched(nil), m.subsystems...)
+	m.mu.Unlock()
+
+	for _, w := range subsystems {
+		check := w.Check()
+		now := time.Now()
+
+		if !check.Disabled && check.Score < recoverBelow && w.Recover != nil && !now.Before(w.nextAttempt) {
+			check = m.recover(w, check, now)
+		}
+
+		m.mu.Lock()
+		w.report.Score = round(check.Score)
+		w.report.Detail = check.Detail
+		w.report.Status = status(check)
+		w.report.CheckedAt = now
+		m.mu.Unlock()
+	}
+
+	return m.Report()
+}
+
+// recover restarts a degraded subsystem, records the action and returns the
+// subsystem's state afterwards. Restarts that leave it degraded back off.
+func (m *Monitor) recover(w *watched, check Check, now time.Time) Check {
+	fmt.Printf("🩺 %s degraded (score %.2f: %s), restarting it\n", w.Name, check.Score, check.Detail)
+
+	action, err := w.Recover()
+	after := w.Check()
+	record := RecoveryAction{
+		Time:      now,
+		Subsystem: w.Name,
+		Reason:    check.Detail,
+		Action:    action,
+		Succeeded: err == nil,
+		Recovered: err == nil && after.Score >= recoverBelow,
+	}
+
+	switch {
+	case err != nil:
+		record.Error = err.Error()
+		fmt.Printf("⚠️  Restarting %s failed: %v\n", w.Name, err)
+	case record.Recovered:
+		fmt.Printf("✅ %s: %s\n", w.Name, action)
+	default:
+		fmt.Printf("⚠️  %s: %s, still %s\n", w.Name, action, after.Detail)
+	}
+
+	if record.Recovered {
+		w.nextAttempt = now.Add(minBackoff)
+		w.backoff = minBackoff
+	} else {
+		w.nextAttempt = now.Add(w.backoff)
+		w.backoff = min(w.backoff*2, maxBackoff)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.report.Recoveries++
+	w.report.LastRecovery = &now
+	m.actions = append(m.actions, record)
+	if len(m.actions) > maxActions {
+		m.actions = m.actions[len(m.actions)-maxActions:]
+	}
+
+	return after
+}
+
+// Report returns the last check results without running new checks
+func (m *Monitor) Report() Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := Report{
+		Subsystems: make([]SubsystemReport, len(m.subsystems)),
+		Recoveries: make([]RecoveryAction, 0, len(m.actions)),
+	}
+
+	var weighted, weights float64
+	for i, w := range m.subsystems {
+		report.Subsystems[i] = w.report
+		if w.report.Status != StatusDisabled {
+			weighted += w.report.Score * w.Weight
+			weights += w.Weight
+		}
+	}
+
+	// Newest action first
+	for i := len(m.actions) - 1; i >= 0; i-- {
+		report.Recoveries = append(report.Recoveries, m.actions[i])
+	}
+
+	report.Score = 1
+	if weights > 0 {
+		report.Score = round(weighted / weights)
+	}
+	report.Status = status(Check{Score: report.Score})
+
+	return report
+}
+
+// status names the state a check score stands for
+func status(check Check) string {
+	switch {
+	case check.Disabled:
+		return StatusDisabled
+	case check.Score >= 0.8:
+		return StatusHealthy
+	case check.Score > 0:
+		return StatusDegraded
+	default:
+		return StatusDown
+	}
+}
+
+// round keeps scores to two decimals
+func round(score float64) float64 {
+	return float64(int(score*100+0.5)) / 100
+}
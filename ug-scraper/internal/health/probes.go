@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// probeTimeout bounds one probe of a deep health check
+const probeTimeout = 10 * time.Second
+
+// Probe statuses
+const (
+	ProbeOK      = "ok"
+	ProbeFailed  = "failed"
+	ProbeSkipped = "skipped" // The dependency isn't configured
+)
+
+// ErrNotConfigured is returned, possibly wrapped, by probes whose
+// dependency isn't set up; they are reported as skipped rather than failed
+var ErrNotConfigured = errors.New("not configured")
+
+// Probe actively checks one dependency on a deep health check, such as
+// whether the UG API answers. Check returns what it found out.
+type Probe struct {
+	Name  string
+	Check func(ctx context.Context) (string, error)
+}
+
+// ProbeResult is the outcome of one probe
+type ProbeResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// RunProbes runs the probes at the same time, each bounded by probeTimeout,
+// and returns their results in the order given
+func RunProbes(ctx context.Context, probes []Probe) []ProbeResult {
+	results := make([]ProbeResult, len(probes))
+
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runProbe(ctx, probe)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// runProbe runs and times one probe
+func runProbe(ctx context.Context, probe Probe) ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	started := time.Now()
+	detail, err := probe.Check(ctx)
+	latency := time.Since(started)
+
+	result := ProbeResult{
+		Name:      probe.Name,
+		Status:    ProbeOK,
+		LatencyMS: math.Round(float64(latency.Microseconds())/10) / 100,
+		Detail:    detail,
+	}
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		result.Status = ProbeSkipped
+		result.Detail = err.Error()
+	case err != nil:
+		result.Status = ProbeFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ProbesFailed reports whether any probe failed
+func ProbesFailed(results []ProbeResult) bool {
+	for _, result := range results {
+		if result.Status == ProbeFailed {
+			return true
+		}
+	}
+	return false
+}
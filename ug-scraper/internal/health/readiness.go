@@ -0,0 +1,71 @@
+package health
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Startup steps the add-on needs before it can serve requests
+const (
+	StepConfig  = "config"  // Settings loaded and the webhook config store open
+	StepStorage = "storage" // Library, profiles, keys and the other data files loaded
+	StepRoutes  = "routes"  // Every route registered and the server listening
+)
+
+// Readiness tracks the startup steps. Unlike health, which says whether the
+// add-on is alive, it says whether the add-on can serve requests yet.
+type Readiness struct {
+	mu      sync.Mutex
+	steps   []string
+	done    map[string]bool
+	readyAt *time.Time
+}
+
+// ReadinessReport is whether every startup step is done, and which are not
+type ReadinessReport struct {
+	Ready   bool       `json:"ready"`
+	Pending []string   `json:"pending,omitempty"`
+	ReadyAt *time.Time `json:"ready_at,omitempty"`
+}
+
+// NewReadiness creates a tracker waiting for the given steps
+func NewReadiness(steps ...string) *Readiness {
+	return &Readiness{
+		steps: steps,
+		done:  make(map[string]bool),
+	}
+}
+
+// Done marks a startup step as done
+func (r *Readiness) Done(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done[step] || !slices.Contains(r.steps, step) {
+		return
+	}
+	r.done[step] = true
+
+	if len(r.done) == len(r.steps) {
+		now := time.Now()
+		r.readyAt = &now
+		fmt.Println("✅ Ready to serve requests")
+	}
+}
+
+// Report returns whether every step is done
+func (r *Readiness) Report() ReadinessReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := ReadinessReport{ReadyAt: r.readyAt}
+	for _, step := range r.steps {
+		if !r.done[step] {
+			report.Pending = append(report.Pending, step)
+		}
+	}
+	report.Ready = len(report.Pending) == 0
+	return report
+}
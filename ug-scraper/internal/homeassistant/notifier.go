@@ -0,0 +1,226 @@
+package homeassistant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+)
+
+const (
+	defaultCoreAPIURL = "http://supervisor/core/api"
+	eventPrefix       = "ug_scraper_"
+)
+
+// Notification modes
+const (
+	ModeOff          = "off"
+	ModeNotification = "notification"
+	ModeEvent        = "event"
+	ModeBoth         = "both"
+)
+
+// Config controls which add-on events are forwarded to Home Assistant
+type Config struct {
+	APIURL string // Core API base URL, reached through the Supervisor proxy
+	Token  string // SUPERVISOR_TOKEN, injected by the Supervisor
+	Mode   string // off, notification, event or both
+
+	NotifyConversions     bool
+	NotifyWebhookFailures bool
+	NotifyGigChanges      bool // Charts that changed on UG shortly before a gig they're in
+}
+
+// ConfigFromEnv reads HA_NOTIFY_MODE, HA_NOTIFY_CONVERSIONS,
+// HA_NOTIFY_WEBHOOK_FAILURES and HA_NOTIFY_GIG_CHANGES. SUPERVISOR_TOKEN is
+// set automatically when the add-on has homeassistant_api access.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		APIURL:                os.Getenv("HA_API_URL"),
+		Token:                 os.Getenv("SUPERVISOR_TOKEN"),
+		Mode:                  strings.ToLower(os.Getenv("HA_NOTIFY_MODE")),
+		NotifyConversions:     os.Getenv("HA_NOTIFY_CONVERSIONS") == "true",
+		NotifyWebhookFailures: os.Getenv("HA_NOTIFY_WEBHOOK_FAILURES") != "false",
+		NotifyGigChanges:      os.Getenv("HA_NOTIFY_GIG_CHANGES") != "false",
+	}
+
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultCoreAPIURL
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeOff
+	}
+
+	return cfg
+}
+
+// Notifier forwards add-on events to Home Assistant as persistent
+// notifications and/or custom events (ug_scraper_<event>)
+type Notifier struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// NewNotifier creates a new Home Assistant notifier
+func NewNotifier(cfg Config) *Notifier {
+	return &Notifier{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether notifications are configured and the API is reachable
+func (n *Notifier) Enabled() bool {
+	return n != nil && n.config.Mode != ModeOff && n.config.Token != ""
+}
+
+// PublishEvent implements events.Publisher
+func (n *Notifier) PublishEvent(name string, data interface{}) {
+	if !n.Enabled() || !n.wants(name) {
+		return
+	}
+
+	attrs := toMap(data)
+
+	if n.config.Mode == ModeEvent || n.config.Mode == ModeBoth {
+		if err := n.FireEvent(eventPrefix+name, attrs); err != nil {
+			fmt.Printf("⚠️  Home Assistant event %s not fired: %v\n", name, err)
+		}
+	}
+
+	if n.config.Mode == ModeNotification || n.config.Mode == ModeBoth {
+		title, message := describe(name, attrs)
+		if err := n.CreateNotification(title, message, notificationID(name, attrs)); err != nil {
+			fmt.Printf("⚠️  Home Assistant notification not created: %v\n", err)
+		}
+	}
+}
+
+// wants reports whether an event is configured to be forwarded
+func (n *Notifier) wants(name string) bool {
+	switch name {
+	case events.TabConverted:
+		return n.config.NotifyConversions
+	case events.WebhookFailed:
+		return n.config.NotifyWebhookFailures
+	case events.GigChartChanged:
+		return n.config.NotifyGigChanges
+	default:
+		return false
+	}
+}
+
+// CreateNotification creates (or replaces, by notificationID) a persistent notification
+func (n *Notifier) CreateNotification(title, message, notificationID string) error {
+	return n.post("/services/persistent_notification/create", map[string]string{
+		"title":           title,
+		"message":         message,
+		"notification_id": notificationID,
+	})
+}
+
+// FireEvent fires a custom event on the Home Assistant event bus
+func (n *Notifier) FireEvent(eventType string, data map[string]interface{}) error {
+	return n.post("/events/"+eventType, data)
+}
+
+// post sends an authenticated JSON request to the Core API
+func (n *Notifier) post(path string, body interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", n.config.APIURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+n.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Home Assistant returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// describe builds a notification title and message for an event
+func describe(name string, attrs map[string]interface{}) (string, string) {
+	song := fmt.Sprintf("%v - %v", attrs["artist"], attrs["title"])
+
+	switch name {
+	case events.TabConverted:
+		message := fmt.Sprintf("**%s** was converted to OnSong format.", song)
+		if key, ok := attrs["key"].(string); ok && key != "" {
+			message += fmt.Sprintf(" Key: %s.", key)
+		}
+		return "Tab converted", message
+	case events.WebhookFailed:
+		return "Webhook delivery failed", fmt.Sprintf("Sending **%s** to the webhook failed: %v", song, attrs["error"])
+	case events.GigChartChanged:
+		return describeGigChange(song, attrs)
+	default:
+		return "Ultimate Guitar Scraper", name
+	}
+}
+
+// describeGigChange summarizes a chart change for the band leader: the gigs
+// the song is in, how much changed and the first changed lines
+func describeGigChange(song string, attrs map[string]interface{}) (string, string) {
+	var gigs []string
+	title := "Chart changed before a gig"
+	setlists, _ := attrs["setlists"].([]interface{})
+	for i, raw := range setlists {
+		setlist, _ := raw.(map[string]interface{})
+		gigs = append(gigs, fmt.Sprintf("**%v** (%v)", setlist["name"], setlist["date"]))
+		if i == 0 {
+			title = fmt.Sprintf("Chart changed before %v", setlist["name"])
+		}
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "**%s** changed on Ultimate Guitar (%v lines added, %v removed) and is in %s.",
+		song, attrs["added"], attrs["removed"], strings.Join(gigs, ", "))
+	if changed, _ := attrs["changed"].([]interface{}); len(changed) > 0 {
+		message.WriteString("\n\n```diff\n")
+		for _, line := range changed {
+			fmt.Fprintf(&message, "%v\n", line)
+		}
+		message.WriteString("```")
+	}
+	message.WriteString("\n\nReview it before the gig: accept or ignore the update under library updates.")
+	return title, message.String()
+}
+
+// notificationID identifies the persistent notification an event replaces.
+// Gig alerts get one per song so alerts for several songs stay visible.
+func notificationID(name string, attrs map[string]interface{}) string {
+	if id, ok := attrs["id"].(string); ok && name == events.GigChartChanged {
+		return eventPrefix + name + "_" + id
+	}
+	return eventPrefix + name
+}
+
+// toMap converts event data (any JSON-serializable value) to a map
+func toMap(data interface{}) map[string]interface{} {
+	attrs := make(map[string]interface{})
+	if raw, err := json.Marshal(data); err == nil {
+		_ = json.Unmarshal(raw, &attrs)
+	}
+	return attrs
+}
@@ -0,0 +1,121 @@
+// Package httpclient builds the HTTP clients used for outbound requests
+// from timeouts and connection pool settings read from the environment.
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the timeouts and connection pool settings of one client
+type Config struct {
+	Timeout               time.Duration // Whole request including the body; 0 means none
+	DialTimeout           time.Duration // Establishing the TCP connection
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration // Waiting for headers after the request is sent; 0 means none
+	KeepAlive             bool          // Reuse connections between requests
+	IdleConnTimeout       time.Duration // How long an unused pooled connection is kept
+	MaxIdleConns          int           // Pooled connections across all hosts
+	MaxIdleConnsPerHost   int
+}
+
+// Defaults returns the settings used when nothing is configured, with the
+// given overall timeout
+func Defaults(timeout time.Duration) Config {
+	return Config{
+		Timeout:             timeout,
+		DialTimeout:         30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		KeepAlive:           true,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+	}
+}
+
+// ConfigFromEnv reads <prefix>_TIMEOUT, <prefix>_DIAL_TIMEOUT,
+// <prefix>_TLS_TIMEOUT, <prefix>_RESPONSE_HEADER_TIMEOUT, <prefix>_KEEP_ALIVE,
+// <prefix>_IDLE_TIMEOUT, <prefix>_MAX_IDLE_CONNS and
+// <prefix>_MAX_IDLE_CONNS_PER_HOST, falling back to the same HTTP_* variable
+// and then to defaults. Durations are Go durations ("45s") or whole seconds.
+func ConfigFromEnv(prefix string, defaults Config) Config {
+	cfg := defaults
+	cfg.Timeout = envDuration(prefix, "TIMEOUT", cfg.Timeout)
+	cfg.DialTimeout = envDuration(prefix, "DIAL_TIMEOUT", cfg.DialTimeout)
+	cfg.TLSHandshakeTimeout = envDuration(prefix, "TLS_TIMEOUT", cfg.TLSHandshakeTimeout)
+	cfg.ResponseHeaderTimeout = envDuration(prefix, "RESPONSE_HEADER_TIMEOUT", cfg.ResponseHeaderTimeout)
+	cfg.IdleConnTimeout = envDuration(prefix, "IDLE_TIMEOUT", cfg.IdleConnTimeout)
+	cfg.MaxIdleConns = envInt(prefix, "MAX_IDLE_CONNS", cfg.MaxIdleConns)
+	cfg.MaxIdleConnsPerHost = envInt(prefix, "MAX_IDLE_CONNS_PER_HOST", cfg.MaxIdleConnsPerHost)
+	if v := lookup(prefix, "KEEP_ALIVE"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.KeepAlive = b
+		}
+	}
+	return cfg
+}
+
+// Transport returns a transport with the configured timeouts and pool
+func (c Config) Transport() *http.Transport {
+	keepAlive := 30 * time.Second
+	if !c.KeepAlive {
+		keepAlive = -1
+	}
+	dialer := &net.Dialer{Timeout: c.DialTimeout, KeepAlive: keepAlive}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   c.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		DisableKeepAlives:     !c.KeepAlive,
+		IdleConnTimeout:       c.IdleConnTimeout,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+	}
+}
+
+// Client returns a client using the configured timeout and transport
+func (c Config) Client() *http.Client {
+	return &http.Client{
+		Timeout:   c.Timeout,
+		Transport: c.Transport(),
+	}
+}
+
+// lookup returns <prefix>_<name>, or HTTP_<name> when that is unset
+func lookup(prefix, name string) string {
+	if v := strings.TrimSpace(os.Getenv(prefix + "_" + name)); v != "" {
+		return v
+	}
+	return strings.TrimSpace(os.Getenv("HTTP_" + name))
+}
+
+// envDuration parses a duration setting; plain numbers are seconds
+func envDuration(prefix, name string, fallback time.Duration) time.Duration {
+	v := lookup(prefix, name)
+	if v == "" {
+		return fallback
+	}
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return d
+	}
+	return fallback
+}
+
+// envInt parses a non-negative count setting
+func envInt(prefix, name string, fallback int) int {
+	if n, err := strconv.Atoi(lookup(prefix, name)); err == nil && n >= 0 {
+		return n
+	}
+	return fallback
+}
@@ -0,0 +1,215 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+const (
+	// maxArchiveFileSize skips entries larger than any real tab page
+	maxArchiveFileSize = 5 << 20
+	// StatusSkipped marks archive entries that were not importable
+	StatusSkipped = "skipped"
+)
+
+// keyLineRegex reads the detected key back out of a formatted OnSong header
+var keyLineRegex = regexp.MustCompile(`(?m)^Key: *(\S+)$`)
+
+// ArchiveItem reports what happened to one file of an archive import
+type ArchiveItem struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	SongID string `json:"song_id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Artist string `json:"artist,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	Duplicates []string `json:"duplicates,omitempty"` // See ItemResult
+}
+
+// ImportArchive imports saved UG pages (.html/.htm), OnSong/ChordPro charts
+// and text tabs (.txt) from a zip archive. Everything is parsed offline; HTML
+// pages keep their UG source, other files are stored without one so the
+// source matcher can find them later.
+func (p *Pipeline) ImportArchive(data []byte) ([]ArchiveItem, error) {
+	items, err := p.ArchiveItems(data)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Collect(items), nil
+}
+
+// ArchiveItems is ImportArchive importing each file as the sequence is
+// ranged over, so results can be handed on one at a time. The zip is opened
+// up front; stopping early leaves the remaining files unimported.
+func (p *Pipeline) ArchiveItems(data []byte) (iter.Seq[ArchiveItem], error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading zip: %w", err)
+	}
+
+	return func(yield func(ArchiveItem) bool) {
+		for _, f := range zr.File {
+			name := f.Name
+			base := path.Base(name)
+			if f.FileInfo().IsDir() || strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") {
+				continue
+			}
+
+			if !yield(p.importArchiveEntry(f)) {
+				return
+			}
+		}
+	}, nil
+}
+
+// importArchiveEntry imports one file of an archive
+func (p *Pipeline) importArchiveEntry(f *zip.File) ArchiveItem {
+	name := f.Name
+	base := path.Base(name)
+	item := ArchiveItem{File: name}
+	ext := strings.ToLower(path.Ext(base))
+	isPage := ext == ".html" || ext == ".htm"
+	if !isPage && !IsSongFile(base) {
+		item.Status = StatusSkipped
+		item.Error = "unsupported file type"
+		return item
+	}
+
+	content, err := readArchiveFile(f)
+	if err != nil {
+		item.Status = StatusFailed
+		item.Error = err.Error()
+		return item
+	}
+
+	if isPage {
+		return p.importSavedPage(item, content)
+	}
+	return p.ImportSongFile(name, content, false)
+}
+
+// readArchiveFile reads a zip entry, refusing oversized files
+func readArchiveFile(f *zip.File) (string, error) {
+	if f.UncompressedSize64 > maxArchiveFileSize {
+		return "", fmt.Errorf("file is larger than %d bytes", maxArchiveFileSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("opening file: %w", err)
+	}
+	defer rc.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(rc, maxArchiveFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	if len(raw) > maxArchiveFileSize {
+		return "", fmt.Errorf("file is larger than %d bytes", maxArchiveFileSize)
+	}
+
+	return string(raw), nil
+}
+
+// importSavedPage imports a saved UG tab page
+func (p *Pipeline) importSavedPage(item ArchiveItem, page string) ArchiveItem {
+	tab, err := scraper.ParseTabPage(page)
+	if err != nil {
+		if results := scraper.ParseSearchPage(page); len(results) > 0 {
+			item.Status = StatusSkipped
+			item.Error = fmt.Sprintf("search results page listing %d tabs; import them by URL instead", len(results))
+			return item
+		}
+		item.Status = StatusFailed
+		item.Error = err.Error()
+		return item
+	}
+
+	if tab.SongName == "" {
+		item.Status = StatusFailed
+		item.Error = "could not find the song title in the page"
+		return item
+	}
+	if tab.ArtistName == "" {
+		tab.ArtistName = "Unknown Artist"
+	}
+
+	song, existing, duplicates, err := p.storeTab(tab, "")
+	if err != nil {
+		item.Status = StatusFailed
+		item.Error = err.Error()
+		return item
+	}
+
+	item.Status = StatusImported
+	if existing {
+		item.Status = StatusExisting
+	}
+	item.SongID = song.ID
+	item.Title = song.Title
+	item.Artist = song.Artist
+	item.Duplicates = duplicates
+	return item
+}
+
+// importTextTab imports a plain text tab, taking the artist and title from
+// an "Artist - Title.txt" file name
+func (p *Pipeline) importTextTab(item ArchiveItem, filename, content string) ArchiveItem {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	stem := strings.TrimSpace(strings.TrimSuffix(filename, path.Ext(filename)))
+	title, artist := stem, "Unknown Artist"
+	if a, t, ok := strings.Cut(stem, " - "); ok {
+		artist, title = strings.TrimSpace(a), strings.TrimSpace(t)
+	}
+
+	if strings.TrimSpace(content) == "" {
+		item.Status = StatusFailed
+		item.Error = "file is empty"
+		return item
+	}
+
+	song := &library.Song{
+		Title:        title,
+		Artist:       artist,
+		Source:       library.SourceManual,
+		Content:      content,
+		OnSongFormat: p.converter.FormatManualContent(title, artist, content),
+	}
+	if m := keyLineRegex.FindStringSubmatch(song.OnSongFormat); m != nil {
+		song.Key = m[1]
+	}
+
+	link, duplicates := p.equivalents(song.Artist, song.Title)
+	if link != nil {
+		item.Status = StatusExisting
+		item.SongID = link.ID
+		item.Title = link.Title
+		item.Artist = link.Artist
+		return item
+	}
+
+	if err := p.library.Save(song); err != nil {
+		item.Status = StatusFailed
+		item.Error = fmt.Sprintf("saving song: %v", err)
+		return item
+	}
+
+	item.Status = StatusImported
+	item.SongID = song.ID
+	item.Title = song.Title
+	item.Artist = song.Artist
+	item.Duplicates = duplicates
+	return item
+}
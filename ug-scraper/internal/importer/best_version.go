@@ -0,0 +1,414 @@
+package importer
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/sharefolder"
+)
+
+// SongRequest identifies a song to be resolved and imported into the library
+type SongRequest struct {
+	Artist       string `json:"artist"`
+	Title        string `json:"title"`
+	PreferredKey string `json:"preferred_key,omitempty"`
+}
+
+// Item statuses reported by the pipeline
+const (
+	StatusImported = "imported"
+	StatusExisting = "existing"
+	StatusReview   = "review"
+	StatusFailed   = "failed"
+)
+
+// ItemResult reports what happened to a single song request
+type ItemResult struct {
+	Request  SongRequest `json:"request"`
+	Status   string      `json:"status"`
+	SongID   string      `json:"song_id,omitempty"`
+	ReviewID string      `json:"review_id,omitempty"`
+	TabID    string      `json:"tab_id,omitempty"`
+	Error    string      `json:"error,omitempty"`
+
+	// Duplicates are library songs that look like the same song under
+	// another spelling, kept apart by the DuplicatesWarn policy
+	Duplicates []string `json:"duplicates,omitempty"`
+}
+
+// Pipeline finds the best available version of a song, converts it and
+// stores it in the library. Requests that cannot be matched confidently are
+// placed in the library review queue instead.
+type Pipeline struct {
+	searchScraper *scraper.SearchScraper
+	ugClient      *scraper.UGClient
+	converter     *converter.OnSongConverter
+	library       *library.Store
+	share         *sharefolder.Writer
+	scores        ScoreModel
+	duplicates    string
+}
+
+// NewPipeline creates a new best-version import pipeline
+func NewPipeline(
+	searchScraper *scraper.SearchScraper,
+	ugClient *scraper.UGClient,
+	conv *converter.OnSongConverter,
+	store *library.Store,
+	share *sharefolder.Writer,
+) *Pipeline {
+	return &Pipeline{
+		searchScraper: searchScraper,
+		ugClient:      ugClient,
+		converter:     conv,
+		library:       store,
+		share:         share,
+		scores:        DefaultScoreModel{},
+		duplicates:    DuplicatesWarn,
+	}
+}
+
+// WithScoreModel makes the pipeline pick versions with the given model
+func (p *Pipeline) WithScoreModel(model ScoreModel) *Pipeline {
+	p.scores = model
+	return p
+}
+
+// Import resolves a song request to the best UG version and saves it.
+// In strict mode both title and artist must match exactly (after
+// normalization); anything less goes to the review queue.
+func (p *Pipeline) Import(ctx context.Context, req SongRequest, strict bool) ItemResult {
+	result := ItemResult{Request: req}
+
+	if req.Title == "" {
+		result.Status = StatusFailed
+		result.Error = "title is required"
+		return result
+	}
+
+	query := strings.TrimSpace(req.Artist + " " + req.Title)
+	candidates, err := p.searchScraper.SearchTabs(ctx, scraper.SearchOptions{Query: query})
+	if ctx.Err() != nil {
+		// The caller gave up; nothing was decided about this song
+		result.Status = StatusFailed
+		result.Error = ctx.Err().Error()
+		return result
+	}
+	if err != nil || len(candidates) == 0 {
+		reason := "no search results"
+		if err != nil {
+			reason = fmt.Sprintf("search failed: %v", err)
+		}
+		return p.queueForReview(result, reason, nil)
+	}
+
+	ranked := RankVersions(req, candidates, minConfidence(strict), p.scores)
+	if len(ranked) == 0 {
+		return p.queueForReview(result, "no confident match", candidates)
+	}
+
+	tab, best, err := p.fetchVersion(ctx, ranked)
+	result.TabID = best.ID
+	if ctx.Err() != nil {
+		result.Status = StatusFailed
+		result.Error = ctx.Err().Error()
+		return result
+	}
+	if err != nil {
+		return p.queueForReview(result, fmt.Sprintf("fetching tab %s failed: %v", best.ID, err), candidates)
+	}
+
+	song, existing, duplicates, err := p.storeTab(tab, req.PreferredKey)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = StatusImported
+	if existing {
+		result.Status = StatusExisting
+	}
+	result.SongID = song.ID
+	result.Duplicates = duplicates
+	return result
+}
+
+// maxDistrustedSkips is how many versions by distrusted contributors an
+// import passes over before it takes the next version anyway
+const maxDistrustedSkips = 2
+
+// fetchVersion fetches the best of the ranked versions. A version by a
+// contributor the score model distrusts is passed over for the next one, as
+// long as there is one, since the contributor is only known once the tab
+// has been fetched.
+func (p *Pipeline) fetchVersion(ctx context.Context, ranked []RankedVersion) (*scraper.TabResult, scraper.SearchResult, error) {
+	for i, version := range ranked {
+		tab, err := p.ugClient.GetTabByID(ctx, version.Result.ID)
+		if err != nil {
+			return nil, version.Result, err
+		}
+
+		last := i == len(ranked)-1 || i == maxDistrustedSkips
+		if last || !p.scores.Distrusted(tab.Contributor.Username) {
+			return tab, version.Result, nil
+		}
+		fmt.Printf("⏭️  Passing over tab %s by %s, whose charts were marked wrong\n", version.Result.ID, tab.Contributor.Username)
+	}
+
+	return nil, scraper.SearchResult{}, fmt.Errorf("no version to fetch")
+}
+
+// ImportTab fetches a tab by ID and saves it to the library. existing is
+// true when the tab, or with DuplicatesLink an equivalent song, was already
+// in the library; nothing is changed then. duplicates lists the equivalent
+// songs the new one was saved next to.
+func (p *Pipeline) ImportTab(ctx context.Context, tabID string) (song *library.Song, existing bool, duplicates []string, err error) {
+	tab, err := p.ugClient.GetTabByID(ctx, tabID)
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("fetching tab %s failed: %w", tabID, err)
+	}
+
+	return p.storeTab(tab, "")
+}
+
+// storeTab converts a fetched tab and saves it, unless a song from the same
+// tab, or one to link to under the duplicates policy, is already in the
+// library
+func (p *Pipeline) storeTab(tab *scraper.TabResult, preferredKey string) (*library.Song, bool, []string, error) {
+	if existing, ok := p.library.FindBySource(tab.TabID); ok {
+		return existing, true, nil, nil
+	}
+	link, duplicates := p.equivalents(tab.ArtistName, tab.SongName)
+	if link != nil {
+		return link, true, nil, nil
+	}
+
+	song, err := p.convertTab(tab)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	song.PreferredKey = preferredKey
+
+	if err := p.library.Save(song); err != nil {
+		return nil, false, nil, fmt.Errorf("saving song: %w", err)
+	}
+
+	p.share.Save(sharefolder.Entry{
+		TabID:   song.SourceTabID,
+		Title:   song.Title,
+		Artist:  song.Artist,
+		Key:     song.Key,
+		Type:    string(song.Type),
+		Content: song.OnSongFormat,
+	})
+
+	return song, false, duplicates, nil
+}
+
+// convertTab validates and converts a fetched tab into a library song.
+// Its errors are permanent: the same tab fails the same way again.
+func (p *Pipeline) convertTab(tab *scraper.TabResult) (*library.Song, error) {
+	if err := p.converter.ValidateTab(tab); err != nil {
+		return nil, &permanentError{fmt.Errorf("invalid tab data: %w", err)}
+	}
+
+	converted, err := p.converter.ConvertByType(tab)
+	if err != nil {
+		return nil, &permanentError{fmt.Errorf("conversion failed: %w", err)}
+	}
+	if converted.Format == converter.FormatBinary {
+		return nil, &permanentError{fmt.Errorf("Guitar Pro tab %d is a binary file and can't be stored in the library", tab.TabID)}
+	}
+	// Piano charts are stored at sounding pitch, with no capo
+	capo := tab.Capo
+	if converted.Profile == converter.ProfilePiano {
+		capo = 0
+	}
+
+	return &library.Song{
+		Title:        tab.SongName,
+		Artist:       tab.ArtistName,
+		Key:          converted.DetectedKey,
+		Capo:         capo,
+		Tuning:       tab.Tuning,
+		Type:         tab.Type,
+		Source:       library.SourceUltimateGuitar,
+		SourceTabID:  tab.TabID,
+		SourceURL:    tab.URLWeb,
+		Contributor:  tab.Contributor.Username,
+		Rating:       tab.Rating,
+		Votes:        tab.Votes,
+		Content:      tab.Content,
+		OnSongFormat: converted.OnSongFormat,
+	}, nil
+}
+
+// queueForReview records the request in the review queue
+func (p *Pipeline) queueForReview(result ItemResult, reason string, candidates []scraper.SearchResult) ItemResult {
+	item := &library.ReviewItem{
+		Artist:       result.Request.Artist,
+		Title:        result.Request.Title,
+		PreferredKey: result.Request.PreferredKey,
+		Reason:       reason,
+		Source:       "import",
+		Candidates:   candidates,
+	}
+
+	if err := p.library.AddReview(item); err != nil {
+		result.Status = StatusFailed
+		result.Error = fmt.Sprintf("%s (queueing for review failed: %v)", reason, err)
+		return result
+	}
+
+	result.Status = StatusReview
+	result.ReviewID = item.ID
+	result.Error = reason
+	return result
+}
+
+// minConfidence is the match confidence a version needs: in strict mode
+// title and artist must match exactly
+func minConfidence(strict bool) float64 {
+	if strict {
+		return 1
+	}
+	return 0
+}
+
+// SelectBestVersion picks the most suitable search result for a request,
+// ranked by model. Returns false when no candidate matches well enough.
+func SelectBestVersion(req SongRequest, candidates []scraper.SearchResult, strict bool, model ScoreModel) (scraper.SearchResult, bool) {
+	best, _, ok := BestMatch(req, candidates, minConfidence(strict), model)
+	return best, ok
+}
+
+// BestMatch returns the best ranked candidate together with its confidence
+func BestMatch(req SongRequest, candidates []scraper.SearchResult, minConfidence float64, model ScoreModel) (scraper.SearchResult, float64, bool) {
+	ranked := RankVersions(req, candidates, minConfidence, model)
+	if len(ranked) == 0 {
+		return scraper.SearchResult{}, 0, false
+	}
+	return ranked[0].Result, ranked[0].Confidence, true
+}
+
+// RankedVersion is a candidate version with its match confidence and score
+type RankedVersion struct {
+	Result     scraper.SearchResult
+	Confidence float64
+	Score      float64
+}
+
+// RankVersions orders the candidates whose match confidence is at least
+// minConfidence, best first: how well title and artist match, plus the
+// model's score. Confidence is 1 when title and artist match exactly after
+// normalization.
+func RankVersions(req SongRequest, candidates []scraper.SearchResult, minConfidence float64, model ScoreModel) []RankedVersion {
+	wantTitle := library.NormalizeName(req.Title)
+	wantArtist := library.NormalizeName(req.Artist)
+
+	var ranked []RankedVersion
+	for _, c := range candidates {
+		titleScore := matchScore(wantTitle, library.NormalizeName(c.Title))
+		artistScore := 1.0
+		if wantArtist != "" {
+			artistScore = matchScore(wantArtist, library.NormalizeName(c.Artist))
+		}
+
+		if titleScore == 0 || artistScore == 0 {
+			continue
+		}
+
+		// Title carries twice the weight of the artist
+		confidence := (titleScore*2 + artistScore) / 3
+		if confidence < minConfidence {
+			continue
+		}
+
+		ranked = append(ranked, RankedVersion{
+			Result:     c,
+			Confidence: confidence,
+			Score:      titleScore*10 + artistScore*5 + model.Score(c),
+		})
+	}
+
+	// Stable, so equal scores keep the search order
+	slices.SortStableFunc(ranked, func(a, b RankedVersion) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return ranked
+}
+
+// matchScore returns 1 for an exact match, 0.5 when one name contains the
+// other and 0 otherwise
+func matchScore(want, got string) float64 {
+	switch {
+	case want == "" || got == "":
+		return 0
+	case want == got:
+		return 1
+	case strings.Contains(got, want) || strings.Contains(want, got):
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// MergeMatch links a song to its suggested UG match. Unless keepContent is
+// set, the hand-entered chart is replaced by the converted UG version.
+func (p *Pipeline) MergeMatch(ctx context.Context, songID string, keepContent bool) (*library.Song, error) {
+	song, ok := p.library.Get(songID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+	if song.SuggestedMatch == nil {
+		return nil, fmt.Errorf("song has no suggested match")
+	}
+	revision := song.Revision
+
+	tab, err := p.ugClient.GetTabByID(ctx, fmt.Sprintf("%d", song.SuggestedMatch.TabID))
+	if err != nil {
+		return nil, fmt.Errorf("fetching tab: %w", err)
+	}
+
+	converted, err := p.convertTab(tab)
+	if err != nil {
+		return nil, err
+	}
+
+	song.SourceTabID = converted.SourceTabID
+	song.SourceURL = converted.SourceURL
+	song.Contributor = converted.Contributor
+	song.Rating = converted.Rating
+	song.Votes = converted.Votes
+	song.Type = converted.Type
+	if !keepContent {
+		song.Source = converted.Source
+		song.Key = converted.Key
+		song.Capo = converted.Capo
+		song.Tuning = converted.Tuning
+		song.Content = converted.Content
+		song.OnSongFormat = converted.OnSongFormat
+	}
+	song.SuggestedMatch = nil
+
+	// Merged only if nobody changed or deleted the song during the fetch
+	err = p.library.SaveIfMatch(song, revision)
+	if err == library.ErrConflict {
+		if _, ok := p.library.Get(songID); !ok {
+			return nil, library.ErrNotFound
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("saving song: %w", err)
+	}
+
+	return song, nil
+}
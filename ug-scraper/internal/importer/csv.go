@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseRepertoireCSV reads "artist,title[,preferred_key]" rows. A leading
+// header row is skipped when its first two columns are "artist" and "title".
+func ParseRepertoireCSV(r io.Reader) ([]SongRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var requests []SongRequest
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		line++
+
+		if isBlankRecord(record) {
+			continue
+		}
+		if line == 1 && isHeaderRecord(record) {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected artist,title[,preferred_key]", line)
+		}
+
+		req := SongRequest{
+			Artist: strings.TrimSpace(record[0]),
+			Title:  strings.TrimSpace(record[1]),
+		}
+		if len(record) > 2 {
+			req.PreferredKey = strings.TrimSpace(record[2])
+		}
+		if req.Title == "" {
+			return nil, fmt.Errorf("line %d: title is required", line)
+		}
+
+		requests = append(requests, req)
+	}
+
+	return requests, nil
+}
+
+// isHeaderRecord reports whether a record is the optional column header
+func isHeaderRecord(record []string) bool {
+	return len(record) >= 2 &&
+		strings.EqualFold(strings.TrimSpace(record[0]), "artist") &&
+		strings.EqualFold(strings.TrimSpace(record[1]), "title")
+}
+
+// isBlankRecord reports whether every field in a record is empty
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,369 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+var (
+	// dictationLeadRegex strips the command in front of the song list
+	dictationLeadRegex = regexp.MustCompile(`(?i)^(?:(?:hey|ok|okay|please|can you|could you|would you)[\s,]+)*(?:add|put|queue|include|append)\s+`)
+	// dictationCreateRegex matches "make a setlist for Friday with A, B and C"
+	dictationCreateRegex = regexp.MustCompile(`(?i)^(?:(?:please|can you|could you)\s+)?(?:make|create|start|build|new)\s+(?:a\s+)?(?:new\s+)?(?:set|setlist|set list)\s+(?:for|called|named)\s+(.+?)\s+with\s+(.+)$`)
+	// dictationTargetRegex matches "... to Friday's set" and "... to the gig setlist"
+	dictationTargetRegex = regexp.MustCompile(`(?i)^(.+?)\s+(?:to|onto|on|into|for)\s+(?:the\s+|my\s+|our\s+)?(.+?)(?:'s|’s|s')?\s+(?:set|setlist|set list|gig|show)$`)
+	// dictationNamedTargetRegex matches "... to the setlist for Friday"
+	dictationNamedTargetRegex = regexp.MustCompile(`(?i)^(.+?)\s+(?:to|onto|on|into)\s+(?:the\s+|my\s+|our\s+)?(?:set|setlist|set list)\s+(?:for|called|named)?\s*(.+)$`)
+	// dictationSplitRegex separates the songs of a list
+	dictationSplitRegex = regexp.MustCompile(`(?i)\s*(?:,|;|\band then\b|\bthen\b|\bplus\b|\bfollowed by\b)\s*`)
+	// dictationAndRegex splits "A and B"; dictationLeadingAnd drops the "and"
+	// before the last song of "A, B, and C"
+	dictationAndRegex   = regexp.MustCompile(`(?i)\s+and\s+`)
+	dictationLeadingAnd = regexp.MustCompile(`(?i)^and\s+`)
+	// dictationByRegex splits "Wonderwall by Oasis"
+	dictationByRegex = regexp.MustCompile(`(?i)^(.+?)\s+by\s+(.+)$`)
+)
+
+// weekdays maps spoken day names to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// Dictation is a spoken setlist request broken into its parts
+type Dictation struct {
+	Songs   []SongRequest `json:"songs"`
+	Setlist string        `json:"setlist,omitempty"` // Spoken name of the target set, e.g. "Friday"
+}
+
+// ParseDictation understands requests like "add Wonderwall, Hallelujah, and
+// Wish You Were Here to Friday's set" or "make a setlist for Friday with ...".
+// isTitle reports whether a phrase is a known song title; it keeps titles
+// such as "Me and Bobby McGee" from being split at their "and".
+func ParseDictation(text string, isTitle func(string) bool) Dictation {
+	text = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(text), ".!?"))
+
+	var d Dictation
+	list := text
+	if m := dictationCreateRegex.FindStringSubmatch(text); m != nil {
+		d.Setlist, list = m[1], m[2]
+	} else {
+		list = dictationLeadRegex.ReplaceAllString(list, "")
+		if m := dictationTargetRegex.FindStringSubmatch(list); m != nil {
+			list, d.Setlist = m[1], m[2]
+		} else if m := dictationNamedTargetRegex.FindStringSubmatch(list); m != nil {
+			list, d.Setlist = m[1], m[2]
+		}
+	}
+	d.Setlist = strings.TrimSpace(d.Setlist)
+
+	seen := make(map[string]bool)
+	for _, phrase := range splitSongList(list, isTitle) {
+		req := SongRequest{Title: phrase}
+		if m := dictationByRegex.FindStringSubmatch(phrase); m != nil && (isTitle == nil || !isTitle(phrase)) {
+			req.Title, req.Artist = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		}
+
+		key := library.NormalizeName(req.Artist + " " + req.Title)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		d.Songs = append(d.Songs, req)
+	}
+
+	return d
+}
+
+// splitSongList breaks a spoken list into song phrases
+func splitSongList(list string, isTitle func(string) bool) []string {
+	var phrases []string
+	for _, part := range dictationSplitRegex.Split(list, -1) {
+		part = dictationLeadingAnd.ReplaceAllString(strings.TrimSpace(part), "")
+
+		// "A and B" is two songs unless it is the title of one
+		if strings.Contains(strings.ToLower(part), " and ") && (isTitle == nil || !isTitle(part)) {
+			for _, p := range dictationAndRegex.Split(part, -1) {
+				phrases = append(phrases, cleanSongPhrase(p))
+			}
+			continue
+		}
+		phrases = append(phrases, cleanSongPhrase(part))
+	}
+
+	out := phrases[:0]
+	for _, p := range phrases {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// cleanSongPhrase strips the quotes and filler words speech recognition
+// leaves around a title
+func cleanSongPhrase(p string) string {
+	p = strings.Trim(strings.TrimSpace(p), `"'“”‘’`)
+	lower := strings.ToLower(p)
+	for _, filler := range []string{"the song ", "the tune ", "song "} {
+		if strings.HasPrefix(lower, filler) {
+			p = p[len(filler):]
+			break
+		}
+	}
+	return strings.TrimSpace(p)
+}
+
+// Capture statuses beyond the pipeline's own
+const (
+	StatusLibrary = "library" // Already in the library, nothing fetched
+	StatusLookup  = "lookup"  // Dry run: would be searched on UG
+)
+
+// CapturedSong is what became of one song of a dictated request
+type CapturedSong struct {
+	Title    string `json:"title"`
+	Artist   string `json:"artist,omitempty"`
+	Status   string `json:"status"`
+	SongID   string `json:"song_id,omitempty"`
+	ReviewID string `json:"review_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Added    bool   `json:"added"` // Appended to the setlist; false if it was already in it
+}
+
+// CaptureResult reports what a dictated setlist request was understood as
+// and what was done, for the assistant to read back
+type CaptureResult struct {
+	Heard   string           `json:"heard"`
+	Setlist *library.Setlist `json:"setlist,omitempty"`
+	Created bool             `json:"created"`
+	Songs   []CapturedSong   `json:"songs"`
+	Added   int              `json:"added"`
+	DryRun  bool             `json:"dry_run,omitempty"`
+	Speech  string           `json:"speech"`
+}
+
+// CaptureSetlist parses a dictated request, finds each song in the library
+// or imports the best UG version of it, and appends the songs to the named
+// setlist, creating it when no setlist matches. setlistID, when set, picks
+// the setlist instead of the spoken name. A dry run resolves nothing online
+// and saves nothing; it shows how the request was understood.
+func (p *Pipeline) CaptureSetlist(ctx context.Context, text, setlistID string, dryRun bool) (*CaptureResult, error) {
+	songs := p.library.List()
+	d := ParseDictation(text, func(phrase string) bool {
+		_, ok := findSongByTitle(songs, SongRequest{Title: phrase})
+		return ok
+	})
+	if len(d.Songs) == 0 {
+		return nil, fmt.Errorf("no song names were recognized")
+	}
+
+	setlist, created, err := p.captureTarget(setlistID, d.Setlist)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CaptureResult{Heard: text, Setlist: setlist, Created: created, DryRun: dryRun}
+	inSet := make(map[string]bool)
+	for _, item := range setlist.Items {
+		inSet[item.SongID] = true
+	}
+
+	for _, req := range d.Songs {
+		song := CapturedSong{Title: req.Title, Artist: req.Artist}
+
+		if found, ok := findSongByTitle(songs, req); ok {
+			song.Status, song.SongID = StatusLibrary, found.ID
+			song.Title, song.Artist = found.Title, found.Artist
+		} else if dryRun {
+			song.Status = StatusLookup
+		} else {
+			imported := p.Import(ctx, req, false)
+			song.Status, song.SongID, song.ReviewID, song.Error = imported.Status, imported.SongID, imported.ReviewID, imported.Error
+			if s, ok := p.library.Get(imported.SongID); ok {
+				song.Title, song.Artist = s.Title, s.Artist
+			}
+		}
+
+		if song.SongID != "" && !inSet[song.SongID] {
+			inSet[song.SongID] = true
+			song.Added = true
+			result.Added++
+			setlist.Items = append(setlist.Items, library.SetlistItem{SongID: song.SongID})
+		}
+		result.Songs = append(result.Songs, song)
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	if !dryRun && (created || result.Added > 0) {
+		if err := p.library.SaveSetlist(setlist); err != nil {
+			return nil, fmt.Errorf("saving setlist: %w", err)
+		}
+	}
+
+	result.Speech = captureSpeech(result)
+	return result, nil
+}
+
+// captureTarget returns the setlist a request goes to: the one with the given
+// ID, one whose name or date matches the spoken name, or a new one
+func (p *Pipeline) captureTarget(setlistID, spoken string) (*library.Setlist, bool, error) {
+	if setlistID != "" {
+		setlist, ok := p.library.GetSetlist(setlistID)
+		if !ok {
+			return nil, false, library.ErrNotFound
+		}
+		return setlist, false, nil
+	}
+	if spoken == "" {
+		return nil, false, fmt.Errorf("no setlist was named; say which set the songs go to or pass setlist_id")
+	}
+
+	want := library.NormalizeName(spoken)
+	date := spokenDate(spoken, time.Now())
+	var byDate, byName *library.Setlist
+	for _, s := range p.library.ListSetlists() {
+		name := library.NormalizeName(s.Name)
+		switch {
+		case name == want:
+			return &s, false, nil
+		case date != "" && s.Date == date && byDate == nil:
+			byDate = &s
+		case want != "" && strings.Contains(name, want) && byName == nil:
+			byName = &s
+		}
+	}
+	if byDate != nil {
+		return byDate, false, nil
+	}
+	if byName != nil {
+		return byName, false, nil
+	}
+
+	// A set named by its day is called after the weekday, "tomorrow" included
+	name := strings.TrimSpace(spoken)
+	if day, err := time.Parse("2006-01-02", date); err == nil {
+		name = day.Weekday().String()
+	}
+	if r := []rune(name); len(r) > 0 {
+		name = strings.ToUpper(string(r[0])) + string(r[1:])
+	}
+	return &library.Setlist{Name: name, Date: date}, true, nil
+}
+
+// spokenDate turns "Friday", "next Friday", "today", "tonight" or "tomorrow"
+// into a YYYY-MM-DD date counted from now; other names give ""
+func spokenDate(spoken string, now time.Time) string {
+	s := strings.ToLower(strings.TrimSpace(spoken))
+	switch s {
+	case "today", "tonight", "this evening":
+		return now.Format("2006-01-02")
+	case "tomorrow", "tomorrow night":
+		return now.AddDate(0, 0, 1).Format("2006-01-02")
+	}
+
+	next := strings.HasPrefix(s, "next ")
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(s, "next "), "this "), " night")
+	day, ok := weekdays[s]
+	if !ok {
+		return ""
+	}
+	ahead := (int(day) - int(now.Weekday()) + 7) % 7
+	if next && ahead == 0 {
+		ahead = 7
+	}
+	return now.AddDate(0, 0, ahead).Format("2006-01-02")
+}
+
+// findSongByTitle finds a library song by normalized title, preferring one
+// by the requested artist
+func findSongByTitle(songs []library.Song, req SongRequest) (*library.Song, bool) {
+	title, artist := library.NormalizeName(req.Title), library.NormalizeName(req.Artist)
+	if title == "" {
+		return nil, false
+	}
+
+	var match *library.Song
+	for i := range songs {
+		if library.NormalizeName(songs[i].Title) != title {
+			continue
+		}
+		if artist == "" || library.NormalizeName(songs[i].Artist) == artist {
+			return &songs[i], true
+		}
+		if match == nil {
+			match = &songs[i]
+		}
+	}
+	if match != nil && artist == "" {
+		return match, true
+	}
+	return nil, false
+}
+
+// captureSpeech sums up a capture in a sentence an assistant can speak
+func captureSpeech(r *CaptureResult) string {
+	var added, already, review, missing []string
+	for _, s := range r.Songs {
+		switch {
+		case s.Added || s.Status == StatusLookup:
+			added = append(added, s.Title)
+		case s.SongID != "":
+			already = append(already, s.Title)
+		case s.Status == StatusReview:
+			review = append(review, s.Title)
+		case s.SongID == "":
+			missing = append(missing, s.Title)
+		}
+	}
+
+	verb := "Added"
+	if r.DryRun {
+		verb = "Would add"
+	}
+	var b strings.Builder
+	if len(added) > 0 {
+		fmt.Fprintf(&b, "%s %s to %s.", verb, spokenList(added), r.Setlist.Name)
+	} else {
+		fmt.Fprintf(&b, "Nothing new was added to %s.", r.Setlist.Name)
+	}
+	if len(already) > 0 {
+		verb := "are"
+		if len(already) == 1 {
+			verb = "is"
+		}
+		fmt.Fprintf(&b, " %s %s already in it.", spokenList(already), verb)
+	}
+	if len(review) > 0 {
+		fmt.Fprintf(&b, " %s need%s a look in the review queue.", spokenList(review), plural(len(review)))
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(&b, " Couldn't find %s.", spokenList(missing))
+	}
+	return b.String()
+}
+
+// spokenList joins names as "A, B and C"
+func spokenList(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// plural returns the verb ending for a subject count: "needs" for one,
+// "need" for more
+func plural(n int) string {
+	if n == 1 {
+		return "s"
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext is how many unchanged lines surround each change
+	diffContext = 2
+	// maxDiffLines caps the size of texts compared line by line
+	maxDiffLines = 3000
+)
+
+// diffOp is one line of a diff: kept (' '), removed ('-') or added ('+')
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// normalizeContent drops carriage returns and trailing whitespace so only
+// real edits count as changes
+func normalizeContent(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// unifiedDiff returns a unified diff from old to new with a few lines of
+// context around each change, and the number of lines added and removed
+func unifiedDiff(old, new string) (string, int, int) {
+	a, b := strings.Split(old, "\n"), strings.Split(new, "\n")
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		return "@@ too large to compare line by line @@\n", len(b), len(a)
+	}
+
+	ops := diffLines(a, b)
+
+	added, removed := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	if added == 0 && removed == 0 {
+		return "", 0, 0
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and the run of changes close to it
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+
+		from := max(first-diffContext, start)
+		to := min(last+diffContext+1, len(ops))
+
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		start = to
+	}
+
+	return out.String(), added, removed
+}
+
+// diffLines aligns two texts on their longest common subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+
+	return ops
+}
+
+// changedLines returns up to limit added and removed lines of a unified
+// diff, with their + or - prefix, skipping lines that are only whitespace
+func changedLines(diff string, limit int) []string {
+	var lines []string
+	for _, line := range strings.Split(diff, "\n") {
+		if len(lines) == limit {
+			break
+		}
+		if (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) && strings.TrimSpace(line[1:]) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
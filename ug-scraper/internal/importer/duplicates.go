@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// What an import does with a song that is already in the library under a
+// slightly different spelling (see library.Equivalent), e.g. "Hallelujah
+// (ver 2)" next to "hallelujah!" by the same artist
+const (
+	DuplicatesWarn = "warn" // Import it anyway and report the equivalent songs
+	DuplicatesLink = "link" // Keep the library song and report it as existing
+)
+
+// DuplicatesFromEnv reads DUPLICATE_SONGS, defaulting to DuplicatesWarn
+func DuplicatesFromEnv() string {
+	switch policy := strings.ToLower(os.Getenv("DUPLICATE_SONGS")); policy {
+	case "", DuplicatesWarn:
+		return DuplicatesWarn
+	case DuplicatesLink:
+		return DuplicatesLink
+	default:
+		fmt.Printf("⚠️  Unknown DUPLICATE_SONGS %q, using %s\n", policy, DuplicatesWarn)
+		return DuplicatesWarn
+	}
+}
+
+// WithDuplicates sets what imports do with songs equivalent to one already
+// in the library
+func (p *Pipeline) WithDuplicates(policy string) *Pipeline {
+	p.duplicates = policy
+	return p
+}
+
+// equivalents looks for library songs equivalent to artist and title. With
+// DuplicatesLink the oldest one is returned as the song to link to;
+// otherwise their IDs are returned as a warning.
+func (p *Pipeline) equivalents(artist, title string) (*library.Song, []string) {
+	songs := p.library.FindEquivalent(artist, title)
+	if len(songs) == 0 {
+		return nil, nil
+	}
+	if p.duplicates == DuplicatesLink {
+		return &songs[0], nil
+	}
+
+	ids := make([]string, len(songs))
+	for i, song := range songs {
+		ids[i] = song.ID
+	}
+	fmt.Printf("⚠️  %s - %s looks like %d song(s) already in the library: %s\n",
+		artist, title, len(ids), strings.Join(ids, ", "))
+	return nil, ids
+}
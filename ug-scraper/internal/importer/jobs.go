@@ -0,0 +1,522 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/bandwidth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+const (
+	// importPause spaces out tab fetches within a job, shortened during a burst
+	importPause = 2 * time.Second
+	// maxJobs is how many finished jobs are kept for status queries
+	maxJobs = 50
+	// jobStallAfter is how long a running job may go without progress
+	// before the workers are reported as degraded
+	jobStallAfter = 10 * time.Minute
+)
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobWaiting   = "waiting" // Every item was tried; failed ones wait for their retry
+	JobCompleted = "completed"
+)
+
+// Job item statuses besides the pipeline's
+const (
+	StatusPending  = "pending"  // Not processed yet
+	StatusRetrying = "retrying" // Failed, tried again at NextRetryAt
+)
+
+// JobItem is one line of a bulk import
+type JobItem struct {
+	Input       string     `json:"input"`
+	TabID       string     `json:"tab_id,omitempty"`
+	Status      string     `json:"status"`
+	SongID      string     `json:"song_id,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Artist      string     `json:"artist,omitempty"`
+	Error       string     `json:"error,omitempty"`      // Last failure, also while retrying
+	Duplicates  []string   `json:"duplicates,omitempty"` // See ItemResult
+	Attempts    int        `json:"attempts,omitempty"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+}
+
+// due reports whether the item is to be processed in a run starting at now
+func (item *JobItem) due(now time.Time) bool {
+	return item.Status == StatusPending || item.Status == StatusRetrying && !item.NextRetryAt.After(now)
+}
+
+// Job is a background bulk import of UG URLs or tab IDs
+type Job struct {
+	ID         string         `json:"id"`
+	Status     string         `json:"status"`
+	Total      int            `json:"total"`
+	Processed  int            `json:"processed"`
+	Summary    map[string]int `json:"summary"`
+	Items      []JobItem      `json:"items"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	// NextRetryAt is when the next failed item is retried, while waiting
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+
+	// Bytes downloaded while the job ran, in total and per upstream
+	DownloadedBytes int64            `json:"downloaded_bytes"`
+	Bandwidth       map[string]int64 `json:"bandwidth,omitempty"`
+}
+
+// clone returns a deep copy safe to hand out while the job is running
+func (j *Job) clone() *Job {
+	copied := *j
+	copied.Items = append([]JobItem(nil), j.Items...)
+	copied.Bandwidth = make(map[string]int64, len(j.Bandwidth))
+	for k, v := range j.Bandwidth {
+		copied.Bandwidth[k] = v
+	}
+	copied.Summary = make(map[string]int, len(j.Summary))
+	for k, v := range j.Summary {
+		copied.Summary[k] = v
+	}
+	return &copied
+}
+
+// JobManager runs bulk imports in the background on a pool of workers and
+// keeps their per-item status in memory. Each job still pauses between its
+// own tab fetches. Items that failed for a reason that may go away are
+// retried on the retry schedule; the job completes once none is left.
+type JobManager struct {
+	pipeline *Pipeline
+	resolver *scraper.Resolver
+	budget   *scraper.RateBudget
+	meter    *bandwidth.Meter
+	events   *events.Dispatcher
+	retries  RetrySchedule
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	queue   chan string
+	workers int
+
+	// Worker state for health checks
+	running map[string]time.Time // Jobs being run and when each last finished an item
+	alive   int                  // Workers running
+	crashed string               // Last panic that stopped a worker; empty while all run
+}
+
+// NewJobManager creates a job manager and starts its workers
+func NewJobManager(pipeline *Pipeline, resolver *scraper.Resolver, budget *scraper.RateBudget, meter *bandwidth.Meter, dispatcher *events.Dispatcher, workers int) *JobManager {
+	m := &JobManager{
+		pipeline: pipeline,
+		resolver: resolver,
+		budget:   budget,
+		meter:    meter,
+		events:   dispatcher,
+		retries:  RetryScheduleFromEnv(),
+		jobs:     make(map[string]*Job),
+		queue:    make(chan string, maxJobs),
+		workers:  max(workers, 1),
+		running:  make(map[string]time.Time),
+	}
+
+	m.alive = m.workers
+	for i := 0; i < m.workers; i++ {
+		go m.worker()
+	}
+
+	return m
+}
+
+// ParseURLList reads one UG tab URL, slug or numeric tab ID per line. Blank lines
+// and lines starting with # are skipped.
+func ParseURLList(r io.Reader) ([]string, error) {
+	var inputs []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		inputs = append(inputs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading URL list: %w", err)
+	}
+
+	return inputs, nil
+}
+
+// Submit queues a job for the given inputs. Lines that are not a tab ID, UG
+// URL or slug are marked failed straight away; shortened links and slugs
+// without an ID are resolved when the job runs.
+func (m *JobManager) Submit(inputs []string) (*Job, error) {
+	now := time.Now()
+	job := &Job{
+		ID:        fmt.Sprintf("job_%d", now.UnixNano()),
+		Status:    JobQueued,
+		Total:     len(inputs),
+		Summary:   map[string]int{},
+		Items:     make([]JobItem, len(inputs)),
+		CreatedAt: now,
+	}
+
+	for i, input := range inputs {
+		item := JobItem{Input: input, Status: StatusPending}
+		if ref, err := scraper.ParseTabRef(input); err != nil {
+			item.Status = StatusFailed
+			item.Error = err.Error()
+			job.Processed++
+			job.Summary[StatusFailed]++
+		} else {
+			item.TabID = ref.TabID
+		}
+		job.Items[i] = item
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case m.queue <- job.ID:
+	default:
+		return nil, fmt.Errorf("too many imports queued, try again later")
+	}
+
+	m.jobs[job.ID] = job
+	m.prune()
+
+	return job.clone(), nil
+}
+
+// Get returns a snapshot of a job
+func (m *JobManager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	return job.clone(), true
+}
+
+// List returns snapshots of all known jobs, newest first
+func (m *JobManager) List() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job.clone())
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+
+	return jobs
+}
+
+// worker processes queued jobs in order. A panic stops the worker and ends
+// the job it was running; the health monitor restarts it.
+func (m *JobManager) worker() {
+	var current string
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("❌ Import worker crashed: %v\n", r)
+			m.failJob(current, fmt.Sprint(r))
+		}
+	}()
+
+	for id := range m.queue {
+		current = id
+		m.run(id)
+	}
+}
+
+// failJob ends the job a crashed worker was running, failing its remaining
+// items
+func (m *JobManager) failJob(id, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.crashed = reason
+	m.alive--
+	delete(m.running, id)
+	job, ok := m.jobs[id]
+	if !ok || job.Status != JobRunning {
+		return
+	}
+
+	for i, item := range job.Items {
+		if item.Status == StatusPending || item.Status == StatusRetrying {
+			job.setStatus(i, StatusFailed)
+			job.Items[i].Error = "import worker crashed: " + reason
+			job.Items[i].NextRetryAt = nil
+		}
+	}
+	finished := time.Now()
+	job.Status = JobCompleted
+	job.FinishedAt = &finished
+}
+
+// Health scores the import workers: down when they all crashed, degraded
+// when some did or a job makes no progress
+func (m *JobManager) Health() (float64, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.running))
+	stalled := ""
+	for id, lastProgress := range m.running {
+		ids = append(ids, id)
+		if since := time.Since(lastProgress); since > jobStallAfter {
+			stalled = fmt.Sprintf("job %s has made no progress for %s", id, since.Round(time.Second))
+		}
+	}
+	sort.Strings(ids)
+
+	switch {
+	case m.alive == 0:
+		return 0, "workers crashed: " + m.crashed
+	case m.alive < m.workers:
+		return 0.4, fmt.Sprintf("%d of %d workers crashed: %s", m.workers-m.alive, m.workers, m.crashed)
+	case stalled != "":
+		return 0.6, stalled
+	case len(ids) > 0:
+		return 1, fmt.Sprintf("running %s, %d queued", strings.Join(ids, ", "), len(m.queue))
+	default:
+		return 1, "idle"
+	}
+}
+
+// Restart replaces crashed workers
+func (m *JobManager) Restart() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	missing := m.workers - m.alive
+	if missing == 0 {
+		return "", fmt.Errorf("import workers are running")
+	}
+	m.crashed = ""
+	m.alive = m.workers
+	for i := 0; i < missing; i++ {
+		go m.worker()
+	}
+
+	return fmt.Sprintf("restarted %d import workers", missing), nil
+}
+
+// run processes every pending item of a job and the failed ones whose retry
+// is due
+func (m *JobManager) run(id string) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	started := time.Now()
+	if job.StartedAt == nil {
+		job.StartedAt = &started
+	}
+	job.Status = JobRunning
+	job.NextRetryAt = nil
+	m.running[id] = started
+	items := append([]JobItem(nil), job.Items...)
+	retrying := job.Summary[StatusRetrying]
+	m.mu.Unlock()
+
+	m.meter.StartJob(id)
+	ctx := bandwidth.WithJob(context.Background(), id)
+	if retrying > 0 {
+		fmt.Printf("\n🔁 Bulk import %s: retrying failed items (%d waiting)\n", id, retrying)
+	} else {
+		fmt.Printf("\n📥 Bulk import %s: %d items\n", id, len(items))
+	}
+
+	fetched := 0
+	for i, item := range items {
+		if !item.due(started) {
+			continue
+		}
+
+		// Over the daily cap every fetch would fail; skip the pause too
+		err := m.meter.Allow()
+		if err == nil && fetched > 0 {
+			time.Sleep(m.budget.Pause(importPause))
+		}
+		fetched++
+
+		if err == nil && item.TabID == "" {
+			item.TabID, err = m.resolveItem(ctx, item.Input)
+		}
+
+		var song *library.Song
+		var existing bool
+		if err == nil {
+			fmt.Printf("   [%d/%d] tab %s\n", i+1, len(items), item.TabID)
+			song, existing, item.Duplicates, err = m.pipeline.ImportTab(ctx, item.TabID)
+		}
+		item.Attempts++
+		item.NextRetryAt = nil
+		status := StatusImported
+		switch {
+		case err != nil && retryable(err) && item.Attempts <= m.retries.MaxRetries:
+			status = StatusRetrying
+			next := time.Now().Add(m.retries.Delay(item.Attempts))
+			item.NextRetryAt = &next
+			item.Error = err.Error()
+			fmt.Printf("   ✗ %v (retry %d of %d in %s)\n", err, item.Attempts, m.retries.MaxRetries, m.retries.Delay(item.Attempts))
+		case err != nil:
+			status = StatusFailed
+			item.Error = err.Error()
+			fmt.Printf("   ✗ %v\n", err)
+		case existing:
+			status = StatusExisting
+			item.Error = ""
+		default:
+			item.Error = ""
+		}
+		if song != nil {
+			item.SongID = song.ID
+			item.Title = song.Title
+			item.Artist = song.Artist
+		}
+
+		bandwidth := m.meter.Job(id)
+		m.mu.Lock()
+		job.Items[i] = item
+		job.setStatus(i, status)
+		job.DownloadedBytes = bandwidth.Total
+		job.Bandwidth = bandwidth.Upstreams
+		m.running[id] = time.Now()
+		m.mu.Unlock()
+	}
+
+	bandwidth := m.meter.EndJob(id)
+	m.mu.Lock()
+	job.DownloadedBytes = bandwidth.Total
+	job.Bandwidth = bandwidth.Upstreams
+	delete(m.running, id)
+	if next := job.nextRetry(); next != nil {
+		job.Status = JobWaiting
+		job.NextRetryAt = next
+		waiting := job.Summary[StatusRetrying]
+		m.scheduleRetry(id, *next)
+		m.mu.Unlock()
+
+		fmt.Printf("⏳ Bulk import %s: %d items failed for now, next retry at %s\n\n", id, waiting, next.Format(time.DateTime))
+		return
+	}
+	finished := time.Now()
+	job.Status = JobCompleted
+	job.FinishedAt = &finished
+	summary := job.clone().Summary
+	m.mu.Unlock()
+
+	fmt.Printf("✅ Bulk import %s complete: %v\n\n", id, summary)
+	m.events.PublishEvent(events.ImportCompleted, map[string]interface{}{
+		"job_id":  id,
+		"total":   len(items),
+		"summary": summary,
+	})
+}
+
+// setStatus moves an item to a new status, keeping the summary and the
+// processed count in step. Items waiting for a retry are not processed yet.
+func (j *Job) setStatus(i int, status string) {
+	previous := j.Items[i].Status
+	if previous != StatusPending {
+		j.Summary[previous]--
+		if j.Summary[previous] == 0 {
+			delete(j.Summary, previous)
+		}
+	}
+	if (previous == StatusPending || previous == StatusRetrying) && status != StatusRetrying {
+		j.Processed++
+	}
+
+	j.Items[i].Status = status
+	j.Summary[status]++
+}
+
+// nextRetry returns when the first item waiting for a retry is due, or nil
+// when none is
+func (j *Job) nextRetry() *time.Time {
+	var next *time.Time
+	for _, item := range j.Items {
+		if item.Status == StatusRetrying && (next == nil || item.NextRetryAt.Before(*next)) {
+			next = item.NextRetryAt
+		}
+	}
+	return next
+}
+
+// scheduleRetry queues a waiting job again once its next retry is due.
+// Caller holds m.mu.
+func (m *JobManager) scheduleRetry(id string, at time.Time) {
+	time.AfterFunc(time.Until(at), func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		job, ok := m.jobs[id]
+		if !ok || job.Status != JobWaiting {
+			return
+		}
+		select {
+		case m.queue <- id:
+			job.Status = JobQueued
+		default:
+			// The queue is full of new imports; try again shortly
+			m.scheduleRetry(id, time.Now().Add(retryBusyWait))
+		}
+	})
+}
+
+// resolveItem finds the tab ID of an item that has none yet
+func (m *JobManager) resolveItem(ctx context.Context, input string) (string, error) {
+	resolution, err := m.resolver.Resolve(ctx, input, false)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s failed: %w", input, err)
+	}
+	return resolution.TabID, nil
+}
+
+// prune drops the oldest finished jobs beyond maxJobs. Caller holds m.mu.
+func (m *JobManager) prune() {
+	if len(m.jobs) <= maxJobs {
+		return
+	}
+
+	finished := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		if job.Status == JobCompleted {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+
+	for _, job := range finished {
+		if len(m.jobs) <= maxJobs {
+			break
+		}
+		delete(m.jobs, job.ID)
+	}
+}
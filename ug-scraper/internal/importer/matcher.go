@@ -0,0 +1,233 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+const (
+	defaultMatchInterval  = 6 * time.Hour
+	defaultMatchThreshold = 0.8
+	// matchPause spaces out searches so a large library doesn't hammer UG;
+	// a burst shortens it
+	matchPause = 5 * time.Second
+	// rematchAfter is how long to wait before searching again for the same song
+	rematchAfter = 7 * 24 * time.Hour
+)
+
+// Matcher periodically searches for UG versions of library songs that were
+// entered by hand, linking confident matches so they can be merged later
+type Matcher struct {
+	searchScraper *scraper.SearchScraper
+	library       *library.Store
+	budget        *scraper.RateBudget
+	interval      time.Duration
+	threshold     float64
+	scores        ScoreModel
+
+	mu      sync.Mutex
+	running bool
+	lastRun time.Time
+	stop    chan struct{}
+}
+
+// MatchRunResult summarizes a single matcher pass
+type MatchRunResult struct {
+	Checked   int       `json:"checked"`
+	Linked    int       `json:"linked"`
+	Errors    int       `json:"errors"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// NewMatcher creates a matcher configured from MATCHER_INTERVAL (a Go
+// duration, "0" disables the schedule) and MATCHER_THRESHOLD (0-1)
+func NewMatcher(searchScraper *scraper.SearchScraper, store *library.Store, budget *scraper.RateBudget) *Matcher {
+	interval := defaultMatchInterval
+	if v := os.Getenv("MATCHER_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			interval = d
+		} else if v == "0" {
+			interval = 0
+		}
+	}
+
+	threshold := defaultMatchThreshold
+	if v := os.Getenv("MATCHER_THRESHOLD"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 1 {
+			threshold = f
+		}
+	}
+
+	return &Matcher{
+		searchScraper: searchScraper,
+		library:       store,
+		budget:        budget,
+		interval:      interval,
+		threshold:     threshold,
+		scores:        DefaultScoreModel{},
+		stop:          make(chan struct{}),
+	}
+}
+
+// WithScoreModel makes the matcher suggest versions picked by the given model
+func (m *Matcher) WithScoreModel(model ScoreModel) *Matcher {
+	m.scores = model
+	return m
+}
+
+// Start runs the matcher on its schedule in the background
+func (m *Matcher) Start() {
+	if m.interval <= 0 {
+		fmt.Println("🔎 Source matcher disabled")
+		return
+	}
+
+	fmt.Printf("🔎 Source matcher running every %s (threshold %.2f)\n", m.interval, m.threshold)
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := m.Run(); err != nil {
+					fmt.Printf("⚠️  Source matcher: %v\n", err)
+				}
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background schedule
+func (m *Matcher) Stop() {
+	close(m.stop)
+}
+
+// Run performs one matching pass over songs missing a source tab
+func (m *Matcher) Run() (*MatchRunResult, error) {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("matcher is already running")
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.running = false
+		m.lastRun = time.Now()
+		m.mu.Unlock()
+	}()
+
+	result := &MatchRunResult{StartedAt: time.Now()}
+	for _, song := range m.library.ListMissingSource() {
+		if song.SuggestedMatch != nil || time.Since(song.LastMatchedAt) < rematchAfter {
+			continue
+		}
+
+		if result.Checked > 0 {
+			time.Sleep(m.budget.Pause(matchPause))
+		}
+		result.Checked++
+
+		linked, err := m.MatchSong(context.Background(), song.ID)
+		if err != nil {
+			fmt.Printf("   ✗ %s - %s: %v\n", song.Artist, song.Title, err)
+			result.Errors++
+			continue
+		}
+		if linked {
+			result.Linked++
+		}
+	}
+
+	result.Duration = time.Since(result.StartedAt).String()
+	fmt.Printf("🔎 Source matcher: checked=%d linked=%d errors=%d\n", result.Checked, result.Linked, result.Errors)
+	return result, nil
+}
+
+// MatchSong searches for a single song and records a suggested match when
+// one is found above the confidence threshold
+func (m *Matcher) MatchSong(ctx context.Context, songID string) (bool, error) {
+	song, ok := m.library.Get(songID)
+	if !ok {
+		return false, library.ErrNotFound
+	}
+
+	req := SongRequest{Artist: song.Artist, Title: song.Title}
+	query := strings.TrimSpace(req.Artist + " " + req.Title)
+	candidates, err := m.searchScraper.SearchTabs(ctx, scraper.SearchOptions{Query: query, Type: scraper.TypeChords})
+
+	// The search is slow, so the song is only saved if nobody changed or
+	// deleted it in the meantime
+	revision := song.Revision
+	song.LastMatchedAt = time.Now()
+	if err != nil {
+		_ = m.library.SaveIfMatch(song, revision)
+		return false, fmt.Errorf("search failed: %w", err)
+	}
+
+	// Drop candidates the user has already rejected for this song
+	filtered := candidates[:0]
+	for _, c := range candidates {
+		if id, err := strconv.Atoi(c.ID); err == nil && !song.IsDismissed(id) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	best, confidence, found := BestMatch(req, filtered, m.threshold, m.scores)
+	if found {
+		tabID, _ := strconv.Atoi(best.ID)
+		song.SuggestedMatch = &library.SourceMatch{
+			TabID:      tabID,
+			Title:      best.Title,
+			Artist:     best.Artist,
+			Type:       best.Type,
+			Rating:     best.Rating,
+			Votes:      best.Votes,
+			URL:        best.URL,
+			Confidence: confidence,
+			FoundAt:    time.Now(),
+		}
+	}
+
+	err = m.library.SaveIfMatch(song, revision)
+	if err == library.ErrConflict {
+		// Dropped; the next run matches the song as it is now
+		if _, ok := m.library.Get(songID); !ok {
+			return false, library.ErrNotFound
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("saving match: %w", err)
+	}
+
+	return found, nil
+}
+
+// Status reports the matcher configuration and last run time
+func (m *Matcher) Status() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return map[string]interface{}{
+		"enabled":   m.interval > 0,
+		"interval":  m.interval.String(),
+		"threshold": m.threshold,
+		"running":   m.running,
+		"last_run":  m.lastRun,
+	}
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// LineItem reports what happened to one line of an NDJSON library import
+type LineItem struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	SongID string `json:"song_id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Artist string `json:"artist,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	Duplicates []string `json:"duplicates,omitempty"` // See ItemResult
+}
+
+// LibraryLines imports an NDJSON library export, one song per line, as the
+// sequence is ranged over. Songs keep their IDs so setlists still point at
+// them. A song whose ID, or artist and title, is already in the library is
+// left alone unless replace is set; equivalent ones are handled as the
+// duplicates policy says. Blank lines are ignored.
+func (p *Pipeline) LibraryLines(r io.Reader, replace bool) iter.Seq[LineItem] {
+	return func(yield func(LineItem) bool) {
+		reader := bufio.NewReader(r)
+		for n := 1; ; n++ {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				if !yield(p.importLibraryLine(n, line, replace)) {
+					return
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(LineItem{Line: n, Status: StatusFailed, Error: fmt.Sprintf("reading: %v", err)})
+				return
+			}
+		}
+	}
+}
+
+// importLibraryLine saves the song on one line of an NDJSON export
+func (p *Pipeline) importLibraryLine(n int, line []byte, replace bool) LineItem {
+	item := LineItem{Line: n}
+
+	var song library.Song
+	if err := json.Unmarshal(line, &song); err != nil {
+		item.Status = StatusFailed
+		item.Error = fmt.Sprintf("invalid JSON: %v", err)
+		return item
+	}
+	item.Title, item.Artist = song.Title, song.Artist
+
+	if strings.TrimSpace(song.Title) == "" {
+		item.Status = StatusFailed
+		item.Error = "title is required"
+		return item
+	}
+	if strings.TrimSpace(song.OnSongFormat) == "" {
+		item.Status = StatusFailed
+		item.Error = "onsong_format is required"
+		return item
+	}
+
+	existing, ok := p.library.Get(song.ID)
+	if !ok {
+		existing, ok = p.library.FindByTitle(song.Artist, song.Title)
+	}
+	if ok {
+		if !replace {
+			item.Status = StatusExisting
+			item.SongID = existing.ID
+			return item
+		}
+		song.ID = existing.ID
+	} else if link, duplicates := p.equivalents(song.Artist, song.Title); link != nil {
+		item.Status = StatusExisting
+		item.SongID = link.ID
+		return item
+	} else {
+		item.Duplicates = duplicates
+	}
+
+	var err error
+	if ok {
+		err = p.library.SaveIfMatch(&song, existing.Revision)
+	} else {
+		// New songs keep their IDs, so setlists migrated with them still match
+		err = p.library.Insert(&song)
+	}
+	if err != nil {
+		item.Status = StatusFailed
+		item.Error = fmt.Sprintf("saving song: %v", err)
+		return item
+	}
+
+	item.Status = StatusImported
+	item.SongID = song.ID
+	return item
+}
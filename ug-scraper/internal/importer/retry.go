@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+const (
+	defaultImportRetries       = 8
+	defaultImportRetryInitial  = 15 * time.Minute
+	defaultImportRetryInterval = 24 * time.Hour
+	// retryBusyWait is how long a due retry waits when the import queue is full
+	retryBusyWait = time.Minute
+)
+
+// RetrySchedule spaces out the automatic retries of bulk import items that
+// failed for a reason that may go away, such as a UG block or a timeout
+type RetrySchedule struct {
+	MaxRetries      int           // Retries after the first attempt; 0 disables them
+	InitialInterval time.Duration // Wait before the first retry, doubled for each next one
+	MaxInterval     time.Duration // Upper bound for a single wait
+}
+
+// RetryScheduleFromEnv reads IMPORT_RETRY_MAX, IMPORT_RETRY_INITIAL and
+// IMPORT_RETRY_MAX_INTERVAL (Go durations). The defaults retry 8 times
+// over about two and a half days: after 15m, 30m, 1h, ... up to 24h.
+func RetryScheduleFromEnv() RetrySchedule {
+	s := RetrySchedule{
+		MaxRetries:      defaultImportRetries,
+		InitialInterval: defaultImportRetryInitial,
+		MaxInterval:     defaultImportRetryInterval,
+	}
+
+	if v := os.Getenv("IMPORT_RETRY_MAX"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			s.MaxRetries = n
+		}
+	}
+	if d, err := time.ParseDuration(os.Getenv("IMPORT_RETRY_INITIAL")); err == nil && d > 0 {
+		s.InitialInterval = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("IMPORT_RETRY_MAX_INTERVAL")); err == nil && d > 0 {
+		s.MaxInterval = d
+	}
+	if s.MaxInterval < s.InitialInterval {
+		s.MaxInterval = s.InitialInterval
+	}
+
+	return s
+}
+
+// Delay returns the wait before the given retry, counting from 1
+func (s RetrySchedule) Delay(retry int) time.Duration {
+	delay := s.InitialInterval
+	for i := 1; i < retry && delay < s.MaxInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, s.MaxInterval)
+}
+
+// permanentError marks an import failure that retrying can't fix, such as
+// a tab that can't be converted
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// retryable reports whether a failed import item may succeed later
+func retryable(err error) bool {
+	var permanent *permanentError
+	return !errors.As(err, &permanent) && !scraper.IsPermanent(err)
+}
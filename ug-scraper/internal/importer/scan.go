@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/ocr"
+)
+
+// reviewSourceScan marks review items made from scanned charts
+const reviewSourceScan = "scan"
+
+// ScanEdits are the corrections made while proofreading a scanned chart;
+// empty fields keep what OCR produced
+type ScanEdits struct {
+	Title   string `json:"title"`
+	Artist  string `json:"artist"`
+	Content string `json:"content"`
+}
+
+// QueueScan cleans up the OCR text of a paper chart, formats it like
+// manually entered content and queues the draft for proofreading. title and
+// artist override the ones guessed from the chart's heading.
+func (p *Pipeline) QueueScan(text, title, artist string) (*library.ReviewItem, *ocr.Chart, error) {
+	chart := ocr.CleanChart(text)
+	if strings.TrimSpace(chart.Text) == "" {
+		return nil, chart, fmt.Errorf("no text was recognized")
+	}
+
+	if title == "" {
+		title = chart.Title
+	}
+	if title == "" {
+		title = "Untitled Scan"
+	}
+	if artist == "" {
+		artist = chart.Artist
+	}
+	if artist == "" {
+		artist = "Unknown Artist"
+	}
+
+	reason := fmt.Sprintf("scanned chart needs proofreading (%d chord lines, %d lyric lines)", chart.ChordLines, chart.LyricLines)
+	if chart.ChordLines == 0 {
+		reason = "scanned chart needs proofreading (no chord lines recognized)"
+	}
+
+	item := &library.ReviewItem{
+		Artist:       artist,
+		Title:        title,
+		Reason:       reason,
+		Source:       reviewSourceScan,
+		Content:      chart.Text,
+		OnSongFormat: p.converter.FormatManualContent(title, artist, chart.Text),
+	}
+	if err := p.library.AddReview(item); err != nil {
+		return nil, chart, fmt.Errorf("queueing for review: %w", err)
+	}
+
+	return item, chart, nil
+}
+
+// AcceptScan saves a proofread scan from the review queue as a library song
+// and removes it from the queue
+func (p *Pipeline) AcceptScan(reviewID string, edits ScanEdits) (*library.Song, error) {
+	item, ok := p.library.GetReview(reviewID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+	if item.Source != reviewSourceScan {
+		return nil, fmt.Errorf("review item is not a scanned chart")
+	}
+
+	title, artist, content := item.Title, item.Artist, item.Content
+	if edits.Title != "" {
+		title = edits.Title
+	}
+	if edits.Artist != "" {
+		artist = edits.Artist
+	}
+	if edits.Content != "" {
+		content = strings.ReplaceAll(edits.Content, "\r\n", "\n")
+	}
+
+	song := &library.Song{
+		Title:        title,
+		Artist:       artist,
+		Source:       library.SourceScan,
+		Content:      content,
+		OnSongFormat: p.converter.FormatManualContent(title, artist, content),
+	}
+	if m := keyLineRegex.FindStringSubmatch(song.OnSongFormat); m != nil {
+		song.Key = m[1]
+	}
+
+	if err := p.library.Save(song); err != nil {
+		return nil, fmt.Errorf("saving song: %w", err)
+	}
+	if err := p.library.RemoveReview(reviewID); err != nil {
+		return nil, fmt.Errorf("removing review item: %w", err)
+	}
+
+	return song, nil
+}
@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"strconv"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// ScoreModel decides which of the versions matching a request is best.
+// The import pipeline and the source matcher use DefaultScoreModel unless
+// another model is plugged in with WithScoreModel.
+type ScoreModel interface {
+	// Score is a candidate's quality, added to how well it matches the
+	// request; higher is better
+	Score(c scraper.SearchResult) float64
+	// Distrusted reports whether charts by a UG contributor should be passed
+	// over when another version is available. Search results don't name
+	// their contributor, so this is asked once a tab has been fetched.
+	Distrusted(contributor string) bool
+}
+
+// DefaultScoreModel prefers chords versions, then higher ratings; votes only
+// break ties between otherwise equal candidates
+type DefaultScoreModel struct{}
+
+// Score implements ScoreModel
+func (DefaultScoreModel) Score(c scraper.SearchResult) float64 {
+	score := c.Rating
+	if c.Type == scraper.TypeChords {
+		score += 20
+	}
+	return score + float64(c.Votes)/1e6
+}
+
+// Distrusted implements ScoreModel; no contributor is distrusted
+func (DefaultScoreModel) Distrusted(string) bool {
+	return false
+}
+
+const (
+	// feedbackWeight is how far the best or worst average rating moves a
+	// version: a chords version marked wrong falls behind other types
+	feedbackWeight = 25
+	// distrustAfter is how many charts of a contributor must have been
+	// marked wrong before their versions are passed over
+	distrustAfter = 2
+)
+
+// FeedbackScoreModel adds the band's accuracy ratings to another model.
+// Versions rated in the library move up or down by their average rating,
+// and contributors whose charts were marked wrong repeatedly, and more
+// often than not, are distrusted.
+type FeedbackScoreModel struct {
+	base  ScoreModel
+	store *library.Store
+}
+
+// NewFeedbackScoreModel creates a model that adjusts base by the feedback
+// recorded in store
+func NewFeedbackScoreModel(base ScoreModel, store *library.Store) *FeedbackScoreModel {
+	return &FeedbackScoreModel{
+		base:  base,
+		store: store,
+	}
+}
+
+// Score implements ScoreModel
+func (m *FeedbackScoreModel) Score(c scraper.SearchResult) float64 {
+	score := m.base.Score(c)
+
+	tabID, err := strconv.Atoi(c.ID)
+	if err != nil {
+		return score
+	}
+	stats, ok := m.store.TabStats(tabID)
+	if !ok {
+		return score
+	}
+
+	// A neutral 3 leaves the score alone; 1 and 5 move it the full weight
+	middle := float64(library.FeedbackMinRating+library.FeedbackMaxRating) / 2
+	span := float64(library.FeedbackMaxRating) - middle
+	return score + (stats.Average-middle)/span*feedbackWeight
+}
+
+// Distrusted implements ScoreModel
+func (m *FeedbackScoreModel) Distrusted(contributor string) bool {
+	if m.base.Distrusted(contributor) {
+		return true
+	}
+
+	stats, ok := m.store.ContributorStats(contributor)
+	return ok && stats.Wrong >= distrustAfter && stats.Wrong*2 > stats.Count
+}
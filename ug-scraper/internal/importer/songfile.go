@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// songFileExtensions are the chart formats ImportSongFile understands
+var songFileExtensions = map[string]bool{
+	".onsong":   true,
+	".chordpro": true,
+	".chopro":   true,
+	".cho":      true,
+	".crd":      true,
+	".pro":      true,
+	".txt":      true,
+}
+
+// IsSongFile reports whether filename has an OnSong, ChordPro or text extension
+func IsSongFile(filename string) bool {
+	return songFileExtensions[strings.ToLower(path.Ext(filename))]
+}
+
+// ImportSongFile imports an existing OnSong or ChordPro chart into the
+// library. Songs already in the library with the same artist and title are
+// left alone unless replace is set; equivalent ones are handled as the
+// duplicates policy says. Plain .txt files without a header are
+// stored as text tabs titled from their "Artist - Title.txt" name.
+func (p *Pipeline) ImportSongFile(filename, content string, replace bool) ArchiveItem {
+	item := ArchiveItem{File: filename}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	if strings.TrimSpace(content) == "" {
+		item.Status = StatusFailed
+		item.Error = "file is empty"
+		return item
+	}
+
+	parsed := converter.ParseSongFile(content)
+	isText := strings.EqualFold(path.Ext(filename), ".txt")
+	if parsed.Title == "" || (isText && !hasChartHeader(content, parsed)) {
+		return p.importTextTab(item, path.Base(filename), content)
+	}
+
+	if parsed.Artist == "" {
+		parsed.Artist = "Unknown Artist"
+	}
+	if parsed.Key == "" {
+		parsed.Key = p.converter.DetectKey(parsed.InlineChords())
+	}
+
+	song := &library.Song{
+		Title:        parsed.Title,
+		Artist:       parsed.Artist,
+		Key:          parsed.Key,
+		Capo:         parsed.Capo,
+		Tuning:       parsed.Tuning,
+		Source:       library.SourceFile,
+		Content:      content,
+		OnSongFormat: parsed.OnSong(),
+	}
+
+	var revision int
+	if existing, ok := p.library.FindByTitle(song.Artist, song.Title); ok {
+		if !replace {
+			item.Status = StatusExisting
+			item.SongID = existing.ID
+			item.Title = existing.Title
+			item.Artist = existing.Artist
+			return item
+		}
+		// Keep the ID and UG link so setlists and source matches still point at it
+		song.ID = existing.ID
+		song.SourceTabID = existing.SourceTabID
+		song.SourceURL = existing.SourceURL
+		song.PreferredKey = existing.PreferredKey
+		revision = existing.Revision
+	} else if link, duplicates := p.equivalents(song.Artist, song.Title); link != nil {
+		item.Status = StatusExisting
+		item.SongID = link.ID
+		item.Title = link.Title
+		item.Artist = link.Artist
+		return item
+	} else {
+		item.Duplicates = duplicates
+	}
+
+	var err error
+	if song.ID != "" {
+		// Replaced only if nobody changed or deleted it since it was found
+		err = p.library.SaveIfMatch(song, revision)
+	} else {
+		err = p.library.Save(song)
+	}
+	if err != nil {
+		item.Status = StatusFailed
+		item.Error = fmt.Sprintf("saving song: %v", err)
+		return item
+	}
+
+	item.Status = StatusImported
+	item.SongID = song.ID
+	item.Title = song.Title
+	item.Artist = song.Artist
+	return item
+}
+
+// hasChartHeader reports whether a .txt file is really an OnSong or ChordPro
+// chart rather than a plain text tab
+func hasChartHeader(content string, parsed *converter.SongFile) bool {
+	return converter.IsChordPro(content) || parsed.Key != "" || parsed.Capo > 0 || parsed.Tuning != ""
+}
@@ -0,0 +1,376 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+const (
+	defaultUpdateInterval = 24 * time.Hour
+	// updatePause spaces out tab fetches; a burst shortens it
+	updatePause = 5 * time.Second
+	// recheckAfter is how long to wait before fetching the same tab again
+	recheckAfter = 7 * 24 * time.Hour
+	// ratingJump is how far the rating must move to count as an update
+	ratingJump = 0.3
+	// defaultGigAlertDays is how far ahead a setlist counts as upcoming
+	defaultGigAlertDays = 14
+	// gigAlertLines caps the changed lines quoted in a gig alert
+	gigAlertLines = 12
+)
+
+// Changes found by the update checker
+const (
+	ChangeContent = "content"
+	ChangeRating  = "rating"
+)
+
+// UpdateChecker periodically re-fetches the source tabs of library songs
+// and records a pending update, with a diff, when UG's version changed
+type UpdateChecker struct {
+	ugClient *scraper.UGClient
+	pipeline *Pipeline
+	library  *library.Store
+	budget   *scraper.RateBudget
+	events   *events.Dispatcher
+	interval time.Duration
+	gigDays  int // Content changes to songs in setlists this many days ahead raise an alert
+
+	mu      sync.Mutex
+	running bool
+	lastRun time.Time
+	stop    chan struct{}
+}
+
+// UpdateRunResult summarizes a single update check pass
+type UpdateRunResult struct {
+	Checked   int       `json:"checked"`
+	Updated   int       `json:"updated"`
+	Errors    int       `json:"errors"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// NewUpdateChecker creates an update checker configured from
+// UPDATE_CHECK_INTERVAL (a Go duration, "0" disables the schedule) and
+// GIG_ALERT_DAYS (how many days ahead a setlist counts as upcoming, "0"
+// disables gig alerts)
+func NewUpdateChecker(ugClient *scraper.UGClient, pipeline *Pipeline, store *library.Store, budget *scraper.RateBudget, dispatcher *events.Dispatcher) *UpdateChecker {
+	interval := defaultUpdateInterval
+	if v := os.Getenv("UPDATE_CHECK_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			interval = d
+		} else if v == "0" {
+			interval = 0
+		}
+	}
+	gigDays := defaultGigAlertDays
+	if v := os.Getenv("GIG_ALERT_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			gigDays = n
+		}
+	}
+
+	return &UpdateChecker{
+		ugClient: ugClient,
+		pipeline: pipeline,
+		library:  store,
+		budget:   budget,
+		events:   dispatcher,
+		interval: interval,
+		gigDays:  gigDays,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the checker on its schedule in the background
+func (u *UpdateChecker) Start() {
+	if u.interval <= 0 {
+		fmt.Println("🔁 Update checker disabled")
+		return
+	}
+
+	fmt.Printf("🔁 Update checker running every %s\n", u.interval)
+	go func() {
+		ticker := time.NewTicker(u.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := u.Run(); err != nil {
+					fmt.Printf("⚠️  Update checker: %v\n", err)
+				}
+			case <-u.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background schedule
+func (u *UpdateChecker) Stop() {
+	close(u.stop)
+}
+
+// Run checks every song with a source tab that wasn't checked recently and
+// has no update waiting
+func (u *UpdateChecker) Run() (*UpdateRunResult, error) {
+	u.mu.Lock()
+	if u.running {
+		u.mu.Unlock()
+		return nil, fmt.Errorf("update checker is already running")
+	}
+	u.running = true
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		u.running = false
+		u.lastRun = time.Now()
+		u.mu.Unlock()
+	}()
+
+	result := &UpdateRunResult{StartedAt: time.Now()}
+	for _, song := range u.library.ListWithSource() {
+		if song.PendingUpdate != nil || time.Since(song.LastCheckedAt) < recheckAfter {
+			continue
+		}
+
+		if result.Checked > 0 {
+			time.Sleep(u.budget.Pause(updatePause))
+		}
+		result.Checked++
+
+		update, err := u.CheckSong(context.Background(), song.ID)
+		if err != nil {
+			fmt.Printf("   ✗ %s - %s: %v\n", song.Artist, song.Title, err)
+			result.Errors++
+			continue
+		}
+		if update != nil {
+			result.Updated++
+		}
+	}
+
+	result.Duration = time.Since(result.StartedAt).String()
+	fmt.Printf("🔁 Update checker: checked=%d updated=%d errors=%d\n", result.Checked, result.Updated, result.Errors)
+	return result, nil
+}
+
+// CheckSong re-fetches a song's source tab and records a pending update
+// when the content changed (unless that content was ignored before) or the
+// rating moved by ratingJump or more. It returns nil when nothing changed.
+func (u *UpdateChecker) CheckSong(ctx context.Context, songID string) (*library.SourceUpdate, error) {
+	song, ok := u.library.Get(songID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+	if !song.HasSource() {
+		return nil, fmt.Errorf("song has no source tab")
+	}
+
+	tab, err := u.ugClient.GetTabByID(ctx, fmt.Sprintf("%d", song.SourceTabID))
+
+	revision := song.Revision
+	song.LastCheckedAt = time.Now()
+	if err != nil {
+		_ = u.saveChecked(song, revision)
+		return nil, fmt.Errorf("fetching tab: %w", err)
+	}
+
+	update := &library.SourceUpdate{
+		TabID:     song.SourceTabID,
+		Rating:    tab.Rating,
+		Votes:     tab.Votes,
+		OldRating: song.Rating,
+		OldVotes:  song.Votes,
+		FoundAt:   time.Now(),
+	}
+
+	oldContent, newContent := normalizeContent(song.Content), normalizeContent(tab.Content)
+	if oldContent != newContent && newContent != "" {
+		hash := contentHash(newContent)
+		if hash != song.IgnoredContent {
+			update.Changes = append(update.Changes, ChangeContent)
+			update.ContentHash = hash
+			update.Diff, update.Added, update.Removed = unifiedDiff(oldContent, newContent)
+		}
+	}
+	if math.Abs(tab.Rating-song.Rating) >= ratingJump {
+		update.Changes = append(update.Changes, ChangeRating)
+	}
+
+	if len(update.Changes) == 0 {
+		update = nil
+	}
+	song.PendingUpdate = update
+
+	err = u.saveChecked(song, revision)
+	if err == library.ErrConflict {
+		// Edited while the tab was fetched; the next run checks it again
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("saving update: %w", err)
+	}
+
+	if update != nil {
+		fmt.Printf("🔁 Update found for %s - %s: %v\n", song.Artist, song.Title, update.Changes)
+		u.events.PublishEvent(events.TabUpdateFound, map[string]interface{}{
+			"id":      song.ID,
+			"title":   song.Title,
+			"artist":  song.Artist,
+			"tab_id":  song.SourceTabID,
+			"changes": update.Changes,
+		})
+		if update.ContentHash != "" {
+			u.alertUpcomingGigs(song, update)
+		}
+	}
+
+	return update, nil
+}
+
+// alertUpcomingGigs publishes GigChartChanged when a song whose chart
+// changed on UG is in a setlist coming up soon, so the band leader can
+// review the change before the gig
+func (u *UpdateChecker) alertUpcomingGigs(song *library.Song, update *library.SourceUpdate) {
+	if u.gigDays <= 0 {
+		return
+	}
+	setlists := u.library.UpcomingSetlists(song.ID, u.gigDays)
+	if len(setlists) == 0 {
+		return
+	}
+
+	gigs := make([]map[string]string, len(setlists))
+	for i, setlist := range setlists {
+		gigs[i] = map[string]string{
+			"id":   setlist.ID,
+			"name": setlist.Name,
+			"date": setlist.Date,
+		}
+	}
+
+	fmt.Printf("📣 %s - %s changed and is in %d upcoming setlists, the first on %s\n", song.Artist, song.Title, len(setlists), setlists[0].Date)
+	u.events.PublishEvent(events.GigChartChanged, map[string]interface{}{
+		"id":       song.ID,
+		"title":    song.Title,
+		"artist":   song.Artist,
+		"tab_id":   song.SourceTabID,
+		"setlists": gigs,
+		"added":    update.Added,
+		"removed":  update.Removed,
+		"changed":  changedLines(update.Diff, gigAlertLines),
+	})
+}
+
+// Accept replaces a song's chart with the current version of its source
+// tab, which is fetched again so the latest edits are applied
+func (u *UpdateChecker) Accept(ctx context.Context, songID string) (*library.Song, error) {
+	song, ok := u.library.Get(songID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+	if song.PendingUpdate == nil {
+		return nil, fmt.Errorf("song has no pending update")
+	}
+	revision := song.Revision
+
+	tab, err := u.ugClient.GetTabByID(ctx, fmt.Sprintf("%d", song.SourceTabID))
+	if err != nil {
+		return nil, fmt.Errorf("fetching tab: %w", err)
+	}
+
+	converted, err := u.pipeline.convertTab(tab)
+	if err != nil {
+		return nil, err
+	}
+
+	song.SourceURL = converted.SourceURL
+	song.Rating = converted.Rating
+	song.Votes = converted.Votes
+	song.Type = converted.Type
+	song.Key = converted.Key
+	song.Capo = converted.Capo
+	song.Tuning = converted.Tuning
+	song.Content = converted.Content
+	song.OnSongFormat = converted.OnSongFormat
+	song.PendingUpdate = nil
+	song.IgnoredContent = ""
+
+	if err := u.saveChecked(song, revision); err != nil {
+		return nil, err
+	}
+
+	return song, nil
+}
+
+// Ignore keeps a song's chart as it is. The new rating becomes the baseline
+// and the ignored content is remembered so it isn't offered again.
+func (u *UpdateChecker) Ignore(songID string) (*library.Song, error) {
+	song, ok := u.library.Get(songID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+	if song.PendingUpdate == nil {
+		return nil, fmt.Errorf("song has no pending update")
+	}
+
+	song.Rating = song.PendingUpdate.Rating
+	song.Votes = song.PendingUpdate.Votes
+	if song.PendingUpdate.ContentHash != "" {
+		song.IgnoredContent = song.PendingUpdate.ContentHash
+	}
+	song.PendingUpdate = nil
+
+	if err := u.saveChecked(song, song.Revision); err != nil {
+		return nil, err
+	}
+
+	return song, nil
+}
+
+// saveChecked saves a song only if it is still at the revision it was read
+// at. It returns ErrNotFound when the song was deleted in the meantime and
+// ErrConflict when it was changed, so the caller can try again later.
+func (u *UpdateChecker) saveChecked(song *library.Song, revision int) error {
+	err := u.library.SaveIfMatch(song, revision)
+	if err == library.ErrConflict {
+		if _, ok := u.library.Get(song.ID); !ok {
+			return library.ErrNotFound
+		}
+	}
+	return err
+}
+
+// Status reports the checker configuration and last run time
+func (u *UpdateChecker) Status() map[string]interface{} {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return map[string]interface{}{
+		"enabled":  u.interval > 0,
+		"interval": u.interval.String(),
+		"running":  u.running,
+		"last_run": u.lastRun,
+	}
+}
+
+// contentHash fingerprints normalized tab content
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,248 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
+)
+
+const (
+	defaultWatchInterval = 24 * time.Hour
+	// watchPause spaces out searches; a burst shortens it
+	watchPause = 5 * time.Second
+)
+
+// Watcher periodically re-runs the searches on the watchlist and publishes
+// an event when tabs show up that weren't there before
+type Watcher struct {
+	searchScraper *scraper.SearchScraper
+	library       *library.Store
+	budget        *scraper.RateBudget
+	events        *events.Dispatcher
+	webhook       *webhook.Client
+	interval      time.Duration
+
+	mu      sync.Mutex
+	running bool
+	lastRun time.Time
+	stop    chan struct{}
+}
+
+// WatchRunResult summarizes a single watchlist pass
+type WatchRunResult struct {
+	Checked   int       `json:"checked"`
+	NewTabs   int       `json:"new_tabs"`
+	Errors    int       `json:"errors"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// NewWatcher creates a watcher configured from WATCHLIST_INTERVAL (a Go
+// duration, "0" disables the schedule)
+func NewWatcher(searchScraper *scraper.SearchScraper, store *library.Store, budget *scraper.RateBudget, dispatcher *events.Dispatcher, webhookClient *webhook.Client) *Watcher {
+	interval := defaultWatchInterval
+	if v := os.Getenv("WATCHLIST_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			interval = d
+		} else if v == "0" {
+			interval = 0
+		}
+	}
+
+	return &Watcher{
+		searchScraper: searchScraper,
+		library:       store,
+		budget:        budget,
+		events:        dispatcher,
+		webhook:       webhookClient,
+		interval:      interval,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Start runs the watcher on its schedule in the background
+func (w *Watcher) Start() {
+	if w.interval <= 0 {
+		fmt.Println("👀 Watchlist disabled")
+		return
+	}
+
+	fmt.Printf("👀 Watchlist running every %s\n", w.interval)
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := w.Run(); err != nil {
+					fmt.Printf("⚠️  Watchlist: %v\n", err)
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background schedule
+func (w *Watcher) Stop() {
+	close(w.stop)
+}
+
+// Run searches again for every watch on the list
+func (w *Watcher) Run() (*WatchRunResult, error) {
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("watchlist is already running")
+	}
+	w.running = true
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.running = false
+		w.lastRun = time.Now()
+		w.mu.Unlock()
+	}()
+
+	result := &WatchRunResult{StartedAt: time.Now()}
+	for _, watch := range w.library.ListWatches() {
+		if result.Checked > 0 {
+			time.Sleep(w.budget.Pause(watchPause))
+		}
+		result.Checked++
+
+		hits, err := w.CheckWatch(context.Background(), watch.ID)
+		if err != nil {
+			fmt.Printf("   ✗ %s: %v\n", watch.Query(), err)
+			result.Errors++
+			continue
+		}
+		result.NewTabs += len(hits)
+	}
+
+	result.Duration = time.Since(result.StartedAt).String()
+	fmt.Printf("👀 Watchlist: checked=%d new_tabs=%d errors=%d\n", result.Checked, result.NewTabs, result.Errors)
+	return result, nil
+}
+
+// CheckWatch runs a watch's search and returns the tabs that are new to it.
+// The first search of a watch only records what already exists, so adding
+// an artist doesn't announce their whole back catalogue.
+func (w *Watcher) CheckWatch(ctx context.Context, watchID string) ([]library.WatchHit, error) {
+	watch, ok := w.library.GetWatch(watchID)
+	if !ok {
+		return nil, library.ErrNotFound
+	}
+
+	results, err := w.searchScraper.SearchTabs(ctx, scraper.SearchOptions{Query: watch.Query(), Type: watch.Type})
+
+	firstRun := watch.LastRunAt.IsZero()
+	watch.LastRunAt = time.Now()
+	if err != nil {
+		watch.LastError = err.Error()
+		_ = w.library.SaveWatch(watch)
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	watch.LastError = ""
+
+	var hits []library.WatchHit
+	for _, r := range results {
+		if r.ID == "" || watch.Seen(r.ID) || !watchMatches(watch, r) {
+			continue
+		}
+		hits = append(hits, library.WatchHit{
+			TabID:   r.ID,
+			Title:   r.Title,
+			Artist:  r.Artist,
+			Type:    r.Type,
+			Rating:  r.Rating,
+			URL:     r.URL,
+			FoundAt: watch.LastRunAt,
+		})
+	}
+
+	if firstRun {
+		for _, hit := range hits {
+			watch.SeenTabIDs = append(watch.SeenTabIDs, hit.TabID)
+		}
+		hits = nil
+	} else if len(hits) > 0 {
+		watch.AddHits(hits)
+		watch.LastFoundAt = watch.LastRunAt
+	}
+
+	if err := w.library.SaveWatch(watch); err != nil {
+		return nil, fmt.Errorf("saving watch: %w", err)
+	}
+
+	if len(hits) > 0 {
+		w.announce(watch, hits)
+	}
+
+	return hits, nil
+}
+
+// Status reports the watcher configuration and last run time
+func (w *Watcher) Status() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return map[string]interface{}{
+		"enabled":  w.interval > 0,
+		"interval": w.interval.String(),
+		"running":  w.running,
+		"last_run": w.lastRun,
+	}
+}
+
+// announce publishes the new tabs of a watch as an event, and posts it to
+// the watch's own webhook when it has one
+func (w *Watcher) announce(watch *library.Watch, hits []library.WatchHit) {
+	fmt.Printf("👀 %d new tab(s) for %s\n", len(hits), watch.Query())
+
+	data := map[string]interface{}{
+		"watch_id": watch.ID,
+		"artist":   watch.Artist,
+		"song":     watch.Song,
+		"tabs":     hits,
+	}
+	w.events.PublishEvent(events.WatchNewTabs, data)
+
+	if watch.WebhookURL == "" {
+		return
+	}
+	go func() {
+		err := w.webhook.SendEvent(context.Background(), webhook.Target{URL: watch.WebhookURL}, &webhook.EventPayload{
+			Event:     events.WatchNewTabs,
+			Data:      data,
+			Timestamp: time.Now(),
+			Source:    "Ultimate Guitar Scraper",
+		})
+		if err != nil {
+			fmt.Printf("⚠️  Watchlist webhook for %s failed: %v\n", watch.Query(), err)
+		}
+	}()
+}
+
+// watchMatches reports whether a search result belongs to the watch: the
+// artist must match, and the title too when the watch names a song
+func watchMatches(watch *library.Watch, r scraper.SearchResult) bool {
+	if matchScore(library.NormalizeName(watch.Artist), library.NormalizeName(r.Artist)) == 0 {
+		return false
+	}
+	if watch.Song == "" {
+		return true
+	}
+	return strings.Contains(library.NormalizeName(r.Title), library.NormalizeName(watch.Song))
+}
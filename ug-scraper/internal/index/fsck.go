@@ -0,0 +1,80 @@
+package index
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/fsck"
+)
+
+// Check compares the index with the library: songs missing from it or
+// indexed at another revision, and entries for deleted songs. With repair
+// the entries are fixed in place and the index is saved.
+func (m *Manager) Check(repair bool) ([]fsck.Issue, error) {
+	songs := m.store.List()
+
+	var issues []fsck.Issue
+	if m.path != "" {
+		if _, err := os.Stat(m.path + ".tmp"); err == nil {
+			issue := fsck.Issue{
+				Area:       fsck.AreaIndex,
+				Kind:       "leftover_temp_file",
+				ID:         m.path + ".tmp",
+				Message:    "an interrupted save left a temp file next to the index",
+				Repairable: true,
+			}
+			if repair && os.Remove(m.path+".tmp") == nil {
+				issue.Repaired = true
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	m.mu.Lock()
+	if m.current == nil || m.building {
+		m.mu.Unlock()
+		return issues, nil
+	}
+
+	fixed := 0
+	report := func(kind, id, message string, fix func()) {
+		issue := fsck.Issue{Area: fsck.AreaIndex, Kind: kind, ID: id, Message: message, Repairable: true}
+		if repair {
+			fix()
+			issue.Repaired = true
+			fixed++
+		}
+		issues = append(issues, issue)
+	}
+
+	present := make(map[string]bool, len(songs))
+	for i := range songs {
+		song := &songs[i]
+		present[song.ID] = true
+		doc, ok := m.current.Docs[song.ID]
+		switch {
+		case !ok:
+			report("missing_entry", song.ID, "song is not in the search index", func() { m.current.add(song) })
+		case doc.Revision != song.Revision:
+			report("stale_entry", song.ID, fmt.Sprintf("song is indexed at revision %d but is at revision %d", doc.Revision, song.Revision), func() { m.current.add(song) })
+		}
+	}
+	var orphans []string
+	for id := range m.current.Docs {
+		if !present[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	sort.Strings(orphans)
+	for _, id := range orphans {
+		report("orphaned_entry", id, "search index has an entry for a deleted song", func() { m.current.remove(id) })
+	}
+	m.repaired += fixed
+	m.mu.Unlock()
+
+	if fixed > 0 {
+		m.save()
+	}
+	return issues, nil
+}
@@ -0,0 +1,302 @@
+// Package index keeps a full-text and chord index of the library that can
+// be rebuilt in the background while the previous one keeps serving
+// searches.
+package index
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// SchemaVersion is the version of the indexing logic. Bump it whenever the
+// tokenizing or the indexed fields change: an index saved by another version
+// is rebuilt in the background at startup.
+const SchemaVersion = 1
+
+// Term weights by field
+const (
+	weightTitle  = 5
+	weightArtist = 3
+	weightLyrics = 1
+)
+
+// minPrefix is the shortest query word matched as a prefix
+const minPrefix = 3
+
+// ErrNotReady is returned by searches before the first index is built
+var ErrNotReady = errors.New("search index is being built")
+
+var (
+	// inlineChordRegex matches an inline [chord]
+	inlineChordRegex = regexp.MustCompile(`\[([^\]]+)\]`)
+	// metadataLineRegex matches OnSong header lines and ChordPro directives,
+	// which are not lyrics
+	metadataLineRegex = regexp.MustCompile(`(?i)^(?:\{.*\}|(?:title|artist|key|capo|tempo|time|tuning|ccli|copyright|duration|book|flow|keywords):.*)$`)
+)
+
+// document is what the index knows about one song
+type document struct {
+	Revision int            `json:"revision"`
+	Terms    map[string]int `json:"terms"` // Word and its weight
+	Chords   []string       `json:"chords,omitempty"`
+}
+
+// snapshot is one generation of the index. Postings are derived from the
+// documents and not saved.
+type snapshot struct {
+	SchemaVersion int                  `json:"schema_version"`
+	BuiltAt       time.Time            `json:"built_at"`
+	Docs          map[string]*document `json:"docs"`
+
+	terms  map[string]map[string]int  // Word -> song ID -> weight
+	chords map[string]map[string]bool // Chord -> song IDs
+}
+
+// newSnapshot creates an empty index generation
+func newSnapshot() *snapshot {
+	return &snapshot{
+		SchemaVersion: SchemaVersion,
+		BuiltAt:       time.Now(),
+		Docs:          make(map[string]*document),
+		terms:         make(map[string]map[string]int),
+		chords:        make(map[string]map[string]bool),
+	}
+}
+
+// rebuildPostings fills the postings from the documents, after loading
+func (s *snapshot) rebuildPostings() {
+	s.terms = make(map[string]map[string]int)
+	s.chords = make(map[string]map[string]bool)
+	for id, doc := range s.Docs {
+		s.post(id, doc)
+	}
+}
+
+// add indexes a song, replacing any earlier version of it
+func (s *snapshot) add(song *library.Song) {
+	s.remove(song.ID)
+	doc := analyze(song)
+	s.Docs[song.ID] = doc
+	s.post(song.ID, doc)
+}
+
+// post adds a document's postings
+func (s *snapshot) post(id string, doc *document) {
+	for term, weight := range doc.Terms {
+		if s.terms[term] == nil {
+			s.terms[term] = make(map[string]int)
+		}
+		s.terms[term][id] = weight
+	}
+	for _, chord := range doc.Chords {
+		if s.chords[chord] == nil {
+			s.chords[chord] = make(map[string]bool)
+		}
+		s.chords[chord][id] = true
+	}
+}
+
+// remove drops a song from the index
+func (s *snapshot) remove(id string) {
+	doc, ok := s.Docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.Terms {
+		delete(s.terms[term], id)
+		if len(s.terms[term]) == 0 {
+			delete(s.terms, term)
+		}
+	}
+	for _, chord := range doc.Chords {
+		delete(s.chords[chord], id)
+		if len(s.chords[chord]) == 0 {
+			delete(s.chords, chord)
+		}
+	}
+	delete(s.Docs, id)
+}
+
+// analyze extracts a song's weighted words and the chords it uses
+func analyze(song *library.Song) *document {
+	doc := &document{Revision: song.Revision, Terms: make(map[string]int)}
+	addTerms := func(text string, weight int) {
+		for _, term := range tokenize(text) {
+			doc.Terms[term] = max(doc.Terms[term], weight)
+		}
+	}
+	addTerms(song.Title, weightTitle)
+	addTerms(song.Artist, weightArtist)
+
+	chart := song.OnSongFormat
+	if chart == "" {
+		chart = song.Content
+	}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(chart, "\n") {
+		line = strings.TrimSpace(line)
+		if metadataLineRegex.MatchString(line) {
+			continue
+		}
+		for _, m := range inlineChordRegex.FindAllStringSubmatch(line, -1) {
+			if chord := normalizeChord(m[1]); chord != "" && !seen[chord] {
+				seen[chord] = true
+				doc.Chords = append(doc.Chords, chord)
+			}
+		}
+		addTerms(inlineChordRegex.ReplaceAllString(line, ""), weightLyrics)
+	}
+	sort.Strings(doc.Chords)
+
+	return doc
+}
+
+// tokenize splits text into lowercase words of two or more letters or digits
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	terms := words[:0]
+	for _, w := range words {
+		w = strings.Trim(w, "'")
+		if len([]rune(w)) >= 2 {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// normalizeChord spells a chord with sharps so A# and Bb are the same chord;
+// text that isn't a chord gives ""
+func normalizeChord(chord string) string {
+	chord = strings.TrimSpace(chord)
+	if r := []rune(chord); len(r) > 0 {
+		// Typed queries often lowercase the root: "em" for Em
+		chord = string(unicode.ToUpper(r[0])) + string(r[1:])
+	}
+	if !converter.ValidChord(chord) {
+		return ""
+	}
+	return converter.RespellChord(chord, converter.SpellingSharps, "")
+}
+
+// Query is a library search
+type Query struct {
+	Text       string   // Every word must appear in the title, artist or lyrics
+	Chords     []string // Every chord must be used by the song
+	OnlyChords bool     // The song may use no chords beyond Chords
+	Limit      int      // 0 means no limit
+}
+
+// Hit is a song matching a query
+type Hit struct {
+	SongID string   `json:"song_id"`
+	Score  int      `json:"score"`
+	Chords []string `json:"chords,omitempty"`
+}
+
+// search runs a query against this generation
+func (s *snapshot) search(q Query) []Hit {
+	var candidates map[string]int
+	narrow := func(matches map[string]int) {
+		if candidates == nil {
+			candidates = matches
+			return
+		}
+		for id, score := range candidates {
+			if add, ok := matches[id]; ok {
+				candidates[id] = score + add
+			} else {
+				delete(candidates, id)
+			}
+		}
+	}
+
+	for _, word := range tokenize(q.Text) {
+		narrow(s.matchWord(word))
+	}
+
+	wanted := make(map[string]bool)
+	for _, chord := range q.Chords {
+		chord = normalizeChord(chord)
+		if chord == "" {
+			continue
+		}
+		wanted[chord] = true
+		matches := make(map[string]int)
+		for id := range s.chords[chord] {
+			matches[id] = 0
+		}
+		narrow(matches)
+	}
+
+	if candidates == nil {
+		if !q.OnlyChords || len(wanted) == 0 {
+			return []Hit{}
+		}
+		candidates = make(map[string]int)
+		for id := range s.Docs {
+			candidates[id] = 0
+		}
+	}
+
+	hits := make([]Hit, 0, len(candidates))
+	for id, score := range candidates {
+		doc := s.Docs[id]
+		if q.OnlyChords && !subset(doc.Chords, wanted) {
+			continue
+		}
+		hits = append(hits, Hit{SongID: id, Score: score, Chords: doc.Chords})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].SongID < hits[j].SongID
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits
+}
+
+// matchWord returns the songs containing a word, or a word it starts with
+// for words of minPrefix letters or more, with the best weight found
+func (s *snapshot) matchWord(word string) map[string]int {
+	matches := make(map[string]int)
+	for id, weight := range s.terms[word] {
+		matches[id] = weight
+	}
+	if len([]rune(word)) < minPrefix {
+		return matches
+	}
+
+	for term, postings := range s.terms {
+		if term == word || !strings.HasPrefix(term, word) {
+			continue
+		}
+		for id, weight := range postings {
+			matches[id] = max(matches[id], weight)
+		}
+	}
+	return matches
+}
+
+// subset reports whether every chord is one of wanted
+func subset(chords []string, wanted map[string]bool) bool {
+	for _, chord := range chords {
+		if !wanted[chord] {
+			return false
+		}
+	}
+	return true
+}
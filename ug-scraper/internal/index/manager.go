@@ -0,0 +1,290 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// Manager owns the library index: it keeps it in step with song edits,
+// saves it to disk and rebuilds it in the background when asked or when the
+// saved index was made by an older SchemaVersion. Searches keep using the
+// previous generation until a rebuild is swapped in.
+type Manager struct {
+	store *library.Store
+	path  string
+
+	mu          sync.RWMutex
+	current     *snapshot
+	building    bool
+	buildReason string
+	pending     map[string]*library.Song // Edits made during a rebuild; nil marks a deletion
+	builds      int
+	lastBuild   time.Duration
+	repaired    int
+	lastErr     string
+}
+
+// Status reports the state of the index
+type Status struct {
+	SchemaVersion  int       `json:"schema_version"`
+	IndexedVersion int       `json:"indexed_version"` // Version of the generation serving searches
+	Documents      int       `json:"documents"`
+	Songs          int       `json:"songs"`
+	Terms          int       `json:"terms"`
+	Chords         int       `json:"chords"`
+	BuiltAt        time.Time `json:"built_at,omitempty"`
+	Building       bool      `json:"building"`
+	BuildReason    string    `json:"build_reason,omitempty"`
+	Builds         int       `json:"builds"`
+	LastBuild      string    `json:"last_build_duration,omitempty"`
+	Repaired       int       `json:"repaired"` // Stale entries fixed when the saved index was loaded
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// NewManager creates an index manager for store that saves the index to
+// path; an empty path keeps it in memory only. Call Start to load or build it.
+func NewManager(store *library.Store, path string) *Manager {
+	m := &Manager{
+		store: store,
+		path:  path,
+	}
+	store.OnSongChange(m.songChanged)
+	return m
+}
+
+// Start loads the saved index, repairing entries for songs changed since it
+// was saved, or starts building one when there is none or it is outdated
+func (m *Manager) Start() {
+	snap, err := m.load()
+	switch {
+	case err == nil && snap.SchemaVersion == SchemaVersion:
+		repaired := m.repair(snap)
+		m.mu.Lock()
+		m.current, m.repaired = snap, repaired
+		m.mu.Unlock()
+		fmt.Printf("🔎 Search index loaded: %d songs (%d stale entries repaired)\n", len(snap.Docs), repaired)
+		if repaired > 0 {
+			m.save()
+		}
+	case err == nil:
+		// Keep serving the old generation while the new one is built
+		m.mu.Lock()
+		m.current = snap
+		m.mu.Unlock()
+		m.Reindex(fmt.Sprintf("schema upgrade from v%d to v%d", snap.SchemaVersion, SchemaVersion))
+	case os.IsNotExist(err):
+		m.Reindex("no saved index")
+	default:
+		fmt.Printf("⚠️  Search index unreadable, rebuilding: %v\n", err)
+		m.Reindex("saved index unreadable")
+	}
+}
+
+// Reindex rebuilds the index from the library in the background and swaps
+// it in when done. It returns false when a rebuild is already running.
+func (m *Manager) Reindex(reason string) bool {
+	m.mu.Lock()
+	if m.building {
+		m.mu.Unlock()
+		return false
+	}
+	m.building = true
+	m.buildReason = reason
+	m.pending = make(map[string]*library.Song)
+	m.mu.Unlock()
+
+	fmt.Printf("🔎 Rebuilding search index (%s)\n", reason)
+	go m.rebuild()
+	return true
+}
+
+// rebuild builds a new generation and swaps it in
+func (m *Manager) rebuild() {
+	start := time.Now()
+	snap := newSnapshot()
+	for _, song := range m.store.List() {
+		snap.add(&song)
+	}
+
+	m.mu.Lock()
+	for id, song := range m.pending {
+		if song == nil {
+			snap.remove(id)
+		} else {
+			snap.add(song)
+		}
+	}
+	m.current = snap
+	m.pending = nil
+	m.building = false
+	m.builds++
+	m.lastBuild = time.Since(start)
+	m.mu.Unlock()
+
+	fmt.Printf("✅ Search index rebuilt: %d songs in %s\n", len(snap.Docs), time.Since(start).Round(time.Millisecond))
+	m.save()
+}
+
+// songChanged keeps the index in step with the library
+func (m *Manager) songChanged(id string, song *library.Song) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.building {
+		m.pending[id] = song
+	}
+	if m.current == nil {
+		return
+	}
+	if song == nil {
+		m.current.remove(id)
+	} else {
+		m.current.add(song)
+	}
+}
+
+// repair re-indexes songs that changed since snap was saved and drops songs
+// that no longer exist, returning how many entries were fixed
+func (m *Manager) repair(snap *snapshot) int {
+	songs := m.store.List()
+	fixed := 0
+	present := make(map[string]bool, len(songs))
+	for i := range songs {
+		present[songs[i].ID] = true
+		if doc, ok := snap.Docs[songs[i].ID]; !ok || doc.Revision != songs[i].Revision {
+			snap.add(&songs[i])
+			fixed++
+		}
+	}
+	for id := range snap.Docs {
+		if !present[id] {
+			snap.remove(id)
+			fixed++
+		}
+	}
+	return fixed
+}
+
+// Search runs a query against the current index
+func (m *Manager) Search(q Query) ([]Hit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.current == nil {
+		return nil, ErrNotReady
+	}
+	return m.current.search(q), nil
+}
+
+// Status returns the state of the index
+func (m *Manager) Status() Status {
+	songs := m.store.Count()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := Status{
+		SchemaVersion: SchemaVersion,
+		Songs:         songs,
+		Building:      m.building,
+		BuildReason:   m.buildReason,
+		Builds:        m.builds,
+		Repaired:      m.repaired,
+		LastError:     m.lastErr,
+	}
+	if m.lastBuild > 0 {
+		status.LastBuild = m.lastBuild.Round(time.Millisecond).String()
+	}
+	if m.current != nil {
+		status.IndexedVersion = m.current.SchemaVersion
+		status.Documents = len(m.current.Docs)
+		status.Terms = len(m.current.terms)
+		status.Chords = len(m.current.chords)
+		status.BuiltAt = m.current.BuiltAt
+	}
+	return status
+}
+
+// Health scores the index: whole when it covers every song with the
+// current schema, half while a build it waits for runs, and low enough for
+// the health monitor to rebuild it when it drifted from the library
+func (m *Manager) Health() (float64, string) {
+	status := m.Status()
+	switch {
+	case status.IndexedVersion == 0:
+		return 0.5, "building the first index"
+	case status.IndexedVersion != SchemaVersion:
+		return 0.5, fmt.Sprintf("serving schema v%d while v%d is built", status.IndexedVersion, SchemaVersion)
+	case status.Documents != status.Songs && !status.Building:
+		return 0.25, fmt.Sprintf("%d of %d songs indexed", status.Documents, status.Songs)
+	case status.LastError != "":
+		return 1, fmt.Sprintf("%d songs indexed; not saved: %s", status.Documents, status.LastError)
+	}
+	return 1, fmt.Sprintf("%d songs indexed (schema v%d)", status.Documents, SchemaVersion)
+}
+
+// Recover rebuilds the index, for the health monitor
+func (m *Manager) Recover() (string, error) {
+	if !m.Reindex("health check") {
+		return "", fmt.Errorf("a rebuild is already running")
+	}
+	return "started a search index rebuild", nil
+}
+
+// load reads the saved index
+func (m *Manager) load() (*snapshot, error) {
+	if m.path == "" {
+		return nil, os.ErrNotExist
+	}
+
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parsing index file: %w", err)
+	}
+	if snap.Docs == nil {
+		snap.Docs = make(map[string]*document)
+	}
+	snap.rebuildPostings()
+	return &snap, nil
+}
+
+// save writes the current index to disk, recording any failure for Status
+func (m *Manager) save() {
+	if m.path == "" {
+		return
+	}
+
+	m.mu.RLock()
+	encoded, err := json.Marshal(m.current)
+	m.mu.RUnlock()
+
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(m.path), 0755)
+	}
+	if err == nil {
+		// Write to a temp file and rename so a crash never leaves a truncated index
+		tmpPath := m.path + ".tmp"
+		if err = os.WriteFile(tmpPath, encoded, 0644); err == nil {
+			err = os.Rename(tmpPath, m.path)
+		}
+	}
+
+	m.mu.Lock()
+	m.lastErr = ""
+	if err != nil {
+		m.lastErr = err.Error()
+		fmt.Printf("⚠️  Failed to save search index: %v\n", err)
+	}
+	m.mu.Unlock()
+}
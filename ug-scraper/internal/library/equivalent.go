@@ -0,0 +1,64 @@
+package library
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// unknownArtist is the artist given to songs imported without one
+const unknownArtist = "unknown artist"
+
+var (
+	versionSuffix   = regexp.MustCompile(`\s*\((ver|version)[^)]*\)`)
+	nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// NormalizeName lowercases a title or artist and strips punctuation,
+// version suffixes and a leading "the"
+func NormalizeName(s string) string {
+	s = strings.ToLower(s)
+	s = versionSuffix.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "&", " and ")
+	s = nonAlphanumeric.ReplaceAllString(s, " ")
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "the ")
+	return s
+}
+
+// sameArtist reports whether two normalized artists may be the same; a
+// missing or unknown artist matches any
+func sameArtist(a, b string) bool {
+	if a == "" || b == "" || a == unknownArtist || b == unknownArtist {
+		return true
+	}
+	return a == b
+}
+
+// Equivalent reports whether two songs are the same song under a slightly
+// different spelling: equal titles and artists after NormalizeName
+func Equivalent(a, b *Song) bool {
+	title := NormalizeName(a.Title)
+	return title != "" && title == NormalizeName(b.Title) &&
+		sameArtist(NormalizeName(a.Artist), NormalizeName(b.Artist))
+}
+
+// FindEquivalent returns the songs equivalent to the given artist and
+// title, oldest first
+func (s *Store) FindEquivalent(artist, title string) []Song {
+	want := &Song{Title: title, Artist: artist}
+
+	s.mu.RLock()
+	var songs []Song
+	for _, song := range s.songs {
+		if Equivalent(want, song) {
+			songs = append(songs, *song.clone())
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(songs, func(i, j int) bool {
+		return songs[i].CreatedAt.Before(songs[j].CreatedAt)
+	})
+	return songs
+}
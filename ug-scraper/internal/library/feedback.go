@@ -0,0 +1,214 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxFeedback caps how many feedback entries are kept; the oldest are dropped
+const maxFeedback = 5000
+
+// Feedback ratings: how accurate an imported chart turned out to be
+const (
+	FeedbackMinRating = 1 // Wrong: chords or structure don't match the song
+	FeedbackMaxRating = 5 // Spot on
+	// FeedbackWrongRating and below count as marking the chart wrong
+	FeedbackWrongRating = 2
+)
+
+// Feedback is a user's verdict on how accurate a scraped chart was. The UG
+// tab and contributor are copied from the song, so the verdict still counts
+// after the song is deleted or replaced by another version.
+type Feedback struct {
+	ID          string    `json:"id"`
+	SongID      string    `json:"song_id"`
+	Title       string    `json:"title"`
+	Artist      string    `json:"artist"`
+	TabID       int       `json:"tab_id,omitempty"`
+	Contributor string    `json:"contributor,omitempty"`
+	Rating      int       `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Wrong reports whether the feedback marks the chart as wrong
+func (f *Feedback) Wrong() bool {
+	return f.Rating <= FeedbackWrongRating
+}
+
+// FeedbackStats aggregates the feedback given to a tab or contributor
+type FeedbackStats struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+	Wrong   int     `json:"wrong"` // Ratings marking the chart wrong
+}
+
+// add counts one rating into the stats
+func (s *FeedbackStats) add(f *Feedback) {
+	s.Average = (s.Average*float64(s.Count) + float64(f.Rating)) / float64(s.Count+1)
+	s.Count++
+	if f.Wrong() {
+		s.Wrong++
+	}
+}
+
+// TabFeedback is the aggregated feedback of one UG tab
+type TabFeedback struct {
+	TabID       int    `json:"tab_id"`
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Contributor string `json:"contributor,omitempty"`
+	FeedbackStats
+}
+
+// ContributorFeedback is the aggregated feedback of one UG contributor's tabs
+type ContributorFeedback struct {
+	Contributor string `json:"contributor"`
+	FeedbackStats
+}
+
+// FeedbackSummary is the feedback of every tab and contributor, worst first
+type FeedbackSummary struct {
+	FeedbackStats
+	Tabs         []TabFeedback         `json:"tabs"`
+	Contributors []ContributorFeedback `json:"contributors"`
+}
+
+// AddFeedback records a rating for a library song, assigning its ID and
+// timestamp. The song must exist.
+func (s *Store) AddFeedback(feedback *Feedback) error {
+	if feedback == nil {
+		return fmt.Errorf("feedback cannot be nil")
+	}
+	if feedback.Rating < FeedbackMinRating || feedback.Rating > FeedbackMaxRating {
+		return fmt.Errorf("rating must be between %d and %d", FeedbackMinRating, FeedbackMaxRating)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, ok := s.songs[feedback.SongID]
+	if !ok {
+		return ErrNotFound
+	}
+	feedback.Title, feedback.Artist = song.Title, song.Artist
+	feedback.TabID, feedback.Contributor = song.SourceTabID, song.Contributor
+	feedback.Comment = strings.TrimSpace(feedback.Comment)
+	feedback.ID = s.nextID("feedback")
+	feedback.CreatedAt = time.Now()
+
+	entry := *feedback
+	s.feedback = append(s.feedback, &entry)
+	if len(s.feedback) > maxFeedback {
+		s.feedback = append([]*Feedback(nil), s.feedback[len(s.feedback)-maxFeedback:]...)
+	}
+
+	return s.persist()
+}
+
+// ListFeedback returns the feedback given to a song, newest first
+func (s *Store) ListFeedback(songID string) []Feedback {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []Feedback
+	for i := len(s.feedback) - 1; i >= 0; i-- {
+		if s.feedback[i].SongID == songID {
+			entries = append(entries, *s.feedback[i])
+		}
+	}
+	return entries
+}
+
+// TabStats returns the aggregated feedback of a UG tab
+func (s *Store) TabStats(tabID int) (FeedbackStats, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats FeedbackStats
+	for _, f := range s.feedback {
+		if tabID != 0 && f.TabID == tabID {
+			stats.add(f)
+		}
+	}
+	return stats, stats.Count > 0
+}
+
+// ContributorStats returns the aggregated feedback of a UG contributor's
+// tabs; contributor names are compared ignoring case
+func (s *Store) ContributorStats(contributor string) (FeedbackStats, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats FeedbackStats
+	for _, f := range s.feedback {
+		if contributor != "" && strings.EqualFold(f.Contributor, contributor) {
+			stats.add(f)
+		}
+	}
+	return stats, stats.Count > 0
+}
+
+// FeedbackSummary aggregates all feedback per tab and per contributor,
+// lowest average first so the versions to avoid lead the lists
+func (s *Store) FeedbackSummary() FeedbackSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := FeedbackSummary{
+		Tabs:         []TabFeedback{},
+		Contributors: []ContributorFeedback{},
+	}
+	tabs := make(map[int]*TabFeedback)
+	contributors := make(map[string]*ContributorFeedback)
+
+	for _, f := range s.feedback {
+		summary.add(f)
+
+		if f.TabID != 0 {
+			tab, ok := tabs[f.TabID]
+			if !ok {
+				tab = &TabFeedback{TabID: f.TabID}
+				tabs[f.TabID] = tab
+			}
+			// Newer feedback carries the current names
+			tab.Title, tab.Artist, tab.Contributor = f.Title, f.Artist, f.Contributor
+			tab.add(f)
+		}
+
+		if f.Contributor != "" {
+			key := strings.ToLower(f.Contributor)
+			contributor, ok := contributors[key]
+			if !ok {
+				contributor = &ContributorFeedback{Contributor: f.Contributor}
+				contributors[key] = contributor
+			}
+			contributor.add(f)
+		}
+	}
+
+	for _, tab := range tabs {
+		summary.Tabs = append(summary.Tabs, *tab)
+	}
+	for _, contributor := range contributors {
+		summary.Contributors = append(summary.Contributors, *contributor)
+	}
+	sort.Slice(summary.Tabs, func(i, j int) bool {
+		a, b := summary.Tabs[i], summary.Tabs[j]
+		if a.Average != b.Average {
+			return a.Average < b.Average
+		}
+		return a.TabID < b.TabID
+	})
+	sort.Slice(summary.Contributors, func(i, j int) bool {
+		a, b := summary.Contributors[i], summary.Contributors[j]
+		if a.Average != b.Average {
+			return a.Average < b.Average
+		}
+		return strings.ToLower(a.Contributor) < strings.ToLower(b.Contributor)
+	})
+
+	return summary
+}
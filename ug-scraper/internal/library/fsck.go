@@ -0,0 +1,327 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/fsck"
+)
+
+// Check verifies the library file and the consistency of what is loaded:
+// song fields, setlist references to songs and medleys, and revision
+// counters and timestamps. With repair, fixable problems are fixed and the
+// library is saved; songs it changed are reported to the OnSongChange
+// listeners.
+func (s *Store) Check(repair bool) ([]fsck.Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var issues []fsck.Issue
+	if s.filePath != "" {
+		issues = append(issues, s.checkFile(repair)...)
+		if !s.persistent {
+			issues = append(issues, fsck.Issue{
+				Area:    fsck.AreaLibrary,
+				Kind:    "read_only",
+				ID:      s.filePath,
+				Message: "the library is open read-only because its file couldn't be loaded or migrated; changes are not saved",
+			})
+		}
+	}
+
+	changed := make(map[string]bool)
+	issues = append(issues, s.checkSongs(repair, changed)...)
+	issues = append(issues, s.checkSetlists(repair)...)
+
+	repaired := false
+	for _, issue := range issues {
+		repaired = repaired || issue.Repaired
+	}
+	if !repaired {
+		return issues, nil
+	}
+
+	for id := range changed {
+		s.notifyChange(id, s.songs[id])
+	}
+	if err := s.persist(); err != nil {
+		return issues, fmt.Errorf("saving repaired library: %w", err)
+	}
+	return issues, nil
+}
+
+// checkFile looks for a library file that can't be read and for the temp
+// file an interrupted save leaves behind. A leftover temp file is removed,
+// or put in place of a library file that can't be read and loaded.
+// Caller holds s.mu.
+func (s *Store) checkFile(repair bool) []fsck.Issue {
+	fileErr := validLibraryFile(s.filePath)
+	tmpPath := s.filePath + ".tmp"
+	tmpErr := validLibraryFile(tmpPath)
+	tmpExists := !errors.Is(tmpErr, os.ErrNotExist)
+
+	switch {
+	case fileErr == nil || (errors.Is(fileErr, os.ErrNotExist) && !tmpExists):
+		if !tmpExists {
+			return nil
+		}
+		issue := fsck.Issue{
+			Area:       fsck.AreaLibrary,
+			Kind:       "leftover_temp_file",
+			ID:         tmpPath,
+			Message:    "an interrupted save left a temp file next to an intact library",
+			Repairable: true,
+		}
+		if repair && os.Remove(tmpPath) == nil {
+			issue.Repaired = true
+		}
+		return []fsck.Issue{issue}
+
+	case tmpErr == nil:
+		issue := fsck.Issue{
+			Area:       fsck.AreaLibrary,
+			Kind:       "unreadable_file",
+			ID:         s.filePath,
+			Message:    fmt.Sprintf("the library file can't be loaded (%v), but the temp file of the last save is intact", fileErr),
+			Repairable: true,
+		}
+		if repair {
+			if err := s.restoreFromTemp(tmpPath); err != nil {
+				issue.Message += "; restoring it failed: " + err.Error()
+			} else {
+				issue.Repaired = true
+				issue.Message += "; restored it and reloaded the library"
+			}
+		}
+		return []fsck.Issue{issue}
+
+	default:
+		message := fmt.Sprintf("the library file can't be loaded (%v)", fileErr)
+		if tmpExists {
+			message += fmt.Sprintf(" and neither can the temp file (%v)", tmpErr)
+		}
+		return []fsck.Issue{{
+			Area:    fsck.AreaLibrary,
+			Kind:    "unreadable_file",
+			ID:      s.filePath,
+			Message: message + "; restore one of the library.json.*.bak backups",
+		}}
+	}
+}
+
+// restoreFromTemp keeps the unreadable library file aside, puts the temp
+// file in its place and loads it. Caller holds s.mu.
+func (s *Store) restoreFromTemp(tmpPath string) error {
+	if _, err := os.Stat(s.filePath); err == nil {
+		corrupt := fmt.Sprintf("%s.corrupt-%s", s.filePath, time.Now().Format("20060102-150405"))
+		if err := os.Rename(s.filePath, corrupt); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, s.filePath); err != nil {
+		return err
+	}
+
+	s.songs = make(map[string]*Song)
+	s.review = make(map[string]*ReviewItem)
+	s.setlists = make(map[string]*Setlist)
+	s.watches = make(map[string]*Watch)
+	s.manifests, s.feedback = nil, nil
+	s.persistent = true
+	if err := s.loadFromFile(); err != nil {
+		return err
+	}
+	for id, song := range s.songs {
+		s.notifyChange(id, song)
+	}
+	return nil
+}
+
+// validLibraryFile reports why path can't be loaded as a library, or nil
+func validLibraryFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var data libraryData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// checkSongs checks every song's identity, fields and revision. IDs of
+// repaired songs are added to changed. Caller holds s.mu.
+func (s *Store) checkSongs(repair bool, changed map[string]bool) []fsck.Issue {
+	var issues []fsck.Issue
+	report := func(area, kind, id, message string, fix func() bool) {
+		issue := fsck.Issue{Area: area, Kind: kind, ID: id, Message: message, Repairable: fix != nil}
+		if repair && fix != nil && fix() {
+			issue.Repaired = true
+			changed[id] = true
+		}
+		issues = append(issues, issue)
+	}
+
+	sources := make(map[int][]string)
+	for _, id := range sortedKeys(s.songs) {
+		song := s.songs[id]
+
+		if song.ID != id {
+			report(fsck.AreaLibrary, "id_mismatch", id, fmt.Sprintf("song stored as %q carries ID %q", id, song.ID), func() bool {
+				song.ID = id
+				return true
+			})
+		}
+		if strings.TrimSpace(song.Title) == "" {
+			report(fsck.AreaLibrary, "missing_title", id, "song has no title", func() bool {
+				song.Title = "Untitled"
+				return true
+			})
+		}
+		if tags, err := NormalizeTags(ParseTags(strings.Join(song.Tags, ","))); err != nil {
+			report(fsck.AreaLibrary, "invalid_tags", id, err.Error(), nil)
+		} else if strings.Join(tags, ",") != strings.Join(song.Tags, ",") {
+			report(fsck.AreaLibrary, "unnormalized_tags", id, fmt.Sprintf("tags %q are not trimmed, sorted and unique", song.Tags), func() bool {
+				song.Tags = tags
+				return true
+			})
+		}
+		if song.PendingUpdate != nil && song.PendingUpdate.TabID != song.SourceTabID {
+			report(fsck.AreaLibrary, "stale_update", id, fmt.Sprintf("pending update is for tab %d, but the song's source is tab %d", song.PendingUpdate.TabID, song.SourceTabID), func() bool {
+				song.PendingUpdate = nil
+				return true
+			})
+		}
+		if song.SuggestedMatch != nil && song.HasSource() {
+			report(fsck.AreaLibrary, "stale_match", id, "song has a source tab but still carries a suggested match", func() bool {
+				song.SuggestedMatch = nil
+				return true
+			})
+		}
+		if song.SourceTabID != 0 {
+			sources[song.SourceTabID] = append(sources[song.SourceTabID], id)
+		}
+
+		issues = append(issues, checkRevision(repair, "song", id, &song.Revision, &song.CreatedAt, &song.UpdatedAt, changed)...)
+	}
+
+	for _, tabID := range sortedKeys(sources) {
+		if ids := sources[tabID]; len(ids) > 1 {
+			report(fsck.AreaLibrary, "duplicate_source", ids[0], fmt.Sprintf("songs %s were all imported from tab %d; merge or delete the extra ones", strings.Join(ids, ", "), tabID), nil)
+		}
+	}
+	return issues
+}
+
+// checkSetlists drops setlist items whose song is gone, unknown medley
+// references and medleys without songs. Caller holds s.mu.
+func (s *Store) checkSetlists(repair bool) []fsck.Issue {
+	var issues []fsck.Issue
+	for _, id := range sortedKeys(s.setlists) {
+		setlist := s.setlists[id]
+		modified := false
+		report := func(kind, message string) {
+			issues = append(issues, fsck.Issue{
+				Area:       fsck.AreaSetlists,
+				Kind:       kind,
+				ID:         id,
+				Message:    message,
+				Repaired:   repair,
+				Repairable: true,
+			})
+			modified = modified || repair
+		}
+
+		items := setlist.Items[:0:0]
+		used := make(map[string]bool)
+		for i, item := range setlist.Items {
+			if _, ok := s.songs[item.SongID]; !ok {
+				report("dangling_song", fmt.Sprintf("item %d refers to deleted song %q", i+1, item.SongID))
+				if repair {
+					continue
+				}
+			}
+			if _, ok := setlist.Medley(item.MedleyID); item.MedleyID != "" && !ok {
+				report("dangling_medley", fmt.Sprintf("item %d refers to unknown medley %q", i+1, item.MedleyID))
+				if repair {
+					item.MedleyID, item.SegueNote = "", ""
+				}
+			}
+			used[item.MedleyID] = true
+			items = append(items, item)
+		}
+
+		medleys := setlist.Medleys[:0:0]
+		for _, medley := range setlist.Medleys {
+			if !used[medley.ID] {
+				report("empty_medley", fmt.Sprintf("medley %q has no songs", medley.ID))
+				if repair {
+					continue
+				}
+			}
+			medleys = append(medleys, medley)
+		}
+
+		issues = append(issues, checkRevision(repair, "setlist", id, &setlist.Revision, &setlist.CreatedAt, &setlist.UpdatedAt, nil)...)
+
+		if modified {
+			setlist.Items, setlist.Medleys = items, medleys
+			setlist.Revision++
+			setlist.UpdatedAt = time.Now()
+		}
+	}
+	return issues
+}
+
+// checkRevision checks that an entry's revision counts from 1 and that it
+// wasn't updated before it was created
+func checkRevision(repair bool, what, id string, revision *int, created, updated *time.Time, changed map[string]bool) []fsck.Issue {
+	var issues []fsck.Issue
+	report := func(kind, message string, fix func()) {
+		issue := fsck.Issue{Area: fsck.AreaRevisions, Kind: kind, ID: id, Message: message, Repairable: true}
+		if repair {
+			fix()
+			issue.Repaired = true
+			if changed != nil {
+				changed[id] = true
+			}
+		}
+		issues = append(issues, issue)
+	}
+
+	if *revision < 1 {
+		report("invalid_revision", fmt.Sprintf("%s has revision %d; revisions start at 1", what, *revision), func() {
+			*revision = 1
+		})
+	}
+	if created.IsZero() {
+		report("missing_created_at", what+" has no creation time", func() {
+			*created = *updated
+			if created.IsZero() {
+				*created = time.Now()
+			}
+		})
+	}
+	if updated.Before(*created) {
+		report("updated_before_created", fmt.Sprintf("%s was last updated (%s) before it was created (%s)", what, updated.Format(time.RFC3339), created.Format(time.RFC3339)), func() {
+			*updated = *created
+		})
+	}
+	return issues
+}
+
+// sortedKeys returns a map's keys in order, for stable reports
+func sortedKeys[K string | int, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,111 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxManifests caps how many delivery manifests are kept; the oldest are dropped
+const maxManifests = 200
+
+// Manifest kinds
+const (
+	ManifestLibraryExport   = "library_export"
+	ManifestSetlistExport   = "setlist_export"
+	ManifestWebhookDelivery = "webhook_delivery"
+	ManifestDropboxDelivery = "dropbox_delivery"
+)
+
+// Manifest records what was delivered or exported in one batch so band
+// members can check they all received the same chart versions
+type Manifest struct {
+	ID           string         `json:"id"`
+	Kind         string         `json:"kind"`
+	Reference    string         `json:"reference,omitempty"` // Setlist ID for setlist exports
+	Name         string         `json:"name,omitempty"`
+	Format       string         `json:"format,omitempty"`
+	Destinations []string       `json:"destinations"`
+	Files        []ManifestFile `json:"files"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// ManifestFile is one delivered file and the hash of its exact content
+type ManifestFile struct {
+	Name        string     `json:"name"`
+	SongID      string     `json:"song_id,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Artist      string     `json:"artist,omitempty"`
+	SHA256      string     `json:"sha256"`
+	Size        int        `json:"size"`
+	SongUpdated *time.Time `json:"song_updated_at,omitempty"`
+}
+
+// clone returns a deep copy of the manifest
+func (m *Manifest) clone() *Manifest {
+	c := *m
+	c.Destinations = append([]string(nil), m.Destinations...)
+	c.Files = append([]ManifestFile(nil), m.Files...)
+	return &c
+}
+
+// AddManifest stores a delivery manifest, assigning its ID and timestamp
+func (s *Store) AddManifest(manifest *Manifest) error {
+	if manifest == nil {
+		return fmt.Errorf("manifest cannot be nil")
+	}
+	if manifest.Kind == "" {
+		return fmt.Errorf("manifest kind is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if manifest.ID == "" {
+		manifest.ID = s.nextID("manifest")
+	}
+	if manifest.CreatedAt.IsZero() {
+		manifest.CreatedAt = time.Now()
+	}
+
+	s.manifests = append(s.manifests, manifest.clone())
+	if len(s.manifests) > maxManifests {
+		s.manifests = append([]*Manifest(nil), s.manifests[len(s.manifests)-maxManifests:]...)
+	}
+
+	return s.persist()
+}
+
+// ListManifests returns delivery manifests, newest first. kind filters by
+// manifest kind when set.
+func (s *Store) ListManifests(kind string) []Manifest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	manifests := make([]Manifest, 0, len(s.manifests))
+	for _, m := range s.manifests {
+		if kind == "" || m.Kind == kind {
+			manifests = append(manifests, *m.clone())
+		}
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return manifests[i].CreatedAt.After(manifests[j].CreatedAt)
+	})
+
+	return manifests
+}
+
+// GetManifest returns a copy of the manifest with the given ID
+func (s *Store) GetManifest(id string) (*Manifest, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, m := range s.manifests {
+		if m.ID == id {
+			return m.clone(), true
+		}
+	}
+
+	return nil, false
+}
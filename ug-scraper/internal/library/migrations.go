@@ -0,0 +1,53 @@
+package library
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/migrate"
+)
+
+// SchemaVersion is the layout of the library file written by this version.
+// Add a migration to Migrations whenever it changes.
+var SchemaVersion = migrate.Latest(Migrations())
+
+// Migrations returns the library file migrations, oldest first
+func Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		{
+			Version: 1,
+			Name:    "song_revisions",
+			// Songs saved before edits were revisioned start at revision 1,
+			// so their first ETag matches songs created since
+			Up: func(doc migrate.Doc) error {
+				for _, song := range doc.Objects("songs") {
+					if rev, _ := song["revision"].(json.Number); rev == "" || rev == "0" {
+						song["revision"] = 1
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version: 2,
+			Name:    "song_contributors",
+			// Songs imported before the contributor was stored get it back
+			// from the "# Contributor:" footer of their converted chart
+			Up: func(doc migrate.Doc) error {
+				for _, song := range doc.Objects("songs") {
+					if _, ok := song["contributor"]; ok {
+						continue
+					}
+					chart, _ := song["onsong_format"].(string)
+					if m := contributorFooterRegex.FindStringSubmatch(chart); m != nil {
+						song["contributor"] = m[1]
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// contributorFooterRegex matches the contributor footer of converted charts
+var contributorFooterRegex = regexp.MustCompile(`(?m)^# Contributor: *(\S.*?)\s*$`)
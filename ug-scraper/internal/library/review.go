@@ -0,0 +1,91 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// ReviewItem is a song request that could not be matched automatically and
+// needs a human to pick the right version
+type ReviewItem struct {
+	ID           string                 `json:"id"`
+	Artist       string                 `json:"artist"`
+	Title        string                 `json:"title"`
+	PreferredKey string                 `json:"preferred_key,omitempty"`
+	Reason       string                 `json:"reason"`
+	Source       string                 `json:"source"`
+	Candidates   []scraper.SearchResult `json:"candidates,omitempty"`
+	Content      string                 `json:"content,omitempty"`       // OCR text of a scanned chart
+	OnSongFormat string                 `json:"onsong_format,omitempty"` // Draft made from it, saved once proofread
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// ListReview returns the review queue, oldest first
+func (s *Store) ListReview() []ReviewItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]ReviewItem, 0, len(s.review))
+	for _, item := range s.review {
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+
+	return items
+}
+
+// GetReview returns a copy of a review queue item
+func (s *Store) GetReview(id string) (*ReviewItem, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.review[id]
+	if !ok {
+		return nil, false
+	}
+
+	itemCopy := *item
+	return &itemCopy, true
+}
+
+// AddReview queues a song request for manual review
+func (s *Store) AddReview(item *ReviewItem) error {
+	if item == nil {
+		return fmt.Errorf("review item cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item.ID == "" {
+		item.ID = s.nextID("review")
+	}
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+
+	itemCopy := *item
+	s.review[item.ID] = &itemCopy
+
+	return s.persist()
+}
+
+// RemoveReview drops an item from the review queue
+func (s *Store) RemoveReview(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.review[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.review, id)
+
+	return s.persist()
+}
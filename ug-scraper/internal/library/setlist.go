@@ -0,0 +1,225 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Setlist is an ordered list of library songs for a performance
+type Setlist struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Date      string        `json:"date,omitempty"` // YYYY-MM-DD
+	Notes     string        `json:"notes,omitempty"`
+	Items     []SetlistItem `json:"items"`
+	Medleys   []Medley      `json:"medleys,omitempty"`
+	Revision  int           `json:"revision"` // Incremented on every save; used for If-Match checks
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// SetlistItem is a song within a setlist
+type SetlistItem struct {
+	SongID string `json:"song_id"`
+	Key    string `json:"key,omitempty"`   // Performance key, overrides the song's key
+	Notes  string `json:"notes,omitempty"` // Performance notes for this song
+	// MedleyID groups consecutive items into a medley
+	MedleyID string `json:"medley_id,omitempty"`
+	// SegueNote describes the transition into the next song of the medley
+	SegueNote string `json:"segue_note,omitempty"`
+}
+
+// Medley groups consecutive setlist items that are played without a break
+type Medley struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	TargetKey string `json:"target_key,omitempty"` // Shared key every song is transposed to
+	Notes     string `json:"notes,omitempty"`
+}
+
+// clone returns a deep copy of the setlist
+func (s *Setlist) clone() *Setlist {
+	c := *s
+	c.Items = append([]SetlistItem(nil), s.Items...)
+	c.Medleys = append([]Medley(nil), s.Medleys...)
+	return &c
+}
+
+// Medley returns the medley with the given ID
+func (s *Setlist) Medley(id string) (*Medley, bool) {
+	for i := range s.Medleys {
+		if s.Medleys[i].ID == id {
+			return &s.Medleys[i], true
+		}
+	}
+	return nil, false
+}
+
+// Validate checks that every item has a song and that medley items are
+// contiguous and refer to a defined medley
+func (s *Setlist) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("setlist name is required")
+	}
+
+	closed := make(map[string]bool)
+	previous := ""
+	for i, item := range s.Items {
+		if item.SongID == "" {
+			return fmt.Errorf("item %d: song_id is required", i+1)
+		}
+
+		if item.MedleyID != previous && previous != "" {
+			closed[previous] = true
+		}
+		if item.MedleyID != "" {
+			if _, ok := s.Medley(item.MedleyID); !ok {
+				return fmt.Errorf("item %d: unknown medley %q", i+1, item.MedleyID)
+			}
+			if closed[item.MedleyID] {
+				return fmt.Errorf("item %d: medley %q items must be consecutive", i+1, item.MedleyID)
+			}
+		}
+		previous = item.MedleyID
+	}
+
+	return nil
+}
+
+// ListSetlists returns all setlists ordered by date, then name
+func (s *Store) ListSetlists() []Setlist {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	setlists := make([]Setlist, 0, len(s.setlists))
+	for _, setlist := range s.setlists {
+		setlists = append(setlists, *setlist.clone())
+	}
+
+	sort.Slice(setlists, func(i, j int) bool {
+		if setlists[i].Date != setlists[j].Date {
+			return setlists[i].Date < setlists[j].Date
+		}
+		return setlists[i].Name < setlists[j].Name
+	})
+
+	return setlists
+}
+
+// UpcomingSetlists returns the setlists dated from today through the next
+// days days that include the song, soonest first. Undated setlists are left
+// out.
+func (s *Store) UpcomingSetlists(songID string, days int) []Setlist {
+	today := time.Now()
+	from, until := today.Format(time.DateOnly), today.AddDate(0, 0, days).Format(time.DateOnly)
+
+	var upcoming []Setlist
+	for _, setlist := range s.ListSetlists() {
+		if setlist.Date < from || setlist.Date > until {
+			continue
+		}
+		for _, item := range setlist.Items {
+			if item.SongID == songID {
+				upcoming = append(upcoming, setlist)
+				break
+			}
+		}
+	}
+	return upcoming
+}
+
+// GetSetlist returns a copy of the setlist with the given ID
+func (s *Store) GetSetlist(id string) (*Setlist, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	setlist, ok := s.setlists[id]
+	if !ok {
+		return nil, false
+	}
+
+	return setlist.clone(), true
+}
+
+// SaveSetlist adds a new setlist or updates an existing one. Every item must
+// refer to a song in the library.
+func (s *Store) SaveSetlist(setlist *Setlist) error {
+	return s.saveSetlist(setlist, -1)
+}
+
+// SaveSetlistIfMatch updates a setlist only if its stored revision is still
+// revision, returning ErrConflict otherwise
+func (s *Store) SaveSetlistIfMatch(setlist *Setlist, revision int) error {
+	return s.saveSetlist(setlist, revision)
+}
+
+// NewMedleyID returns a unique ID for a new medley, so its items can refer
+// to it before the setlist is saved
+func (s *Store) NewMedleyID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.nextID("medley")
+}
+
+// saveSetlist stores a setlist; a non-negative expected revision must match the stored one
+func (s *Store) saveSetlist(setlist *Setlist, expected int) error {
+	if setlist == nil {
+		return fmt.Errorf("setlist cannot be nil")
+	}
+	if err := setlist.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, item := range setlist.Items {
+		if _, ok := s.songs[item.SongID]; !ok {
+			return fmt.Errorf("item %d: song %q not found", i+1, item.SongID)
+		}
+	}
+
+	now := time.Now()
+	if setlist.ID == "" {
+		setlist.ID = s.nextID("setlist")
+	}
+	existing, ok := s.setlists[setlist.ID]
+	if expected >= 0 && (!ok || existing.Revision != expected) {
+		return ErrConflict
+	}
+	for i := range setlist.Medleys {
+		if setlist.Medleys[i].ID == "" {
+			setlist.Medleys[i].ID = s.nextID("medley")
+		}
+	}
+	if ok {
+		setlist.CreatedAt = existing.CreatedAt
+		setlist.Revision = existing.Revision + 1
+	} else {
+		if setlist.CreatedAt.IsZero() {
+			setlist.CreatedAt = now
+		}
+		setlist.Revision = 1
+	}
+	setlist.UpdatedAt = now
+
+	s.setlists[setlist.ID] = setlist.clone()
+
+	return s.persist()
+}
+
+// DeleteSetlist removes a setlist
+func (s *Store) DeleteSetlist(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.setlists[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.setlists, id)
+
+	return s.persist()
+}
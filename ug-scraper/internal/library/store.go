@@ -0,0 +1,507 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/migrate"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// Song is a converted chart stored in the library
+type Song struct {
+	ID           string          `json:"id"`
+	Title        string          `json:"title"`
+	Artist       string          `json:"artist"`
+	Key          string          `json:"key,omitempty"`
+	PreferredKey string          `json:"preferred_key,omitempty"`
+	Capo         int             `json:"capo,omitempty"`
+	Tuning       string          `json:"tuning,omitempty"`
+	Type         scraper.TabType `json:"type,omitempty"`
+	Source       string          `json:"source"`
+	SourceTabID  int             `json:"source_tab_id,omitempty"`
+	SourceURL    string          `json:"source_url,omitempty"`
+	Contributor  string          `json:"contributor,omitempty"` // UG user who posted the source tab
+	Rating       float64         `json:"rating,omitempty"`
+	Votes        int             `json:"votes,omitempty"`
+	Content      string          `json:"content,omitempty"`
+	OnSongFormat string          `json:"onsong_format"`
+	Tags         []string        `json:"tags,omitempty"` // Free labels such as "christmas" or "youth"
+	Revision     int             `json:"revision"`       // Incremented on every save; used for If-Match checks
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+
+	// SuggestedMatch is a UG version found for a song without a source tab
+	SuggestedMatch   *SourceMatch `json:"suggested_match,omitempty"`
+	DismissedMatches []int        `json:"dismissed_matches,omitempty"`
+	LastMatchedAt    time.Time    `json:"last_matched_at,omitempty"`
+
+	// PendingUpdate is a newer version of the source tab found by the
+	// update checker
+	PendingUpdate  *SourceUpdate `json:"pending_update,omitempty"`
+	IgnoredContent string        `json:"ignored_content,omitempty"` // Hash of the last content update ignored
+	LastCheckedAt  time.Time     `json:"last_checked_at,omitempty"`
+}
+
+// SourceMatch is a candidate UG tab linked to a hand-entered song
+type SourceMatch struct {
+	TabID      int             `json:"tab_id"`
+	Title      string          `json:"title"`
+	Artist     string          `json:"artist"`
+	Type       scraper.TabType `json:"type"`
+	Rating     float64         `json:"rating"`
+	Votes      int             `json:"votes"`
+	URL        string          `json:"url"`
+	Confidence float64         `json:"confidence"`
+	FoundAt    time.Time       `json:"found_at"`
+}
+
+// HasSource reports whether the song is linked to a UG tab
+func (s *Song) HasSource() bool {
+	return s.SourceTabID != 0
+}
+
+// IsDismissed reports whether the user rejected a tab as a match for this song
+func (s *Song) IsDismissed(tabID int) bool {
+	for _, id := range s.DismissedMatches {
+		if id == tabID {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a deep copy of the song so stored entries are never shared
+func (s *Song) clone() *Song {
+	c := *s
+	if s.SuggestedMatch != nil {
+		match := *s.SuggestedMatch
+		c.SuggestedMatch = &match
+	}
+	c.DismissedMatches = append([]int(nil), s.DismissedMatches...)
+	c.Tags = append([]string(nil), s.Tags...)
+	if s.PendingUpdate != nil {
+		update := *s.PendingUpdate
+		update.Changes = append([]string(nil), s.PendingUpdate.Changes...)
+		c.PendingUpdate = &update
+	}
+	return &c
+}
+
+// Source values recorded on library songs
+const (
+	SourceUltimateGuitar = "ultimate-guitar"
+	SourceManual         = "manual"
+	SourceScan           = "scan"
+	SourceFile           = "file"
+)
+
+// libraryData is the on-disk representation of the library
+type libraryData struct {
+	SchemaVersion int           `json:"schema_version"`
+	Songs         []*Song       `json:"songs"`
+	Review        []*ReviewItem `json:"review"`
+	Setlists      []*Setlist    `json:"setlists"`
+	Manifests     []*Manifest   `json:"manifests,omitempty"`
+	Watches       []*Watch      `json:"watches,omitempty"`
+	Feedback      []*Feedback   `json:"feedback,omitempty"`
+}
+
+// Store manages the song library with thread-safe operations
+type Store struct {
+	mu         sync.RWMutex
+	songs      map[string]*Song
+	review     map[string]*ReviewItem
+	setlists   map[string]*Setlist
+	manifests  []*Manifest
+	watches    map[string]*Watch
+	feedback   []*Feedback
+	filePath   string
+	persistent bool
+	lastID     int64
+	onChange   []func(id string, song *Song)
+}
+
+// NewStore creates a new library store, loading existing songs from filePath
+func NewStore(filePath string) *Store {
+	store := &Store{
+		songs:      make(map[string]*Song),
+		review:     make(map[string]*ReviewItem),
+		setlists:   make(map[string]*Setlist),
+		watches:    make(map[string]*Watch),
+		filePath:   filePath,
+		persistent: filePath != "",
+	}
+
+	if store.persistent {
+		if err := store.loadFromFile(); err != nil {
+			fmt.Printf("⚠️  Failed to load library: %v\n", err)
+		}
+	}
+
+	return store
+}
+
+// List returns all songs sorted by artist then title
+func (s *Store) List() []Song {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	songs := make([]Song, 0, len(s.songs))
+	for _, song := range s.songs {
+		songs = append(songs, *song.clone())
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		ai, aj := strings.ToLower(songs[i].Artist), strings.ToLower(songs[j].Artist)
+		if ai != aj {
+			return ai < aj
+		}
+		return strings.ToLower(songs[i].Title) < strings.ToLower(songs[j].Title)
+	})
+
+	return songs
+}
+
+// Get returns a copy of the song with the given ID
+func (s *Store) Get(id string) (*Song, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		return nil, false
+	}
+
+	return song.clone(), true
+}
+
+// FindBySource returns the song imported from the given UG tab ID, if any
+func (s *Store) FindBySource(tabID int) (*Song, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, song := range s.songs {
+		if song.SourceTabID != 0 && song.SourceTabID == tabID {
+			return song.clone(), true
+		}
+	}
+
+	return nil, false
+}
+
+// FindByTitle returns a song with the given artist and title, ignoring case
+func (s *Store) FindByTitle(artist, title string) (*Song, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, song := range s.songs {
+		if strings.EqualFold(song.Title, title) && strings.EqualFold(song.Artist, artist) {
+			return song.clone(), true
+		}
+	}
+
+	return nil, false
+}
+
+// ListMissingSource returns songs without a linked UG tab, least recently
+// matched first
+func (s *Store) ListMissingSource() []Song {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var songs []Song
+	for _, song := range s.songs {
+		if !song.HasSource() {
+			songs = append(songs, *song.clone())
+		}
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		return songs[i].LastMatchedAt.Before(songs[j].LastMatchedAt)
+	})
+
+	return songs
+}
+
+// Expected revisions of saveSong that don't check a revision
+const (
+	anyRevision = -1 // Save: a new song, or whatever revision is stored
+	noRevision  = -2 // Insert: no song may be stored under the ID yet
+)
+
+// Save adds a new song or updates an existing one. A song with an ID must
+// still be in the library: ErrNotFound is returned once it was deleted, so
+// a late save never brings it back.
+func (s *Store) Save(song *Song) error {
+	return s.saveSong(song, anyRevision)
+}
+
+// SaveIfMatch updates a song only if its stored revision is still revision,
+// returning ErrConflict when someone else saved or deleted it in the meantime
+func (s *Store) SaveIfMatch(song *Song, revision int) error {
+	return s.saveSong(song, revision)
+}
+
+// Insert adds a song under the ID it carries, such as one migrated from
+// another library, returning ErrConflict when the ID is already taken
+func (s *Store) Insert(song *Song) error {
+	return s.saveSong(song, noRevision)
+}
+
+// saveSong stores a song; a non-negative expected revision must match the stored one
+func (s *Store) saveSong(song *Song, expected int) error {
+	if song == nil {
+		return fmt.Errorf("song cannot be nil")
+	}
+	if song.Title == "" {
+		return fmt.Errorf("song title is required")
+	}
+	tags, err := NormalizeTags(song.Tags)
+	if err != nil {
+		return err
+	}
+	song.Tags = tags
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	existing, ok := s.songs[song.ID]
+	switch {
+	case expected >= 0 && (!ok || existing.Revision != expected):
+		return ErrConflict
+	case song.ID == "":
+		song.ID = s.nextID("song")
+	case expected == anyRevision && !ok:
+		return ErrNotFound
+	case expected == noRevision && ok:
+		return ErrConflict
+	}
+	if ok {
+		song.CreatedAt = existing.CreatedAt
+		song.Revision = existing.Revision + 1
+	} else {
+		if song.CreatedAt.IsZero() {
+			song.CreatedAt = now
+		}
+		song.Revision = 1
+	}
+	song.UpdatedAt = now
+
+	s.songs[song.ID] = song.clone()
+	s.notifyChange(song.ID, s.songs[song.ID])
+
+	return s.persist()
+}
+
+// Delete removes a song from the library
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.songs[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.songs, id)
+	s.notifyChange(id, nil)
+
+	return s.persist()
+}
+
+// OnSongChange registers fn to be called whenever a song is saved, with a
+// copy of it, or deleted, with a nil song. fn runs while the store is locked
+// and must not call back into it.
+func (s *Store) OnSongChange(fn func(id string, song *Song)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onChange = append(s.onChange, fn)
+}
+
+// notifyChange tells the listeners about a changed song. Caller holds s.mu.
+func (s *Store) notifyChange(id string, song *Song) {
+	for _, fn := range s.onChange {
+		if song == nil {
+			fn(id, nil)
+			continue
+		}
+		fn(id, song.clone())
+	}
+}
+
+// Count returns the number of songs in the library
+func (s *Store) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.songs)
+}
+
+// ErrNotFound is returned when a library entry does not exist
+var ErrNotFound = fmt.Errorf("not found")
+
+// ErrConflict is returned when an If-Match revision no longer matches the stored entry
+var ErrConflict = fmt.Errorf("revision conflict")
+
+// nextID returns a unique, time-based ID with the given prefix (caller holds the lock)
+func (s *Store) nextID(prefix string) string {
+	id := time.Now().UnixNano()
+	if id <= s.lastID {
+		id = s.lastID + 1
+	}
+	s.lastID = id
+
+	return fmt.Sprintf("%s_%d", prefix, id)
+}
+
+// persist saves the library to its JSON file (caller holds the lock)
+func (s *Store) persist() error {
+	if !s.persistent {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("creating library directory: %w", err)
+	}
+
+	encoded, err := s.encode()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so a crash never leaves a truncated library
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, encoded, 0644); err != nil {
+		return fmt.Errorf("writing library file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.filePath); err != nil {
+		return fmt.Errorf("replacing library file: %w", err)
+	}
+
+	return nil
+}
+
+// encode returns the library in its on-disk form (caller holds the lock)
+func (s *Store) encode() ([]byte, error) {
+	data := libraryData{
+		SchemaVersion: SchemaVersion,
+		Songs:         make([]*Song, 0, len(s.songs)),
+		Review:        make([]*ReviewItem, 0, len(s.review)),
+		Setlists:      make([]*Setlist, 0, len(s.setlists)),
+		Manifests:     s.manifests,
+		Watches:       make([]*Watch, 0, len(s.watches)),
+		Feedback:      s.feedback,
+	}
+	for _, song := range s.songs {
+		data.Songs = append(data.Songs, song)
+	}
+	for _, item := range s.review {
+		data.Review = append(data.Review, item)
+	}
+	for _, setlist := range s.setlists {
+		data.Setlists = append(data.Setlists, setlist)
+	}
+	for _, watch := range s.watches {
+		data.Watches = append(data.Watches, watch)
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling library: %w", err)
+	}
+	return encoded, nil
+}
+
+// loadFromFile migrates the library file to the current schema and loads it.
+// A file that can't be migrated is loaded as is and never overwritten.
+func (s *Store) loadFromFile() error {
+	result, err := migrate.File(s.filePath, Migrations(), -1)
+	switch {
+	case err != nil:
+		s.persistent = false
+		fmt.Printf("⚠️  Library opened read-only, changes won't be saved: %v\n", err)
+	case len(result.Applied) > 0:
+		fmt.Printf("🗄️  Library migrated from schema v%d to v%d (backup: %s)\n", result.From, result.To, result.Backup)
+	}
+
+	raw, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading library file: %w", err)
+	}
+
+	var data libraryData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("unmarshaling library: %w", err)
+	}
+
+	s.fill(&data)
+	return nil
+}
+
+// fill loads decoded library data into the empty store (caller holds the
+// lock)
+func (s *Store) fill(data *libraryData) {
+	for _, song := range data.Songs {
+		s.songs[song.ID] = song
+	}
+	for _, item := range data.Review {
+		s.review[item.ID] = item
+	}
+	for _, setlist := range data.Setlists {
+		s.setlists[setlist.ID] = setlist
+	}
+	s.manifests = data.Manifests
+	for _, watch := range data.Watches {
+		s.watches[watch.ID] = watch
+	}
+	s.feedback = data.Feedback
+}
+
+// Snapshot returns the whole library in its on-disk form, for backups
+func (s *Store) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.encode()
+}
+
+// Restore replaces the whole library with a snapshot, migrated first when
+// it was taken by an older version. Nothing changes when the snapshot can't
+// be read; the previous library is put back when it can't be saved.
+func (s *Store) Restore(raw []byte) error {
+	raw, err := migrate.Upgrade(raw, Migrations())
+	if err != nil {
+		return fmt.Errorf("migrating library: %w", err)
+	}
+	var data libraryData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("unmarshaling library: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	songs, review, setlists, manifests, watches, feedback := s.songs, s.review, s.setlists, s.manifests, s.watches, s.feedback
+	s.songs = make(map[string]*Song)
+	s.review = make(map[string]*ReviewItem)
+	s.setlists = make(map[string]*Setlist)
+	s.watches = make(map[string]*Watch)
+	s.fill(&data)
+
+	if err := s.persist(); err != nil {
+		s.songs, s.review, s.setlists, s.manifests, s.watches, s.feedback = songs, review, setlists, manifests, watches, feedback
+		return err
+	}
+	return nil
+}
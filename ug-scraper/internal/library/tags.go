@@ -0,0 +1,115 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxTagLength caps a single tag, in characters
+const maxTagLength = 40
+
+// TagCount is a tag and how many songs carry it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTags trims tags and collapses their inner spaces, dropping empty
+// ones and duplicates that differ only in case; the first spelling is kept.
+// Tags can't contain commas, which separate them in query strings.
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" {
+			continue
+		}
+		if strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("tag %q: tags can't contain commas", tag)
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("tag %q: tags are at most %d characters", tag, maxTagLength)
+		}
+
+		key := strings.ToLower(tag)
+		if !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return strings.ToLower(normalized[i]) < strings.ToLower(normalized[j])
+	})
+	return normalized, nil
+}
+
+// ParseTags splits a comma-separated list of tags, as used in query strings
+func ParseTags(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.Join(strings.Fields(tag), " "); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HasTags reports whether the song carries every one of tags, ignoring case
+func (s *Song) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range s.Tags {
+			if strings.EqualFold(own, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ListTagged returns the songs carrying every one of tags, in List order
+func (s *Store) ListTagged(tags ...string) []Song {
+	songs := s.List()
+
+	tagged := songs[:0]
+	for _, song := range songs {
+		if song.HasTags(tags...) {
+			tagged = append(tagged, song)
+		}
+	}
+	return tagged
+}
+
+// Tags returns every tag in use with the number of songs carrying it,
+// alphabetically. Spellings that differ in case count as one tag.
+func (s *Store) Tags() []TagCount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]*TagCount)
+	for _, song := range s.songs {
+		for _, tag := range song.Tags {
+			key := strings.ToLower(tag)
+			if counts[key] == nil {
+				counts[key] = &TagCount{Tag: tag}
+			}
+			counts[key].Count++
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for _, count := range counts {
+		tags = append(tags, *count)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].Tag) < strings.ToLower(tags[j].Tag)
+	})
+	return tags
+}
@@ -0,0 +1,61 @@
+package library
+
+import (
+	"sort"
+	"time"
+)
+
+// SourceUpdate is a newer version of a song's UG tab waiting for the user
+// to accept or ignore it
+type SourceUpdate struct {
+	TabID       int       `json:"tab_id"`
+	Changes     []string  `json:"changes"` // "content" and/or "rating"
+	Rating      float64   `json:"rating"`
+	Votes       int       `json:"votes"`
+	OldRating   float64   `json:"old_rating"`
+	OldVotes    int       `json:"old_votes"`
+	ContentHash string    `json:"content_hash,omitempty"` // Hash of the new content, set when it changed
+	Diff        string    `json:"diff,omitempty"`         // Unified diff from the stored content to UG's
+	Added       int       `json:"added,omitempty"`
+	Removed     int       `json:"removed,omitempty"`
+	FoundAt     time.Time `json:"found_at"`
+}
+
+// ListWithSource returns songs linked to a UG tab, least recently checked
+// for updates first
+func (s *Store) ListWithSource() []Song {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var songs []Song
+	for _, song := range s.songs {
+		if song.HasSource() {
+			songs = append(songs, *song.clone())
+		}
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		return songs[i].LastCheckedAt.Before(songs[j].LastCheckedAt)
+	})
+
+	return songs
+}
+
+// ListPendingUpdates returns songs with an update waiting, newest first
+func (s *Store) ListPendingUpdates() []Song {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var songs []Song
+	for _, song := range s.songs {
+		if song.PendingUpdate != nil {
+			songs = append(songs, *song.clone())
+		}
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		return songs[i].PendingUpdate.FoundAt.After(songs[j].PendingUpdate.FoundAt)
+	})
+
+	return songs
+}
@@ -0,0 +1,160 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+)
+
+// maxWatchHits caps how many recently found tabs a watch remembers
+const maxWatchHits = 20
+
+// Watch is an artist or song query that is searched again on a schedule so
+// new tabs are noticed, such as the first chord sheet of a new single
+type Watch struct {
+	ID         string          `json:"id"`
+	Artist     string          `json:"artist"`
+	Song       string          `json:"song,omitempty"` // Empty watches every song of the artist
+	Type       scraper.TabType `json:"type,omitempty"` // Empty watches every tab type
+	WebhookURL string          `json:"webhook_url,omitempty"`
+	// SeenTabIDs are the tabs already known; only others count as new
+	SeenTabIDs  []string   `json:"seen_tab_ids,omitempty"`
+	Hits        []WatchHit `json:"hits,omitempty"` // Newest first
+	LastRunAt   time.Time  `json:"last_run_at,omitempty"`
+	LastFoundAt time.Time  `json:"last_found_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// WatchHit is a tab that appeared for a watch
+type WatchHit struct {
+	TabID   string          `json:"tab_id"`
+	Title   string          `json:"title"`
+	Artist  string          `json:"artist"`
+	Type    scraper.TabType `json:"type"`
+	Rating  float64         `json:"rating"`
+	URL     string          `json:"url"`
+	FoundAt time.Time       `json:"found_at"`
+}
+
+// clone returns a deep copy of the watch
+func (w *Watch) clone() *Watch {
+	c := *w
+	c.SeenTabIDs = append([]string(nil), w.SeenTabIDs...)
+	c.Hits = append([]WatchHit(nil), w.Hits...)
+	return &c
+}
+
+// Query is the search text for the watch
+func (w *Watch) Query() string {
+	return strings.TrimSpace(w.Artist + " " + w.Song)
+}
+
+// Seen reports whether a tab was already known to the watch
+func (w *Watch) Seen(tabID string) bool {
+	for _, id := range w.SeenTabIDs {
+		if id == tabID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddHits marks tabs as seen and records them as found, newest first
+func (w *Watch) AddHits(hits []WatchHit) {
+	for _, hit := range hits {
+		w.SeenTabIDs = append(w.SeenTabIDs, hit.TabID)
+	}
+	w.Hits = append(append([]WatchHit(nil), hits...), w.Hits...)
+	if len(w.Hits) > maxWatchHits {
+		w.Hits = w.Hits[:maxWatchHits]
+	}
+}
+
+// Validate checks that the watch has something to search for
+func (w *Watch) Validate() error {
+	if strings.TrimSpace(w.Artist) == "" {
+		return fmt.Errorf("artist is required")
+	}
+	if w.WebhookURL != "" && !strings.HasPrefix(w.WebhookURL, "http://") && !strings.HasPrefix(w.WebhookURL, "https://") {
+		return fmt.Errorf("webhook_url must start with http:// or https://")
+	}
+	return nil
+}
+
+// ListWatches returns all watches ordered by artist, then song
+func (s *Store) ListWatches() []Watch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	watches := make([]Watch, 0, len(s.watches))
+	for _, watch := range s.watches {
+		watches = append(watches, *watch.clone())
+	}
+
+	sort.Slice(watches, func(i, j int) bool {
+		ai, aj := strings.ToLower(watches[i].Artist), strings.ToLower(watches[j].Artist)
+		if ai != aj {
+			return ai < aj
+		}
+		return strings.ToLower(watches[i].Song) < strings.ToLower(watches[j].Song)
+	})
+
+	return watches
+}
+
+// GetWatch returns a copy of the watch with the given ID
+func (s *Store) GetWatch(id string) (*Watch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	watch, ok := s.watches[id]
+	if !ok {
+		return nil, false
+	}
+
+	return watch.clone(), true
+}
+
+// SaveWatch adds a new watch or updates an existing one
+func (s *Store) SaveWatch(watch *Watch) error {
+	if watch == nil {
+		return fmt.Errorf("watch cannot be nil")
+	}
+	if err := watch.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if watch.ID == "" {
+		watch.ID = s.nextID("watch")
+	}
+	if existing, ok := s.watches[watch.ID]; ok {
+		watch.CreatedAt = existing.CreatedAt
+	} else if watch.CreatedAt.IsZero() {
+		watch.CreatedAt = time.Now()
+	}
+
+	s.watches[watch.ID] = watch.clone()
+
+	return s.persist()
+}
+
+// DeleteWatch removes a watch
+func (s *Store) DeleteWatch(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.watches[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.watches, id)
+
+	return s.persist()
+}
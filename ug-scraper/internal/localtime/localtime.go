@@ -0,0 +1,177 @@
+// Package localtime applies the configured time zone and date locale: every
+// timestamp the add-on creates, its daily boundaries (bandwidth cap,
+// spoken setlist dates) and report file names follow the zone, and dates
+// read from upstream are parsed leniently in it.
+package localtime
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Embedded zone database, as the add-on image may not ship one
+	_ "time/tzdata"
+)
+
+// Config is the time zone and date locale
+type Config struct {
+	Location *time.Location
+	Locale   string // e.g. "en-US"; decides whether 03/04 is March 4 or April 3
+}
+
+// monthFirstLocales read numeric dates month first
+var monthFirstLocales = map[string]bool{
+	"en-us": true, "us": true, "en-ph": true, "en-ca": true, "fil": true,
+}
+
+var (
+	mu         sync.RWMutex
+	monthFirst bool
+)
+
+// ConfigFromEnv reads TIMEZONE (an IANA name such as "Europe/Berlin"; the
+// TZ the add-on runs with when empty) and DATE_LOCALE (default "en-GB")
+func ConfigFromEnv() Config {
+	cfg := Config{Location: time.Local, Locale: "en-GB"}
+
+	if name := strings.TrimSpace(os.Getenv("TIMEZONE")); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			cfg.Location = loc
+		} else {
+			fmt.Printf("⚠️  Unknown TIMEZONE %q, keeping %s: %v\n", name, time.Local, err)
+		}
+	}
+	if locale := strings.TrimSpace(os.Getenv("DATE_LOCALE")); locale != "" {
+		cfg.Locale = locale
+	}
+
+	return cfg
+}
+
+// Apply makes cfg the add-on's time zone and date locale
+func Apply(cfg Config) {
+	if cfg.Location != nil {
+		time.Local = cfg.Location
+	}
+
+	mu.Lock()
+	monthFirst = monthFirstLocales[strings.ToLower(strings.ReplaceAll(cfg.Locale, "_", "-"))]
+	mu.Unlock()
+
+	order := "day first"
+	if MonthFirst() {
+		order = "month first"
+	}
+	fmt.Printf("🕐 Time zone: %s, date locale: %s (%s)\n", time.Local, cfg.Locale, order)
+}
+
+// MonthFirst reports whether numeric dates are read month first
+func MonthFirst() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return monthFirst
+}
+
+// dateLayouts are the spelled-out layouts ParseDate tries, without a zone
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"Jan 2 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Mon, 2 Jan 2006",
+}
+
+// ParseDate reads a date as upstreams send it: Unix seconds (or
+// milliseconds), RFC 3339, ISO dates, dates with English month names or
+// numeric dates such as 04/03/2021, read in the order of the date locale
+// unless a part over 12 settles it. Dates without a zone are in the
+// configured one, and every date is returned in it.
+func ParseDate(raw string) (time.Time, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n <= 0 {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", raw)
+		}
+		if n >= 1e11 { // Milliseconds
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.In(time.Local), nil
+		}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, ok := parseNumericDate(s); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", raw)
+}
+
+// parseNumericDate reads d/m/y, m/d/y or y/m/d dates separated by "/", "."
+// or "-", with two or four digit years
+func parseNumericDate(s string) (time.Time, bool) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '/' || r == '.' || r == '-' })
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	var n [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return time.Time{}, false
+		}
+		n[i] = v
+	}
+
+	// A part over 12 can only be the day, whatever the locale says
+	mdy := MonthFirst()
+	if n[0] > 12 && n[1] <= 12 {
+		mdy = false
+	} else if n[1] > 12 && n[0] <= 12 {
+		mdy = true
+	}
+
+	var year, month, day int
+	switch {
+	case len(parts[0]) == 4:
+		year, month, day = n[0], n[1], n[2]
+	case mdy:
+		month, day, year = n[0], n[1], n[2]
+	default:
+		day, month, year = n[0], n[1], n[2]
+	}
+	if len(parts[2]) == 2 && len(parts[0]) != 4 {
+		year += 2000
+		if year > time.Now().Year()+1 {
+			year -= 100
+		}
+	}
+
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	// time.Date normalizes overflows; a changed day or month means an invalid date
+	if month < 1 || month > 12 || t.Day() != day || int(t.Month()) != month {
+		return time.Time{}, false
+	}
+	return t, true
+}
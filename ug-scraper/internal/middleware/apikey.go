@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+)
+
+// APIKey requires a login session or a valid API key on /api requests once
+// at least one key exists or a UI password is configured. Keys are sent as
+// "Authorization: Bearer <key>" or "X-API-Key"; sessions use the HttpOnly
+// session cookie and must echo their CSRF token in X-CSRF-Token on unsafe
+// methods. Requests through Home Assistant ingress are already authenticated
+// by HA and pass through, as do the health and readiness checks and the
+// login endpoints.
+// The matched key or session is stored in c.Locals("api_key") or
+// c.Locals("session").
+//
+// Invalid keys count towards the limiter's lockout, and denials, lockouts and
+// keys used from a new address are written to the audit log.
+func APIKey(keys *auth.KeyStore, sessions *auth.SessionStore, limiter *auth.Limiter, audit *auth.AuditLog) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() == fiber.MethodOptions || c.Path() == "/api/health" || c.Path() == "/api/ready" ||
+			strings.HasPrefix(c.Path(), "/api/auth/") || fromIngress(c) {
+			return c.Next()
+		}
+
+		if session, ok := sessions.Get(c.Cookies(auth.SessionCookie)); ok {
+			if !safeMethod(c.Method()) && !session.ValidCSRF(c.Get("X-CSRF-Token")) {
+				recordEvent(audit, c, auth.EventPermissionDenied, session.Username, "", "missing or invalid CSRF token")
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "missing or invalid CSRF token",
+				})
+			}
+			c.Locals("session", session)
+			return c.Next()
+		}
+
+		if !keys.Enabled() && !sessions.PasswordLogin() {
+			return c.Next()
+		}
+
+		secret := c.Get("X-API-Key")
+		if bearer := c.Get(fiber.HeaderAuthorization); secret == "" && strings.HasPrefix(bearer, "Bearer ") {
+			secret = strings.TrimSpace(strings.TrimPrefix(bearer, "Bearer "))
+		}
+		if secret == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "login or API key required",
+			})
+		}
+
+		if wait := limiter.Check(c.IP()); wait > 0 {
+			return tooManyAttempts(c, wait)
+		}
+
+		key, ok := keys.Authenticate(secret)
+		if !ok {
+			recordEvent(audit, c, auth.EventKeyInvalid, "", "", "invalid, expired or revoked key")
+			if lockout := limiter.Fail(c.IP()); lockout > 0 {
+				fmt.Printf("🔒 Locked out %s for %s after repeated failures\n", c.IP(), lockout)
+				recordEvent(audit, c, auth.EventLockedOut, "", "", "locked for "+lockout.String())
+				return tooManyAttempts(c, lockout)
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid, expired or revoked API key",
+			})
+		}
+		limiter.Succeed(c.IP())
+
+		actor := "key:" + key.Name
+		if keys.NoteIP(key.ID, c.IP()) {
+			recordEvent(audit, c, auth.EventKeyNewIP, actor, key.ID, "")
+		}
+		if !key.Allows(c.Method(), c.Path()) {
+			recordEvent(audit, c, auth.EventPermissionDenied, actor, key.ID, "role "+key.Role)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "the " + key.Role + " role cannot perform this request",
+			})
+		}
+
+		c.Locals("api_key", key)
+		return c.Next()
+	}
+}
+
+// RequireAdmin guards a route group for admin keys, whatever path the
+// request was spelled with. Sessions and ingress requests act for the UI
+// user, who administers the add-on, and pass.
+func RequireAdmin(audit *auth.AuditLog) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := c.Locals("api_key").(*auth.APIKey)
+		if !ok || key.Role == auth.RoleAdmin {
+			return c.Next()
+		}
+		recordEvent(audit, c, auth.EventPermissionDenied, "key:"+key.Name, key.ID, "role "+key.Role)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "the " + key.Role + " role cannot perform this request",
+		})
+	}
+}
+
+// fromIngress reports whether the request came through Home Assistant ingress
+func fromIngress(c *fiber.Ctx) bool {
+	return auth.FromIngress(c.IP(), c.Get("X-Ingress-Path"))
+}
+
+// safeMethod reports whether a method only reads, so needs no CSRF token
+func safeMethod(method string) bool {
+	return method == fiber.MethodGet || method == fiber.MethodHead
+}
+
+// recordEvent adds a request-related event to the audit log
+func recordEvent(audit *auth.AuditLog, c *fiber.Ctx, eventType, actor, keyID, details string) {
+	audit.Record(auth.AuditEvent{
+		Type:    eventType,
+		IP:      c.IP(),
+		Actor:   actor,
+		KeyID:   keyID,
+		Method:  c.Method(),
+		Path:    c.Path(),
+		Details: details,
+	})
+}
+
+// tooManyAttempts responds 429 with a Retry-After header for a locked out client
+func tooManyAttempts(c *fiber.Ctx, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":   "too many failed attempts",
+		"details": fmt.Sprintf("try again in %d seconds", seconds),
+	})
+}
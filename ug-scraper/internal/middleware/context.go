@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// disconnectPoll is how often an open request checks whether its client
+// has gone away
+const disconnectPoll = 250 * time.Millisecond
+
+// RequestContext gives each request a context, available through
+// c.UserContext(), that is cancelled when the handler returns or the client
+// closes the connection. Handlers pass it on to the UG, FlareSolverr and
+// webhook clients so abandoned requests stop their outbound calls.
+func RequestContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(c.UserContext())
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		done := make(chan struct{})
+		defer close(done)
+		go watchDisconnect(c.Context().Conn(), done, cancel)
+
+		return c.Next()
+	}
+}
+
+// watchDisconnect cancels the request once the client is gone, until done
+// is closed
+func watchDisconnect(conn net.Conn, done <-chan struct{}, cancel context.CancelFunc) {
+	ticker := time.NewTicker(disconnectPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if connClosed(conn) {
+				cancel()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package middleware
+
+import "net"
+
+// connClosed can't tell on this platform; requests are only cancelled when
+// their handler returns
+func connClosed(conn net.Conn) bool {
+	return false
+}
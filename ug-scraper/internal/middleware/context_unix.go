@@ -0,0 +1,30 @@
+//go:build linux || darwin
+
+package middleware
+
+import (
+	"net"
+	"syscall"
+)
+
+// connClosed peeks at the socket without consuming anything: a read of zero
+// bytes means the client sent FIN. Pipelined request data or no data at all
+// both leave the connection open.
+func connClosed(conn net.Conn) bool {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	closed := false
+	buf := make([]byte, 1)
+	_ = raw.Control(func(fd uintptr) {
+		n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		closed = n == 0 && err == nil || err == syscall.ECONNRESET
+	})
+	return closed
+}
@@ -9,7 +9,7 @@ import (
 func CORS() fiber.Handler {
 	return cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Profile, If-Match, If-None-Match",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	})
 }
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/features"
+)
+
+// RequireFeature answers 501 with the capability descriptor for requests to
+// a switched-off subsystem's endpoints, so clients can tell a disabled
+// feature from a missing route
+func RequireFeature(registry *features.Registry, name string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if registry.Enabled(name) {
+			return c.Next()
+		}
+
+		capability, _ := registry.Get(name)
+		// Routing ignores case and trailing slashes, so the match does too
+		path := auth.RoutePath(c.Path())
+		for _, endpoint := range capability.Endpoints {
+			endpoint = auth.RoutePath(endpoint)
+			if path == endpoint || strings.HasPrefix(path, endpoint+"/") {
+				return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
+					"error":      name + " is disabled",
+					"details":    capability.Reason,
+					"capability": capability,
+				})
+			}
+		}
+		return c.Next()
+	}
+}
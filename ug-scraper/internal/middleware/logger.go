@@ -5,10 +5,20 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
-// Logger returns logger middleware configuration
-func Logger() fiber.Handler {
+// Logger returns logger middleware configuration for the given log level:
+// debug adds the client address and errors to each request line, warn and
+// error leave requests out
+func Logger(level string) fiber.Handler {
+	format := "${time} | ${status} | ${latency} | ${method} ${path}\n"
+	if level == "debug" {
+		format = "${time} | ${status} | ${latency} | ${ip} | ${method} ${path} ${error}\n"
+	}
+
 	return logger.New(logger.Config{
-		Format:     "${time} | ${status} | ${latency} | ${method} ${path}\n",
+		Next: func(*fiber.Ctx) bool {
+			return level == "warn" || level == "error"
+		},
+		Format:     format,
 		TimeFormat: "2006-01-02 15:04:05",
 		TimeZone:   "Local",
 	})
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/profiles"
+)
+
+// ProfileHeader selects the user profile a request acts for
+const ProfileHeader = "X-Profile"
+
+// Profile selects the user profile of /api requests: the one named in the
+// X-Profile header, else the one named like the logged-in user (the login
+// session or the Home Assistant user behind ingress). The profile is stored
+// in c.Locals("profile"); requests without one use the shared settings. An
+// X-Profile header naming no profile answers 404.
+func Profile(store *profiles.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if name := c.Get(ProfileHeader); name != "" {
+			profile, ok := store.Get(name)
+			if !ok {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error":   "unknown profile",
+					"details": "no profile is named " + name,
+				})
+			}
+			c.Locals("profile", profile)
+			return c.Next()
+		}
+
+		username := ""
+		if session, ok := c.Locals("session").(*auth.Session); ok {
+			username = session.Username
+		} else if fromIngress(c) {
+			username = c.Get("X-Remote-User-Name")
+		}
+		if profile, ok := store.Get(username); ok && username != "" {
+			c.Locals("profile", profile)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+)
+
+// SecurityHeaders sets a Content-Security-Policy that limits the web UI to
+// its own scripts, styles and API, and stops browsers sniffing content types
+// or leaking URLs in referrers. The UI may be framed by its own origin, which
+// covers Home Assistant ingress, and by frameAncestors ("*" for any).
+func SecurityHeaders(frameAncestors []string) fiber.Handler {
+	ancestors := "'self'"
+	frameOptions := "SAMEORIGIN"
+	if len(frameAncestors) > 0 {
+		ancestors += " " + strings.Join(frameAncestors, " ")
+		// X-Frame-Options can't name other origins; the CSP does
+		frameOptions = ""
+	}
+
+	csp := strings.Join([]string{
+		"default-src 'self'",
+		"script-src 'self'",
+		"style-src 'self' 'unsafe-inline'", // Material UI injects its styles
+		"img-src 'self' data: blob:",
+		"font-src 'self' data:",
+		"connect-src 'self'",
+		"object-src 'none'",
+		"base-uri 'self'",
+		"form-action 'self'",
+		"frame-ancestors " + ancestors,
+	}, "; ")
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, csp)
+		if frameOptions != "" {
+			c.Set(fiber.HeaderXFrameOptions, frameOptions)
+		}
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Set(fiber.HeaderReferrerPolicy, "same-origin")
+		return c.Next()
+	}
+}
+
+// CSRF protects unsafe /api requests from browsers that have neither a login
+// session nor an API key, i.e. through Home Assistant ingress or while the
+// API is open. Such browsers get a random token in the HttpOnly CSRF cookie,
+// which GET /api/auth/session also returns, and must echo it in
+// X-CSRF-Token; a logged-in browser may send its session's token instead.
+// Requests with a valid key, and clients that aren't browsers (no Origin or
+// Sec-Fetch-Site header), are left alone. It runs after APIKey; the token is
+// stored in c.Locals("csrf_token").
+func CSRF(sessions *auth.SessionStore, audit *auth.AuditLog) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cookie := c.Cookies(auth.CSRFCookie)
+		token := cookie
+		if token == "" {
+			var err error
+			if token, err = auth.NewCSRFToken(); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   "failed to create CSRF token",
+					"details": err.Error(),
+				})
+			}
+			c.Cookie(&fiber.Cookie{
+				Name:     auth.CSRFCookie,
+				Value:    token,
+				Path:     "/",
+				HTTPOnly: true,
+				Secure:   c.Protocol() == "https",
+				SameSite: fiber.CookieSameSiteStrictMode,
+			})
+		}
+		c.Locals("csrf_token", token)
+
+		if safeMethod(c.Method()) || c.Method() == fiber.MethodOptions ||
+			strings.HasPrefix(c.Path(), "/api/auth/") || !fromBrowser(c) ||
+			c.Locals("session") != nil || c.Locals("api_key") != nil {
+			return c.Next()
+		}
+
+		header := c.Get("X-CSRF-Token")
+		if header != "" && cookie != "" && subtle.ConstantTimeCompare([]byte(header), []byte(cookie)) == 1 {
+			return c.Next()
+		}
+		if session, ok := sessions.Get(c.Cookies(auth.SessionCookie)); ok && session.ValidCSRF(header) {
+			return c.Next()
+		}
+
+		recordEvent(audit, c, auth.EventPermissionDenied, c.Get("X-Remote-User-Name"), "", "missing or invalid CSRF token")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "missing or invalid CSRF token",
+		})
+	}
+}
+
+// fromBrowser reports whether a request was made by a browser, which sends
+// Origin or Sec-Fetch-Site with every unsafe request
+func fromBrowser(c *fiber.Ctx) bool {
+	return c.Get(fiber.HeaderOrigin) != "" || c.Get("Sec-Fetch-Site") != ""
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package migrate
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive advisory lock on path, waiting for another
+// process (the CLI or a second server) to finish, and returns its release
+func lock(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
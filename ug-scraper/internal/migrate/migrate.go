@@ -0,0 +1,211 @@
+// Package migrate upgrades and rolls back the schema of the JSON data files
+// kept in /data, so an add-on update can change their layout safely.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// VersionKey is the top-level field holding a file's schema version; files
+// without it are version 0
+const VersionKey = "schema_version"
+
+// ErrTooNew is returned for a file written by a newer schema than the
+// migrations know
+var ErrTooNew = errors.New("data file has a newer schema")
+
+// Doc is a decoded data file. Numbers are kept as json.Number so values
+// migrations don't touch are written back unchanged.
+type Doc map[string]any
+
+// Migration moves a file from schema Version-1 to Version and back
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(doc Doc) error
+	Down    func(doc Doc) error // nil when older versions read the upgraded data as is
+}
+
+// Result describes what a migration run did
+type Result struct {
+	From    int      `json:"from"`
+	To      int      `json:"to"`
+	Applied []string `json:"applied,omitempty"`
+	Backup  string   `json:"backup,omitempty"` // Copy of the file before it was changed
+}
+
+// Latest returns the schema version the migrations lead to
+func Latest(migrations []Migration) int {
+	return len(migrations)
+}
+
+// File migrates the JSON file at path to the target schema version, or to
+// the latest one when target is negative. The file is locked while it is
+// migrated, copied to a timestamped backup first and replaced only once
+// every step succeeded. A missing file is left alone.
+func File(path string, migrations []Migration, target int) (*Result, error) {
+	for i, m := range migrations {
+		if m.Version != i+1 || m.Up == nil {
+			return nil, fmt.Errorf("migration %q must be number %d and have an Up step", m.Name, i+1)
+		}
+	}
+	if target < 0 {
+		target = Latest(migrations)
+	}
+	if target > Latest(migrations) {
+		return nil, fmt.Errorf("no migrations to schema v%d (latest is v%d)", target, Latest(migrations))
+	}
+
+	unlock, err := lock(path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+	defer unlock()
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Result{From: target, To: target}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	doc, err := decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	from, err := Version(doc)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{From: from, To: target}
+	if from > Latest(migrations) {
+		return result, fmt.Errorf("%w: v%d, this version knows up to v%d", ErrTooNew, from, Latest(migrations))
+	}
+	if from == target {
+		return result, nil
+	}
+
+	// Work on the decoded copy; the file is only replaced after every step
+	if err := apply(doc, migrations, result); err != nil {
+		return result, err
+	}
+
+	result.Backup = fmt.Sprintf("%s.v%d-%s.bak", path, from, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(result.Backup, raw, 0644); err != nil {
+		return result, fmt.Errorf("writing backup: %w", err)
+	}
+
+	encoded, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return result, fmt.Errorf("encoding %s: %w", path, err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, encoded, 0644); err != nil {
+		return result, fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return result, fmt.Errorf("replacing %s: %w", path, err)
+	}
+
+	return result, nil
+}
+
+// Upgrade migrates a data file held in memory, such as one read from a
+// backup, to the latest schema. Files already there are returned as is.
+func Upgrade(raw []byte, migrations []Migration) ([]byte, error) {
+	doc, err := decode(raw)
+	if err != nil {
+		return nil, err
+	}
+	from, err := Version(doc)
+	if err != nil {
+		return nil, err
+	}
+	if from > Latest(migrations) {
+		return nil, fmt.Errorf("%w: v%d, this version knows up to v%d", ErrTooNew, from, Latest(migrations))
+	}
+	if from == Latest(migrations) {
+		return raw, nil
+	}
+
+	if err := apply(doc, migrations, &Result{From: from, To: Latest(migrations)}); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// apply moves a decoded file from result.From to result.To, recording the
+// steps taken
+func apply(doc Doc, migrations []Migration, result *Result) error {
+	if result.From < result.To {
+		for _, m := range migrations[result.From:result.To] {
+			if err := m.Up(doc); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			result.Applied = append(result.Applied, fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+	} else {
+		for i := result.From - 1; i >= result.To; i-- {
+			m := migrations[i]
+			if m.Down != nil {
+				if err := m.Down(doc); err != nil {
+					return fmt.Errorf("rolling back migration %d (%s): %w", m.Version, m.Name, err)
+				}
+			}
+			result.Applied = append(result.Applied, fmt.Sprintf("%d_%s (rolled back)", m.Version, m.Name))
+		}
+	}
+	doc[VersionKey] = result.To
+	return nil
+}
+
+// Version returns a decoded file's schema version
+func Version(doc Doc) (int, error) {
+	v, ok := doc[VersionKey]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%s is not a number", VersionKey)
+	}
+	version, err := n.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid %s %q", VersionKey, n)
+	}
+	return int(version), nil
+}
+
+// decode parses a data file keeping numbers exact
+func decode(raw []byte) (Doc, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var doc Doc
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = Doc{}
+	}
+	return doc, nil
+}
+
+// Objects returns the JSON objects in a top-level array field, for
+// migrations that rewrite every entry of a list
+func (d Doc) Objects(key string) []map[string]any {
+	list, _ := d[key].([]any)
+	objects := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if obj, ok := item.(map[string]any); ok {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
@@ -0,0 +1,250 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
+)
+
+const (
+	defaultTopicPrefix     = "ug-scraper"
+	defaultDiscoveryPrefix = "homeassistant"
+)
+
+// Config holds MQTT broker connection settings
+type Config struct {
+	Broker      string // e.g. tcp://core-mosquitto:1883
+	Username    string
+	Password    string
+	ClientID    string
+	TopicPrefix string
+	QoS         byte
+	Retain      bool
+
+	// Discovery publishes Home Assistant MQTT discovery configs for the add-on sensors
+	Discovery       bool
+	DiscoveryPrefix string
+}
+
+// ConfigFromEnv reads MQTT settings from MQTT_* environment variables.
+// An empty Broker means MQTT publishing is disabled.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Broker:      os.Getenv("MQTT_BROKER"),
+		Username:    os.Getenv("MQTT_USERNAME"),
+		Password:    os.Getenv("MQTT_PASSWORD"),
+		ClientID:    os.Getenv("MQTT_CLIENT_ID"),
+		TopicPrefix: strings.Trim(os.Getenv("MQTT_TOPIC_PREFIX"), "/"),
+		QoS:         1,
+
+		Discovery:       os.Getenv("MQTT_DISCOVERY") != "false",
+		DiscoveryPrefix: strings.Trim(os.Getenv("MQTT_DISCOVERY_PREFIX"), "/"),
+	}
+
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = defaultTopicPrefix
+	}
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = defaultDiscoveryPrefix
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = fmt.Sprintf("ug-scraper-%d", time.Now().Unix())
+	}
+
+	return cfg
+}
+
+// Event is a notification published to <prefix>/events/<name>
+type Event struct {
+	Name      string      `json:"event"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Client publishes converted songs and events to an MQTT broker.
+// A Client without a broker configured is valid and silently drops messages.
+type Client struct {
+	mu     sync.Mutex
+	config Config
+
+	connMu sync.RWMutex
+	client paho.Client
+	opts   *paho.ClientOptions
+
+	stateMu sync.Mutex
+	state   sensorState
+}
+
+// NewClient creates a new MQTT client and connects in the background.
+// Connection failures are retried automatically by the underlying library.
+func NewClient(cfg Config) *Client {
+	c := &Client{config: cfg}
+	if cfg.Broker == "" {
+		return c
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(10*time.Second).
+		SetConnectTimeout(10*time.Second).
+		SetWill(c.availabilityTopic(), "offline", cfg.QoS, true).
+		SetOnConnectHandler(func(paho.Client) {
+			fmt.Printf("📡 MQTT connected to %s\n", cfg.Broker)
+			c.publishDiscovery()
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			fmt.Printf("⚠️  MQTT connection lost: %v\n", err)
+		})
+
+	c.opts = opts
+	c.client = paho.NewClient(opts)
+	c.client.Connect() // Returns immediately; ConnectRetry keeps trying in the background
+
+	return c
+}
+
+// conn returns the current broker connection
+func (c *Client) conn() paho.Client {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.client
+}
+
+// Enabled reports whether a broker is configured
+func (c *Client) Enabled() bool {
+	return c != nil && c.conn() != nil
+}
+
+// Connected reports whether the client currently has a broker connection
+func (c *Client) Connected() bool {
+	return c.Enabled() && c.conn().IsConnectionOpen()
+}
+
+// Reconnect replaces the broker connection with a fresh one. The library
+// retries on its own, but a connection that stays down (e.g. after the
+// broker restarted with new credentials) is recreated from scratch.
+func (c *Client) Reconnect() (string, error) {
+	if !c.Enabled() {
+		return "", fmt.Errorf("MQTT is not configured")
+	}
+
+	c.connMu.Lock()
+	old := c.client
+	c.client = paho.NewClient(c.opts)
+	c.client.Connect()
+	c.connMu.Unlock()
+
+	old.Disconnect(0)
+
+	return "recreated the connection to " + c.config.Broker, nil
+}
+
+// Health scores the broker connection: 1 when connected, 0 otherwise
+func (c *Client) Health() (float64, string) {
+	if c.Connected() {
+		return 1, "connected to " + c.config.Broker
+	}
+	return 0, "not connected to " + c.config.Broker
+}
+
+// Broker returns the configured broker URL
+func (c *Client) Broker() string {
+	if c == nil {
+		return ""
+	}
+	return c.config.Broker
+}
+
+// Topic returns a full topic name under the configured prefix
+func (c *Client) Topic(parts ...string) string {
+	return strings.Join(append([]string{c.config.TopicPrefix}, parts...), "/")
+}
+
+// PublishSong publishes a converted song to <prefix>/songs using the same
+// payload structure as webhook deliveries
+func (c *Client) PublishSong(payload *webhook.WebhookPayload) error {
+	if err := c.publish(c.Topic("songs"), payload, false); err != nil {
+		return err
+	}
+
+	c.PublishEvent(events.SongPublished, map[string]string{
+		"title":  payload.Title,
+		"artist": payload.Artist,
+	})
+
+	return nil
+}
+
+// PublishEvent publishes an event to <prefix>/events/<name>. Errors are
+// logged rather than returned since events are best-effort.
+func (c *Client) PublishEvent(name string, data interface{}) {
+	if !c.Enabled() {
+		return
+	}
+
+	event := Event{
+		Name:      name,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	if err := c.publish(c.Topic("events", name), event, false); err != nil {
+		fmt.Printf("⚠️  MQTT event %s not published: %v\n", name, err)
+	}
+
+	c.updateSensors(name, data)
+}
+
+// publish marshals v as JSON and publishes it, waiting for the broker to acknowledge
+func (c *Client) publish(topic string, v interface{}, retain bool) error {
+	if !c.Enabled() {
+		return fmt.Errorf("MQTT is not configured")
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling MQTT payload: %w", err)
+	}
+
+	return c.publishRaw(topic, data, retain)
+}
+
+// publishRaw publishes a string or byte payload as-is
+func (c *Client) publishRaw(topic string, payload interface{}, retain bool) error {
+	if !c.Enabled() {
+		return fmt.Errorf("MQTT is not configured")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token := c.conn().Publish(topic, c.config.QoS, retain || c.config.Retain, payload)
+	if !token.WaitTimeout(10 * time.Second) {
+		return fmt.Errorf("publishing to %s timed out", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("publishing to %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// Close marks the add-on offline and disconnects from the broker
+func (c *Client) Close() {
+	if c.Enabled() {
+		_ = c.publishRaw(c.availabilityTopic(), "offline", true)
+		c.conn().Disconnect(250)
+	}
+}
@@ -0,0 +1,168 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/events"
+)
+
+// sensor describes a Home Assistant sensor announced via MQTT discovery
+type sensor struct {
+	objectID   string
+	name       string
+	icon       string
+	stateClass string
+	unit       string
+}
+
+var sensors = []sensor{
+	{objectID: "last_song", name: "Last song converted", icon: "mdi:music-note"},
+	{objectID: "webhook_status", name: "Webhook status", icon: "mdi:webhook"},
+	{objectID: "scrape_failures", name: "Scrape failures", icon: "mdi:alert-circle-outline", stateClass: "total_increasing", unit: "failures"},
+}
+
+// sensorState holds the current value and attributes of each sensor
+type sensorState struct {
+	lastSong       string
+	lastSongAttrs  map[string]interface{}
+	webhookStatus  string
+	webhookAttrs   map[string]interface{}
+	scrapeFailures int
+	failureAttrs   map[string]interface{}
+}
+
+// availabilityTopic is where the add-on reports online/offline (retained)
+func (c *Client) availabilityTopic() string {
+	return c.Topic("status")
+}
+
+// publishDiscovery announces every sensor to Home Assistant and publishes
+// the current states. Called on every (re)connect.
+func (c *Client) publishDiscovery() {
+	if err := c.publishRaw(c.availabilityTopic(), "online", true); err != nil {
+		fmt.Printf("⚠️  MQTT availability not published: %v\n", err)
+	}
+
+	if !c.config.Discovery {
+		return
+	}
+
+	device := map[string]interface{}{
+		"identifiers":  []string{c.config.TopicPrefix},
+		"name":         "Ultimate Guitar Scraper",
+		"manufacturer": "RFC1918-hub",
+		"model":        "Home Assistant add-on",
+	}
+
+	for _, s := range sensors {
+		config := map[string]interface{}{
+			"name":                  s.name,
+			"unique_id":             fmt.Sprintf("%s_%s", c.config.TopicPrefix, s.objectID),
+			"state_topic":           c.Topic("sensor", s.objectID, "state"),
+			"json_attributes_topic": c.Topic("sensor", s.objectID, "attributes"),
+			"availability_topic":    c.availabilityTopic(),
+			"icon":                  s.icon,
+			"device":                device,
+		}
+		if s.stateClass != "" {
+			config["state_class"] = s.stateClass
+		}
+		if s.unit != "" {
+			config["unit_of_measurement"] = s.unit
+		}
+
+		topic := fmt.Sprintf("%s/sensor/%s/%s/config", c.config.DiscoveryPrefix, c.config.TopicPrefix, s.objectID)
+		if err := c.publish(topic, config, true); err != nil {
+			fmt.Printf("⚠️  MQTT discovery for %s not published: %v\n", s.objectID, err)
+		}
+	}
+
+	c.publishSensorStates()
+}
+
+// updateSensors applies an event to the sensor states and republishes them
+func (c *Client) updateSensors(name string, data interface{}) {
+	if !c.config.Discovery {
+		return
+	}
+
+	attrs := toAttributes(data)
+	now := time.Now()
+
+	c.stateMu.Lock()
+	switch name {
+	case events.TabConverted:
+		c.state.lastSong = fmt.Sprintf("%v - %v", attrs["artist"], attrs["title"])
+		c.state.lastSongAttrs = withTimestamp(attrs, now)
+	case events.WebhookSent:
+		c.state.webhookStatus = "delivered"
+		c.state.webhookAttrs = withTimestamp(attrs, now)
+	case events.WebhookFailed:
+		c.state.webhookStatus = "failed"
+		c.state.webhookAttrs = withTimestamp(attrs, now)
+	case events.ScrapeFailed:
+		c.state.scrapeFailures++
+		c.state.failureAttrs = withTimestamp(attrs, now)
+	default:
+		c.stateMu.Unlock()
+		return
+	}
+	c.stateMu.Unlock()
+
+	c.publishSensorStates()
+}
+
+// publishSensorStates publishes the retained state and attributes of every sensor
+func (c *Client) publishSensorStates() {
+	c.stateMu.Lock()
+	states := map[string]string{
+		"last_song":       orUnknown(c.state.lastSong),
+		"webhook_status":  orUnknown(c.state.webhookStatus),
+		"scrape_failures": fmt.Sprintf("%d", c.state.scrapeFailures),
+	}
+	attributes := map[string]map[string]interface{}{
+		"last_song":       c.state.lastSongAttrs,
+		"webhook_status":  c.state.webhookAttrs,
+		"scrape_failures": c.state.failureAttrs,
+	}
+	c.stateMu.Unlock()
+
+	for objectID, state := range states {
+		if err := c.publishRaw(c.Topic("sensor", objectID, "state"), state, true); err != nil {
+			fmt.Printf("⚠️  MQTT state for %s not published: %v\n", objectID, err)
+			continue
+		}
+		if attrs := attributes[objectID]; attrs != nil {
+			_ = c.publish(c.Topic("sensor", objectID, "attributes"), attrs, true)
+		}
+	}
+}
+
+// toAttributes converts event data (any JSON-serializable value) to a map
+func toAttributes(data interface{}) map[string]interface{} {
+	attrs := make(map[string]interface{})
+	if raw, err := json.Marshal(data); err == nil {
+		_ = json.Unmarshal(raw, &attrs)
+	}
+	return attrs
+}
+
+// withTimestamp returns a copy of attrs with an updated_at field
+func withTimestamp(attrs map[string]interface{}, now time.Time) map[string]interface{} {
+	copied := make(map[string]interface{}, len(attrs)+1)
+	for k, v := range attrs {
+		copied[k] = v
+	}
+	copied["updated_at"] = now
+	return copied
+}
+
+// orUnknown returns "unknown" for empty sensor states
+func orUnknown(state string) string {
+	if state == "" {
+		return "unknown"
+	}
+	return state
+}
@@ -0,0 +1,81 @@
+package ocr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxAPIResponse caps how much text is read back from an OCR service
+const maxAPIResponse = 4 << 20
+
+// APIClient sends scans to an external OCR service. The scan is POSTed as
+// the raw body with its Content-Type; the service answers with plain text or
+// JSON holding a "text" field.
+type APIClient struct {
+	url        string
+	key        string
+	httpClient *http.Client
+}
+
+// NewAPIClient creates an OCR service client
+func NewAPIClient(url, key string) *APIClient {
+	return &APIClient{
+		url: url,
+		key: key,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// Name implements Engine
+func (a *APIClient) Name() string {
+	return EngineAPI
+}
+
+// Recognize implements Engine
+func (a *APIClient) Recognize(data []byte, contentType string) (string, error) {
+	req, err := http.NewRequest("POST", a.url, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json, text/plain")
+	if a.key != "" {
+		req.Header.Set("Authorization", "Bearer "+a.key)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("OCR request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAPIResponse))
+	if err != nil {
+		return "", fmt.Errorf("reading OCR response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("OCR service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var result struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return "", fmt.Errorf("decoding OCR response: %w", err)
+		}
+		return result.Text, nil
+	}
+
+	return string(body), nil
+}
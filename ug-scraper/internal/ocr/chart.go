@@ -0,0 +1,161 @@
+package ocr
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+)
+
+var (
+	// tokenRegex finds the words of a line with their positions
+	tokenRegex = regexp.MustCompile(`\S+`)
+	// tokenJunk is punctuation OCR leaves around chord names and bar lines
+	tokenJunk = "|.,;:'\"`()[]{}!"
+	// noiseLineRegex matches lines of stray marks from smudges and staff lines
+	noiseLineRegex = regexp.MustCompile(`^[\s\p{P}\p{S}]*$`)
+	// titleSplitRegex splits a "Title - Artist" or "Title by Artist" heading
+	titleSplitRegex = regexp.MustCompile(`^(.+?)\s+(?:-|–|—|by)\s+(.+)$`)
+)
+
+// chordLineShare is the share of words that must read as chords for a line
+// to be taken as a chord line
+const chordLineShare = 0.7
+
+// Chart is OCR text cleaned up into a plain chord chart: chords on their own
+// lines above the lyrics, as FormatManualContent expects
+type Chart struct {
+	Text       string `json:"text"`
+	Title      string `json:"title,omitempty"`  // Guessed from the heading
+	Artist     string `json:"artist,omitempty"` // Guessed from the heading
+	ChordLines int    `json:"chord_lines"`
+	LyricLines int    `json:"lyric_lines"`
+}
+
+// CleanChart tells chord lines from lyrics in OCR output and repairs the
+// chord names OCR commonly misreads ("Arn" for "Am", "F♯" for "F#", stray
+// bar lines and dots), keeping each chord above the syllable it was over.
+// Lines of stray marks are dropped. The first lines before any chord line
+// are taken as the title and artist.
+func CleanChart(text string) *Chart {
+	text = converter.NormalizeTypography(strings.NewReplacer("\r\n", "\n", "\f", "\n").Replace(text))
+
+	chart := &Chart{}
+	var heading, out []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line != "" && noiseLineRegex.MatchString(line) {
+			continue
+		}
+
+		if cleaned, ok := chordLine(line); ok {
+			chart.ChordLines++
+			out = append(out, cleaned)
+			continue
+		}
+
+		if strings.TrimSpace(line) != "" {
+			if chart.ChordLines == 0 && chart.LyricLines == len(heading) && len(heading) < 2 && !isSectionLabel(line) {
+				heading = append(heading, strings.TrimSpace(line))
+			}
+			chart.LyricLines++
+		}
+		out = append(out, line)
+	}
+
+	// Drop the heading from the chart body once it is used as title/artist
+	if len(heading) > 0 {
+		chart.Title = heading[0]
+		if m := titleSplitRegex.FindStringSubmatch(chart.Title); m != nil {
+			chart.Title, chart.Artist = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		} else if len(heading) > 1 {
+			chart.Artist = heading[1]
+		}
+
+		used := 1
+		if chart.Artist != "" && len(heading) > 1 && chart.Artist == heading[1] {
+			used = 2
+		}
+		for removed := 0; removed < used && len(out) > 0; {
+			if strings.TrimSpace(out[0]) != "" {
+				removed++
+				chart.LyricLines--
+			}
+			out = out[1:]
+		}
+	}
+
+	chart.Text = strings.Trim(strings.Join(out, "\n"), "\n") + "\n"
+	return chart
+}
+
+// chordLine reports whether a line is mostly chords and returns it with the
+// chords repaired and everything else blanked, keeping their columns
+func chordLine(line string) (string, bool) {
+	matches := tokenRegex.FindAllStringIndex(line, -1)
+	if len(matches) == 0 {
+		return line, false
+	}
+
+	var b strings.Builder
+	chords, words, last := 0, 0, 0
+	for _, m := range matches {
+		token := line[m[0]:m[1]]
+		trimmed := strings.Trim(token, tokenJunk)
+		if trimmed == "" {
+			// Bar lines and dots carry no chord; keep the column
+			b.WriteString(line[last:m[0]] + strings.Repeat(" ", len(token)))
+			last = m[1]
+			continue
+		}
+		words++
+
+		chord, ok := repairChord(trimmed)
+		if !ok {
+			b.WriteString(line[last:m[0]] + strings.Repeat(" ", len(token)))
+			last = m[1]
+			continue
+		}
+		chords++
+		b.WriteString(line[last:m[0]] + chord)
+		if pad := len(token) - len(chord); pad > 0 {
+			b.WriteString(strings.Repeat(" ", pad))
+		}
+		last = m[1]
+	}
+
+	if chords == 0 || float64(chords) < chordLineShare*float64(words) {
+		return line, false
+	}
+	return strings.TrimRight(b.String(), " "), true
+}
+
+// repairChord fixes the usual OCR misreads of a chord name and reports
+// whether the result is a chord
+func repairChord(token string) (string, bool) {
+	if converter.ValidChord(token) {
+		return token, true
+	}
+
+	fixed := strings.ReplaceAll(token, "rn", "m")
+	if r := []rune(fixed); len(r) > 0 && unicode.IsLower(r[0]) && strings.ContainsRune("abcdefg", r[0]) && len(r) <= 6 {
+		fixed = string(unicode.ToUpper(r[0])) + string(r[1:])
+	}
+	if converter.ValidChord(fixed) {
+		return fixed, true
+	}
+	return token, false
+}
+
+// isSectionLabel reports whether a line names a part of the song, like
+// "Chorus" or "Verse 2:"
+func isSectionLabel(line string) bool {
+	label := strings.ToLower(strings.Trim(strings.TrimSpace(line), "[]():"))
+	for _, name := range []string{"intro", "verse", "chorus", "bridge", "pre-chorus", "prechorus", "outro", "solo", "interlude", "instrumental", "coda", "tag", "refrain"} {
+		if label == name || strings.HasPrefix(label, name+" ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package ocr
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Engines
+const (
+	EngineOff       = "off"
+	EngineTesseract = "tesseract" // Local tesseract binary, pdftoppm for PDFs
+	EngineAPI       = "api"       // External OCR service over HTTP
+)
+
+const defaultLanguage = "eng"
+
+// Config selects and configures the OCR engine
+type Config struct {
+	Engine   string // off, tesseract or api
+	Language string // Tesseract language code, e.g. "eng" or "eng+deu"
+	APIURL   string // Endpoint the scan is POSTed to for the api engine
+	APIKey   string // Sent as a bearer token when set
+}
+
+// ConfigFromEnv reads OCR_ENGINE, OCR_LANGUAGE, OCR_API_URL and OCR_API_KEY
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Engine:   strings.ToLower(os.Getenv("OCR_ENGINE")),
+		Language: os.Getenv("OCR_LANGUAGE"),
+		APIURL:   os.Getenv("OCR_API_URL"),
+		APIKey:   os.Getenv("OCR_API_KEY"),
+	}
+
+	if cfg.Engine != EngineTesseract && cfg.Engine != EngineAPI {
+		cfg.Engine = EngineOff
+	}
+	if cfg.Language == "" {
+		cfg.Language = defaultLanguage
+	}
+
+	return cfg
+}
+
+// Engine turns a photo or scan into text
+type Engine interface {
+	// Name identifies the engine in responses and logs
+	Name() string
+	// Recognize returns the text of an image (PNG, JPEG, TIFF, ...) or PDF.
+	// contentType is the upload's MIME type.
+	Recognize(data []byte, contentType string) (string, error)
+}
+
+// New returns the engine selected by cfg, or nil when OCR is off or the
+// api engine has no URL
+func New(cfg Config) Engine {
+	switch cfg.Engine {
+	case EngineTesseract:
+		fmt.Printf("📷 OCR: tesseract (%s)\n", cfg.Language)
+		return NewTesseract(cfg.Language)
+	case EngineAPI:
+		if cfg.APIURL == "" {
+			fmt.Println("⚠️  OCR disabled: OCR_API_URL is required for the api engine")
+			return nil
+		}
+		fmt.Printf("📷 OCR: %s\n", cfg.APIURL)
+		return NewAPIClient(cfg.APIURL, cfg.APIKey)
+	default:
+		return nil
+	}
+}
+
+// IsPDF reports whether an upload is a PDF, by MIME type or magic bytes
+func IsPDF(data []byte, contentType string) bool {
+	return strings.HasPrefix(contentType, "application/pdf") || strings.HasPrefix(string(data[:min(len(data), 5)]), "%PDF-")
+}
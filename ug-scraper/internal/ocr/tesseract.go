@@ -0,0 +1,88 @@
+package ocr
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pdfResolution is the DPI PDF pages are rendered at before OCR
+const pdfResolution = "300"
+
+// Tesseract runs the local tesseract binary. PDF scans are rendered to one
+// image per page with pdftoppm (poppler) first.
+type Tesseract struct {
+	language string
+}
+
+// NewTesseract creates a tesseract engine for the given language codes
+func NewTesseract(language string) *Tesseract {
+	return &Tesseract{language: language}
+}
+
+// Name implements Engine
+func (t *Tesseract) Name() string {
+	return EngineTesseract
+}
+
+// Recognize implements Engine
+func (t *Tesseract) Recognize(data []byte, contentType string) (string, error) {
+	if !IsPDF(data, contentType) {
+		return t.recognizeImage(data)
+	}
+
+	dir, err := os.MkdirTemp("", "ocr-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pdf := filepath.Join(dir, "scan.pdf")
+	if err := os.WriteFile(pdf, data, 0600); err != nil {
+		return "", fmt.Errorf("writing PDF: %w", err)
+	}
+	if out, err := exec.Command("pdftoppm", "-r", pdfResolution, "-png", pdf, filepath.Join(dir, "page")).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("rendering PDF: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	pages, _ := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if len(pages) == 0 {
+		return "", fmt.Errorf("PDF has no pages")
+	}
+	sort.Strings(pages)
+
+	texts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		image, err := os.ReadFile(page)
+		if err != nil {
+			return "", fmt.Errorf("reading rendered page: %w", err)
+		}
+		text, err := t.recognizeImage(image)
+		if err != nil {
+			return "", fmt.Errorf("page %s: %w", strings.TrimPrefix(filepath.Base(page), "page-"), err)
+		}
+		texts = append(texts, text)
+	}
+
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// recognizeImage pipes one image through tesseract. Page segmentation mode 6
+// reads the page as a single block, which keeps chords above their lyrics.
+func (t *Tesseract) recognizeImage(image []byte) (string, error) {
+	cmd := exec.Command("tesseract", "stdin", "stdout", "-l", t.language, "--psm", "6", "-c", "preserve_interword_spaces=1")
+	cmd.Stdin = bytes.NewReader(image)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
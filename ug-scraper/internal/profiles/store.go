@@ -0,0 +1,481 @@
+// Package profiles keeps per-user settings for households sharing one
+// add-on: each named profile has its own webhook, favorite songs, history
+// of viewed and sent tabs and the part of the library it works with.
+package profiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+)
+
+// maxHistory is how many history entries a profile keeps
+const maxHistory = 200
+
+// maxNameLength bounds profile names
+const maxNameLength = 64
+
+// History actions
+const (
+	ActionViewed = "viewed"
+	ActionSent   = "sent"
+)
+
+var (
+	// ErrNotFound is returned for a profile that doesn't exist
+	ErrNotFound = errors.New("profile not found")
+	// ErrExists is returned when creating a profile whose name is taken
+	ErrExists = errors.New("profile already exists")
+	// ErrInvalidName is returned for names that are empty, too long or use
+	// characters other than letters, digits, spaces and . _ -
+	ErrInvalidName = errors.New("invalid profile name")
+	// ErrInvalidTags is returned for library scope tags songs couldn't carry
+	ErrInvalidTags = errors.New("invalid library tags")
+)
+
+// Profile is one user's settings
+type Profile struct {
+	Name string `json:"name"`
+	// LibraryTags scopes library listings and searches to songs carrying
+	// any of these tags; empty for the whole library
+	LibraryTags []string              `json:"library_tags"`
+	Webhook     *config.WebhookConfig `json:"webhook,omitempty"` // nil uses the shared webhook
+	Favorites   []string              `json:"favorites"`         // Song IDs, most recent first
+	History     []HistoryEntry        `json:"history"`           // Newest first
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// HistoryEntry records a tab the profile viewed or sent
+type HistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	TabID  int       `json:"tab_id,omitempty"`
+	Title  string    `json:"title"`
+	Artist string    `json:"artist,omitempty"`
+}
+
+// InScope reports whether a song with tags is in the profile's library
+func (p *Profile) InScope(tags []string) bool {
+	if len(p.LibraryTags) == 0 {
+		return true
+	}
+	for _, tag := range p.LibraryTags {
+		for _, songTag := range tags {
+			if strings.EqualFold(tag, songTag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clone returns a deep copy
+func (p *Profile) clone() *Profile {
+	c := *p
+	c.LibraryTags = slices.Clone(p.LibraryTags)
+	c.Favorites = slices.Clone(p.Favorites)
+	c.History = slices.Clone(p.History)
+	if p.Webhook != nil {
+		webhook := *p.Webhook
+		webhook.Headers = nil
+		webhook.RedactFields = slices.Clone(p.Webhook.RedactFields)
+		if p.Webhook.Headers != nil {
+			webhook.Headers = make(map[string]string, len(p.Webhook.Headers))
+			for name, value := range p.Webhook.Headers {
+				webhook.Headers[name] = value
+			}
+		}
+		c.Webhook = &webhook
+	}
+	return &c
+}
+
+// Store manages profiles with thread-safe operations, persisted to a JSON
+// file
+type Store struct {
+	mu         sync.RWMutex
+	profiles   map[string]*Profile // By lower-cased name
+	filePath   string
+	persistent bool
+}
+
+// NewStore creates a profile store, loading existing profiles from filePath
+func NewStore(filePath string) *Store {
+	store := &Store{
+		profiles:   make(map[string]*Profile),
+		filePath:   filePath,
+		persistent: filePath != "",
+	}
+
+	if store.persistent {
+		if err := store.loadFromFile(); err != nil {
+			fmt.Printf("⚠️  Failed to load profiles: %v\n", err)
+		}
+	}
+
+	return store
+}
+
+// List returns every profile by name
+func (s *Store) List() []Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profiles := make([]Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, *profile.clone())
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+	})
+	return profiles
+}
+
+// Get returns a profile by name, ignoring case
+func (s *Store) Get(name string) (*Profile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, ok := s.profiles[key(name)]
+	if !ok {
+		return nil, false
+	}
+	return profile.clone(), true
+}
+
+// Create adds a profile
+func (s *Store) Create(name string, libraryTags []string) (*Profile, error) {
+	name = strings.TrimSpace(name)
+	if !ValidName(name) {
+		return nil, ErrInvalidName
+	}
+	tags, err := scopeTags(libraryTags)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.profiles[key(name)]; ok {
+		return nil, ErrExists
+	}
+
+	now := time.Now()
+	profile := &Profile{
+		Name:        name,
+		LibraryTags: tags,
+		Favorites:   []string{},
+		History:     []HistoryEntry{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.profiles[key(name)] = profile
+	if err := s.persist(); err != nil {
+		delete(s.profiles, key(name))
+		return nil, err
+	}
+	return profile.clone(), nil
+}
+
+// Delete removes a profile with its webhook, favorites and history
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, ok := s.profiles[key(name)]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.profiles, key(name))
+	if err := s.persist(); err != nil {
+		s.profiles[key(name)] = profile
+		return err
+	}
+	return nil
+}
+
+// SetLibraryTags changes the part of the library a profile works with
+func (s *Store) SetLibraryTags(name string, libraryTags []string) (*Profile, error) {
+	tags, err := scopeTags(libraryTags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.update(name, func(p *Profile) error {
+		p.LibraryTags = tags
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	profile, _ := s.Get(name)
+	return profile, nil
+}
+
+// SaveWebhook sets a profile's own webhook
+func (s *Store) SaveWebhook(name string, webhook *config.WebhookConfig) error {
+	return s.update(name, func(p *Profile) error {
+		webhook.CreatedAt = time.Now()
+		if p.Webhook != nil && !p.Webhook.CreatedAt.IsZero() {
+			webhook.CreatedAt = p.Webhook.CreatedAt
+		}
+		webhook.UpdatedAt = time.Now()
+		p.Webhook = webhook
+		return nil
+	})
+}
+
+// ClearWebhook removes a profile's own webhook, so it uses the shared one
+func (s *Store) ClearWebhook(name string) error {
+	return s.update(name, func(p *Profile) error {
+		p.Webhook = nil
+		return nil
+	})
+}
+
+// AddFavorite puts a song first in a profile's favorites
+func (s *Store) AddFavorite(name, songID string) error {
+	return s.update(name, func(p *Profile) error {
+		p.Favorites = slices.Insert(slices.DeleteFunc(p.Favorites, func(id string) bool { return id == songID }), 0, songID)
+		return nil
+	})
+}
+
+// RemoveFavorite drops a song from a profile's favorites
+func (s *Store) RemoveFavorite(name, songID string) error {
+	return s.update(name, func(p *Profile) error {
+		p.Favorites = slices.DeleteFunc(p.Favorites, func(id string) bool { return id == songID })
+		return nil
+	})
+}
+
+// Record adds an entry to a profile's history, keeping the newest 200. An
+// empty name records nothing, for requests without a profile.
+func (s *Store) Record(name string, entry HistoryEntry) {
+	if name == "" {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	err := s.update(name, func(p *Profile) error {
+		p.History = append([]HistoryEntry{entry}, p.History...)
+		if len(p.History) > maxHistory {
+			p.History = p.History[:maxHistory]
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		fmt.Printf("⚠️  Failed to record history for %s: %v\n", name, err)
+	}
+}
+
+// ClearHistory empties a profile's history
+func (s *Store) ClearHistory(name string) error {
+	return s.update(name, func(p *Profile) error {
+		p.History = []HistoryEntry{}
+		return nil
+	})
+}
+
+// SongDeleted drops a deleted song from every profile's favorites
+func (s *Store) SongDeleted(id string, song *library.Song) {
+	if song != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, profile := range s.profiles {
+		if slices.Contains(profile.Favorites, id) {
+			profile.Favorites = slices.DeleteFunc(profile.Favorites, func(fav string) bool { return fav == id })
+			changed = true
+		}
+	}
+	if changed {
+		if err := s.persist(); err != nil {
+			fmt.Printf("⚠️  Failed to save profiles: %v\n", err)
+		}
+	}
+}
+
+// update applies fn to a profile and saves the store, rolling back when the
+// save fails
+func (s *Store) update(name string, fn func(p *Profile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, ok := s.profiles[key(name)]
+	if !ok {
+		return ErrNotFound
+	}
+	backup := profile.clone()
+
+	if err := fn(profile); err != nil {
+		return err
+	}
+	profile.UpdatedAt = time.Now()
+
+	if err := s.persist(); err != nil {
+		s.profiles[key(name)] = backup
+		return err
+	}
+	return nil
+}
+
+// ValidName reports whether name can name a profile
+func ValidName(name string) bool {
+	if name == "" || len(name) > maxNameLength || strings.TrimSpace(name) != name {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" ._-", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeTags normalizes library scope tags like song tags
+func scopeTags(tags []string) ([]string, error) {
+	normalized, err := library.NormalizeTags(tags)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTags, err)
+	}
+	if normalized == nil {
+		normalized = []string{}
+	}
+	return normalized, nil
+}
+
+// key is the map key of a profile name
+func key(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// persist saves profiles to the JSON file (caller holds the lock)
+func (s *Store) persist() error {
+	if !s.persistent {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("creating profile directory: %w", err)
+	}
+
+	data, err := s.encode()
+	if err != nil {
+		return err
+	}
+
+	// Webhook headers may carry tokens, so keep the file private
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("writing profiles file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.filePath); err != nil {
+		return fmt.Errorf("replacing profiles file: %w", err)
+	}
+	return nil
+}
+
+// encode returns the profiles in their on-disk form, sorted by name (caller
+// holds the lock)
+func (s *Store) encode() ([]byte, error) {
+	profiles := make([]*Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return key(profiles[i].Name) < key(profiles[j].Name) })
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling profiles: %w", err)
+	}
+	return data, nil
+}
+
+// Snapshot returns every profile in its on-disk form, for backups
+func (s *Store) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.encode()
+}
+
+// Restore replaces every profile with those of a snapshot. Nothing changes
+// when the snapshot can't be read or saved.
+func (s *Store) Restore(data []byte) error {
+	var profiles []*Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("unmarshaling profiles: %w", err)
+	}
+	for _, profile := range profiles {
+		if !ValidName(profile.Name) {
+			return fmt.Errorf("%w: %q", ErrInvalidName, profile.Name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.profiles
+	s.profiles = make(map[string]*Profile)
+	s.fill(profiles)
+	if err := s.persist(); err != nil {
+		s.profiles = previous
+		return err
+	}
+	return nil
+}
+
+// loadFromFile loads profiles from the JSON file
+func (s *Store) loadFromFile() error {
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading profiles file: %w", err)
+	}
+
+	var profiles []*Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("unmarshaling profiles: %w", err)
+	}
+
+	s.fill(profiles)
+	return nil
+}
+
+// fill adds decoded profiles to the store (caller holds the lock)
+func (s *Store) fill(profiles []*Profile) {
+	for _, profile := range profiles {
+		if profile.Favorites == nil {
+			profile.Favorites = []string{}
+		}
+		if profile.LibraryTags == nil {
+			profile.LibraryTags = []string{}
+		}
+		if profile.History == nil {
+			profile.History = []HistoryEntry{}
+		}
+		s.profiles[key(profile.Name)] = profile
+	}
+}
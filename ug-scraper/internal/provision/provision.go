@@ -0,0 +1,635 @@
+// Package provision reconciles the add-on's configuration with a
+// declarative document, so tools like Ansible or Home Assistant packages can
+// manage webhooks, API keys, profiles, export settings and watches. Every
+// section present in the document is authoritative: what it lists is
+// created or updated, what it leaves out is removed. Sections left out of
+// the document are not touched.
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/auth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/config"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/library"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/profiles"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/scraper"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/webhook"
+	"gopkg.in/yaml.v3"
+)
+
+// Document sections
+const (
+	SectionWebhook   = "webhook"
+	SectionAPIKeys   = "api_keys"
+	SectionProfiles  = "profiles"
+	SectionSettings  = "settings"
+	SectionWatchlist = "watchlist"
+)
+
+// Change actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Document is the desired state. A nil section is left as it is; an empty
+// list removes everything in it.
+type Document struct {
+	Webhook   *Webhook   `json:"webhook"`
+	APIKeys   *[]APIKey  `json:"api_keys"`
+	Profiles  *[]Profile `json:"profiles"`
+	Settings  *Settings  `json:"settings"`
+	Watchlist *[]Watch   `json:"watchlist"`
+}
+
+// Webhook is a webhook target. An empty URL removes the webhook.
+type Webhook struct {
+	URL           string            `json:"url"`
+	Enabled       *bool             `json:"enabled"` // Default true
+	Headers       map[string]string `json:"headers"`
+	SchemaVersion int               `json:"schema_version"`
+	IncludeBundle bool              `json:"include_bundle"`
+	RedactFields  []string          `json:"redact_fields"`
+	MaxLines      int               `json:"max_lines"`
+}
+
+// APIKey is an API key, identified by its name. New keys' secrets are
+// returned once, in the report.
+type APIKey struct {
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Profile is a user profile, identified by its name. Favorites and
+// history are kept.
+type Profile struct {
+	Name        string   `json:"name"`
+	LibraryTags []string `json:"library_tags"`
+	Webhook     *Webhook `json:"webhook"` // nil uses the shared webhook
+}
+
+// Settings are the export format defaults; a nil format is left as it is
+type Settings struct {
+	PDF      *config.PDFDefaults      `json:"pdf"`
+	ChordPro *config.ChordProDefaults `json:"chordpro"`
+}
+
+// Watch is a watchlist entry, identified by artist, song and type
+type Watch struct {
+	Artist     string `json:"artist"`
+	Song       string `json:"song"`
+	Type       string `json:"type"`
+	WebhookURL string `json:"webhook_url"`
+}
+
+// Change is one difference between the document and the current state
+type Change struct {
+	Section string `json:"section"`
+	Action  string `json:"action"`
+	Name    string `json:"name"`
+	Details string `json:"details,omitempty"`
+	// Secret is a created API key's secret; it is not shown again
+	Secret string `json:"secret,omitempty"`
+}
+
+// Report lists what a reconcile changed, or would change on a dry run
+type Report struct {
+	DryRun  bool     `json:"dry_run"`
+	Changed bool     `json:"changed"`
+	Changes []Change `json:"changes"`
+}
+
+// ErrInvalidDocument is returned when a document doesn't validate; nothing
+// was changed
+var ErrInvalidDocument = errors.New("invalid provisioning document")
+
+// Reconciler applies documents to the stores holding the configuration
+type Reconciler struct {
+	webhooks *config.ConfigStore
+	keys     *auth.KeyStore
+	profiles *profiles.Store
+	formats  *config.FormatStore
+	library  *library.Store
+}
+
+// NewReconciler creates a reconciler for the given stores
+func NewReconciler(webhooks *config.ConfigStore, keys *auth.KeyStore, profileStore *profiles.Store, formats *config.FormatStore, libraryStore *library.Store) *Reconciler {
+	return &Reconciler{
+		webhooks: webhooks,
+		keys:     keys,
+		profiles: profileStore,
+		formats:  formats,
+		library:  libraryStore,
+	}
+}
+
+// Parse reads a document from JSON, or from YAML when yamlBody is set.
+// Unknown fields are rejected so typos don't go unnoticed.
+func Parse(data []byte, yamlBody bool) (*Document, error) {
+	if yamlBody {
+		var raw any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		converted, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		data = converted
+	}
+
+	var doc Document
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Validate checks the whole document, so nothing is applied when any part
+// of it is invalid
+func (d *Document) Validate() error {
+	var errs []error
+	if d.Webhook != nil {
+		if err := d.Webhook.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+
+	if d.APIKeys != nil {
+		seen := make(map[string]bool)
+		for i, key := range *d.APIKeys {
+			name := strings.TrimSpace(key.Name)
+			switch {
+			case name == "":
+				errs = append(errs, fmt.Errorf("api_keys[%d]: name is required", i))
+			case seen[strings.ToLower(name)]:
+				errs = append(errs, fmt.Errorf("api_keys[%d]: %q is listed twice", i, name))
+			case !auth.ValidRole(key.Role):
+				errs = append(errs, fmt.Errorf("api_keys[%d]: role must be %s, %s or %s", i, auth.RoleReader, auth.RoleEditor, auth.RoleAdmin))
+			case key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()):
+				errs = append(errs, fmt.Errorf("api_keys[%d]: expires_at must be in the future", i))
+			}
+			seen[strings.ToLower(name)] = true
+		}
+	}
+
+	if d.Profiles != nil {
+		seen := make(map[string]bool)
+		for i, profile := range *d.Profiles {
+			switch {
+			case !profiles.ValidName(profile.Name):
+				errs = append(errs, fmt.Errorf("profiles[%d]: %w %q", i, profiles.ErrInvalidName, profile.Name))
+			case seen[strings.ToLower(profile.Name)]:
+				errs = append(errs, fmt.Errorf("profiles[%d]: %q is listed twice", i, profile.Name))
+			}
+			seen[strings.ToLower(profile.Name)] = true
+			if _, err := library.NormalizeTags(profile.LibraryTags); err != nil {
+				errs = append(errs, fmt.Errorf("profiles[%d]: %w", i, err))
+			}
+			if profile.Webhook != nil {
+				if err := profile.Webhook.validate(); err != nil {
+					errs = append(errs, fmt.Errorf("profiles[%d].webhook: %w", i, err))
+				}
+			}
+		}
+	}
+
+	if d.Settings != nil {
+		if d.Settings.PDF != nil {
+			if err := d.Settings.PDF.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("settings.pdf: %w", err))
+			}
+		}
+		if d.Settings.ChordPro != nil {
+			if err := d.Settings.ChordPro.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("settings.chordpro: %w", err))
+			}
+		}
+	}
+
+	if d.Watchlist != nil {
+		seen := make(map[string]bool)
+		for i, spec := range *d.Watchlist {
+			watch, err := spec.watch()
+			if err == nil {
+				err = watch.Validate()
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("watchlist[%d]: %w", i, err))
+				continue
+			}
+			if seen[watchKey(watch)] {
+				errs = append(errs, fmt.Errorf("watchlist[%d]: %q is listed twice", i, watch.Query()))
+			}
+			seen[watchKey(watch)] = true
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reconcile validates doc and brings the stores in line with it. With
+// dryRun the changes are only reported. An error part way leaves the
+// changes before it applied; the report lists them.
+func (r *Reconciler) Reconcile(doc *Document, dryRun bool) (*Report, error) {
+	report := &Report{DryRun: dryRun, Changes: []Change{}}
+	if err := doc.Validate(); err != nil {
+		return report, fmt.Errorf("%w:\n%w", ErrInvalidDocument, err)
+	}
+
+	steps := []func(*Document, *Report) error{
+		r.reconcileSettings,
+		r.reconcileWebhook,
+		r.reconcileProfiles,
+		r.reconcileKeys,
+		r.reconcileWatchlist,
+	}
+	for _, step := range steps {
+		if err := step(doc, report); err != nil {
+			report.Changed = len(report.Changes) > 0
+			return report, err
+		}
+	}
+
+	report.Changed = len(report.Changes) > 0
+	return report, nil
+}
+
+// reconcileSettings updates the export format defaults that differ
+func (r *Reconciler) reconcileSettings(doc *Document, report *Report) error {
+	if doc.Settings == nil {
+		return nil
+	}
+	current := r.formats.Defaults()
+
+	if want := doc.Settings.PDF; want != nil && *want != current.PDF {
+		if err := r.updateFormat(report, config.FormatPDF, want, fmt.Sprintf("font_size %g → %g", current.PDF.FontSize, want.FontSize)); err != nil {
+			return err
+		}
+	}
+	if want := doc.Settings.ChordPro; want != nil && *want != current.ChordPro {
+		var changed []string
+		if want.DirectiveStyle != current.ChordPro.DirectiveStyle {
+			changed = append(changed, fmt.Sprintf("directive_style %s → %s", current.ChordPro.DirectiveStyle, want.DirectiveStyle))
+		}
+		if want.Environments != current.ChordPro.Environments {
+			changed = append(changed, fmt.Sprintf("environments %t → %t", current.ChordPro.Environments, want.Environments))
+		}
+		if err := r.updateFormat(report, config.FormatChordPro, want, strings.Join(changed, ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateFormat stores one format's defaults and reports it
+func (r *Reconciler) updateFormat(report *Report, format string, options any, details string) error {
+	if !report.DryRun {
+		data, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		if _, err := r.formats.Update(format, data); err != nil {
+			return fmt.Errorf("settings.%s: %w", format, err)
+		}
+	}
+	report.add(SectionSettings, ActionUpdate, format, details, "")
+	return nil
+}
+
+// reconcileWebhook saves or removes the shared webhook
+func (r *Reconciler) reconcileWebhook(doc *Document, report *Report) error {
+	if doc.Webhook == nil {
+		return nil
+	}
+
+	current := r.webhooks.Get()
+	configured := current != nil && current.URL != ""
+	switch {
+	case doc.Webhook.URL == "" && configured:
+		if !report.DryRun {
+			if err := r.webhooks.Clear(); err != nil {
+				return fmt.Errorf("webhook: %w", err)
+			}
+		}
+		report.add(SectionWebhook, ActionDelete, "shared", "", "")
+	case doc.Webhook.URL != "" && !sameWebhook(current, doc.Webhook):
+		if !report.DryRun {
+			if err := r.webhooks.Save(doc.Webhook.config()); err != nil {
+				return fmt.Errorf("webhook: %w", err)
+			}
+		}
+		report.add(SectionWebhook, action(configured), "shared", doc.Webhook.URL, "")
+	}
+	return nil
+}
+
+// reconcileProfiles creates, updates and deletes profiles
+func (r *Reconciler) reconcileProfiles(doc *Document, report *Report) error {
+	if doc.Profiles == nil {
+		return nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, spec := range *doc.Profiles {
+		wanted[strings.ToLower(spec.Name)] = true
+		tags, _ := library.NormalizeTags(spec.LibraryTags)
+
+		current, exists := r.profiles.Get(spec.Name)
+		if !exists {
+			if !report.DryRun {
+				if _, err := r.profiles.Create(spec.Name, tags); err != nil {
+					return fmt.Errorf("profile %s: %w", spec.Name, err)
+				}
+				if spec.Webhook != nil && spec.Webhook.URL != "" {
+					if err := r.profiles.SaveWebhook(spec.Name, spec.Webhook.config()); err != nil {
+						return fmt.Errorf("profile %s: %w", spec.Name, err)
+					}
+				}
+			}
+			report.add(SectionProfiles, ActionCreate, spec.Name, "", "")
+			continue
+		}
+
+		var changed []string
+		if !slices.Equal(tags, current.LibraryTags) && (len(tags) > 0 || len(current.LibraryTags) > 0) {
+			changed = append(changed, "library_tags")
+			if !report.DryRun {
+				if _, err := r.profiles.SetLibraryTags(spec.Name, tags); err != nil {
+					return fmt.Errorf("profile %s: %w", spec.Name, err)
+				}
+			}
+		}
+
+		hasWebhook := current.Webhook != nil && current.Webhook.URL != ""
+		wantsWebhook := spec.Webhook != nil && spec.Webhook.URL != ""
+		switch {
+		case hasWebhook && !wantsWebhook:
+			changed = append(changed, "webhook removed")
+			if !report.DryRun {
+				if err := r.profiles.ClearWebhook(spec.Name); err != nil {
+					return fmt.Errorf("profile %s: %w", spec.Name, err)
+				}
+			}
+		case wantsWebhook && !sameWebhook(current.Webhook, spec.Webhook):
+			changed = append(changed, "webhook")
+			if !report.DryRun {
+				if err := r.profiles.SaveWebhook(spec.Name, spec.Webhook.config()); err != nil {
+					return fmt.Errorf("profile %s: %w", spec.Name, err)
+				}
+			}
+		}
+
+		if len(changed) > 0 {
+			report.add(SectionProfiles, ActionUpdate, current.Name, strings.Join(changed, ", "), "")
+		}
+	}
+
+	for _, profile := range r.profiles.List() {
+		if wanted[strings.ToLower(profile.Name)] {
+			continue
+		}
+		if !report.DryRun {
+			if err := r.profiles.Delete(profile.Name); err != nil {
+				return fmt.Errorf("profile %s: %w", profile.Name, err)
+			}
+		}
+		report.add(SectionProfiles, ActionDelete, profile.Name, "", "")
+	}
+	return nil
+}
+
+// reconcileKeys creates missing keys, updates roles and expiries and
+// revokes the active keys the document leaves out
+func (r *Reconciler) reconcileKeys(doc *Document, report *Report) error {
+	if doc.APIKeys == nil {
+		return nil
+	}
+
+	// Active keys by name; older duplicates of a name count as extra
+	active := make(map[string]auth.APIKey)
+	var extra []auth.APIKey
+	now := time.Now()
+	for _, key := range r.keys.List() {
+		if !key.Active(now) {
+			continue
+		}
+		name := strings.ToLower(key.Name)
+		if _, dup := active[name]; dup {
+			extra = append(extra, key)
+			continue
+		}
+		active[name] = key
+	}
+
+	wanted := make(map[string]bool)
+	for _, spec := range *doc.APIKeys {
+		name := strings.TrimSpace(spec.Name)
+		wanted[strings.ToLower(name)] = true
+
+		current, exists := active[strings.ToLower(name)]
+		if !exists {
+			secret := ""
+			if !report.DryRun {
+				key, s, err := r.keys.Create(name, spec.Role, spec.ExpiresAt)
+				if err != nil {
+					return fmt.Errorf("api key %s: %w", name, err)
+				}
+				fmt.Printf("🔑 API key created: %s (%s)\n", key.Name, key.Role)
+				secret = s
+			}
+			report.add(SectionAPIKeys, ActionCreate, name, spec.Role, secret)
+			continue
+		}
+
+		if current.Role == spec.Role && sameTime(current.ExpiresAt, spec.ExpiresAt) {
+			continue
+		}
+		if !report.DryRun {
+			if _, err := r.keys.Update(current.ID, spec.Role, spec.ExpiresAt); err != nil {
+				return fmt.Errorf("api key %s: %w", name, err)
+			}
+		}
+		report.add(SectionAPIKeys, ActionUpdate, current.Name, fmt.Sprintf("role %s, expires %s", spec.Role, expiry(spec.ExpiresAt)), "")
+	}
+
+	for _, key := range active {
+		if !wanted[strings.ToLower(key.Name)] {
+			extra = append(extra, key)
+		}
+	}
+	slices.SortFunc(extra, func(a, b auth.APIKey) int { return strings.Compare(a.Name, b.Name) })
+	for _, key := range extra {
+		if !report.DryRun {
+			if err := r.keys.Revoke(key.ID); err != nil {
+				return fmt.Errorf("api key %s: %w", key.Name, err)
+			}
+			fmt.Printf("🔑 API key revoked: %s\n", key.Name)
+		}
+		report.add(SectionAPIKeys, ActionDelete, key.Name, "revoked", "")
+	}
+	return nil
+}
+
+// reconcileWatchlist adds and removes watches and updates their webhook.
+// New watches record the tabs that already exist on their first run.
+func (r *Reconciler) reconcileWatchlist(doc *Document, report *Report) error {
+	if doc.Watchlist == nil {
+		return nil
+	}
+
+	current := make(map[string]library.Watch)
+	for _, watch := range r.library.ListWatches() {
+		current[watchKey(&watch)] = watch
+	}
+
+	wanted := make(map[string]bool)
+	for _, spec := range *doc.Watchlist {
+		watch, _ := spec.watch()
+		key := watchKey(watch)
+		wanted[key] = true
+
+		existing, exists := current[key]
+		switch {
+		case !exists:
+			if !report.DryRun {
+				if err := r.library.SaveWatch(watch); err != nil {
+					return fmt.Errorf("watch %s: %w", watch.Query(), err)
+				}
+			}
+			report.add(SectionWatchlist, ActionCreate, watch.Query(), string(watch.Type), "")
+		case existing.WebhookURL != watch.WebhookURL:
+			if !report.DryRun {
+				existing.WebhookURL = watch.WebhookURL
+				if err := r.library.SaveWatch(&existing); err != nil {
+					return fmt.Errorf("watch %s: %w", watch.Query(), err)
+				}
+			}
+			report.add(SectionWatchlist, ActionUpdate, watch.Query(), "webhook_url", "")
+		}
+	}
+
+	for _, watch := range r.library.ListWatches() {
+		if wanted[watchKey(&watch)] {
+			continue
+		}
+		if !report.DryRun {
+			if err := r.library.DeleteWatch(watch.ID); err != nil {
+				return fmt.Errorf("watch %s: %w", watch.Query(), err)
+			}
+		}
+		report.add(SectionWatchlist, ActionDelete, watch.Query(), "", "")
+	}
+	return nil
+}
+
+// add records a change
+func (r *Report) add(section, action, name, details, secret string) {
+	r.Changes = append(r.Changes, Change{
+		Section: section,
+		Action:  action,
+		Name:    name,
+		Details: details,
+		Secret:  secret,
+	})
+}
+
+// validate checks a webhook target; an empty URL is valid and removes it
+func (w *Webhook) validate() error {
+	if w.URL == "" {
+		return nil
+	}
+	if !webhook.ValidSchema(w.SchemaVersion) {
+		return fmt.Errorf("schema_version must be between 1 and %d", webhook.LatestSchema)
+	}
+	if err := webhook.ValidateRedaction(w.RedactFields, w.MaxLines); err != nil {
+		return err
+	}
+	return w.config().Validate()
+}
+
+// config converts the target to a stored webhook configuration
+func (w *Webhook) config() *config.WebhookConfig {
+	return &config.WebhookConfig{
+		URL:           w.URL,
+		Enabled:       w.Enabled == nil || *w.Enabled,
+		Headers:       maps.Clone(w.Headers),
+		SchemaVersion: w.SchemaVersion,
+		IncludeBundle: w.IncludeBundle,
+		RedactFields:  slices.Clone(w.RedactFields),
+		MaxLines:      w.MaxLines,
+	}
+}
+
+// sameWebhook reports whether a stored webhook matches the target
+func sameWebhook(current *config.WebhookConfig, want *Webhook) bool {
+	if current == nil {
+		return false
+	}
+	wanted := want.config()
+	return current.URL == wanted.URL &&
+		current.Enabled == wanted.Enabled &&
+		maps.Equal(current.Headers, wanted.Headers) &&
+		current.SchemaVersion == wanted.SchemaVersion &&
+		current.IncludeBundle == wanted.IncludeBundle &&
+		slices.Equal(current.RedactFields, wanted.RedactFields) &&
+		current.MaxLines == wanted.MaxLines
+}
+
+// watch converts the entry to a library watch
+func (w *Watch) watch() (*library.Watch, error) {
+	watch := &library.Watch{
+		Artist:     strings.TrimSpace(w.Artist),
+		Song:       strings.TrimSpace(w.Song),
+		WebhookURL: w.WebhookURL,
+	}
+	if w.Type != "" {
+		tabType, ok := scraper.ParseTabType(w.Type)
+		if !ok {
+			return nil, fmt.Errorf("unknown tab type %q (accepted values: %s)", w.Type, scraper.TabTypeList())
+		}
+		watch.Type = tabType
+	}
+	return watch, nil
+}
+
+// watchKey identifies a watch by artist, song and type
+func watchKey(watch *library.Watch) string {
+	return strings.ToLower(watch.Artist) + "\x00" + strings.ToLower(watch.Song) + "\x00" + string(watch.Type)
+}
+
+// action is create for something new and update for something existing
+func action(exists bool) string {
+	if exists {
+		return ActionUpdate
+	}
+	return ActionCreate
+}
+
+// sameTime reports whether two optional times are equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// expiry describes an optional expiry time
+func expiry(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
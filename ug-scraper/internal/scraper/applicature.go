@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Barre is a finger laid across several strings at one fret
+type Barre struct {
+	Fret       int `json:"fret"`
+	FromString int `json:"from_string"` // 0 = lowest string
+	ToString   int `json:"to_string"`
+	Finger     int `json:"finger,omitempty"`
+}
+
+// ChordVoicing is one fingering of a chord as the tab author drew it.
+// Strings are listed from the lowest, like a chord diagram is read.
+type ChordVoicing struct {
+	Frets    []int   `json:"frets"`             // Absolute frets; -1 muted, 0 open
+	Fingers  []int   `json:"fingers,omitempty"` // 1 index to 4 pinky, 0 none
+	BaseFret int     `json:"base_fret"`         // Fret the diagram starts at
+	Barres   []Barre `json:"barres,omitempty"`
+}
+
+// Applicature is the set of voicings a tab gives for one chord, the
+// author's preferred voicing first
+type Applicature struct {
+	Chord    string         `json:"chord"`
+	Voicings []ChordVoicing `json:"voicings"`
+}
+
+// ugVoicing is a voicing as UG sends it: strings from the highest down and
+// barres named "capos"
+type ugVoicing struct {
+	Frets     []int `json:"frets"`
+	Fingers   []int `json:"fingers"`
+	Fret      int   `json:"fret"`
+	ListCapos []struct {
+		Fret        int `json:"fret"`
+		StartString int `json:"startString"`
+		LastString  int `json:"lastString"`
+		Finger      int `json:"finger"`
+	} `json:"listCapos"`
+}
+
+// voicing converts the UG layout to lowest-string-first
+func (v ugVoicing) voicing() ChordVoicing {
+	n := len(v.Frets)
+	voicing := ChordVoicing{
+		Frets:    reversed(v.Frets),
+		BaseFret: max(v.Fret, 1),
+	}
+	if len(v.Fingers) == n {
+		voicing.Fingers = reversed(v.Fingers)
+	}
+	for _, c := range v.ListCapos {
+		from, to := n-1-c.LastString, n-1-c.StartString
+		voicing.Barres = append(voicing.Barres, Barre{
+			Fret:       c.Fret,
+			FromString: min(from, to),
+			ToString:   max(from, to),
+			Finger:     c.Finger,
+		})
+	}
+	return voicing
+}
+
+// ParseApplicature reads the applicature of a tab response. The app API
+// sends a list of {"chord", "variations"} objects and tab pages an object
+// keyed by chord name; both keep the author's chord order where they can.
+func ParseApplicature(raw json.RawMessage) ([]Applicature, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var list []struct {
+		Chord      string      `json:"chord"`
+		Variations []ugVoicing `json:"variations"`
+	}
+	if err := json.Unmarshal(raw, &list); err == nil {
+		applicature := make([]Applicature, 0, len(list))
+		for _, entry := range list {
+			applicature = append(applicature, newApplicature(entry.Chord, entry.Variations))
+		}
+		return applicature, nil
+	}
+
+	// Object keys carry the order, which a Go map would lose
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("applicature is neither a list nor an object")
+	}
+	var applicature []Applicature
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("decoding applicature: %w", err)
+		}
+		chord, _ := tok.(string)
+		var variations []ugVoicing
+		if err := dec.Decode(&variations); err != nil {
+			return nil, fmt.Errorf("decoding applicature for %s: %w", chord, err)
+		}
+		applicature = append(applicature, newApplicature(chord, variations))
+	}
+	return applicature, nil
+}
+
+// newApplicature converts the UG voicings of a chord, skipping empty ones
+func newApplicature(chord string, variations []ugVoicing) Applicature {
+	applicature := Applicature{Chord: chord, Voicings: []ChordVoicing{}}
+	for _, v := range variations {
+		if len(v.Frets) > 0 {
+			applicature.Voicings = append(applicature.Voicings, v.voicing())
+		}
+	}
+	return applicature
+}
+
+// reversed returns a reversed copy of s
+func reversed(s []int) []int {
+	out := make([]int, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
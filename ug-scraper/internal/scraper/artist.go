@@ -0,0 +1,269 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxArtistPages caps how many pages of an artist's catalog are walked
+	maxArtistPages = 50
+	// artistPagePause spaces out artist page fetches
+	artistPagePause = time.Second
+)
+
+// artistPath matches UG artist page paths like /artist/oasis_6916
+var artistPath = regexp.MustCompile(`^/artist/[^/]+_\d+$`)
+
+// Discography is every tab UG lists on an artist's page
+type Discography struct {
+	Artist    string         `json:"artist"`
+	ArtistURL string         `json:"artist_url"`
+	Pages     int            `json:"pages"`
+	Total     int            `json:"total"`
+	Tabs      []SearchResult `json:"tabs"`
+}
+
+// artistTabEntry is one tab in the lists of an artist page's js-store
+type artistTabEntry struct {
+	ID         int     `json:"id"`
+	SongName   string  `json:"song_name"`
+	ArtistName string  `json:"artist_name"`
+	Type       string  `json:"type"`
+	TabURL     string  `json:"tab_url"`
+	Rating     float64 `json:"rating"`
+	Votes      int     `json:"votes"`
+	Difficulty string  `json:"difficulty"`
+	ArtistURL  string  `json:"artist_url"`
+}
+
+// artistPage is what one page of an artist's catalog holds
+type artistPage struct {
+	artist  string
+	entries []artistTabEntry
+	artists []artistTabEntry // Entries linking to an artist page
+	total   int              // Number of pages UG reports, 0 if unknown
+}
+
+// ArtistTabs walks an artist's UG page, following its pagination, and
+// returns every tab listed, optionally only those of one type. name is an
+// artist name, which is looked up by search, an artist page URL or path, or
+// its slug (oasis_6916).
+func (s *SearchScraper) ArtistTabs(ctx context.Context, name string, tabType TabType) (*Discography, error) {
+	artistURL, err := s.findArtistPage(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("🎸 Walking artist page: %s\n", artistURL)
+	disco := &Discography{Artist: name, ArtistURL: artistURL, Tabs: []SearchResult{}}
+	seen := make(map[string]bool)
+
+	for page := 1; page <= maxArtistPages; page++ {
+		if page > 1 {
+			time.Sleep(artistPagePause)
+		}
+
+		pageURL := artistURL
+		if page > 1 {
+			pageURL += "?page=" + fmt.Sprint(page)
+		}
+		body, finalURL, err := s.fetchPage(ctx, pageURL)
+		s.stats.record(err)
+		if err != nil {
+			if page == 1 {
+				return nil, fmt.Errorf("fetching artist page: %w", err)
+			}
+			fmt.Printf("   ✗ Page %d failed, stopping: %v\n", page, err)
+			break
+		}
+
+		parsed, err := parseArtistPage(string(body))
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if parsed.artist != "" {
+			disco.Artist = parsed.artist
+		}
+
+		added := 0
+		for _, entry := range parsed.entries {
+			id := fmt.Sprint(entry.ID)
+			if entry.ID == 0 || seen[id] {
+				continue
+			}
+			seen[id] = true
+			added++
+
+			result := SearchResult{
+				ID:         id,
+				Title:      entry.SongName,
+				Artist:     entry.ArtistName,
+				Type:       NormalizeTabType(entry.Type),
+				Rating:     entry.Rating,
+				Votes:      entry.Votes,
+				Difficulty: NormalizeDifficulty(entry.Difficulty),
+				URL:        CanonicalTabURL(entry.TabURL),
+				Locale:     LocaleFromURL(finalURL),
+			}
+			if tabType == "" || result.Type == tabType {
+				disco.Tabs = append(disco.Tabs, result)
+			}
+		}
+		disco.Pages = page
+		fmt.Printf("   Page %d: %d tabs\n", page, added)
+
+		// UG repeats the last page for numbers past the end
+		if added == 0 || (parsed.total > 0 && page >= parsed.total) {
+			break
+		}
+	}
+
+	disco.Total = len(disco.Tabs)
+	return disco, nil
+}
+
+// findArtistPage returns the artist page URL for a name, URL or path
+func (s *SearchScraper) findArtistPage(ctx context.Context, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("artist name cannot be empty")
+	}
+
+	if path := artistPagePath(name); path != "" {
+		return s.ugClient.endpoints.WebBaseURL + path, nil
+	}
+	if path := artistPagePath("/artist/" + name); path != "" {
+		return s.ugClient.endpoints.WebBaseURL + path, nil
+	}
+
+	// Band search lists artists; title search results link each tab to
+	// its artist's page, which helps when band search finds nothing
+	fmt.Printf("🔍 Looking up artist: %q\n", name)
+	var fallback string
+	for _, searchType := range []string{"band", "title"} {
+		params := url.Values{}
+		params.Set("search_type", searchType)
+		params.Set("value", name)
+
+		body, _, err := s.fetchPage(ctx, s.ugClient.endpoints.SearchURL()+"?"+params.Encode())
+		s.stats.record(err)
+		if err != nil {
+			return "", fmt.Errorf("searching for artist: %w", err)
+		}
+		parsed, err := parseArtistPage(string(body))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range parsed.artists {
+			path := artistPagePath(entry.ArtistURL)
+			if path == "" {
+				continue
+			}
+			if strings.EqualFold(strings.TrimSpace(entry.ArtistName), name) {
+				return s.ugClient.endpoints.WebBaseURL + path, nil
+			}
+			if fallback == "" {
+				fallback = path
+			}
+		}
+	}
+
+	if fallback == "" {
+		return "", fmt.Errorf("no artist found for %q", name)
+	}
+	return s.ugClient.endpoints.WebBaseURL + fallback, nil
+}
+
+// artistPagePath returns the /artist/<slug>_<id> path of an artist URL or
+// path, or "" if raw is not one
+func artistPagePath(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (parsed.Host != "" && !IsUGHost(parsed.Host)) {
+		return ""
+	}
+
+	path := "/" + strings.Trim(parsed.Path, "/")
+	if m := localePathPrefix.FindStringSubmatch(path); m != nil && strings.HasPrefix(m[2], "/artist") {
+		path = m[2]
+	}
+	if !artistPath.MatchString(path) {
+		return ""
+	}
+	return path
+}
+
+// parseArtistPage reads the tab lists and pagination from the js-store of
+// an artist or search page. Artist pages split tabs over several lists
+// (albums, other tabs, ...), so every list of tab entries is collected.
+func parseArtistPage(page string) (*artistPage, error) {
+	m := jsStoreRegex.FindStringSubmatch(page)
+	if m == nil {
+		return nil, fmt.Errorf("no js-store data on page")
+	}
+
+	var store struct {
+		Store struct {
+			Page struct {
+				Data map[string]json.RawMessage `json:"data"`
+			} `json:"page"`
+		} `json:"store"`
+	}
+	if err := json.Unmarshal([]byte(html.UnescapeString(m[1])), &store); err != nil {
+		return nil, fmt.Errorf("parsing js-store JSON: %w", err)
+	}
+
+	// Walk the lists in a fixed order so results don't shuffle between calls
+	keys := make([]string, 0, len(store.Store.Page.Data))
+	for key := range store.Store.Page.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parsed := &artistPage{}
+	for _, key := range keys {
+		raw := store.Store.Page.Data[key]
+		switch key {
+		case "artist":
+			var artist struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(raw, &artist) == nil {
+				parsed.artist = artist.Name
+			}
+		case "pagination":
+			var pagination struct {
+				Total int `json:"total"`
+			}
+			if json.Unmarshal(raw, &pagination) == nil {
+				parsed.total = pagination.Total
+			}
+		default:
+			var entries []artistTabEntry
+			if json.Unmarshal(raw, &entries) != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if entry.ArtistURL != "" {
+					parsed.artists = append(parsed.artists, entry)
+				}
+				if entry.ID != 0 && entry.TabURL != "" {
+					parsed.entries = append(parsed.entries, entry)
+				}
+			}
+		}
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,196 @@
+package scraper
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// BurstFactor is how much shorter the pauses between UG requests are
+	// during a burst
+	BurstFactor = 4
+	// MaxBurstMinutes caps how long a burst may run
+	MaxBurstMinutes = 120
+	// burstConfirmWindow is how long a burst request waits for confirmation
+	burstConfirmWindow = 2 * time.Minute
+)
+
+var (
+	// ErrBurstNotRequested is returned when confirming an unknown or expired token
+	ErrBurstNotRequested = errors.New("no pending burst request for this token")
+	// ErrNoBurst is returned when ending a burst that isn't running
+	ErrNoBurst = errors.New("burst mode is not active")
+)
+
+// Burst is a time-boxed period of faster UG requests
+type Burst struct {
+	EnabledBy string    `json:"enabled_by,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+// BurstRequest is a burst waiting for the admin to confirm it
+type BurstRequest struct {
+	Token       string    `json:"confirm_token,omitempty"`
+	Minutes     int       `json:"minutes"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// BudgetStatus describes the current UG rate budget
+type BudgetStatus struct {
+	Burst   *Burst        `json:"burst,omitempty"`
+	Pending *BurstRequest `json:"pending,omitempty"`
+	Factor  int           `json:"factor"` // Pauses are divided by this; 1 outside a burst
+}
+
+// RateBudget paces bulk work against UG. Outside a burst every pause is
+// used as configured; an admin can shorten them by BurstFactor for a few
+// minutes, after which the normal politeness settings return by themselves.
+type RateBudget struct {
+	mu      sync.Mutex
+	burst   *Burst
+	pending *BurstRequest
+	timer   *time.Timer
+	onEnd   func(Burst)
+}
+
+// NewRateBudget creates a rate budget with no burst running
+func NewRateBudget() *RateBudget {
+	return &RateBudget{}
+}
+
+// OnExpire sets a function called when a burst runs out on its own
+func (b *RateBudget) OnExpire(fn func(Burst)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.onEnd = fn
+}
+
+// Pause returns how long to wait where base is the normal pause
+func (b *RateBudget) Pause(base time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.active(time.Now()) {
+		return base / BurstFactor
+	}
+	return base
+}
+
+// Request starts a burst request that must be confirmed with the returned
+// token within two minutes. A new request replaces an unconfirmed one.
+func (b *RateBudget) Request(minutes int, by string) (*BurstRequest, error) {
+	if minutes < 1 || minutes > MaxBurstMinutes {
+		return nil, fmt.Errorf("minutes must be between 1 and %d", MaxBurstMinutes)
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("generating confirmation token: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = &BurstRequest{
+		Token:       hex.EncodeToString(random),
+		Minutes:     minutes,
+		RequestedBy: by,
+		ExpiresAt:   time.Now().Add(burstConfirmWindow),
+	}
+	request := *b.pending
+	return &request, nil
+}
+
+// Confirm starts the requested burst. A running burst is replaced, so
+// confirming again extends it.
+func (b *RateBudget) Confirm(token, by string) (*Burst, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.pending == nil || subtle.ConstantTimeCompare([]byte(b.pending.Token), []byte(token)) != 1 || now.After(b.pending.ExpiresAt) {
+		return nil, ErrBurstNotRequested
+	}
+
+	duration := time.Duration(b.pending.Minutes) * time.Minute
+	b.burst = &Burst{EnabledBy: by, StartedAt: now, EndsAt: now.Add(duration)}
+	b.pending = nil
+
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	burst := *b.burst
+	b.timer = time.AfterFunc(duration, func() { b.expire(burst) })
+
+	fmt.Printf("🚀 Burst mode on until %s (pauses %dx shorter)\n", burst.EndsAt.Format(time.Kitchen), BurstFactor)
+	return &burst, nil
+}
+
+// End stops a running burst early
+func (b *RateBudget) End() (*Burst, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.active(time.Now()) {
+		return nil, ErrNoBurst
+	}
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	burst := *b.burst
+	b.burst = nil
+
+	fmt.Println("🐢 Burst mode ended early")
+	return &burst, nil
+}
+
+// Status returns the running burst and any unconfirmed request, without
+// the request's token
+func (b *RateBudget) Status() BudgetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	status := BudgetStatus{Factor: 1}
+	if b.active(now) {
+		burst := *b.burst
+		status.Burst = &burst
+		status.Factor = BurstFactor
+	}
+	if b.pending != nil && now.Before(b.pending.ExpiresAt) {
+		pending := *b.pending
+		pending.Token = ""
+		status.Pending = &pending
+	}
+	return status
+}
+
+// expire ends a burst when its time is up, unless it was replaced or ended
+func (b *RateBudget) expire(burst Burst) {
+	b.mu.Lock()
+	if b.burst == nil || !b.burst.StartedAt.Equal(burst.StartedAt) {
+		b.mu.Unlock()
+		return
+	}
+	b.burst = nil
+	onEnd := b.onEnd
+	b.mu.Unlock()
+
+	fmt.Println("🐢 Burst mode expired, back to normal pacing")
+	if onEnd != nil {
+		onEnd(burst)
+	}
+}
+
+// active reports whether a burst is running (caller holds the lock)
+func (b *RateBudget) active(now time.Time) bool {
+	return b.burst != nil && now.Before(b.burst.EndsAt)
+}
@@ -0,0 +1,239 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// chromeTimeout bounds loading a page and waiting out the challenge
+	chromeTimeout = 60 * time.Second
+	// chromePollInterval is how often the page is checked for the challenge
+	chromePollInterval = 500 * time.Millisecond
+)
+
+// challengeScript is true once the page has loaded and is no longer the
+// Cloudflare challenge (or the blank tab it was opened in)
+const challengeScript = `location.href !== "about:blank" && document.readyState === "complete" && !/just a moment|checking your browser|attention required/i.test(document.title)`
+
+// pageScript returns the final URL and HTML of the page
+const pageScript = `JSON.stringify({url: location.href, html: document.documentElement.outerHTML})`
+
+// chromeSolver loads pages in a headless Chrome reached over the DevTools
+// protocol, e.g. a browserless/chrome or zenika/alpine-chrome container,
+// and waits for the challenge page to go away. Chrome downloads the pages
+// itself, so they are not counted by the bandwidth meter.
+type chromeSolver struct {
+	url    string // DevTools HTTP endpoint, e.g. http://chrome:9222
+	client *http.Client
+}
+
+// newChromeSolverFromEnv creates a solver for the Chrome at CHROME_URL, or
+// nil when it is not set
+func newChromeSolverFromEnv() *chromeSolver {
+	devtoolsURL := os.Getenv("CHROME_URL")
+	if devtoolsURL == "" {
+		return nil
+	}
+	return &chromeSolver{
+		url:    strings.TrimRight(devtoolsURL, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the solver in logs
+func (c *chromeSolver) Name() string {
+	return "Chrome"
+}
+
+// chromeTarget is a browser tab opened through the DevTools HTTP endpoint
+type chromeTarget struct {
+	ID                   string `json:"id"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// Fetch opens the page in a new tab and returns its HTML once the challenge
+// is passed
+func (c *chromeSolver) Fetch(ctx context.Context, pageURL string) (Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, chromeTimeout)
+	defer cancel()
+
+	target, err := c.openTarget(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	defer c.closeTarget(target.ID)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, target.WebSocketDebuggerURL, nil)
+	if err != nil {
+		return Page{}, fmt.Errorf("connecting to Chrome tab: %w", err)
+	}
+	defer conn.Close()
+	// Unblock a pending read when the deadline passes
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	session := &cdpSession{conn: conn}
+	var navigation struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := session.call("Page.navigate", map[string]any{"url": pageURL}, &navigation); err != nil {
+		return Page{}, c.failure(ctx, "navigating", err)
+	}
+	if navigation.ErrorText != "" {
+		return Page{}, fmt.Errorf("navigating: %s", navigation.ErrorText)
+	}
+
+	ticker := time.NewTicker(chromePollInterval)
+	defer ticker.Stop()
+	for {
+		var passed bool
+		if err := session.evaluate(challengeScript, &passed); err != nil {
+			return Page{}, c.failure(ctx, "checking the page", err)
+		}
+		if passed {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return Page{}, fmt.Errorf("challenge not passed within %s: %w", chromeTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	var raw string
+	if err := session.evaluate(pageScript, &raw); err != nil {
+		return Page{}, c.failure(ctx, "reading the page", err)
+	}
+	var page struct {
+		URL  string `json:"url"`
+		HTML string `json:"html"`
+	}
+	if err := json.Unmarshal([]byte(raw), &page); err != nil {
+		return Page{}, fmt.Errorf("decoding page: %w", err)
+	}
+	return Page{Body: []byte(page.HTML), URL: page.URL}, nil
+}
+
+// failure reports a DevTools error, or the deadline when that caused it
+func (c *chromeSolver) failure(ctx context.Context, step string, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", step, ctx.Err())
+	}
+	return fmt.Errorf("%s: %w", step, err)
+}
+
+// openTarget opens a blank tab
+func (c *chromeSolver) openTarget(ctx context.Context) (chromeTarget, error) {
+	// Recent Chrome versions only accept PUT here
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.url+"/json/new?"+url.QueryEscape("about:blank"), nil)
+	if err != nil {
+		return chromeTarget{}, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return chromeTarget{}, fmt.Errorf("Chrome unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return chromeTarget{}, fmt.Errorf("Chrome returned status %d opening a tab", resp.StatusCode)
+	}
+	var target chromeTarget
+	if err := json.NewDecoder(resp.Body).Decode(&target); err != nil {
+		return chromeTarget{}, fmt.Errorf("decoding tab: %w", err)
+	}
+	if target.WebSocketDebuggerURL == "" {
+		return chromeTarget{}, fmt.Errorf("Chrome returned a tab without a DevTools address")
+	}
+	return target, nil
+}
+
+// closeTarget closes a tab, even when the fetch was cancelled
+func (c *chromeSolver) closeTarget(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url+"/json/close/"+url.PathEscape(id), nil)
+	if err != nil {
+		return
+	}
+	if resp, err := c.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// CloseIdleConnections drops pooled connections to Chrome
+func (c *chromeSolver) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+}
+
+// cdpSession sends DevTools protocol commands to one tab
+type cdpSession struct {
+	conn   *websocket.Conn
+	nextID int
+}
+
+// call sends a command and waits for its result, skipping the events the
+// tab sends meanwhile
+func (s *cdpSession) call(method string, params any, result any) error {
+	s.nextID++
+	id := s.nextID
+	if err := s.conn.WriteJSON(map[string]any{"id": id, "method": method, "params": params}); err != nil {
+		return fmt.Errorf("sending %s: %w", method, err)
+	}
+
+	for {
+		var msg struct {
+			ID     int             `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return fmt.Errorf("waiting for %s: %w", method, err)
+		}
+		if msg.ID != id {
+			continue
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	}
+}
+
+// evaluate runs a script in the page and decodes its value
+func (s *cdpSession) evaluate(script string, value any) error {
+	var result struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	params := map[string]any{"expression": script, "returnByValue": true}
+	if err := s.call("Runtime.evaluate", params, &result); err != nil {
+		return err
+	}
+	if result.ExceptionDetails != nil {
+		return fmt.Errorf("script failed: %s", result.ExceptionDetails.Text)
+	}
+	if len(result.Result.Value) == 0 {
+		return fmt.Errorf("script returned no value")
+	}
+	return json.Unmarshal(result.Result.Value, value)
+}
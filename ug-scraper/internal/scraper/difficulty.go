@@ -0,0 +1,83 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Canonical difficulties
+const (
+	DifficultyBeginner     = "beginner"
+	DifficultyIntermediate = "intermediate"
+	DifficultyAdvanced     = "advanced"
+)
+
+// Difficulties lists every canonical difficulty, easiest first
+var Difficulties = []string{DifficultyBeginner, DifficultyIntermediate, DifficultyAdvanced}
+
+// difficultyAliases maps the spellings UG (and users) use to canonical
+// difficulties; UG calls its easiest level "novice"
+var difficultyAliases = map[string]string{
+	"beginner":          DifficultyBeginner,
+	"novice":            DifficultyBeginner,
+	"absolute beginner": DifficultyBeginner,
+	"easy":              DifficultyBeginner,
+	"1":                 DifficultyBeginner,
+
+	"intermediate": DifficultyIntermediate,
+	"medium":       DifficultyIntermediate,
+	"2":            DifficultyIntermediate,
+
+	"advanced": DifficultyAdvanced,
+	"hard":     DifficultyAdvanced,
+	"expert":   DifficultyAdvanced,
+	"3":        DifficultyAdvanced,
+}
+
+// ParseDifficulty normalizes a difficulty spelling to its canonical name
+func ParseDifficulty(raw string) (string, bool) {
+	key := strings.TrimSpace(typeSeparators.ReplaceAllString(strings.ToLower(raw), " "))
+	d, ok := difficultyAliases[key]
+	return d, ok
+}
+
+// NormalizeDifficulty returns the canonical difficulty for raw, or "" if it
+// is unknown
+func NormalizeDifficulty(raw string) string {
+	d, _ := ParseDifficulty(raw)
+	return d
+}
+
+// DifficultyList returns the canonical difficulties as a
+// "beginner|intermediate|advanced" string for help and error messages
+func DifficultyList() string {
+	return strings.Join(Difficulties, "|")
+}
+
+// filterByDifficulty keeps only results of the requested difficulty. UG's
+// web search has no difficulty filter, so this is the only place it is
+// applied. Results whose difficulty is unknown are dropped too, unless no
+// result has one (a search path that doesn't report it), in which case
+// nothing can be filtered.
+func filterByDifficulty(results []SearchResult, difficulty string) []SearchResult {
+	if difficulty == "" {
+		return results
+	}
+
+	known := false
+	filtered := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if r.Difficulty != "" {
+			known = true
+		}
+		if r.Difficulty == difficulty {
+			filtered = append(filtered, r)
+		}
+	}
+
+	if !known && len(results) > 0 {
+		fmt.Printf("   ⚠️  Results carry no difficulty, %q filter not applied\n", difficulty)
+		return results
+	}
+	return filtered
+}
@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	defaultWebBaseURL = "https://www.ultimate-guitar.com"
+	defaultAPIBaseURL = "https://api.ultimate-guitar.com/api/v1"
+)
+
+// Endpoints holds the base URLs used to reach Ultimate Guitar. They can be
+// pointed at a mirror or caching proxy for users behind regional blocks.
+type Endpoints struct {
+	WebBaseURL string // Website, used for HTML search scraping
+	APIBaseURL string // Android app API, used for tab fetching
+}
+
+// DefaultEndpoints returns the official Ultimate Guitar URLs
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		WebBaseURL: defaultWebBaseURL,
+		APIBaseURL: defaultAPIBaseURL,
+	}
+}
+
+// EndpointsFromEnv reads UG_WEB_BASE_URL and UG_API_BASE_URL, falling back
+// to the official URLs for unset or invalid values
+func EndpointsFromEnv() Endpoints {
+	endpoints := DefaultEndpoints()
+
+	if v := os.Getenv("UG_WEB_BASE_URL"); v != "" {
+		if base, err := normalizeBaseURL(v); err == nil {
+			endpoints.WebBaseURL = base
+		} else {
+			fmt.Printf("⚠️  Ignoring UG_WEB_BASE_URL: %v\n", err)
+		}
+	}
+
+	if v := os.Getenv("UG_API_BASE_URL"); v != "" {
+		if base, err := normalizeBaseURL(v); err == nil {
+			endpoints.APIBaseURL = base
+		} else {
+			fmt.Printf("⚠️  Ignoring UG_API_BASE_URL: %v\n", err)
+		}
+	}
+
+	return endpoints
+}
+
+// IsDefault reports whether both base URLs point at Ultimate Guitar itself
+func (e Endpoints) IsDefault() bool {
+	return e == DefaultEndpoints()
+}
+
+// SearchURL is the website search page
+func (e Endpoints) SearchURL() string {
+	return e.WebBaseURL + "/search.php"
+}
+
+// AppSearchURL is the app API search endpoint
+func (e Endpoints) AppSearchURL() string {
+	return e.APIBaseURL + "/search"
+}
+
+// SuggestURL is the app API suggest endpoint
+func (e Endpoints) SuggestURL() string {
+	return e.APIBaseURL + "/suggest"
+}
+
+// TabSearchURL is the app API tab search endpoint
+func (e Endpoints) TabSearchURL() string {
+	return e.APIBaseURL + "/tab-search"
+}
+
+// TabInfoURL is the app API endpoint returning a full tab
+func (e Endpoints) TabInfoURL(tabID string) string {
+	return fmt.Sprintf("%s/tab/info?tab_id=%s&tab_access_type=private", e.APIBaseURL, url.QueryEscape(tabID))
+}
+
+// TabDownloadURL is the website endpoint serving Guitar Pro files. UG hosts
+// downloads on the tabs subdomain; mirrors are expected to serve the same path.
+func (e Endpoints) TabDownloadURL(tabID string) string {
+	base := e.WebBaseURL
+	if base == defaultWebBaseURL {
+		base = "https://" + canonicalTabHost
+	}
+	return fmt.Sprintf("%s/tab/download?id=%s", base, url.QueryEscape(tabID))
+}
+
+// normalizeBaseURL validates an http(s) base URL and strips any trailing slash
+func normalizeBaseURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("URL %q must start with http:// or https://", raw)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("URL %q has no host", raw)
+	}
+
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
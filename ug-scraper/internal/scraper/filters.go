@@ -0,0 +1,255 @@
+package scraper
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/localtime"
+)
+
+// PartWhole asks for tabs of the whole song, which UG gives no part
+const PartWhole = "whole"
+
+// Result filters pick which of the matching results a search returns
+const (
+	FilterTop    = "top"    // The top-rated version per artist, chords preferred (default)
+	FilterChords = "chords" // Every chords version
+	FilterNone   = "none"   // Every result
+)
+
+// ResultFilters lists the result filters
+var ResultFilters = []string{FilterTop, FilterChords, FilterNone}
+
+// ParseResultFilter checks a result filter name; empty means FilterTop
+func ParseResultFilter(raw string) (string, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		return FilterTop, true
+	}
+	return raw, slices.Contains(ResultFilters, raw)
+}
+
+// ResultFilterList returns the result filters as a "top|chords|none" string
+// for help and error messages
+func ResultFilterList() string {
+	return strings.Join(ResultFilters, "|")
+}
+
+// standardTunings are the standard tunings of guitar, bass and ukulele,
+// spelled without spaces or octave numbers
+var standardTunings = []string{"standard", "estandard", "eadgbe", "eadg", "beadg", "gcea"}
+
+// IsStandardTuning reports whether a UG tuning name or note list is a
+// standard tuning, e.g. "Standard", "E A D G B E" or "EADGBE"
+func IsStandardTuning(tuning string) bool {
+	var b strings.Builder
+	for _, r := range strings.ToLower(tuning) {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return slices.Contains(standardTunings, b.String())
+}
+
+// types returns every type the search accepts: Type and Types together
+func (o SearchOptions) types() []TabType {
+	var types []TabType
+	for _, t := range append([]TabType{o.Type}, o.Types...) {
+		if t != "" && !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// describe lists the filters of a search for logs
+func (o SearchOptions) describe() string {
+	var filters []string
+	for _, t := range o.types() {
+		filters = append(filters, "type="+string(t))
+	}
+	if o.Difficulty != "" {
+		filters = append(filters, "difficulty="+o.Difficulty)
+	}
+	if o.MinRating > 0 {
+		filters = append(filters, fmt.Sprintf("min_rating=%g", o.MinRating))
+	}
+	if o.MinVotes > 0 {
+		filters = append(filters, fmt.Sprintf("min_votes=%d", o.MinVotes))
+	}
+	if o.StandardTuning {
+		filters = append(filters, "tuning=standard")
+	}
+	if o.Part != "" {
+		filters = append(filters, "part="+o.Part)
+	}
+	if o.Filter != "" && o.Filter != FilterTop {
+		filters = append(filters, "filter="+o.Filter)
+	}
+	if o.Sort != "" && o.Sort != SortRelevance {
+		filters = append(filters, "sort="+o.Sort)
+	}
+	if len(filters) == 0 {
+		return "no filters"
+	}
+	return strings.Join(filters, ", ")
+}
+
+// filterResults applies every filter of the search to its results
+func filterResults(results []SearchResult, opts SearchOptions) []SearchResult {
+	results = filterByType(results, opts.types())
+	results = filterByDifficulty(results, opts.Difficulty)
+	results = filterByPopularity(results, opts.MinRating, opts.MinVotes)
+	if opts.StandardTuning {
+		results = filterByStandardTuning(results)
+	}
+	return filterByPart(results, opts.Part)
+}
+
+// pickResults applies the search's result filter
+func pickResults(results []SearchResult, filter string) []SearchResult {
+	switch filter {
+	case FilterNone:
+		return results
+	case FilterChords:
+		return filterByType(results, []TabType{TypeChords})
+	default:
+		return filterTopResults(results)
+	}
+}
+
+// filterByPopularity keeps results with at least the given rating and votes
+func filterByPopularity(results []SearchResult, minRating float64, minVotes int) []SearchResult {
+	if minRating <= 0 && minVotes <= 0 {
+		return results
+	}
+
+	filtered := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if r.Rating >= minRating && r.Votes >= minVotes {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// filterByStandardTuning drops results in another tuning. Like difficulty,
+// results whose tuning is unknown are dropped too, unless no result has one.
+func filterByStandardTuning(results []SearchResult) []SearchResult {
+	known := false
+	filtered := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if r.Tuning != "" {
+			known = true
+		}
+		if IsStandardTuning(r.Tuning) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	if !known && len(results) > 0 {
+		fmt.Println("   ⚠️  Results carry no tuning, standard tuning filter not applied")
+		return results
+	}
+	return filtered
+}
+
+// filterByPart keeps results of one part of the song, compared without
+// case; PartWhole keeps the ones without a part
+func filterByPart(results []SearchResult, part string) []SearchResult {
+	part = strings.TrimSpace(part)
+	if part == "" {
+		return results
+	}
+
+	filtered := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if strings.EqualFold(r.Part, part) || (strings.EqualFold(part, PartWhole) && r.Part == "") {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// Result orders
+const (
+	SortRelevance = "relevance" // UG's order (default)
+	SortRating    = "rating"    // Highest rated first, then most votes
+	SortVotes     = "votes"     // Most votes first, then highest rated
+	SortNewest    = "newest"    // Most recently published first; undated results last
+)
+
+// SortOrders lists the result orders
+var SortOrders = []string{SortRelevance, SortRating, SortVotes, SortNewest}
+
+// ParseSort checks a result order name; empty means SortRelevance
+func ParseSort(raw string) (string, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		return SortRelevance, true
+	}
+	return raw, slices.Contains(SortOrders, raw)
+}
+
+// SortList returns the result orders as a "relevance|rating|..." string for
+// help and error messages
+func SortList() string {
+	return strings.Join(SortOrders, "|")
+}
+
+// sortResults orders results; ties keep UG's order, so the same search
+// always returns the same list
+func sortResults(results []SearchResult, order string) []SearchResult {
+	byRating := func(a, b SearchResult) int {
+		return cmp.Compare(b.Rating, a.Rating)
+	}
+	byVotes := func(a, b SearchResult) int {
+		return cmp.Compare(b.Votes, a.Votes)
+	}
+
+	switch order {
+	case SortRating:
+		slices.SortStableFunc(results, func(a, b SearchResult) int {
+			return cmp.Or(byRating(a, b), byVotes(a, b))
+		})
+	case SortVotes:
+		slices.SortStableFunc(results, func(a, b SearchResult) int {
+			return cmp.Or(byVotes(a, b), byRating(a, b))
+		})
+	case SortNewest:
+		slices.SortStableFunc(results, func(a, b SearchResult) int {
+			switch {
+			case a.Date == nil && b.Date == nil:
+				return 0
+			case a.Date == nil:
+				return 1
+			case b.Date == nil:
+				return -1
+			}
+			return b.Date.Compare(*a.Date)
+		})
+	}
+	return results
+}
+
+// parseUGDate reads a publication date as UG sends it: Unix seconds as a
+// number, or a string localtime.ParseDate understands. Returns nil when
+// there is none.
+func parseUGDate(v any) *time.Time {
+	var t time.Time
+	switch v := v.(type) {
+	case float64:
+		if v > 0 {
+			t = time.Unix(int64(v), 0)
+		}
+	case string:
+		t, _ = localtime.ParseDate(v)
+	}
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
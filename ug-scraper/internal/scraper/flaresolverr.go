@@ -0,0 +1,410 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/bandwidth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/httpclient"
+)
+
+const (
+	// defaultFlareSolverrTimeout leaves FlareSolverr's own 60s challenge
+	// limit room to answer (it typically takes 42-44s)
+	defaultFlareSolverrTimeout = 70 * time.Second
+	// flareSolverrSlack is how much sooner FlareSolverr is asked to give up
+	// than our client, so its error arrives instead of a client timeout
+	flareSolverrSlack         = 10 * time.Second
+	defaultFlareCheckInterval = time.Minute
+	// flarePingTimeout bounds a health check; FlareSolverr answers it without
+	// starting a browser
+	flarePingTimeout = 5 * time.Second
+	// solveWindow is how many recent solves the average covers
+	solveWindow = 20
+)
+
+// FlareSolverrStatus is what is known about the FlareSolverr service from
+// periodic checks and the solves made for searches
+type FlareSolverrStatus struct {
+	Configured    bool       `json:"configured"`
+	Reachable     bool       `json:"reachable"`
+	Version       string     `json:"version,omitempty"`
+	PingMs        int64      `json:"ping_ms,omitempty"`
+	CheckedAt     *time.Time `json:"checked_at,omitempty"`
+	DownSince     *time.Time `json:"down_since,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	Solves        int        `json:"solves"`
+	SolveFailures int        `json:"solve_failures"`
+	AvgSolveMs    int64      `json:"avg_solve_ms,omitempty"` // Over the last solveWindow successful solves
+	LastSolveMs   int64      `json:"last_solve_ms,omitempty"`
+}
+
+// flareMonitor keeps the FlareSolverr status
+type flareMonitor struct {
+	mu         sync.Mutex
+	status     FlareSolverrStatus
+	solveTimes []time.Duration
+}
+
+// recordCheck stores the outcome of a health check
+func (m *flareMonitor) recordCheck(version string, ping time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.status.CheckedAt = &now
+	m.markReachable(err)
+	if err == nil {
+		m.status.Version = version
+		m.status.PingMs = ping.Milliseconds()
+	}
+}
+
+// recordSolve stores the outcome of a solve made for a search
+func (m *flareMonitor) recordSolve(took time.Duration, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.status.SolveFailures++
+		m.status.LastError = err.Error()
+		return
+	}
+	m.markReachable(nil)
+	m.status.Solves++
+	m.status.LastSolveMs = took.Milliseconds()
+	m.solveTimes = append(m.solveTimes, took)
+	if len(m.solveTimes) > solveWindow {
+		m.solveTimes = m.solveTimes[len(m.solveTimes)-solveWindow:]
+	}
+	var total time.Duration
+	for _, t := range m.solveTimes {
+		total += t
+	}
+	m.status.AvgSolveMs = (total / time.Duration(len(m.solveTimes))).Milliseconds()
+}
+
+// markReachable updates reachability, keeping when the service went down
+// (caller holds the lock)
+func (m *flareMonitor) markReachable(err error) {
+	if err != nil {
+		m.status.LastError = err.Error()
+		if m.status.Reachable || m.status.DownSince == nil {
+			now := time.Now()
+			m.status.DownSince = &now
+		}
+		m.status.Reachable = false
+		return
+	}
+	m.status.Reachable = true
+	m.status.DownSince = nil
+}
+
+// snapshot returns a copy of the status
+func (m *flareMonitor) snapshot() FlareSolverrStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// knownDown reports whether a recent check or solve found the service
+// down. An old verdict is not trusted, so searches try again even when the
+// background checks are off.
+func (m *flareMonitor) knownDown() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status.CheckedAt != nil && !m.status.Reachable && time.Since(*m.status.CheckedAt) < 5*defaultFlareCheckInterval
+}
+
+// flareSolver fetches pages through a service speaking the FlareSolverr
+// API (FlareSolverr itself or Byparr), which passes the challenge in a
+// browser
+type flareSolver struct {
+	name       string
+	url        string
+	client     *http.Client
+	maxTimeout time.Duration // Sent to the service as maxTimeout
+	monitor    flareMonitor
+}
+
+// newFlareSolverFromEnv creates a solver for the service at the URL in
+// urlVar, or nil when it is not set. Requests use the FLARESOLVERR_HTTP_*
+// settings.
+func newFlareSolverFromEnv(name, urlVar string) *flareSolver {
+	serviceURL := os.Getenv(urlVar)
+	if serviceURL == "" {
+		return nil
+	}
+
+	config := httpclient.ConfigFromEnv("FLARESOLVERR_HTTP", httpclient.Defaults(defaultFlareSolverrTimeout))
+	maxTimeout := 60 * time.Second
+	if config.Timeout > 0 {
+		maxTimeout = max(config.Timeout-flareSolverrSlack, flareSolverrSlack)
+	}
+
+	return &flareSolver{
+		name:       name,
+		url:        strings.TrimRight(serviceURL, "/"),
+		client:     config.Client(),
+		maxTimeout: maxTimeout,
+	}
+}
+
+// Name identifies the solver in logs
+func (f *flareSolver) Name() string {
+	return f.name
+}
+
+// Fetch asks the service to load the page, recording how the solve went
+func (f *flareSolver) Fetch(ctx context.Context, pageURL string) (Page, error) {
+	requestBody := map[string]interface{}{
+		"cmd":        "request.get",
+		"url":        pageURL,
+		"maxTimeout": f.maxTimeout.Milliseconds(),
+		// Wait for search results to appear (React renders them)
+		"postBody": "",
+		"cookies":  []map[string]string{},
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return Page{}, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", f.url+"/v1", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return Page{}, fmt.Errorf("creating %s request: %w", f.name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := f.client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("%s request failed: %w", f.name, err)
+		var netErr net.Error
+		switch {
+		case ctx.Err() != nil:
+		case errors.As(err, &netErr) && netErr.Timeout():
+			// A slow challenge, not a dead service
+			f.monitor.recordSolve(time.Since(start), err)
+		default:
+			// Not reaching it at all is as telling as a failed health check
+			f.monitor.recordCheck("", 0, err)
+		}
+		return Page{}, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+		Solution struct {
+			URL      string `json:"url"`
+			Status   int    `json:"status"`
+			Response string `json:"response"`
+		} `json:"solution"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		err = fmt.Errorf("decoding %s response: %w", f.name, err)
+		f.monitor.recordSolve(time.Since(start), err)
+		return Page{}, err
+	}
+
+	if result.Status != "ok" {
+		err := fmt.Errorf("%s returned status: %s, message: %s", f.name, result.Status, result.Message)
+		f.monitor.recordSolve(time.Since(start), err)
+		return Page{}, err
+	}
+	f.monitor.recordSolve(time.Since(start), nil)
+
+	return Page{Body: []byte(result.Solution.Response), URL: result.Solution.URL}, nil
+}
+
+func (f *flareSolver) knownDown() bool {
+	return f.monitor.knownDown()
+}
+
+func (f *flareSolver) withMeter(meter *bandwidth.Meter) {
+	f.client = meter.Client(bandwidth.UpstreamFlareSolverr, f.client)
+}
+
+// CloseIdleConnections drops pooled connections to the service
+func (f *flareSolver) CloseIdleConnections() {
+	f.client.CloseIdleConnections()
+}
+
+// ping checks that the service answers, recording its version and
+// response time
+func (f *flareSolver) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, flarePingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	version, err := f.index(ctx)
+	f.monitor.recordCheck(version, time.Since(start), err)
+	return err
+}
+
+// index requests the service's index, which reports its version
+func (f *flareSolver) index(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s unreachable: %w", f.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned status %d", f.name, resp.StatusCode)
+	}
+
+	var index struct {
+		Version string `json:"version"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = json.Unmarshal(body, &index)
+	return index.Version, nil
+}
+
+// FlareSolverrStatus returns the FlareSolverr status for health reporting
+func (s *SearchScraper) FlareSolverrStatus() FlareSolverrStatus {
+	if s.flare == nil {
+		return FlareSolverrStatus{}
+	}
+	status := s.flare.monitor.snapshot()
+	status.Configured = true
+	return status
+}
+
+// FlareSolverrConfigured reports whether searches go through FlareSolverr
+func (s *SearchScraper) FlareSolverrConfigured() bool {
+	return s.flare != nil
+}
+
+// PingFlareSolverr checks that the FlareSolverr service answers, recording
+// its version and response time
+func (s *SearchScraper) PingFlareSolverr(ctx context.Context) error {
+	if s.flare == nil {
+		return fmt.Errorf("FlareSolverr is not configured")
+	}
+	return s.flare.ping(ctx)
+}
+
+// MonitorFlareSolverr checks FlareSolverr in the background every
+// FLARESOLVERR_CHECK_INTERVAL (a Go duration, default 1m, "0" disables it),
+// so searches skip it while it is down instead of waiting for it to time out
+func (s *SearchScraper) MonitorFlareSolverr() {
+	if !s.FlareSolverrConfigured() {
+		return
+	}
+
+	interval := defaultFlareCheckInterval
+	if v := os.Getenv("FLARESOLVERR_CHECK_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			interval = d
+		} else if v == "0" {
+			interval = 0
+		}
+	}
+	if interval <= 0 {
+		fmt.Println("🛡️  FlareSolverr health checks disabled")
+		return
+	}
+
+	fmt.Printf("🛡️  Checking FlareSolverr every %s\n", interval)
+	go func() {
+		wasDown := false
+		check := func() {
+			err := s.PingFlareSolverr(context.Background())
+			switch {
+			case err != nil && !wasDown:
+				fmt.Printf("⚠️  FlareSolverr is down, searches go direct until it is back: %v\n", err)
+			case err == nil && wasDown:
+				fmt.Println("✅ FlareSolverr is reachable again")
+			}
+			wasDown = err != nil
+		}
+
+		check()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			check()
+		}
+	}()
+}
+
+// FlareSolverrHealth scores FlareSolverr from the last check, checking on
+// the spot when the background checks are off
+func (s *SearchScraper) FlareSolverrHealth() (float64, string) {
+	status := s.FlareSolverrStatus()
+	if status.CheckedAt == nil || time.Since(*status.CheckedAt) > 5*defaultFlareCheckInterval {
+		_ = s.PingFlareSolverr(context.Background())
+		status = s.FlareSolverrStatus()
+	}
+
+	if !status.Reachable {
+		detail := status.LastError
+		if status.DownSince != nil {
+			detail += fmt.Sprintf(" (down since %s)", status.DownSince.Format(time.RFC3339))
+		}
+		return 0, detail
+	}
+
+	detail := "reachable"
+	if status.Version != "" {
+		detail += ", version " + status.Version
+	}
+	if status.Solves > 0 {
+		detail += fmt.Sprintf(", average solve %s", (time.Duration(status.AvgSolveMs) * time.Millisecond).Round(100*time.Millisecond))
+	}
+	return 1, detail
+}
+
+// WriteFlareSolverrPrometheus writes the FlareSolverr status in the
+// Prometheus text exposition format
+func (s *SearchScraper) WriteFlareSolverrPrometheus(w io.Writer) {
+	status := s.FlareSolverrStatus()
+	if !status.Configured {
+		return
+	}
+
+	up := 0
+	if status.Reachable {
+		up = 1
+	}
+	fmt.Fprintln(w, "# HELP ug_scraper_flaresolverr_up Whether the last FlareSolverr check or solve succeeded.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_flaresolverr_up gauge")
+	fmt.Fprintf(w, "ug_scraper_flaresolverr_up{version=%q} %d\n", status.Version, up)
+
+	fmt.Fprintln(w, "# HELP ug_scraper_flaresolverr_solves_total FlareSolverr solves made for searches since the add-on started.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_flaresolverr_solves_total counter")
+	fmt.Fprintf(w, "ug_scraper_flaresolverr_solves_total{result=\"ok\"} %d\n", status.Solves)
+	fmt.Fprintf(w, "ug_scraper_flaresolverr_solves_total{result=\"failed\"} %d\n", status.SolveFailures)
+
+	fmt.Fprintln(w, "# HELP ug_scraper_flaresolverr_solve_seconds_avg Average time of recent successful FlareSolverr solves.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_flaresolverr_solve_seconds_avg gauge")
+	fmt.Fprintf(w, "ug_scraper_flaresolverr_solve_seconds_avg %.3f\n", float64(status.AvgSolveMs)/1000)
+
+	fmt.Fprintln(w, "# HELP ug_scraper_flaresolverr_ping_seconds Response time of the last FlareSolverr check.")
+	fmt.Fprintln(w, "# TYPE ug_scraper_flaresolverr_ping_seconds gauge")
+	fmt.Fprintf(w, "ug_scraper_flaresolverr_ping_seconds %.3f\n", float64(status.PingMs)/1000)
+}
@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// healthWindow is how many recent requests the success rate covers
+const healthWindow = 20
+
+// requestStats tracks the outcome of recent upstream requests
+type requestStats struct {
+	mu       sync.Mutex
+	outcomes []bool
+	lastErr  string
+}
+
+// record adds a request outcome
+func (s *requestStats) record(err error) {
+	// A request abandoned by its caller says nothing about UG's health
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outcomes = append(s.outcomes, err == nil)
+	if len(s.outcomes) > healthWindow {
+		s.outcomes = s.outcomes[len(s.outcomes)-healthWindow:]
+	}
+	if err != nil {
+		s.lastErr = err.Error()
+	}
+}
+
+// successRate returns the share of recent requests that succeeded, the
+// number of requests it covers and the last error seen
+func (s *requestStats) successRate() (float64, int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.outcomes) == 0 {
+		return 1, 0, ""
+	}
+	ok := 0
+	for _, success := range s.outcomes {
+		if success {
+			ok++
+		}
+	}
+	return float64(ok) / float64(len(s.outcomes)), len(s.outcomes), s.lastErr
+}
+
+// reset forgets recorded outcomes
+func (s *requestStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes = nil
+}
+
+// Health scores the client by the success rate of its recent requests
+func (c *UGClient) Health() (float64, string) {
+	return healthFromStats(&c.stats)
+}
+
+// Health scores the search scraper by the success rate of its recent searches
+func (s *SearchScraper) Health() (float64, string) {
+	return healthFromStats(&s.stats)
+}
+
+// healthFromStats turns request stats into a score and description
+func healthFromStats(stats *requestStats) (float64, string) {
+	rate, count, lastErr := stats.successRate()
+	if count == 0 {
+		return 1, "no requests yet"
+	}
+	detail := fmt.Sprintf("%d of the last %d requests succeeded", int(rate*float64(count)+0.5), count)
+	if rate < 1 && lastErr != "" {
+		detail += "; last error: " + lastErr
+	}
+	return rate, detail
+}
+
+// ResetConnections drops pooled connections, which may be stuck on a dead
+// upstream, and starts the success rate afresh
+func (c *UGClient) ResetConnections() {
+	c.httpClient.CloseIdleConnections()
+	c.stats.reset()
+}
+
+// ResetConnections drops the search scraper's pooled connections
+func (s *SearchScraper) ResetConnections() {
+	for _, solver := range s.solvers {
+		if r, ok := solver.(connectionResetter); ok {
+			r.CloseIdleConnections()
+		}
+	}
+	s.ugClient.ResetConnections()
+	s.stats.reset()
+}
+
+// Ping checks that the app API answers. Any response below 500 counts, since
+// the API refuses a bare request to its base URL; only errors reaching it
+// and server errors fail.
+func (c *UGClient) Ping(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.endpoints.APIBaseURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	c.configureHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("API unreachable: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	return fmt.Sprintf("%s answered with status %d", c.endpoints.APIBaseURL, resp.StatusCode), nil
+}
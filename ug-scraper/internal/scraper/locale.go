@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	ugRootDomain     = "ultimate-guitar.com"
+	canonicalTabHost = "tabs.ultimate-guitar.com"
+	maxRedirects     = 10
+)
+
+var (
+	// Locale subdomains look like de.ultimate-guitar.com or pt-br.ultimate-guitar.com
+	localeSubdomain = regexp.MustCompile(`^([a-z]{2}(?:-[a-z]{2})?)\.ultimate-guitar\.com$`)
+	// Locale path prefixes look like /es/tab/... or /pt-br/tab/...
+	localePathPrefix = regexp.MustCompile(`^/([a-z]{2}(?:-[a-z]{2})?)(/.*)$`)
+	// Tab URLs end in -<id>, optionally followed by a slash
+	tabURLID = regexp.MustCompile(`-(\d+)/?$`)
+
+	// Two-letter subdomains that are not locales
+	nonLocaleSubdomains = map[string]bool{"m": true}
+)
+
+// IsUGHost reports whether host belongs to Ultimate Guitar
+func IsUGHost(host string) bool {
+	host = strings.ToLower(strings.Split(host, ":")[0])
+	return host == ugRootDomain || strings.HasSuffix(host, "."+ugRootDomain)
+}
+
+// LocaleFromURL returns the locale a UG URL is served in (e.g. "de"), or ""
+// for the canonical English site and non-UG URLs
+func LocaleFromURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host != "" && !IsUGHost(host) {
+		return ""
+	}
+
+	if m := localeSubdomain.FindStringSubmatch(host); m != nil && !nonLocaleSubdomains[m[1]] {
+		return m[1]
+	}
+
+	if m := localePathPrefix.FindStringSubmatch(parsed.Path); m != nil && strings.HasPrefix(m[2], "/tab") {
+		return m[1]
+	}
+
+	return ""
+}
+
+// CanonicalTabURL rewrites a (possibly relative or localized) UG tab URL to
+// its canonical https://tabs.ultimate-guitar.com form without tracking
+// parameters. Non-UG absolute URLs are returned unchanged.
+func CanonicalTabURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return raw
+	}
+
+	if parsed.Host != "" && !IsUGHost(parsed.Host) {
+		return raw
+	}
+
+	path := parsed.Path
+	if m := localePathPrefix.FindStringSubmatch(path); m != nil && strings.HasPrefix(m[2], "/tab") {
+		path = m[2]
+	}
+
+	canonical := url.URL{
+		Scheme: "https",
+		Host:   canonicalTabHost,
+		Path:   path,
+	}
+
+	return canonical.String()
+}
+
+// TabIDFromURL extracts the numeric tab ID from a UG tab URL, or "" if none
+func TabIDFromURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+
+	if m := tabURLID.FindStringSubmatch(parsed.Path); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// redirectPolicy follows redirects between UG hosts (including localized
+// ones) and the configured mirrors, refusing to be sent anywhere else
+func redirectPolicy(endpoints Endpoints) func(req *http.Request, via []*http.Request) error {
+	allowed := make(map[string]bool)
+	for _, base := range []string{endpoints.WebBaseURL, endpoints.APIBaseURL} {
+		if parsed, err := url.Parse(base); err == nil {
+			allowed[strings.ToLower(parsed.Host)] = true
+		}
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		host := strings.ToLower(req.URL.Host)
+		if !IsUGHost(host) && !allowed[host] {
+			return fmt.Errorf("refusing redirect to non-UG host %s", host)
+		}
+
+		// Carry the original headers across hosts; Go drops some on cross-domain hops
+		for name, values := range via[0].Header {
+			if _, ok := req.Header[name]; !ok {
+				req.Header[name] = values
+			}
+		}
+
+		if locale := LocaleFromURL(req.URL.String()); locale != "" {
+			fmt.Printf("   ↪ Following redirect to localized UG site (%s): %s\n", locale, req.URL.Host)
+		}
+
+		return nil
+	}
+}
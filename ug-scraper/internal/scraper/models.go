@@ -1,64 +1,124 @@
 package scraper
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // SearchResult represents a single tab search result
 type SearchResult struct {
-	ID         string  `json:"id"`
-	Title      string  `json:"title"`
-	Artist     string  `json:"artist"`
-	Type       string  `json:"type"`
-	Rating     float64 `json:"rating"`
-	Votes      int     `json:"votes"`
-	Difficulty string  `json:"difficulty,omitempty"`
-	URL        string  `json:"url"`
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	Artist     string     `json:"artist"`
+	Type       TabType    `json:"type"`
+	Rating     float64    `json:"rating"`
+	Votes      int        `json:"votes"`
+	Difficulty string     `json:"difficulty,omitempty"`
+	Part       string     `json:"part,omitempty"`   // e.g. "intro" or "solo"; empty for the whole song
+	Tuning     string     `json:"tuning,omitempty"` // Set when UG reports it
+	Date       *time.Time `json:"date,omitempty"`   // When the tab was published, if UG reports it
+	URL        string     `json:"url"`
+	Locale     string     `json:"locale,omitempty"` // Set when the result came from a localized UG site
 }
 
 // TabResult represents the complete tab data from UG API
 type TabResult struct {
-	TabID         int       `json:"tab_id"`
-	SongName      string    `json:"song_name"`
-	ArtistName    string    `json:"artist_name"`
-	Type          string    `json:"type"`
-	Part          string    `json:"part"`
-	Version       int       `json:"version"`
-	Votes         int       `json:"votes"`
-	Rating        float64   `json:"rating"`
-	Date          time.Time `json:"date"`
-	Status        string    `json:"status"`
-	TonalityName  string    `json:"tonality_name"`
-	Verified      int       `json:"verified"`
-	Capo          int       `json:"capo"`
-	Tuning        string    `json:"tuning"`
-	Difficulty    string    `json:"difficulty"`
-	Content       string    `json:"content"`
-	URLWeb        string    `json:"urlWeb"`
-	Contributor   struct {
+	TabID        int           `json:"tab_id"`
+	SongName     string        `json:"song_name"`
+	ArtistName   string        `json:"artist_name"`
+	Type         TabType       `json:"type"`
+	Part         string        `json:"part"`
+	Version      int           `json:"version"`
+	Votes        int           `json:"votes"`
+	Rating       float64       `json:"rating"`
+	Date         time.Time     `json:"date"`
+	Status       string        `json:"status"`
+	TonalityName string        `json:"tonality_name"`
+	Verified     int           `json:"verified"`
+	Capo         int           `json:"capo"`
+	Tuning       string        `json:"tuning"`
+	Difficulty   string        `json:"difficulty"`
+	Content      string        `json:"content"`
+	URLWeb       string        `json:"urlWeb"`
+	Locale       string        `json:"locale,omitempty"`
+	Strummings   []Strumming   `json:"strummings,omitempty"`
+	Applicature  []Applicature `json:"applicature,omitempty"` // Chord voicings the author drew
+	Contributor  struct {
 		UserID   int    `json:"user_id"`
 		Username string `json:"username"`
 	} `json:"contributor"`
 }
 
+// Strumming is a strumming pattern from a tab's metadata
+type Strumming struct {
+	Part        string `json:"part"`
+	Denominator int    `json:"denominator"` // Note value of one stroke, e.g. 8 for eighths
+	BPM         int    `json:"bpm"`
+	Triplet     bool   `json:"is_triplet"`
+	Measures    []int  `json:"measures"` // Stroke codes, one per beat subdivision
+}
+
+// UnmarshalJSON reads UG's strumming format, which spells the denominator
+// "denuminator" and wraps every stroke code in a {"measure": n} object
+func (s *Strumming) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Part        string            `json:"part"`
+		Denominator int               `json:"denominator"`
+		Denuminator int               `json:"denuminator"`
+		BPM         int               `json:"bpm"`
+		Triplet     bool              `json:"is_triplet"`
+		Measures    []json.RawMessage `json:"measures"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = Strumming{
+		Part:        raw.Part,
+		Denominator: max(raw.Denominator, raw.Denuminator),
+		BPM:         raw.BPM,
+		Triplet:     raw.Triplet,
+		Measures:    make([]int, 0, len(raw.Measures)),
+	}
+	for _, m := range raw.Measures {
+		var code int
+		if err := json.Unmarshal(m, &code); err != nil {
+			var wrapped struct {
+				Measure int `json:"measure"`
+			}
+			if err := json.Unmarshal(m, &wrapped); err != nil {
+				return fmt.Errorf("decoding strum measure: %w", err)
+			}
+			code = wrapped.Measure
+		}
+		s.Measures = append(s.Measures, code)
+	}
+	return nil
+}
+
 // UGAPIResponse wraps the Ultimate Guitar API response
 type UGAPIResponse struct {
-	TabID         int       `json:"id"`
-	SongName      string    `json:"song_name"`
-	ArtistName    string    `json:"artist_name"`
-	Type          string    `json:"type"`
-	Part          string    `json:"part"`
-	Version       int       `json:"version"`
-	Votes         int       `json:"votes"`
-	Rating        float64   `json:"rating"`
-	Date          string    `json:"date"`
-	Status        string    `json:"status"`
-	TonalityName  string    `json:"tonality_name"`
-	Verified      int       `json:"verified"`
-	Capo          int       `json:"capo"`
-	Tuning        string    `json:"tuning"`
-	Difficulty    string    `json:"difficulty"`
-	Content       string    `json:"content"`
-	URLWeb        string    `json:"urlWeb"`
-	Contributor   struct {
+	TabID        int             `json:"id"`
+	SongName     string          `json:"song_name"`
+	ArtistName   string          `json:"artist_name"`
+	Type         string          `json:"type"`
+	Part         string          `json:"part"`
+	Version      int             `json:"version"`
+	Votes        int             `json:"votes"`
+	Rating       float64         `json:"rating"`
+	Date         string          `json:"date"`
+	Status       string          `json:"status"`
+	TonalityName string          `json:"tonality_name"`
+	Verified     int             `json:"verified"`
+	Capo         int             `json:"capo"`
+	Tuning       string          `json:"tuning"`
+	Difficulty   string          `json:"difficulty"`
+	Content      string          `json:"content"`
+	URLWeb       string          `json:"urlWeb"`
+	Strummings   []Strumming     `json:"strummings"`
+	Applicature  json.RawMessage `json:"applicature"`
+	Contributor  struct {
 		UserID   int    `json:"user_id"`
 		Username string `json:"username"`
 	} `json:"contributor"`
@@ -0,0 +1,392 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Ways a reference was resolved to a tab ID
+const (
+	ResolvedByID       = "id"       // Bare numeric tab ID
+	ResolvedByURL      = "url"      // ID read from a tab URL or slug
+	ResolvedByRedirect = "redirect" // Shortened link expanded to a tab URL
+	ResolvedBySearch   = "search"   // Artist/title looked up by search
+)
+
+const maxUnwrapDepth = 3
+
+var (
+	// ErrNotUGReference is returned for input that says nothing about a UG tab
+	ErrNotUGReference = errors.New("not an Ultimate Guitar tab URL, slug or ID")
+	// ErrNoTabFound is returned when a search for a reference has no tab
+	ErrNoTabFound = errors.New("no tab found")
+
+	// shortenerHosts are link shorteners whose redirects are followed to find
+	// the tab URL behind them; app.link serves UG's app share links
+	shortenerHosts = []string{
+		"bit.ly", "tinyurl.com", "t.co", "goo.gl", "ow.ly", "is.gd", "buff.ly",
+		"rb.gy", "tiny.cc", "cutt.ly", "shorturl.at", "lnkd.in", "app.link",
+	}
+
+	// wrapperParams are query parameters redirect and tracking links keep the
+	// real URL in (Google, Facebook, newsletter click trackers)
+	wrapperParams = []string{"url", "u", "q", "target", "dest", "redirect", "link"}
+
+	// tabIDParams are query parameters that carry a tab ID directly
+	tabIDParams = []string{"id", "tab_id", "tabid"}
+
+	numericID = regexp.MustCompile(`^\d+$`)
+	// slugTypeSuffix is the type UG appends to tab slugs, e.g. wonderwall-chords-39144
+	slugTypeSuffix = regexp.MustCompile(`-(chords|tabs|tab|bass|ukulele|guitar-pro|drums|drum-tab|power|official|video)$`)
+	// legacySlug matches old-style /tabs/o/oasis/wonderwall_ver2_crd.htm pages
+	legacySlug = regexp.MustCompile(`^(.+?)(?:_ver\d+)?_(crd|tab|btab|drum_tab|ukulele_crd|guitar_pro|power_tab|video)\.htm$`)
+	// hostLike tells scheme-less URLs ("tabs.ultimate-guitar.com/...") from slugs
+	hostLike = regexp.MustCompile(`^[a-z0-9-]+(?:\.[a-z0-9-]+)+(?::\d+)?(?:/|$)`)
+
+	// nonTabSections are UG site sections whose slugs name no tab
+	nonTabSections = map[string]bool{"artist": true, "user": true, "news": true, "lessons": true, "forum": true}
+
+	legacyTypes = map[string]TabType{
+		"crd":         TypeChords,
+		"tab":         TypeTab,
+		"btab":        TypeBass,
+		"drum_tab":    TypeDrums,
+		"ukulele_crd": TypeUkulele,
+		"guitar_pro":  TypeGuitarPro,
+		"power_tab":   TypePower,
+		"video":       TypeVideo,
+	}
+)
+
+// Resolution is a UG reference resolved to a tab
+type Resolution struct {
+	Input    string  `json:"input"`
+	TabID    string  `json:"tab_id,omitempty"`
+	Type     TabType `json:"type,omitempty"`
+	Artist   string  `json:"artist,omitempty"`
+	Title    string  `json:"title,omitempty"`
+	URL      string  `json:"url,omitempty"` // Canonical tab URL
+	Locale   string  `json:"locale,omitempty"`
+	Source   string  `json:"source,omitempty"`  // How the tab ID was found
+	Verified bool    `json:"verified"`          // Details come from the tab itself, not the URL
+	Warning  string  `json:"warning,omitempty"` // Why the details could not be verified
+	expand   string  // Shortened link still to be followed
+	query    string  // Search terms for references without an ID
+}
+
+// ParseTabRef reads what it can from a UG reference without network access:
+// a numeric ID, a desktop, mobile or localized tab URL (tracking parameters
+// and redirect wrappers are stripped), an artist/song slug, an old-style
+// .htm page or a search URL. Artist and title are guessed from the slug.
+func ParseTabRef(input string) (*Resolution, error) {
+	res := &Resolution{Input: input}
+	if err := res.parse(strings.TrimSpace(input), 0); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// NeedsLookup reports whether the reference can only be resolved online
+func (r *Resolution) NeedsLookup() bool {
+	return r.TabID == ""
+}
+
+// parse fills r from a reference, unwrapping redirect links up to depth
+func (r *Resolution) parse(ref string, depth int) error {
+	if ref == "" {
+		return ErrNotUGReference
+	}
+	if numericID.MatchString(ref) {
+		r.TabID = ref
+		r.Source = ResolvedByID
+		return nil
+	}
+
+	lower := strings.ToLower(ref)
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+	case strings.HasPrefix(ref, "//"):
+		ref = "https:" + ref
+	case hostLike.MatchString(lower):
+		ref = "https://" + ref
+	case strings.ContainsAny(ref, " \t"):
+		return ErrNotUGReference
+	default:
+		// A bare slug such as "oasis/wonderwall-chords-39144"
+		ref = "https://" + canonicalTabHost + "/tab/" + strings.TrimPrefix(strings.TrimPrefix(ref, "/"), "tab/")
+	}
+
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotUGReference, err)
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	if !IsUGHost(host) {
+		if depth < maxUnwrapDepth {
+			for _, param := range wrapperParams {
+				if inner := parsed.Query().Get(param); inner != "" && strings.Contains(strings.ToLower(inner), ugRootDomain) {
+					return r.parse(inner, depth+1)
+				}
+			}
+		}
+		if isShortener(host) {
+			r.expand = parsed.String()
+			return nil
+		}
+		return fmt.Errorf("%w: %s is not an Ultimate Guitar host", ErrNotUGReference, host)
+	}
+
+	r.Locale = LocaleFromURL(parsed.String())
+	path := strings.TrimSuffix(parsed.Path, "/")
+	if m := localePathPrefix.FindStringSubmatch(path); m != nil && r.Locale != "" {
+		path = m[2]
+	}
+
+	for _, param := range tabIDParams {
+		if id := parsed.Query().Get(param); numericID.MatchString(id) {
+			r.TabID = id
+			r.Source = ResolvedByURL
+		}
+	}
+
+	// Search result pages carry the search in ?value= (or ?q=)
+	if strings.HasPrefix(path, "/search") {
+		for _, param := range []string{"value", "q", "query"} {
+			if q := strings.TrimSpace(parsed.Query().Get(param)); q != "" && r.TabID == "" {
+				r.query = q
+				if tabType, ok := ParseTabType(parsed.Query().Get("type")); ok {
+					r.Type = tabType
+				}
+				return nil
+			}
+		}
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if nonTabSections[segments[0]] {
+		return fmt.Errorf("%w: %s pages are not tabs", ErrNotUGReference, segments[0])
+	}
+	last := segments[len(segments)-1]
+
+	if m := legacySlug.FindStringSubmatch(last); m != nil && len(segments) >= 2 {
+		r.Title = slugWords(m[1])
+		r.Artist = slugWords(segments[len(segments)-2])
+		r.Type = legacyTypes[m[2]]
+		r.query = r.Artist + " " + r.Title
+		return nil
+	}
+
+	slug := last
+	if m := tabURLID.FindStringSubmatch(slug); m != nil {
+		r.TabID = m[1]
+		r.Source = ResolvedByURL
+		slug = strings.TrimSuffix(slug, m[0])
+	} else if numericID.MatchString(slug) && len(segments) >= 2 && segments[len(segments)-2] == "tab" {
+		// Short /tab/<id> links
+		r.TabID = slug
+		r.Source = ResolvedByURL
+		slug = ""
+	}
+
+	if slug != "" {
+		if m := slugTypeSuffix.FindStringSubmatch(slug); m != nil {
+			r.Type = NormalizeTabType(m[1])
+			slug = strings.TrimSuffix(slug, m[0])
+		}
+		r.Title = slugWords(slug)
+		if len(segments) >= 2 && segments[len(segments)-2] != "tab" {
+			r.Artist = slugWords(segments[len(segments)-2])
+		}
+	}
+
+	if r.TabID != "" {
+		r.URL = CanonicalTabURL(parsed.String())
+		return nil
+	}
+	if r.Title == "" {
+		return ErrNotUGReference
+	}
+	r.query = strings.TrimSpace(r.Artist + " " + r.Title)
+	return nil
+}
+
+// slugWords turns a URL slug into words, e.g. "guns-n-roses" -> "Guns N Roses"
+func slugWords(slug string) string {
+	if unescaped, err := url.PathUnescape(slug); err == nil {
+		slug = unescaped
+	}
+	words := strings.FieldsFunc(slug, func(r rune) bool { return r == '-' || r == '_' || r == '+' })
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// isShortener reports whether host is a known link shortener
+func isShortener(host string) bool {
+	for _, s := range shortenerHosts {
+		if host == s || strings.HasSuffix(host, "."+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver resolves UG references to tabs, following shortened links,
+// searching for references without an ID and checking the result against
+// the tab itself
+type Resolver struct {
+	ugClient      *UGClient
+	searchScraper *SearchScraper
+	httpClient    *http.Client
+}
+
+// NewResolver creates a new tab reference resolver
+func NewResolver(ugClient *UGClient, searchScraper *SearchScraper) *Resolver {
+	return &Resolver{
+		ugClient:      ugClient,
+		searchScraper: searchScraper,
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Resolve turns any UG reference into a tab ID. With verify the tab is
+// fetched so artist, title and type are the real ones rather than slug
+// guesses; a failed check is reported as a warning, not an error.
+func (r *Resolver) Resolve(ctx context.Context, input string, verify bool) (*Resolution, error) {
+	res, err := ParseTabRef(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.expand != "" {
+		target, err := r.expandLink(ctx, res.expand)
+		if err != nil {
+			return nil, err
+		}
+		expanded := &Resolution{Input: input}
+		if err := expanded.parse(target, 0); err != nil {
+			return nil, err
+		}
+		res = expanded
+		if res.TabID != "" {
+			res.Source = ResolvedByRedirect
+		}
+	}
+
+	if res.TabID == "" {
+		if err := r.search(ctx, res); err != nil {
+			return nil, err
+		}
+	}
+
+	if verify {
+		r.verify(ctx, res)
+	}
+	return res, nil
+}
+
+// expandLink follows a shortened link's redirects until they reach UG,
+// without loading the UG page itself
+func (r *Resolver) expandLink(ctx context.Context, link string) (string, error) {
+	var target string
+	client := *r.httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		host := strings.ToLower(req.URL.Hostname())
+		switch {
+		case IsUGHost(host):
+			target = req.URL.String()
+			return http.ErrUseLastResponse
+		case isShortener(host):
+			return nil
+		}
+		return fmt.Errorf("%w: link leads to %s", ErrNotUGReference, host)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return "", fmt.Errorf("expanding %s: %w", link, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("expanding %s: %w", link, err)
+	}
+	resp.Body.Close()
+
+	if target == "" {
+		return "", fmt.Errorf("%w: %s does not redirect to Ultimate Guitar", ErrNotUGReference, link)
+	}
+	fmt.Printf("   ↪ Expanded %s to %s\n", link, target)
+	return target, nil
+}
+
+// search finds the tab for a reference without an ID, preferring a result
+// whose title and artist match the reference
+func (r *Resolver) search(ctx context.Context, res *Resolution) error {
+	if res.query == "" {
+		return ErrNotUGReference
+	}
+
+	results, err := r.searchScraper.SearchTabs(ctx, SearchOptions{Query: res.query, Type: res.Type})
+	if err != nil {
+		return fmt.Errorf("searching for %q: %w", res.query, err)
+	}
+
+	var best *SearchResult
+	for i := range results {
+		if results[i].ID == "" {
+			continue
+		}
+		if best == nil {
+			best = &results[i]
+		}
+		if strings.EqualFold(results[i].Title, res.Title) && (res.Artist == "" || strings.EqualFold(results[i].Artist, res.Artist)) {
+			best = &results[i]
+			break
+		}
+	}
+	if best == nil {
+		return fmt.Errorf("%w for %q", ErrNoTabFound, res.query)
+	}
+
+	res.TabID = best.ID
+	res.Title = best.Title
+	res.Artist = best.Artist
+	res.Type = best.Type
+	res.URL = CanonicalTabURL(best.URL)
+	res.Locale = best.Locale
+	res.Source = ResolvedBySearch
+	return nil
+}
+
+// verify replaces the guessed details with the tab's own
+func (r *Resolver) verify(ctx context.Context, res *Resolution) {
+	tab, err := r.ugClient.GetTabByID(ctx, res.TabID)
+	if err != nil {
+		res.Warning = fmt.Sprintf("could not fetch tab %s to verify it: %v", res.TabID, err)
+		return
+	}
+
+	res.Artist = tab.ArtistName
+	res.Title = tab.SongName
+	if tab.Type != "" {
+		res.Type = tab.Type
+	}
+	if tab.URLWeb != "" {
+		res.URL = CanonicalTabURL(tab.URLWeb)
+	}
+	if tab.Locale != "" {
+		res.Locale = tab.Locale
+	}
+	res.Verified = true
+}
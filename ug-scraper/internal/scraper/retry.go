@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	defaultMaxRetries      = 3
+	defaultRetryInitial    = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 8 * time.Second
+	// retryMaxElapsed bounds the time spent on one request across all attempts
+	retryMaxElapsed = 90 * time.Second
+)
+
+// RetryConfig controls how UG API requests are retried after transient
+// failures: timeouts, connection resets and 5xx responses
+type RetryConfig struct {
+	MaxRetries      uint64        // Retries after the first attempt; 0 disables retrying
+	InitialInterval time.Duration // Wait before the first retry, doubled for each next one
+	MaxInterval     time.Duration // Upper bound for a single wait
+}
+
+// RetryConfigFromEnv reads UG_RETRY_MAX, UG_RETRY_INITIAL and
+// UG_RETRY_MAX_INTERVAL (Go durations)
+func RetryConfigFromEnv() RetryConfig {
+	cfg := RetryConfig{
+		MaxRetries:      defaultMaxRetries,
+		InitialInterval: defaultRetryInitial,
+		MaxInterval:     defaultRetryMaxBackoff,
+	}
+
+	if v := os.Getenv("UG_RETRY_MAX"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 8); err == nil {
+			cfg.MaxRetries = n
+		}
+	}
+	if d, err := time.ParseDuration(os.Getenv("UG_RETRY_INITIAL")); err == nil && d > 0 {
+		cfg.InitialInterval = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("UG_RETRY_MAX_INTERVAL")); err == nil && d > 0 {
+		cfg.MaxInterval = d
+	}
+	if cfg.MaxInterval < cfg.InitialInterval {
+		cfg.MaxInterval = cfg.InitialInterval
+	}
+
+	return cfg
+}
+
+// statusError is a non-200 response from UG
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("API returned status %d", e.code)
+	}
+	return fmt.Sprintf("API returned status %d: %s", e.code, e.body)
+}
+
+// withRetry runs op until it succeeds, fails permanently, the retries are
+// used up or ctx is cancelled, waiting with exponential backoff and jitter
+// between attempts
+func (c *UGClient) withRetry(ctx context.Context, name string, op func() error) error {
+	if c.retry.MaxRetries == 0 {
+		return op()
+	}
+
+	policy := backoff.NewExponentialBackOff()
+	policy.InitialInterval = c.retry.InitialInterval
+	policy.MaxInterval = c.retry.MaxInterval
+	policy.MaxElapsedTime = retryMaxElapsed
+	policy.RandomizationFactor = 0.5
+
+	attempt := 0
+	return backoff.RetryNotify(func() error {
+		attempt++
+		err := op()
+		if err != nil && (ctx.Err() != nil || !isTransient(err)) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithContext(backoff.WithMaxRetries(policy, c.retry.MaxRetries), ctx), func(err error, wait time.Duration) {
+		fmt.Printf("🔁 %s attempt %d failed, retrying in %s: %v\n", name, attempt, wait.Round(time.Millisecond), err)
+	})
+}
+
+// isTransient reports whether a failed request may succeed when repeated
+func isTransient(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// IsPermanent reports whether a failed tab lookup will fail the same way
+// however often it is repeated: the input isn't a UG reference, nothing was
+// found for it or UG rejected the request as such (a 4xx other than 403,
+// 408 and 429, which is how blocks and rate limits show up)
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrNotUGReference) || errors.Is(err, ErrNoTabFound) {
+		return true
+	}
+
+	var status *statusError
+	if errors.As(err, &status) && status.code >= 400 && status.code < 500 {
+		switch status.code {
+		case 403, 408, 429:
+			return false
+		}
+		return true
+	}
+	return false
+}
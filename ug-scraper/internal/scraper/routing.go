@@ -0,0 +1,388 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// routingWindow is how many recent fetches a solver's success rate and
+	// latency cover
+	routingWindow = 20
+	// routingMinSamples is how many fetches a solver needs before its
+	// numbers move it in the order
+	routingMinSamples = 3
+	// breakerThreshold is how many fetches in a row may fail before a
+	// solver's breaker opens
+	breakerThreshold = 3
+	// breakerCooldown is how long an open breaker first skips its solver,
+	// doubled every time a probe fails up to breakerMaxCooldown
+	breakerCooldown    = 30 * time.Second
+	breakerMaxCooldown = 10 * time.Minute
+)
+
+// Solver routing modes
+const (
+	RoutingAdaptive = "adaptive" // Order solvers by their recent results and skip failing ones
+	RoutingFixed    = "fixed"    // Always try solvers in SOLVERS order
+)
+
+// Breaker states
+const (
+	BreakerClosed   = "closed"    // The solver is used normally
+	BreakerOpen     = "open"      // The solver failed repeatedly and is skipped
+	BreakerHalfOpen = "half_open" // One probe is let through to see if it recovered
+)
+
+// RoutingFromEnv reads SOLVER_ROUTING: adaptive (default) or fixed
+func RoutingFromEnv() string {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("SOLVER_ROUTING"))); mode {
+	case "", RoutingAdaptive:
+		return RoutingAdaptive
+	case RoutingFixed:
+		return RoutingFixed
+	default:
+		fmt.Printf("⚠️  Unknown SOLVER_ROUTING %q, using %s\n", mode, RoutingAdaptive)
+		return RoutingAdaptive
+	}
+}
+
+// fetchOutcome is one fetch made through a solver
+type fetchOutcome struct {
+	ok      bool
+	latency time.Duration
+}
+
+// solverRoute tracks how a solver has been doing. Guarded by router.mu.
+type solverRoute struct {
+	solver   Solver
+	position int // Place in SOLVERS
+	outcomes []fetchOutcome
+	failures int // Failed fetches in a row
+	state    string
+	openedAt time.Time
+	cooldown time.Duration
+	probing  bool // A half-open probe is in flight
+	lastErr  string
+}
+
+// successRate returns the share of recent fetches that worked, and their
+// average duration
+func (r *solverRoute) successRate() (float64, time.Duration) {
+	if len(r.outcomes) == 0 {
+		return 1, 0
+	}
+	ok := 0
+	var total time.Duration
+	for _, o := range r.outcomes {
+		if o.ok {
+			ok++
+		}
+		total += o.latency
+	}
+	return float64(ok) / float64(len(r.outcomes)), total / time.Duration(len(r.outcomes))
+}
+
+// cost is the time a fetch through the solver is expected to take per page
+// it delivers: trying solvers by increasing cost finishes soonest on
+// average. Solvers without enough fetches yet have no cost.
+func (r *solverRoute) cost() (float64, bool) {
+	if len(r.outcomes) < routingMinSamples {
+		return 0, false
+	}
+	rate, latency := r.successRate()
+	if rate == 0 {
+		return math.Inf(1), true
+	}
+	return latency.Seconds() / rate, true
+}
+
+// router decides which solvers a page fetch tries, in which order
+type router struct {
+	mode   string
+	mu     sync.Mutex
+	routes []*solverRoute
+}
+
+// newRouter creates a router for solvers in SOLVERS order
+func newRouter(mode string, solvers []Solver) *router {
+	r := &router{mode: mode}
+	for i, solver := range solvers {
+		r.routes = append(r.routes, &solverRoute{
+			solver:   solver,
+			position: i,
+			state:    BreakerClosed,
+			cooldown: breakerCooldown,
+		})
+	}
+	return r
+}
+
+// plan orders the solvers for one fetch and decides which to skip. In
+// adaptive mode solvers with enough fetches are ordered by cost; the others
+// keep their SOLVERS place ahead of them, so new and rarely used solvers
+// still get tried. Open breakers are skipped until their cooldown is over,
+// then a single request probes the solver while the others skip it. The
+// decisions line up with the returned routes.
+func (r *router) plan(now time.Time) ([]*solverRoute, []RoutingDecision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered := append([]*solverRoute(nil), r.routes...)
+	if r.mode == RoutingAdaptive {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			ci, knownI := ordered[i].cost()
+			cj, knownJ := ordered[j].cost()
+			if knownI != knownJ {
+				return !knownI
+			}
+			if !knownI || ci == cj {
+				return ordered[i].position < ordered[j].position
+			}
+			return ci < cj
+		})
+	}
+
+	tries := 0
+	decisions := make([]RoutingDecision, 0, len(ordered))
+	for _, route := range ordered {
+		decision := route.decision()
+		switch {
+		case isKnownDown(route.solver):
+			decision.Action, decision.Reason = RouteSkip, reasonDown
+		case r.mode == RoutingFixed:
+			decision.Action = RouteTry
+		case route.state == BreakerOpen && now.Sub(route.openedAt) < route.cooldown:
+			decision.Action = RouteSkip
+			decision.Reason = fmt.Sprintf("breaker open until %s", route.openedAt.Add(route.cooldown).Format(time.TimeOnly))
+		case route.state == BreakerOpen || route.state == BreakerHalfOpen && !route.probing:
+			route.state = BreakerHalfOpen
+			route.probing = true
+			decision.State = BreakerHalfOpen
+			decision.Action, decision.Reason = RouteTry, reasonProbe
+		case route.state == BreakerHalfOpen:
+			decision.Action, decision.Reason = RouteSkip, "breaker half-open, another request is probing"
+		default:
+			decision.Action = RouteTry
+		}
+		if decision.Action == RouteTry {
+			tries++
+		}
+		decisions = append(decisions, decision)
+	}
+
+	// Skipping everything would fail the fetch for sure; try the solvers
+	// whose breakers are open anyway, in order
+	if tries == 0 {
+		for i := range decisions {
+			if decisions[i].Reason != reasonDown {
+				decisions[i].Action, decisions[i].Reason = RouteTry, "every solver is skipped, trying anyway"
+			}
+		}
+	}
+
+	return ordered, decisions
+}
+
+// release hands back probes that were planned but not made, e.g. because an
+// earlier solver fetched the page
+func (r *router) release(routes []*solverRoute) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, route := range routes {
+		route.probing = false
+	}
+}
+
+// record counts a fetch through a route, opening its breaker after
+// breakerThreshold failures in a row and closing it on a success
+func (r *router) record(route *solverRoute, latency time.Duration, err error) {
+	// A fetch abandoned by its caller says nothing about the solver
+	if errors.Is(err, context.Canceled) {
+		r.release([]*solverRoute{route})
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route.outcomes = append(route.outcomes, fetchOutcome{ok: err == nil, latency: latency})
+	if len(route.outcomes) > routingWindow {
+		route.outcomes = route.outcomes[len(route.outcomes)-routingWindow:]
+	}
+
+	wasProbe := route.probing
+	route.probing = false
+	if err == nil {
+		if route.state != BreakerClosed {
+			fmt.Printf("✅ %s works again, closing its breaker\n", route.solver.Name())
+		}
+		route.failures = 0
+		route.state = BreakerClosed
+		route.cooldown = breakerCooldown
+		return
+	}
+
+	route.failures++
+	route.lastErr = err.Error()
+	switch {
+	case r.mode == RoutingFixed:
+	case wasProbe:
+		route.cooldown = min(route.cooldown*2, breakerMaxCooldown)
+		route.state, route.openedAt = BreakerOpen, time.Now()
+		fmt.Printf("⚡ %s probe failed, skipping it for %s\n", route.solver.Name(), route.cooldown)
+	case route.state == BreakerClosed && route.failures >= breakerThreshold:
+		route.state, route.openedAt = BreakerOpen, time.Now()
+		fmt.Printf("⚡ %s failed %d times in a row, skipping it for %s\n", route.solver.Name(), route.failures, route.cooldown)
+	}
+}
+
+// decision describes a route for the trace. Caller holds r.mu.
+func (r *solverRoute) decision() RoutingDecision {
+	rate, latency := r.successRate()
+	d := RoutingDecision{
+		Solver:       r.solver.Name(),
+		State:        r.state,
+		Samples:      len(r.outcomes),
+		SuccessRate:  rate,
+		AvgLatencyMs: latency.Milliseconds(),
+	}
+	if cost, ok := r.cost(); ok && !math.IsInf(cost, 1) {
+		d.CostSeconds = &cost
+	}
+	return d
+}
+
+// stats returns every solver's routing state in SOLVERS order
+func (r *router) stats() []SolverStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]SolverStats, len(r.routes))
+	for i, route := range r.routes {
+		rate, latency := route.successRate()
+		stats[i] = SolverStats{
+			Solver:       route.solver.Name(),
+			State:        route.state,
+			Samples:      len(route.outcomes),
+			SuccessRate:  rate,
+			AvgLatencyMs: latency.Milliseconds(),
+			Failures:     route.failures,
+			LastError:    route.lastErr,
+		}
+		if route.state == BreakerOpen {
+			until := route.openedAt.Add(route.cooldown)
+			stats[i].OpenUntil = &until
+		}
+	}
+	return stats
+}
+
+// isKnownDown reports whether the solver knows its service is down
+func isKnownDown(solver Solver) bool {
+	d, ok := solver.(downDetector)
+	return ok && d.knownDown()
+}
+
+// SolverStats is a solver's recent results and breaker state
+type SolverStats struct {
+	Solver       string     `json:"solver"`
+	State        string     `json:"state"`
+	Samples      int        `json:"samples"`
+	SuccessRate  float64    `json:"success_rate"`
+	AvgLatencyMs int64      `json:"avg_latency_ms"`
+	Failures     int        `json:"consecutive_failures"`
+	OpenUntil    *time.Time `json:"open_until,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// SolverStats returns the routing state of every solver, in SOLVERS order
+func (s *SearchScraper) SolverStats() []SolverStats {
+	return s.router.stats()
+}
+
+// Routing returns the solver routing mode
+func (s *SearchScraper) Routing() string {
+	return s.router.mode
+}
+
+// Route actions
+const (
+	RouteTry  = "try"
+	RouteSkip = "skip"
+)
+
+// Decision reasons the router acts on again later
+const (
+	reasonDown  = "service is down"
+	reasonProbe = "probing after cooldown"
+)
+
+// RoutingDecision is what a page fetch decided about one solver and what
+// came of it
+type RoutingDecision struct {
+	Solver       string   `json:"solver"`
+	Action       string   `json:"action"` // try or skip
+	Reason       string   `json:"reason,omitempty"`
+	State        string   `json:"state"`
+	Samples      int      `json:"samples"`
+	SuccessRate  float64  `json:"success_rate"`
+	AvgLatencyMs int64    `json:"avg_latency_ms"`
+	CostSeconds  *float64 `json:"cost_seconds,omitempty"` // Expected seconds per delivered page
+	// Result of the fetch when the solver was tried
+	Outcome   string `json:"outcome,omitempty"` // ok, failed or not_needed
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// FetchTrace records the routing of one page fetch
+type FetchTrace struct {
+	URL       string            `json:"url"`
+	Routing   string            `json:"routing"`
+	Decisions []RoutingDecision `json:"decisions"`
+	Solver    string            `json:"solver,omitempty"` // The solver that fetched the page
+	Error     string            `json:"error,omitempty"`
+}
+
+// Trace collects the routing decisions of the page fetches made for one
+// request, for debugging
+type Trace struct {
+	mu      sync.Mutex
+	fetches []FetchTrace
+}
+
+// Fetches returns the traced page fetches in the order they were made
+func (t *Trace) Fetches() []FetchTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]FetchTrace{}, t.fetches...)
+}
+
+// add appends a finished fetch
+func (t *Trace) add(fetch FetchTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fetches = append(t.fetches, fetch)
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context whose page fetches are recorded in the
+// returned trace
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+// traceFrom returns the trace of ctx, or nil when it isn't traced
+func traceFrom(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
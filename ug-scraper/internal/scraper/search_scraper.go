@@ -1,98 +1,112 @@
 package scraper
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"regexp"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
-)
-
-const (
-	ugSearchURL    = "https://www.ultimate-guitar.com/search.php"
-	ugAppSearchURL = "https://api.ultimate-guitar.com/api/v1/search"
-	ugSuggestURL   = "https://api.ultimate-guitar.com/api/v1/suggest"
-	ugTabSearchURL = "https://api.ultimate-guitar.com/api/v1/tab-search"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/bandwidth"
 )
 
 // SearchScraper handles searching Ultimate Guitar
 type SearchScraper struct {
-	httpClient      *http.Client
-	ugClient        *UGClient
-	flareSolverrURL string
+	ugClient *UGClient
+	solvers  []Solver     // Tried in order to fetch web pages
+	flare    *flareSolver // The FlareSolverr solver when it is in the chain
+	router   *router      // Orders and skips solvers by their recent results
+	stats    requestStats
 }
 
-// NewSearchScraper creates a new search scraper with UG client authentication
+// NewSearchScraper creates a new search scraper with UG client authentication.
+// Web pages are fetched through the solvers listed in SOLVERS (see
+// solversFromEnv); direct requests use the UG_HTTP_* settings and
+// FlareSolverr-compatible services the FLARESOLVERR_HTTP_* ones (see
+// httpclient.ConfigFromEnv), in the order SOLVER_ROUTING picks (see
+// RoutingFromEnv).
 func NewSearchScraper() *SearchScraper {
-	// Check for FlareSolverr URL from environment
-	flareSolverrURL := ""
-	if url := os.Getenv("FLARESOLVERR_URL"); url != "" {
-		flareSolverrURL = url
-	}
+	ugClient := NewUGClient()
+	s := &SearchScraper{ugClient: ugClient, router: newRouter(RoutingFromEnv(), nil)}
+	return s.WithSolvers(solversFromEnv(ugClient.endpoints)...)
+}
 
-	return &SearchScraper{
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second, // Increased for FlareSolverr (42-44s response time)
-		},
-		ugClient:        NewUGClient(),
-		flareSolverrURL: flareSolverrURL,
+// WithMeter counts the scraper's downloads from the UG website, the app API
+// and FlareSolverr-compatible solvers in meter, which also enforces the daily bandwidth cap
+func (s *SearchScraper) WithMeter(meter *bandwidth.Meter) *SearchScraper {
+	for _, solver := range s.solvers {
+		if m, ok := solver.(meteredSolver); ok {
+			m.withMeter(meter)
+		}
 	}
+	s.ugClient.WithMeter(meter)
+	return s
 }
 
 // SearchOptions contains search filter options
 type SearchOptions struct {
-	Query      string
-	Type       string // chords, tabs, bass, etc.
-	Difficulty string // beginner, intermediate, advanced
+	Query          string
+	Type           TabType   // canonical type; empty for all types
+	Types          []TabType // more types accepted alongside Type
+	Difficulty     string    // canonical difficulty; empty for all
+	MinRating      float64   // 0 for any rating
+	MinVotes       int       // 0 for any number of votes
+	StandardTuning bool      // only tabs in standard tuning
+	Part           string    // e.g. "intro" or "solo", PartWhole for whole-song tabs; empty for all
+	Filter         string    // FilterTop, FilterChords or FilterNone; empty for FilterTop
+	Sort           string    // SortRelevance, SortRating, SortVotes or SortNewest; empty for SortRelevance
 }
 
 // SearchTabs searches Ultimate Guitar and returns tab results
-// Uses HTML scraping (API endpoints return 404)
-func (s *SearchScraper) SearchTabs(opts SearchOptions) ([]SearchResult, error) {
+// Uses HTML scraping (API endpoints return 404). Cancelling ctx aborts the
+// page and FlareSolverr requests.
+func (s *SearchScraper) SearchTabs(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
 	if opts.Query == "" {
 		return nil, fmt.Errorf("search query cannot be empty")
 	}
 
-	fmt.Printf("🔍 Searching for: %q (type=%s, difficulty=%s)\n", opts.Query, opts.Type, opts.Difficulty)
+	fmt.Printf("🔍 Searching for: %q (%s)\n", opts.Query, opts.describe())
 
 	// Skip API search - all endpoints return 404
 	// Go directly to HTML scraping
 	fmt.Println("🌐 Using HTML scraping (API endpoints unavailable)...")
-	results, err := s.searchViaHTML(opts)
+	results, err := s.searchViaHTML(ctx, opts)
+	s.stats.record(err)
 	if err != nil {
 		fmt.Printf("❌ HTML scraping failed: %v\n", err)
 		return nil, err
 	}
 
 	fmt.Printf("✅ HTML scraping successful: %d results\n", len(results))
-	return filterTopResults(results), nil
+	return sortResults(pickResults(filterResults(results, opts), opts.Filter), opts.Sort), nil
 }
 
 // searchViaAPI searches using Ultimate Guitar's Android app API with authentication
-func (s *SearchScraper) searchViaAPI(opts SearchOptions) ([]SearchResult, error) {
+func (s *SearchScraper) searchViaAPI(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
 	// Try multiple endpoints
 	endpoints := []string{
-		fmt.Sprintf("%s?value=%s", ugSuggestURL, url.QueryEscape(opts.Query)),
-		fmt.Sprintf("%s?query=%s", ugTabSearchURL, url.QueryEscape(opts.Query)),
-		fmt.Sprintf("%s?title=%s", ugAppSearchURL, url.QueryEscape(opts.Query)),
+		fmt.Sprintf("%s?value=%s", s.ugClient.endpoints.SuggestURL(), url.QueryEscape(opts.Query)),
+		fmt.Sprintf("%s?query=%s", s.ugClient.endpoints.TabSearchURL(), url.QueryEscape(opts.Query)),
+		fmt.Sprintf("%s?title=%s", s.ugClient.endpoints.AppSearchURL(), url.QueryEscape(opts.Query)),
 	}
 
 	fmt.Printf("   Trying %d API endpoints...\n", len(endpoints))
 	var lastErr error
 	for i, apiURL := range endpoints {
-		if opts.Type != "" {
-			apiURL += fmt.Sprintf("&type=%s", opts.Type)
+		if types := opts.types(); len(types) == 1 {
+			apiURL += fmt.Sprintf("&type=%s", types[0].Label())
+		}
+		if opts.Difficulty != "" {
+			apiURL += "&difficulty=" + url.QueryEscape(opts.Difficulty)
 		}
 
 		fmt.Printf("   [%d/%d] %s\n", i+1, len(endpoints), apiURL)
-		results, err := s.trySearchEndpoint(apiURL)
+		results, err := s.trySearchEndpoint(ctx, apiURL)
 		if err == nil && len(results) > 0 {
 			fmt.Printf("   ✓ Endpoint returned %d results\n", len(results))
 			return results, nil
@@ -105,10 +119,10 @@ func (s *SearchScraper) searchViaAPI(opts SearchOptions) ([]SearchResult, error)
 }
 
 // trySearchEndpoint attempts to search using a specific endpoint
-func (s *SearchScraper) trySearchEndpoint(apiURL string) ([]SearchResult, error) {
+func (s *SearchScraper) trySearchEndpoint(ctx context.Context, apiURL string) ([]SearchResult, error) {
 
 	// Create request
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating API request: %w", err)
 	}
@@ -154,7 +168,7 @@ func (s *SearchScraper) trySearchEndpoint(apiURL string) ([]SearchResult, error)
 }
 
 // searchViaHTML falls back to HTML scraping
-func (s *SearchScraper) searchViaHTML(opts SearchOptions) ([]SearchResult, error) {
+func (s *SearchScraper) searchViaHTML(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
 	// Build search URL with query parameters
 	searchURL, err := s.buildSearchURL(opts)
 	if err != nil {
@@ -162,42 +176,14 @@ func (s *SearchScraper) searchViaHTML(opts SearchOptions) ([]SearchResult, error
 	}
 
 	fmt.Printf("   URL: %s\n", searchURL)
-	var body []byte
-
-	// Try FlareSolverr first if configured
-	if s.flareSolverrURL != "" {
-		fmt.Printf("   Using FlareSolverr at %s\n", s.flareSolverrURL)
-		htmlContent, err := s.searchViaFlareSolverr(searchURL)
-		if err == nil {
-			fmt.Println("   ✓ FlareSolverr bypass successful")
-			body = []byte(htmlContent)
-		} else {
-			fmt.Printf("   ✗ FlareSolverr failed: %v\n", err)
-		}
-	} else {
-		fmt.Println("   FlareSolverr not configured, using direct request")
+	body, finalURL, err := s.fetchPage(ctx, searchURL)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fallback to direct request if FlareSolverr not configured or failed
-	if body == nil {
-		req, err := http.NewRequest("GET", searchURL, nil)
-		if err != nil {
-			return nil, fmt.Errorf("creating request: %w", err)
-		}
-
-		req.Header.Set("User-Agent", ugUserAgent)
-		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-
-		resp, err := s.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("making request: %w", err)
-		}
-		defer resp.Body.Close()
-
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("reading response: %w", err)
-		}
+	pageLocale := LocaleFromURL(finalURL)
+	if pageLocale != "" {
+		fmt.Printf("   Search served from localized site: %s\n", pageLocale)
 	}
 
 	// Optionally save HTML for debugging (commented out for production)
@@ -208,7 +194,7 @@ func (s *SearchScraper) searchViaHTML(opts SearchOptions) ([]SearchResult, error
 	results, err := s.parseHTMLWithRegex(string(body))
 	if err == nil && len(results) > 0 {
 		fmt.Printf("   ✓ Regex parsing found %d results\n", len(results))
-		return results, nil
+		return normalizeResults(results, pageLocale), nil
 	}
 	fmt.Printf("   ✗ Regex parsing failed: %v\n", err)
 
@@ -223,54 +209,28 @@ func (s *SearchScraper) searchViaHTML(opts SearchOptions) ([]SearchResult, error
 		return nil, fmt.Errorf("no results found")
 	}
 
-	return results, nil
+	return normalizeResults(results, pageLocale), nil
 }
 
-// searchViaFlareSolverr uses FlareSolverr to bypass Cloudflare protection
-func (s *SearchScraper) searchViaFlareSolverr(targetURL string) (string, error) {
-	requestBody := map[string]interface{}{
-		"cmd":        "request.get",
-		"url":        targetURL,
-		"maxTimeout": 60000,
-		// Wait for search results to appear (React renders them)
-		"postBody": "",
-		"cookies":  []map[string]string{},
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("marshaling request: %w", err)
-	}
-
-	resp, err := http.Post(
-		fmt.Sprintf("%s/v1", s.flareSolverrURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return "", fmt.Errorf("FlareSolverr request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		Status   string `json:"status"`
-		Message  string `json:"message"`
-		Solution struct {
-			URL      string `json:"url"`
-			Status   int    `json:"status"`
-			Response string `json:"response"`
-		} `json:"solution"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("decoding FlareSolverr response: %w", err)
-	}
+// normalizeResults rewrites localized result URLs to canonical ones and
+// records the locale each result was served in
+func normalizeResults(results []SearchResult, pageLocale string) []SearchResult {
+	for i := range results {
+		locale := LocaleFromURL(results[i].URL)
+		if locale == "" {
+			locale = pageLocale
+		}
+		results[i].Locale = locale
 
-	if result.Status != "ok" {
-		return "", fmt.Errorf("FlareSolverr returned status: %s, message: %s", result.Status, result.Message)
+		if results[i].URL != "" {
+			if results[i].ID == "" {
+				results[i].ID = TabIDFromURL(results[i].URL)
+			}
+			results[i].URL = CanonicalTabURL(results[i].URL)
+		}
 	}
 
-	return result.Solution.Response, nil
+	return results
 }
 
 // buildSearchURL constructs the search URL with parameters
@@ -279,11 +239,14 @@ func (s *SearchScraper) buildSearchURL(opts SearchOptions) (string, error) {
 	params.Set("search_type", "title")
 	params.Set("value", opts.Query)
 
-	if opts.Type != "" {
-		params.Set("type", opts.Type)
+	// UG filters on one type only; several are filtered here afterwards
+	if types := opts.types(); len(types) == 1 {
+		if code := types[0].searchCode(); code != "" {
+			params.Set("type", code)
+		}
 	}
 
-	return fmt.Sprintf("%s?%s", ugSearchURL, params.Encode()), nil
+	return fmt.Sprintf("%s?%s", s.ugClient.endpoints.SearchURL(), params.Encode()), nil
 }
 
 // parseTabResult converts a map to SearchResult
@@ -305,7 +268,7 @@ func (s *SearchScraper) parseTabResult(data map[string]interface{}) SearchResult
 	}
 
 	if tabType, ok := data["type"].(string); ok {
-		result.Type = tabType
+		result.Type = NormalizeTabType(tabType)
 	}
 
 	if rating, ok := data["rating"].(float64); ok {
@@ -317,9 +280,19 @@ func (s *SearchScraper) parseTabResult(data map[string]interface{}) SearchResult
 	}
 
 	if difficulty, ok := data["difficulty"].(string); ok {
-		result.Difficulty = difficulty
+		result.Difficulty = NormalizeDifficulty(difficulty)
+	}
+
+	if part, ok := data["part"].(string); ok {
+		result.Part = part
+	}
+
+	if tuning, ok := data["tuning"].(string); ok {
+		result.Tuning = tuning
 	}
 
+	result.Date = parseUGDate(data["date"])
+
 	if tabURL, ok := data["tab_url"].(string); ok {
 		result.URL = tabURL
 	}
@@ -347,7 +320,7 @@ func (s *SearchScraper) parseAPIResults(apiResp map[string]interface{}) []Search
 					result.Artist = artist
 				}
 				if tabType, ok := tabMap["type"].(string); ok {
-					result.Type = tabType
+					result.Type = NormalizeTabType(tabType)
 				}
 				if rating, ok := tabMap["rating"].(float64); ok {
 					result.Rating = rating
@@ -355,6 +328,16 @@ func (s *SearchScraper) parseAPIResults(apiResp map[string]interface{}) []Search
 				if votes, ok := tabMap["votes"].(float64); ok {
 					result.Votes = int(votes)
 				}
+				if difficulty, ok := tabMap["difficulty"].(string); ok {
+					result.Difficulty = NormalizeDifficulty(difficulty)
+				}
+				if part, ok := tabMap["part"].(string); ok {
+					result.Part = part
+				}
+				if tuning, ok := tabMap["tuning"].(string); ok {
+					result.Tuning = tuning
+				}
+				result.Date = parseUGDate(tabMap["date"])
 				if url, ok := tabMap["tab_url"].(string); ok {
 					result.URL = url
 				}
@@ -407,6 +390,11 @@ func (s *SearchScraper) parseHTMLWithRegex(html string) ([]SearchResult, error)
 						Type       string  `json:"type"`
 						TabURL     string  `json:"tab_url"`
 						Rating     float64 `json:"rating"`
+						Votes      int     `json:"votes"`
+						Difficulty string  `json:"difficulty"`
+						Part       string  `json:"part"`
+						Tuning     string  `json:"tuning"`
+						Date       any     `json:"date"`
 					} `json:"results"`
 				} `json:"data"`
 			} `json:"page"`
@@ -421,19 +409,22 @@ func (s *SearchScraper) parseHTMLWithRegex(html string) ([]SearchResult, error)
 	results := make([]SearchResult, 0, len(store.Store.Page.Data.Results))
 	for _, r := range store.Store.Page.Data.Results {
 		results = append(results, SearchResult{
-			ID:     fmt.Sprintf("%d", r.ID),
-			Title:  r.SongName,
-			Artist: r.ArtistName,
-			Type:   r.Type,
-			URL:    r.TabURL,
-			Rating: r.Rating,
+			ID:         fmt.Sprintf("%d", r.ID),
+			Title:      r.SongName,
+			Artist:     r.ArtistName,
+			Type:       NormalizeTabType(r.Type),
+			URL:        r.TabURL,
+			Rating:     r.Rating,
+			Votes:      r.Votes,
+			Difficulty: NormalizeDifficulty(r.Difficulty),
+			Part:       r.Part,
+			Tuning:     r.Tuning,
+			Date:       parseUGDate(r.Date),
 		})
 	}
 
-	// Filter to get top-rated Chords per artist
-	filtered := filterTopResults(results)
-
-	return filtered, nil
+	// SearchTabs picks the top results once the filters have been applied
+	return results, nil
 }
 
 // decodeHTMLEntities decodes common HTML entities
@@ -454,10 +445,30 @@ func decodeHTMLEntities(s string) string {
 	return result
 }
 
-// filterTopResults picks the top-rated Chords version per artist
+// filterByType keeps only results of the requested types. UG's type filter
+// is not applied on every search path, and takes one type only, so results
+// are checked again here.
+func filterByType(results []SearchResult, types []TabType) []SearchResult {
+	if len(types) == 0 {
+		return results
+	}
+
+	filtered := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if slices.Contains(types, r.Type) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return filtered
+}
+
+// filterTopResults picks the top-rated Chords version per artist, keeping
+// the artists in the order they first appear
 func filterTopResults(results []SearchResult) []SearchResult {
 	// Map to store top result per artist
 	topResults := make(map[string]SearchResult)
+	var artists []string
 
 	for _, r := range results {
 		artist := r.Artist
@@ -466,12 +477,13 @@ func filterTopResults(results []SearchResult) []SearchResult {
 		}
 
 		current, exists := topResults[artist]
-		isChords := strings.ToLower(r.Type) == "chords"
-		currentIsChords := strings.ToLower(current.Type) == "chords"
+		isChords := r.Type == TypeChords
+		currentIsChords := current.Type == TypeChords
 
 		if !exists {
 			// No result for this artist yet
 			topResults[artist] = r
+			artists = append(artists, artist)
 		} else if isChords && !currentIsChords {
 			// Replace non-Chords with Chords version
 			topResults[artist] = r
@@ -484,10 +496,9 @@ func filterTopResults(results []SearchResult) []SearchResult {
 		}
 	}
 
-	// Convert map to slice
-	filtered := make([]SearchResult, 0, len(topResults))
-	for _, result := range topResults {
-		filtered = append(filtered, result)
+	filtered := make([]SearchResult, 0, len(artists))
+	for _, artist := range artists {
+		filtered = append(filtered, topResults[artist])
 	}
 
 	return filtered
@@ -503,19 +514,6 @@ func (s *SearchScraper) parseReactDOM(html string) ([]SearchResult, error) {
 	var results []SearchResult
 
 	// UG URL pattern: /tab/{artist}/{song-name}-{type}-{id}
-	// Known tab types in URLs
-	tabTypeMap := map[string]string{
-		"chords":   "Chords",
-		"tabs":     "Tab",
-		"guitar":   "Guitar Pro", // guitar-pro
-		"bass":     "Bass",
-		"drums":    "Drums",
-		"ukulele":  "Ukulele",
-		"power":    "Power",
-		"video":    "Video",
-		"official": "Official",
-	}
-
 	// Look for links that match /tab/ pattern
 	doc.Find("a[href*='/tab/']").Each(func(i int, sel *goquery.Selection) {
 		href, exists := sel.Attr("href")
@@ -524,14 +522,8 @@ func (s *SearchScraper) parseReactDOM(html string) ([]SearchResult, error) {
 		}
 
 		// Extract tab ID from URL (last number after final hyphen)
-		parts := strings.Split(href, "-")
-		if len(parts) == 0 {
-			return
-		}
-		idStr := parts[len(parts)-1]
-
-		// Validate ID is numeric
-		if !regexp.MustCompile(`^\d+$`).MatchString(idStr) {
+		idStr := TabIDFromURL(href)
+		if idStr == "" {
 			return
 		}
 
@@ -558,18 +550,15 @@ func (s *SearchScraper) parseReactDOM(html string) ([]SearchResult, error) {
 
 		// Extract tab type from URL
 		// URL ends with: {song}-{type}-{id} or {song}-{type}-pro-{id}
-		tabType := ""
+		parts := strings.Split(strings.TrimRight(parsedURL.Path, "/"), "-")
+		var tabType TabType
 		if len(parts) >= 2 {
 			// Check the second-to-last part for type
 			typeCandidate := parts[len(parts)-2]
-			if mapped, ok := tabTypeMap[typeCandidate]; ok {
-				tabType = mapped
-			}
+			tabType = NormalizeTabType(typeCandidate)
 			// Handle "guitar-pro" (type is two segments before ID)
-			if typeCandidate == "pro" && len(parts) >= 3 {
-				if parts[len(parts)-3] == "guitar" {
-					tabType = "Guitar Pro"
-				}
+			if typeCandidate == "pro" && len(parts) >= 3 && parts[len(parts)-3] != "guitar" {
+				tabType = ""
 			}
 		}
 
@@ -0,0 +1,259 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/bandwidth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/httpclient"
+)
+
+// defaultSolvers is the solver chain used when SOLVERS is not set
+const defaultSolvers = "flaresolverr,direct"
+
+// Page is a web page fetched by a Solver
+type Page struct {
+	Body []byte
+	URL  string // Final URL after redirects
+}
+
+// Solver fetches UG web pages, getting past the Cloudflare challenge if it
+// can. Searches try the solvers in order until one returns a page.
+type Solver interface {
+	Name() string
+	Fetch(ctx context.Context, pageURL string) (Page, error)
+}
+
+// downDetector is implemented by solvers that know when their service is
+// down, so it can be skipped instead of waiting for it to time out
+type downDetector interface {
+	knownDown() bool
+}
+
+// meteredSolver is implemented by solvers whose downloads are counted
+type meteredSolver interface {
+	withMeter(meter *bandwidth.Meter)
+}
+
+// connectionResetter is implemented by solvers with pooled connections
+type connectionResetter interface {
+	CloseIdleConnections()
+}
+
+// solverFactories create the solvers SOLVERS can name. A factory returns
+// nil when its backend is not configured.
+var solverFactories = map[string]func(endpoints Endpoints) Solver{
+	"direct": func(endpoints Endpoints) Solver { return newDirectSolver(endpoints) },
+	"flaresolverr": func(Endpoints) Solver {
+		if f := newFlareSolverFromEnv("FlareSolverr", "FLARESOLVERR_URL"); f != nil {
+			return f
+		}
+		return nil
+	},
+	"byparr": func(Endpoints) Solver {
+		if f := newFlareSolverFromEnv("Byparr", "BYPARR_URL"); f != nil {
+			return f
+		}
+		return nil
+	},
+	"chrome": func(Endpoints) Solver {
+		if c := newChromeSolverFromEnv(); c != nil {
+			return c
+		}
+		return nil
+	},
+}
+
+// solversFromEnv builds the solver chain from SOLVERS, a comma-separated
+// list of direct, flaresolverr, byparr and chrome (default
+// "flaresolverr,direct"). Backends without a URL are left out; an empty
+// chain falls back to direct requests.
+func solversFromEnv(endpoints Endpoints) []Solver {
+	names := os.Getenv("SOLVERS")
+	if strings.TrimSpace(names) == "" {
+		names = defaultSolvers
+	}
+
+	var solvers []Solver
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		factory, ok := solverFactories[name]
+		if !ok {
+			fmt.Printf("⚠️  Unknown solver %q in SOLVERS, ignoring it\n", name)
+			continue
+		}
+		if solver := factory(endpoints); solver != nil {
+			solvers = append(solvers, solver)
+		}
+	}
+
+	if len(solvers) == 0 {
+		solvers = append(solvers, newDirectSolver(endpoints))
+	}
+	return solvers
+}
+
+// WithSolvers replaces the scraper's solver chain, e.g. to plug in a backend
+// that SOLVERS doesn't know
+func (s *SearchScraper) WithSolvers(solvers ...Solver) *SearchScraper {
+	s.solvers = solvers
+	s.router = newRouter(s.router.mode, solvers)
+	s.flare = nil
+	for _, solver := range solvers {
+		if flare, ok := solver.(*flareSolver); ok && flare.name == "FlareSolverr" {
+			s.flare = flare
+			break
+		}
+	}
+	return s
+}
+
+// Solvers returns the names of the solvers searches try, in order
+func (s *SearchScraper) Solvers() []string {
+	names := make([]string, 0, len(s.solvers))
+	for _, solver := range s.solvers {
+		names = append(names, solver.Name())
+	}
+	return names
+}
+
+// fetchPage loads a UG web page through the first solver that manages it,
+// trying them in the order the router picks. Returns the HTML and the final
+// URL after redirects. The routing is recorded when ctx carries a Trace.
+func (s *SearchScraper) fetchPage(ctx context.Context, pageURL string) ([]byte, string, error) {
+	routes, decisions := s.router.plan(time.Now())
+	fetch := FetchTrace{URL: pageURL, Routing: s.router.mode, Decisions: decisions}
+	defer func() {
+		if trace := traceFrom(ctx); trace != nil {
+			trace.add(fetch)
+		}
+	}()
+
+	var lastErr error
+	for i, route := range routes {
+		decision := &decisions[i]
+		if decision.Action == RouteSkip {
+			fmt.Printf("   Skipping %s: %s\n", route.solver.Name(), decision.Reason)
+			continue
+		}
+
+		fmt.Printf("   Fetching via %s\n", route.solver.Name())
+		start := time.Now()
+		page, err := route.solver.Fetch(ctx, pageURL)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		latency := time.Since(start)
+		s.router.record(route, latency, err)
+		decision.LatencyMs = latency.Milliseconds()
+
+		if ctx.Err() != nil {
+			decision.Outcome, decision.Error = "failed", err.Error()
+			fetch.Error = err.Error()
+			s.releaseRest(routes[i+1:], decisions[i+1:])
+			return nil, "", ctx.Err()
+		}
+		if err == nil {
+			fmt.Printf("   ✓ %s fetched the page\n", route.solver.Name())
+			decision.Outcome = "ok"
+			fetch.Solver = route.solver.Name()
+			s.releaseRest(routes[i+1:], decisions[i+1:])
+			if page.URL == "" {
+				page.URL = pageURL
+			}
+			return page.Body, page.URL, nil
+		}
+		fmt.Printf("   ✗ %s failed: %v\n", route.solver.Name(), err)
+		decision.Outcome, decision.Error = "failed", err.Error()
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no solver available")
+	}
+	fetch.Error = lastErr.Error()
+	return nil, "", lastErr
+}
+
+// releaseRest marks the solvers after the one that ended a fetch as not
+// needed, handing back the probes planned for them
+func (s *SearchScraper) releaseRest(routes []*solverRoute, decisions []RoutingDecision) {
+	var probes []*solverRoute
+	for i := range decisions {
+		if decisions[i].Action != RouteTry {
+			continue
+		}
+		decisions[i].Outcome = "not_needed"
+		if decisions[i].Reason == reasonProbe {
+			probes = append(probes, routes[i])
+		}
+	}
+	s.router.release(probes)
+}
+
+// challengeTitleRegex matches the title of Cloudflare's challenge page
+var challengeTitleRegex = regexp.MustCompile(`(?i)<title>\s*(?:just a moment|attention required|checking your browser)`)
+
+// directSolver requests pages straight from UG, which works as long as
+// Cloudflare doesn't challenge the add-on's address
+type directSolver struct {
+	client *http.Client
+}
+
+// newDirectSolver creates a direct solver using the UG_HTTP_* settings
+func newDirectSolver(endpoints Endpoints) *directSolver {
+	client := httpclient.ConfigFromEnv("UG_HTTP", httpclient.Defaults(defaultUGTimeout)).Client()
+	client.CheckRedirect = redirectPolicy(endpoints)
+	return &directSolver{client: client}
+}
+
+// Name identifies the solver in logs
+func (d *directSolver) Name() string {
+	return "direct request"
+}
+
+// Fetch requests the page without any challenge solving
+func (d *directSolver) Fetch(ctx context.Context, pageURL string) (Page, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return Page{}, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", ugUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return Page{}, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Page{}, fmt.Errorf("reading response: %w", err)
+	}
+	// The challenge page holds no results; failing here lets the next solver
+	// try and tells the router the direct path is blocked
+	if resp.Header.Get("cf-mitigated") == "challenge" || challengeTitleRegex.Match(body) {
+		return Page{}, fmt.Errorf("blocked by the Cloudflare challenge (HTTP %d)", resp.StatusCode)
+	}
+	return Page{Body: body, URL: resp.Request.URL.String()}, nil
+}
+
+func (d *directSolver) withMeter(meter *bandwidth.Meter) {
+	d.client = meter.Client(bandwidth.UpstreamUGWeb, d.client)
+}
+
+// CloseIdleConnections drops pooled connections to UG
+func (d *directSolver) CloseIdleConnections() {
+	d.client.CloseIdleConnections()
+}
@@ -0,0 +1,221 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Suggest cache tuning. Entries are served fresh for suggestFreshFor, then
+// served stale while a background refresh runs until suggestStaleFor, after
+// which they are fetched again before answering.
+const (
+	suggestFreshFor = 10 * time.Minute
+	suggestStaleFor = 24 * time.Hour
+)
+
+// Suggest fetches autocomplete suggestions for a search prefix from the app API
+func (c *UGClient) Suggest(ctx context.Context, prefix string) (_ []string, err error) {
+	defer func() { c.stats.record(err) }()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s?value=%s", c.endpoints.SuggestURL(), url.QueryEscape(prefix)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	c.configureHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	// The API answers with either a bare list or {"suggestions": [...]}
+	var suggestions []string
+	if err := json.Unmarshal(body, &suggestions); err == nil {
+		return suggestions, nil
+	}
+	var wrapped struct {
+		Suggestions []string `json:"suggestions"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return wrapped.Suggestions, nil
+}
+
+// NormalizeSuggestPrefix lowercases a prefix and collapses its whitespace, so
+// "Wonder  wall" and "wonder wall" share a cache entry
+func NormalizeSuggestPrefix(prefix string) string {
+	return strings.Join(strings.Fields(strings.ToLower(prefix)), " ")
+}
+
+// SuggestResult is a suggest answer and where it came from
+type SuggestResult struct {
+	Suggestions []string `json:"suggestions"`
+	Cached      bool     `json:"cached"`
+	Stale       bool     `json:"stale"` // Served while a refresh runs
+}
+
+// suggestEntry is a cached suggest response
+type suggestEntry struct {
+	suggestions []string
+	fetchedAt   time.Time
+	hits        int
+	lastUsed    time.Time
+}
+
+// SuggestCache keeps suggest responses in memory so typing in a search box
+// doesn't send a request upstream for every keystroke. Stale entries are
+// answered immediately and refreshed in the background; when full, the
+// least frequently used entry is evicted.
+type SuggestCache struct {
+	mu         sync.Mutex
+	entries    map[string]*suggestEntry
+	refreshing map[string]bool
+	size       int
+	fetch      func(ctx context.Context, prefix string) ([]string, error)
+}
+
+// NewSuggestCache creates a suggest cache in front of the UG client holding
+// up to size prefixes
+func NewSuggestCache(client *UGClient, size int) *SuggestCache {
+	return &SuggestCache{
+		entries:    make(map[string]*suggestEntry),
+		size:       max(size, 1),
+		refreshing: make(map[string]bool),
+		fetch:      client.Suggest,
+	}
+}
+
+// Get returns suggestions for a prefix, from the cache when possible
+func (c *SuggestCache) Get(ctx context.Context, prefix string) (*SuggestResult, error) {
+	key := NormalizeSuggestPrefix(prefix)
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		age := time.Since(entry.fetchedAt)
+		if age < suggestStaleFor {
+			entry.hits++
+			entry.lastUsed = time.Now()
+			result := &SuggestResult{
+				Suggestions: entry.suggestions,
+				Cached:      true,
+				Stale:       age >= suggestFreshFor,
+			}
+			if result.Stale && !c.refreshing[key] {
+				c.refreshing[key] = true
+				go c.refresh(key)
+			}
+			c.mu.Unlock()
+			return result, nil
+		}
+	}
+	c.mu.Unlock()
+
+	suggestions, err := c.fetch(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, suggestions)
+
+	return &SuggestResult{Suggestions: suggestions}, nil
+}
+
+// refresh re-fetches a stale entry in the background. On failure the stale
+// entry keeps being served until it expires.
+func (c *SuggestCache) refresh(key string) {
+	suggestions, err := c.fetch(context.Background(), key)
+
+	c.mu.Lock()
+	delete(c.refreshing, key)
+	c.mu.Unlock()
+
+	if err != nil {
+		fmt.Printf("⚠️  Suggest refresh failed for %q: %v\n", key, err)
+		return
+	}
+	c.store(key, suggestions)
+}
+
+// store caches suggestions, evicting the least frequently used entry (the
+// least recently used among equals) when the cache is full
+func (c *SuggestCache) store(key string, suggestions []string) {
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if entry, ok := c.entries[key]; ok {
+		entry.suggestions = suggestions
+		entry.fetchedAt = now
+		return
+	}
+
+	if len(c.entries) >= c.size {
+		var victim string
+		var least *suggestEntry
+		for k, e := range c.entries {
+			if least == nil || e.hits < least.hits || (e.hits == least.hits && e.lastUsed.Before(least.lastUsed)) {
+				victim, least = k, e
+			}
+		}
+		delete(c.entries, victim)
+	}
+
+	c.entries[key] = &suggestEntry{
+		suggestions: suggestions,
+		fetchedAt:   now,
+		lastUsed:    now,
+	}
+}
+
+// Check reports how full the cache is and how many entries are stale or
+// expired. It fails when the cache stays locked until ctx is done, e.g.
+// behind a hung refresh.
+func (c *SuggestCache) Check(ctx context.Context) (string, error) {
+	done := make(chan string, 1)
+	go func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		stale, expired := 0, 0
+		for _, entry := range c.entries {
+			switch age := time.Since(entry.fetchedAt); {
+			case age >= suggestStaleFor:
+				expired++
+			case age >= suggestFreshFor:
+				stale++
+			}
+		}
+		done <- fmt.Sprintf("%d of %d prefixes cached, %d stale, %d expired, %d refreshing",
+			len(c.entries), c.size, stale, expired, len(c.refreshing))
+	}()
+
+	select {
+	case detail := <-done:
+		return detail, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("suggest cache still locked: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	jsStoreRegex = regexp.MustCompile(`<div class="js-store"[^>]*data-content="([^"]+)"`)
+	// pageTitleRegex matches titles like "WONDERWALL CHORDS by Oasis @ Ultimate-Guitar.Com"
+	pageTitleRegex = regexp.MustCompile(`(?i)^\s*(.+?)\s+(chords|tabs?|bass(?: tabs?)?|ukulele(?: chords)?|drum(?:s| tabs?)|power(?: tabs?)?|guitar pro)\s+(?:\(ver \d+\)\s+)?by\s+(.+?)\s*@`)
+)
+
+// ParseTabPage extracts a tab from a saved Ultimate Guitar tab page. It reads
+// the embedded js-store JSON when present and falls back to the <pre> block
+// and page title of older layouts. Works offline; nothing is fetched.
+func ParseTabPage(page string) (*TabResult, error) {
+	if tab, err := parseTabStore(page); err == nil {
+		return tab, nil
+	}
+
+	return parseTabPre(page)
+}
+
+// parseTabStore reads the tab from the js-store data blob of a UG tab page
+func parseTabStore(page string) (*TabResult, error) {
+	m := jsStoreRegex.FindStringSubmatch(page)
+	if m == nil {
+		return nil, fmt.Errorf("no js-store data")
+	}
+
+	var store struct {
+		Store struct {
+			Page struct {
+				Data struct {
+					Tab struct {
+						ID           int     `json:"id"`
+						SongName     string  `json:"song_name"`
+						ArtistName   string  `json:"artist_name"`
+						Type         string  `json:"type"`
+						Rating       float64 `json:"rating"`
+						Votes        int     `json:"votes"`
+						TonalityName string  `json:"tonality_name"`
+						TabURL       string  `json:"tab_url"`
+					} `json:"tab"`
+					TabView struct {
+						WikiTab struct {
+							Content string `json:"content"`
+						} `json:"wiki_tab"`
+						Strummings  []Strumming     `json:"strummings"`
+						Applicature json.RawMessage `json:"applicature"`
+						Meta        struct {
+							Capo   json.Number `json:"capo"`
+							Tuning struct {
+								Value string `json:"value"`
+							} `json:"tuning"`
+						} `json:"meta"`
+					} `json:"tab_view"`
+				} `json:"data"`
+			} `json:"page"`
+		} `json:"store"`
+	}
+
+	if err := json.Unmarshal([]byte(html.UnescapeString(m[1])), &store); err != nil {
+		return nil, fmt.Errorf("parsing js-store JSON: %w", err)
+	}
+
+	data := store.Store.Page.Data
+	if data.TabView.WikiTab.Content == "" {
+		return nil, fmt.Errorf("page has no tab content")
+	}
+
+	tab := &TabResult{
+		TabID:        data.Tab.ID,
+		SongName:     data.Tab.SongName,
+		ArtistName:   data.Tab.ArtistName,
+		Type:         NormalizeTabType(data.Tab.Type),
+		Rating:       data.Tab.Rating,
+		Votes:        data.Tab.Votes,
+		TonalityName: data.Tab.TonalityName,
+		Tuning:       data.TabView.Meta.Tuning.Value,
+		Content:      data.TabView.WikiTab.Content,
+		URLWeb:       CanonicalTabURL(data.Tab.TabURL),
+		Locale:       LocaleFromURL(data.Tab.TabURL),
+		Strummings:   data.TabView.Strummings,
+	}
+	if applicature, err := ParseApplicature(data.TabView.Applicature); err == nil {
+		tab.Applicature = applicature
+	}
+	if capo, err := data.TabView.Meta.Capo.Int64(); err == nil {
+		tab.Capo = int(capo)
+	}
+
+	return tab, nil
+}
+
+// parseTabPre reads the tab from the <pre> block and <title> of older UG pages
+func parseTabPre(page string) (*TabResult, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("parsing HTML: %w", err)
+	}
+
+	// The tab is the longest <pre> block; smaller ones are usually chord legends
+	content := ""
+	doc.Find("pre").Each(func(_ int, sel *goquery.Selection) {
+		if text := sel.Text(); len(text) > len(content) {
+			content = text
+		}
+	})
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("no tab content found in page")
+	}
+
+	tab := &TabResult{Content: content}
+	if m := pageTitleRegex.FindStringSubmatch(doc.Find("title").First().Text()); m != nil {
+		tab.SongName = strings.Title(strings.ToLower(m[1]))
+		tab.Type = NormalizeTabType(m[2])
+		tab.ArtistName = m[3]
+	}
+
+	if href, ok := doc.Find(`link[rel="canonical"]`).Attr("href"); ok {
+		tab.URLWeb = CanonicalTabURL(href)
+		if id := TabIDFromURL(href); id != "" {
+			fmt.Sscanf(id, "%d", &tab.TabID)
+		}
+	}
+
+	return tab, nil
+}
+
+// ParseSearchPage extracts results from a saved UG search results page
+func ParseSearchPage(page string) []SearchResult {
+	s := &SearchScraper{}
+	if results, err := s.parseHTMLWithRegex(page); err == nil && len(results) > 0 {
+		return results
+	}
+	if results, err := s.parseReactDOM(page); err == nil {
+		return results
+	}
+	return nil
+}
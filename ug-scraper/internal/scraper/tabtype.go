@@ -0,0 +1,160 @@
+package scraper
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TabType is the canonical tab type used throughout the API, library and exports
+type TabType string
+
+// Canonical tab types
+const (
+	TypeChords    TabType = "chords"
+	TypeTab       TabType = "tab"
+	TypeGuitarPro TabType = "guitar_pro"
+	TypeBass      TabType = "bass"
+	TypeUkulele   TabType = "ukulele"
+	TypeDrums     TabType = "drums"
+	TypePower     TabType = "power"
+	TypeOfficial  TabType = "official"
+	TypeVideo     TabType = "video"
+)
+
+// TabTypes lists every canonical tab type
+var TabTypes = []TabType{
+	TypeChords, TypeTab, TypeGuitarPro, TypeBass, TypeUkulele,
+	TypeDrums, TypePower, TypeOfficial, TypeVideo,
+}
+
+var (
+	// tabTypeAliases maps the spellings UG (and users) use to canonical types.
+	// Keys are lowercased with separators collapsed to single spaces.
+	tabTypeAliases = map[string]TabType{
+		"chords": TypeChords,
+		"chord":  TypeChords,
+
+		"tab":         TypeTab,
+		"tabs":        TypeTab,
+		"guitar tab":  TypeTab,
+		"guitar tabs": TypeTab,
+
+		"guitar pro": TypeGuitarPro,
+		"guitar":     TypeGuitarPro,
+		"pro":        TypeGuitarPro,
+		"gp":         TypeGuitarPro,
+
+		"bass":      TypeBass,
+		"bass tab":  TypeBass,
+		"bass tabs": TypeBass,
+
+		"ukulele":        TypeUkulele,
+		"ukulele chords": TypeUkulele,
+		"uke":            TypeUkulele,
+
+		"drums":     TypeDrums,
+		"drum":      TypeDrums,
+		"drum tab":  TypeDrums,
+		"drum tabs": TypeDrums,
+
+		"power":     TypePower,
+		"power tab": TypePower,
+
+		"official": TypeOfficial,
+
+		"video":        TypeVideo,
+		"video lesson": TypeVideo,
+	}
+
+	// ugSearchTypeCodes are the numeric type filters of UG's search page
+	ugSearchTypeCodes = map[TabType]string{
+		TypeVideo:     "100",
+		TypeTab:       "200",
+		TypeChords:    "300",
+		TypeBass:      "400",
+		TypeGuitarPro: "500",
+		TypePower:     "600",
+		TypeDrums:     "700",
+		TypeUkulele:   "800",
+	}
+
+	tabTypeLabels = map[TabType]string{
+		TypeChords:    "Chords",
+		TypeTab:       "Tab",
+		TypeGuitarPro: "Guitar Pro",
+		TypeBass:      "Bass",
+		TypeUkulele:   "Ukulele",
+		TypeDrums:     "Drums",
+		TypePower:     "Power",
+		TypeOfficial:  "Official",
+		TypeVideo:     "Video",
+	}
+
+	typeSeparators = regexp.MustCompile(`[\s_\-]+`)
+)
+
+// ParseTabType normalizes any known spelling ("Chords", "Guitar Pro",
+// "guitar-pro", "guitar_pro", ...) to its canonical type
+func ParseTabType(raw string) (TabType, bool) {
+	key := strings.TrimSpace(typeSeparators.ReplaceAllString(strings.ToLower(raw), " "))
+	t, ok := tabTypeAliases[key]
+	return t, ok
+}
+
+// NormalizeTabType returns the canonical type for raw, or "" if it is unknown
+func NormalizeTabType(raw string) TabType {
+	t, _ := ParseTabType(raw)
+	return t
+}
+
+// TabTypeList returns the canonical types as a "chords|tab|..." string for help and error messages
+func TabTypeList() string {
+	names := make([]string, len(TabTypes))
+	for i, t := range TabTypes {
+		names[i] = string(t)
+	}
+	return strings.Join(names, "|")
+}
+
+// Label returns the human-readable name of the type
+func (t TabType) Label() string {
+	return tabTypeLabels[t]
+}
+
+// searchCode returns UG's numeric search filter for the type, or "" if UG has none
+func (t TabType) searchCode() string {
+	return ugSearchTypeCodes[t]
+}
+
+// UnmarshalJSON accepts any known spelling so stored data and request bodies
+// written before types were canonical still decode to the enum
+func (t *TabType) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*t = NormalizeTabType(raw)
+	return nil
+}
+
+// ParseTabTypes parses a comma-separated list of types, e.g. "chords,tab",
+// dropping duplicates. ok is false, with the offending entry, when one is
+// unknown.
+func ParseTabTypes(raw string) (types []TabType, bad string, ok bool) {
+	for _, field := range strings.Split(raw, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		t, ok := ParseTabType(field)
+		if !ok {
+			return nil, strings.TrimSpace(field), false
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types, "", true
+}
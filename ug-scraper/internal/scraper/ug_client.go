@@ -1,35 +1,63 @@
 package scraper
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/bandwidth"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/httpclient"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/localtime"
 )
 
 const (
-	ugAPIEndpoint = "https://api.ultimate-guitar.com/api/v1"
-	ugUserAgent   = "UGT_ANDROID/4.11.1 (Pixel; 8.1.0)"
-	ugTimeFormat  = "2006-01-02"
+	ugUserAgent  = "UGT_ANDROID/4.11.1 (Pixel; 8.1.0)"
+	ugTimeFormat = "2006-01-02"
+	// defaultUGTimeout bounds a UG request unless UG_HTTP_TIMEOUT says otherwise
+	defaultUGTimeout = 60 * time.Second
 )
 
 // UGClient handles communication with Ultimate Guitar API
 type UGClient struct {
 	deviceID   string
 	httpClient *http.Client
+	endpoints  Endpoints
+	retry      RetryConfig
+	stats      requestStats
 }
 
-// NewUGClient creates a new Ultimate Guitar API client with generated device ID
+// NewUGClient creates a new Ultimate Guitar API client with generated device ID.
+// Base URLs can be overridden with UG_WEB_BASE_URL and UG_API_BASE_URL,
+// retries with UG_RETRY_MAX, UG_RETRY_INITIAL and UG_RETRY_MAX_INTERVAL, and
+// timeouts and connection pooling with the UG_HTTP_* settings.
 func NewUGClient() *UGClient {
+	endpoints := EndpointsFromEnv()
+
+	httpClient := httpclient.ConfigFromEnv("UG_HTTP", httpclient.Defaults(defaultUGTimeout)).Client()
+	httpClient.CheckRedirect = redirectPolicy(endpoints)
+
 	return &UGClient{
 		deviceID:   generateDeviceID(),
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		httpClient: httpClient,
+		endpoints:  endpoints,
+		retry:      RetryConfigFromEnv(),
 	}
 }
 
+// WithMeter counts the client's downloads in meter, which also enforces
+// the daily bandwidth cap
+func (c *UGClient) WithMeter(meter *bandwidth.Meter) *UGClient {
+	c.httpClient = meter.Client(bandwidth.UpstreamUGAPI, c.httpClient)
+	return c
+}
+
 // generateDeviceID creates a 16-byte random hex device ID
 func generateDeviceID() string {
 	raw := make([]byte, 16)
@@ -66,30 +94,41 @@ func (c *UGClient) configureHeaders(req *http.Request) {
 }
 
 // GetTabByID fetches tab information from Ultimate Guitar API
-func (c *UGClient) GetTabByID(tabID string) (*TabResult, error) {
-	url := fmt.Sprintf("%s/tab/info?tab_id=%s&tab_access_type=private", ugAPIEndpoint, tabID)
+func (c *UGClient) GetTabByID(ctx context.Context, tabID string) (_ *TabResult, err error) {
+	defer func() { c.stats.record(err) }()
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
+	url := c.endpoints.TabInfoURL(tabID)
 
-	c.configureHeaders(req)
+	var apiResp UGAPIResponse
+	var finalURL string
+	err = c.withRetry(ctx, "tab "+tabID, func() error {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("creating request: %w", err))
+		}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("making request: %w", err)
-	}
-	defer resp.Body.Close()
+		c.configureHeaders(req)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("making request: %w", err)
+		}
+		defer resp.Body.Close()
 
-	var apiResp UGAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return &statusError{code: resp.StatusCode, body: string(body)}
+		}
+
+		apiResp = UGAPIResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+		finalURL = resp.Request.URL.String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert API response to TabResult
@@ -97,7 +136,7 @@ func (c *UGClient) GetTabByID(tabID string) (*TabResult, error) {
 		TabID:        apiResp.TabID,
 		SongName:     apiResp.SongName,
 		ArtistName:   apiResp.ArtistName,
-		Type:         apiResp.Type,
+		Type:         NormalizeTabType(apiResp.Type),
 		Part:         apiResp.Part,
 		Version:      apiResp.Version,
 		Votes:        apiResp.Votes,
@@ -109,13 +148,27 @@ func (c *UGClient) GetTabByID(tabID string) (*TabResult, error) {
 		Tuning:       apiResp.Tuning,
 		Difficulty:   apiResp.Difficulty,
 		Content:      apiResp.Content,
-		URLWeb:       apiResp.URLWeb,
+		URLWeb:       CanonicalTabURL(apiResp.URLWeb),
+		Locale:       LocaleFromURL(apiResp.URLWeb),
+		Strummings:   apiResp.Strummings,
 		Contributor:  apiResp.Contributor,
 	}
 
+	// Voicings are a nice-to-have; a layout change shouldn't fail the tab
+	if applicature, err := ParseApplicature(apiResp.Applicature); err == nil {
+		tabResult.Applicature = applicature
+	} else {
+		fmt.Printf("⚠️  Ignoring applicature of tab %d: %v\n", apiResp.TabID, err)
+	}
+
+	// The API may have redirected us to a localized host
+	if tabResult.Locale == "" {
+		tabResult.Locale = LocaleFromURL(finalURL)
+	}
+
 	// Parse date if present
 	if apiResp.Date != "" {
-		if parsedDate, err := time.Parse("2006-01-02", apiResp.Date); err == nil {
+		if parsedDate, err := localtime.ParseDate(apiResp.Date); err == nil {
 			tabResult.Date = parsedDate
 		}
 	}
@@ -123,6 +176,70 @@ func (c *UGClient) GetTabByID(tabID string) (*TabResult, error) {
 	return tabResult, nil
 }
 
+// TabFile is a downloaded binary tab (e.g. a Guitar Pro file)
+type TabFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// maxTabFileSize guards against unexpectedly large downloads
+const maxTabFileSize = 10 << 20
+
+// DownloadTabFile downloads the binary file behind a Guitar Pro tab
+func (c *UGClient) DownloadTabFile(ctx context.Context, tabID string) (_ *TabFile, err error) {
+	defer func() { c.stats.record(err) }()
+
+	file := &TabFile{Filename: fmt.Sprintf("tab-%s.gp", tabID)}
+	err = c.withRetry(ctx, "download of tab "+tabID, func() error {
+		req, err := http.NewRequestWithContext(ctx, "GET", c.endpoints.TabDownloadURL(tabID), nil)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("creating request: %w", err))
+		}
+
+		c.configureHeaders(req)
+		req.Header.Set("Accept", "*/*")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("making request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return &statusError{code: resp.StatusCode}
+		}
+
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxTabFileSize+1))
+		if err != nil {
+			return fmt.Errorf("reading download: %w", err)
+		}
+		if len(data) > maxTabFileSize {
+			return backoff.Permanent(fmt.Errorf("download exceeds %d bytes", maxTabFileSize))
+		}
+
+		file.ContentType = resp.Header.Get("Content-Type")
+		file.Data = data
+		if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+			file.Filename = params["filename"]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if file.ContentType == "" {
+		file.ContentType = "application/octet-stream"
+	}
+
+	return file, nil
+}
+
+// Endpoints returns the base URLs the client talks to
+func (c *UGClient) Endpoints() Endpoints {
+	return c.endpoints
+}
+
 // GetDeviceID returns the current device ID (useful for debugging)
 func (c *UGClient) GetDeviceID() string {
 	return c.deviceID
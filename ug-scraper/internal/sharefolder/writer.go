@@ -0,0 +1,186 @@
+package sharefolder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+)
+
+// Existing-file policies
+const (
+	PolicyOverwrite = "overwrite"
+	PolicySkip      = "skip"
+)
+
+const (
+	defaultTemplate  = "{artist} - {title}"
+	defaultExtension = ".onsong"
+)
+
+// Config holds the share folder export settings
+type Config struct {
+	Dir       string // e.g. /share/onsong; empty disables the export
+	Template  string // File name template, may contain "/" for subfolders
+	Policy    string // overwrite or skip
+	Extension string
+}
+
+// ConfigFromEnv reads SHARE_EXPORT_DIR, SHARE_FILENAME_TEMPLATE and
+// SHARE_EXISTING_FILES
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Dir:       os.Getenv("SHARE_EXPORT_DIR"),
+		Template:  os.Getenv("SHARE_FILENAME_TEMPLATE"),
+		Policy:    strings.ToLower(os.Getenv("SHARE_EXISTING_FILES")),
+		Extension: defaultExtension,
+	}
+
+	if cfg.Template == "" {
+		cfg.Template = defaultTemplate
+	}
+	if cfg.Policy != PolicySkip {
+		cfg.Policy = PolicyOverwrite
+	}
+
+	return cfg
+}
+
+// Entry is a converted song to save
+type Entry struct {
+	TabID   int
+	Title   string
+	Artist  string
+	Key     string
+	Type    string
+	Content string
+}
+
+// Writer saves every converted song as a file in a shared directory so other
+// add-ons and Samba shares can pick them up
+type Writer struct {
+	config Config
+}
+
+// NewWriter creates a new share folder writer
+func NewWriter(cfg Config) *Writer {
+	return &Writer{
+		config: cfg,
+	}
+}
+
+// Enabled reports whether a share directory is configured
+func (w *Writer) Enabled() bool {
+	return w != nil && w.config.Dir != ""
+}
+
+// Write saves the entry and returns the file path. Existing files are left
+// alone under the skip policy; written is false then.
+func (w *Writer) Write(entry Entry) (path string, written bool, err error) {
+	if !w.Enabled() {
+		return "", false, nil
+	}
+
+	path, err = w.Path(entry)
+	if err != nil {
+		return "", false, err
+	}
+
+	if w.config.Policy == PolicySkip {
+		if _, err := os.Stat(path); err == nil {
+			return path, false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", false, fmt.Errorf("creating share directory: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial chart
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(entry.Content), 0644); err != nil {
+		return "", false, fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", false, fmt.Errorf("replacing %s: %w", path, err)
+	}
+
+	return path, true, nil
+}
+
+// Save writes the entry and logs the outcome; failures never interrupt the
+// conversion that triggered them
+func (w *Writer) Save(entry Entry) {
+	if !w.Enabled() {
+		return
+	}
+
+	path, written, err := w.Write(entry)
+	switch {
+	case err != nil:
+		fmt.Printf("⚠️  Share folder export failed: %v\n", err)
+	case written:
+		fmt.Printf("📁 Saved to share folder: %s\n", path)
+	default:
+		fmt.Printf("📁 Share folder already has %s, skipping\n", path)
+	}
+}
+
+// Path renders the file name template for an entry. Placeholders are
+// {artist}, {title}, {key}, {type} and {id}; each path segment is sanitized.
+func (w *Writer) Path(entry Entry) (string, error) {
+	replacer := strings.NewReplacer(
+		"{artist}", entry.Artist,
+		"{title}", entry.Title,
+		"{key}", entry.Key,
+		"{type}", entry.Type,
+		"{id}", strconv.Itoa(entry.TabID),
+	)
+
+	var segments []string
+	for _, segment := range strings.Split(w.config.Template, "/") {
+		segment = strings.TrimSpace(replacer.Replace(segment))
+		if segment == "" {
+			continue
+		}
+		segments = append(segments, export.SanitizeFilename(segment))
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("filename template %q produced an empty name", w.config.Template)
+	}
+
+	segments[len(segments)-1] += w.config.Extension
+	return filepath.Join(append([]string{w.config.Dir}, segments...)...), nil
+}
+
+// Health checks that the share directory exists and accepts files
+func (w *Writer) Health() (float64, string) {
+	info, err := os.Stat(w.config.Dir)
+	if err != nil {
+		return 0, fmt.Sprintf("share directory unavailable: %v", err)
+	}
+	if !info.IsDir() {
+		return 0, w.config.Dir + " is not a directory"
+	}
+
+	probe, err := os.CreateTemp(w.config.Dir, ".health-*")
+	if err != nil {
+		return 0, fmt.Sprintf("share directory not writable: %v", err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return 1, "writing to " + w.config.Dir
+}
+
+// Recreate creates a missing share directory, e.g. after the share was
+// remounted or the folder deleted
+func (w *Writer) Recreate() (string, error) {
+	if err := os.MkdirAll(w.config.Dir, 0755); err != nil {
+		return "", fmt.Errorf("creating share directory: %w", err)
+	}
+	return "recreated " + w.config.Dir, nil
+}
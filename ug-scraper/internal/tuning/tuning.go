@@ -0,0 +1,166 @@
+// Package tuning sizes worker pools, caches and rendering concurrency for
+// the hardware the add-on runs on, so the same image behaves on a Raspberry
+// Pi 3 and on a NUC without manual tuning.
+package tuning
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Hardware classes
+const (
+	ClassSmall  = "small"  // Under 1.5 GB of memory or a single CPU, e.g. a Raspberry Pi 3
+	ClassMedium = "medium" // Under 4 GB or two CPUs, e.g. a Raspberry Pi 4
+	ClassLarge  = "large"
+)
+
+// Settings are the performance settings in effect
+type Settings struct {
+	CPUs             int    `json:"cpus"`
+	MemoryMB         int    `json:"memory_mb"` // 0 when unknown
+	Class            string `json:"class"`
+	ImportWorkers    int    `json:"import_workers"`     // Bulk import jobs run at the same time
+	SuggestCacheSize int    `json:"suggest_cache_size"` // Search prefixes kept for autocomplete
+	PDFConcurrency   int    `json:"pdf_concurrency"`    // PDFs and scans rendered at the same time
+}
+
+// Detect picks conservative defaults for the CPUs and memory available to
+// the container
+func Detect() Settings {
+	s := Settings{CPUs: cpuCount(), MemoryMB: memoryMB()}
+
+	switch {
+	case s.CPUs <= 1 || (s.MemoryMB > 0 && s.MemoryMB < 1536):
+		s.Class = ClassSmall
+		s.ImportWorkers, s.SuggestCacheSize, s.PDFConcurrency = 1, 200, 1
+	case s.CPUs <= 2 || (s.MemoryMB > 0 && s.MemoryMB < 4096):
+		s.Class = ClassMedium
+		s.ImportWorkers, s.SuggestCacheSize, s.PDFConcurrency = 2, 500, 2
+	default:
+		s.Class = ClassLarge
+		s.ImportWorkers, s.SuggestCacheSize, s.PDFConcurrency = 3, 2000, min(s.CPUs/2, 4)
+	}
+
+	return s
+}
+
+// FromEnv detects the defaults and applies IMPORT_WORKERS,
+// SUGGEST_CACHE_SIZE and PDF_CONCURRENCY; unset or 0 keeps the detected value
+func FromEnv() Settings {
+	s := Detect()
+	s.ImportWorkers = envInt("IMPORT_WORKERS", s.ImportWorkers, 16)
+	s.SuggestCacheSize = envInt("SUGGEST_CACHE_SIZE", s.SuggestCacheSize, 100000)
+	s.PDFConcurrency = envInt("PDF_CONCURRENCY", s.PDFConcurrency, 16)
+	return s
+}
+
+// String describes the settings for the startup log
+func (s Settings) String() string {
+	memory := "unknown memory"
+	if s.MemoryMB > 0 {
+		memory = fmt.Sprintf("%d MB", s.MemoryMB)
+	}
+	return fmt.Sprintf("%d CPUs, %s (%s): %d import workers, suggest cache %d, %d concurrent PDF renders",
+		s.CPUs, memory, s.Class, s.ImportWorkers, s.SuggestCacheSize, s.PDFConcurrency)
+}
+
+// envInt reads a positive setting capped at limit
+func envInt(name string, fallback, limit int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(name)))
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return min(n, limit)
+}
+
+// cpuCount returns the CPUs usable by the process, honouring a cgroup v2
+// CPU quota
+func cpuCount() int {
+	cpus := runtime.NumCPU()
+
+	data, err := os.ReadFile("/sys/fs/cgroup/cpu.max")
+	if err != nil {
+		return cpus
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] == "max" {
+		return cpus
+	}
+	quota, err1 := strconv.Atoi(fields[0])
+	period, err2 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil || quota <= 0 || period <= 0 {
+		return cpus
+	}
+	return max(1, min(cpus, (quota+period-1)/period))
+}
+
+// memoryMB returns the memory available to the container: the cgroup limit
+// when there is one, otherwise the machine's total
+func memoryMB() int {
+	total := meminfoTotal()
+
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil || limit <= 0 {
+			continue // "max": no limit
+		}
+		// cgroup v1 reports an unset limit as a huge number
+		if mb := int(limit >> 20); total == 0 || mb < total {
+			return mb
+		}
+	}
+
+	return total
+}
+
+// meminfoTotal reads MemTotal from /proc/meminfo in MB, 0 when unavailable
+func meminfoTotal() int {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, _ := strconv.Atoi(fields[1])
+			return kb >> 10
+		}
+	}
+	return 0
+}
+
+// Limiter bounds how many expensive operations run at the same time
+type Limiter chan struct{}
+
+// NewLimiter creates a limiter allowing n operations at once
+func NewLimiter(n int) Limiter {
+	return make(Limiter, max(n, 1))
+}
+
+// Acquire waits for a free slot, giving up when ctx is done
+func (l Limiter) Acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (l Limiter) Release() {
+	<-l
+}
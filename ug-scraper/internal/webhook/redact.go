@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RedactableFields are the payload fields a target can leave out. The
+// schema version always stays so receivers can read the rest.
+var RedactableFields = []string{
+	"title", "artist", "key", "capo", "onsong_format", "timestamp", "source",
+	"chords", "sections", "ast", "formats",
+}
+
+// Redaction trims what a target receives, such as a public channel that
+// may only see metadata and not full lyrics
+type Redaction struct {
+	Fields   []string // Payload fields left out
+	MaxLines int      // Chart lines kept after the header, 0 for all
+}
+
+// ValidateRedaction checks that the redacted fields exist and the line
+// limit isn't negative
+func ValidateRedaction(fields []string, maxLines int) error {
+	for _, field := range fields {
+		if !slices.Contains(RedactableFields, field) {
+			return fmt.Errorf("unknown redacted field %q (one of %s)", field, strings.Join(RedactableFields, ", "))
+		}
+	}
+	if maxLines < 0 {
+		return fmt.Errorf("max_lines must not be negative")
+	}
+	return nil
+}
+
+// truncate keeps an OnSong chart's header block and its first maxLines
+// non-blank lines. Everything built from the chart (sections, AST and
+// formats) is built from what is left.
+func truncate(content string, maxLines int) string {
+	if maxLines <= 0 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		i++
+	}
+
+	kept := 0
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if kept == maxLines {
+			return strings.TrimRight(strings.Join(lines[:i], "\n"), "\n")
+		}
+		kept++
+	}
+	return content
+}
+
+// drop removes the redacted fields from a marshaled body
+func (r Redaction) drop(body interface{}) (interface{}, error) {
+	if len(r.Fields) == 0 {
+		return body, nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range r.Fields {
+		delete(fields, field)
+	}
+	return fields, nil
+}
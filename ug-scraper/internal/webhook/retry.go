@@ -7,11 +7,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/httpclient"
 )
 
+// defaultTimeout bounds one delivery attempt unless WEBHOOK_HTTP_TIMEOUT
+// says otherwise
+const defaultTimeout = 10 * time.Second
+
 // Client handles webhook delivery with retry logic
 type Client struct {
 	httpClient *http.Client
@@ -19,17 +26,26 @@ type Client struct {
 	timeout    time.Duration
 }
 
-// NewClient creates a new webhook client
+// NewClient creates a new webhook client. Timeouts and connection pooling
+// come from the WEBHOOK_HTTP_* settings (see httpclient.ConfigFromEnv).
 func NewClient() *Client {
+	cfg := httpclient.ConfigFromEnv("WEBHOOK_HTTP", httpclient.Defaults(defaultTimeout))
+
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		httpClient: cfg.Client(),
 		maxRetries: 6,
-		timeout:    10 * time.Second,
+		timeout:    cfg.Timeout,
 	}
 }
 
+// withTimeout bounds one attempt by the configured timeout, if any
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
 // DeliveryResult contains the result of a webhook delivery attempt
 type DeliveryResult struct {
 	Success    bool      `json:"success"`
@@ -40,28 +56,55 @@ type DeliveryResult struct {
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+// Target describes a webhook destination, the extra headers sent with it,
+// the payload schema it expects and what is redacted for it
+type Target struct {
+	URL           string
+	Headers       map[string]string
+	SchemaVersion int       // 0 for DefaultSchema
+	Bundle        bool      // Add the chart AST and every format to the payload
+	Redaction     Redaction // Fields left out and chart lines kept
+}
+
+// applyHeaders sets the target's custom headers on a request
+func (t Target) applyHeaders(req *http.Request) {
+	for name, value := range t.Headers {
+		req.Header.Set(name, value)
+	}
+}
+
 // WebhookPayload is the structure sent to the webhook
 type WebhookPayload struct {
-	Title        string    `json:"title"`
-	Artist       string    `json:"artist"`
-	Key          string    `json:"key"`
-	Capo         int       `json:"capo,omitempty"`
-	OnSongFormat string    `json:"onsong_format"`
-	Timestamp    time.Time `json:"timestamp"`
-	Source       string    `json:"source"`
+	SchemaVersion int       `json:"schema_version,omitempty"` // Set on delivery
+	Title         string    `json:"title"`
+	Artist        string    `json:"artist"`
+	Key           string    `json:"key"`
+	Capo          int       `json:"capo,omitempty"`
+	OnSongFormat  string    `json:"onsong_format"`
+	Timestamp     time.Time `json:"timestamp"`
+	Source        string    `json:"source"`
+
+	// With Target.Bundle, set on delivery
+	AST     *converter.Chart `json:"ast,omitempty"`
+	Formats *Formats         `json:"formats,omitempty"`
 }
 
-// SendWithRetry sends a webhook payload with exponential backoff retry
-func (c *Client) SendWithRetry(webhookURL string, payload *WebhookPayload) (*DeliveryResult, error) {
-	if webhookURL == "" {
+// SendWithRetry sends a webhook payload with exponential backoff retry.
+// Cancelling ctx stops the attempt in flight and any further retries.
+func (c *Client) SendWithRetry(ctx context.Context, target Target, payload *WebhookPayload) (*DeliveryResult, error) {
+	if target.URL == "" {
 		return nil, fmt.Errorf("webhook URL is empty")
 	}
 
 	startTime := time.Now()
 	deliveryID := generateDeliveryID()
 
-	// Serialize payload to JSON
-	jsonData, err := json.Marshal(payload)
+	// Serialize payload to JSON in the target's schema
+	body, err := payload.forTarget(target)
+	if err != nil {
+		return nil, fmt.Errorf("redacting payload: %w", err)
+	}
+	jsonData, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("marshaling payload: %w", err)
 	}
@@ -86,26 +129,31 @@ func (c *Client) SendWithRetry(webhookURL string, payload *WebhookPayload) (*Del
 		attempts++
 
 		// Create request
-		req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequest("POST", target.URL, bytes.NewBuffer(jsonData))
 		if err != nil {
 			return backoff.Permanent(fmt.Errorf("creating request: %w", err))
 		}
 
-		// Set headers
+		// Set headers (custom headers first so the fixed ones always win)
+		target.applyHeaders(req)
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("User-Agent", "UG-Scraper-Webhook/1.0")
 		req.Header.Set("X-Delivery-ID", deliveryID)
 		req.Header.Set("X-Attempt", fmt.Sprintf("%d", attempts))
+		req.Header.Set(SchemaHeader, strconv.Itoa(resolveSchema(target.SchemaVersion)))
 
 		// Create context with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		attemptCtx, cancel := c.withTimeout(ctx)
 		defer cancel()
-		req = req.WithContext(ctx)
+		req = req.WithContext(attemptCtx)
 
 		// Make request
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
 			lastErr = fmt.Errorf("attempt %d failed: %w", attempts, err)
+			if ctx.Err() != nil {
+				return backoff.Permanent(lastErr)
+			}
 			return lastErr
 		}
 		defer resp.Body.Close()
@@ -124,7 +172,7 @@ func (c *Client) SendWithRetry(webhookURL string, payload *WebhookPayload) (*Del
 	}
 
 	// Execute with retry
-	err = backoff.Retry(operation, backoffWithRetry)
+	err = backoff.Retry(operation, backoff.WithContext(backoffWithRetry, ctx))
 
 	duration := time.Since(startTime)
 
@@ -144,46 +192,82 @@ func (c *Client) SendWithRetry(webhookURL string, payload *WebhookPayload) (*Del
 	return result, nil
 }
 
+// EventPayload is posted to a webhook when an add-on event concerns it,
+// such as new tabs showing up for a watchlist entry
+type EventPayload struct {
+	Event     string      `json:"event"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+	Source    string      `json:"source"`
+}
+
 // Send makes a single webhook delivery attempt without retry
-func (c *Client) Send(webhookURL string, payload *WebhookPayload) error {
-	if webhookURL == "" {
-		return fmt.Errorf("webhook URL is empty")
+func (c *Client) Send(ctx context.Context, target Target, payload *WebhookPayload) error {
+	_, err := c.send(ctx, target, payload)
+	return err
+}
+
+// send delivers a payload in the target's schema, returning the response
+// headers
+func (c *Client) send(ctx context.Context, target Target, payload *WebhookPayload) (http.Header, error) {
+	body, err := payload.forTarget(target)
+	if err != nil {
+		return nil, fmt.Errorf("redacting payload: %w", err)
+	}
+	return c.post(ctx, target, body, resolveSchema(target.SchemaVersion))
+}
+
+// SendEvent makes a single attempt to post an event to a webhook
+func (c *Client) SendEvent(ctx context.Context, target Target, event *EventPayload) error {
+	_, err := c.post(ctx, target, event, 0)
+	return err
+}
+
+// post sends v as JSON in a single attempt, labelled with its payload
+// schema unless that is 0
+func (c *Client) post(ctx context.Context, target Target, v interface{}, schema int) (http.Header, error) {
+	if target.URL == "" {
+		return nil, fmt.Errorf("webhook URL is empty")
 	}
 
 	// Serialize payload
-	jsonData, err := json.Marshal(payload)
+	jsonData, err := json.Marshal(v)
 	if err != nil {
-		return fmt.Errorf("marshaling payload: %w", err)
+		return nil, fmt.Errorf("marshaling payload: %w", err)
 	}
 
 	// Create request
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", target.URL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
+	target.applyHeaders(req)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "UG-Scraper-Webhook/1.0")
+	if schema > 0 {
+		req.Header.Set(SchemaHeader, strconv.Itoa(schema))
+	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 	req = req.WithContext(ctx)
 
 	// Make request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Check status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
+		return resp.Header, fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	return nil
+	return resp.Header, nil
 }
 
 // generateDeliveryID creates a unique delivery ID for tracking
@@ -191,8 +275,9 @@ func generateDeliveryID() string {
 	return fmt.Sprintf("delivery_%d", time.Now().UnixNano())
 }
 
-// TestWebhook sends a test payload to verify the webhook URL
-func (c *Client) TestWebhook(webhookURL string) error {
+// TestWebhook sends a test payload to verify the webhook URL. It returns
+// the schema versions the receiver says it accepts, if it says so.
+func (c *Client) TestWebhook(ctx context.Context, target Target) ([]int, error) {
 	testPayload := &WebhookPayload{
 		Title:        "Test Song",
 		Artist:       "Test Artist",
@@ -202,5 +287,9 @@ func (c *Client) TestWebhook(webhookURL string) error {
 		Source:       "UG-Scraper Test",
 	}
 
-	return c.Send(webhookURL, testPayload)
+	header, err := c.send(ctx, target, testPayload)
+	if err != nil {
+		return nil, err
+	}
+	return parseSchemaAccept(header.Get(SchemaAcceptHeader)), nil
 }
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/converter"
+	"github.com/ultimate-guitar-scrapper/ug-scraper/internal/export"
+)
+
+// Webhook payload schema versions. Each target picks one, so receivers can
+// move to a newer schema on their own timeline.
+const (
+	SchemaV1 = 1 // Flat: title, artist, key, capo and the OnSong text
+	SchemaV2 = 2 // Adds the chart's sections, lines and chord positions
+
+	DefaultSchema = SchemaV1
+	LatestSchema  = SchemaV2
+)
+
+// SchemaHeader carries the schema version of a delivery. Receivers can
+// answer a test delivery with the versions they understand in
+// SchemaAcceptHeader, e.g. "1, 2".
+const (
+	SchemaHeader       = "X-Webhook-Schema"
+	SchemaAcceptHeader = "X-Webhook-Schema-Accept"
+)
+
+// ValidSchema reports whether version is a known payload schema; 0 means
+// the default
+func ValidSchema(version int) bool {
+	return version >= 0 && version <= LatestSchema
+}
+
+// resolveSchema turns a target's schema setting into a version
+func resolveSchema(version int) int {
+	if version <= 0 || version > LatestSchema {
+		return DefaultSchema
+	}
+	return version
+}
+
+// payloadV2 is the v2 schema: the v1 fields plus the chart structure
+type payloadV2 struct {
+	SchemaVersion int                      `json:"schema_version"`
+	Title         string                   `json:"title"`
+	Artist        string                   `json:"artist"`
+	Key           string                   `json:"key"`
+	Capo          int                      `json:"capo,omitempty"`
+	OnSongFormat  string                   `json:"onsong_format"`
+	Timestamp     time.Time                `json:"timestamp"`
+	Source        string                   `json:"source"`
+	Chords        []string                 `json:"chords"`
+	Sections      []converter.ChartSection `json:"sections"`
+	AST           *converter.Chart         `json:"ast,omitempty"`
+	Formats       *Formats                 `json:"formats,omitempty"`
+}
+
+// Formats are the song rendered in each export format, so receivers don't
+// have to call the API again for another rendering
+type Formats struct {
+	OnSong   string `json:"onsong"`
+	ChordPro string `json:"chordpro"`
+	Plain    string `json:"plain"`
+}
+
+// forTarget returns the body sent to a target: the payload in its schema,
+// with the bundle when it asked for one and its redaction applied
+func (p *WebhookPayload) forTarget(target Target) (interface{}, error) {
+	if target.Redaction.MaxLines > 0 {
+		truncated := *p
+		truncated.OnSongFormat = truncate(p.OnSongFormat, target.Redaction.MaxLines)
+		p = &truncated
+	}
+
+	var chart *converter.Chart
+	var formats *Formats
+	if target.Bundle || resolveSchema(target.SchemaVersion) == SchemaV2 {
+		chart = converter.ParseChart(p.OnSongFormat)
+	}
+	if target.Bundle {
+		formats = &Formats{
+			OnSong:   p.OnSongFormat,
+			ChordPro: export.OnSongToChordPro(p.OnSongFormat, export.ChordProOptions{Directives: export.DirectivesLong}),
+			Plain:    chart.Text(),
+		}
+	}
+
+	switch resolveSchema(target.SchemaVersion) {
+	case SchemaV2:
+		chords := chart.Chords()
+		if chords == nil {
+			chords = []string{}
+		}
+		v2 := &payloadV2{
+			SchemaVersion: SchemaV2,
+			Title:         p.Title,
+			Artist:        p.Artist,
+			Key:           p.Key,
+			Capo:          p.Capo,
+			OnSongFormat:  p.OnSongFormat,
+			Timestamp:     p.Timestamp,
+			Source:        p.Source,
+			Chords:        chords,
+			Sections:      chart.Sections,
+		}
+		if target.Bundle {
+			v2.AST, v2.Formats = chart, formats
+		}
+		return target.Redaction.drop(v2)
+	default:
+		v1 := *p
+		v1.SchemaVersion = SchemaV1
+		if target.Bundle {
+			v1.AST, v1.Formats = chart, formats
+		}
+		return target.Redaction.drop(&v1)
+	}
+}
+
+// parseSchemaAccept reads the versions listed in a SchemaAcceptHeader,
+// ignoring unknown ones
+func parseSchemaAccept(header string) []int {
+	var versions []int
+	for _, field := range strings.Split(header, ",") {
+		field = strings.TrimPrefix(strings.TrimSpace(strings.ToLower(field)), "v")
+		if version, err := strconv.Atoi(field); err == nil && version >= SchemaV1 && version <= LatestSchema {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
@@ -1,40 +1,17 @@
 #!/usr/bin/with-contenv bashio
 # Home Assistant Add-on: Ultimate Guitar Scraper
+#
+# The server reads the add-on options from /data/options.json itself and
+# validates them at startup; only what bashio knows about is passed here.
 
-# Read options from Home Assistant
-FLARESOLVERR_URL=$(bashio::config 'flaresolverr_url' '')
-WEBHOOK_URL=$(bashio::config 'webhook_url' '')
-WEBHOOK_ENABLED=$(bashio::config 'webhook_enabled' 'false')
-ONSONG_TOKEN=$(bashio::config 'onsong_token' '')
-
-# Export environment variables for the Go server
-export FLARESOLVERR_URL
-export PORT=8080
-export CONFIG_FILE=/data/webhook-config.json
-export ONSONG_TOKEN
+# Fall back to the Mosquitto add-on when no broker is set explicitly
+if [ -z "$(bashio::config 'mqtt_broker' '')" ] && bashio::services.available "mqtt"; then
+    export MQTT_BROKER="tcp://$(bashio::services mqtt 'host'):$(bashio::services mqtt 'port')"
+    export MQTT_USERNAME=$(bashio::services mqtt 'username')
+    export MQTT_PASSWORD=$(bashio::services mqtt 'password')
+    bashio::log.info "MQTT: using the Mosquitto add-on at ${MQTT_BROKER}"
+fi
 
 bashio::log.info "Starting Ultimate Guitar Scraper..."
 bashio::log.info "Port: 8080"
-
-if [ -n "$FLARESOLVERR_URL" ]; then
-    bashio::log.info "FlareSolverr: ${FLARESOLVERR_URL}"
-else
-    bashio::log.warning "FlareSolverr: Not configured (Cloudflare bypass disabled)"
-fi
-
-# Pre-configure webhook if set in HA options
-if [ -n "$WEBHOOK_URL" ]; then
-    mkdir -p /data
-    cat > /data/webhook-config.json <<EOF
-{
-  "url": "${WEBHOOK_URL}",
-  "enabled": ${WEBHOOK_ENABLED},
-  "created_at": "$(date -u +%Y-%m-%dT%H:%M:%SZ)",
-  "updated_at": "$(date -u +%Y-%m-%dT%H:%M:%SZ)"
-}
-EOF
-    bashio::log.info "Webhook configured: ${WEBHOOK_URL} (enabled=${WEBHOOK_ENABLED})"
-fi
-
-bashio::log.info "Starting server..."
 exec /server